@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// APITokenEnvVar is the environment variable of the API token for the server and the CLI.
+const APITokenEnvVar = "PCSM_API_TOKEN"
+
+// requireAPIToken rejects the requests without the bearer token in the Authorization header
+// with 401 Unauthorized. An empty token disables the authentication.
+func requireAPIToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getAPIToken returns the API token of the --token flag or the [APITokenEnvVar] variable.
+func getAPIToken(flags *pflag.FlagSet) string {
+	token, _ := flags.GetString("token")
+	if token == "" {
+		token = os.Getenv(APITokenEnvVar)
+	}
+
+	return token
+}
+
+// apiServerTLSConfig returns the TLS configuration of the HTTP API server with the certificate
+// and the key files. With the client CA file, the server requires the client certificates
+// signed by the CA (mutual TLS).
+func apiServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load certificate")
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		cfg.ClientCAs, err = loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "client CA")
+		}
+
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// apiClientTLSConfig returns the TLS configuration of the CLI requests. The CA file is used to
+// verify the server certificate instead of the system roots. The certificate and the key files
+// are the client certificate presented to the server.
+func apiClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		var err error
+
+		cfg.RootCAs, err = loadCertPool(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "CA")
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no PEM certificates in %q", file)
+	}
+
+	return pool, nil
+}
+
+// newClient returns the client of the HTTP API configured by the flags of the CLI command.
+// Any of the TLS flags enables HTTPS.
+func newClient(flags *pflag.FlagSet) (PCSMClient, error) {
+	port, err := getPort(flags)
+	if err != nil {
+		return PCSMClient{}, err
+	}
+
+	c := NewClient(port, getAPIToken(flags))
+
+	caFile, _ := flags.GetString("api-tls-ca")
+	certFile, _ := flags.GetString("api-tls-cert")
+	keyFile, _ := flags.GetString("api-tls-key")
+
+	if caFile != "" || certFile != "" || keyFile != "" {
+		c.tls, err = apiClientTLSConfig(caFile, certFile, keyFile)
+		if err != nil {
+			return PCSMClient{}, errors.Wrap(err, "api tls")
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServerHandler_APIToken(t *testing.T) {
+	t.Parallel()
+
+	handler := (&server{apiToken: "secret"}).Handler()
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer other", want: http.StatusUnauthorized},
+		{name: "not bearer", header: "Basic secret", want: http.StatusUnauthorized},
+		{name: "valid token", header: "Bearer secret", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/schema/start", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerHandler_NoAPIToken(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	(&server{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schema/start", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestPCSMClient_APIToken(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(requireAPIToken("secret", http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"ok":true}`)) //nolint:errcheck
+		})))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := fetchClientResponse[pauseResponse](t.Context(), NewClient(port, "secret"),
+		http.MethodPost, "pause", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.Ok {
+		t.Error("got ok = false, want true")
+	}
+
+	_, err = fetchClientResponse[pauseResponse](t.Context(), NewClient(port, ""),
+		http.MethodPost, "pause", nil)
+	if err == nil {
+		t.Error("got no error without the token")
+	}
+}
+
+func TestAPI_MutualTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	ca := newTestCA(t, dir, "ca")
+	ca.issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	ca.issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+
+	untrusted := newTestCA(t, dir, "untrusted-ca")
+	untrusted.issue(t, dir, "untrusted-client", x509.ExtKeyUsageClientAuth)
+
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tlsConfig, err := apiServerTLSConfig(path("server.crt"), path("server.key"), path("ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"ok":true}`)) //nolint:errcheck
+	}))
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cert    string
+		wantErr bool
+	}{
+		{name: "trusted client", cert: "client"},
+		{name: "untrusted client", cert: "untrusted-client", wantErr: true},
+		{name: "no client certificate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var certFile, keyFile string
+			if tt.cert != "" {
+				certFile, keyFile = path(tt.cert+".crt"), path(tt.cert+".key")
+			}
+
+			c := NewClient(port, "")
+
+			var err error
+
+			c.tls, err = apiClientTLSConfig(path("ca.crt"), certFile, keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := fetchClientResponse[pauseResponse](t.Context(), c, http.MethodPost, "pause", nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("got no error, want the handshake error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !resp.Ok {
+				t.Error("got ok = false, want true")
+			}
+		})
+	}
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCA writes a self-signed CA certificate to the <name>.crt file of the directory.
+func newTestCA(t *testing.T, dir, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+
+	return &testCA{cert: cert, key: key}
+}
+
+// issue writes a localhost certificate signed by the CA and its key to the <name>.crt
+// and <name>.key files of the directory.
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	t.Helper()
+
+	err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
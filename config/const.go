@@ -28,6 +28,32 @@ const (
 	HeartbeatCollection = "heartbeats"
 )
 
+// Progress snapshot settings.
+const (
+	// DefaultProgressInterval is the default interval of the progress snapshots.
+	DefaultProgressInterval = 10 * time.Second
+	// ProgressBatchSize is the number of the progress snapshots written at once.
+	ProgressBatchSize = 6
+	// ProgressRetention is the time after which a progress snapshot expires.
+	ProgressRetention = 7 * 24 * time.Hour
+)
+
+// Webhook notification settings.
+const (
+	// NotifyCheckInterval is the interval of the status checks for the webhook notifications.
+	NotifyCheckInterval = 5 * time.Second
+	// NotifyTimeout is the timeout of a webhook notification request.
+	NotifyTimeout = 10 * time.Second
+)
+
+// CLI polling settings.
+const (
+	// DefaultPollInterval is the default interval of the status requests of the waiting commands.
+	DefaultPollInterval = 5 * time.Second
+	// MinPollInterval is the minimum interval of the status requests of the waiting commands.
+	MinPollInterval = time.Second
+)
+
 // Recovery and heartbeat settings.
 const (
 	// RecoveryCheckpointingInternal is the interval for recovery checkpointing.
@@ -97,6 +123,60 @@ const (
 	// MaxCloneReadBatchSizeBytes is the maximum allowed read cursor batch size.
 	MaxCloneReadBatchSizeBytes = math.MaxInt32
 
+	// DocRetryInterval is the interval between the retries of a change event that fails to apply
+	// with a write error before it is stored in the dead-letter collection.
+	DocRetryInterval = time.Second
+
+	// ConnectionsExhaustedBackoff is the initial interval between the retries of a bulk write
+	// rejected by the target at its connection limit. The interval doubles up to
+	// [MaxConnectionsExhaustedBackoff].
+	ConnectionsExhaustedBackoff = time.Second
+	// MaxConnectionsExhaustedBackoff is the maximum interval between the retries of a bulk write
+	// rejected by the target at its connection limit.
+	MaxConnectionsExhaustedBackoff = 30 * time.Second
+	// ConnectionsExhaustedTimeout is the time after which a bulk write rejected by the target
+	// at its connection limit fails the replication.
+	ConnectionsExhaustedTimeout = 10 * time.Minute
+	// ApplyConcurrencyRestoreInterval is the time of the successful bulk writes after which
+	// the apply concurrency reduced on the exhausted target connections is doubled.
+	ApplyConcurrencyRestoreInterval = 30 * time.Second
+
+	// MaxHeldChanges is the maximum number of the held change events of the paused namespaces.
+	// Over the limit, the namespaces are resumed and the held changes are applied.
+	MaxHeldChanges = 100_000
+
+	// DegradedRetryInterval is the interval between the retries of the change replication that
+	// has failed within the failure grace period.
+	DegradedRetryInterval = 5 * time.Second
+
+	// FinalizeCatchUpTimeout is the max time for the change replication to apply the last write
+	// of the source locked by the finalization.
+	FinalizeCatchUpTimeout = time.Minute
+	// FinalizeCatchUpInterval is the interval of checking the change replication catch-up.
+	FinalizeCatchUpInterval = 100 * time.Millisecond
+
+	// BudgetRetryInterval is the interval between the attempts to stop the replication that has
+	// exceeded the max total duration while it is starting.
+	BudgetRetryInterval = time.Second
+
+	// CloneDiscoverInterval is the interval of re-listing the source collections during the clone
+	// to find the collections created after the clone has started.
+	CloneDiscoverInterval = 5 * time.Second
+
+	// CloneManifestInterval is the interval of the clone manifest file updates during the clone.
+	CloneManifestInterval = 5 * time.Second
+
+	// MaxCloneCursorResumes defines the maximum number of consecutive resumes of a killed
+	// clone read cursor without reading a document in between.
+	MaxCloneCursorResumes = 3
+
+	// VerifyBatchSize defines the number of documents of a range compared by the verification.
+	VerifyBatchSize = 1000
+
+	// DefaultEstimateSampleSize is the default number of the sampled documents per collection
+	// of the sample size estimate of the plan.
+	DefaultEstimateSampleSize = 1000
+
 	// MaxInsertBatchSize defines the maximum number of documents that can be inserted in a single
 	// batch insert operation.
 	MaxInsertBatchSize = 10_000
@@ -112,6 +192,12 @@ const (
 // db.hello().maxBsonObjectSize => 16777216.
 const MaxBSONSize = 16 * humanize.MiByte
 
+// MaxNestingDepth is the maximum nesting depth of the stored documents, including
+// the top-level document. 100 levels.
+//
+//	https://www.mongodb.com/docs/manual/reference/limits/#mongodb-limit-Nested-Depth-for-BSON-Documents
+const MaxNestingDepth = 100
+
 // MaxMessageSizeBytes is the maximum permitted size of a BSON wire protocol message.
 // The default value is 48000000 bytes.
 //
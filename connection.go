@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// errConnectionFailed indicates that the test-connection command has failed to connect to a cluster.
+var errConnectionFailed = errors.New("connection failed")
+
+// connectionEndpoint is a cluster tested by the test-connection command.
+type connectionEndpoint struct {
+	name string
+	uri  string
+}
+
+// pingFunc connects to the cluster and returns the round-trip latency of a ping.
+type pingFunc func(ctx context.Context, uri string) (time.Duration, error)
+
+// pingCluster connects to the cluster and measures the round trip of a ping.
+func pingCluster(ctx context.Context, uri string) (time.Duration, error) {
+	m, err := topo.Connect(ctx, uri)
+	if err != nil {
+		return 0, errors.Wrap(err, "connect")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, m.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect: " + err.Error())
+		}
+	}()
+
+	startedAt := time.Now()
+
+	err = m.Ping(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "ping")
+	}
+
+	return time.Since(startedAt), nil
+}
+
+// runTestConnection connects to each endpoint and prints the result and the round-trip latency.
+// It returns [errConnectionFailed] if an endpoint cannot be reached.
+func runTestConnection(ctx context.Context, w io.Writer, endpoints []connectionEndpoint, ping pingFunc) error {
+	var failed []string
+
+	for _, e := range endpoints {
+		latency, err := ping(ctx, e.uri)
+		if err != nil {
+			fmt.Fprintf(w, "%s: failed: %v\n", e.name, err)
+
+			failed = append(failed, e.name)
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%s: ok (round trip %s)\n", e.name, latency.Round(time.Microsecond))
+	}
+
+	if len(failed) != 0 {
+		return errors.Wrapf(errConnectionFailed, "%v", failed)
+	}
+
+	return nil
+}
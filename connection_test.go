@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestRunTestConnection(t *testing.T) {
+	t.Parallel()
+
+	errRefused := errors.New("connection refused")
+	ping := func(_ context.Context, uri string) (time.Duration, error) {
+		if uri == "mongodb://unreachable:27017" {
+			return 0, errRefused
+		}
+
+		return 3 * time.Millisecond, nil
+	}
+
+	var buf bytes.Buffer
+
+	err := runTestConnection(t.Context(), &buf, []connectionEndpoint{
+		{"source", "mongodb://source:27017"},
+		{"target", "mongodb://target:27017"},
+	}, ping)
+	if err != nil {
+		t.Errorf("reachable: got = %v, want nil", err)
+	}
+
+	want := "source: ok (round trip 3ms)\ntarget: ok (round trip 3ms)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("reachable: got output %q, want %q", got, want)
+	}
+
+	buf.Reset()
+
+	err = runTestConnection(t.Context(), &buf, []connectionEndpoint{
+		{"source", "mongodb://source:27017"},
+		{"target", "mongodb://unreachable:27017"},
+	}, ping)
+	if !errors.Is(err, errConnectionFailed) || !strings.Contains(err.Error(), "target") {
+		t.Errorf("unreachable: got = %v, want %v of the target", err, errConnectionFailed)
+	}
+
+	want = "source: ok (round trip 3ms)\ntarget: failed: connection refused\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unreachable: got output %q, want %q", got, want)
+	}
+}
+
+func TestPingCluster_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+	defer cancel()
+
+	// nothing listens on the port
+	_, err := pingCluster(ctx, "mongodb://127.0.0.1:1")
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+// errInvalidFilters indicates that the validate-filters command has found invalid patterns.
+var errInvalidFilters = errors.New("invalid namespace filters")
+
+// readNamespaceFile reads the namespace patterns of the file: one pattern per line.
+// The empty lines and the lines starting with "#" are ignored.
+func readNamespaceFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open")
+	}
+	defer f.Close()
+
+	var patterns []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %q", path)
+	}
+
+	return patterns, nil
+}
+
+// readNamespacePatterns returns the patterns of the flag followed by the patterns of the files.
+func readNamespacePatterns(patterns, files []string) ([]string, error) {
+	patterns = append([]string(nil), patterns...)
+
+	for _, path := range files {
+		filePatterns, err := readNamespaceFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+
+		patterns = append(patterns, filePatterns...)
+	}
+
+	return patterns, nil
+}
+
+// writeFilterReport prints the resolved namespaces and the validation errors of the filters.
+// It returns [errInvalidFilters] if there is an error.
+func writeFilterReport(w io.Writer, namespaces []pcsm.Namespace, errs []error) error {
+	for _, ns := range namespaces {
+		fmt.Fprintln(w, ns.String())
+	}
+
+	fmt.Fprintf(w, "%d namespaces\n", len(namespaces))
+
+	for _, err := range errs {
+		fmt.Fprintln(w, "error: "+err.Error())
+	}
+
+	if len(errs) != 0 {
+		return errors.Wrapf(errInvalidFilters, "%d errors", len(errs))
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestReadNamespacePatterns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "include.txt")
+
+	err := os.WriteFile(path, []byte("# sales\ndb1.*\n\n  db2.coll1  \n#db3.*\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readNamespacePatterns([]string{"db0.coll0"}, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"db0.coll0", "db1.*", "db2.coll1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	_, err = readNamespacePatterns(nil, []string{filepath.Join(t.TempDir(), "missing.txt")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestWriteFilterReport(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []pcsm.Namespace{{"db1", "coll1"}, {"db2", "coll1"}}
+
+	var buf bytes.Buffer
+
+	err := writeFilterReport(&buf, namespaces, nil)
+	if err != nil {
+		t.Errorf("got = %v, want nil", err)
+	}
+
+	if got, want := buf.String(), "db1.coll1\ndb2.coll1\n2 namespaces\n"; got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+
+	buf.Reset()
+
+	errs := pcsm.ValidateNamespacePatterns([]string{"db1"}, nil)
+
+	err = writeFilterReport(&buf, nil, errs)
+	if !errors.Is(err, errInvalidFilters) {
+		t.Errorf("got = %v, want %v", err, errInvalidFilters)
+	}
+
+	want := "0 namespaces\nerror: include \"db1\": missing collection name (use \"db1.*\" for all collections): " +
+		"invalid namespace pattern\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+}
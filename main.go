@@ -3,15 +3,21 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -20,6 +26,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
 
@@ -61,16 +68,19 @@ var rootCmd = &cobra.Command{
 	SilenceUsage: true,
 
 	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
-		logLevelFlag, _ := cmd.PersistentFlags().GetString("log-level")
-		logJSON, _ := cmd.PersistentFlags().GetBool("log-json")
-		logNoColor, _ := cmd.PersistentFlags().GetBool("no-color")
+		logJSON, _ := cmd.Flags().GetBool("log-json")
+		logNoColor, _ := cmd.Flags().GetBool("no-color")
 
-		logLevel, err := zerolog.ParseLevel(logLevelFlag)
+		logLevel, warning, err := getLogLevel(cmd.Flags())
 		if err != nil {
 			log.InitGlobals(0, logJSON, true).Fatal().Msg("Unknown log level")
 		}
 
 		lg := log.InitGlobals(logLevel, logJSON, logNoColor)
+		if warning != "" {
+			lg.Warn().Msg(warning)
+		}
+
 		ctx := lg.WithContext(context.Background())
 		cmd.SetContext(ctx)
 	},
@@ -98,12 +108,36 @@ var rootCmd = &cobra.Command{
 		if targetURI == "" {
 			targetURI = os.Getenv("PCSM_TARGET_URI")
 		}
+
+		targetType, _ := cmd.Flags().GetString("target-type")
+		switch targetType {
+		case targetTypeMongoDB:
+		case targetTypeStdout:
+			return runSink(cmd.Context(), sourceURI, targetType, "")
+		case targetTypeFile:
+			if targetURI == "" {
+				return errors.New("required flag --target (file path) not set")
+			}
+
+			return runSink(cmd.Context(), sourceURI, targetType, targetURI)
+		default:
+			return errors.Errorf("invalid --target-type %q", targetType)
+		}
+
 		if targetURI == "" {
 			return errors.New("required flag --target not set")
 		}
 
+		stateBackend, _ := cmd.Flags().GetString("state-backend")
+		stateFile, _ := cmd.Flags().GetString("state-file")
+
 		if ok, _ := cmd.Flags().GetBool("reset-state"); ok {
-			err := resetState(cmd.Context(), targetURI)
+			resetStateFile := ""
+			if stateBackend == stateBackendFile {
+				resetStateFile = stateFile
+			}
+
+			err := resetState(cmd.Context(), targetURI, resetStateFile)
 			if err != nil {
 				return err
 			}
@@ -114,6 +148,52 @@ var rootCmd = &cobra.Command{
 		start, _ := cmd.Flags().GetBool("start")
 		pause, _ := cmd.Flags().GetBool("pause-on-initial-sync")
 
+		var targetEncryption *topo.EncryptionOptions
+		if schema, _ := cmd.Flags().GetString("target-encryption-schema"); schema != "" {
+			keyVaultNS, _ := cmd.Flags().GetString("target-key-vault-namespace")
+			kmsProviders, _ := cmd.Flags().GetString("target-kms-providers")
+
+			targetEncryption, err = topo.ParseEncryptionOptions(schema, keyVaultNS, kmsProviders)
+			if err != nil {
+				return errors.Wrap(err, "target encryption")
+			}
+		}
+
+		retryJitterFlag, _ := cmd.Flags().GetString("retry-jitter")
+
+		retryJitter, err := topo.ParseRetryJitter(retryJitterFlag)
+		if err != nil {
+			return err
+		}
+
+		topo.SetRetryJitter(retryJitter)
+
+		progressCollection, _ := cmd.Flags().GetString("progress-collection")
+		progressInterval, _ := cmd.Flags().GetDuration("progress-interval")
+		reportInterval, _ := cmd.Flags().GetDuration("report-interval")
+		statsdAddress, _ := cmd.Flags().GetString("statsd-address")
+		notifyWebhook, _ := cmd.Flags().GetString("notify-webhook")
+
+		apiToken, _ := cmd.Flags().GetString("api-token")
+		if apiToken == "" {
+			apiToken = os.Getenv(APITokenEnvVar)
+		}
+
+		apiTLSCert, _ := cmd.Flags().GetString("api-tls-cert")
+		apiTLSKey, _ := cmd.Flags().GetString("api-tls-key")
+		apiTLSClientCA, _ := cmd.Flags().GetString("api-tls-client-ca")
+
+		targetURIs, _ := cmd.Flags().GetStringArray("target-uri")
+		pauseOnTargetFailure, _ := cmd.Flags().GetBool("pause-on-target-failure")
+
+		var maxMemory uint64
+		if s, _ := cmd.Flags().GetString("max-memory"); s != "" {
+			maxMemory, err = humanize.ParseBytes(s)
+			if err != nil {
+				return errors.Wrap(err, "invalid --max-memory")
+			}
+		}
+
 		log.Ctx(cmd.Context()).Info("Percona ClusterSync for MongoDB " + buildVersion())
 
 		return runServer(cmd.Context(), serverOptions{
@@ -122,6 +202,29 @@ var rootCmd = &cobra.Command{
 			targetURI: targetURI,
 			start:     start,
 			pause:     pause,
+
+			targetEncryption: targetEncryption,
+
+			progressCollection: progressCollection,
+			progressInterval:   progressInterval,
+			reportInterval:     reportInterval,
+
+			statsdAddress: statsdAddress,
+			notifyWebhook: notifyWebhook,
+
+			apiToken: apiToken,
+
+			apiTLSCert:     apiTLSCert,
+			apiTLSKey:      apiTLSKey,
+			apiTLSClientCA: apiTLSClientCA,
+
+			targetURIs:           targetURIs,
+			pauseOnTargetFailure: pauseOnTargetFailure,
+
+			stateBackend: stateBackend,
+			stateFile:    stateFile,
+
+			maxMemory: int64(min(maxMemory, math.MaxInt64)), //nolint:gosec
 		})
 	},
 }
@@ -150,12 +253,139 @@ var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Get the status of the replication process",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		port, err := getPort(cmd.Flags())
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		watch, _ := cmd.Flags().GetBool("watch")
+		if !watch {
+			return client.Status(cmd.Context())
+		}
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		err = validatePollInterval(interval)
+		if err != nil {
+			return err
+		}
+
+		return client.WatchStatus(cmd.Context(), interval)
+	},
+}
+
+//nolint:gochecknoglobals
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait until the replication process is in the state",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		s, _ := cmd.Flags().GetString("state")
+
+		state, err := parseWaitState(s)
+		if err != nil {
+			return err
+		}
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		err = validatePollInterval(interval)
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return client.Wait(ctx, state, interval)
+	},
+}
+
+//nolint:gochecknoglobals
+var tailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print the change events read from the source before they are applied",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		namespaces, _ := cmd.Flags().GetStringSlice("namespace")
+		fullDocument, _ := cmd.Flags().GetBool("full-document")
+
+		return client.Tail(cmd.Context(), namespaces, fullDocument)
+	},
+}
+
+//nolint:gochecknoglobals
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Get the lifetime statistics of the replication process",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return errors.Errorf("invalid output format %q: expected text or json", output)
+		}
+
+		return client.Stats(cmd.Context(), output)
+	},
+}
+
+//nolint:gochecknoglobals
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Print the source namespaces of the filters with their indexes, options, and size",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
-		return NewClient(port).Status(cmd.Context())
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return errors.Errorf("invalid output format %q: expected text or json", output)
+		}
+
+		includeNamespaces, _ := cmd.Flags().GetStringSlice("include-namespaces")
+		excludeNamespaces, _ := cmd.Flags().GetStringSlice("exclude-namespaces")
+
+		estimateMode, _ := cmd.Flags().GetString("estimate-mode")
+
+		_, err = pcsm.ParseEstimateMode(estimateMode)
+		if err != nil {
+			return errors.Wrap(err, "invalid --estimate-mode")
+		}
+
+		sampleSize, _ := cmd.Flags().GetInt("sample-size")
+		if sampleSize <= 0 {
+			return errors.Errorf("invalid --sample-size: %d", sampleSize)
+		}
+
+		req := planRequest{
+			IncludeNamespaces: includeNamespaces,
+			ExcludeNamespaces: excludeNamespaces,
+			EstimateMode:      estimateMode,
+			SampleSize:        sampleSize,
+		}
+
+		return client.PlanDetailed(cmd.Context(), req, output)
 	},
 }
 
@@ -164,7 +394,7 @@ var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start Cluster Replication",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		port, err := getPort(cmd.Flags())
+		client, err := newClient(cmd.Flags())
 		if err != nil {
 			return err
 		}
@@ -172,14 +402,159 @@ var startCmd = &cobra.Command{
 		pauseOnInitialSync, _ := cmd.Flags().GetBool("pause-on-initial-sync")
 		includeNamespaces, _ := cmd.Flags().GetStringSlice("include-namespaces")
 		excludeNamespaces, _ := cmd.Flags().GetStringSlice("exclude-namespaces")
+		replicateInclude, _ := cmd.Flags().GetStringSlice("replicate-include")
+		replicateExclude, _ := cmd.Flags().GetStringSlice("replicate-exclude")
+		strictNamespaces, _ := cmd.Flags().GetBool("strict-namespaces")
+		minOplogHours, _ := cmd.Flags().GetFloat64("min-oplog-hours")
+		ignoreOplogWindow, _ := cmd.Flags().GetBool("ignore-oplog-window")
+		requireMatchingFCV, _ := cmd.Flags().GetBool("require-matching-fcv")
+		cloneNoCursorTimeout, _ := cmd.Flags().GetBool("clone-cursor-no-timeout")
+
+		var cloneBatchBytes uint64
+		if s, _ := cmd.Flags().GetString("clone-batch-bytes"); s != "" {
+			cloneBatchBytes, err = humanize.ParseBytes(s)
+			if err != nil {
+				return errors.Wrap(err, "invalid --clone-batch-bytes")
+			}
+		}
+
+		copyMarkerField, _ := cmd.Flags().GetString("copy-marker-field")
+		cloneMaxInflightBatches, _ := cmd.Flags().GetInt("clone-max-inflight-batches")
+		cappedTail, _ := cmd.Flags().GetStringToInt64("capped-tail")
+		cloneSnapshot, _ := cmd.Flags().GetString("clone-snapshot")
+		cloneOrder, _ := cmd.Flags().GetString("clone-order")
+		cloneReadConcern, _ := cmd.Flags().GetString("clone-read-concern")
+		disableBalancer, _ := cmd.Flags().GetBool("disable-balancer-during-clone")
+		includeEmptyCollections, _ := cmd.Flags().GetBool("include-empty-collections")
+		discoverNewCollections, _ := cmd.Flags().GetBool("discover-new-collections")
+		depends, _ := cmd.Flags().GetStringSlice("depends")
+		transforms, _ := cmd.Flags().GetStringSlice("transform")
+		maxClockSkew, _ := cmd.Flags().GetDuration("max-clock-skew")
+		replicationMethod, _ := cmd.Flags().GetString("replication-method")
+		deadLetterNamespace, _ := cmd.Flags().GetString("dead-letter-namespace")
+		ddlAuditCollection, _ := cmd.Flags().GetString("ddl-audit-collection")
+		maxDocRetries, _ := cmd.Flags().GetInt("max-doc-retries")
+		pauseOnDDL, _ := cmd.Flags().GetBool("pause-on-ddl")
+		applyOpTimeout, _ := cmd.Flags().GetDuration("apply-op-timeout")
+		failureGracePeriod, _ := cmd.Flags().GetDuration("failure-grace-period")
+		maxEventAge, _ := cmd.Flags().GetDuration("max-event-age")
+		onStaleEvents, _ := cmd.Flags().GetString("on-stale-events")
+		onNestingExceeded, _ := cmd.Flags().GetString("on-nesting-exceeded")
+		onUnknownEvent, _ := cmd.Flags().GetString("on-unknown-event")
+		maxTotalDuration, _ := cmd.Flags().GetDuration("max-total-duration")
+		onBudgetExceeded, _ := cmd.Flags().GetString("on-budget-exceeded")
+		onCollectionError, _ := cmd.Flags().GetString("on-collection-error")
+		onSchemaDrift, _ := cmd.Flags().GetString("on-schema-drift")
+		onOplogLost, _ := cmd.Flags().GetString("on-oplog-lost")
+		changeStreamBatchSize, _ := cmd.Flags().GetInt32("change-stream-batch-size")
+		changeStreamMaxAwaitTime, _ := cmd.Flags().GetDuration("change-stream-max-await-time")
+		bypassDocumentValidation, _ := cmd.Flags().GetBool("bypass-document-validation")
+		dedupWindow, _ := cmd.Flags().GetInt("dedup-window")
+		preserveTxnOrder, _ := cmd.Flags().GetBool("preserve-order-within-transaction")
+		updateAsUpsert, _ := cmd.Flags().GetBool("update-as-upsert")
+		ddlWorkers, _ := cmd.Flags().GetInt("ddl-workers")
+		copyClusterParameters, _ := cmd.Flags().GetBool("copy-cluster-parameters")
+		startFromBackupTS, _ := cmd.Flags().GetString("start-from-backup-timestamp")
+		onKeyTooLong, _ := cmd.Flags().GetString("on-keytoolong")
+		manageTTL, _ := cmd.Flags().GetBool("manage-ttl-during-replication")
+		replicateHiddenIndexes, _ := cmd.Flags().GetBool("replicate-hidden-indexes")
+		replicateDropDatabase, _ := cmd.Flags().GetBool("replicate-dropdatabase")
+		renameCollisionSuffix, _ := cmd.Flags().GetString("rename-collision-suffix")
+		targetNamespacePrefix, _ := cmd.Flags().GetString("target-namespace-prefix")
+		cloneManifest, _ := cmd.Flags().GetString("clone-manifest")
+		internalNamespaces, _ := cmd.Flags().GetStringSlice("include-internal-namespaces")
 
 		startOptions := startRequest{
 			PauseOnInitialSync: pauseOnInitialSync,
 			IncludeNamespaces:  includeNamespaces,
 			ExcludeNamespaces:  excludeNamespaces,
+			ReplicateInclude:   replicateInclude,
+			ReplicateExclude:   replicateExclude,
+			StrictNamespaces:   strictNamespaces,
+			MinOplogHours:      minOplogHours,
+			IgnoreOplogWindow:  ignoreOplogWindow,
+			RequireMatchingFCV: requireMatchingFCV,
+
+			CloneNoCursorTimeout: cloneNoCursorTimeout,
+			CloneBatchBytes:      int64(cloneBatchBytes), //nolint:gosec
+			CopyMarkerField:      copyMarkerField,
+			CappedTail:           cappedTail,
+			CloneSnapshot:        cloneSnapshot,
+			CloneOrder:           cloneOrder,
+			CloneReadConcern:     cloneReadConcern,
+			Transforms:           transforms,
+			ReplicationMethod:    replicationMethod,
+			DeadLetterNamespace:  deadLetterNamespace,
+			DDLAuditCollection:   ddlAuditCollection,
+			MaxDocRetries:        maxDocRetries,
+			PauseOnDDL:           pauseOnDDL,
+
+			DisableBalancerDuringClone: disableBalancer,
+			CloneMaxInflightBatches:    cloneMaxInflightBatches,
+			DiscoverNewCollections:     discoverNewCollections,
+			Depends:                    depends,
+			BypassDocumentValidation:   bypassDocumentValidation,
+			DedupWindow:                dedupWindow,
+			PreserveTxnOrder:           preserveTxnOrder,
+			UpdateAsUpsert:             updateAsUpsert,
+			DDLWorkers:                 ddlWorkers,
+			CopyClusterParameters:      copyClusterParameters,
+			OnKeyTooLong:               onKeyTooLong,
+			ReplicateHiddenIndexes:     replicateHiddenIndexes,
+			OnStaleEvents:              onStaleEvents,
+			OnNestingExceeded:          onNestingExceeded,
+			OnUnknownEvent:             onUnknownEvent,
+			OnBudgetExceeded:           onBudgetExceeded,
+			OnCollectionError:          onCollectionError,
+			OnSchemaDrift:              onSchemaDrift,
+			OnOplogLost:                onOplogLost,
+			StartFromBackupTimestamp:   startFromBackupTS,
+			RenameCollisionSuffix:      renameCollisionSuffix,
+			TargetNamespacePrefix:      targetNamespacePrefix,
+			CloneManifest:              cloneManifest,
+			InternalNamespaces:         internalNamespaces,
+		}
+
+		if maxClockSkew > 0 {
+			startOptions.MaxClockSkew = maxClockSkew.String()
+		}
+
+		if applyOpTimeout > 0 {
+			startOptions.ApplyOpTimeout = applyOpTimeout.String()
+		}
+
+		if failureGracePeriod > 0 {
+			startOptions.FailureGracePeriod = failureGracePeriod.String()
+		}
+
+		if maxEventAge > 0 {
+			startOptions.MaxEventAge = maxEventAge.String()
+		}
+
+		if maxTotalDuration > 0 {
+			startOptions.MaxTotalDuration = maxTotalDuration.String()
 		}
 
-		return NewClient(port).Start(cmd.Context(), startOptions)
+		if !manageTTL {
+			startOptions.ManageTTLDuringReplication = &manageTTL
+		}
+		if !replicateDropDatabase {
+			startOptions.ReplicateDropDatabase = &replicateDropDatabase
+		}
+
+		if cmd.Flags().Changed("change-stream-batch-size") {
+			startOptions.ChangeStreamBatchSize = &changeStreamBatchSize
+		}
+
+		if cmd.Flags().Changed("change-stream-max-await-time") {
+			startOptions.ChangeStreamMaxAwaitTime = changeStreamMaxAwaitTime.String()
+		}
+
+		if !includeEmptyCollections {
+			startOptions.IncludeEmptyCollections = &includeEmptyCollections
+		}
+
+		return client.Start(cmd.Context(), startOptions)
 	},
 }
 
@@ -188,18 +563,20 @@ var finalizeCmd = &cobra.Command{
 	Use:   "finalize",
 	Short: "Finalize Cluster Replication",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		port, err := getPort(cmd.Flags())
+		client, err := newClient(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
 		ignoreHistoryLost, _ := cmd.Flags().GetBool("ignore-history-lost")
+		lockSource, _ := cmd.Flags().GetBool("lock-source-on-finalize")
 
 		finalizeOptions := finalizeRequest{
 			IgnoreHistoryLost: ignoreHistoryLost,
+			LockSource:        lockSource,
 		}
 
-		return NewClient(port).Finalize(cmd.Context(), finalizeOptions)
+		return client.Finalize(cmd.Context(), finalizeOptions)
 	},
 }
 
@@ -208,12 +585,28 @@ var pauseCmd = &cobra.Command{
 	Use:   "pause",
 	Short: "Pause Cluster Replication",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		port, err := getPort(cmd.Flags())
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		namespace, _ := cmd.Flags().GetString("namespace")
+
+		return client.Pause(cmd.Context(), pauseRequest{Namespace: namespace})
+	},
+}
+
+//nolint:gochecknoglobals
+var drainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "Stop the Data Clone after the collections in progress and pause Cluster Replication",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
-		return NewClient(port).Pause(cmd.Context())
+		return client.Drain(cmd.Context())
 	},
 }
 
@@ -222,18 +615,130 @@ var resumeCmd = &cobra.Command{
 	Use:   "resume",
 	Short: "Resume Cluster Replication",
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		port, err := getPort(cmd.Flags())
+		client, err := newClient(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
 		fromFailure, _ := cmd.Flags().GetBool("from-failure")
+		manifest, _ := cmd.Flags().GetString("manifest")
+		namespace, _ := cmd.Flags().GetString("namespace")
 
 		resumeOptions := resumeRequest{
 			FromFailure: fromFailure,
+			Manifest:    manifest,
+			Namespace:   namespace,
+		}
+
+		return client.Resume(cmd.Context(), resumeOptions)
+	},
+}
+
+//nolint:gochecknoglobals
+var approveDDLCmd = &cobra.Command{
+	Use:   "approve-ddl",
+	Short: "Approve the pending DDL change and resume Cluster Replication",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return client.ApproveDDL(cmd.Context())
+	},
+}
+
+//nolint:gochecknoglobals
+var reauthCmd = &cobra.Command{
+	Use:   "reauth",
+	Short: "Supply new source credentials and resume Cluster Replication paused on the authentication failure",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		sourceURI, _ := cmd.Flags().GetString("source")
+		if sourceURI == "" {
+			sourceURI = os.Getenv("PCSM_SOURCE_URI")
+		}
+		if sourceURI == "" {
+			return errors.New("required flag --source not set")
+		}
+
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return client.Reauth(cmd.Context(), reauthRequest{Source: sourceURI})
+	},
+}
+
+//nolint:gochecknoglobals
+var replayDeadLetterCmd = &cobra.Command{
+	Use:   "replay-dead-letter",
+	Short: "Replay the change events stored in the dead-letter collection",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := newClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		namespace, _ := cmd.Flags().GetString("namespace")
+
+		replayOptions := replayDeadLetterRequest{
+			Namespace: namespace,
+		}
+
+		return client.ReplayDeadLetter(cmd.Context(), replayOptions)
+	},
+}
+
+//nolint:gochecknoglobals
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Apply the dead-letter entries of a file to the target",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		targetURI, _ := cmd.Flags().GetString("target")
+		if targetURI == "" {
+			targetURI = os.Getenv("PCSM_TARGET_URI")
+		}
+		if targetURI == "" {
+			return errors.New("required flag --target not set")
+		}
+
+		filename, _ := cmd.Flags().GetString("from-file")
+		if filename == "" {
+			return errors.New("required flag --from-file not set")
+		}
+
+		return runReplayFile(cmd.Context(), cmd.OutOrStdout(), targetURI, filename)
+	},
+}
+
+//nolint:gochecknoglobals
+var testConnectionCmd = &cobra.Command{
+	Use:   "test-connection",
+	Short: "Connect to the source and the target and report the round-trip latency",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		sourceURI, _ := cmd.Flags().GetString("source")
+		if sourceURI == "" {
+			sourceURI = os.Getenv("PCSM_SOURCE_URI")
+		}
+
+		targetURI, _ := cmd.Flags().GetString("target")
+		if targetURI == "" {
+			targetURI = os.Getenv("PCSM_TARGET_URI")
+		}
+
+		var endpoints []connectionEndpoint
+		if sourceURI != "" {
+			endpoints = append(endpoints, connectionEndpoint{"source", sourceURI})
+		}
+		if targetURI != "" {
+			endpoints = append(endpoints, connectionEndpoint{"target", targetURI})
+		}
+		if len(endpoints) == 0 {
+			return errors.New("required flag --source or --target not set")
 		}
 
-		return NewClient(port).Resume(cmd.Context(), resumeOptions)
+		return runTestConnection(cmd.Context(), cmd.OutOrStdout(), endpoints, pingCluster)
 	},
 }
 
@@ -250,7 +755,9 @@ var resetCmd = &cobra.Command{
 			return errors.New("required flag --target not set")
 		}
 
-		err := resetState(cmd.Context(), targetURI)
+		stateFile, _ := cmd.Flags().GetString("state-file")
+
+		err := resetState(cmd.Context(), targetURI, stateFile)
 		if err != nil {
 			return err
 		}
@@ -289,7 +796,7 @@ var resetRecoveryCmd = &cobra.Command{
 			}
 		}()
 
-		err = DeleteRecoveryData(ctx, target)
+		err = DeleteRecoveryData(ctx, mongoStateStore{target})
 		if err != nil {
 			return err
 		}
@@ -339,41 +846,351 @@ var resetHeartbeatCmd = &cobra.Command{
 	},
 }
 
-func getPort(flags *pflag.FlagSet) (int, error) {
-	port, _ := flags.GetInt("port")
-	if flags.Changed("port") {
-		return port, nil
+// getLogLevel returns the log level of the --log-level, --quiet, and --verbose flags.
+// --quiet (error) and --verbose (debug) override --log-level. --verbose wins over --quiet.
+// The warning describes the overridden flags.
+func getLogLevel(flags *pflag.FlagSet) (zerolog.Level, string, error) {
+	logLevelFlag, _ := flags.GetString("log-level")
+	quiet, _ := flags.GetBool("quiet")
+	verbose, _ := flags.GetBool("verbose")
+
+	logLevel, err := zerolog.ParseLevel(logLevelFlag)
+	if err != nil {
+		return 0, "", err //nolint:wrapcheck
 	}
 
-	portVar := os.Getenv("PCSM_PORT")
-	if portVar == "" {
-		return port, nil
+	var warning string
+
+	switch {
+	case verbose && quiet:
+		logLevel = zerolog.DebugLevel
+		warning = "--verbose overrides --quiet"
+	case verbose:
+		logLevel = zerolog.DebugLevel
+	case quiet:
+		logLevel = zerolog.ErrorLevel
+	default:
+		return logLevel, "", nil
 	}
 
-	parsedPort, err := strconv.ParseInt(portVar, 10, 32)
-	if err != nil {
-		return 0, errors.Errorf("invalid environment variable PCSM_PORT='%s'", portVar)
+	if flags.Changed("log-level") {
+		if warning != "" {
+			warning += " and --log-level"
+		} else if verbose {
+			warning = "--verbose overrides --log-level"
+		} else {
+			warning = "--quiet overrides --log-level"
+		}
 	}
 
-	return int(parsedPort), nil
+	return logLevel, warning, nil
 }
 
-func main() {
-	rootCmd.PersistentFlags().String("log-level", "info", "Log level")
-	rootCmd.PersistentFlags().Bool("log-json", false, "Output log in JSON format")
-	rootCmd.PersistentFlags().Bool("no-color", false, "Disable log color")
+//nolint:gochecknoglobals
+var validateFiltersCmd = &cobra.Command{
+	Use:   "validate-filters",
+	Short: "Print the source namespaces of the include and exclude filters without starting",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		sourceURI, _ := cmd.Flags().GetString("source")
+		if sourceURI == "" {
+			sourceURI = os.Getenv("PCSM_SOURCE_URI")
+		}
+		if sourceURI == "" {
+			return errors.New("required flag --source not set")
+		}
 
-	rootCmd.Flags().Int("port", DefaultServerPort, "Port number")
-	rootCmd.Flags().String("source", "", "MongoDB connection string for the source")
-	rootCmd.Flags().String("target", "", "MongoDB connection string for the target")
+		includeNamespaces, _ := cmd.Flags().GetStringSlice("include-namespaces")
+		excludeNamespaces, _ := cmd.Flags().GetStringSlice("exclude-namespaces")
+		includeFiles, _ := cmd.Flags().GetStringSlice("include-file")
+		excludeFiles, _ := cmd.Flags().GetStringSlice("exclude-file")
+
+		include, err := readNamespacePatterns(includeNamespaces, includeFiles)
+		if err != nil {
+			return errors.Wrap(err, "include file")
+		}
+
+		exclude, err := readNamespacePatterns(excludeNamespaces, excludeFiles)
+		if err != nil {
+			return errors.Wrap(err, "exclude file")
+		}
+
+		ctx := cmd.Context()
+
+		source, err := topo.Connect(ctx, sourceURI)
+		if err != nil {
+			return errors.Wrap(err, "connect")
+		}
+
+		defer func() {
+			err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, source.Disconnect)
+			if err != nil {
+				log.Ctx(ctx).Warn("Disconnect: " + err.Error())
+			}
+		}()
+
+		namespaces, err := pcsm.ListSourceNamespaces(ctx, source)
+		if err != nil {
+			return errors.Wrap(err, "list source namespaces")
+		}
+
+		return writeFilterReport(cmd.OutOrStdout(),
+			pcsm.FilterNamespaces(namespaces, include, exclude),
+			pcsm.ValidateNamespacePatterns(include, exclude))
+	},
+}
+
+//nolint:gochecknoglobals
+var stateExportCmd = &cobra.Command{
+	Use:   "state-export",
+	Short: "Export the recovery data of the migration to a file with the secrets redacted",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		targetURI, _ := cmd.Flags().GetString("target")
+		if targetURI == "" {
+			targetURI = os.Getenv("PCSM_TARGET_URI")
+		}
+
+		stateFile, _ := cmd.Flags().GetString("state-file")
+		if targetURI == "" && stateFile == "" {
+			return errors.New("required flag --target or --state-file not set")
+		}
+
+		output, _ := cmd.Flags().GetString("file")
+
+		ctx := cmd.Context()
+
+		return withStateStore(ctx, targetURI, stateFile, func(store StateStore) error {
+			if output == "" || output == "-" {
+				return exportState(ctx, store, cmd.OutOrStdout())
+			}
+
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:mnd
+			if err != nil {
+				return errors.Wrap(err, "create output file")
+			}
+
+			err = exportState(ctx, store, f)
+			if err != nil {
+				f.Close() //nolint:errcheck
+
+				return err
+			}
+
+			err = f.Close()
+			if err != nil {
+				return errors.Wrap(err, "close output file")
+			}
+
+			log.New("cli").Infof("OK: state exported to %q", output)
+
+			return nil
+		})
+	},
+}
+
+//nolint:gochecknoglobals
+var stateImportCmd = &cobra.Command{
+	Use:   "state-import",
+	Short: "Import the recovery data of the migration exported by state-export",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		targetURI, _ := cmd.Flags().GetString("target")
+		if targetURI == "" {
+			targetURI = os.Getenv("PCSM_TARGET_URI")
+		}
+
+		stateFile, _ := cmd.Flags().GetString("state-file")
+		if targetURI == "" && stateFile == "" {
+			return errors.New("required flag --target or --state-file not set")
+		}
+
+		input, _ := cmd.Flags().GetString("file")
+		if input == "" {
+			return errors.New("required flag --file not set")
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+
+		f, err := os.Open(input)
+		if err != nil {
+			return errors.Wrap(err, "open input file")
+		}
+		defer f.Close()
+
+		ctx := cmd.Context()
+
+		err = withStateStore(ctx, targetURI, stateFile, func(store StateStore) error {
+			return importState(ctx, store, f, force)
+		})
+		if err != nil {
+			return err
+		}
+
+		log.New("cli").Infof("OK: state imported from %q", input)
+
+		return nil
+	},
+}
+
+//nolint:gochecknoglobals
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Compare the target documents with the source range by range",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		sourceURI, _ := cmd.Flags().GetString("source")
+		if sourceURI == "" {
+			sourceURI = os.Getenv("PCSM_SOURCE_URI")
+		}
+		if sourceURI == "" {
+			return errors.New("required flag --source not set")
+		}
+
+		targetURI, _ := cmd.Flags().GetString("target")
+		if targetURI == "" {
+			targetURI = os.Getenv("PCSM_TARGET_URI")
+		}
+		if targetURI == "" {
+			return errors.New("required flag --target not set")
+		}
+
+		includeNamespaces, _ := cmd.Flags().GetStringSlice("include-namespaces")
+		excludeNamespaces, _ := cmd.Flags().GetStringSlice("exclude-namespaces")
+		checkpointFile, _ := cmd.Flags().GetString("checkpoint-file")
+		batchSize, _ := cmd.Flags().GetInt("verify-batch-size")
+		rateLimit, _ := cmd.Flags().GetInt("verify-rate-limit")
+		checksumAlgorithm, _ := cmd.Flags().GetString("checksum-algorithm")
+
+		if batchSize < 0 {
+			return errors.Errorf("invalid --verify-batch-size: %d", batchSize)
+		}
+		if rateLimit < 0 {
+			return errors.Errorf("invalid --verify-rate-limit: %d", rateLimit)
+		}
+
+		checksum, err := pcsm.ParseChecksumAlgorithm(checksumAlgorithm)
+		if err != nil {
+			return errors.Wrap(err, "invalid --checksum-algorithm")
+		}
+
+		errs := pcsm.ValidateNamespacePatterns(includeNamespaces, excludeNamespaces)
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+
+		return runVerify(cmd.Context(), cmd.OutOrStdout(), verifyOptions{
+			sourceURI:      sourceURI,
+			targetURI:      targetURI,
+			include:        includeNamespaces,
+			exclude:        excludeNamespaces,
+			checkpointFile: checkpointFile,
+			VerifyOptions: pcsm.VerifyOptions{
+				BatchSize: batchSize,
+				RateLimit: rateLimit,
+				Checksum:  checksum,
+			},
+		})
+	},
+}
+
+func getPort(flags *pflag.FlagSet) (int, error) {
+	port, _ := flags.GetInt("port")
+	if flags.Changed("port") {
+		return port, nil
+	}
+
+	portVar := os.Getenv("PCSM_PORT")
+	if portVar == "" {
+		return port, nil
+	}
+
+	parsedPort, err := strconv.ParseInt(portVar, 10, 32)
+	if err != nil {
+		return 0, errors.Errorf("invalid environment variable PCSM_PORT='%s'", portVar)
+	}
+
+	return int(parsedPort), nil
+}
+
+func main() {
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Log errors only (same as --log-level=error)")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log debug messages (same as --log-level=debug)")
+	rootCmd.PersistentFlags().Bool("log-json", false, "Output log in JSON format")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable log color")
+
+	rootCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	rootCmd.Flags().String("source", "", "MongoDB connection string for the source")
+	rootCmd.Flags().String("target", "", "MongoDB connection string for the target")
+	rootCmd.Flags().String("target-type", targetTypeMongoDB,
+		"Type of the target (mongodb|file|stdout). file and stdout write the change events as JSON Lines")
+	rootCmd.Flags().StringArray("target-uri", nil,
+		"MongoDB connection string of an additional target to replicate to. Repeat for each target")
+	rootCmd.Flags().Bool("pause-on-target-failure", false,
+		"Pause the replication to all targets when the replication to one target fails")
 	rootCmd.Flags().Bool("start", false, "Start Cluster Replication immediately")
 	rootCmd.Flags().Bool("reset-state", false, "Reset stored PCSM state")
 	rootCmd.Flags().Bool("pause-on-initial-sync", false, "Pause on Initial Sync")
+	rootCmd.Flags().String("target-encryption-schema", "",
+		"JSON schema map (inline or file path) for the client-side field level encryption on the target")
+	rootCmd.Flags().String("target-key-vault-namespace", "encryption.__keyVault",
+		"Key vault namespace for the client-side field level encryption on the target")
+	rootCmd.Flags().String("target-kms-providers", "",
+		"KMS providers configuration (inline JSON or file path) for the client-side field level encryption")
+	rootCmd.Flags().String("retry-jitter", string(topo.RetryJitterNone),
+		"Jitter of the transient error retry backoff (full|equal|none)")
+	rootCmd.Flags().String("progress-collection", "",
+		"Collection of the PCSM database on the target to write the progress snapshots to")
+	rootCmd.Flags().Duration("progress-interval", config.DefaultProgressInterval,
+		"Interval of the progress snapshots")
+	rootCmd.Flags().Duration("report-interval", 0,
+		"Interval of the progress summary logged by the server (0 disables it)")
+	rootCmd.Flags().String("statsd-address", "",
+		"Address (host:port) of the StatsD server to send the metrics to")
+	rootCmd.Flags().String("notify-webhook", "",
+		"URL to POST a JSON notification to on the clone completion, initial sync completion, failure, and finalize")
+	rootCmd.Flags().String("api-token", "",
+		"Bearer token required by the HTTP API (default: $"+APITokenEnvVar+")")
+	rootCmd.Flags().String("api-tls-cert", "", "Certificate file to serve the HTTP API over HTTPS")
+	rootCmd.Flags().String("api-tls-key", "", "Key file of the HTTPS certificate")
+	rootCmd.Flags().String("api-tls-client-ca", "",
+		"CA file to verify the client certificates of the HTTPS API (mutual TLS)")
+	rootCmd.Flags().String("state-backend", stateBackendMongoDB,
+		"Storage of the recovery data (mongodb|file). file does not write to the target PCSM database")
+	rootCmd.Flags().String("state-file", "pcsm.state", "File of the recovery data for the file state backend")
+	rootCmd.Flags().String("max-memory", "",
+		"Limit of the clone read batches and the queued change events (e.g. 2GiB). Reads wait at the limit")
 	rootCmd.Flags().MarkHidden("start")                 //nolint:errcheck
 	rootCmd.Flags().MarkHidden("reset-state")           //nolint:errcheck
 	rootCmd.Flags().MarkHidden("pause-on-initial-sync") //nolint:errcheck
 
 	statusCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	statusCmd.Flags().Bool("watch", false, "Print the status every interval until interrupted")
+
+	waitCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	waitCmd.Flags().String("state", pcsm.StateFinalized,
+		"State to wait for (idle|running|paused|finalizing|finalized|failed)")
+	waitCmd.Flags().Duration("timeout", 0, "Maximum time to wait (0 waits without a limit)")
+
+	for _, cmd := range []*cobra.Command{statusCmd, waitCmd} {
+		cmd.Flags().Duration("interval", config.DefaultPollInterval,
+			"Interval of the status requests (minimum "+config.MinPollInterval.String()+")")
+	}
+
+	tailCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	tailCmd.Flags().StringSlice("namespace", nil, "Namespaces of the change events (e.g. db1.collection1,db2.*)")
+	tailCmd.Flags().Bool("full-document", false, "Print the full document of the change events")
+
+	statsCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	statsCmd.Flags().String("output", "text", "Output format (text|json)")
+
+	planCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	planCmd.Flags().String("output", "text", "Output format (text|json)")
+	planCmd.Flags().StringSlice("include-namespaces", nil,
+		"Namespaces to include (e.g. db1.collection1,db2.*)")
+	planCmd.Flags().StringSlice("exclude-namespaces", nil,
+		"Namespaces to exclude (e.g. db3.collection3,db4.*)")
+	planCmd.Flags().String("estimate-mode", string(pcsm.EstimateCollStats),
+		"Method of the size estimate (collstats|sample). sample averages the size of a document sample")
+	planCmd.Flags().Int("sample-size", config.DefaultEstimateSampleSize,
+		"Number of the sampled documents per collection of the sample estimate")
 
 	startCmd.Flags().Int("port", DefaultServerPort, "Port number")
 	startCmd.Flags().Bool("pause-on-initial-sync", false, "Pause on Initial Sync")
@@ -382,27 +1199,222 @@ func main() {
 		"Namespaces to include in the replication (e.g. db1.collection1,db2.collection2)")
 	startCmd.Flags().StringSlice("exclude-namespaces", nil,
 		"Namespaces to exclude from the replication (e.g. db3.collection3,db4.*)")
+	startCmd.Flags().StringSlice("replicate-include", nil,
+		"Included namespaces to replicate the changes of (e.g. db1.hot). The others are cloned only")
+	startCmd.Flags().StringSlice("replicate-exclude", nil,
+		"Included namespaces to clone without replicating their changes (e.g. db1.archive,db2.*)")
+	startCmd.Flags().Bool("strict-namespaces", false,
+		"Fail to start if an include namespace pattern matches no source namespace")
+	startCmd.Flags().Float64("min-oplog-hours", 0,
+		"Fail to start if the source oplog window is less than the number of hours (0 disables)")
+	startCmd.Flags().Bool("ignore-oplog-window", false,
+		"Report an insufficient source oplog window without failing to start")
+	startCmd.Flags().Bool("require-matching-fcv", false,
+		"Fail to start if the target featureCompatibilityVersion is lower than the source")
+	startCmd.Flags().Bool("clone-cursor-no-timeout", false,
+		"Disable the server idle timeout for the clone read cursors")
+	startCmd.Flags().String("clone-batch-bytes", "",
+		"Maximum size of a clone copy batch (e.g. 8MB). A larger document is copied in its own batch. Default: 48MB")
+	startCmd.Flags().Int("clone-max-inflight-batches", 0,
+		"Maximum number of the clone insert batches of a collection not inserted yet (0 is no limit)")
+	startCmd.Flags().String("copy-marker-field", "",
+		"Stamp the cloned documents with the field and skip the stamped documents on a restarted clone")
+	startCmd.Flags().StringToInt64("capped-tail", nil,
+		"Clone only the last N documents of the capped collections (e.g. db1.log=1000,db2.events=500)")
+	startCmd.Flags().String("clone-snapshot", string(pcsm.CloneSnapshotNone),
+		"Read consistency of the collection clone (session|none)")
+	startCmd.Flags().String("clone-order", string(pcsm.CloneOrderLargestFirst),
+		"Order of the collection clone (largest-first|interleave)")
+	startCmd.Flags().String("clone-read-concern", "",
+		"Read concern of the clone reads from the source (local|available|majority|snapshot)")
+	startCmd.Flags().Bool("include-empty-collections", true,
+		"Create the empty source collections on the target with their options and indexes")
+	startCmd.Flags().Bool("disable-balancer-during-clone", false,
+		"Stop the balancer of the sharded target during the clone and start it after")
+	startCmd.Flags().Bool("discover-new-collections", false,
+		"Re-list the source collections during the clone and copy the collections created after its start")
+	startCmd.Flags().StringSlice("depends", nil,
+		"Clone a namespace after the namespace it depends on (e.g. db1.orders:db1.customers)")
+	startCmd.Flags().StringSlice("transform", nil,
+		"Transforms applied to change events before apply (noop, delay:<duration>)")
+	startCmd.Flags().Duration("max-clock-skew", 0,
+		"Report the replicated date and timestamp values later than the source time by more than the duration")
+	startCmd.Flags().String("replication-method", string(pcsm.ReplicationAuto),
+		"Method of reading changes from the source (changestream|oplog|auto)")
+	startCmd.Flags().String("dead-letter-namespace", "",
+		"Target namespace to store the change events that fail to apply (e.g. pcsm_dlq.events)")
+	startCmd.Flags().Int("max-doc-retries", 0,
+		"Retries of a change event that fails to apply before it is stored in the dead-letter namespace")
+	startCmd.Flags().String("ddl-audit-collection", "",
+		"Target namespace to record the replicated DDL changes (e.g. pcsm_audit.ddl)")
+	startCmd.Flags().Bool("pause-on-ddl", false,
+		"Pause on create, drop, rename, and collMod changes until approved with approve-ddl")
+	startCmd.Flags().Bool("replicate-dropdatabase", true,
+		"Apply the dropDatabase changes on the target. The collection drops are applied regardless")
+	startCmd.Flags().Duration("apply-op-timeout", 0,
+		"Abort and retry a single apply write to the target that does not complete in the duration")
+	startCmd.Flags().Duration("failure-grace-period", 0,
+		"Retry the failed change replication as degraded and fail only if the failures persist beyond the period")
+	startCmd.Flags().Duration("max-event-age", 0,
+		"Maximum age of the first change event after a reconnect before the on-stale-events action (0 disables)")
+	startCmd.Flags().String("on-stale-events", string(pcsm.StaleEventsWarn),
+		"Handling of the change events older than max-event-age after a reconnect: warn, pause, or refuse")
+	startCmd.Flags().String("on-nesting-exceeded", "",
+		"Handling of the documents nested deeper than the target limit: skip or fail (default no check)")
+	startCmd.Flags().String("on-unknown-event", string(pcsm.UnknownEventLog),
+		"Handling of the change events of an unknown operation type: log, skip, or fail")
+	startCmd.Flags().Duration("max-total-duration", 0,
+		"Maximum time since the start to be finalized before the on-budget-exceeded action (0 disables)")
+	startCmd.Flags().String("on-budget-exceeded", string(pcsm.BudgetAbort),
+		"Handling of the replication not finalized within max-total-duration: abort or pause")
+	startCmd.Flags().String("on-collection-error", string(pcsm.CollectionErrorFail),
+		"Handling of a collection that fails to clone: fail or skip the collection and clone the others")
+	startCmd.Flags().String("on-schema-drift", "",
+		"Keep the existing target collections and handle their options that differ from the source: "+
+			"adopt, error, or collmod (default drop and recreate)")
+	startCmd.Flags().String("on-oplog-lost", string(pcsm.OplogLostFail),
+		"Handling of the change replication that falls off the source oplog: fail or reclone the written namespaces")
+	startCmd.Flags().Int32("change-stream-batch-size", config.ChangeStreamBatchSize,
+		"Batch size of the source change stream")
+	startCmd.Flags().Duration("change-stream-max-await-time", config.ChangeStreamAwaitTime,
+		"Maximum time the source change stream waits for new changes")
+	startCmd.Flags().Bool("bypass-document-validation", false,
+		"Skip the document validation of the target collections when applying changes")
+	startCmd.Flags().Int("dedup-window", 0,
+		"Number of the recent applied changes to skip when read again after a reconnect (0 disables)")
+	startCmd.Flags().Bool("preserve-order-within-transaction", false,
+		"Apply the operations of a transaction in one bulk write in the order of applyOps")
+	startCmd.Flags().Bool("update-as-upsert", false,
+		"Apply the updates as upserts of the full document looked up by the change stream (updateLookup)")
+	startCmd.Flags().Int("ddl-workers", 0,
+		"Number of the index builds, index drops, and collMod changes applied concurrently "+
+			"across namespaces (0 applies them in order)")
+	startCmd.Flags().Bool("copy-cluster-parameters", false,
+		"Copy the cluster parameters of the source (e.g. defaultMaxTimeMS) to the target before the clone")
+	startCmd.Flags().String("on-keytoolong", string(pcsm.KeyTooLongSkip),
+		"Handling of an index build failed by keys over the target index key limit: skip or fail")
+	startCmd.Flags().String("start-from-backup-timestamp", "",
+		"Skip the clone and replicate the changes since the backup restore point (e.g. 1700000000,3)")
+	startCmd.Flags().Bool("manage-ttl-during-replication", true,
+		"Disable the target TTL indexes until finalization. Source TTL deletes are replicated")
+	startCmd.Flags().Bool("replicate-hidden-indexes", false,
+		"Hide the target indexes during the replication as on the source (default hidden on finalization)")
+	startCmd.Flags().String("rename-collision-suffix", "",
+		"Clone an existing target collection into the suffixed collection that replaces it on finalization")
+	startCmd.Flags().String("target-namespace-prefix", "",
+		"Prefix of each target database name (e.g. test_ clones the app database into test_app)")
+	startCmd.Flags().String("clone-manifest", "",
+		"File of the clone progress of each namespace updated during the clone (see resume --manifest)")
+	startCmd.Flags().StringSlice("include-internal-namespaces", nil,
+		"Internal collections to replicate by the exact name (e.g. config.system.sessions)")
 
 	pauseCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	pauseCmd.Flags().String("namespace", "",
+		"Pause only the namespaces matching the pattern (e.g. db.coll or db.*). Others are replicated")
+
+	drainCmd.Flags().Int("port", DefaultServerPort, "Port number")
 
 	resumeCmd.Flags().Int("port", DefaultServerPort, "Port number")
 	resumeCmd.Flags().Bool("from-failure", false, "Reuse from failure")
+	resumeCmd.Flags().String("manifest", "",
+		"Clone manifest file (start --clone-manifest) to resume the replication from when the recovery data is lost")
+	resumeCmd.Flags().String("namespace", "",
+		"Resume the namespaces paused with the pattern (pause --namespace)")
+
+	approveDDLCmd.Flags().Int("port", DefaultServerPort, "Port number")
+
+	reauthCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	reauthCmd.Flags().String("source", "",
+		"MongoDB connection string for the source with the new credentials (default: $PCSM_SOURCE_URI)")
+
+	replayDeadLetterCmd.Flags().Int("port", DefaultServerPort, "Port number")
+	replayDeadLetterCmd.Flags().String("namespace", "",
+		"Dead-letter namespace (default: the namespace set on start)")
 
 	finalizeCmd.Flags().Int("port", DefaultServerPort, "Port number")
 	finalizeCmd.Flags().Bool("ignore-history-lost", false, "Ignore history lost error")
 	finalizeCmd.Flags().MarkHidden("ignore-history-lost") //nolint:errcheck
+	finalizeCmd.Flags().Bool("lock-source-on-finalize", false,
+		"Lock the source writes with fsyncLock on finalize. The source stays read-only until fsyncUnlock")
+
+	for _, cmd := range []*cobra.Command{
+		statusCmd, waitCmd, tailCmd, statsCmd, planCmd, startCmd, finalizeCmd, pauseCmd,
+		drainCmd, resumeCmd, approveDDLCmd, reauthCmd, replayDeadLetterCmd,
+	} {
+		cmd.Flags().String("token", "", "Token of the HTTP API (default: $"+APITokenEnvVar+")")
+		cmd.Flags().String("api-tls-ca", "", "CA file to verify the HTTPS certificate of the HTTP API")
+		cmd.Flags().String("api-tls-cert", "", "Client certificate file for the HTTPS API")
+		cmd.Flags().String("api-tls-key", "", "Client key file for the HTTPS API")
+	}
 
 	resetCmd.Flags().String("target", "", "MongoDB connection string for the target")
+	resetCmd.Flags().String("state-file", "", "Reset the recovery data of the file state backend instead")
 
 	resetCmd.AddCommand(resetRecoveryCmd, resetHeartbeatCmd)
+
+	validateFiltersCmd.Flags().String("source", "", "MongoDB connection string for the source")
+	validateFiltersCmd.Flags().StringSlice("include-namespaces", nil,
+		"Namespaces to include (e.g. db1.collection1,db2.*)")
+	validateFiltersCmd.Flags().StringSlice("exclude-namespaces", nil,
+		"Namespaces to exclude (e.g. db3.collection3,db4.*)")
+	validateFiltersCmd.Flags().StringSlice("include-file", nil,
+		"File of the namespaces to include: one per line, # for comments")
+	validateFiltersCmd.Flags().StringSlice("exclude-file", nil,
+		"File of the namespaces to exclude: one per line, # for comments")
+
+	for _, cmd := range []*cobra.Command{stateExportCmd, stateImportCmd} {
+		cmd.Flags().String("target", "", "MongoDB connection string for the target with the recovery data")
+		cmd.Flags().String("state-file", "", "Recovery data file of the file state backend instead of the target")
+	}
+
+	stateExportCmd.Flags().String("file", "", "Export file (default: stdout)")
+	stateImportCmd.Flags().String("file", "", "Export file of the state-export command")
+	stateImportCmd.Flags().Bool("force", false, "Replace the existing recovery data")
+
+	testConnectionCmd.Flags().String("source", "", "MongoDB connection string for the source")
+	testConnectionCmd.Flags().String("target", "", "MongoDB connection string for the target")
+
+	replayCmd.Flags().String("target", "", "MongoDB connection string for the target")
+	replayCmd.Flags().String("from-file", "",
+		"JSON Lines file of the dead-letter entries (e.g. exported with mongoexport)")
+
+	verifyCmd.Flags().String("source", "", "MongoDB connection string for the source")
+	verifyCmd.Flags().String("target", "", "MongoDB connection string for the target")
+	verifyCmd.Flags().StringSlice("include-namespaces", nil,
+		"Namespaces to include (e.g. db1.collection1,db2.*)")
+	verifyCmd.Flags().StringSlice("exclude-namespaces", nil,
+		"Namespaces to exclude (e.g. db3.collection3,db4.*)")
+	verifyCmd.Flags().String("checkpoint-file", "",
+		"File of the verified ranges to resume a stopped verification from")
+	verifyCmd.Flags().Int("verify-batch-size", config.VerifyBatchSize,
+		"Number of documents of a range compared at once")
+	verifyCmd.Flags().Int("verify-rate-limit", 0,
+		"Maximum number of documents per second read from the source and from the target (0 is unlimited)")
+	verifyCmd.Flags().String("checksum-algorithm", string(pcsm.ChecksumXXHash),
+		"Checksum algorithm of the compared ranges: xxhash (fastest), md5, or sha256 (collision resistant)")
+
 	rootCmd.AddCommand(
 		versionCmd,
 		statusCmd,
+		waitCmd,
+		tailCmd,
+		statsCmd,
+		planCmd,
 		startCmd,
 		finalizeCmd,
 		pauseCmd,
+		drainCmd,
 		resumeCmd,
+		approveDDLCmd,
+		reauthCmd,
+		replayDeadLetterCmd,
+		replayCmd,
 		resetCmd,
+		testConnectionCmd,
+		validateFiltersCmd,
+		verifyCmd,
+		stateExportCmd,
+		stateImportCmd,
 	)
 
 	err := rootCmd.Execute()
@@ -411,7 +1423,13 @@ func main() {
 	}
 }
 
-func resetState(ctx context.Context, targetURI string) error {
+// resetState deletes the heartbeat and the recovery data. With the state file, the recovery data
+// is deleted from the file and there is no heartbeat to delete.
+func resetState(ctx context.Context, targetURI, stateFile string) error {
+	if stateFile != "" {
+		return errors.Wrap(DeleteRecoveryData(ctx, newFileStateStore(stateFile)), "delete recovery data")
+	}
+
 	target, err := topo.Connect(ctx, targetURI)
 	if err != nil {
 		return errors.Wrap(err, "connect")
@@ -429,9 +1447,9 @@ func resetState(ctx context.Context, targetURI string) error {
 		return errors.Wrap(err, "delete heartbeat")
 	}
 
-	err = DeleteRecoveryData(ctx, target)
+	err = DeleteRecoveryData(ctx, mongoStateStore{target})
 	if err != nil {
-		return errors.Wrap(err, "delete heartbeat")
+		return errors.Wrap(err, "delete recovery data")
 	}
 
 	return nil
@@ -443,6 +1461,49 @@ type serverOptions struct {
 	targetURI string
 	start     bool
 	pause     bool
+
+	// targetEncryption enables the automatic encryption on the target client if set.
+	targetEncryption *topo.EncryptionOptions
+
+	// progressCollection is the collection of the PCSM database on the target
+	// to write the progress snapshots to. Empty disables the snapshots.
+	progressCollection string
+	// progressInterval is the interval of the progress snapshots.
+	progressInterval time.Duration
+	// reportInterval is the interval of the progress summary logged by the server.
+	// Zero disables the summary.
+	reportInterval time.Duration
+
+	// statsdAddress is the address (host:port) of the StatsD server to send the metrics to.
+	// Empty disables the StatsD export.
+	statsdAddress string
+	// notifyWebhook is the URL to post the notifications of the key transitions to.
+	// Empty disables the notifications.
+	notifyWebhook string
+
+	// apiToken is the bearer token required by the HTTP API. Empty disables the authentication.
+	apiToken string
+
+	// apiTLSCert and apiTLSKey are the certificate and key files to serve the HTTP API
+	// over HTTPS. Empty serves HTTP.
+	apiTLSCert string
+	apiTLSKey  string
+	// apiTLSClientCA is the CA file to verify the client certificates (mutual TLS).
+	apiTLSClientCA string
+
+	// targetURIs are the connection strings of the additional targets of the fan-out replication.
+	targetURIs []string
+	// pauseOnTargetFailure pauses the replication to all targets when one target fails.
+	pauseOnTargetFailure bool
+
+	// stateBackend is the storage of the recovery data (mongodb or file).
+	stateBackend string
+	// stateFile is the file of the recovery data for the file state backend.
+	stateFile string
+
+	// maxMemory is the limit in bytes of the clone read batches and the queued change events
+	// of all targets. Zero does not limit the memory.
+	maxMemory int64
 }
 
 func (s serverOptions) validate() error {
@@ -461,6 +1522,58 @@ func (s serverOptions) validate() error {
 		return errors.New("source URI and target URI are identical")
 	}
 
+	if s.progressCollection != "" {
+		if s.progressInterval <= 0 {
+			return errors.New("progress interval must be positive")
+		}
+
+		ns := pcsm.Namespace{Database: config.PCSMDatabase, Collection: s.progressCollection}
+
+		err := ns.ValidateLength()
+		if err != nil {
+			return errors.Wrap(err, "progress collection")
+		}
+	}
+
+	if s.reportInterval < 0 {
+		return errors.New("report interval must not be negative")
+	}
+
+	if s.notifyWebhook != "" {
+		u, err := url.Parse(s.notifyWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("invalid notify webhook URL %q", s.notifyWebhook)
+		}
+	}
+
+	for i, uri := range s.targetURIs {
+		if uri == s.sourceURI || uri == s.targetURI || slices.Contains(s.targetURIs[:i], uri) {
+			return errors.Errorf("target URI #%d is identical to the source or another target URI", i+1)
+		}
+	}
+
+	if (s.apiTLSCert == "") != (s.apiTLSKey == "") {
+		return errors.New("api tls certificate and key must be set together")
+	}
+
+	if s.apiTLSClientCA != "" && s.apiTLSCert == "" {
+		return errors.New("api tls client CA requires the api tls certificate")
+	}
+
+	switch s.stateBackend {
+	case stateBackendMongoDB:
+	case stateBackendFile:
+		if s.stateFile == "" {
+			return errors.New("file state backend requires the state file")
+		}
+
+		if len(s.targetURIs) != 0 {
+			return errors.New("file state backend does not support additional target URIs")
+		}
+	default:
+		return errors.Errorf("invalid state backend %q", s.stateBackend)
+	}
+
 	return nil
 }
 
@@ -471,18 +1584,28 @@ func runServer(ctx context.Context, options serverOptions) error {
 		return errors.Wrap(err, "validate options")
 	}
 
+	var tlsConfig *tls.Config
+	if options.apiTLSCert != "" {
+		tlsConfig, err = apiServerTLSConfig(options.apiTLSCert, options.apiTLSKey, options.apiTLSClientCA)
+		if err != nil {
+			return errors.Wrap(err, "api tls")
+		}
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer stop()
 
-	srv, err := createServer(ctx, options.sourceURI, options.targetURI)
+	srv, err := createServer(ctx, options)
 	if err != nil {
 		return errors.Wrap(err, "new server")
 	}
 
 	if options.start && srv.pcsm.Status(ctx).State == pcsm.StateIdle {
-		err = srv.pcsm.Start(ctx, &pcsm.StartOptions{
-			PauseOnInitialSync: options.pause,
-		})
+		err = srv.fanOutOrUndo(
+			func(p *pcsm.PCSM) error {
+				return p.Start(ctx, &pcsm.StartOptions{PauseOnInitialSync: options.pause})
+			},
+			func(p *pcsm.PCSM) error { return stopStarted(ctx, p) })
 		if err != nil {
 			log.New("cli").Error(err, "Failed to start Cluster Replication")
 		}
@@ -506,6 +1629,14 @@ func runServer(ctx context.Context, options serverOptions) error {
 
 		ReadTimeout:       ServerReadTimeout,
 		ReadHeaderTimeout: ServerReadHeaderTimeout,
+
+		TLSConfig: tlsConfig,
+	}
+
+	if tlsConfig != nil {
+		log.Ctx(ctx).Info("Starting HTTPS server at https://" + addr)
+
+		return httpServer.ListenAndServeTLS("", "") //nolint:wrapcheck
 	}
 
 	log.Ctx(ctx).Info("Starting HTTP server at http://" + addr)
@@ -517,6 +1648,12 @@ func runServer(ctx context.Context, options serverOptions) error {
 type server struct {
 	// sourceCluster is the MongoDB client for the source cluster.
 	sourceCluster *mongo.Client
+	// sourceURI is the connection string of the source cluster at the server start.
+	sourceURI string
+	// reauthSourceURI is the connection string of the source of the last reauth.
+	reauthSourceURI string
+	// sourceLock guards sourceCluster and reauthSourceURI replaced on reauth.
+	sourceLock sync.Mutex
 	// targetCluster is the MongoDB client for the target cluster.
 	targetCluster *mongo.Client
 	// pcsm is the PCSM instance for cluster replication.
@@ -526,12 +1663,26 @@ type server struct {
 
 	// promRegistry is the Prometheus registry for metrics.
 	promRegistry *prometheus.Registry
+
+	// apiToken is the bearer token required by the HTTP API.
+	apiToken string
+
+	// targets are the additional targets of the fan-out replication.
+	targets []*replicationTarget
+	// pauseOnTargetFailure pauses the replication to all targets when one target fails.
+	pauseOnTargetFailure bool
+
+	// memory is the budget of the clone read batches and the queued change events shared
+	// by all targets. Nil if the memory is not limited.
+	memory *pcsm.MemoryBudget
 }
 
 // createServer creates a new server with the given options.
-func createServer(ctx context.Context, sourceURI, targetURI string) (*server, error) {
+func createServer(ctx context.Context, options serverOptions) (*server, error) {
 	lg := log.Ctx(ctx)
 
+	sourceURI, targetURI := options.sourceURI, options.targetURI
+
 	source, err := topo.Connect(ctx, sourceURI)
 	if err != nil {
 		return nil, errors.Wrap(err, "connect to source cluster")
@@ -559,6 +1710,7 @@ func createServer(ctx context.Context, sourceURI, targetURI string) (*server, er
 
 	target, err := topo.ConnectWithOptions(ctx, targetURI, &topo.ConnectOptions{
 		Compressors: config.UseTargetClientCompressors(),
+		Encryption:  options.targetEncryption,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "connect to target cluster")
@@ -584,38 +1736,98 @@ func createServer(ctx context.Context, sourceURI, targetURI string) (*server, er
 	lg.Infof("Connected to target cluster [%s]: %s://%s",
 		targetVersion.FullString(), cs.Scheme, strings.Join(cs.Hosts, ","))
 
-	stopHeartbeat, err := RunHeartbeat(ctx, target)
-	if err != nil {
-		return nil, errors.Wrap(err, "heartbeat")
+	if enc := options.targetEncryption; enc != nil {
+		for _, ns := range slices.Sorted(maps.Keys(enc.SchemaMap)) {
+			lg.Infof("Automatic encryption on target %q: %s",
+				ns, strings.Join(enc.EncryptedFields(ns), ", "))
+		}
 	}
 
-	promRegistry := prometheus.NewRegistry()
-	metrics.Init(promRegistry)
+	var state StateStore = mongoStateStore{target}
 
-	pcs := pcsm.New(source, target)
+	stopHeartbeat := StopHeartbeat(func(context.Context) error { return nil })
 
-	err = Restore(ctx, target, pcs)
-	if err != nil {
-		return nil, errors.Wrap(err, "recover PCSM")
+	// the file state backend does not write to the target PCSM database: no heartbeat
+	if options.stateBackend == stateBackendFile {
+		lg.Infof("Recovery data is stored in %q", options.stateFile)
+
+		state = newFileStateStore(options.stateFile)
+	} else {
+		stopHeartbeat, err = RunHeartbeat(ctx, target)
+		if err != nil {
+			return nil, errors.Wrap(err, "heartbeat")
+		}
 	}
 
-	pcs.SetOnStateChanged(func(newState pcsm.State) {
-		err := DoCheckpoint(ctx, target, pcs)
+	promRegistry := prometheus.NewRegistry()
+	metrics.Init(promRegistry)
+
+	if options.statsdAddress != "" {
+		err = metrics.RunStatsD(ctx, options.statsdAddress, promRegistry)
 		if err != nil {
-			log.New("http:checkpointing").Error(err, "checkpoint")
-		} else {
-			log.New("http:checkpointing").Debugf("Checkpoint saved on %q", newState)
+			return nil, errors.Wrap(err, "statsd")
 		}
+	}
+
+	memory := pcsm.NewMemoryBudget(options.maxMemory)
+
+	pcs := pcsm.New(source, target, pcsm.Options{
+		// the client-level bulk write does not support automatic encryption
+		UseCollectionBulkWrite: options.targetEncryption != nil,
+		Memory:                 memory,
 	})
 
-	go RunCheckpointing(ctx, target, pcs)
+	err = Restore(ctx, state, pcs)
+	if err != nil {
+		return nil, errors.Wrap(err, "recover PCSM")
+	}
 
 	s := &server{
 		sourceCluster: source,
+		sourceURI:     sourceURI,
 		targetCluster: target,
 		pcsm:          pcs,
 		stopHeartbeat: stopHeartbeat,
 		promRegistry:  promRegistry,
+		apiToken:      options.apiToken,
+
+		pauseOnTargetFailure: options.pauseOnTargetFailure,
+		memory:               memory,
+	}
+
+	pcs.SetOnStateChanged(s.onTargetStateChanged(ctx, state, pcs))
+
+	go RunCheckpointing(ctx, state, pcs)
+
+	for _, uri := range options.targetURIs {
+		var t *replicationTarget
+
+		t, err = connectReplicationTarget(ctx, source, uri, memory, options)
+		if err != nil {
+			for _, t := range s.targets {
+				_ = util.CtxWithTimeout(ctx, config.DisconnectTimeout, t.close)
+			}
+
+			return nil, errors.Wrap(err, "additional target")
+		}
+
+		t.pcsm.SetOnStateChanged(s.onTargetStateChanged(ctx, mongoStateStore{t.cluster}, t.pcsm))
+		s.targets = append(s.targets, t)
+	}
+
+	if options.progressCollection != "" {
+		err = RunProgress(ctx, target, options.progressCollection, options.progressInterval, pcs)
+		if err != nil {
+			return nil, errors.Wrap(err, "progress")
+		}
+	}
+
+	if options.reportInterval > 0 {
+		RunProgressReport(ctx, options.reportInterval, pcs)
+	}
+
+	if options.notifyWebhook != "" {
+		RunPhaseNotifier(ctx, options.notifyWebhook, pcs)
 	}
 
 	return s, nil
@@ -624,10 +1836,15 @@ func createServer(ctx context.Context, sourceURI, targetURI string) (*server, er
 // Close stops heartbeat and closes the server connections.
 func (s *server) Close(ctx context.Context) error {
 	err0 := s.stopHeartbeat(ctx)
-	err1 := s.sourceCluster.Disconnect(ctx)
+	err1 := s.source().Disconnect(ctx)
 	err2 := s.targetCluster.Disconnect(ctx)
 
-	return errors.Join(err0, err1, err2)
+	errs := []error{err0, err1, err2}
+	for _, t := range s.targets {
+		errs = append(errs, errors.Wrap(t.close(ctx), "target "+t.name))
+	}
+
+	return errors.Join(errs...)
 }
 
 // Handler returns the HTTP handler for the server.
@@ -635,22 +1852,60 @@ func (s *server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/stats", s.handleStats)
 	mux.HandleFunc("/start", s.handleStart)
+	mux.HandleFunc("/schema/start", s.handleStartSchema)
 	mux.HandleFunc("/finalize", s.handleFinalize)
 	mux.HandleFunc("/pause", s.handlePause)
+	mux.HandleFunc("/drain", s.handleDrain)
 	mux.HandleFunc("/resume", s.handleResume)
+	mux.HandleFunc("/approve-ddl", s.handleApproveDDL)
+	mux.HandleFunc("/reauth", s.handleReauth)
+	mux.HandleFunc("/replay-dead-letter", s.handleReplayDeadLetter)
+	mux.HandleFunc("/plan/detailed", s.handlePlanDetailed)
+	mux.HandleFunc("/tail", s.handleTail)
 	mux.Handle("/metrics", s.handleMetrics())
 
+	handler := requireAPIToken(s.apiToken, mux)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/metrics" {
 			log.New("http").Trace(r.Method + " " + r.URL.String())
 		} else {
 			log.New("http").Info(r.Method + " " + r.URL.String())
 		}
-		mux.ServeHTTP(w, r)
+		handler.ServeHTTP(w, r)
 	})
 }
 
+// statusInfo returns the description of the replication phase.
+func statusInfo(status *pcsm.Status) string {
+	switch {
+	case status.State == pcsm.StateRunning && !status.Clone.IsFinished():
+		return "Initial Sync: Cloning Data"
+	case status.State == pcsm.StateRunning && !status.InitialSyncCompleted:
+		return "Initial Sync: Replicating Changes"
+	case status.State == pcsm.StateRunning:
+		return "Replicating Changes"
+	case status.State == pcsm.StateDegraded:
+		return "Degraded: Retrying Change Replication"
+	case status.State == pcsm.StatePaused && status.Repl.PendingDDL != nil:
+		return "Paused: DDL change is pending approval"
+	case status.State == pcsm.StatePaused && status.Budget != nil && status.Budget.Exceeded:
+		return "Paused: Max total duration exceeded"
+	case status.State == pcsm.StatePaused && status.Clone.Drained:
+		return "Paused: Data Clone drained"
+	case status.State == pcsm.StateFinalizing:
+		return "Finalizing"
+	case status.State == pcsm.StateFinalized:
+		return "Finalized"
+	case status.State == pcsm.StateFailed:
+		return "Failed"
+	}
+
+	return ""
+}
+
 // handleStatus handles the /status endpoint.
 func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
@@ -669,64 +1924,664 @@ func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
 			http.StatusText(http.StatusRequestEntityTooLarge),
 			http.StatusRequestEntityTooLarge)
 
-		return
-	}
+		return
+	}
+
+	status := s.pcsm.Status(ctx)
+
+	res := statusResponse{
+		Ok:    status.Error == nil,
+		State: status.State,
+	}
+
+	for _, t := range s.targets {
+		res.Targets = append(res.Targets, newStatusTargetResponse(t.name, t.pcsm.Status(ctx)))
+	}
+
+	if s.memory != nil {
+		res.Memory = &statusMemoryResponse{
+			UsedBytes:  s.memory.Used(),
+			LimitBytes: s.memory.Limit(),
+		}
+	}
+
+	if err := status.Error; err != nil {
+		res.Err = err.Error()
+	}
+
+	if status.State == pcsm.StateIdle {
+		writeResponse(w, res)
+
+		return
+	}
+
+	res.EventsProcessed = status.Repl.EventsProcessed
+	res.DeadLettered = status.Repl.DeadLettered
+	res.LagTime = status.TotalLagTime
+
+	if !status.Repl.LastReplicatedOpTime.IsZero() {
+		res.LastReplicatedOpTime = fmt.Sprintf("%d.%d",
+			status.Repl.LastReplicatedOpTime.T,
+			status.Repl.LastReplicatedOpTime.I)
+	}
+
+	res.PausedNamespaces = status.Repl.PausedNamespaces
+	res.HeldChanges = status.Repl.HeldChanges
+
+	if ddl := status.Repl.PendingDDL; ddl != nil {
+		res.PendingDDL = &statusPendingDDLResponse{
+			OperationType: string(ddl.OperationType),
+			Namespace:     ddl.Namespace.String(),
+			ClusterTime:   fmt.Sprintf("%d.%d", ddl.ClusterTime.T, ddl.ClusterTime.I),
+		}
+	}
+
+	res.InitialSync = &statusInitialSyncResponse{
+		Completed: status.InitialSyncCompleted,
+		LagTime:   status.InitialSyncLagTime,
+
+		CloneCompleted:     status.Clone.IsFinished(),
+		EstimatedCloneSize: status.Clone.EstimatedTotalSize,
+		ClonedSize:         status.Clone.CopiedSize,
+	}
+
+	for ns, err := range status.Clone.Skipped {
+		if res.InitialSync.SkippedCollections == nil {
+			res.InitialSync.SkippedCollections = make(map[string]string, len(status.Clone.Skipped))
+		}
+
+		res.InitialSync.SkippedCollections[ns.String()] = err
+	}
+
+	for _, build := range status.IndexBuilds {
+		res.IndexBuildProgress = append(res.IndexBuildProgress, statusIndexBuildResponse{
+			Namespace: build.Namespace,
+			Index:     build.Index,
+			Phase:     build.Msg,
+			Done:      build.Done,
+			Total:     build.Total,
+			Percent:   math.Round(build.Percent()*100) / 100, //nolint:mnd
+		})
+	}
+
+	if params := status.ClusterParameters; params != nil {
+		res.ClusterParameters = &statusClusterParametersResponse{
+			Copied:  params.Copied,
+			Skipped: params.Skipped,
+		}
+	}
+
+	if budget := status.Budget; budget != nil {
+		res.Budget = &statusBudgetResponse{
+			MaxTotalDuration: budget.MaxTotalDuration.String(),
+			Deadline:         budget.Deadline.UTC().Format(time.RFC3339),
+			RemainingSeconds: max(int64(time.Until(budget.Deadline).Seconds()), 0),
+			Action:           string(budget.Action),
+			Exceeded:         budget.Exceeded,
+		}
+	}
+
+	res.Info = statusInfo(status)
+
+	writeResponse(w, res)
+}
+
+// handleStats handles the /stats endpoint.
+func (s *server) handleStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodGet {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	stats := s.pcsm.Stats(ctx)
+
+	res := statsResponse{
+		Ok:              true,
+		Elapsed:         int64(stats.Elapsed.Seconds()),
+		ClonedDocuments: stats.ClonedDocuments,
+		ClonedSize:      stats.ClonedSize,
+		EventsApplied:   stats.EventsApplied,
+		Retries:         stats.Retries,
+		Errors:          stats.Errors,
+		AvgThroughput:   math.Round(stats.AvgThroughput*100) / 100,  //nolint:mnd
+		PeakThroughput:  math.Round(stats.PeakThroughput*100) / 100, //nolint:mnd
+	}
+
+	if !stats.StartTime.IsZero() {
+		res.StartTime = stats.StartTime.UTC().Format(time.RFC3339)
+	}
+
+	writeResponse(w, res)
+}
+
+// handleStart handles the /start endpoint.
+func (s *server) handleStart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodPost {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	var params startRequest
+
+	if r.ContentLength != 0 {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError)
+
+			return
+		}
+
+		schemaErrs := validateRequest(startRequestSchema, data)
+		if len(schemaErrs) != 0 {
+			msgs := make([]string, len(schemaErrs))
+			for i, e := range schemaErrs {
+				msgs[i] = e.Error()
+			}
+
+			writeResponse(w, startResponse{
+				Err:              "invalid request: " + strings.Join(msgs, "; "),
+				ValidationErrors: schemaErrs,
+			})
+
+			return
+		}
+
+		err = json.Unmarshal(data, &params)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusBadRequest),
+				http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if params.MinOplogHours < 0 {
+		writeResponse(w, startResponse{Err: "minOplogHours must not be negative"})
+
+		return
+	}
+
+	if params.CloneBatchBytes < 0 || params.CloneBatchBytes > config.MaxWriteBatchSizeBytes {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid cloneBatchBytes: %d (max %d)",
+			params.CloneBatchBytes, config.MaxWriteBatchSizeBytes)})
+
+		return
+	}
+
+	if params.CloneMaxInflightBatches < 0 {
+		writeResponse(w, startResponse{Err: "cloneMaxInflightBatches must not be negative"})
+
+		return
+	}
+
+	if params.CopyMarkerField != "" {
+		err := pcsm.ValidateMarkerField(params.CopyMarkerField)
+		if err != nil {
+			writeResponse(w, startResponse{Err: "invalid copyMarkerField: " + err.Error()})
+
+			return
+		}
+	}
+
+	for ns, n := range params.CappedTail {
+		if n <= 0 {
+			writeResponse(w, startResponse{Err: fmt.Sprintf("cappedTail for %q must be positive", ns)})
+
+			return
+		}
+	}
+
+	cloneDependencies, err := pcsm.ParseCloneDependencies(params.Depends)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	cloneSnapshot, err := pcsm.ParseCloneSnapshotMode(params.CloneSnapshot)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	cloneOrder, err := pcsm.ParseCloneOrder(params.CloneOrder)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	cloneReadConcern, err := pcsm.ParseCloneReadConcern(params.CloneReadConcern)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	var startAt bson.Timestamp
+	if params.StartFromBackupTimestamp != "" {
+		startAt, err = topo.ParseTimestamp(params.StartFromBackupTimestamp)
+		if err != nil {
+			writeResponse(w, startResponse{Err: "invalid startFromBackupTimestamp: " + err.Error()})
+
+			return
+		}
+	}
+
+	onKeyTooLong, err := pcsm.ParseKeyTooLongAction(params.OnKeyTooLong)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onStaleEvents, err := pcsm.ParseStaleEventsAction(params.OnStaleEvents)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onNestingExceeded, err := pcsm.ParseNestingExceededAction(params.OnNestingExceeded)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onUnknownEvent, err := pcsm.ParseUnknownEventAction(params.OnUnknownEvent)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onBudgetExceeded, err := pcsm.ParseBudgetAction(params.OnBudgetExceeded)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onCollectionError, err := pcsm.ParseCollectionErrorAction(params.OnCollectionError)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onSchemaDrift, err := pcsm.ParseSchemaDriftAction(params.OnSchemaDrift)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	onOplogLost, err := pcsm.ParseOplogLostAction(params.OnOplogLost)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	replicationMethod, err := pcsm.ParseReplicationMethod(params.ReplicationMethod)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	var maxClockSkew time.Duration
+	if params.MaxClockSkew != "" {
+		maxClockSkew, err = time.ParseDuration(params.MaxClockSkew)
+		if err != nil || maxClockSkew < 0 {
+			writeResponse(w, startResponse{Err: "invalid maxClockSkew: " + params.MaxClockSkew})
+
+			return
+		}
+	}
+
+	var applyOpTimeout time.Duration
+	if params.ApplyOpTimeout != "" {
+		applyOpTimeout, err = time.ParseDuration(params.ApplyOpTimeout)
+		if err != nil || applyOpTimeout < 0 {
+			writeResponse(w, startResponse{Err: "invalid applyOpTimeout: " + params.ApplyOpTimeout})
+
+			return
+		}
+	}
+
+	var failureGracePeriod time.Duration
+	if params.FailureGracePeriod != "" {
+		failureGracePeriod, err = time.ParseDuration(params.FailureGracePeriod)
+		if err != nil || failureGracePeriod < 0 {
+			writeResponse(w, startResponse{Err: "invalid failureGracePeriod: " + params.FailureGracePeriod})
+
+			return
+		}
+	}
+
+	var maxEventAge time.Duration
+	if params.MaxEventAge != "" {
+		maxEventAge, err = time.ParseDuration(params.MaxEventAge)
+		if err != nil || maxEventAge < 0 {
+			writeResponse(w, startResponse{Err: "invalid maxEventAge: " + params.MaxEventAge})
+
+			return
+		}
+	}
+
+	var maxTotalDuration time.Duration
+	if params.MaxTotalDuration != "" {
+		maxTotalDuration, err = time.ParseDuration(params.MaxTotalDuration)
+		if err != nil || maxTotalDuration < 0 {
+			writeResponse(w, startResponse{Err: "invalid maxTotalDuration: " + params.MaxTotalDuration})
+
+			return
+		}
+	}
+
+	if params.DedupWindow < 0 {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid dedupWindow: %d", params.DedupWindow)})
+
+		return
+	}
+
+	if params.DDLWorkers < 0 {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid ddlWorkers: %d", params.DDLWorkers)})
+
+		return
+	}
+
+	if strings.ContainsAny(params.RenameCollisionSuffix, pcsm.InvalidCollectionNameChars) {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid renameCollisionSuffix: %q",
+			params.RenameCollisionSuffix)})
+
+		return
+	}
+
+	if strings.ContainsAny(params.TargetNamespacePrefix, pcsm.InvalidDatabaseNameChars) {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid targetNamespacePrefix: %q",
+			params.TargetNamespacePrefix)})
+
+		return
+	}
+
+	if params.MaxDocRetries < 0 {
+		writeResponse(w, startResponse{Err: fmt.Sprintf("invalid maxDocRetries: %d", params.MaxDocRetries)})
+
+		return
+	}
+
+	if params.CloneManifest != "" && len(s.targets) != 0 {
+		writeResponse(w, startResponse{Err: "cloneManifest: not supported with additional targets"})
+
+		return
+	}
+
+	_, err = pcsm.ParseInternalNamespaces(params.InternalNamespaces)
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error()})
+
+		return
+	}
+
+	var changeStreamBatchSize int32
+	if params.ChangeStreamBatchSize != nil {
+		changeStreamBatchSize = *params.ChangeStreamBatchSize
+		if changeStreamBatchSize <= 0 {
+			writeResponse(w, startResponse{
+				Err: fmt.Sprintf("invalid changeStreamBatchSize: %d", changeStreamBatchSize),
+			})
+
+			return
+		}
+	}
+
+	var changeStreamMaxAwaitTime time.Duration
+	if params.ChangeStreamMaxAwaitTime != "" {
+		changeStreamMaxAwaitTime, err = time.ParseDuration(params.ChangeStreamMaxAwaitTime)
+		if err != nil || changeStreamMaxAwaitTime <= 0 {
+			writeResponse(w, startResponse{
+				Err: "invalid changeStreamMaxAwaitTime: " + params.ChangeStreamMaxAwaitTime,
+			})
+
+			return
+		}
+	}
+
+	options := &pcsm.StartOptions{
+		PauseOnInitialSync: params.PauseOnInitialSync,
+		IncludeNamespaces:  params.IncludeNamespaces,
+		ExcludeNamespaces:  params.ExcludeNamespaces,
+		ReplicateInclude:   params.ReplicateInclude,
+		ReplicateExclude:   params.ReplicateExclude,
+		MinOplogWindow:     time.Duration(params.MinOplogHours * float64(time.Hour)),
+		IgnoreOplogWindow:  params.IgnoreOplogWindow,
+		RequireMatchingFCV: params.RequireMatchingFCV,
+		StartAt:            startAt,
+
+		CloneNoCursorTimeout: params.CloneNoCursorTimeout,
+		CloneBatchBytes:      int(params.CloneBatchBytes),
+		CopyMarkerField:      params.CopyMarkerField,
+		CappedTail:           params.CappedTail,
+		CloneSnapshot:        cloneSnapshot,
+		CloneOrder:           cloneOrder,
+		CloneReadConcern:     cloneReadConcern,
+		Transforms:           params.Transforms,
+		MaxClockSkew:         maxClockSkew,
+		ReplicationMethod:    replicationMethod,
+		DeadLetterNamespace:  params.DeadLetterNamespace,
+		DDLAuditCollection:   params.DDLAuditCollection,
+		MaxDocRetries:        params.MaxDocRetries,
+		PauseOnDDL:           params.PauseOnDDL,
+		SkipDropDatabase:     params.ReplicateDropDatabase != nil && !*params.ReplicateDropDatabase,
+		ApplyOpTimeout:       applyOpTimeout,
+		FailureGracePeriod:   failureGracePeriod,
+		MaxEventAge:          maxEventAge,
+		OnStaleEvents:        onStaleEvents,
+		OnNestingExceeded:    onNestingExceeded,
+		OnUnknownEvent:       onUnknownEvent,
+		MaxTotalDuration:     maxTotalDuration,
+		OnBudgetExceeded:     onBudgetExceeded,
+		OnOplogLost:          onOplogLost,
+
+		ChangeStreamBatchSize:    changeStreamBatchSize,
+		ChangeStreamMaxAwaitTime: changeStreamMaxAwaitTime,
+		BypassDocumentValidation: params.BypassDocumentValidation,
+		DedupWindow:              params.DedupWindow,
+		DDLWorkers:               params.DDLWorkers,
+		PreserveTxnOrder:         params.PreserveTxnOrder,
+		UpdateAsUpsert:           params.UpdateAsUpsert,
+		CopyClusterParameters:    params.CopyClusterParameters,
+		OnKeyTooLong:             onKeyTooLong,
+		KeepTargetTTL:            params.ManageTTLDuringReplication != nil && !*params.ManageTTLDuringReplication,
+		ReplicateHiddenIndexes:   params.ReplicateHiddenIndexes,
+		RenameCollisionSuffix:    params.RenameCollisionSuffix,
+		TargetDatabasePrefix:     params.TargetNamespacePrefix,
+		CloneManifest:            params.CloneManifest,
+		InternalNamespaces:       params.InternalNamespaces,
+
+		DisableBalancerDuringClone: params.DisableBalancerDuringClone,
+		CloneMaxInflightBatches:    params.CloneMaxInflightBatches,
+		SkipEmptyCollections:       params.IncludeEmptyCollections != nil && !*params.IncludeEmptyCollections,
+		DiscoverNewCollections:     params.DiscoverNewCollections,
+		CloneDependencies:          cloneDependencies,
+		OnCollectionError:          onCollectionError,
+		OnSchemaDrift:              onSchemaDrift,
+	}
+
+	var warnings []string
+	if len(params.IncludeNamespaces) != 0 {
+		namespaces, err := pcsm.ListSourceNamespaces(ctx, s.source())
+		if err != nil {
+			writeResponse(w, startResponse{Err: errors.Wrap(err, "list source namespaces").Error()})
+
+			return
+		}
+
+		for _, pattern := range pcsm.UnmatchedIncludePatterns(namespaces, params.IncludeNamespaces) {
+			warnings = append(warnings, fmt.Sprintf("include pattern %q matches no source namespace", pattern))
+		}
+
+		if params.StrictNamespaces && len(warnings) != 0 {
+			writeResponse(w, startResponse{Err: "strictNamespaces: " + strings.Join(warnings, "; ")})
+
+			return
+		}
+
+		for _, warning := range warnings {
+			log.New("http").Warn(warning)
+		}
+	}
+
+	err = s.fanOutOrUndo(
+		func(p *pcsm.PCSM) error { return p.Start(ctx, options) },
+		func(p *pcsm.PCSM) error { return stopStarted(ctx, p) })
+	if err != nil {
+		writeResponse(w, startResponse{Err: err.Error(), Warnings: warnings})
+
+		return
+	}
+
+	writeResponse(w, startResponse{Ok: true, Warnings: warnings})
+}
+
+// handleStartSchema handles the /schema/start endpoint.
+func (s *server) handleStartSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(startRequestSchemaData) //nolint:errcheck
+}
+
+// handleFinalize handles the /finalize endpoint.
+func (s *server) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodPost {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	var params finalizeRequest
+
+	if r.ContentLength != 0 {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError)
+
+			return
+		}
 
-	status := s.pcsm.Status(ctx)
+		err = json.Unmarshal(data, &params)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusBadRequest),
+				http.StatusBadRequest)
 
-	res := statusResponse{
-		Ok:    status.Error == nil,
-		State: status.State,
+			return
+		}
 	}
 
-	if err := status.Error; err != nil {
-		res.Err = err.Error()
+	options := &pcsm.FinalizeOptions{
+		IgnoreHistoryLost: params.IgnoreHistoryLost,
+		LockSource:        params.LockSource,
+		SourceURI:         s.currentSourceURI(),
 	}
 
-	if status.State == pcsm.StateIdle {
-		writeResponse(w, res)
+	err := s.fanOut(func(p *pcsm.PCSM) error {
+		opts := *options
+		// the shared source is locked once, by the --target finalization that runs first
+		opts.LockSource = options.LockSource && p == s.pcsm
+		opts.SourceLocked = options.LockSource && p != s.pcsm
+
+		return p.Finalize(ctx, opts)
+	})
+	if err != nil {
+		writeResponse(w, finalizeResponse{Err: err.Error()})
 
 		return
 	}
 
-	res.EventsProcessed = status.Repl.EventsProcessed
-	res.LagTime = status.TotalLagTime
-
-	if !status.Repl.LastReplicatedOpTime.IsZero() {
-		res.LastReplicatedOpTime = fmt.Sprintf("%d.%d",
-			status.Repl.LastReplicatedOpTime.T,
-			status.Repl.LastReplicatedOpTime.I)
-	}
+	writeResponse(w, newFinalizeResponse(s.pcsm.Status(ctx)))
+}
 
-	res.InitialSync = &statusInitialSyncResponse{
-		Completed: status.InitialSyncCompleted,
-		LagTime:   status.InitialSyncLagTime,
+// newFinalizeResponse returns the response of a successful finalization with the cutover point:
+// the resume token and the cluster time of the last applied change.
+func newFinalizeResponse(status *pcsm.Status) finalizeResponse {
+	res := finalizeResponse{Ok: true}
 
-		CloneCompleted:     status.Clone.IsFinished(),
-		EstimatedCloneSize: status.Clone.EstimatedTotalSize,
-		ClonedSize:         status.Clone.CopiedSize,
+	if ts := status.Repl.LastReplicatedOpTime; !ts.IsZero() {
+		res.ClusterTime = fmt.Sprintf("%d.%d", ts.T, ts.I)
 	}
 
-	switch {
-	case status.State == pcsm.StateRunning && !status.Clone.IsFinished():
-		res.Info = "Initial Sync: Cloning Data"
-	case status.State == pcsm.StateRunning && !status.InitialSyncCompleted:
-		res.Info = "Initial Sync: Replicating Changes"
-	case status.State == pcsm.StateRunning:
-		res.Info = "Replicating Changes"
-	case status.State == pcsm.StateFinalizing:
-		res.Info = "Finalizing"
-	case status.State == pcsm.StateFinalized:
-		res.Info = "Finalized"
-	case status.State == pcsm.StateFailed:
-		res.Info = "Failed"
+	if len(status.Repl.LastReplicatedToken) != 0 {
+		token, err := bson.MarshalExtJSON(status.Repl.LastReplicatedToken, false, false)
+		if err != nil {
+			log.New("http").Warnf("Finalize: resume token: %v", err)
+		} else {
+			res.ResumeToken = token
+		}
 	}
 
-	writeResponse(w, res)
+	return res
 }
 
-// handleStart handles the /start endpoint.
-func (s *server) handleStart(w http.ResponseWriter, r *http.Request) {
+// handlePause handles the /pause endpoint.
+func (s *server) handlePause(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
 	defer cancel()
 
@@ -746,7 +2601,7 @@ func (s *server) handleStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params startRequest
+	var params pauseRequest
 
 	if r.ContentLength != 0 {
 		data, err := io.ReadAll(r.Body)
@@ -768,24 +2623,38 @@ func (s *server) handleStart(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	options := &pcsm.StartOptions{
-		PauseOnInitialSync: params.PauseOnInitialSync,
-		IncludeNamespaces:  params.IncludeNamespaces,
-		ExcludeNamespaces:  params.ExcludeNamespaces,
+	if params.Namespace != "" {
+		err := pcsm.ValidateNamespacePattern(params.Namespace)
+		if err != nil {
+			writeResponse(w, pauseResponse{Err: err.Error()})
+
+			return
+		}
+
+		err = s.fanOut(func(p *pcsm.PCSM) error { return p.PauseNamespace(ctx, params.Namespace) })
+		if err != nil {
+			writeResponse(w, pauseResponse{Err: err.Error()})
+
+			return
+		}
+
+		writeResponse(w, pauseResponse{Ok: true})
+
+		return
 	}
 
-	err := s.pcsm.Start(ctx, options)
+	err := s.fanOut(func(p *pcsm.PCSM) error { return p.Pause(ctx) })
 	if err != nil {
-		writeResponse(w, startResponse{Err: err.Error()})
+		writeResponse(w, pauseResponse{Err: err.Error()})
 
 		return
 	}
 
-	writeResponse(w, startResponse{Ok: true})
+	writeResponse(w, pauseResponse{Ok: true})
 }
 
-// handleFinalize handles the /finalize endpoint.
-func (s *server) handleFinalize(w http.ResponseWriter, r *http.Request) {
+// handleDrain handles the /drain endpoint.
+func (s *server) handleDrain(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
 	defer cancel()
 
@@ -805,7 +2674,38 @@ func (s *server) handleFinalize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params finalizeRequest
+	err := s.fanOut(func(p *pcsm.PCSM) error { return p.Drain(ctx) })
+	if err != nil {
+		writeResponse(w, drainResponse{Err: err.Error()})
+
+		return
+	}
+
+	writeResponse(w, drainResponse{Ok: true})
+}
+
+// handleResume handles the /resume endpoint.
+func (s *server) handleResume(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodPost {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	var params resumeRequest
 
 	if r.ContentLength != 0 {
 		data, err := io.ReadAll(r.Body)
@@ -827,22 +2727,48 @@ func (s *server) handleFinalize(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	options := &pcsm.FinalizeOptions{
-		IgnoreHistoryLost: params.IgnoreHistoryLost,
+	if params.Manifest != "" && len(s.targets) != 0 {
+		writeResponse(w, resumeResponse{Err: "manifest: not supported with additional targets"})
+
+		return
+	}
+
+	if params.Namespace != "" {
+		if params.FromFailure || params.Manifest != "" {
+			writeResponse(w, resumeResponse{Err: "namespace: cannot be used with fromFailure or manifest"})
+
+			return
+		}
+
+		err := s.fanOut(func(p *pcsm.PCSM) error { return p.ResumeNamespace(ctx, params.Namespace) })
+		if err != nil {
+			writeResponse(w, resumeResponse{Err: err.Error()})
+
+			return
+		}
+
+		writeResponse(w, resumeResponse{Ok: true})
+
+		return
 	}
 
-	err := s.pcsm.Finalize(ctx, *options)
+	options := &pcsm.ResumeOptions{
+		ResumeFromFailure: params.FromFailure,
+		Manifest:          params.Manifest,
+	}
+
+	err := s.fanOut(func(p *pcsm.PCSM) error { return p.Resume(ctx, *options) })
 	if err != nil {
-		writeResponse(w, finalizeResponse{Err: err.Error()})
+		writeResponse(w, resumeResponse{Err: err.Error()})
 
 		return
 	}
 
-	writeResponse(w, finalizeResponse{Ok: true})
+	writeResponse(w, resumeResponse{Ok: true})
 }
 
-// handlePause handles the /pause endpoint.
-func (s *server) handlePause(w http.ResponseWriter, r *http.Request) {
+// handleApproveDDL handles the /approve-ddl endpoint.
+func (s *server) handleApproveDDL(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
 	defer cancel()
 
@@ -862,18 +2788,18 @@ func (s *server) handlePause(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.pcsm.Pause(ctx)
+	err := s.fanOut(func(p *pcsm.PCSM) error { return p.ApproveDDL(ctx) })
 	if err != nil {
-		writeResponse(w, pauseResponse{Err: err.Error()})
+		writeResponse(w, approveDDLResponse{Err: err.Error()})
 
 		return
 	}
 
-	writeResponse(w, pauseResponse{Ok: true})
+	writeResponse(w, approveDDLResponse{Ok: true})
 }
 
-// handleResume handles the /resume endpoint.
-func (s *server) handleResume(w http.ResponseWriter, r *http.Request) {
+// handleReplayDeadLetter handles the /replay-dead-letter endpoint.
+func (s *server) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
 	defer cancel()
 
@@ -893,7 +2819,7 @@ func (s *server) handleResume(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params resumeRequest
+	var params replayDeadLetterRequest
 
 	if r.ContentLength != 0 {
 		data, err := io.ReadAll(r.Body)
@@ -915,18 +2841,25 @@ func (s *server) handleResume(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	options := &pcsm.ResumeOptions{
-		ResumeFromFailure: params.FromFailure,
-	}
+	resp := replayDeadLetterResponse{}
 
-	err := s.pcsm.Resume(ctx, *options)
-	if err != nil {
-		writeResponse(w, resumeResponse{Err: err.Error()})
+	err := s.fanOut(func(p *pcsm.PCSM) error {
+		res, err := p.ReplayDeadLetter(ctx, params.Namespace)
+		if res != nil {
+			resp.Replayed += res.Replayed
+			resp.Failed += res.Failed
+		}
 
-		return
+		return err
+	})
+
+	resp.Ok = err == nil
+
+	if err != nil {
+		resp.Err = err.Error()
 	}
 
-	writeResponse(w, resumeResponse{Ok: true})
+	writeResponse(w, resp)
 }
 
 func (s *server) handleMetrics() http.Handler {
@@ -952,6 +2885,154 @@ type startRequest struct {
 	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
 	// ExcludeNamespaces are the namespaces to exclude from the replication.
 	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// ReplicateInclude are the included namespaces whose changes are replicated.
+	// The other included namespaces are cloned only.
+	ReplicateInclude []string `json:"replicateInclude,omitempty"`
+	// ReplicateExclude are the included namespaces cloned without replicating their changes.
+	ReplicateExclude []string `json:"replicateExclude,omitempty"`
+	// StrictNamespaces fails the start if an include pattern matches no source namespace.
+	// Otherwise, the unmatched patterns are reported in the warnings of the response.
+	StrictNamespaces bool `json:"strictNamespaces,omitempty"`
+
+	// MinOplogHours is the minimum source oplog window in hours required to start.
+	MinOplogHours float64 `json:"minOplogHours,omitempty"`
+	// IgnoreOplogWindow indicates whether an insufficient oplog window is only reported.
+	IgnoreOplogWindow bool `json:"ignoreOplogWindow,omitempty"`
+	// RequireMatchingFCV fails the start if the target featureCompatibilityVersion is lower
+	// than the source.
+	RequireMatchingFCV bool `json:"requireMatchingFcv,omitempty"`
+
+	// CloneNoCursorTimeout disables the server idle timeout for the clone read cursors.
+	CloneNoCursorTimeout bool `json:"cloneNoCursorTimeout,omitempty"`
+	// CloneBatchBytes is the maximum size in bytes of a clone copy batch.
+	CloneBatchBytes int64 `json:"cloneBatchBytes,omitempty"`
+	// CloneMaxInflightBatches is the maximum number of the clone insert batches of a collection
+	// not inserted yet.
+	CloneMaxInflightBatches int `json:"cloneMaxInflightBatches,omitempty"`
+	// CopyMarkerField is the field stamped on the cloned documents to skip them on
+	// a restarted clone.
+	CopyMarkerField string `json:"copyMarkerField,omitempty"`
+	// CappedTail is the number of the most recent documents to clone per capped collection.
+	CappedTail map[string]int64 `json:"cappedTail,omitempty"`
+	// CloneSnapshot is the read consistency mode of the collection clone (session or none).
+	CloneSnapshot string `json:"cloneSnapshot,omitempty"`
+	// CloneOrder is the order in which the collections are cloned (largest-first or interleave).
+	CloneOrder string `json:"cloneOrder,omitempty"`
+	// CloneReadConcern is the read concern level of the collection clone reads
+	// (local, available, majority, or snapshot).
+	CloneReadConcern string `json:"cloneReadConcern,omitempty"`
+	// DisableBalancerDuringClone indicates whether to stop the balancer of the sharded target
+	// during the clone.
+	DisableBalancerDuringClone bool `json:"disableBalancerDuringClone,omitempty"`
+	// IncludeEmptyCollections indicates whether to create the empty collections on the target.
+	// Unset means true.
+	IncludeEmptyCollections *bool `json:"includeEmptyCollections,omitempty"`
+	// DiscoverNewCollections indicates whether to copy the collections created during the clone.
+	DiscoverNewCollections bool `json:"discoverNewCollections,omitempty"`
+	// Depends are the clone dependencies of the namespaces ("<ns>:<dependsOnNs>").
+	Depends []string `json:"depends,omitempty"`
+
+	// Transforms are the transforms applied to change events before apply.
+	Transforms []string `json:"transforms,omitempty"`
+	// MaxClockSkew is the tolerance of the future-dated values of the replicated documents
+	// (e.g. "5s").
+	MaxClockSkew string `json:"maxClockSkew,omitempty"`
+
+	// ReplicationMethod is the method of reading changes from the source
+	// (changestream, oplog, or auto).
+	ReplicationMethod string `json:"replicationMethod,omitempty"`
+
+	// DeadLetterNamespace is the target namespace to store the change events that fail to apply.
+	DeadLetterNamespace string `json:"deadLetterNamespace,omitempty"`
+	// MaxDocRetries is the number of retries of a change event that fails to apply
+	// before it is stored in the dead-letter collection.
+	MaxDocRetries int `json:"maxDocRetries,omitempty"`
+	// DDLAuditCollection is the target namespace to record the replicated DDL changes.
+	DDLAuditCollection string `json:"ddlAuditCollection,omitempty"`
+
+	// PauseOnDDL indicates whether to pause on DDL changes until approved.
+	PauseOnDDL bool `json:"pauseOnDDL,omitempty"`
+	// ReplicateDropDatabase indicates whether to apply the dropDatabase changes on the target.
+	// Defaults to true.
+	ReplicateDropDatabase *bool `json:"replicateDropDatabase,omitempty"`
+
+	// ApplyOpTimeout is the deadline of a single apply write to the target (e.g. "30s").
+	ApplyOpTimeout string `json:"applyOpTimeout,omitempty"`
+	// FailureGracePeriod is the period of the change replication failures retried as degraded
+	// before the replication fails (e.g. "5m").
+	FailureGracePeriod string `json:"failureGracePeriod,omitempty"`
+	// MaxEventAge is the maximum age of the first change event after a reconnect (e.g. "1h").
+	MaxEventAge string `json:"maxEventAge,omitempty"`
+	// OnStaleEvents is the handling of the change events older than MaxEventAge
+	// (warn, pause, or refuse).
+	OnStaleEvents string `json:"onStaleEvents,omitempty"`
+	// OnNestingExceeded is the handling of the documents nested deeper than the target limit
+	// (skip or fail).
+	OnNestingExceeded string `json:"onNestingExceeded,omitempty"`
+	// OnUnknownEvent is the handling of the change events of an unknown operation type
+	// (log, skip, or fail).
+	OnUnknownEvent string `json:"onUnknownEvent,omitempty"`
+	// MaxTotalDuration is the maximum time since the start to be finalized (e.g. "8h").
+	MaxTotalDuration string `json:"maxTotalDuration,omitempty"`
+	// OnBudgetExceeded is the handling of the replication not finalized within MaxTotalDuration
+	// (abort or pause).
+	OnBudgetExceeded string `json:"onBudgetExceeded,omitempty"`
+	// OnCollectionError is the handling of a collection that fails to clone (fail or skip).
+	OnCollectionError string `json:"onCollectionError,omitempty"`
+	// OnSchemaDrift is the handling of the source collections that exist on the target
+	// (adopt, error, or collmod).
+	OnSchemaDrift string `json:"onSchemaDrift,omitempty"`
+	// OnOplogLost is the handling of the change replication that falls off the source oplog
+	// (fail or reclone).
+	OnOplogLost string `json:"onOplogLost,omitempty"`
+
+	// ChangeStreamBatchSize is the batch size of the source change stream.
+	ChangeStreamBatchSize *int32 `json:"changeStreamBatchSize,omitempty"`
+	// ChangeStreamMaxAwaitTime is the maximum time the source change stream waits
+	// for new changes (e.g. "1s").
+	ChangeStreamMaxAwaitTime string `json:"changeStreamMaxAwaitTime,omitempty"`
+
+	// BypassDocumentValidation indicates whether to skip the document validation
+	// of the target collections on apply.
+	BypassDocumentValidation bool `json:"bypassDocumentValidation,omitempty"`
+	// DedupWindow is the number of the recent applied changes to skip when read again
+	// after a reconnect.
+	DedupWindow int `json:"dedupWindow,omitempty"`
+	// PreserveTxnOrder indicates whether to apply the operations of a transaction
+	// in one bulk write in the order of applyOps.
+	PreserveTxnOrder bool `json:"preserveOrderWithinTransaction,omitempty"`
+	// UpdateAsUpsert indicates whether to apply the updates as the upserts of the full document
+	// looked up by the change stream (updateLookup).
+	UpdateAsUpsert bool `json:"updateAsUpsert,omitempty"`
+	// DDLWorkers is the number of the DDL changes applied concurrently across namespaces.
+	DDLWorkers int `json:"ddlWorkers,omitempty"`
+	// CopyClusterParameters indicates whether to copy the cluster parameters of the source
+	// to the target before the clone.
+	CopyClusterParameters bool `json:"copyClusterParameters,omitempty"`
+
+	// OnKeyTooLong is the handling of an index build failed by the keys longer than
+	// the index key limit of the target (skip or fail).
+	OnKeyTooLong string `json:"onKeyTooLong,omitempty"`
+	// ManageTTLDuringReplication indicates whether to disable the TTL indexes of the target
+	// until finalization. Defaults to true.
+	ManageTTLDuringReplication *bool `json:"manageTTLDuringReplication,omitempty"`
+	// ReplicateHiddenIndexes indicates whether to hide the target indexes during the replication
+	// as on the source instead of on finalization.
+	ReplicateHiddenIndexes bool `json:"replicateHiddenIndexes,omitempty"`
+	// RenameCollisionSuffix is the suffix of the collections that the existing target collections
+	// are cloned into and replaced by on finalization (e.g. "__plm_new").
+	RenameCollisionSuffix string `json:"renameCollisionSuffix,omitempty"`
+	// TargetNamespacePrefix is prepended to the name of each target database (e.g. "test_").
+	TargetNamespacePrefix string `json:"targetNamespacePrefix,omitempty"`
+	// CloneManifest is the file of the clone progress of each namespace updated during the clone.
+	CloneManifest string `json:"cloneManifest,omitempty"`
+	// InternalNamespaces are the internal collections replicated by the exact name
+	// (e.g. "config.system.sessions"). Wildcards are not allowed.
+	InternalNamespaces []string `json:"internalNamespaces,omitempty"`
+
+	// StartFromBackupTimestamp is the restore point timestamp of the backup restored on the target
+	// (e.g. "1700000000,3"). The clone is skipped and the changes since the timestamp are replicated.
+	StartFromBackupTimestamp string `json:"startFromBackupTimestamp,omitempty"`
 }
 
 // startResponse represents the response body for the /start endpoint.
@@ -960,6 +3041,13 @@ type startResponse struct {
 	Ok bool `json:"ok"`
 	// Err is the error message if the operation failed.
 	Err string `json:"error,omitempty"`
+
+	// ValidationErrors are the fields of the request that do not match the schema.
+	ValidationErrors []schemaError `json:"validationErrors,omitempty"`
+
+	// Warnings are the issues of the request that do not prevent the start
+	// (e.g. an include pattern that matches no source namespace).
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // finalizeRequest represents the request body for the /finalize endpoint.
@@ -967,6 +3055,8 @@ type finalizeRequest struct {
 	// IgnoreHistoryLost indicates whether the operation can ignore the ChangeStreamHistoryLost
 	// error.
 	IgnoreHistoryLost bool `json:"ignoreHistoryLost,omitempty"`
+	// LockSource locks the source writes with fsyncLock before the change replication is paused.
+	LockSource bool `json:"lockSource,omitempty"`
 }
 
 // finalizeResponse represents the response body for the /finalize endpoint.
@@ -975,6 +3065,11 @@ type finalizeResponse struct {
 	Ok bool `json:"ok"`
 	// Err is the error message if the operation failed.
 	Err string `json:"error,omitempty"`
+
+	// ResumeToken is the resume token of the last applied change event (extended JSON).
+	ResumeToken json.RawMessage `json:"resumeToken,omitempty"`
+	// ClusterTime is the cluster time of the last applied change ("T.I").
+	ClusterTime string `json:"clusterTime,omitempty"`
 }
 
 // statusResponse represents the response body for the /status endpoint.
@@ -996,11 +3091,66 @@ type statusResponse struct {
 	LagTime int64 `json:"lagTime"`
 	// EventsProcessed is the number of events processed.
 	EventsProcessed int64 `json:"eventsProcessed"`
+	// DeadLettered is the number of events stored in the dead-letter collection.
+	DeadLettered int64 `json:"deadLettered,omitempty"`
 	// LastReplicatedOpTime is the last replicated operation time.
 	LastReplicatedOpTime string `json:"lastReplicatedOpTime,omitempty"`
 
+	// PendingDDL is the DDL change waiting for the approval.
+	PendingDDL *statusPendingDDLResponse `json:"pendingDDL,omitempty"`
+	// PausedNamespaces are the patterns of the paused namespaces (see pause --namespace).
+	PausedNamespaces []string `json:"pausedNamespaces,omitempty"`
+	// HeldChanges is the number of the held changes of the paused namespaces.
+	HeldChanges int `json:"heldChanges,omitempty"`
+
 	// InitialSync contains the initial sync status details.
 	InitialSync *statusInitialSyncResponse `json:"initialSync,omitempty"`
+
+	// IndexBuildProgress contains the progress of the in-progress index builds on the target.
+	IndexBuildProgress []statusIndexBuildResponse `json:"indexBuildProgress,omitempty"`
+
+	// Targets contains the status of the additional targets (see --target-uri).
+	Targets []statusTargetResponse `json:"targets,omitempty"`
+
+	// ClusterParameters contains the result of the cluster parameters copy.
+	ClusterParameters *statusClusterParametersResponse `json:"clusterParameters,omitempty"`
+
+	// Memory contains the memory usage of the clone read batches and the queued change events
+	// (see --max-memory).
+	Memory *statusMemoryResponse `json:"memory,omitempty"`
+
+	// Budget contains the max total duration of the replication (see --max-total-duration).
+	Budget *statusBudgetResponse `json:"budget,omitempty"`
+}
+
+// statusBudgetResponse represents the max total duration in the /status response.
+type statusBudgetResponse struct {
+	// MaxTotalDuration is the max total duration since the start.
+	MaxTotalDuration string `json:"maxTotalDuration"`
+	// Deadline is the time the budget is exceeded at.
+	Deadline string `json:"deadline"`
+	// RemainingSeconds is the time remaining until the deadline.
+	RemainingSeconds int64 `json:"remainingSeconds"`
+	// Action is the handling of the exceeded budget (abort or pause).
+	Action string `json:"action"`
+	// Exceeded indicates the budget has been exceeded and the action is taken.
+	Exceeded bool `json:"exceeded,omitempty"`
+}
+
+// statusMemoryResponse represents the memory usage in the /status response.
+type statusMemoryResponse struct {
+	// UsedBytes is the size of the clone read batches and the queued change events.
+	UsedBytes int64 `json:"usedBytes"`
+	// LimitBytes is the limit of --max-memory.
+	LimitBytes int64 `json:"limitBytes"`
+}
+
+// statusClusterParametersResponse represents the cluster parameters copy in the /status response.
+type statusClusterParametersResponse struct {
+	// Copied are the names of the parameters set on the target.
+	Copied []string `json:"copied,omitempty"`
+	// Skipped are the reasons of the skipped parameters by name.
+	Skipped map[string]string `json:"skipped,omitempty"`
 }
 
 // statusInitialSyncResponse represents the initial sync status in the /status response.
@@ -1017,6 +3167,72 @@ type statusInitialSyncResponse struct {
 	Completed bool `json:"completed"`
 	// CloneCompleted indicates if the cloning process is completed.
 	CloneCompleted bool `json:"cloneCompleted"`
+	// SkippedCollections are the errors of the collections skipped by the clone by namespace
+	// (see onCollectionError).
+	SkippedCollections map[string]string `json:"skippedCollections,omitempty"`
+}
+
+// statusPendingDDLResponse represents the DDL change pending approval in the /status response.
+type statusPendingDDLResponse struct {
+	// OperationType is the type of the DDL change (e.g. drop).
+	OperationType string `json:"operationType"`
+	// Namespace is the namespace of the DDL change.
+	Namespace string `json:"namespace"`
+	// ClusterTime is the operation time of the DDL change.
+	ClusterTime string `json:"clusterTime"`
+}
+
+// statusIndexBuildResponse represents the progress of an index build in the /status response.
+type statusIndexBuildResponse struct {
+	// Namespace is the namespace of the collection.
+	Namespace string `json:"namespace"`
+	// Index is the name of the index.
+	Index string `json:"index"`
+	// Phase is the build phase reported by the target.
+	Phase string `json:"phase,omitempty"`
+	// Done is the number of processed items of the current phase.
+	Done int64 `json:"done"`
+	// Total is the total number of items of the current phase.
+	Total int64 `json:"total"`
+	// Percent is the progress of the current phase in percents.
+	Percent float64 `json:"percent"`
+}
+
+// statsResponse represents the response body for the /stats endpoint.
+type statsResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+
+	// StartTime is the time when the replication started (RFC 3339).
+	StartTime string `json:"startTime,omitempty"`
+	// Elapsed is the time in seconds since the replication started.
+	Elapsed int64 `json:"elapsed"`
+
+	// ClonedDocuments is the total number of cloned documents.
+	ClonedDocuments int64 `json:"clonedDocuments"`
+	// ClonedSize is the total size of the cloned documents in bytes.
+	ClonedSize uint64 `json:"clonedSize"`
+	// EventsApplied is the total number of applied change events.
+	EventsApplied int64 `json:"eventsApplied"`
+
+	// Retries is the number of retries of transient errors.
+	Retries int64 `json:"retries"`
+	// Errors is the number of failures of the replication.
+	Errors int64 `json:"errors"`
+
+	// AvgThroughput is the average number of documents and events processed per second.
+	AvgThroughput float64 `json:"avgThroughput"`
+	// PeakThroughput is the peak number of documents and events processed per second.
+	PeakThroughput float64 `json:"peakThroughput"`
+}
+
+// pauseRequest represents the request body for the /pause endpoint.
+type pauseRequest struct {
+	// Namespace is the pattern of the namespaces to pause (e.g. "db.coll" or "db.*").
+	// The changes of other namespaces are replicated. Empty pauses the whole replication.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // pauseResponse represents the response body for the /pause endpoint.
@@ -1027,10 +3243,23 @@ type pauseResponse struct {
 	Err string `json:"error,omitempty"`
 }
 
+// drainResponse represents the response body for the /drain endpoint.
+type drainResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+}
+
 // resumeRequest represents the request body for the /resume endpoint.
 type resumeRequest struct {
 	// FromFailure indicates whether to resume from a failed state.
 	FromFailure bool `json:"fromFailure,omitempty"`
+	// Manifest is the clone manifest file to resume the replication from when the recovery
+	// data is lost.
+	Manifest string `json:"manifest,omitempty"`
+	// Namespace is the pattern of the namespaces paused with [pauseRequest.Namespace] to resume.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // resumeResponse represents the response body for the /resume
@@ -1042,74 +3271,196 @@ type resumeResponse struct {
 	Err string `json:"error,omitempty"`
 }
 
+// approveDDLResponse represents the response body for the /approve-ddl endpoint.
+type approveDDLResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+}
+
+// replayDeadLetterRequest represents the request body for the /replay-dead-letter endpoint.
+type replayDeadLetterRequest struct {
+	// Namespace is the dead-letter namespace. Default: the namespace set on start.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// replayDeadLetterResponse represents the response body for the /replay-dead-letter endpoint.
+type replayDeadLetterResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+
+	// Replayed is the number of the applied and removed events.
+	Replayed int `json:"replayed"`
+	// Failed is the number of the events that failed again and remain in the collection.
+	Failed int `json:"failed"`
+}
+
 type PCSMClient struct {
-	port int
+	port  int
+	token string      // bearer token of the requests. Empty sends no token
+	tls   *tls.Config // HTTPS configuration. Nil uses HTTP
 }
 
-func NewClient(port int) PCSMClient {
-	return PCSMClient{port: port}
+func NewClient(port int, token string) PCSMClient {
+	return PCSMClient{port: port, token: token}
 }
 
 // Status sends a request to get the status of the cluster replication.
 func (c PCSMClient) Status(ctx context.Context) error {
-	return doClientRequest[statusResponse](ctx, c.port, http.MethodGet, "status", nil)
+	return doClientRequest[statusResponse](ctx, c, http.MethodGet, "status", nil)
+}
+
+// Stats sends a request to get the lifetime statistics of the cluster replication.
+// The output is either "json" or a human-readable "text".
+func (c PCSMClient) Stats(ctx context.Context, output string) error {
+	if output == "json" {
+		return doClientRequest[statsResponse](ctx, c, http.MethodGet, "stats", nil)
+	}
+
+	resp, err := fetchClientResponse[statsResponse](ctx, c, http.MethodGet, "stats", nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.Err != "" {
+		return errors.New(resp.Err)
+	}
+
+	elapsed := time.Duration(resp.Elapsed) * time.Second
+
+	fmt.Printf("Start Time:       %s\n", resp.StartTime)
+	fmt.Printf("Elapsed:          %s\n", elapsed)
+	fmt.Printf("Cloned Documents: %d\n", resp.ClonedDocuments)
+	fmt.Printf("Cloned Size:      %s\n", humanize.Bytes(resp.ClonedSize))
+	fmt.Printf("Events Applied:   %d\n", resp.EventsApplied)
+	fmt.Printf("Retries:          %d\n", resp.Retries)
+	fmt.Printf("Errors:           %d\n", resp.Errors)
+	fmt.Printf("Avg Throughput:   %.2f ops/s\n", resp.AvgThroughput)
+	fmt.Printf("Peak Throughput:  %.2f ops/s\n", resp.PeakThroughput)
+
+	return nil
 }
 
 // Start sends a request to start the cluster replication.
 func (c PCSMClient) Start(ctx context.Context, req startRequest) error {
-	return doClientRequest[startResponse](ctx, c.port, http.MethodPost, "start", req)
+	return doClientRequest[startResponse](ctx, c, http.MethodPost, "start", req)
 }
 
 // Finalize sends a request to finalize the cluster replication.
 func (c PCSMClient) Finalize(ctx context.Context, req finalizeRequest) error {
-	return doClientRequest[finalizeResponse](ctx, c.port, http.MethodPost, "finalize", req)
+	return doClientRequest[finalizeResponse](ctx, c, http.MethodPost, "finalize", req)
+}
+
+// Pause sends a request to pause the cluster replication or the namespaces.
+func (c PCSMClient) Pause(ctx context.Context, req pauseRequest) error {
+	return doClientRequest[pauseResponse](ctx, c, http.MethodPost, "pause", req)
 }
 
-// Pause sends a request to pause the cluster replication.
-func (c PCSMClient) Pause(ctx context.Context) error {
-	return doClientRequest[pauseResponse](ctx, c.port, http.MethodPost, "pause", nil)
+// Drain sends a request to stop the data clone after the collections in progress.
+func (c PCSMClient) Drain(ctx context.Context) error {
+	return doClientRequest[drainResponse](ctx, c, http.MethodPost, "drain", nil)
 }
 
 // Resume sends a request to resume the cluster replication.
 func (c PCSMClient) Resume(ctx context.Context, req resumeRequest) error {
-	return doClientRequest[resumeResponse](ctx, c.port, http.MethodPost, "resume", req)
+	return doClientRequest[resumeResponse](ctx, c, http.MethodPost, "resume", req)
+}
+
+// ApproveDDL sends a request to approve the pending DDL change.
+func (c PCSMClient) ApproveDDL(ctx context.Context) error {
+	return doClientRequest[approveDDLResponse](ctx, c, http.MethodPost, "approve-ddl", nil)
+}
+
+// ReplayDeadLetter sends a request to replay the dead-letter collection.
+func (c PCSMClient) ReplayDeadLetter(ctx context.Context, req replayDeadLetterRequest) error {
+	return doClientRequest[replayDeadLetterResponse](ctx, c, http.MethodPost,
+		"replay-dead-letter", req)
+}
+
+func doClientRequest[T any](ctx context.Context, c PCSMClient, method, path string, body any) error {
+	resp, err := fetchClientResponse[T](ctx, c, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(resp)
 }
 
-func doClientRequest[T any](ctx context.Context, port int, method, path string, body any) error {
-	url := fmt.Sprintf("http://localhost:%d/%s", port, path)
+// printJSON prints the response as indented JSON.
+func printJSON(resp any) error {
+	j := json.NewEncoder(os.Stdout)
+	j.SetIndent("", "  ")
+	err := j.Encode(resp)
+
+	return errors.Wrap(err, "print response")
+}
 
+func fetchClientResponse[T any](
+	ctx context.Context,
+	c PCSMClient,
+	method string,
+	path string,
+	body any,
+) (*T, error) {
 	bodyData := []byte("")
 	if body != nil {
 		var err error
 		bodyData, err = json.Marshal(body)
 		if err != nil {
-			return errors.Wrap(err, "encode request")
+			return nil, errors.Wrap(err, "encode request")
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(bodyData))
+	res, err := c.do(ctx, method, path, bodyData)
 	if err != nil {
-		return errors.Wrap(err, "build request")
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	log.Ctx(ctx).Debugf("POST /%s %s", path, string(bodyData))
+	var resp T
 
-	res, err := http.DefaultClient.Do(req)
+	err = json.NewDecoder(res.Body).Decode(&resp)
 	if err != nil {
-		return errors.Wrap(err, "request")
+		return nil, errors.Wrap(err, "decode response")
 	}
-	defer res.Body.Close()
 
-	var resp T
+	return &resp, nil
+}
 
-	err = json.NewDecoder(res.Body).Decode(&resp)
+// do sends the request to the HTTP API. The caller closes the response body.
+func (c PCSMClient) do(ctx context.Context, method, path string, bodyData []byte) (*http.Response, error) {
+	scheme, httpClient := "http", http.DefaultClient
+	if c.tls != nil {
+		scheme = "https"
+		httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: c.tls}}
+	}
+
+	url := fmt.Sprintf("%s://localhost:%d/%s", scheme, c.port, path)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(bodyData))
 	if err != nil {
-		return errors.Wrap(err, "decode response")
+		return nil, errors.Wrap(err, "build request")
 	}
 
-	j := json.NewEncoder(os.Stdout)
-	j.SetIndent("", "  ")
-	err = j.Encode(resp)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 
-	return errors.Wrap(err, "print response")
+	log.Ctx(ctx).Debugf("%s /%s %s", method, path, string(bodyData))
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request")
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+
+		return nil, errors.New("unauthorized: invalid or missing API token (--token or " + APITokenEnvVar + ")")
+	}
+
+	return res, nil
 }
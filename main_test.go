@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestGetLogLevel(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		args    []string
+		want    zerolog.Level
+		warning bool
+	}{
+		{nil, zerolog.InfoLevel, false},
+		{[]string{"--log-level=warn"}, zerolog.WarnLevel, false},
+		{[]string{"--quiet"}, zerolog.ErrorLevel, false},
+		{[]string{"--verbose"}, zerolog.DebugLevel, false},
+		{[]string{"-v"}, zerolog.DebugLevel, false},
+		{[]string{"--quiet", "--verbose"}, zerolog.DebugLevel, true},
+		{[]string{"--log-level=trace", "--quiet"}, zerolog.ErrorLevel, true},
+		{[]string{"--log-level=trace", "-v"}, zerolog.DebugLevel, true},
+		{[]string{"--log-level=error", "--quiet", "-v"}, zerolog.DebugLevel, true},
+	} {
+		flags := pflag.NewFlagSet("pcsm", pflag.ContinueOnError)
+		flags.String("log-level", "info", "")
+		flags.Bool("quiet", false, "")
+		flags.BoolP("verbose", "v", false, "")
+
+		err := flags.Parse(tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, warning, err := getLogLevel(flags)
+		if err != nil {
+			t.Errorf("%v: got error %v", tc.args, err)
+
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("%v: got level %q, want %q", tc.args, got, tc.want)
+		}
+
+		if (warning != "") != tc.warning {
+			t.Errorf("%v: got warning %q, want warning: %v", tc.args, warning, tc.warning)
+		}
+	}
+
+	flags := pflag.NewFlagSet("pcsm", pflag.ContinueOnError)
+	flags.String("log-level", "loud", "")
+	flags.Bool("quiet", false, "")
+	flags.Bool("verbose", false, "")
+
+	_, _, err := getLogLevel(flags)
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
+
+func TestNewFinalizeResponse(t *testing.T) {
+	t.Parallel()
+
+	token, err := bson.Marshal(bson.D{{"_data", "8267B8C5A2000000012B0429296E1404"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := &pcsm.Status{
+		State: pcsm.StateFinalizing,
+		Repl: pcsm.ReplStatus{
+			LastReplicatedOpTime: bson.Timestamp{T: 1740162466, I: 1},
+			LastReplicatedToken:  token,
+		},
+	}
+
+	data, err := json.Marshal(newFinalizeResponse(status))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct {
+		Ok          bool              `json:"ok"`
+		ResumeToken map[string]string `json:"resumeToken"`
+		ClusterTime string            `json:"clusterTime"`
+	}
+
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Ok || res.ResumeToken["_data"] != "8267B8C5A2000000012B0429296E1404" {
+		t.Errorf("got = %s, want the resume token", data)
+	}
+
+	if res.ClusterTime != "1740162466.1" {
+		t.Errorf("clusterTime: got = %q, want 1740162466.1", res.ClusterTime)
+	}
+
+	// nothing is replicated (e.g. the oplog method without a resume token)
+	data, err = json.Marshal(newFinalizeResponse(&pcsm.Status{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"ok":true}`; string(data) != want {
+		t.Errorf("empty: got = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// StatsDFlushInterval is the interval of sending the metrics to the StatsD server.
+const StatsDFlushInterval = 10 * time.Second
+
+// statsdMaxPacketSize is the maximum size of a UDP packet that avoids the IP fragmentation
+// on the common networks.
+const statsdMaxPacketSize = 1432
+
+// statsdExporter sends the PCSM counters and gauges of the gatherer over the StatsD protocol.
+// The metric definitions are shared with Prometheus: the values are read from the registry.
+// The counters are sent as the increments since the previous flush.
+type statsdExporter struct {
+	conn     net.Conn
+	gatherer prometheus.Gatherer
+
+	counters map[string]float64 // counter values of the previous flush
+}
+
+// RunStatsD sends the metrics of the gatherer to the StatsD server at the address (host:port)
+// every [StatsDFlushInterval] until the context is canceled.
+func RunStatsD(ctx context.Context, address string, gatherer prometheus.Gatherer) error {
+	e, err := newStatsDExporter(address, gatherer)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer e.conn.Close()
+
+		lg := log.New("statsd")
+
+		t := time.NewTicker(StatsDFlushInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			err := e.flush()
+			if err != nil {
+				lg.Error(err, "Failed to send metrics")
+			}
+		}
+	}()
+
+	return nil
+}
+
+func newStatsDExporter(address string, gatherer prometheus.Gatherer) (*statsdExporter, error) {
+	conn, err := net.Dial("udp", address) //nolint:noctx
+	if err != nil {
+		return nil, errors.Wrap(err, "dial")
+	}
+
+	e := &statsdExporter{
+		conn:     conn,
+		gatherer: gatherer,
+		counters: make(map[string]float64),
+	}
+
+	return e, nil
+}
+
+// flush sends the current values of the PCSM counters and gauges.
+func (e *statsdExporter) flush() error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return errors.Wrap(err, "gather")
+	}
+
+	var packet bytes.Buffer
+
+	for _, mf := range families {
+		name := mf.GetName()
+		if !strings.HasPrefix(name, metricNamespace+"_") {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			var line string
+
+			switch mf.GetType() { //nolint:exhaustive
+			case dto.MetricType_COUNTER:
+				v := m.GetCounter().GetValue()
+				delta := v - e.counters[name]
+				e.counters[name] = v
+
+				if delta <= 0 {
+					continue
+				}
+
+				line = name + ":" + formatStatsDValue(delta) + "|c"
+
+			case dto.MetricType_GAUGE:
+				line = name + ":" + formatStatsDValue(m.GetGauge().GetValue()) + "|g"
+
+			default:
+				continue
+			}
+
+			if packet.Len() != 0 && packet.Len()+1+len(line) > statsdMaxPacketSize {
+				err = e.send(&packet)
+				if err != nil {
+					return err
+				}
+			}
+
+			if packet.Len() != 0 {
+				packet.WriteByte('\n')
+			}
+
+			packet.WriteString(line)
+		}
+	}
+
+	return e.send(&packet)
+}
+
+func (e *statsdExporter) send(packet *bytes.Buffer) error {
+	if packet.Len() == 0 {
+		return nil
+	}
+
+	_, err := e.conn.Write(packet.Bytes())
+	packet.Reset()
+
+	return errors.Wrap(err, "send")
+}
+
+func formatStatsDValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+package metrics //nolint:testpackage
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStatsDExporter(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	reg := prometheus.NewRegistry()
+	Init(reg)
+
+	e, err := newStatsDExporter(listener.LocalAddr().String(), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.conn.Close()
+
+	receive := func() []string {
+		t.Helper()
+
+		var lines []string
+
+		buf := make([]byte, statsdMaxPacketSize)
+
+		for {
+			_ = listener.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+			n, _, err := listener.ReadFrom(buf)
+			if err != nil {
+				return lines // no more packets
+			}
+
+			if n > statsdMaxPacketSize {
+				t.Errorf("got packet of %d bytes, want at most %d", n, statsdMaxPacketSize)
+			}
+
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+	}
+
+	AddEventsProcessed(5)
+	SetLagTimeSeconds(3)
+
+	err = e.flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := receive()
+	for _, want := range []string{
+		"percona_clustersync_mongodb_events_processed_total:5|c",
+		"percona_clustersync_mongodb_lag_time_seconds:3|g",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("%q is not sent: %v", want, lines)
+		}
+	}
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line, metricNamespace+"_") {
+			t.Errorf("got non-PCSM metric %q", line)
+		}
+	}
+
+	// the counters are sent as the increments since the previous flush
+	AddEventsProcessed(2)
+
+	err = e.flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines = receive()
+	if want := "percona_clustersync_mongodb_events_processed_total:2|c"; !slices.Contains(lines, want) {
+		t.Errorf("%q is not sent: %v", want, lines)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+// phaseEvent is a key transition of the migration notified to the webhook.
+type phaseEvent string
+
+const (
+	phaseCloneCompleted    phaseEvent = "clone_completed"
+	phaseReplicationSteady phaseEvent = "replication_steady"
+	phaseFailed            phaseEvent = "failed"
+	phaseFinalized         phaseEvent = "finalized"
+)
+
+// phaseNotification is the JSON body posted to the webhook (--notify-webhook).
+// The text field is the message of the Slack incoming webhooks.
+type phaseNotification struct {
+	Event       phaseEvent `json:"event"`
+	MigrationID string     `json:"migrationId"`
+	Time        time.Time  `json:"time"`
+	Text        string     `json:"text"`
+
+	State pcsm.State `json:"state"`
+	Info  string     `json:"info,omitempty"`
+	Error string     `json:"error,omitempty"`
+
+	LagTime              int64  `json:"lagTime"`
+	EventsProcessed      int64  `json:"eventsProcessed"`
+	EstimatedCloneSize   uint64 `json:"estimatedCloneSize"`
+	ClonedSize           uint64 `json:"clonedSize"`
+	ClonedDocuments      int64  `json:"clonedDocuments"`
+	LastReplicatedOpTime string `json:"lastReplicatedOpTime,omitempty"`
+}
+
+// migrationID returns the ID of the migration: the source cluster time of the clone start.
+// It is kept across the restarts and differs for each started migration.
+func migrationID(status *pcsm.Status) string {
+	ts := status.Clone.StartTS
+
+	return fmt.Sprintf("%d.%d", ts.T, ts.I)
+}
+
+func newPhaseNotification(event phaseEvent, now time.Time, status *pcsm.Status) *phaseNotification {
+	n := &phaseNotification{
+		Event:       event,
+		MigrationID: migrationID(status),
+		Time:        now.UTC(),
+
+		State: status.State,
+		Info:  statusInfo(status),
+
+		LagTime:            status.TotalLagTime,
+		EventsProcessed:    status.Repl.EventsProcessed,
+		EstimatedCloneSize: status.Clone.EstimatedTotalSize,
+		ClonedSize:         status.Clone.CopiedSize,
+		ClonedDocuments:    status.Clone.CopiedCount,
+	}
+
+	if status.Error != nil {
+		n.Error = status.Error.Error()
+	}
+
+	if ts := status.Repl.LastReplicatedOpTime; !ts.IsZero() {
+		n.LastReplicatedOpTime = fmt.Sprintf("%d.%d", ts.T, ts.I)
+	}
+
+	switch event {
+	case phaseCloneCompleted:
+		n.Text = fmt.Sprintf("PCSM migration %s: Data Clone completed (%d documents)",
+			n.MigrationID, n.ClonedDocuments)
+	case phaseReplicationSteady:
+		n.Text = fmt.Sprintf("PCSM migration %s: Initial Sync completed, replicating changes (lag %ds)",
+			n.MigrationID, n.LagTime)
+	case phaseFailed:
+		n.Text = fmt.Sprintf("PCSM migration %s: failed: %s", n.MigrationID, n.Error)
+	case phaseFinalized:
+		n.Text = fmt.Sprintf("PCSM migration %s: finalized", n.MigrationID)
+	}
+
+	return n
+}
+
+// phaseEvents returns the transitions from the previous status to the current one.
+func phaseEvents(prev, cur *pcsm.Status) []phaseEvent {
+	var events []phaseEvent
+
+	if !prev.Clone.IsFinished() && cur.Clone.IsFinished() && cur.Clone.Err == nil {
+		events = append(events, phaseCloneCompleted)
+	}
+
+	if !prev.InitialSyncCompleted && cur.InitialSyncCompleted {
+		events = append(events, phaseReplicationSteady)
+	}
+
+	if prev.State != cur.State {
+		switch cur.State { //nolint:exhaustive
+		case pcsm.StateFailed:
+			events = append(events, phaseFailed)
+		case pcsm.StateFinalized:
+			events = append(events, phaseFinalized)
+		}
+	}
+
+	return events
+}
+
+// phaseNotifier checks the status at each interval and sends a notification for each key
+// transition. The status on the start is the baseline: a restart does not notify again.
+// A failed notification is logged: the migration is not affected.
+type phaseNotifier struct {
+	status   func(context.Context) *pcsm.Status
+	send     func(context.Context, *phaseNotification) error
+	interval time.Duration
+}
+
+func (p *phaseNotifier) run(ctx context.Context) {
+	lg := log.New("notify")
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	prev := p.status(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-t.C:
+			cur := p.status(ctx)
+
+			if prev.State == pcsm.StateIdle && cur.State != pcsm.StateIdle {
+				prev = &pcsm.Status{State: pcsm.StateIdle} // a new migration
+			}
+
+			for _, event := range phaseEvents(prev, cur) {
+				err := p.send(ctx, newPhaseNotification(event, now, cur))
+				if err != nil {
+					lg.Errorf(err, "Notify %s", event)
+				} else {
+					lg.Debugf("Notified %s", event)
+				}
+			}
+
+			prev = cur
+		}
+	}
+}
+
+// postWebhook posts the notification as JSON to the URL.
+func postWebhook(ctx context.Context, client *http.Client, url string, n *phaseNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.NotifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "post")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("webhook responded %s", res.Status)
+	}
+
+	return nil
+}
+
+// RunPhaseNotifier posts a notification to the webhook URL on the key transitions
+// of the migration: the clone completion, the initial sync completion, the failure,
+// and the finalization.
+func RunPhaseNotifier(ctx context.Context, url string, pcs *pcsm.PCSM) {
+	n := &phaseNotifier{
+		status: pcs.Status,
+		send: func(ctx context.Context, n *phaseNotification) error {
+			return postWebhook(ctx, http.DefaultClient, url, n)
+		},
+		interval: config.NotifyCheckInterval,
+	}
+
+	go n.run(ctx)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestPhaseNotifier_CloneCompleted(t *testing.T) {
+	t.Parallel()
+
+	notifiedC := make(chan *phaseNotification, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got = %s %s, want POST application/json", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		var n phaseNotification
+
+		err := json.NewDecoder(r.Body).Decode(&n)
+		if err != nil {
+			t.Error(err)
+		}
+
+		notifiedC <- &n
+	}))
+	t.Cleanup(srv.Close)
+
+	start := time.Now()
+	cloning := pcsm.Status{
+		State: pcsm.StateRunning,
+		Clone: pcsm.CloneStatus{
+			EstimatedTotalSize: 2048,
+			CopiedSize:         1024,
+			CopiedCount:        10,
+			StartTS:            bson.Timestamp{T: 1700000000, I: 3},
+			StartTime:          start,
+		},
+	}
+
+	var mu sync.Mutex
+	status := cloning
+
+	n := &phaseNotifier{
+		status: func(context.Context) *pcsm.Status {
+			mu.Lock()
+			defer mu.Unlock()
+
+			s := status
+
+			return &s
+		},
+		send: func(ctx context.Context, n *phaseNotification) error {
+			return postWebhook(ctx, srv.Client(), srv.URL, n)
+		},
+		interval: 10 * time.Millisecond,
+	}
+
+	go n.run(t.Context())
+
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	status.Clone.CopiedSize = 2048
+	status.Clone.CopiedCount = 20
+	status.Clone.FinishTS = bson.Timestamp{T: 1700000100, I: 1}
+	status.Clone.FinishTime = start.Add(time.Minute)
+	mu.Unlock()
+
+	var got *phaseNotification
+	select {
+	case got = <-notifiedC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got = no notification, want clone_completed")
+	}
+
+	if got.Event != phaseCloneCompleted {
+		t.Errorf("event: got = %s, want %s", got.Event, phaseCloneCompleted)
+	}
+
+	if got.MigrationID != "1700000000.3" {
+		t.Errorf("migrationId: got = %s, want 1700000000.3", got.MigrationID)
+	}
+
+	if got.State != pcsm.StateRunning || got.ClonedDocuments != 20 ||
+		got.ClonedSize != 2048 || got.EstimatedCloneSize != 2048 {
+		t.Errorf("summary: got = %+v, want running with 20 documents of 2048 bytes", got)
+	}
+
+	if got.Text == "" || got.Time.IsZero() {
+		t.Errorf("got = %+v, want the text and time", got)
+	}
+
+	// the transition is notified once
+	select {
+	case n := <-notifiedC:
+		t.Errorf("got = %s notified again, want none", n.Event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPhaseEvents(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	running := &pcsm.Status{
+		State: pcsm.StateRunning,
+		Clone: pcsm.CloneStatus{StartTime: now},
+	}
+	cloned := &pcsm.Status{
+		State: pcsm.StateRunning,
+		Clone: pcsm.CloneStatus{StartTime: now, FinishTime: now},
+	}
+	steady := &pcsm.Status{
+		State:                pcsm.StateRunning,
+		InitialSyncCompleted: true,
+		Clone:                pcsm.CloneStatus{StartTime: now, FinishTime: now},
+	}
+	failed := &pcsm.Status{State: pcsm.StateFailed, Clone: running.Clone}
+	finalized := &pcsm.Status{State: pcsm.StateFinalized, InitialSyncCompleted: true, Clone: steady.Clone}
+
+	for _, tc := range []struct {
+		name      string
+		prev, cur *pcsm.Status
+		want      []phaseEvent
+	}{
+		{"unchanged", running, running, nil},
+		{"cloned", running, cloned, []phaseEvent{phaseCloneCompleted}},
+		{"steady", cloned, steady, []phaseEvent{phaseReplicationSteady}},
+		{"failed", running, failed, []phaseEvent{phaseFailed}},
+		{"finalized", steady, finalized, []phaseEvent{phaseFinalized}},
+	} {
+		got := phaseEvents(tc.prev, tc.cur)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package pcsm
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// balancerControl controls the balancer of the sharded target.
+type balancerControl interface {
+	Enabled(ctx context.Context) (bool, error)
+	Stop(ctx context.Context) error
+	Start(ctx context.Context) error
+}
+
+// targetBalancer is the balancer of the target cluster.
+type targetBalancer struct {
+	m *mongo.Client
+}
+
+func (b targetBalancer) Enabled(ctx context.Context) (bool, error) {
+	return topo.BalancerEnabled(ctx, b.m) //nolint:wrapcheck
+}
+
+func (b targetBalancer) Stop(ctx context.Context) error {
+	return topo.StopBalancer(ctx, b.m) //nolint:wrapcheck
+}
+
+func (b targetBalancer) Start(ctx context.Context) error {
+	return topo.StartBalancer(ctx, b.m) //nolint:wrapcheck
+}
+
+// withBalancerStopped runs fn with the balancer stopped to prevent chunk migrations
+// from interfering with the copy. The balancer is started after fn returns
+// only if it was enabled before or it was stopped by PCSM before the clone was resumed
+// (stopped). setStopped records in the checkpoint whether the balancer is stopped by PCSM.
+func withBalancerStopped(
+	ctx context.Context,
+	b balancerControl,
+	stopped bool,
+	setStopped func(bool),
+	fn func() error,
+) error {
+	lg := log.Ctx(ctx)
+
+	if !stopped {
+		enabled, err := b.Enabled(ctx)
+		if err != nil {
+			return errors.Wrap(err, "balancer status")
+		}
+
+		if !enabled {
+			lg.Info("Target balancer is already stopped")
+
+			return fn()
+		}
+	}
+
+	// stop it again on resume: it could be started while the clone was stopped
+	err := b.Stop(ctx)
+	if err != nil {
+		return errors.Wrap(err, "stop balancer")
+	}
+
+	setStopped(true)
+
+	lg.Info("Target balancer is stopped for Data Clone. " +
+		"If the clone is not resumed, start it with sh.startBalancer()")
+
+	fnErr := fn()
+
+	err = b.Start(context.WithoutCancel(ctx))
+	if err != nil {
+		if fnErr != nil {
+			lg.Error(err, "Start target balancer")
+
+			return fnErr
+		}
+
+		return errors.Wrap(err, "start balancer")
+	}
+
+	setStopped(false)
+
+	lg.Info("Target balancer is started")
+
+	return fnErr
+}
@@ -0,0 +1,135 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// fakeBalancer records the balancer calls.
+type fakeBalancer struct {
+	enabled bool
+	calls   []string
+}
+
+func (b *fakeBalancer) Enabled(context.Context) (bool, error) {
+	b.calls = append(b.calls, "status")
+
+	return b.enabled, nil
+}
+
+func (b *fakeBalancer) Stop(context.Context) error {
+	b.calls = append(b.calls, "stop")
+	b.enabled = false
+
+	return nil
+}
+
+func (b *fakeBalancer) Start(context.Context) error {
+	b.calls = append(b.calls, "start")
+	b.enabled = true
+
+	return nil
+}
+
+func TestWithBalancerStopped(t *testing.T) {
+	t.Parallel()
+
+	b := &fakeBalancer{enabled: true}
+
+	err := withBalancerStopped(t.Context(), b, false, func(bool) {}, func() error {
+		if b.enabled {
+			t.Error("balancer is enabled during clone")
+		}
+
+		b.calls = append(b.calls, "clone")
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"status", "stop", "clone", "start"}; !slices.Equal(b.calls, want) {
+		t.Errorf("got = %v, want %v", b.calls, want)
+	}
+
+	if !b.enabled {
+		t.Error("balancer is not enabled after clone")
+	}
+}
+
+func TestWithBalancerStopped_CloneFailed(t *testing.T) {
+	t.Parallel()
+
+	b := &fakeBalancer{enabled: true}
+	errClone := errors.New("clone failed")
+
+	err := withBalancerStopped(t.Context(), b, false, func(bool) {}, func() error {
+		return errClone
+	})
+	if !errors.Is(err, errClone) {
+		t.Errorf("got = %v, want %v", err, errClone)
+	}
+
+	if !b.enabled {
+		t.Error("balancer is not enabled after failed clone")
+	}
+}
+
+func TestWithBalancerStopped_AlreadyStopped(t *testing.T) {
+	t.Parallel()
+
+	b := &fakeBalancer{enabled: false}
+
+	err := withBalancerStopped(t.Context(), b, false, func(bool) {}, func() error {
+		b.calls = append(b.calls, "clone")
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"status", "clone"}; !slices.Equal(b.calls, want) {
+		t.Errorf("got = %v, want %v", b.calls, want)
+	}
+
+	if b.enabled {
+		t.Error("balancer is enabled, want left stopped")
+	}
+}
+
+func TestWithBalancerStopped_Resumed(t *testing.T) {
+	t.Parallel()
+
+	// the balancer stopped by PCSM before the clone was resumed
+	b := &fakeBalancer{enabled: false}
+
+	var recorded []bool
+
+	err := withBalancerStopped(t.Context(), b, true, func(stopped bool) {
+		recorded = append(recorded, stopped)
+	}, func() error {
+		b.calls = append(b.calls, "clone")
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"stop", "clone", "start"}; !slices.Equal(b.calls, want) {
+		t.Errorf("got = %v, want %v", b.calls, want)
+	}
+
+	if want := []bool{true, false}; !slices.Equal(recorded, want) {
+		t.Errorf("stopped by PCSM: got = %v, want %v", recorded, want)
+	}
+
+	if !b.enabled {
+		t.Error("balancer is not enabled after the resumed clone")
+	}
+}
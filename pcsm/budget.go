@@ -0,0 +1,156 @@
+package pcsm
+
+import (
+	"context"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// ErrBudgetExceeded indicates the replication has not reached a terminal state within
+// the max total duration (--max-total-duration).
+var ErrBudgetExceeded = errors.New("max total duration exceeded")
+
+// BudgetAction is the handling of the replication that has exceeded the max total duration.
+type BudgetAction string
+
+const (
+	// BudgetAbort stops the replication and fails it with [ErrBudgetExceeded].
+	BudgetAbort BudgetAction = "abort"
+	// BudgetPause pauses the replication. It can be resumed without the budget.
+	BudgetPause BudgetAction = "pause"
+)
+
+// ParseBudgetAction parses the budget action. The empty string is [BudgetAbort].
+func ParseBudgetAction(s string) (BudgetAction, error) {
+	switch a := BudgetAction(s); a {
+	case "":
+		return BudgetAbort, nil
+	case BudgetAbort, BudgetPause:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid budget action %q", s)
+}
+
+// BudgetStatus is the status of the max total duration of the replication.
+type BudgetStatus struct {
+	// MaxTotalDuration is the max total duration since the start.
+	MaxTotalDuration time.Duration
+	// Deadline is the time the budget is exceeded at.
+	Deadline time.Time
+	// Action is the handling of the exceeded budget.
+	Action BudgetAction
+	// Exceeded indicates the budget has been exceeded and the action is taken.
+	Exceeded bool
+}
+
+// budgetStatus returns the status of the budget. Nil if the max total duration is not set.
+func (ml *PCSM) budgetStatus() *BudgetStatus {
+	if ml.maxTotalDuration <= 0 {
+		return nil
+	}
+
+	return &BudgetStatus{
+		MaxTotalDuration: ml.maxTotalDuration,
+		Deadline:         ml.budgetDeadline,
+		Action:           ml.onBudgetExceeded,
+		Exceeded:         ml.budgetExceeded,
+	}
+}
+
+// watchBudget schedules the budget action at the deadline. It does nothing if the max total
+// duration is not set or the budget has already been exceeded.
+func (ml *PCSM) watchBudget() {
+	if ml.budgetDeadline.IsZero() || ml.budgetExceeded {
+		return
+	}
+
+	deadline := ml.budgetDeadline
+	time.AfterFunc(time.Until(deadline), func() { ml.exceedBudget(deadline) })
+}
+
+// exceedBudget takes the budget action on the replication that has not reached a terminal state
+// by the deadline. The data clone in progress is drained: the action is completed after
+// the in-progress collections are copied (see [PCSM.run]).
+func (ml *PCSM) exceedBudget(deadline time.Time) {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if !ml.budgetDeadline.Equal(deadline) || ml.budgetExceeded {
+		return // restarted with another budget
+	}
+
+	switch ml.state {
+	case StateIdle, StateFinalizing, StateFinalized, StateFailed:
+		return
+	}
+
+	lg := log.New("pcsm:budget")
+
+	if ml.state == StateRunning {
+		cloneStatus := ml.clone.Status()
+		replStatus := ml.repl.Status()
+
+		switch {
+		case cloneStatus.IsRunning():
+			err := ml.clone.Drain()
+			if err != nil {
+				lg.Error(err, "Drain Data Clone")
+
+				return
+			}
+
+			ml.budgetExceeded = true
+			ml.budgetDraining = true
+
+			lg.Warnf("Max total duration %s exceeded. Draining Data Clone to %s",
+				ml.maxTotalDuration, ml.onBudgetExceeded)
+
+			return
+
+		case replStatus.IsRunning():
+			//nolint:contextcheck
+			err := ml.repl.Pause(context.Background())
+			if err != nil {
+				lg.Error(err, "Pause Change Replication")
+
+				return
+			}
+
+		default:
+			// the change replication is starting
+			time.AfterFunc(config.BudgetRetryInterval, func() { ml.exceedBudget(deadline) })
+
+			return
+		}
+	}
+
+	ml.budgetExceeded = true
+
+	lg.Warnf("Max total duration %s exceeded: %s", ml.maxTotalDuration, ml.onBudgetExceeded)
+
+	ml.applyBudgetAction()
+}
+
+// applyBudgetAction sets the state of the stopped replication by the budget action.
+func (ml *PCSM) applyBudgetAction() {
+	ml.budgetDraining = false
+
+	if ml.onBudgetExceeded == BudgetPause {
+		if ml.state != StatePaused {
+			ml.state = StatePaused
+			go ml.onStateChanged(StatePaused)
+		}
+
+		return
+	}
+
+	ml.state = StateFailed
+	ml.err = ErrBudgetExceeded
+	ml.errorCount++
+
+	go ml.onStateChanged(StateFailed)
+}
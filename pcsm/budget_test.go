@@ -0,0 +1,158 @@
+package pcsm //nolint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseBudgetAction(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]BudgetAction{
+		"":      BudgetAbort,
+		"abort": BudgetAbort,
+		"pause": BudgetPause,
+	} {
+		got, err := ParseBudgetAction(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %v %v, want %v", s, got, err, want)
+		}
+	}
+
+	if _, err := ParseBudgetAction("fail"); err == nil {
+		t.Error("fail: got = nil, want error")
+	}
+}
+
+func TestPCSM_ExceedBudget(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	deadline := now.Add(-time.Second)
+
+	newPCSM := func(state State, action BudgetAction, cloneFinished bool) *PCSM {
+		doneSig := make(chan struct{})
+		close(doneSig)
+
+		clone := &Clone{startTime: now.Add(-time.Hour)}
+		if cloneFinished {
+			clone.finishTime = now.Add(-time.Minute)
+		}
+
+		return &PCSM{
+			state:            state,
+			clone:            clone,
+			repl:             &Repl{startTime: now, pauseC: make(chan struct{}, 1), doneSig: doneSig},
+			maxTotalDuration: time.Hour,
+			budgetDeadline:   deadline,
+			onBudgetExceeded: action,
+			onStateChanged:   func(State) {},
+		}
+	}
+
+	waitReplPaused := func(t *testing.T, ml *PCSM) {
+		t.Helper()
+
+		for range 100 {
+			replStatus := ml.repl.Status()
+			if replStatus.IsPaused() {
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		t.Error("Change Replication is not paused")
+	}
+
+	// the change replication is stopped and failed
+	ml := newPCSM(StateRunning, BudgetAbort, true)
+	ml.exceedBudget(deadline)
+	waitReplPaused(t, ml)
+
+	if ml.state != StateFailed || !errors.Is(ml.err, ErrBudgetExceeded) || !ml.budgetExceeded {
+		t.Errorf("abort: got = %s %v, want %s %v", ml.state, ml.err, StateFailed, ErrBudgetExceeded)
+	}
+
+	// the change replication is paused
+	ml = newPCSM(StateRunning, BudgetPause, true)
+	ml.exceedBudget(deadline)
+	waitReplPaused(t, ml)
+
+	if ml.state != StatePaused || ml.err != nil {
+		t.Errorf("pause: got = %s %v, want %s", ml.state, ml.err, StatePaused)
+	}
+
+	if s := ml.budgetStatus(); s == nil || !s.Exceeded || !s.Deadline.Equal(deadline) {
+		t.Errorf("status: got = %+v, want exceeded at %s", s, deadline)
+	}
+
+	// the clone is drained before the action
+	ml = newPCSM(StateRunning, BudgetAbort, false)
+	ml.exceedBudget(deadline)
+
+	if ml.state != StateRunning || !ml.budgetDraining || !ml.clone.draining.Load() {
+		t.Fatalf("drain: got = %s, draining %v, want %s draining", ml.state, ml.budgetDraining, StateRunning)
+	}
+
+	ml.applyBudgetAction()
+
+	if ml.state != StateFailed || ml.budgetDraining {
+		t.Errorf("drained: got = %s, want %s", ml.state, StateFailed)
+	}
+
+	// the paused replication is failed
+	ml = newPCSM(StatePaused, BudgetAbort, true)
+	ml.exceedBudget(deadline)
+
+	if ml.state != StateFailed {
+		t.Errorf("paused: got = %s, want %s", ml.state, StateFailed)
+	}
+
+	// the finalized replication and the replication restarted with another budget are kept
+	ml = newPCSM(StateFinalized, BudgetAbort, true)
+	ml.exceedBudget(deadline)
+
+	if ml.state != StateFinalized || ml.budgetExceeded {
+		t.Errorf("finalized: got = %s, want %s", ml.state, StateFinalized)
+	}
+
+	ml = newPCSM(StatePaused, BudgetAbort, true)
+	ml.exceedBudget(deadline.Add(-time.Hour))
+
+	if ml.state != StatePaused || ml.budgetExceeded {
+		t.Errorf("restarted: got = %s, want %s", ml.state, StatePaused)
+	}
+}
+
+func TestPCSM_WatchBudget(t *testing.T) {
+	t.Parallel()
+
+	ml := &PCSM{
+		state:            StatePaused,
+		maxTotalDuration: 10 * time.Millisecond,
+		budgetDeadline:   time.Now().Add(10 * time.Millisecond),
+		onBudgetExceeded: BudgetAbort,
+		onStateChanged:   func(State) {},
+	}
+
+	ml.lock.Lock()
+	ml.watchBudget()
+	ml.lock.Unlock()
+
+	for range 100 {
+		ml.lock.Lock()
+		state := ml.state
+		ml.lock.Unlock()
+
+		if state == StateFailed {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("got = %s, want %s", ml.state, StateFailed)
+}
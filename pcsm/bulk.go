@@ -5,7 +5,9 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -14,25 +16,38 @@ import (
 
 	"github.com/percona/percona-clustersync-mongodb/errors"
 	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
 )
 
 //nolint:gochecknoglobals
 var yes = true // for ref
 
-//nolint:gochecknoglobals
-var clientBulkOptions = options.ClientBulkWrite().
-	SetOrdered(true).
-	SetBypassDocumentValidation(false)
+// bulkOptions configures the bulk writes of the change replication.
+type bulkOptions struct {
+	// opTimeout is the deadline of a single write attempt. Zero disables the deadline.
+	opTimeout time.Duration
+	// bypassDocumentValidation skips the document validation of the target collections.
+	bypassDocumentValidation bool
+}
 
-//nolint:gochecknoglobals
-var collectionBulkOptions = options.BulkWrite().
-	SetOrdered(true).
-	SetBypassDocumentValidation(false)
+func (o bulkOptions) clientBulkWrite() *options.ClientBulkWriteOptionsBuilder {
+	return options.ClientBulkWrite().
+		SetOrdered(true).
+		SetBypassDocumentValidation(o.bypassDocumentValidation)
+}
+
+func (o bulkOptions) collectionBulkWrite() *options.BulkWriteOptionsBuilder {
+	return options.BulkWrite().
+		SetOrdered(true).
+		SetBypassDocumentValidation(o.bypassDocumentValidation)
+}
 
 type bulkWrite interface {
 	Full() bool
 	Empty() bool
 	Do(ctx context.Context, m *mongo.Client) (int, error)
+	// Reset discards the buffered writes.
+	Reset()
 
 	Insert(ns Namespace, event *InsertEvent)
 	Update(ns Namespace, event *UpdateEvent)
@@ -40,18 +55,53 @@ type bulkWrite interface {
 	Delete(ns Namespace, event *DeleteEvent)
 }
 
+// runWrite runs the write and retries it on a transient error. With a positive op timeout,
+// each attempt is aborted at the deadline (e.g. a write hung on the target) and retried.
+// The write rejected by the target at its connection limit is not retried: it returns
+// the [connectionsExhaustedError].
+func runWrite(
+	ctx context.Context,
+	opTimeout time.Duration,
+	retryInterval time.Duration,
+	write func(ctx context.Context) error,
+) error {
+	return topo.RunWithRetry(ctx, func(ctx context.Context) error { //nolint:wrapcheck
+		var err error
+		if opTimeout <= 0 {
+			err = write(ctx)
+		} else {
+			err = util.CtxWithTimeout(ctx, opTimeout, write)
+		}
+
+		if topo.IsConnectionExhausted(err) {
+			return connectionsExhaustedError{err}
+		}
+
+		return err //nolint:wrapcheck
+	}, retryInterval, topo.DefaultMaxRetries)
+}
+
 type clientBulkWrite struct {
-	writes []mongo.ClientBulkWrite
+	max     int
+	writes  []mongo.ClientBulkWrite
+	options bulkOptions
+
+	// concurrency backs off the write on the exhausted target connections.
+	// The client bulk write is a single write.
+	concurrency *applyConcurrency
 }
 
-func newClientBulkWrite(size int) *clientBulkWrite {
+func newClientBulkWrite(size int, opts bulkOptions) *clientBulkWrite {
 	return &clientBulkWrite{
-		make([]mongo.ClientBulkWrite, 0, size),
+		max:         size,
+		writes:      make([]mongo.ClientBulkWrite, 0, size),
+		options:     opts,
+		concurrency: newApplyConcurrency(1),
 	}
 }
 
 func (o *clientBulkWrite) Full() bool {
-	return len(o.writes) == cap(o.writes)
+	return len(o.writes) >= o.max
 }
 
 func (o *clientBulkWrite) Empty() bool {
@@ -59,22 +109,30 @@ func (o *clientBulkWrite) Empty() bool {
 }
 
 func (o *clientBulkWrite) Do(ctx context.Context, m *mongo.Client) (int, error) {
-	err := topo.RunWithRetry(ctx, func(ctx context.Context) error {
-		_, err := m.BulkWrite(ctx, o.writes, clientBulkOptions)
+	opts := o.options.clientBulkWrite()
+
+	err := o.concurrency.run(ctx, func(ctx context.Context, _ int) error {
+		return runWrite(ctx, o.options.opTimeout, topo.DefaultRetryInterval, func(ctx context.Context) error {
+			_, err := m.BulkWrite(ctx, o.writes, opts)
 
-		return errors.Wrap(err, "bulk write")
-	}, topo.DefaultRetryInterval, topo.DefaultMaxRetries)
+			return errors.Wrap(err, "bulk write")
+		})
+	})
 	if err != nil {
 		return 0, err // nolint:wrapcheck
 	}
 
 	size := len(o.writes)
-	clear(o.writes)
-	o.writes = o.writes[:0]
+	o.Reset()
 
 	return size, nil
 }
 
+func (o *clientBulkWrite) Reset() {
+	clear(o.writes)
+	o.writes = o.writes[:0]
+}
+
 func (o *clientBulkWrite) Insert(ns Namespace, event *InsertEvent) {
 	bw := mongo.ClientBulkWrite{
 		Database:   ns.Database,
@@ -128,20 +186,26 @@ func (o *clientBulkWrite) Delete(ns Namespace, event *DeleteEvent) {
 }
 
 type collectionBulkWrite struct {
-	max    int
-	count  int
-	writes map[Namespace][]mongo.WriteModel
+	max     int
+	count   int
+	writes  map[Namespace][]mongo.WriteModel
+	options bulkOptions
+
+	// concurrency limits the namespaces written concurrently.
+	concurrency *applyConcurrency
 }
 
-func newCollectionBulkWrite(size int) *collectionBulkWrite {
+func newCollectionBulkWrite(size int, opts bulkOptions) *collectionBulkWrite {
 	return &collectionBulkWrite{
-		max:    size,
-		writes: make(map[Namespace][]mongo.WriteModel),
+		max:         size,
+		writes:      make(map[Namespace][]mongo.WriteModel),
+		options:     opts,
+		concurrency: newApplyConcurrency(runtime.NumCPU()),
 	}
 }
 
 func (o *collectionBulkWrite) Full() bool {
-	return o.count == o.max
+	return o.count >= o.max
 }
 
 func (o *collectionBulkWrite) Empty() bool {
@@ -149,39 +213,79 @@ func (o *collectionBulkWrite) Empty() bool {
 }
 
 func (o *collectionBulkWrite) Do(ctx context.Context, m *mongo.Client) (int, error) {
-	var total atomic.Int64
+	opts := o.options.collectionBulkWrite()
 
-	grp, grpCtx := errgroup.WithContext(ctx)
-	grp.SetLimit(runtime.NumCPU())
+	total := 0
 
-	for ns, ops := range o.writes {
-		grp.Go(func() error {
+	err := o.concurrency.run(ctx, func(ctx context.Context, limit int) error {
+		n, err := o.writeNamespaces(ctx, limit, func(ctx context.Context, ns Namespace, ops []mongo.WriteModel) error {
 			mcoll := m.Database(ns.Database).Collection(ns.Collection)
 
-			err := topo.RunWithRetry(ctx, func(_ context.Context) error {
-				_, err := mcoll.BulkWrite(grpCtx, ops, collectionBulkOptions)
+			return runWrite(ctx, o.options.opTimeout, topo.DefaultRetryInterval, func(ctx context.Context) error {
+				_, err := mcoll.BulkWrite(ctx, ops, opts)
 
 				return errors.Wrapf(err, "bulk write %q", ns)
-			}, topo.DefaultRetryInterval, topo.DefaultMaxRetries)
+			})
+		})
+
+		total += n
+
+		return err
+	})
+	if err != nil {
+		return 0, err // nolint:wrapcheck
+	}
+
+	o.Reset()
+
+	return total, nil
+}
+
+// writeNamespaces writes the namespaces with up to limit concurrent writes and returns
+// the number of the written operations. The written namespaces are removed from the bulk,
+// so that a retry writes only the failed ones.
+func (o *collectionBulkWrite) writeNamespaces(
+	ctx context.Context,
+	limit int,
+	write func(ctx context.Context, ns Namespace, ops []mongo.WriteModel) error,
+) (int, error) {
+	var total atomic.Int64
+	var lock sync.Mutex
+	var written []Namespace
+
+	grp, grpCtx := errgroup.WithContext(ctx)
+	grp.SetLimit(limit)
+
+	for ns, ops := range o.writes {
+		grp.Go(func() error {
+			err := write(grpCtx, ns, ops)
 			if err != nil {
-				return err // nolint:wrapcheck
+				return err
 			}
 
 			total.Add(int64(len(ops)))
 
+			lock.Lock()
+			written = append(written, ns)
+			lock.Unlock()
+
 			return nil
 		})
 	}
 
 	err := grp.Wait()
-	if err != nil {
-		return 0, err // nolint:wrapcheck
+
+	for _, ns := range written {
+		o.count -= len(o.writes[ns])
+		delete(o.writes, ns)
 	}
 
+	return int(total.Load()), err // nolint:wrapcheck
+}
+
+func (o *collectionBulkWrite) Reset() {
 	clear(o.writes)
 	o.count = 0
-
-	return int(total.Load()), nil
 }
 
 func (o *collectionBulkWrite) Insert(ns Namespace, event *InsertEvent) {
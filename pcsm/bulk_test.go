@@ -1,7 +1,11 @@
 package pcsm //nolint
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 func TestIsArrayPath(t *testing.T) { //nolint:paralleltest
@@ -39,3 +43,93 @@ func TestIsArrayPath(t *testing.T) { //nolint:paralleltest
 		}
 	}
 }
+
+func TestRunWrite_OpTimeout(t *testing.T) {
+	t.Parallel()
+
+	const opTimeout = 50 * time.Millisecond
+
+	var attempts []time.Duration
+
+	// the first attempt hangs until aborted, the second succeeds
+	write := func(ctx context.Context) error {
+		start := time.Now()
+
+		if len(attempts) == 0 {
+			<-ctx.Done()
+			attempts = append(attempts, time.Since(start))
+
+			return ctx.Err()
+		}
+
+		attempts = append(attempts, time.Since(start))
+
+		return nil
+	}
+
+	err := runWrite(t.Context(), opTimeout, time.Millisecond, write)
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+
+	if len(attempts) != 2 { //nolint:mnd
+		t.Fatalf("got %d attempts, want 2", len(attempts))
+	}
+
+	if attempts[0] < opTimeout || attempts[0] > 10*opTimeout {
+		t.Errorf("hung write aborted after %s, want about %s", attempts[0], opTimeout)
+	}
+}
+
+func TestRunWrite_NoOpTimeout(t *testing.T) {
+	t.Parallel()
+
+	err := runWrite(t.Context(), 0, time.Millisecond, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("got deadline, want none")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+}
+
+func TestBulkOptions_BypassDocumentValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, bypass := range []bool{true, false} {
+		opts := ReplOptions{BypassDocumentValidation: bypass}.bulkOptions()
+
+		var clientOpts options.ClientBulkWriteOptions
+
+		for _, set := range opts.clientBulkWrite().List() {
+			err := set(&clientOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := clientOpts.BypassDocumentValidation; got == nil || *got != bypass {
+			t.Errorf("client bulk write: got = %v, want %v", got, bypass)
+		}
+
+		var collOpts options.BulkWriteOptions
+
+		for _, set := range opts.collectionBulkWrite().List() {
+			err := set(&collOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := collOpts.BypassDocumentValidation; got == nil || *got != bypass {
+			t.Errorf("collection bulk write: got = %v, want %v", got, bypass)
+		}
+
+		if collOpts.Ordered == nil || !*collOpts.Ordered || clientOpts.Ordered == nil || !*clientOpts.Ordered {
+			t.Error("got unordered bulk write, want ordered")
+		}
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"encoding/hex"
 	"math"
+	"slices"
 	"strings"
 	"sync"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/percona/percona-clustersync-mongodb/errors"
 	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
 	"github.com/percona/percona-clustersync-mongodb/topo"
 )
 
@@ -35,6 +38,8 @@ type UUIDMap map[string]Namespace
 type CreateCollectionOptions struct {
 	// ClusteredIndex is the clustered index for the collection.
 	ClusteredIndex bson.D `bson:"clusteredIndex,omitempty"`
+	// ExpireAfterSeconds is the TTL in seconds for documents in a clustered collection.
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds,omitempty"`
 
 	// Capped  is if the collection is capped.
 	Capped *bool `bson:"capped,omitempty"`
@@ -79,11 +84,59 @@ type ModifyIndexOption struct {
 	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds,omitempty"`
 }
 
+// KeyTooLongAction is the handling of an index build that fails on the target
+// because of the keys longer than the index key limit of the target.
+type KeyTooLongAction string
+
+const (
+	// KeyTooLongSkip skips the index. The index is retried on finalization.
+	KeyTooLongSkip KeyTooLongAction = "skip"
+	// KeyTooLongFail fails the replication.
+	KeyTooLongFail KeyTooLongAction = "fail"
+)
+
+// ParseKeyTooLongAction parses the key too long action. The empty string is [KeyTooLongSkip].
+func ParseKeyTooLongAction(s string) (KeyTooLongAction, error) {
+	switch a := KeyTooLongAction(s); a {
+	case "":
+		return KeyTooLongSkip, nil
+	case KeyTooLongSkip, KeyTooLongFail:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid key too long action %q", s)
+}
+
+// CatalogOptions configures the catalog.
+type CatalogOptions struct {
+	// OnKeyTooLong is the handling of an index build failed because of too long keys.
+	OnKeyTooLong KeyTooLongAction
+	// KeepTargetTTL keeps the TTL indexes of the target active during the replication.
+	// By default, the target TTL indexes do not expire documents until finalization,
+	// and the documents are deleted by the replicated TTL deletes of the source.
+	KeepTargetTTL bool
+	// ReplicateHiddenIndexes creates the hidden indexes as hidden on the target and applies
+	// the hidden toggles of collMod during the replication. By default, the indexes are visible
+	// on the target until finalization.
+	ReplicateHiddenIndexes bool
+	// RenameCollisionSuffix is the suffix of the target collections that the existing
+	// target collections are cloned into. The suffixed collections replace the existing
+	// collections on finalization. An empty suffix drops the existing collections.
+	RenameCollisionSuffix string
+	// TargetDatabasePrefix is prepended to the name of each target database
+	// (e.g. "test_" for a sandbox copy of a production source).
+	TargetDatabasePrefix string
+}
+
 // Catalog manages the MongoDB catalog.
 type Catalog struct {
 	lock      sync.RWMutex
 	target    *mongo.Client
+	options   CatalogOptions
 	Databases map[string]databaseCatalog
+
+	swapLock sync.Mutex
+	swaps    map[Namespace]struct{} // the namespaces cloned into the suffixed collections
 }
 
 type databaseCatalog struct {
@@ -108,15 +161,17 @@ func (i indexCatalogEntry) Unsuccessful() bool {
 }
 
 // NewCatalog creates a new Catalog.
-func NewCatalog(target *mongo.Client) *Catalog {
+func NewCatalog(target *mongo.Client, options CatalogOptions) *Catalog {
 	return &Catalog{
 		target:    target,
+		options:   options,
 		Databases: make(map[string]databaseCatalog),
 	}
 }
 
 type catalogCheckpoint struct {
 	Catalog map[string]databaseCatalog `bson:"catalog"`
+	Swaps   []Namespace                `bson:"swaps,omitempty"`
 }
 
 func (c *Catalog) LockWrite() {
@@ -139,7 +194,7 @@ func (c *Catalog) Checkpoint() *catalogCheckpoint { //nolint:revive
 		return nil
 	}
 
-	return &catalogCheckpoint{Catalog: c.Databases}
+	return &catalogCheckpoint{Catalog: c.Databases, Swaps: c.swappedNamespaces()}
 }
 
 func (c *Catalog) Recover(cp *catalogCheckpoint) error {
@@ -152,6 +207,13 @@ func (c *Catalog) Recover(cp *catalogCheckpoint) error {
 
 	c.Databases = cp.Catalog
 
+	c.swapLock.Lock()
+	c.swaps = make(map[Namespace]struct{}, len(cp.Swaps))
+	for _, ns := range cp.Swaps {
+		c.swaps[ns] = struct{}{}
+	}
+	c.swapLock.Unlock()
+
 	return nil
 }
 
@@ -179,9 +241,64 @@ func (c *Catalog) doCreateCollection(
 	coll string,
 	opts *CreateCollectionOptions,
 ) error {
+	create := func(opts *CreateCollectionOptions) error {
+		cmd := buildCreateCollectionCmd(c.targetCollection(db, coll), opts)
+
+		return runWithRetry(ctx, func(ctx context.Context) error {
+			err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
+
+			return errors.Wrapf(err, "create collection %s.%s", db, coll)
+		})
+	}
+
+	err := create(opts)
+	if err != nil && topo.IsInvalidStorageEngineOptions(err) && hasStorageEngineOptions(opts) {
+		log.Ctx(ctx).Warnf("Collection %s.%s: the storage engine options are not supported by the target "+
+			"(%v). The collection is created without them: storageEngine: %v, indexOptionDefaults: %v",
+			db, coll, err, opts.StorageEngine, opts.IndexOptionDefaults)
+
+		err = create(withoutStorageEngineOptions(opts))
+	}
+	if err != nil && !topo.IsNamespaceExists(err) {
+		return err //nolint:wrapcheck
+	}
+
+	log.Ctx(ctx).Debugf("Created collection %s.%s", db, coll)
+
+	c.lock.Lock()
+	c.addCollectionToCatalog(ctx, db, coll)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// hasStorageEngineOptions returns true if the collection has the storage engine options
+// of the collection or its indexes.
+func hasStorageEngineOptions(opts *CreateCollectionOptions) bool {
+	return opts.StorageEngine != nil || opts.IndexOptionDefaults != nil
+}
+
+// withoutStorageEngineOptions returns a copy of the options without the storage engine options
+// of the collection and its indexes.
+func withoutStorageEngineOptions(opts *CreateCollectionOptions) *CreateCollectionOptions {
+	rv := *opts
+	rv.StorageEngine = nil
+	rv.IndexOptionDefaults = nil
+
+	return &rv
+}
+
+// buildCreateCollectionCmd builds the create command with all collection options.
+// The options must not be applied by a follow-up command (e.g. collMod): a single create
+// is atomic, so a crash cannot leave the collection on the target partially configured.
+func buildCreateCollectionCmd(coll string, opts *CreateCollectionOptions) bson.D {
 	cmd := bson.D{{"create", coll}}
 	if opts.ClusteredIndex != nil {
 		cmd = append(cmd, bson.E{"clusteredIndex", opts.ClusteredIndex})
+
+		if opts.ExpireAfterSeconds != nil {
+			cmd = append(cmd, bson.E{"expireAfterSeconds", opts.ExpireAfterSeconds})
+		}
 	}
 
 	if opts.Capped != nil {
@@ -223,22 +340,7 @@ func (c *Catalog) doCreateCollection(
 		cmd = append(cmd, bson.E{"indexOptionDefaults", opts.IndexOptionDefaults})
 	}
 
-	err := runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
-
-		return errors.Wrapf(err, "create collection %s.%s", db, coll)
-	})
-	if err != nil && !topo.IsNamespaceExists(err) {
-		return err //nolint:wrapcheck
-	}
-
-	log.Ctx(ctx).Debugf("Created collection %s.%s", db, coll)
-
-	c.lock.Lock()
-	c.addCollectionToCatalog(ctx, db, coll)
-	c.lock.Unlock()
-
-	return nil
+	return cmd
 }
 
 // doCreateView creates a new view in the target MongoDB.
@@ -259,7 +361,7 @@ func (c *Catalog) doCreateView(
 	}
 
 	err := runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 		return errors.Wrapf(err, "create view %s.%s", db, view)
 	})
@@ -275,7 +377,7 @@ func (c *Catalog) doCreateView(
 // DropCollection drops a collection in the target MongoDB.
 func (c *Catalog) DropCollection(ctx context.Context, db, coll string) error {
 	err := runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).Collection(coll).Drop(ctx)
+		err := c.target.Database(c.targetDatabase(db)).Collection(c.targetCollection(db, coll)).Drop(ctx)
 
 		return errors.Wrapf(err, "drop collection %s.%s", db, coll)
 	})
@@ -292,11 +394,13 @@ func (c *Catalog) DropCollection(ctx context.Context, db, coll string) error {
 	return nil
 }
 
-// DropDatabase drops a database in the target MongoDB.
-func (c *Catalog) DropDatabase(ctx context.Context, db string) error {
+// DropDatabase drops the collections of a database in the target MongoDB that are allowed
+// by the filter. The excluded collections of the database are kept. A missing database is no-op,
+// so the drop can be applied again.
+func (c *Catalog) DropDatabase(ctx context.Context, db string, filter sel.NSFilter) error {
 	lg := log.Ctx(ctx)
 
-	colls, err := topo.ListCollectionNames(ctx, c.target, db)
+	colls, err := topo.ListCollectionNames(ctx, c.target, c.targetDatabase(db))
 	if err != nil {
 		return errors.Wrap(err, "list collection names")
 	}
@@ -304,9 +408,15 @@ func (c *Catalog) DropDatabase(ctx context.Context, db string) error {
 	eg, grpCtx := errgroup.WithContext(ctx)
 
 	for _, coll := range colls {
+		if !filter(db, coll) {
+			lg.Debugf("Keep excluded collection %s.%s", db, coll)
+
+			continue
+		}
+
 		eg.Go(func() error {
 			err := runWithRetry(grpCtx, func(ctx context.Context) error {
-				err := c.target.Database(db).Collection(coll).Drop(ctx)
+				err := c.target.Database(c.targetDatabase(db)).Collection(coll).Drop(ctx)
 
 				return errors.Wrapf(err, "drop namespace %s.%s", db, coll)
 			})
@@ -323,6 +433,7 @@ func (c *Catalog) DropDatabase(ctx context.Context, db string) error {
 	lg.Debugf("Dropped database %s", db)
 
 	c.deleteDatabaseFromCatalog(ctx, db)
+	c.forgetDatabaseSwaps(db)
 
 	return eg.Wait() //nolint:wrapcheck
 }
@@ -363,7 +474,7 @@ func (c *Catalog) CreateIndexes(
 			lg.Info("Create prepareUnique index as non-unique: " + index.Name)
 		}
 
-		if index.ExpireAfterSeconds != nil {
+		if index.ExpireAfterSeconds != nil && !c.options.KeepTargetTTL {
 			maxDuration := int64(math.MaxInt32)
 			idxCopy := *index
 			idxCopy.ExpireAfterSeconds = &maxDuration
@@ -371,7 +482,7 @@ func (c *Catalog) CreateIndexes(
 			lg.Info("Create TTL index with modified expireAfterSeconds value: " + index.Name)
 		}
 
-		if index.Hidden != nil && *index.Hidden {
+		if index.Hidden != nil && *index.Hidden && !c.options.ReplicateHiddenIndexes {
 			idxCopy := *index
 			idxCopy.Hidden = nil
 			index = &idxCopy
@@ -407,14 +518,24 @@ func (c *Catalog) CreateIndexes(
 	// which does not support `prepareUnique`.
 	for _, index := range idxs {
 		err := runWithRetry(ctx, func(ctx context.Context) error {
-			err := c.target.Database(db).RunCommand(ctx, bson.D{
-				{"createIndexes", coll},
+			err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, bson.D{
+				{"createIndexes", c.targetCollection(db, coll)},
 				{"indexes", bson.A{index}},
 			}).Err()
 
 			return errors.Wrapf(err, "create index %s.%s.%s", db, coll, index.Name)
 		})
 		if err != nil {
+			if topo.IsKeyTooLong(err) {
+				var fail bool
+
+				fail, err = handleKeyTooLong(ctx, c.options.OnKeyTooLong, db, coll, index, err,
+					c.findTargetIDs(db, coll))
+				if fail {
+					return err
+				}
+			}
+
 			processedIdxs[index.Name] = err
 
 			continue
@@ -529,7 +650,7 @@ func (c *Catalog) ModifyCappedCollection(
 	sizeBytes *int64,
 	maxDocs *int64,
 ) error {
-	cmd := bson.D{{"collMod", coll}}
+	cmd := bson.D{{"collMod", c.targetCollection(db, coll)}}
 	if sizeBytes != nil {
 		cmd = append(cmd, bson.E{"cappedSize", sizeBytes})
 	}
@@ -539,7 +660,7 @@ func (c *Catalog) ModifyCappedCollection(
 	}
 
 	return runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 		return errors.Wrapf(err, "modify capped collection %s.%s", db, coll)
 	}) //nolint:wrapcheck
@@ -554,7 +675,7 @@ func (c *Catalog) ModifyView(ctx context.Context, db, view, viewOn string, pipel
 	}
 
 	return runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 		return errors.Wrapf(err, "modify view %s.%s", db, view)
 	}) //nolint:wrapcheck
@@ -567,12 +688,12 @@ func (c *Catalog) ModifyChangeStreamPreAndPostImages(
 	enabled bool,
 ) error {
 	cmd := bson.D{
-		{"collMod", coll},
+		{"collMod", c.targetCollection(db, coll)},
 		{"changeStreamPreAndPostImages", bson.D{{"enabled", enabled}}},
 	}
 
 	return runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 		return errors.Wrapf(err, "modify changeStreamPreAndPostImages %s.%s", db, coll)
 	}) //nolint:wrapcheck
@@ -587,7 +708,7 @@ func (c *Catalog) ModifyValidation(
 	validationLevel *string,
 	validationAction *string,
 ) error {
-	cmd := bson.D{{"collMod", coll}}
+	cmd := bson.D{{"collMod", c.targetCollection(db, coll)}}
 	if validator != nil {
 		cmd = append(cmd, bson.E{"validator", validator})
 	}
@@ -601,7 +722,7 @@ func (c *Catalog) ModifyValidation(
 	}
 
 	return runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 		return errors.Wrapf(err, "modify validation %s.%s", db, coll)
 	}) //nolint:wrapcheck
@@ -610,21 +731,43 @@ func (c *Catalog) ModifyValidation(
 // ModifyIndex modifies an index in the target MongoDB.
 func (c *Catalog) ModifyIndex(ctx context.Context, db, coll string, mods *ModifyIndexOption) error {
 	if mods.ExpireAfterSeconds != nil {
+		var expireAfterSeconds int64 = math.MaxInt32
+		if c.options.KeepTargetTTL {
+			expireAfterSeconds = *mods.ExpireAfterSeconds
+		}
+
 		cmd := bson.D{
-			{"collMod", coll},
+			{"collMod", c.targetCollection(db, coll)},
 			{"index", bson.D{
 				{"name", mods.Name},
-				{"expireAfterSeconds", math.MaxInt32},
+				{"expireAfterSeconds", expireAfterSeconds},
 			}},
 		}
 
 		err := runWithRetry(ctx, func(ctx context.Context) error {
-			err := c.target.Database(db).RunCommand(ctx, cmd).Err()
+			err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, cmd).Err()
 
 			return errors.Wrapf(err, "modify index %s.%s.%s", db, coll, mods.Name)
 		})
 		if err != nil {
-			return err //nolint:wrapcheck
+			if !topo.IsIndexNotFound(err) {
+				return err //nolint:wrapcheck
+			}
+
+			// the change is applied again after the index is dropped (e.g. after a restart)
+			log.Ctx(ctx).Warnf("Modify index %q: index not found on the target", mods.Name)
+		}
+	}
+
+	if mods.Hidden != nil && c.options.ReplicateHiddenIndexes {
+		// setting the current value again is a no-op on the target
+		err := c.doModifyIndexOption(ctx, db, coll, mods.Name, "hidden", *mods.Hidden)
+		if err != nil {
+			if !topo.IsIndexNotFound(err) {
+				return err
+			}
+
+			log.Ctx(ctx).Warnf("Modify index %q hidden: index not found on the target", mods.Name)
 		}
 	}
 
@@ -660,8 +803,8 @@ func (c *Catalog) Rename(ctx context.Context, db, coll, targetDB, targetColl str
 	lg := log.Ctx(ctx)
 
 	opts := bson.D{
-		{"renameCollection", db + "." + coll},
-		{"to", targetDB + "." + targetColl},
+		{"renameCollection", c.targetDatabase(db) + "." + c.targetCollection(db, coll)},
+		{"to", c.targetDatabase(targetDB) + "." + c.targetCollection(targetDB, targetColl)},
 		{"dropTarget", true},
 	}
 
@@ -692,7 +835,7 @@ func (c *Catalog) DropIndex(ctx context.Context, db, coll, index string) error {
 	lg := log.Ctx(ctx)
 
 	err := runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).Collection(coll).Indexes().DropOne(ctx, index)
+		err := c.target.Database(c.targetDatabase(db)).Collection(c.targetCollection(db, coll)).Indexes().DropOne(ctx, index)
 
 		return errors.Wrapf(err, "drop index %s.%s.%s", db, coll, index)
 	})
@@ -776,7 +919,8 @@ func (c *Catalog) UUIDMap() UUIDMap {
 	return uuidMap
 }
 
-// Finalize finalizes the indexes in the target MongoDB.
+// Finalize finalizes the indexes in the target MongoDB and replaces the existing target
+// collections by the collections cloned with [CatalogOptions.RenameCollisionSuffix].
 func (c *Catalog) Finalize(ctx context.Context) error {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -819,6 +963,10 @@ func (c *Catalog) Finalize(ctx context.Context) error {
 
 					err = c.doModifyIndexOption(ctx, db, coll, index.Name, "unique", true)
 					if err != nil {
+						if violations, ok := topo.UniqueIndexViolations(err); ok {
+							c.reportUniqueIndexViolations(ctx, db, coll, index.IndexSpecification, violations)
+						}
+
 						idxErrors = append(idxErrors,
 							errors.Wrap(err, "convert to unique: "+index.Name))
 
@@ -873,7 +1021,196 @@ func (c *Catalog) Finalize(ctx context.Context) error {
 		lg.Errorf(errors.Join(idxErrors...), "Finalize indexes")
 	}
 
-	return nil
+	return c.finalizeSwaps(ctx)
+}
+
+// maxReportedViolations is the maximum number of the duplicate keys or the documents with
+// too long keys reported per index.
+const maxReportedViolations = 10
+
+// keyTooLongReportSize is the total size of the string key values of a document reported as
+// too long for the 1024-byte index key limit. The limit includes the key overhead.
+const keyTooLongReportSize = 1000
+
+// findIDsFunc returns the _id values of up to limit documents matching the filter.
+type findIDsFunc func(ctx context.Context, filter bson.D, limit int) ([]any, error)
+
+// findTargetIDs returns the [findIDsFunc] of the target collection of the namespace.
+func (c *Catalog) findTargetIDs(db, coll string) findIDsFunc {
+	return func(ctx context.Context, filter bson.D, limit int) ([]any, error) {
+		targetColl := c.target.Database(c.targetDatabase(db)).Collection(c.targetCollection(db, coll))
+
+		cur, err := targetColl.Aggregate(ctx, mongo.Pipeline{
+			{{"$match", filter}},
+			{{"$project", bson.D{{"_id", 1}}}},
+			{{"$limit", limit}},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "aggregate")
+		}
+
+		var docs []struct {
+			ID any `bson:"_id"`
+		}
+
+		err = cur.All(ctx, &docs)
+		if err != nil {
+			return nil, errors.Wrap(err, "all")
+		}
+
+		ids := make([]any, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.ID
+		}
+
+		return ids, nil
+	}
+}
+
+// handleKeyTooLong logs the _id values of the target documents with the index keys too long
+// for the index key limit of the target, up to [maxReportedViolations]. It returns the index
+// build error with the _id values and true if the error fails the index creation
+// ([KeyTooLongFail]). Otherwise, the index is skipped and retried on finalization.
+func handleKeyTooLong(
+	ctx context.Context,
+	action KeyTooLongAction,
+	db string,
+	coll string,
+	index *topo.IndexSpecification,
+	buildErr error,
+	findIDs findIDsFunc,
+) (bool, error) {
+	fail := action == KeyTooLongFail
+
+	match := keyTooLongMatch(index.KeysDocument)
+	if match == nil {
+		return fail, buildErr // no ascending or descending string keys to check
+	}
+
+	lg := log.Ctx(ctx)
+
+	ids, err := findIDs(ctx, match, maxReportedViolations)
+	if err != nil {
+		lg.Warnf("Index %s on %s.%s has too long keys (find documents: %v)", index.Name, db, coll, err)
+
+		return fail, buildErr
+	}
+
+	if len(ids) > maxReportedViolations {
+		ids = ids[:maxReportedViolations]
+	}
+
+	for _, id := range ids {
+		lg.Warnf("Index %s on %s.%s: key too long in document _id %v", index.Name, db, coll, id)
+	}
+
+	return fail, errors.Wrapf(buildErr, "key too long: _id %v", ids)
+}
+
+// keyTooLongMatch returns the $match expression of the documents with the total size of
+// the string values of the ascending or descending index keys at least [keyTooLongReportSize].
+// It returns nil if the index has no such keys (e.g. a hashed or text index).
+func keyTooLongMatch(keys bson.Raw) bson.D {
+	elems, _ := keys.Elements()
+
+	sizes := bson.A{}
+
+	for _, elem := range elems {
+		if elem.Value().Type == bson.TypeString {
+			continue // special index type
+		}
+
+		field := "$" + elem.Key()
+		sizes = append(sizes, bson.D{{"$cond", bson.A{
+			bson.D{{"$eq", bson.A{bson.D{{"$type", field}}, "string"}}},
+			bson.D{{"$strLenBytes", field}},
+			0,
+		}}})
+	}
+
+	if len(sizes) == 0 {
+		return nil
+	}
+
+	return bson.D{{"$expr", bson.D{{"$gte", bson.A{
+		bson.D{{"$add", sizes}},
+		keyTooLongReportSize,
+	}}}}}
+}
+
+// reportUniqueIndexViolations logs the duplicate keys that prevent the conversion of the index
+// to unique, with the _id values of the conflicting documents to clean up on the target.
+func (c *Catalog) reportUniqueIndexViolations(
+	ctx context.Context,
+	db string,
+	coll string,
+	index *topo.IndexSpecification,
+	violations [][]any,
+) {
+	lg := log.Ctx(ctx)
+
+	lg.Warnf("Unique index %s on %s.%s has %d duplicate keys", index.Name, db, coll, len(violations))
+
+	paths := indexKeyPaths(index.KeysDocument)
+
+	projection := make(bson.D, len(paths))
+	for i, path := range paths {
+		projection[i] = bson.E{path, 1}
+	}
+
+	for _, ids := range violations[:min(len(violations), maxReportedViolations)] {
+		var doc bson.Raw
+
+		err := c.target.Database(c.targetDatabase(db)).Collection(c.targetCollection(db, coll)).
+			FindOne(ctx, bson.D{{"_id", bson.D{{"$in", ids}}}},
+				options.FindOne().SetProjection(projection)).
+			Decode(&doc)
+		if err != nil {
+			lg.Warnf("Duplicate key of unique index %s on %s.%s: _id %v (find key: %v)",
+				index.Name, db, coll, ids, err)
+
+			continue
+		}
+
+		key, _ := bson.MarshalExtJSON(indexKey(paths, doc), false, false)
+
+		lg.Warnf("Duplicate key %s of unique index %s on %s.%s: _id %v",
+			key, index.Name, db, coll, ids)
+	}
+
+	if len(violations) > maxReportedViolations {
+		lg.Warnf("Unique index %s on %s.%s: %d more duplicate keys are not reported",
+			index.Name, db, coll, len(violations)-maxReportedViolations)
+	}
+}
+
+// indexKeyPaths returns the field paths of the index keys document.
+func indexKeyPaths(keys bson.Raw) []string {
+	elems, _ := keys.Elements()
+
+	paths := make([]string, len(elems))
+	for i, elem := range elems {
+		paths[i] = elem.Key()
+	}
+
+	return paths
+}
+
+// indexKey returns the values of the index key fields of the document.
+// A missing field is null.
+func indexKey(paths []string, doc bson.Raw) bson.D {
+	key := make(bson.D, len(paths))
+
+	for i, path := range paths {
+		key[i].Key = path
+
+		val, err := doc.LookupErr(strings.Split(path, ".")...)
+		if err == nil {
+			key[i].Value = val
+		}
+	}
+
+	return key
 }
 
 // finalizeUnsuccessfulIndexes finalizes indexes that were unsuccessful
@@ -900,8 +1237,8 @@ func (c *Catalog) finalizeUnsuccessfulIndexes(ctx context.Context) {
 				}
 
 				err := runWithRetry(ctx, func(ctx context.Context) error {
-					err := c.target.Database(db).RunCommand(ctx, bson.D{
-						{"createIndexes", coll},
+					err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, bson.D{
+						{"createIndexes", c.targetCollection(db, coll)},
 						{"indexes", bson.A{index.IndexSpecification}},
 					}).Err()
 
@@ -932,8 +1269,8 @@ func (c *Catalog) doModifyIndexOption(
 	value any,
 ) error {
 	return runWithRetry(ctx, func(ctx context.Context) error {
-		err := c.target.Database(db).RunCommand(ctx, bson.D{
-			{"collMod", coll},
+		err := c.target.Database(c.targetDatabase(db)).RunCommand(ctx, bson.D{
+			{"collMod", c.targetCollection(db, coll)},
 			{"index", bson.D{
 				{"name", index},
 				{propName, value},
@@ -1074,6 +1411,21 @@ func (c *Catalog) removeIndexFromCatalog(ctx context.Context, db, coll, index st
 	lg.Warnf("remove index: index %q not found in namespace %q", index, db+"."+coll)
 }
 
+// collectionNames returns the names of the catalog collections of the database.
+func (c *Catalog) collectionNames(db string) []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	names := make([]string, 0, len(c.Databases[db].Collections))
+	for coll := range c.Databases[db].Collections {
+		names = append(names, coll)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
 // addCollectionToCatalog adds a collection to the catalog.
 func (c *Catalog) addCollectionToCatalog(ctx context.Context, db, coll string) {
 	lg := log.Ctx(ctx)
@@ -1096,6 +1448,35 @@ func (c *Catalog) addCollectionToCatalog(ctx context.Context, db, coll string) {
 	lg.Debugf("Collection added to catalog %s.%s", db, coll)
 }
 
+// adoptCollection adds the collection that exists on the target to the catalog with the indexes
+// without creating them. The indexes are finalized as the indexes created by [Catalog.CreateIndexes].
+func (c *Catalog) adoptCollection(
+	ctx context.Context,
+	db string,
+	coll string,
+	uuid *bson.Binary,
+	indexes []*topo.IndexSpecification,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.addCollectionToCatalog(ctx, db, coll)
+
+	if len(indexes) != 0 {
+		entries := make([]indexCatalogEntry, len(indexes))
+		for i, index := range indexes {
+			entries[i] = indexCatalogEntry{IndexSpecification: index}
+		}
+
+		c.addIndexesToCatalog(ctx, db, coll, entries)
+	}
+
+	dbCat := c.Databases[db]
+	collCat := dbCat.Collections[coll]
+	collCat.UUID = uuid
+	dbCat.Collections[coll] = collCat
+}
+
 // deleteCollectionFromCatalog deletes a collection entry from the catalog.
 func (c *Catalog) deleteCollectionFromCatalog(ctx context.Context, db, coll string) {
 	databaseEntry, ok := c.Databases[db]
@@ -1161,7 +1542,7 @@ func (c *Catalog) ShardCollection(
 	unique bool,
 ) error {
 	cmd := bson.D{
-		{"shardCollection", db + "." + coll},
+		{"shardCollection", c.targetDatabase(db) + "." + c.targetCollection(db, coll)},
 		{"key", shardKey},
 		{"collation", bson.D{{"locale", "simple"}}},
 	}
@@ -0,0 +1,301 @@
+package pcsm //nolint
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+func TestBuildCreateCollectionCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options bson.D
+	}{
+		{
+			name: "capped with validation",
+			options: bson.D{
+				{"capped", true},
+				{"size", int64(4096)},
+				{"max", int32(100)},
+				{"collation", bson.D{{"locale", "fr"}, {"strength", int32(2)}}},
+				{"changeStreamPreAndPostImages", bson.D{{"enabled", true}}},
+				{"validator", bson.D{{"a", bson.D{{"$exists", true}}}}},
+				{"validationLevel", "moderate"},
+				{"validationAction", "warn"},
+				{"storageEngine", bson.D{{"wiredTiger", bson.D{}}}},
+				{"indexOptionDefaults", bson.D{{"storageEngine", bson.D{}}}},
+			},
+		},
+		{
+			name: "clustered with ttl",
+			options: bson.D{
+				{"clusteredIndex", bson.D{{"key", bson.D{{"_id", 1}}}, {"unique", true}}},
+				{"expireAfterSeconds", int64(3600)},
+				{"collation", bson.D{{"locale", "en"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the same flow as on clone: the source collection options are decoded
+			// into [CreateCollectionOptions] and the create command is built.
+			raw, err := bson.Marshal(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var opts CreateCollectionOptions
+
+			err = bson.Unmarshal(raw, &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := buildCreateCollectionCmd("coll", &opts)
+
+			if cmd[0].Key != "create" || cmd[0].Value != "coll" {
+				t.Fatalf("got first element = %v, want create: coll", cmd[0])
+			}
+
+			// every option must be set by the single create command. otherwise, a crash
+			// before a follow-up command leaves the collection partially configured.
+			keys := make(map[string]bool, len(cmd))
+			for _, e := range cmd[1:] {
+				keys[e.Key] = true
+			}
+
+			for _, e := range tt.options {
+				if !keys[e.Key] {
+					t.Errorf("option %q is missing in the create command", e.Key)
+				}
+			}
+
+			if len(cmd)-1 != len(tt.options) {
+				t.Errorf("got %d options, want %d", len(cmd)-1, len(tt.options))
+			}
+		})
+	}
+}
+
+func TestIndexOptionDefaultsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	indexOptionDefaults := bson.D{
+		{"storageEngine", bson.D{{"wiredTiger", bson.D{{"configString", "block_compressor=zlib"}}}}},
+	}
+	want := mustMarshal(t, indexOptionDefaults)
+
+	fromSpec := func() CreateCollectionOptions {
+		var opts CreateCollectionOptions
+
+		err := bson.Unmarshal(mustMarshal(t, bson.D{{"indexOptionDefaults", indexOptionDefaults}}), &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return opts
+	}
+
+	fromChangeStream := func() CreateCollectionOptions {
+		var change ChangeEvent
+
+		err := parseChangeEvent(mustMarshal(t, bson.D{
+			{"operationType", "create"},
+			{"ns", bson.D{{"db", "db_1"}, {"coll", "coll_1"}}},
+			{"operationDescription", bson.D{{"indexOptionDefaults", indexOptionDefaults}}},
+		}), &change)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return change.Event.(CreateEvent).OperationDescription //nolint:forcetypeassert
+	}
+
+	fromOplog := func() CreateCollectionOptions {
+		changes := parseOplogEntry(t, newOplogParser(), bson.D{
+			{"ts", bson.Timestamp{T: 100, I: 1}},
+			{"op", "c"},
+			{"ns", "db_1.$cmd"},
+			{"o", bson.D{{"create", "coll_1"}, {"indexOptionDefaults", indexOptionDefaults}}},
+		})
+
+		return changes[0].Event.(CreateEvent).OperationDescription //nolint:forcetypeassert
+	}
+
+	for name, options := range map[string]func() CreateCollectionOptions{
+		"clone":         fromSpec,
+		"change stream": fromChangeStream,
+		"oplog":         fromOplog,
+	} {
+		opts := options()
+		cmd := buildCreateCollectionCmd("coll_1", &opts)
+
+		var got bson.Raw
+		for _, e := range cmd {
+			if e.Key == "indexOptionDefaults" {
+				got, _ = e.Value.(bson.Raw)
+			}
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got indexOptionDefaults = %v, want %v", name, got, bson.Raw(want))
+		}
+	}
+}
+
+func TestWithoutStorageEngineOptions(t *testing.T) {
+	t.Parallel()
+
+	capped := true
+	opts := &CreateCollectionOptions{
+		Capped:              &capped,
+		StorageEngine:       mustMarshal(t, bson.D{{"wiredTiger", bson.D{{"configString", "block_compressor=zstd"}}}}),
+		IndexOptionDefaults: mustMarshal(t, bson.D{{"storageEngine", bson.D{{"wiredTiger", bson.D{}}}}}),
+	}
+
+	if !hasStorageEngineOptions(opts) {
+		t.Fatal("got no storage engine options")
+	}
+
+	got := withoutStorageEngineOptions(opts)
+	if hasStorageEngineOptions(got) {
+		t.Errorf("got storage engine options: %v, %v", got.StorageEngine, got.IndexOptionDefaults)
+	}
+
+	if got.Capped == nil || !*got.Capped {
+		t.Error("got other options removed")
+	}
+
+	if opts.StorageEngine == nil || opts.IndexOptionDefaults == nil {
+		t.Error("got the original options modified")
+	}
+
+	cmd := buildCreateCollectionCmd("coll", got)
+	for _, e := range cmd {
+		if e.Key == "storageEngine" || e.Key == "indexOptionDefaults" {
+			t.Errorf("got %q in the create command", e.Key)
+		}
+	}
+}
+
+func TestIndexKey(t *testing.T) {
+	t.Parallel()
+
+	paths := indexKeyPaths(mustMarshal(t, bson.D{{"email", 1}, {"address.city", -1}, {"tag", 1}}))
+	doc := mustMarshal(t, bson.D{
+		{"_id", 1},
+		{"email", "User@Example.com"},
+		{"address", bson.D{{"city", "Zürich"}}},
+	})
+
+	got, err := bson.MarshalExtJSON(indexKey(paths, doc), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"email":"User@Example.com","address.city":"Zürich","tag":null}`
+	if string(got) != want {
+		t.Errorf("got = %s, want %s", got, want)
+	}
+}
+
+func TestKeyTooLongMatch(t *testing.T) {
+	t.Parallel()
+
+	for _, keys := range []bson.D{{{"h", "hashed"}}, {{"$**", "text"}}} {
+		if got := keyTooLongMatch(mustMarshal(t, keys)); got != nil {
+			t.Errorf("%v: got = %v, want nil", keys, got)
+		}
+	}
+
+	got, err := bson.MarshalExtJSON(keyTooLongMatch(mustMarshal(t, bson.D{
+		{"name", 1},
+		{"loc", "2dsphere"},
+		{"tags.label", -1},
+	})), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"$expr":{"$gte":[{"$add":[` +
+		`{"$cond":[{"$eq":[{"$type":"$name"},"string"]},{"$strLenBytes":"$name"},0]},` +
+		`{"$cond":[{"$eq":[{"$type":"$tags.label"},"string"]},{"$strLenBytes":"$tags.label"},0]}` +
+		`]},1000]}}`
+	if string(got) != want {
+		t.Errorf("got = %s, want %s", got, want)
+	}
+}
+
+func TestHandleKeyTooLong(t *testing.T) {
+	t.Parallel()
+
+	buildErr := errors.New("key too long")
+	index := &topo.IndexSpecification{Name: "name_1", KeysDocument: mustMarshal(t, bson.D{{"name", 1}})}
+
+	for _, tc := range []struct {
+		action KeyTooLongAction
+		fail   bool
+	}{
+		{KeyTooLongSkip, false},
+		{KeyTooLongFail, true},
+	} {
+		var gotLimit int
+
+		findIDs := func(_ context.Context, _ bson.D, limit int) ([]any, error) {
+			gotLimit = limit
+
+			ids := make([]any, maxReportedViolations+5)
+			for i := range ids {
+				ids[i] = i
+			}
+
+			return ids, nil
+		}
+
+		fail, err := handleKeyTooLong(t.Context(), tc.action, "db", "coll", index, buildErr, findIDs)
+		if fail != tc.fail {
+			t.Errorf("%s: fail = %v, want %v", tc.action, fail, tc.fail)
+		}
+
+		if !errors.Is(err, buildErr) {
+			t.Errorf("%s: got = %v, want %v", tc.action, err, buildErr)
+		}
+
+		if gotLimit != maxReportedViolations {
+			t.Errorf("%s: limit = %d, want %d", tc.action, gotLimit, maxReportedViolations)
+		}
+
+		want := fmt.Sprintf("_id [0 1 2 3 4 5 6 7 8 9]: %v", buildErr)
+		if err == nil || !strings.HasSuffix(err.Error(), want) {
+			t.Errorf("%s: got = %v, want suffix %q", tc.action, err, want)
+		}
+	}
+
+	findErr := func(context.Context, bson.D, int) ([]any, error) {
+		return nil, errors.New("find")
+	}
+
+	fail, err := handleKeyTooLong(t.Context(), KeyTooLongFail, "db", "coll", index, buildErr, findErr)
+	if !fail || err != buildErr { //nolint:errorlint
+		t.Errorf("find error: got = %v, %v, want true, %v", fail, err, buildErr)
+	}
+
+	hashed := &topo.IndexSpecification{Name: "h_hashed", KeysDocument: mustMarshal(t, bson.D{{"h", "hashed"}})}
+
+	fail, err = handleKeyTooLong(t.Context(), KeyTooLongSkip, "db", "coll", hashed, buildErr, findErr)
+	if fail || err != buildErr { //nolint:errorlint
+		t.Errorf("hashed: got = %v, %v, want false, %v", fail, err, buildErr)
+	}
+}
@@ -3,6 +3,7 @@ package pcsm
 import (
 	"cmp"
 	"context"
+	"maps"
 	"runtime"
 	"slices"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/percona/percona-clustersync-mongodb/config"
@@ -22,12 +24,17 @@ import (
 	"github.com/percona/percona-clustersync-mongodb/topo"
 )
 
+// errCloneDrained indicates that the clone has stopped after the in-progress collections
+// on [Clone.Drain].
+var errCloneDrained = errors.New("clone drained")
+
 // Clone handles the cloning of data from a source MongoDB to a target MongoDB.
 type Clone struct {
 	source   *mongo.Client // Source MongoDB client
 	target   *mongo.Client // Target MongoDB client
 	catalog  *Catalog      // Catalog for managing collections and indexes
 	nsFilter sel.NSFilter  // Namespace filter
+	options  CloneOptions
 
 	lock sync.Mutex
 	err  error // Error encountered during the cloning process
@@ -37,18 +44,30 @@ type Clone struct {
 	sizeMap    sizeMap
 	totalSize  uint64        // Estimated total bytes to be cloned
 	copiedSize atomic.Uint64 // Bytes copied so far
+	copiedDocs atomic.Int64  // Documents copied so far
 
 	startTS  bson.Timestamp // source cluster timestamp when cloning started
 	finishTS bson.Timestamp // source cluster timestamp when cloning completed
 
 	startTime  time.Time
 	finishTime time.Time
+
+	completed map[Namespace]struct{}    // namespaces copied entirely
+	skipped   map[Namespace]string      // errors of the namespaces skipped by [CollectionErrorSkip]
+	progress  map[Namespace]*nsProgress // copy progress of the listed namespaces (the manifest)
+	draining  atomic.Bool               // do not start new collections
+	drained   bool                      // stopped by drain. Start resumes the clone
+	// stopped by the source authentication failure. Start resumes the clone
+	interrupted bool
+	// the target balancer is stopped by PCSM. The resumed clone starts it when it finishes
+	balancerStopped bool
 }
 
 // CloneStatus represents the status of the cloning process.
 type CloneStatus struct {
 	EstimatedTotalSize uint64 // Estimated total bytes to be copied
 	CopiedSize         uint64 // Bytes copied so far
+	CopiedCount        int64  // Documents copied so far
 
 	StartTS  bson.Timestamp
 	FinishTS bson.Timestamp
@@ -56,6 +75,13 @@ type CloneStatus struct {
 	StartTime  time.Time
 	FinishTime time.Time
 
+	Drained bool // Stopped by drain after the in-progress collections
+	// Interrupted is stopped by the source authentication failure. Resumed from the remaining collections
+	Interrupted bool
+
+	// Skipped are the errors of the collections skipped by [CollectionErrorSkip] by namespace.
+	Skipped map[Namespace]string
+
 	Err error // Error encountered during the cloning process
 }
 
@@ -74,12 +100,153 @@ func (cs *CloneStatus) IsFinished() bool {
 	return !cs.FinishTime.IsZero()
 }
 
-func NewClone(source, target *mongo.Client, catalog *Catalog, nsFilter sel.NSFilter) *Clone {
+// CloneSnapshotMode is the read consistency mode of a collection copy.
+type CloneSnapshotMode string
+
+const (
+	// CloneSnapshotNone reads a collection without a snapshot. The changes made during the copy
+	// are reconciled by the change replication.
+	CloneSnapshotNone CloneSnapshotMode = "none"
+	// CloneSnapshotSession reads each collection in a snapshot session (a point-in-time copy).
+	CloneSnapshotSession CloneSnapshotMode = "session"
+)
+
+// ParseCloneSnapshotMode parses the clone snapshot mode. The empty string is [CloneSnapshotNone].
+func ParseCloneSnapshotMode(s string) (CloneSnapshotMode, error) {
+	switch m := CloneSnapshotMode(s); m {
+	case "":
+		return CloneSnapshotNone, nil
+	case CloneSnapshotNone, CloneSnapshotSession:
+		return m, nil
+	}
+
+	return "", errors.Errorf("invalid clone snapshot mode %q", s)
+}
+
+// CloneOrder is the order in which the collections are cloned.
+type CloneOrder string
+
+const (
+	// CloneOrderLargestFirst clones the larger collections first.
+	CloneOrderLargestFirst CloneOrder = "largest-first"
+	// CloneOrderInterleave alternates the largest and the smallest remaining collections,
+	// so that the small collections finish early while the large ones are copied.
+	CloneOrderInterleave CloneOrder = "interleave"
+)
+
+// ParseCloneOrder parses the clone order. The empty string is [CloneOrderLargestFirst].
+func ParseCloneOrder(s string) (CloneOrder, error) {
+	switch o := CloneOrder(s); o {
+	case "":
+		return CloneOrderLargestFirst, nil
+	case CloneOrderLargestFirst, CloneOrderInterleave:
+		return o, nil
+	}
+
+	return "", errors.Errorf("invalid clone order %q", s)
+}
+
+// CloneReadConcern is the read concern level of the clone reads from the source.
+type CloneReadConcern string
+
+const (
+	// CloneReadConcernLocal reads the most recent data of the queried node.
+	CloneReadConcernLocal CloneReadConcern = "local"
+	// CloneReadConcernAvailable reads the most recent data without the routing table refresh
+	// of the sharded source. The reads may return orphaned documents.
+	CloneReadConcernAvailable CloneReadConcern = "available"
+	// CloneReadConcernMajority reads the data acknowledged by a majority of the replica set.
+	CloneReadConcernMajority CloneReadConcern = "majority"
+	// CloneReadConcernSnapshot reads the majority-committed data of a single point in time.
+	CloneReadConcernSnapshot CloneReadConcern = "snapshot"
+)
+
+// ParseCloneReadConcern parses the clone read concern level. The empty string keeps
+// the read concern of the source connection ([CloneReadConcernMajority]).
+func ParseCloneReadConcern(s string) (CloneReadConcern, error) {
+	switch rc := CloneReadConcern(s); rc {
+	case "", CloneReadConcernLocal, CloneReadConcernAvailable,
+		CloneReadConcernMajority, CloneReadConcernSnapshot:
+		return rc, nil
+	}
+
+	return "", errors.Errorf("invalid clone read concern %q", s)
+}
+
+// readConcern returns the read concern of the level or nil for the empty level.
+func (rc CloneReadConcern) readConcern() *readconcern.ReadConcern {
+	if rc == "" {
+		return nil
+	}
+
+	return &readconcern.ReadConcern{Level: string(rc)}
+}
+
+// CloneOptions configures the data clone.
+type CloneOptions struct {
+	// NoCursorTimeout disables the server idle timeout for the read cursors.
+	NoCursorTimeout bool
+	// BatchSizeBytes is the maximum size in bytes of a copy batch.
+	// Zero is [config.MaxWriteBatchSizeBytes].
+	BatchSizeBytes int
+	// MaxInflightBatches is the maximum number of the insert batches of a collection
+	// not inserted yet. Zero does not limit the batches.
+	MaxInflightBatches int
+	// MarkerField is the field stamped on the copied documents ([CopyManagerOptions.MarkerField]).
+	// The target collection of a restarted clone that has the stamped documents is kept.
+	MarkerField string
+	// CappedTail is the number of the most recent documents to copy per capped collection
+	// namespace (e.g. "db.log"). Other namespaces are copied entirely.
+	CappedTail map[string]int64
+	// Snapshot is the read consistency mode of the collection copy.
+	Snapshot CloneSnapshotMode
+	// Order is the order in which the collections are cloned.
+	// The empty value is [CloneOrderLargestFirst].
+	Order CloneOrder
+	// ReadConcern is the read concern level of the collection copy reads.
+	// The empty value keeps the read concern of the source connection.
+	ReadConcern CloneReadConcern
+	// DisableBalancer stops the balancer of the sharded target during the copy.
+	DisableBalancer bool
+	// SkipEmptyCollections skips the collections without documents. By default, an empty
+	// collection is created on the target with its options and indexes.
+	SkipEmptyCollections bool
+	// DiscoverNewCollections re-lists the source collections during the clone and copies
+	// the collections created after the clone has started.
+	DiscoverNewCollections bool
+	// OnNestingExceeded is the handling of the documents nested deeper than
+	// [config.MaxNestingDepth]. The empty value disables the check.
+	OnNestingExceeded NestingExceededAction
+	// Dependencies are the namespaces cloned before the namespaces that depend on them.
+	// The dependencies have no cycle ([ParseCloneDependencies]).
+	Dependencies []CloneDependency
+	// Memory bounds the read batches not inserted yet. Nil does not limit the memory.
+	Memory *MemoryBudget
+	// OnCollectionError is the handling of a collection that fails to clone.
+	// The empty value is [CollectionErrorFail].
+	OnCollectionError CollectionErrorAction
+	// OnSchemaDrift keeps the existing target collection and handles its options that differ
+	// from the source. The empty value drops and recreates the collection.
+	OnSchemaDrift SchemaDriftAction
+	// InternalNamespaces are the internal collections copied on demand (e.g. config.system.sessions).
+	// The documents are copied into the existing target collection: it is not dropped,
+	// and its options, indexes, and sharding are kept.
+	InternalNamespaces []Namespace
+}
+
+func NewClone(
+	source *mongo.Client,
+	target *mongo.Client,
+	catalog *Catalog,
+	nsFilter sel.NSFilter,
+	options CloneOptions,
+) *Clone {
 	return &Clone{
 		source:   source,
 		target:   target,
 		catalog:  catalog,
 		nsFilter: nsFilter,
+		options:  options,
 		doneSig:  make(chan struct{}),
 	}
 }
@@ -87,6 +254,7 @@ func NewClone(source, target *mongo.Client, catalog *Catalog, nsFilter sel.NSFil
 type cloneCheckpoint struct {
 	TotalSize  uint64 `bson:"totalSize,omitempty"`
 	CopiedSize uint64 `bson:"copiedSize,omitempty"`
+	CopiedDocs int64  `bson:"copiedDocs,omitempty"`
 
 	StartTS  bson.Timestamp `bson:"startTS,omitempty"`
 	FinishTS bson.Timestamp `bson:"finishTS,omitempty"`
@@ -94,6 +262,31 @@ type cloneCheckpoint struct {
 	StartTime  time.Time `bson:"startTime,omitempty"`
 	FinishTime time.Time `bson:"finishTime,omitempty"`
 
+	NoCursorTimeout bool              `bson:"noCursorTimeout,omitempty"`
+	BatchSizeBytes  int               `bson:"batchSizeBytes,omitempty"`
+	MarkerField     string            `bson:"markerField,omitempty"`
+	CappedTail      map[string]int64  `bson:"cappedTail,omitempty"`
+	Snapshot        CloneSnapshotMode `bson:"snapshot,omitempty"`
+	Order           CloneOrder        `bson:"order,omitempty"`
+	ReadConcern     CloneReadConcern  `bson:"readConcern,omitempty"`
+	DisableBalancer bool              `bson:"disableBalancer,omitempty"`
+
+	BalancerStoppedByPCSM bool `bson:"balancerStoppedByPCSM,omitempty"`
+
+	SkipEmptyCollections   bool `bson:"skipEmptyCollections,omitempty"`
+	DiscoverNewCollections bool `bson:"discoverNewCollections,omitempty"`
+	MaxInflightBatches     int  `bson:"maxInflightBatches,omitempty"`
+
+	OnNestingExceeded NestingExceededAction `bson:"onNestingExceeded,omitempty"`
+	Dependencies      []CloneDependency     `bson:"dependencies,omitempty"`
+	OnCollectionError CollectionErrorAction `bson:"onCollectionError,omitempty"`
+	OnSchemaDrift     SchemaDriftAction     `bson:"onSchemaDrift,omitempty"`
+
+	Completed   []Namespace         `bson:"completed,omitempty"`
+	Skipped     []skippedCollection `bson:"skipped,omitempty"`
+	Drained     bool                `bson:"drained,omitempty"`
+	Interrupted bool                `bson:"interrupted,omitempty"`
+
 	Error string `bson:"error,omitempty"`
 }
 
@@ -108,11 +301,44 @@ func (c *Clone) Checkpoint() *cloneCheckpoint { //nolint:revive
 	cp := &cloneCheckpoint{
 		TotalSize:  c.totalSize,
 		CopiedSize: c.copiedSize.Load(),
+		CopiedDocs: c.copiedDocs.Load(),
 		StartTS:    c.startTS,
 		FinishTS:   bson.Timestamp{},
 		StartTime:  c.startTime,
 		FinishTime: c.finishTime,
+
+		NoCursorTimeout: c.options.NoCursorTimeout,
+		BatchSizeBytes:  c.options.BatchSizeBytes,
+		MarkerField:     c.options.MarkerField,
+		CappedTail:      c.options.CappedTail,
+		Snapshot:        c.options.Snapshot,
+		Order:           c.options.Order,
+		ReadConcern:     c.options.ReadConcern,
+		DisableBalancer: c.options.DisableBalancer,
+
+		BalancerStoppedByPCSM: c.balancerStopped,
+
+		SkipEmptyCollections:   c.options.SkipEmptyCollections,
+		DiscoverNewCollections: c.options.DiscoverNewCollections,
+		MaxInflightBatches:     c.options.MaxInflightBatches,
+
+		OnNestingExceeded: c.options.OnNestingExceeded,
+		Dependencies:      c.options.Dependencies,
+		OnCollectionError: c.options.OnCollectionError,
+		OnSchemaDrift:     c.options.OnSchemaDrift,
+
+		Drained:     c.drained,
+		Interrupted: c.interrupted,
 	}
+
+	for ns := range c.completed {
+		cp.Completed = append(cp.Completed, ns)
+	}
+
+	for ns, err := range c.skipped {
+		cp.Skipped = append(cp.Skipped, skippedCollection{Namespace: ns, Error: err})
+	}
+
 	if c.err != nil {
 		cp.Error = c.err.Error()
 	}
@@ -130,10 +356,39 @@ func (c *Clone) Recover(cp *cloneCheckpoint) error {
 
 	c.totalSize = cp.TotalSize // XXX: re-calculate
 	c.copiedSize.Store(cp.CopiedSize)
+	c.copiedDocs.Store(cp.CopiedDocs)
 	c.startTS = cp.StartTS
 	c.finishTS = cp.FinishTS
 	c.startTime = cp.StartTime
 	c.finishTime = cp.FinishTime
+	c.options.NoCursorTimeout = cp.NoCursorTimeout
+	c.options.BatchSizeBytes = cp.BatchSizeBytes
+	c.options.MarkerField = cp.MarkerField
+	c.options.MaxInflightBatches = cp.MaxInflightBatches
+	c.options.CappedTail = cp.CappedTail
+	c.options.Snapshot = cp.Snapshot
+	c.options.Order = cp.Order
+	c.options.ReadConcern = cp.ReadConcern
+	c.options.DisableBalancer = cp.DisableBalancer
+	c.balancerStopped = cp.BalancerStoppedByPCSM
+	c.options.SkipEmptyCollections = cp.SkipEmptyCollections
+	c.options.DiscoverNewCollections = cp.DiscoverNewCollections
+	c.options.OnNestingExceeded = cp.OnNestingExceeded
+	c.options.Dependencies = cp.Dependencies
+	c.options.OnCollectionError = cp.OnCollectionError
+	c.options.OnSchemaDrift = cp.OnSchemaDrift
+	c.drained = cp.Drained
+	c.interrupted = cp.Interrupted
+
+	c.completed = make(map[Namespace]struct{}, len(cp.Completed))
+	for _, ns := range cp.Completed {
+		c.completed[ns] = struct{}{}
+	}
+
+	c.skipped = make(map[Namespace]string, len(cp.Skipped))
+	for _, s := range cp.Skipped {
+		c.skipped[s.Namespace] = s.Error
+	}
 
 	if cp.Error != "" {
 		c.err = errors.New(cp.Error)
@@ -150,14 +405,51 @@ func (c *Clone) Status() CloneStatus {
 	return CloneStatus{
 		EstimatedTotalSize: c.totalSize,
 		CopiedSize:         c.copiedSize.Load(),
+		CopiedCount:        c.copiedDocs.Load(),
 		StartTS:            c.startTS,
 		FinishTS:           c.finishTS,
 		StartTime:          c.startTime,
 		FinishTime:         c.finishTime,
+		Drained:            c.drained,
+		Interrupted:        c.interrupted,
+		Skipped:            maps.Clone(c.skipped),
 		Err:                c.err,
 	}
 }
 
+// Skip finishes the clone at the timestamp without copying the data. The target must already have
+// the data of the source at the timestamp (e.g. restored from a backup). The change replication
+// starts at the timestamp.
+func (c *Clone) Skip(ts bson.Timestamp) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+
+	c.startTS = ts
+	c.finishTS = ts
+	c.startTime = now
+	c.finishTime = now
+	close(c.doneSig)
+
+	log.New("clone").With(log.OpTime(ts.T, ts.I)).Info("Data Clone skipped")
+}
+
+// Drain stops the clone after the in-progress collections are copied. The clone does not start
+// new collections. The drained clone is resumed by [Clone.Start] from the remaining collections.
+func (c *Clone) Drain() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.startTime.IsZero() || !c.finishTime.IsZero() || c.drained {
+		return errors.New("not running")
+	}
+
+	c.draining.Store(true)
+
+	return nil
+}
+
 func (c *Clone) resetError() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -187,13 +479,22 @@ func (c *Clone) Start(context.Context) error {
 		return errors.New("already completed")
 	}
 
-	if !c.startTime.IsZero() {
+	switch {
+	case c.drained || c.interrupted:
+		lg.Infof("Resuming Data Clone: %d collections are completed", len(c.completed))
+
+		c.drained = false
+		c.interrupted = false
+		c.doneSig = make(chan struct{})
+
+	case !c.startTime.IsZero():
 		return errors.New("already started")
-	}
 
-	lg.Info("Starting Data Clone")
+	default:
+		lg.Info("Starting Data Clone")
 
-	c.startTime = time.Now()
+		c.startTime = time.Now()
+	}
 
 	go func() {
 		err := c.run()
@@ -201,10 +502,29 @@ func (c *Clone) Start(context.Context) error {
 		c.lock.Lock()
 		defer c.lock.Unlock()
 
+		if errors.Is(err, errCloneDrained) {
+			c.drained = true
+			c.draining.Store(false)
+			close(c.doneSig)
+
+			lg.Infof("Data Clone drained: %d collections are completed", len(c.completed))
+
+			return
+		}
+
 		if err != nil {
 			c.err = err
 		}
 
+		if topo.IsAuthenticationFailed(err) {
+			c.interrupted = true
+			close(c.doneSig)
+
+			lg.Errorf(err, "Data Clone is interrupted: %d collections are completed", len(c.completed))
+
+			return
+		}
+
 		select {
 		case <-c.doneSig:
 		default:
@@ -237,16 +557,25 @@ func (c *Clone) run() error {
 	lg := log.New("clone")
 	ctx = lg.WithContext(ctx)
 
-	startTS, err := topo.ClusterTime(ctx, c.source)
-	if err != nil {
-		return errors.Wrap(err, "startTS: get source cluster time")
-	}
-
 	c.lock.Lock()
-	c.startTS = startTS
+	startTS := c.startTS
 	c.lock.Unlock()
 
-	err = c.collectSizeMap(ctx)
+	// a resumed clone keeps the start time: the changes since then are replicated
+	if startTS.IsZero() {
+		var err error
+
+		startTS, err = topo.ClusterTime(ctx, c.source)
+		if err != nil {
+			return errors.Wrap(err, "startTS: get source cluster time")
+		}
+
+		c.lock.Lock()
+		c.startTS = startTS
+		c.lock.Unlock()
+	}
+
+	err := c.collectSizeMap(ctx)
 	if err != nil {
 		return errors.Wrap(err, "get size map")
 	}
@@ -265,8 +594,12 @@ func (c *Clone) run() error {
 
 	namespaces := c.listPrioritizedNamespaces()
 	if len(namespaces) != 0 {
-		err = c.doClone(ctx, namespaces)
+		err = c.cloneWithBalancer(ctx, namespaces)
 		if err != nil {
+			if errors.Is(err, errCloneDrained) {
+				return err
+			}
+
 			return errors.Wrap(err, "copy")
 		}
 	} else {
@@ -285,6 +618,41 @@ func (c *Clone) run() error {
 	return nil
 }
 
+// cloneWithBalancer clones the namespaces. With [CloneOptions.DisableBalancer],
+// the balancer of the sharded target is stopped during the clone.
+func (c *Clone) cloneWithBalancer(ctx context.Context, namespaces []namespaceInfo) error {
+	if !c.options.DisableBalancer {
+		return c.doClone(ctx, namespaces)
+	}
+
+	hello, err := topo.SayHello(ctx, c.target)
+	if err != nil {
+		return errors.Wrap(err, "target hello")
+	}
+
+	if hello.Msg != "isdbgrid" {
+		log.Ctx(ctx).Warn("The target is not a sharded cluster. Balancer is not stopped")
+
+		return c.doClone(ctx, namespaces)
+	}
+
+	c.lock.Lock()
+	stopped := c.balancerStopped
+	c.lock.Unlock()
+
+	return withBalancerStopped(ctx, targetBalancer{c.target}, stopped, c.setBalancerStopped,
+		func() error {
+			return c.doClone(ctx, namespaces)
+		})
+}
+
+// setBalancerStopped records whether the target balancer is stopped by PCSM.
+func (c *Clone) setBalancerStopped(stopped bool) {
+	c.lock.Lock()
+	c.balancerStopped = stopped
+	c.lock.Unlock()
+}
+
 func (c *Clone) doClone(ctx context.Context, namespaces []namespaceInfo) error {
 	cloneLogger := log.Ctx(ctx)
 
@@ -295,78 +663,226 @@ func (c *Clone) doClone(ctx context.Context, namespaces []namespaceInfo) error {
 
 	cloneLogger.Debugf("NumParallelCollections: %d", numParallelCollections)
 
+	c.lock.Lock()
+	startTS := c.startTS
+	c.lock.Unlock()
+
 	copyManager := NewCopyManager(c.source, c.target, CopyManagerOptions{
 		NumReadWorkers:     config.CloneNumReadWorkers(),
 		NumInsertWorkers:   config.CloneNumInsertWorkers(),
 		SegmentSizeBytes:   config.CloneSegmentSizeBytes(),
 		ReadBatchSizeBytes: config.CloneReadBatchSizeBytes(),
+		BatchSizeBytes:     c.options.BatchSizeBytes,
+		MaxInflightBatches: c.options.MaxInflightBatches,
+		MarkerField:        c.options.MarkerField,
+		MarkerValue:        startTS,
+		NoCursorTimeout:    c.options.NoCursorTimeout,
+		CappedTail:         c.options.CappedTail,
+		Snapshot:           c.options.Snapshot,
+		ReadConcern:        c.options.ReadConcern,
+		TargetNamespace:    c.catalog.TargetNamespace,
+		OnNestingExceeded:  c.options.OnNestingExceeded,
+		Memory:             c.options.Memory,
 	})
 	defer copyManager.Close()
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	eg, grpCtx := errgroup.WithContext(ctx)
 	eg.SetLimit(numParallelCollections)
 
-	for _, ns := range namespaces {
-		eg.Go(func() error {
-			ns := ns
-			lg := cloneLogger.With(log.NS(ns.Database, ns.Collection))
-			ctx := lg.WithContext(grpCtx)
-
-			for {
-				err := c.doCollectionClone(ctx, copyManager, ns.Namespace)
-				if err != nil && !errors.As(err, &NamespaceNotFoundError{}) {
-					return errors.Wrap(err, ns.String())
-				}
+	var drained atomic.Bool
+	var pending atomic.Int64 // enqueued namespaces not copied yet
 
-				// check if the collection was renamed during clone.
-
-				if ns.UUID == nil { // view cannot be renamed
-					return nil
-				}
+	// the dependencies are enqueued before the dependents, so a waiting dependent
+	// does not hold the slot of its dependency
+	deps := dependencyMap(c.options.Dependencies)
+	namespaces = orderByDependencies(namespaces, deps)
+	depWaiter := newDependencyWaiter(namespaces, deps)
 
-				name, err := topo.GetCollectionNameByUUID(ctx, c.source, ns.Database, *ns.UUID)
-				if err != nil {
-					if errors.Is(err, topo.ErrNotFound) { // dropped
-						lg.Warnf("Collection %s not found", ns.Namespace)
+	enqueue := func(ns namespaceInfo) bool {
+		if c.draining.Load() {
+			drained.Store(true)
 
-						return nil
-					}
+			return false
+		}
 
-					return errors.Wrapf(err, "get collection name by uuid: %s", ns)
-				}
+		pending.Add(1)
 
-				if name == ns.Collection {
-					return nil // OK: collection has not been renamed
-				}
+		eg.Go(func() error {
+			defer pending.Add(-1)
+			defer depWaiter.finish(ns.Namespace)
 
-				prevNS := ns
-				ns = namespaceInfo{
-					Namespace: Namespace{prevNS.Database, name},
-					UUID:      prevNS.UUID,
-				}
+			err := depWaiter.wait(grpCtx, ns.Namespace)
+			if err != nil {
+				return nil //nolint:nilerr // a dependency has failed
+			}
 
-				c.lock.Lock()
-				elem := c.sizeMap[prevNS.Namespace]
-				delete(c.sizeMap, prevNS.Namespace)
-				c.sizeMap[prevNS.Namespace] = elem
-				c.lock.Unlock()
+			if c.draining.Load() { // drained while waiting for a free slot
+				drained.Store(true)
 
-				lg.Infof("Collection %s was renamed to %s. Retrying to clone the collection",
-					prevNS.Namespace, ns.Namespace)
+				return nil
+			}
 
-				err = c.catalog.DropCollection(ctx, prevNS.Database, prevNS.Collection)
-				if err != nil {
-					return errors.Wrapf(err, "drop collection %q", prevNS.Namespace)
-				}
+			err = c.cloneNamespace(grpCtx, copyManager, ns)
+			if err != nil && c.skipFailedCollection(grpCtx, ns.Namespace, err) {
+				c.dropSkippedCollection(grpCtx, ns.Namespace)
 
-				lg.Infof("Previous collection %s was dropped", prevNS.Namespace)
+				return nil
 			}
+
+			return err
 		})
+
+		return true
+	}
+
+	for _, ns := range namespaces {
+		if !enqueue(ns) {
+			break
+		}
+	}
+
+	var discoverErr error
+	if c.options.DiscoverNewCollections && !drained.Load() {
+		discoverErr = c.discoverNewCollections(grpCtx, &pending, enqueue)
+		if discoverErr != nil {
+			cancel()
+		}
 	}
 
 	err := eg.Wait()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if discoverErr != nil {
+		return errors.Wrap(discoverErr, "discover new collections")
+	}
+
+	if drained.Load() {
+		return errCloneDrained
+	}
+
+	return nil
+}
+
+// cloneNamespace copies the namespace. A collection renamed during the copy is copied again
+// under the new name.
+func (c *Clone) cloneNamespace(ctx context.Context, copyManager *CopyManager, ns namespaceInfo) error {
+	lg := log.Ctx(ctx).With(log.NS(ns.Database, ns.Collection))
+	ctx = lg.WithContext(ctx)
+
+	for {
+		err := c.doCollectionClone(ctx, copyManager, ns.Namespace)
+		if err != nil && !errors.As(err, &NamespaceNotFoundError{}) {
+			return errors.Wrap(err, ns.String())
+		}
+
+		// check if the collection was renamed during clone.
+
+		if ns.UUID == nil { // view cannot be renamed
+			c.markCompleted(ns.Namespace)
+
+			return nil
+		}
+
+		name, err := topo.GetCollectionNameByUUID(ctx, c.source, ns.Database, *ns.UUID)
+		if err != nil {
+			if errors.Is(err, topo.ErrNotFound) { // dropped
+				lg.Warnf("Collection %s not found", ns.Namespace)
+				c.markCompleted(ns.Namespace)
+
+				return nil
+			}
 
-	return err //nolint:wrapcheck
+			return errors.Wrapf(err, "get collection name by uuid: %s", ns)
+		}
+
+		if name == ns.Collection {
+			c.markCompleted(ns.Namespace)
+
+			return nil // OK: collection has not been renamed
+		}
+
+		prevNS := ns
+		ns = namespaceInfo{
+			Namespace: Namespace{prevNS.Database, name},
+			UUID:      prevNS.UUID,
+		}
+
+		c.lock.Lock()
+		elem := c.sizeMap[prevNS.Namespace]
+		delete(c.sizeMap, prevNS.Namespace)
+		c.sizeMap[prevNS.Namespace] = elem
+		delete(c.progress, prevNS.Namespace)
+		c.lock.Unlock()
+
+		lg.Infof("Collection %s was renamed to %s. Retrying to clone the collection",
+			prevNS.Namespace, ns.Namespace)
+
+		err = c.catalog.DropCollection(ctx, prevNS.Database, prevNS.Collection)
+		if err != nil {
+			return errors.Wrapf(err, "drop collection %q", prevNS.Namespace)
+		}
+
+		lg.Infof("Previous collection %s was dropped", prevNS.Namespace)
+	}
+}
+
+// discoverNewCollections re-lists the source collections every [config.CloneDiscoverInterval]
+// and enqueues the new ones until all enqueued namespaces are copied and no new collection
+// is found. It stops when the enqueue is refused by the drain.
+func (c *Clone) discoverNewCollections(
+	ctx context.Context,
+	pending *atomic.Int64,
+	enqueue func(namespaceInfo) bool,
+) error {
+	lg := log.Ctx(ctx)
+
+	t := time.NewTicker(config.CloneDiscoverInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil // the copy has failed
+		case <-t.C:
+		}
+
+		// read before the listing: a collection created after the last copy is found
+		idle := pending.Load() == 0
+
+		namespaces, err := c.listNewNamespaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, ns := range namespaces {
+			lg.With(log.NS(ns.Database, ns.Collection)).Infof("New collection %s discovered", ns.Namespace)
+
+			if !enqueue(ns) {
+				return nil
+			}
+		}
+
+		if idle && len(namespaces) == 0 {
+			return nil
+		}
+	}
+}
+
+// markCompleted records the namespace as copied entirely. A resumed clone skips it.
+func (c *Clone) markCompleted(ns Namespace) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.completed == nil {
+		c.completed = make(map[Namespace]struct{})
+	}
+
+	c.completed[ns] = struct{}{}
 }
 
 func (c *Clone) doCollectionClone(
@@ -391,6 +907,11 @@ func (c *Clone) doCollectionClone(
 
 	c.lock.Lock()
 	nsSize := c.sizeMap[ns]
+	progress := &nsProgress{TotalCount: nsSize.Count, TotalSize: nsSize.Size}
+	if c.progress == nil {
+		c.progress = make(map[Namespace]*nsProgress)
+	}
+	c.progress[ns] = progress // the collection is copied again from the start
 	c.lock.Unlock()
 
 	lg.With(log.Count(nsSize.Count), log.Size(nsSize.Size)).
@@ -417,38 +938,25 @@ func (c *Clone) doCollectionClone(
 		return ErrTimeseriesUnsupported
 	}
 
-	err = c.createCollection(ctx, ns, spec)
-	if err != nil {
-		if !errors.Is(err, context.Canceled) {
-			lg.Errorf(err, "Failed to create %q collection", ns.String())
-		}
-
-		return errors.Wrap(err, "createCollection")
-	}
+	if slices.Contains(c.options.InternalNamespaces, ns) {
+		// the target server owns the collection: the documents are merged into it
+		c.catalog.adoptCollection(ctx, ns.Database, ns.Collection, spec.UUID, nil)
 
-	if spec.Type == topo.TypeCollection {
-		err = c.createIndexes(ctx, ns)
+		lg.Infof("Internal collection %q is copied into the existing target collection", ns.String())
+	} else {
+		resumed, err := c.resumeMarkedCollection(ctx, ns)
 		if err != nil {
-			return errors.Wrap(err, "create indexes")
+			return err
 		}
-	}
-
-	lg.Infof("Collection %q created", ns.String())
-
-	shInfo, err := topo.GetCollectionShardingInfo(ctx, c.source, ns.Database, ns.Collection)
-	if err != nil && !errors.Is(err, topo.ErrNotFound) {
-		return errors.Wrap(err, "get sharding info")
-	}
 
-	if shInfo != nil && shInfo.IsSharded() {
-		err := c.catalog.ShardCollection(ctx, ns.Database, ns.Collection, shInfo.ShardKey, shInfo.Unique)
-		if err != nil {
-			return errors.Wrap(err, "shard collection")
+		if !resumed {
+			err = c.prepareCollection(ctx, ns, spec)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	lg.Infof("Collection %q sharded", ns.String())
-
 	c.catalog.SetCollectionTimestamp(ctx, ns.Database, ns.Collection, capturedAt)
 
 	if spec.UUID != nil {
@@ -510,6 +1018,12 @@ func (c *Clone) doCollectionClone(
 		totalCopiedCount += int64(update.Count)
 		totalCopiedSizeBytes += update.SizeBytes
 		c.copiedSize.Add(update.SizeBytes)
+		c.copiedDocs.Add(int64(update.Count))
+
+		c.lock.Lock()
+		progress.CopiedCount += int64(update.Count)
+		progress.CopiedSize += update.SizeBytes
+		c.lock.Unlock()
 
 		copiedCountSinceLastLog += int64(update.Count)
 		copiedSizeBytesSinceLastLog += update.SizeBytes
@@ -632,6 +1146,12 @@ func (c *Clone) collectSizeMap(ctx context.Context) error {
 						return errors.Wrapf(err, "get collection stats for %q", db+"."+spec.Name)
 					}
 
+					if stats.Count == 0 && c.options.SkipEmptyCollections {
+						lg.With(log.NS(db, spec.Name)).Infof("Empty collection %q skipped", db+"."+spec.Name)
+
+						return nil
+					}
+
 					mu.Lock()
 					sm[Namespace{db, spec.Name}] = sizeMapElem{
 						UUID:  spec.UUID,
@@ -659,9 +1179,17 @@ func (c *Clone) collectSizeMap(ctx context.Context) error {
 		return errors.Wrap(err, "collect databases")
 	}
 
+	internalSize, err := c.collectInternalNamespaces(ctx, sm)
+	if err != nil {
+		return errors.Wrap(err, "collect internal namespaces")
+	}
+
+	total += internalSize
+
 	c.lock.Lock()
 	c.sizeMap = sm
 	c.totalSize = total
+	c.trackProgress(sm)
 	c.lock.Unlock()
 
 	return nil
@@ -676,20 +1204,143 @@ type namespaceInfo struct {
 func (c *Clone) listPrioritizedNamespaces() []namespaceInfo {
 	namespaces := []namespaceInfo{}
 	for ns, elem := range c.sizeMap {
+		if _, ok := c.completed[ns]; ok {
+			continue // copied before the drain
+		}
+
+		if _, ok := c.skipped[ns]; ok {
+			continue // failed before the drain
+		}
+
 		namespaces = append(namespaces, namespaceInfo{
 			Namespace: ns,
 			UUID:      elem.UUID,
 		})
 	}
 
-	// sort from larger to smaller
+	// sort from larger to smaller. namespaces of the same size are sorted by name
+	// to keep the order stable across restarts (the map iteration order is random).
 	slices.SortFunc(namespaces, func(a, b namespaceInfo) int {
-		return cmp.Compare(c.sizeMap[b.Namespace].Size, c.sizeMap[a.Namespace].Size)
+		return cmp.Or(
+			cmp.Compare(c.sizeMap[b.Namespace].Size, c.sizeMap[a.Namespace].Size),
+			cmp.Compare(a.Database, b.Database),
+			cmp.Compare(a.Collection, b.Collection))
 	})
 
+	if c.options.Order == CloneOrderInterleave {
+		namespaces = interleaveBySize(namespaces)
+	}
+
 	return namespaces
 }
 
+// interleaveBySize returns the namespaces sorted from larger to smaller in the order
+// of the largest, the smallest, the second largest, the second smallest, and so on.
+func interleaveBySize(sorted []namespaceInfo) []namespaceInfo {
+	interleaved := make([]namespaceInfo, 0, len(sorted))
+
+	for i, j := 0, len(sorted)-1; i <= j; i, j = i+1, j-1 {
+		interleaved = append(interleaved, sorted[i])
+		if i != j {
+			interleaved = append(interleaved, sorted[j])
+		}
+	}
+
+	return interleaved
+}
+
+// listNewNamespaces lists the source namespaces that match the filter and are not known
+// by name or UUID. The new namespaces are added to the size map.
+func (c *Clone) listNewNamespaces(ctx context.Context) ([]namespaceInfo, error) {
+	databases, err := topo.ListDatabaseNames(ctx, c.source)
+	if err != nil {
+		return nil, errors.Wrap(err, "list database names")
+	}
+
+	c.lock.Lock()
+	knownUUIDs := make(map[string]struct{}, len(c.sizeMap))
+	for _, elem := range c.sizeMap {
+		if elem.UUID != nil {
+			knownUUIDs[string(elem.UUID.Data)] = struct{}{}
+		}
+	}
+	c.lock.Unlock()
+
+	namespaces := []namespaceInfo{}
+	sm := make(sizeMap)
+	total := uint64(0)
+
+	for _, db := range databases {
+		if db == config.PCSMDatabase {
+			continue
+		}
+
+		collSpecs, err := topo.ListCollectionSpecs(ctx, c.source, db)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listCollections for %q", db)
+		}
+
+		for _, spec := range collSpecs {
+			if spec.Type == topo.TypeTimeseries || !c.nsFilter(db, spec.Name) {
+				continue
+			}
+
+			ns := Namespace{db, spec.Name}
+
+			c.lock.Lock()
+			_, known := c.sizeMap[ns]
+			c.lock.Unlock()
+
+			if known {
+				continue
+			}
+
+			if spec.UUID != nil {
+				if _, ok := knownUUIDs[string(spec.UUID.Data)]; ok {
+					continue // renamed during the clone
+				}
+			}
+
+			elem := sizeMapElem{UUID: spec.UUID}
+
+			if spec.Type != topo.TypeView {
+				stats, err := topo.GetCollStats(ctx, c.source, db, spec.Name)
+				if err != nil {
+					if errors.Is(err, topo.ErrNotFound) {
+						continue
+					}
+
+					return nil, errors.Wrapf(err, "get collection stats for %q", ns)
+				}
+
+				if stats.Count == 0 && c.options.SkipEmptyCollections {
+					continue
+				}
+
+				elem.Size = uint64(stats.Size) //nolint:gosec
+				elem.Count = stats.Count
+			}
+
+			sm[ns] = elem
+			total += elem.Size
+			namespaces = append(namespaces, namespaceInfo{Namespace: ns, UUID: spec.UUID})
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return namespaces, nil
+	}
+
+	c.lock.Lock()
+	maps.Copy(c.sizeMap, sm)
+	c.totalSize += total
+	c.trackProgress(sm)
+	metrics.SetEstimatedTotalSizeBytes(c.totalSize)
+	c.lock.Unlock()
+
+	return namespaces, nil
+}
+
 type NamespaceNotFoundError struct {
 	Database   string
 	Collection string
@@ -699,6 +1350,65 @@ func (e NamespaceNotFoundError) Error() string {
 	return "collection not found: " + e.Database + "." + e.Collection
 }
 
+// prepareCollection creates the collection on the target with the indexes and the sharding
+// of the source collection. The existing target collection kept with [CloneOptions.OnSchemaDrift]
+// gets the source indexes only.
+func (c *Clone) prepareCollection(
+	ctx context.Context,
+	ns Namespace,
+	spec *topo.CollectionSpecification,
+) error {
+	lg := log.Ctx(ctx).With(log.NS(ns.Database, ns.Collection))
+
+	kept, err := c.keepExistingCollection(ctx, ns, spec)
+	if err != nil {
+		return errors.Wrap(err, "existing collection")
+	}
+
+	if kept {
+		err = c.createIndexes(ctx, ns)
+		if err != nil {
+			return errors.Wrap(err, "create indexes")
+		}
+
+		return nil
+	}
+
+	err = c.createCollection(ctx, ns, spec)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			lg.Errorf(err, "Failed to create %q collection", ns.String())
+		}
+
+		return errors.Wrap(err, "createCollection")
+	}
+
+	if spec.Type == topo.TypeCollection {
+		err = c.createIndexes(ctx, ns)
+		if err != nil {
+			return errors.Wrap(err, "create indexes")
+		}
+	}
+
+	lg.Infof("Collection %q created", ns.String())
+
+	shInfo, err := topo.GetCollectionShardingInfo(ctx, c.source, ns.Database, ns.Collection)
+	if err != nil && !errors.Is(err, topo.ErrNotFound) {
+		return errors.Wrap(err, "get sharding info")
+	}
+
+	if shInfo != nil && shInfo.IsSharded() {
+		err := c.catalog.ShardCollection(ctx, ns.Database, ns.Collection, shInfo.ShardKey, shInfo.Unique)
+		if err != nil {
+			return errors.Wrap(err, "shard collection")
+		}
+	}
+
+	lg.Infof("Collection %q sharded", ns.String())
+
+	return nil
+}
+
 func (c *Clone) createCollection(
 	ctx context.Context,
 	ns Namespace,
@@ -715,6 +1425,13 @@ func (c *Clone) createCollection(
 		return errors.Wrap(err, "unmarshal options")
 	}
 
+	if spec.Type == topo.TypeCollection {
+		_, err = c.catalog.PrepareSwap(ctx, ns.Database, ns.Collection)
+		if err != nil {
+			return errors.Wrap(err, "prepare swap")
+		}
+	}
+
 	err = c.catalog.DropCollection(ctx, ns.Database, ns.Collection)
 	if err != nil {
 		return errors.Wrap(err, "ensure no collection before create")
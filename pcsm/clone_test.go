@@ -0,0 +1,385 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestListPrioritizedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	c := &Clone{sizeMap: sizeMap{
+		Namespace{"db_1", "coll_2"}: {Size: 100},
+		Namespace{"db_1", "coll_0"}: {Size: 100},
+		Namespace{"db_0", "coll_9"}: {Size: 100},
+		Namespace{"db_0", "coll_1"}: {Size: 500},
+		Namespace{"db_2", "coll_0"}: {},
+		Namespace{"db_1", "coll_1"}: {},
+	}}
+
+	want := []Namespace{
+		{"db_0", "coll_1"},
+		{"db_0", "coll_9"},
+		{"db_1", "coll_0"},
+		{"db_1", "coll_2"},
+		{"db_1", "coll_1"},
+		{"db_2", "coll_0"},
+	}
+
+	for range 2 {
+		got := make([]Namespace, 0, len(want))
+		for _, ns := range c.listPrioritizedNamespaces() {
+			got = append(got, ns.Namespace)
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestClone_ListInterleavedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	c := &Clone{
+		options: CloneOptions{Order: CloneOrderInterleave},
+		sizeMap: sizeMap{
+			Namespace{"db_0", "coll_1"}: {Size: 100},
+			Namespace{"db_0", "coll_2"}: {Size: 200},
+			Namespace{"db_0", "coll_3"}: {Size: 300},
+			Namespace{"db_0", "coll_4"}: {Size: 400},
+			Namespace{"db_0", "coll_5"}: {Size: 500},
+		},
+	}
+
+	var got []Namespace
+	for _, ns := range c.listPrioritizedNamespaces() {
+		got = append(got, ns.Namespace)
+	}
+
+	// the largest, the smallest, and so on
+	want := []Namespace{
+		{"db_0", "coll_5"},
+		{"db_0", "coll_1"},
+		{"db_0", "coll_4"},
+		{"db_0", "coll_2"},
+		{"db_0", "coll_3"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	for n := range 4 {
+		sorted := make([]namespaceInfo, n)
+		if got := interleaveBySize(sorted); len(got) != n {
+			t.Errorf("%d namespaces: got %d, want %d", n, len(got), n)
+		}
+	}
+}
+
+func TestClone_DrainedCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	c := &Clone{
+		sizeMap: sizeMap{
+			Namespace{"db_0", "coll_0"}: {Size: 100},
+			Namespace{"db_0", "coll_1"}: {Size: 200},
+			Namespace{"db_0", "coll_2"}: {Size: 300},
+		},
+		startTime: time.Now(),
+		startTS:   bson.Timestamp{T: 1},
+		drained:   true,
+	}
+
+	c.markCompleted(Namespace{"db_0", "coll_2"})
+
+	restored := &Clone{doneSig: make(chan struct{})}
+
+	err := restored.Recover(c.Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !restored.Status().Drained {
+		t.Error("the restored clone is not drained")
+	}
+
+	restored.sizeMap = c.sizeMap
+
+	var got []Namespace
+	for _, ns := range restored.listPrioritizedNamespaces() {
+		got = append(got, ns.Namespace)
+	}
+
+	// the completed collection is not copied again
+	want := []Namespace{{"db_0", "coll_1"}, {"db_0", "coll_0"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func TestCappedTailSkip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		count, tail, want int64
+	}{
+		{count: 100, tail: 0, want: 0},   // entire collection
+		{count: 100, tail: 10, want: 90}, // the last 10 documents
+		{count: 100, tail: 100, want: 0}, // the tail is the collection
+		{count: 5, tail: 10, want: 0},    // fewer documents than the tail
+		{count: 100, tail: -1, want: 0},  // invalid tail copies all
+	}
+
+	for _, tt := range tests {
+		if got := cappedTailSkip(tt.count, tt.tail); got != tt.want {
+			t.Errorf("cappedTailSkip(%d, %d) = %d, want %d", tt.count, tt.tail, got, tt.want)
+		}
+	}
+}
+
+func TestCopyManager_StartReadSession(t *testing.T) {
+	t.Parallel()
+
+	// the client does not connect until the first operation
+	source, err := mongo.Connect(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = source.Disconnect(t.Context()) })
+
+	for _, mode := range []CloneSnapshotMode{"", CloneSnapshotNone} {
+		cm := &CopyManager{source: source, options: CopyManagerOptions{Snapshot: mode}}
+
+		ctx, endSession, err := cm.startReadSession(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sess := mongo.SessionFromContext(ctx); sess != nil {
+			t.Errorf("%q: got a session, want none", mode)
+		}
+
+		endSession()
+	}
+
+	cm := &CopyManager{source: source, options: CopyManagerOptions{Snapshot: CloneSnapshotSession}}
+
+	ctx, endSession, err := cm.startReadSession(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer endSession()
+
+	sess := mongo.SessionFromContext(ctx)
+	if sess == nil {
+		t.Fatal("session: got no session, want a snapshot session")
+	}
+
+	if !sess.ClientSession().Snapshot {
+		t.Error("session: got a non-snapshot session")
+	}
+}
+
+func TestParseCloneSnapshotMode(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]CloneSnapshotMode{
+		"":        CloneSnapshotNone,
+		"none":    CloneSnapshotNone,
+		"session": CloneSnapshotSession,
+	} {
+		got, err := ParseCloneSnapshotMode(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q, %v, want %q", s, got, err, want)
+		}
+	}
+
+	_, err := ParseCloneSnapshotMode("majority")
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
+
+func TestParseCloneReadConcern(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "local", "available", "majority", "snapshot"} {
+		got, err := ParseCloneReadConcern(s)
+		if err != nil || string(got) != s {
+			t.Errorf("%q: got = %q, %v", s, got, err)
+		}
+	}
+
+	for _, s := range []string{"linearizable", "Majority", "none"} {
+		_, err := ParseCloneReadConcern(s)
+		if err == nil {
+			t.Errorf("%q: got no error", s)
+		}
+	}
+}
+
+func TestSourceCollectionOptions(t *testing.T) {
+	t.Parallel()
+
+	collectionOptions := func(rc CloneReadConcern) *options.CollectionOptions {
+		opts := &options.CollectionOptions{}
+		for _, set := range sourceCollectionOptions(rc).List() {
+			err := set(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return opts
+	}
+
+	if rc := collectionOptions("").ReadConcern; rc != nil {
+		t.Errorf("empty: got read concern %q, want the client read concern", rc.Level)
+	}
+
+	for _, level := range []CloneReadConcern{
+		CloneReadConcernLocal,
+		CloneReadConcernAvailable,
+		CloneReadConcernMajority,
+		CloneReadConcernSnapshot,
+	} {
+		rc := collectionOptions(level).ReadConcern
+		if rc == nil || rc.Level != string(level) {
+			t.Errorf("%q: got read concern %v", level, rc)
+		}
+	}
+}
+
+func TestCopyManager_ReadSegmentBatchBytes(t *testing.T) {
+	t.Parallel()
+
+	const limit = 4096
+
+	var docs []bson.Raw
+
+	for i, size := range []int{100, 3000, 100, 5000, 2000, 2000, 100} {
+		doc, err := bson.Marshal(bson.D{{"_id", i}, {"s", strings.Repeat("x", size)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	cm := &CopyManager{options: CopyManagerOptions{BatchSizeBytes: limit}}
+	resultC := make(chan readBatchResult, len(docs))
+
+	var batchID uint32
+
+	err := cm.readSegment(t.Context(), resultC, &fakeCursor{docs: docs}, func() uint32 {
+		batchID++
+
+		return batchID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(resultC)
+
+	var counts []int
+
+	total := 0
+
+	for batch := range resultC {
+		counts = append(counts, len(batch.Documents))
+		total += len(batch.Documents)
+
+		// the large document is copied in its own batch
+		if batch.SizeBytes > limit && len(batch.Documents) != 1 {
+			t.Errorf("batch %d: got %d bytes of %d documents, want at most %d",
+				batch.ID, batch.SizeBytes, len(batch.Documents), limit)
+		}
+	}
+
+	if want := []int{3, 1, 2, 1}; !slices.Equal(counts, want) {
+		t.Errorf("batches: got = %v, want %v", counts, want)
+	}
+
+	if total != len(docs) {
+		t.Errorf("got %d documents, want %d", total, len(docs))
+	}
+}
+
+func TestInflightLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+
+	l := newInflightLimit(limit)
+
+	var inflight, maxInflight atomic.Int32
+
+	insertedC := make(chan struct{}, 100)
+
+	go func() { // the insert results release the batches
+		for range insertedC {
+			time.Sleep(time.Millisecond)
+			inflight.Add(-1)
+			l.release()
+		}
+	}()
+
+	for range 50 {
+		err := l.acquire(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := inflight.Add(1)
+		if n > maxInflight.Load() {
+			maxInflight.Store(n)
+		}
+
+		insertedC <- struct{}{}
+	}
+
+	close(insertedC)
+
+	if got := maxInflight.Load(); got > limit || got == 0 {
+		t.Errorf("got %d in-flight batches, want at most %d", got, limit)
+	}
+
+	// the full limit waits until the context is done
+	full := newInflightLimit(1)
+
+	err := full.acquire(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := full.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// no limit
+	var unlimited inflightLimit
+	for range 10 {
+		if err := unlimited.acquire(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unlimited.release()
+}
@@ -0,0 +1,83 @@
+package pcsm
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// clusterParameterNames are the cluster parameters copied from the source to the target
+// with [StartOptions.CopyClusterParameters].
+var clusterParameterNames = []string{ //nolint:gochecknoglobals
+	"defaultMaxTimeMS",    // MongoDB 8.0+
+	"changeStreamOptions", // MongoDB 6.0+
+}
+
+// clusterParameters reads and writes the cluster parameters of a cluster.
+type clusterParameters interface {
+	Get(ctx context.Context, name string) (bson.D, error)
+	Set(ctx context.Context, name string, value bson.D) error
+}
+
+// mongoClusterParameters are the cluster parameters of the MongoDB cluster.
+type mongoClusterParameters struct {
+	m *mongo.Client
+}
+
+func (p mongoClusterParameters) Get(ctx context.Context, name string) (bson.D, error) {
+	return topo.GetClusterParameter(ctx, p.m, name) //nolint:wrapcheck
+}
+
+func (p mongoClusterParameters) Set(ctx context.Context, name string, value bson.D) error {
+	return topo.SetClusterParameter(ctx, p.m, name, value) //nolint:wrapcheck
+}
+
+// ClusterParametersReport is the result of the cluster parameters copy.
+type ClusterParametersReport struct {
+	// Copied are the names of the parameters set on the target.
+	Copied []string `bson:"copied,omitempty"`
+	// Skipped are the reasons of the parameters not copied by name (e.g. unsupported by
+	// the source or the target version).
+	Skipped map[string]string `bson:"skipped,omitempty"`
+}
+
+// copyClusterParameters sets the cluster parameters of the source on the target.
+// A parameter that cannot be read from the source or set on the target is skipped
+// and reported: the copy does not fail the replication.
+func copyClusterParameters(
+	ctx context.Context,
+	source clusterParameters,
+	target clusterParameters,
+	names []string,
+) ClusterParametersReport {
+	lg := log.Ctx(ctx)
+
+	report := ClusterParametersReport{Skipped: map[string]string{}}
+
+	for _, name := range names {
+		value, err := source.Get(ctx, name)
+		if err != nil {
+			lg.Warnf("Cluster parameter %q is skipped: source: %v", name, err)
+			report.Skipped[name] = "source: " + err.Error()
+
+			continue
+		}
+
+		err = target.Set(ctx, name, value)
+		if err != nil {
+			lg.Warnf("Cluster parameter %q is skipped: target: %v", name, err)
+			report.Skipped[name] = "target: " + err.Error()
+
+			continue
+		}
+
+		lg.Infof("Cluster parameter %q is copied: %v", name, value)
+		report.Copied = append(report.Copied, name)
+	}
+
+	return report
+}
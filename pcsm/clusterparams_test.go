@@ -0,0 +1,82 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// fakeClusterParameters are the cluster parameters of a cluster version.
+type fakeClusterParameters struct {
+	params    map[string]bson.D
+	supported []string
+}
+
+func (p *fakeClusterParameters) Get(_ context.Context, name string) (bson.D, error) {
+	value, ok := p.params[name]
+	if !ok {
+		return nil, errors.Errorf("unknown cluster parameter %q", name)
+	}
+
+	return value, nil
+}
+
+func (p *fakeClusterParameters) Set(_ context.Context, name string, value bson.D) error {
+	if !slices.Contains(p.supported, name) {
+		return errors.Errorf("unknown cluster parameter %q", name)
+	}
+
+	p.params[name] = value
+
+	return nil
+}
+
+func TestCopyClusterParameters(t *testing.T) {
+	t.Parallel()
+
+	maxTimeMS := bson.D{{"readOperations", int64(5000)}}
+	streamOptions := bson.D{{"preAndPostImages", bson.D{{"expireAfterSeconds", int64(3600)}}}}
+
+	source := &fakeClusterParameters{params: map[string]bson.D{
+		"defaultMaxTimeMS":    maxTimeMS,
+		"changeStreamOptions": streamOptions,
+	}}
+	// the target version does not support defaultMaxTimeMS
+	target := &fakeClusterParameters{
+		params:    map[string]bson.D{},
+		supported: []string{"changeStreamOptions", "auditConfig"},
+	}
+
+	names := []string{"changeStreamOptions", "defaultMaxTimeMS", "auditConfig"}
+	report := copyClusterParameters(t.Context(), source, target, names)
+
+	if want := []string{"changeStreamOptions"}; !slices.Equal(report.Copied, want) {
+		t.Errorf("copied: got = %v, want %v", report.Copied, want)
+	}
+
+	if got := target.params["changeStreamOptions"]; !reflect.DeepEqual(got, streamOptions) {
+		t.Errorf("target changeStreamOptions: got = %v, want %v", got, streamOptions)
+	}
+
+	if _, ok := target.params["defaultMaxTimeMS"]; ok {
+		t.Error("target defaultMaxTimeMS: got set")
+	}
+
+	if len(report.Skipped) != 2 {
+		t.Errorf("skipped: got = %v, want 2", report.Skipped)
+	}
+
+	if reason := report.Skipped["defaultMaxTimeMS"]; !strings.HasPrefix(reason, "target: ") {
+		t.Errorf("skipped defaultMaxTimeMS: got reason %q", reason)
+	}
+
+	if reason := report.Skipped["auditConfig"]; !strings.HasPrefix(reason, "source: ") {
+		t.Errorf("skipped auditConfig: got reason %q", reason)
+	}
+}
@@ -0,0 +1,100 @@
+package pcsm
+
+import (
+	"context"
+	"maps"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// CollectionErrorAction is the handling of a collection that fails to clone
+// (e.g. permission denied on a single namespace).
+type CollectionErrorAction string
+
+const (
+	// CollectionErrorFail fails the clone.
+	CollectionErrorFail CollectionErrorAction = "fail"
+	// CollectionErrorSkip skips the collection and clones the others. The skipped collection
+	// is dropped on the target and its changes are not replicated.
+	CollectionErrorSkip CollectionErrorAction = "skip"
+)
+
+// ParseCollectionErrorAction parses the collection error action. The empty string is
+// [CollectionErrorFail].
+func ParseCollectionErrorAction(s string) (CollectionErrorAction, error) {
+	switch a := CollectionErrorAction(s); a {
+	case "":
+		return CollectionErrorFail, nil
+	case CollectionErrorFail, CollectionErrorSkip:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid collection error action %q", s)
+}
+
+// skippedCollection is a collection skipped by the clone on an error.
+type skippedCollection struct {
+	Namespace Namespace `bson:"ns"`
+	Error     string    `bson:"error"`
+}
+
+// skipFailedCollection records the namespace failed to clone as skipped with
+// [CollectionErrorSkip] and reports whether it is skipped. The stopped clone (e.g. on the source
+// authentication failure) is not skipped.
+func (c *Clone) skipFailedCollection(ctx context.Context, ns Namespace, err error) bool {
+	if c.options.OnCollectionError != CollectionErrorSkip ||
+		ctx.Err() != nil || topo.IsAuthenticationFailed(err) {
+		return false
+	}
+
+	lg := log.Ctx(ctx).With(log.NS(ns.Database, ns.Collection))
+	lg.Errorf(err, "Collection %q skipped: clone failed", ns.String())
+
+	c.lock.Lock()
+	if c.skipped == nil {
+		c.skipped = make(map[Namespace]string)
+	}
+
+	c.skipped[ns] = err.Error()
+	c.lock.Unlock()
+
+	return true
+}
+
+// dropSkippedCollection drops the partial copy of the skipped namespace on the target.
+// A failed drop is logged: the clone continues.
+func (c *Clone) dropSkippedCollection(ctx context.Context, ns Namespace) {
+	err := c.catalog.DropCollection(ctx, ns.Database, ns.Collection)
+	if err != nil {
+		log.Ctx(ctx).With(log.NS(ns.Database, ns.Collection)).Error(err, "Drop skipped collection")
+	}
+}
+
+// IsSkipped reports whether the namespace is skipped by the clone on an error.
+func (c *Clone) IsSkipped(db, coll string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.skipped[Namespace{db, coll}]
+
+	return ok
+}
+
+// skippedCollections returns a copy of the skipped namespaces.
+func (c *Clone) skippedCollections() map[Namespace]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return maps.Clone(c.skipped)
+}
+
+// excludeSkipped returns the filter that does not allow the namespaces skipped by the clone,
+// so their changes are not replicated.
+func excludeSkipped(filter sel.NSFilter, clone *Clone) sel.NSFilter {
+	return func(db, coll string) bool {
+		return filter(db, coll) && !clone.IsSkipped(db, coll)
+	}
+}
@@ -0,0 +1,103 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseCollectionErrorAction(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]CollectionErrorAction{
+		"":     CollectionErrorFail,
+		"fail": CollectionErrorFail,
+		"skip": CollectionErrorSkip,
+	} {
+		got, err := ParseCollectionErrorAction(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q (%v), want %q", s, got, err, want)
+		}
+	}
+
+	if _, err := ParseCollectionErrorAction("ignore"); err == nil {
+		t.Error("ignore: got = nil, want error")
+	}
+}
+
+func TestClone_SkipFailedCollection(t *testing.T) {
+	t.Parallel()
+
+	client := unreachableClient(t)
+	failed := Namespace{"db_0", "coll_1"}
+	errDenied := errors.New("permission denied")
+
+	newClone := func(action CollectionErrorAction) *Clone {
+		c := NewClone(client, client, NewCatalog(client, CatalogOptions{}), makeNSFilter(nil, nil),
+			CloneOptions{OnCollectionError: action})
+		c.sizeMap = sizeMap{
+			Namespace{"db_0", "coll_0"}: {Size: 100},
+			failed:                      {Size: 200},
+		}
+		c.startTime = time.Now()
+		c.startTS = bson.Timestamp{T: 1}
+
+		return c
+	}
+
+	if newClone(CollectionErrorFail).skipFailedCollection(t.Context(), failed, errDenied) {
+		t.Error("fail: got = skipped, want failed")
+	}
+
+	// a stopped clone is not skipped
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if newClone(CollectionErrorSkip).skipFailedCollection(ctx, failed, errDenied) {
+		t.Error("canceled: got = skipped, want failed")
+	}
+
+	c := newClone(CollectionErrorSkip)
+	if !c.skipFailedCollection(t.Context(), failed, errDenied) {
+		t.Fatal("skip: got = failed, want skipped")
+	}
+
+	if got := c.Status().Skipped; got[failed] != errDenied.Error() {
+		t.Errorf("status: got = %v, want %s: %v", got, failed, errDenied)
+	}
+
+	// the other collections are replicated
+	filter := excludeSkipped(makeNSFilter(nil, nil), c)
+	if filter(failed.Database, failed.Collection) || !filter("db_0", "coll_0") {
+		t.Error("filter: got the skipped collection allowed or the other excluded")
+	}
+
+	// the skipped collection is not cloned again after a restart
+	restored := &Clone{doneSig: make(chan struct{})}
+
+	err := restored.Recover(c.Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !restored.IsSkipped(failed.Database, failed.Collection) {
+		t.Error("restored: got = not skipped, want skipped")
+	}
+
+	restored.sizeMap = c.sizeMap
+
+	var got []Namespace
+	for _, ns := range restored.listPrioritizedNamespaces() {
+		got = append(got, ns.Namespace)
+	}
+
+	want := []Namespace{{"db_0", "coll_0"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package pcsm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// connectionsExhaustedError is the error of a write rejected by the target at its connection
+// limit. It is not retried by [runWrite]: the [applyConcurrency] backs off and reduces
+// the concurrency instead.
+type connectionsExhaustedError struct {
+	err error
+}
+
+func (e connectionsExhaustedError) Error() string {
+	return "target connections exhausted: " + e.err.Error()
+}
+
+func isConnectionsExhausted(err error) bool {
+	var exErr connectionsExhaustedError
+
+	return errors.As(err, &exErr) || topo.IsConnectionExhausted(err)
+}
+
+// applyConcurrency limits the concurrent writes of the change replication. When the target
+// rejects new connections, the limit is halved and the write is retried after a backoff.
+// The limit is doubled back to the maximum after each restore interval of successful writes.
+type applyConcurrency struct {
+	lock sync.Mutex
+
+	max      int
+	limit    int
+	reduced  time.Time // time of the last reduce or restore. Zero at the maximum
+	restored time.Duration
+
+	backoff    time.Duration
+	maxBackoff time.Duration
+	timeout    time.Duration
+}
+
+func newApplyConcurrency(maxLimit int) *applyConcurrency {
+	return &applyConcurrency{
+		max:        maxLimit,
+		limit:      maxLimit,
+		restored:   config.ApplyConcurrencyRestoreInterval,
+		backoff:    config.ConnectionsExhaustedBackoff,
+		maxBackoff: config.MaxConnectionsExhaustedBackoff,
+		timeout:    config.ConnectionsExhaustedTimeout,
+	}
+}
+
+// current returns the current limit.
+func (c *applyConcurrency) current() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.limit
+}
+
+// reduce halves the limit down to one and returns the new limit.
+func (c *applyConcurrency) reduce(now time.Time) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.limit = max(c.limit/2, 1) //nolint:mnd
+	c.reduced = now
+
+	return c.limit
+}
+
+// succeeded records a successful write at now. It doubles the reduced limit up to the maximum
+// after the restore interval since the last reduce or restore, and returns the new limit.
+func (c *applyConcurrency) succeeded(now time.Time) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.limit >= c.max || now.Sub(c.reduced) < c.restored {
+		return c.limit
+	}
+
+	c.limit = min(c.limit*2, c.max) //nolint:mnd
+	c.reduced = now
+
+	if c.limit == c.max {
+		c.reduced = time.Time{}
+	}
+
+	log.New("bulk:write").Infof("Apply concurrency restored to %d", c.limit)
+
+	return c.limit
+}
+
+// run runs the write with the current limit. On the exhausted target connections, it reduces
+// the limit and retries the write after a backoff until the timeout.
+func (c *applyConcurrency) run(ctx context.Context, write func(ctx context.Context, limit int) error) error {
+	backoff := c.backoff
+	deadline := time.Now().Add(c.timeout)
+
+	for {
+		err := write(ctx, c.current())
+		if err == nil {
+			c.succeeded(time.Now())
+
+			return nil
+		}
+
+		if !isConnectionsExhausted(err) || !time.Now().Before(deadline) {
+			return err
+		}
+
+		limit := c.reduce(time.Now())
+
+		log.Ctx(ctx).Warnf("Bulk write rejected: %v. Apply concurrency reduced to %d, retrying in %s",
+			err, limit, backoff)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, c.maxBackoff) //nolint:mnd
+	}
+}
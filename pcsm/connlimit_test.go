@@ -0,0 +1,145 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestApplyConcurrency_ReduceRestore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := newApplyConcurrency(8)
+
+	for _, want := range []int{4, 2, 1, 1} {
+		if got := c.reduce(now); got != want {
+			t.Errorf("reduce: got = %d, want %d", got, want)
+		}
+	}
+
+	// not restored before the restore interval of successful writes
+	if got := c.succeeded(now.Add(c.restored / 2)); got != 1 {
+		t.Errorf("early: got = %d, want 1", got)
+	}
+
+	for i, want := range []int{2, 4, 8, 8} {
+		if got := c.succeeded(now.Add(time.Duration(i+1) * c.restored)); got != want {
+			t.Errorf("restore #%d: got = %d, want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestCollectionBulkWrite_ConnectionsExhausted(t *testing.T) {
+	t.Parallel()
+
+	bw := newCollectionBulkWrite(10, bulkOptions{})
+	bw.concurrency = newApplyConcurrency(4)
+	bw.concurrency.backoff = time.Millisecond
+	bw.concurrency.maxBackoff = time.Millisecond
+	bw.concurrency.restored = 0
+
+	exhausted := Namespace{"db_0", "coll_0"}
+	for _, ns := range []Namespace{exhausted, {"db_0", "coll_1"}, {"db_1", "coll_0"}} {
+		bw.Insert(ns, &InsertEvent{
+			DocumentKey:  bson.D{{"_id", 1}},
+			FullDocument: mustMarshal(t, bson.D{{"_id", 1}}),
+		})
+	}
+
+	var lock sync.Mutex
+	writes := map[Namespace]int{}
+	var limits []int
+
+	rejected := 2
+
+	write := func(ctx context.Context, limit int) error {
+		lock.Lock()
+		limits = append(limits, limit)
+		lock.Unlock()
+
+		_, err := bw.writeNamespaces(ctx, limit, func(ctx context.Context, ns Namespace, _ []mongo.WriteModel) error {
+			return runWrite(ctx, 0, time.Millisecond, func(context.Context) error {
+				lock.Lock()
+				defer lock.Unlock()
+
+				writes[ns]++
+
+				if ns == exhausted && rejected > 0 {
+					rejected--
+
+					return errors.Wrapf(topology.WaitQueueTimeoutError{Wrapped: context.DeadlineExceeded},
+						"bulk write %q", ns)
+				}
+
+				return nil
+			})
+		})
+
+		return err
+	}
+
+	err := bw.concurrency.run(t.Context(), write)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the concurrency is reduced on each rejection and the written namespaces are not rewritten
+	if want := []int{4, 2, 1}; !slices.Equal(limits, want) {
+		t.Errorf("limits: got = %v, want %v", limits, want)
+	}
+
+	if writes[exhausted] != 3 || writes[Namespace{"db_0", "coll_1"}] != 1 || writes[Namespace{"db_1", "coll_0"}] != 1 {
+		t.Errorf("writes: got = %v, want 3 of %s and 1 of the others", writes, exhausted)
+	}
+
+	if !bw.Empty() {
+		t.Errorf("got %d buffered writes, want none", bw.count)
+	}
+
+	// the connections are free: the concurrency is restored
+	if got := bw.concurrency.current(); got != 2 {
+		t.Errorf("restored: got = %d, want 2", got)
+	}
+
+	for range 2 {
+		err = bw.concurrency.run(t.Context(), write)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := bw.concurrency.current(); got != 4 {
+		t.Errorf("restored: got = %d, want 4", got)
+	}
+}
+
+func TestApplyConcurrency_OtherError(t *testing.T) {
+	t.Parallel()
+
+	c := newApplyConcurrency(4)
+	errWrite := errors.New("write failed")
+
+	calls := 0
+
+	err := c.run(t.Context(), func(context.Context, int) error {
+		calls++
+
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) || calls != 1 {
+		t.Errorf("got = %v after %d calls, want %v after 1", err, calls, errWrite)
+	}
+
+	if got := c.current(); got != 4 {
+		t.Errorf("got = %d, want 4", got)
+	}
+}
@@ -78,6 +78,40 @@ type CopyManagerOptions struct {
 	// max: 2GiB [config.MaxCloneReadBatchSizeBytes].
 	// default: 96MB [config.DefaultCloneReadBatchSizeBytes].
 	ReadBatchSizeBytes int32
+	// BatchSizeBytes is the maximum size in bytes of a copy batch of the documents read
+	// from a segment and inserted together. A larger document is copied in its own batch.
+	// max and default: 48MB [config.MaxWriteBatchSizeBytes].
+	BatchSizeBytes int
+	// MaxInflightBatches is the maximum number of the insert batches of a collection sent to
+	// the insert workers and not inserted yet. default: no limit.
+	MaxInflightBatches int
+	// MarkerField is the field set to MarkerValue on each copied document. The documents
+	// stamped on the target with the same value are not inserted again, so a restarted copy
+	// into the kept target collection skips them. default: the documents are not stamped.
+	MarkerField string
+	// MarkerValue identifies the copy of the stamped documents (e.g. the clone start time).
+	MarkerValue bson.Timestamp
+	// NoCursorTimeout disables the server idle timeout for the read cursors.
+	// default: false. A killed cursor is resumed by _id regardless of the option.
+	NoCursorTimeout bool
+	// CappedTail is the number of the most recent documents to copy per capped collection
+	// namespace. default: the entire collection.
+	CappedTail map[string]int64
+	// Snapshot is the read consistency mode of a collection copy.
+	// In [CloneSnapshotSession] mode, the segments of a collection are read sequentially
+	// in a snapshot session. default: [CloneSnapshotNone].
+	Snapshot CloneSnapshotMode
+	// ReadConcern is the read concern level of the collection reads.
+	// default: the read concern of the source client.
+	ReadConcern CloneReadConcern
+	// TargetNamespace returns the target namespace of the copied source namespace.
+	// default: the source namespace.
+	TargetNamespace func(Namespace) Namespace
+	// OnNestingExceeded is the handling of the documents nested deeper than
+	// [config.MaxNestingDepth]. default: no check.
+	OnNestingExceeded NestingExceededAction
+	// Memory bounds the read batches not inserted yet. default: unlimited.
+	Memory *MemoryBudget
 }
 
 func NewCopyManager(source, target *mongo.Client, options CopyManagerOptions) *CopyManager {
@@ -102,6 +136,10 @@ func NewCopyManager(source, target *mongo.Client, options CopyManagerOptions) *C
 		options.ReadBatchSizeBytes = min(options.ReadBatchSizeBytes, config.MaxCloneReadBatchSizeBytes)
 	}
 
+	if options.BatchSizeBytes <= 0 || options.BatchSizeBytes > config.MaxWriteBatchSizeBytes {
+		options.BatchSizeBytes = config.MaxWriteBatchSizeBytes
+	}
+
 	lg := log.New("copy")
 	lg.Debugf("NumReadWorkers: %d", options.NumReadWorkers)
 	lg.Debugf("NumInsertWorkers: %d", options.NumInsertWorkers)
@@ -113,6 +151,11 @@ func NewCopyManager(source, target *mongo.Client, options CopyManagerOptions) *C
 	}
 	lg.Debugf("ReadBatchSizeBytes: %d (%s)", options.ReadBatchSizeBytes,
 		humanize.Bytes(uint64(options.ReadBatchSizeBytes))) //nolint:gosec
+	lg.Debugf("BatchSizeBytes: %d (%s)", options.BatchSizeBytes,
+		humanize.Bytes(uint64(options.BatchSizeBytes))) //nolint:gosec
+	if options.MaxInflightBatches > 0 {
+		lg.Debugf("MaxInflightBatches: %d", options.MaxInflightBatches)
+	}
 
 	insertCtx, cancelInsert := context.WithCancel(context.Background())
 
@@ -135,6 +178,7 @@ func NewCopyManager(source, target *mongo.Client, options CopyManagerOptions) *C
 			for t := range cm.insertQueue {
 				l := lg.With(log.NS(t.Namespace.Database, t.Namespace.Collection))
 				cm.insertBatch(l.WithContext(insertCtx), t)
+				cm.options.Memory.release(t.SizeBytes)
 			}
 		}()
 	}
@@ -185,7 +229,7 @@ func (cm *CopyManager) Do(
 }
 
 type (
-	nextSegmentFunc func(context.Context) (*mongo.Cursor, error)
+	nextSegmentFunc func(context.Context) (segmentCursor, error)
 	nextBatchIDFunc func() uint32
 )
 
@@ -212,8 +256,12 @@ func (cm *CopyManager) copyCollection(
 	var nextID nextBatchIDFunc = func() uint32 { return batchID.Add(1) }
 
 	if isCapped { //nolint:nestif
-		segmenter, err := NewCappedSegmenter(ctx,
-			cm.source, namespace, cm.options.ReadBatchSizeBytes)
+		segmenter, err := NewCappedSegmenter(ctx, cm.source, namespace, SegmentOptions{
+			BatchSizeBytes:  cm.options.ReadBatchSizeBytes,
+			NoCursorTimeout: cm.options.NoCursorTimeout,
+			TailDocs:        cm.options.CappedTail[namespace.String()],
+			ReadConcern:     cm.options.ReadConcern,
+		})
 		if err != nil {
 			if errors.Is(err, errEOC) {
 				return nil
@@ -231,6 +279,8 @@ func (cm *CopyManager) copyCollection(
 			SegmentSizeBytes: cm.options.SegmentSizeBytes,
 			BatchSizeBytes:   cm.options.ReadBatchSizeBytes,
 			AutoNumSegment:   cm.options.NumReadWorkers,
+			NoCursorTimeout:  cm.options.NoCursorTimeout,
+			ReadConcern:      cm.options.ReadConcern,
 		})
 		if err != nil {
 			if errors.Is(err, errEOC) {
@@ -245,7 +295,15 @@ func (cm *CopyManager) copyCollection(
 		go segmenter.handleNanIDDoc(readResultC, nextID)
 	}
 
-	collectionReadCtx, stopCollectionRead := context.WithCancel(ctx)
+	readCtx, endSession, err := cm.startReadSession(ctx)
+	if err != nil {
+		return errors.Wrap(err, "start snapshot session")
+	}
+
+	// a session must not be used concurrently. read the segments one by one
+	sequentialRead := isCapped || cm.options.Snapshot == CloneSnapshotSession
+
+	collectionReadCtx, stopCollectionRead := context.WithCancel(readCtx)
 
 	// pendingSegments tracks in-progress read segments
 	pendingSegments := &sync.WaitGroup{}
@@ -255,10 +313,12 @@ func (cm *CopyManager) copyCollection(
 	// pendingInserts tracks in-progress insert batches
 	pendingInserts := &sync.WaitGroup{}
 	insertResultC := make(chan insertBatchResult, cm.options.NumInsertWorkers)
+	inflight := newInflightLimit(cm.options.MaxInflightBatches)
 
 	go func() { // cleanup
 		<-collectionReadCtx.Done() // EOC or read error
 		pendingSegments.Wait()     // all segments is read (EOS)
+		endSession()               // no more reads in the session
 		close(readResultC)         // no more read batches: release send inserts routine
 		<-allBatchesSent           // wait until no more new batches for inserters
 		pendingInserts.Wait()      // all batches inserted
@@ -306,14 +366,15 @@ func (cm *CopyManager) copyCollection(
 
 			go func() {
 				defer func() {
-					<-cm.readLimit
-					pendingSegments.Done()
-
+					// close before the session can be ended
 					err := util.CtxWithTimeout(context.Background(),
 						config.CloseCursorTimeout, cursor.Close)
 					if err != nil {
 						log.Ctx(ctx).Error(err, "Close cursor")
 					}
+
+					<-cm.readLimit
+					pendingSegments.Done()
 				}()
 
 				err = cm.readSegment(ctx, readResultC, cursor, nextID)
@@ -324,7 +385,7 @@ func (cm *CopyManager) copyCollection(
 				}
 			}()
 
-			if isCapped {
+			if sequentialRead {
 				pendingSegments.Wait()
 			}
 		}
@@ -337,6 +398,15 @@ func (cm *CopyManager) copyCollection(
 
 		// collect batches from read workers
 		for readResult := range readResultC {
+			// the batches of the collection not inserted yet are bounded
+			err := inflight.acquire(ctx)
+			if err != nil {
+				cm.options.Memory.release(readResult.SizeBytes)
+				updateC <- CopyUpdate{Err: errors.Wrap(err, "wait for in-flight batches")}
+
+				continue
+			}
+
 			// send the batch to an insert worker
 			pendingInserts.Add(1)
 
@@ -356,6 +426,7 @@ func (cm *CopyManager) copyCollection(
 
 	// collect results from insert workers. notify caller
 	for insertResult := range insertResultC {
+		inflight.release()
 		pendingInserts.Done()
 
 		updateC <- CopyUpdate{
@@ -368,6 +439,26 @@ func (cm *CopyManager) copyCollection(
 	return nil
 }
 
+// startReadSession starts a snapshot session for a point-in-time read of a collection
+// in [CloneSnapshotSession] mode. It returns the context bound to the session and the function
+// that ends the session. In other modes, it returns the ctx unchanged.
+func (cm *CopyManager) startReadSession(ctx context.Context) (context.Context, func(), error) {
+	if cm.options.Snapshot != CloneSnapshotSession {
+		return ctx, func() {}, nil
+	}
+
+	sess, err := cm.source.StartSession(options.Session().SetSnapshot(true))
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	endSession := func() {
+		sess.EndSession(context.Background())
+	}
+
+	return mongo.NewSessionContext(ctx, sess), endSession, nil
+}
+
 type readBatchResult struct {
 	ID        uint32
 	Documents []any
@@ -375,14 +466,15 @@ type readBatchResult struct {
 }
 
 // readSegment reads documents from a segment cursor and sends readBatchResult to the result
-// channel. It batches documents until the configured maximum batch size is reached or the cursor is
-// exhausted. Each batch includes the documents, their total size, and a unique batch ID.
+// channel. It batches documents until [CopyManagerOptions.BatchSizeBytes] or the maximum
+// number of documents is reached, or the cursor is exhausted. Each batch includes the documents,
+// their total size, and a unique batch ID.
 // Returns when the segment ends or yields nothing. Does not close the resultC channel.
 // Metrics are collected for performance monitoring.
 func (cm *CopyManager) readSegment(
 	ctx context.Context,
 	resultC chan<- readBatchResult,
-	cur *mongo.Cursor,
+	cur segmentCursor,
 	nextID nextBatchIDFunc,
 ) error {
 	zl := log.Ctx(ctx).Unwrap()
@@ -391,9 +483,74 @@ func (cm *CopyManager) readSegment(
 	sizeBytes := 0
 	lastSentAt := time.Now()
 
+	maxSizeBytes := cm.options.BatchSizeBytes
+	if maxSizeBytes <= 0 {
+		maxSizeBytes = config.MaxWriteBatchSizeBytes
+	}
+
+	// the memory of a batch is acquired before its documents are read. The batch carries
+	// its size to the insert worker that releases it. The rest is released here.
+	held := 0
+	defer func() { cm.options.Memory.release(held) }()
+
+	acquire := func() error {
+		err := cm.options.Memory.acquire(ctx, maxSizeBytes)
+		if err != nil {
+			return errors.Wrap(err, "acquire memory")
+		}
+
+		held = maxSizeBytes
+
+		return nil
+	}
+
+	handOver := func() error {
+		if sizeBytes <= held {
+			cm.options.Memory.release(held - sizeBytes)
+			held = 0
+
+			return nil
+		}
+
+		// a document larger than the batch
+		cm.options.Memory.release(held)
+		held = 0
+
+		return errors.Wrap(cm.options.Memory.acquire(ctx, sizeBytes), "acquire memory")
+	}
+
+	err := acquire()
+	if err != nil {
+		return err
+	}
+
 	for cur.Next(ctx) {
-		if sizeBytes+len(cur.Current) > config.MaxWriteBatchSizeBytes ||
-			len(documents) == config.MaxInsertBatchSize {
+		doc := cur.Document()
+
+		if cm.options.OnNestingExceeded != "" {
+			err := checkNesting(doc)
+			if err != nil {
+				if cm.options.OnNestingExceeded == NestingExceededFail {
+					return err
+				}
+
+				log.Ctx(ctx).Warnf("Skip document: %v", err)
+
+				continue
+			}
+		}
+
+		if cm.options.MarkerField != "" {
+			var err error
+
+			doc, err = stampMarker(doc, cm.options.MarkerField, cm.options.MarkerValue)
+			if err != nil {
+				return errors.Wrap(err, "stamp marker")
+			}
+		}
+
+		if len(documents) != 0 && (sizeBytes+len(doc) > maxSizeBytes ||
+			len(documents) == config.MaxInsertBatchSize) {
 			elapsed := time.Since(lastSentAt)
 
 			zl.Trace().
@@ -407,6 +564,11 @@ func (cm *CopyManager) readSegment(
 			metrics.AddCopyReadDocumentCount(len(documents))
 			metrics.SetCopyReadBatchDurationSeconds(elapsed)
 
+			err := handOver()
+			if err != nil {
+				return err
+			}
+
 			resultC <- readBatchResult{
 				ID:        batchID,
 				Documents: documents,
@@ -417,13 +579,18 @@ func (cm *CopyManager) readSegment(
 			documents = make([]any, 0, config.MaxInsertBatchSize)
 			sizeBytes = 0
 			lastSentAt = time.Now()
+
+			err = acquire()
+			if err != nil {
+				return err
+			}
 		}
 
-		documents = append(documents, cur.Current)
-		sizeBytes += len(cur.Current)
+		documents = append(documents, doc)
+		sizeBytes += len(doc)
 	}
 
-	err := cur.Err()
+	err = cur.Err()
 	if err != nil {
 		zl.Trace().
 			Err(err).
@@ -453,6 +620,11 @@ func (cm *CopyManager) readSegment(
 	metrics.AddCopyReadDocumentCount(len(documents))
 	metrics.SetCopyReadBatchDurationSeconds(elapsed)
 
+	err = handOver()
+	if err != nil {
+		return err
+	}
+
 	resultC <- readBatchResult{
 		ID:        batchID,
 		Documents: documents,
@@ -462,6 +634,40 @@ func (cm *CopyManager) readSegment(
 	return nil
 }
 
+// inflightLimit bounds the insert batches of a collection that are sent to the insert workers
+// and not inserted yet. The methods of a nil limit do not bound the batches.
+type inflightLimit chan struct{}
+
+// newInflightLimit returns the limit of n batches. Zero or less returns nil (unlimited).
+func newInflightLimit(n int) inflightLimit {
+	if n <= 0 {
+		return nil
+	}
+
+	return make(inflightLimit, n)
+}
+
+// acquire waits until the batch fits in the limit.
+func (l inflightLimit) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
+
+// release releases the inserted batch.
+func (l inflightLimit) release() {
+	if l != nil {
+		<-l
+	}
+}
+
 type insertBatchTask struct {
 	Namespace Namespace
 	ID        uint32
@@ -490,7 +696,35 @@ func (cm *CopyManager) insertBatch(ctx context.Context, task insertBatchTask) {
 
 	startedAt := time.Now()
 
-	collection := cm.target.Database(task.Namespace.Database).Collection(task.Namespace.Collection)
+	ns := task.Namespace
+	if cm.options.TargetNamespace != nil {
+		ns = cm.options.TargetNamespace(ns)
+	}
+
+	collection := cm.target.Database(ns.Database).Collection(ns.Collection)
+
+	if cm.options.MarkerField != "" {
+		marked, err := cm.findMarked(ctx, collection, task.Documents)
+		if err != nil {
+			task.ResultC <- insertBatchResult{ID: task.ID, Err: errors.Wrap(err, "find marked documents")}
+
+			return
+		}
+
+		if len(marked) != 0 {
+			zl.Trace().
+				Uint32("id", task.ID).
+				Int("count", len(marked)).
+				Msgf("skip marked documents of batch %d", task.ID)
+
+			task.Documents, task.SizeBytes = filterMarked(task.Documents, marked)
+			if len(task.Documents) == 0 {
+				task.ResultC <- insertBatchResult{ID: task.ID}
+
+				return
+			}
+		}
+	}
 
 	err := topo.RunWithRetry(ctx, func(ctx context.Context) error {
 		_, err := collection.InsertMany(ctx, task.Documents, insertOptions)
@@ -508,6 +742,9 @@ func (cm *CopyManager) insertBatch(ctx context.Context, task insertBatchTask) {
 			return
 		}
 
+		// the _id uniqueness is enforced only by the _id index of the target: the batches
+		// are not checked before the insert, and a duplicate key is an already copied
+		// document (e.g. a segment read again after a killed cursor is resumed)
 		for _, e := range bulkError.WriteErrors {
 			if !mongo.IsDuplicateKeyError(e) {
 				task.ResultC <- insertBatchResult{ID: task.ID, Err: err}
@@ -556,6 +793,7 @@ type Segmenter struct {
 	mcoll       *mongo.Collection
 	segmentSize int64
 	batchSize   int32
+	noTimeout   bool
 	keyRanges   []keyRange
 	currIDRange keyRange
 	nanDoc      bson.Raw // document with NaN _id, if any
@@ -581,6 +819,23 @@ type SegmentOptions struct {
 	SegmentSizeBytes int64
 	BatchSizeBytes   int32
 	AutoNumSegment   int
+	NoCursorTimeout  bool
+	// TailDocs is the number of the most recent documents to copy (capped collections only).
+	// Zero copies the entire collection.
+	TailDocs int64
+	// ReadConcern is the read concern level of the segment reads.
+	// The empty value keeps the read concern of the client.
+	ReadConcern CloneReadConcern
+}
+
+// sourceCollectionOptions returns the options of the collection handle of the segment reads.
+func sourceCollectionOptions(rc CloneReadConcern) *options.CollectionOptionsBuilder {
+	opts := options.Collection()
+	if rc := rc.readConcern(); rc != nil {
+		opts.SetReadConcern(rc)
+	}
+
+	return opts
 }
 
 // NewSegmenter initializes a Segmenter for a given MongoDB namespace.
@@ -622,7 +877,7 @@ func NewSegmenter(
 	//nolint:gosec
 	batchSize := int32(min(int64(options.BatchSizeBytes)/stats.AvgObjSize, math.MaxInt32))
 
-	mcoll := m.Database(ns.Database).Collection(ns.Collection)
+	mcoll := m.Database(ns.Database).Collection(ns.Collection, sourceCollectionOptions(options.ReadConcern))
 
 	idKeyRange, nanDoc, err := getIDKeyRange(ctx, mcoll)
 	if err != nil {
@@ -638,6 +893,7 @@ func NewSegmenter(
 			mcoll:       mcoll,
 			segmentSize: segmentSize,
 			batchSize:   batchSize,
+			noTimeout:   options.NoCursorTimeout,
 			currIDRange: idKeyRange,
 			nanDoc:      *nanDoc,
 		}
@@ -661,6 +917,7 @@ func NewSegmenter(
 		mcoll:       mcoll,
 		segmentSize: segmentSize,
 		batchSize:   batchSize,
+		noTimeout:   options.NoCursorTimeout,
 		keyRanges:   remainingKeyRanges,
 		currIDRange: currIDRange,
 		nanDoc:      *nanDoc,
@@ -673,7 +930,7 @@ func NewSegmenter(
 // It advances through the collection by updating internal state with the next key range.
 // Returns ErrEOC when the end of the collection is reached, or ErrEOS if the current segment is
 // exhausted.
-func (seg *Segmenter) Next(ctx context.Context) (*mongo.Cursor, error) {
+func (seg *Segmenter) Next(ctx context.Context) (segmentCursor, error) {
 	seg.lock.Lock()
 	defer seg.lock.Unlock()
 
@@ -714,8 +971,9 @@ func (seg *Segmenter) Next(ctx context.Context) (*mongo.Cursor, error) {
 //
 // Returns:
 // - ErrEOS if the current segment is exhausted or contains no documents.
-// - A cursor over the current segment if documents are found.
-func (seg *Segmenter) doNext(ctx context.Context) (*mongo.Cursor, error) {
+// - A cursor over the current segment if documents are found. If the server kills the cursor,
+// it is resumed after the last read _id within the segment.
+func (seg *Segmenter) doNext(ctx context.Context) (segmentCursor, error) {
 	if seg.currIDRange.Max.IsZero() {
 		return nil, errEOS // previous segment was the last one
 	}
@@ -728,11 +986,31 @@ func (seg *Segmenter) doNext(ctx context.Context) (*mongo.Cursor, error) {
 	log.New("seg").With(log.NS(seg.mcoll.Database().Name(), seg.mcoll.Name())).
 		Tracef("[%v <=> %v]", seg.currIDRange.Min, maxKey)
 
-	cur, err := seg.mcoll.Find(ctx,
-		bson.D{{"_id", bson.D{{"$gte", seg.currIDRange.Min}, {"$lte", maxKey}}}},
-		options.Find().SetSort(bson.D{{"_id", 1}}).SetBatchSize(seg.batchSize))
+	minKey := seg.currIDRange.Min
+	findOptions := options.Find().
+		SetSort(bson.D{{"_id", 1}}).
+		SetBatchSize(seg.batchSize).
+		SetNoCursorTimeout(seg.noTimeout)
+
+	openAfter := func(ctx context.Context, after segmentKey) (segmentCursor, error) {
+		lowerBound := bson.E{"$gte", minKey}
+		if !after.IsZero() {
+			lowerBound = bson.E{"$gt", after}
+		}
+
+		cur, err := seg.mcoll.Find(ctx,
+			bson.D{{"_id", bson.D{lowerBound, {"$lte", maxKey}}}},
+			findOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "query")
+		}
+
+		return mongoCursor{cur}, nil
+	}
+
+	cur, err := newResumableCursor(ctx, openAfter)
 	if err != nil {
-		return nil, errors.Wrap(err, "query")
+		return nil, err
 	}
 
 	if maxKey.Equal(seg.currIDRange.Max) {
@@ -876,11 +1154,14 @@ func getMultiTypeIDKeyRanges(ctx context.Context, mcoll *mongo.Collection) ([]ke
 
 // CappedSegmenter provides sequential cursor access for capped collections.
 // Unlike Segmenter, it does not split the collection into multiple segments.
-// It returns a single forward-only cursor over the entire collection ordered by $natural.
+// It returns a single forward-only cursor over the entire collection (or its most recent
+// documents, see [SegmentOptions.TailDocs]) ordered by $natural.
 type CappedSegmenter struct {
 	lock      sync.Mutex
 	mcoll     *mongo.Collection
 	batchSize int32
+	skip      int64
+	noTimeout bool
 	endOfColl bool
 }
 
@@ -892,7 +1173,7 @@ func NewCappedSegmenter(
 	ctx context.Context,
 	m *mongo.Client,
 	ns Namespace,
-	batchSizeBytes int32,
+	options SegmentOptions,
 ) (*CappedSegmenter, error) {
 	stats, err := topo.GetCollStats(ctx, m, ns.Database, ns.Collection)
 	if err != nil {
@@ -907,18 +1188,37 @@ func NewCappedSegmenter(
 		return nil, errEOC
 	}
 
-	batchSize := int32(min(int64(batchSizeBytes)/stats.AvgObjSize, math.MaxInt32)) //nolint:gosec
-	mcoll := m.Database(ns.Database).Collection(ns.Collection)
+	//nolint:gosec
+	batchSize := int32(min(int64(options.BatchSizeBytes)/stats.AvgObjSize, math.MaxInt32))
+	mcoll := m.Database(ns.Database).Collection(ns.Collection, sourceCollectionOptions(options.ReadConcern))
 
 	cs := &CappedSegmenter{
 		mcoll:     mcoll,
 		batchSize: batchSize,
+		skip:      cappedTailSkip(stats.Count, options.TailDocs),
+		noTimeout: options.NoCursorTimeout,
+	}
+
+	if cs.skip != 0 {
+		log.Ctx(ctx).Infof("Capped collection %q: copy the last %d documents (skip %d)",
+			ns, options.TailDocs, cs.skip)
 	}
 
 	return cs, nil
 }
 
-func (cs *CappedSegmenter) Next(ctx context.Context) (*mongo.Cursor, error) {
+// cappedTailSkip returns the number of the oldest documents to skip to copy only the tail
+// documents of a capped collection with count documents. Zero tail copies all documents.
+// The documents inserted after the count are replicated by the change replication.
+func cappedTailSkip(count, tail int64) int64 {
+	if tail <= 0 || count <= tail {
+		return 0
+	}
+
+	return count - tail
+}
+
+func (cs *CappedSegmenter) Next(ctx context.Context) (segmentCursor, error) {
 	cs.lock.Lock()
 	defer cs.lock.Unlock()
 
@@ -927,12 +1227,16 @@ func (cs *CappedSegmenter) Next(ctx context.Context) (*mongo.Cursor, error) {
 	}
 
 	cur, err := cs.mcoll.Find(ctx, bson.D{},
-		options.Find().SetHint(bson.D{{"$natural", 1}}).SetBatchSize(cs.batchSize))
+		options.Find().
+			SetHint(bson.D{{"$natural", 1}}).
+			SetSkip(cs.skip).
+			SetBatchSize(cs.batchSize).
+			SetNoCursorTimeout(cs.noTimeout))
 	if err != nil {
 		return nil, errors.Wrap(err, "query")
 	}
 
 	cs.endOfColl = true
 
-	return cur, nil
+	return mongoCursor{cur}, nil
 }
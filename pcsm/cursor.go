@@ -0,0 +1,121 @@
+package pcsm
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// segmentCursor iterates documents of a collection segment.
+type segmentCursor interface {
+	// Next advances the cursor to the next document. Returns false at the end or on error.
+	Next(ctx context.Context) bool
+	// Document returns the current document.
+	Document() bson.Raw
+	// Err returns the last error.
+	Err() error
+	// Close closes the cursor.
+	Close(ctx context.Context) error
+}
+
+// mongoCursor adapts [mongo.Cursor] to [segmentCursor].
+type mongoCursor struct {
+	*mongo.Cursor
+}
+
+func (c mongoCursor) Document() bson.Raw {
+	return c.Current
+}
+
+// openAfterFunc opens a cursor over the remaining documents of a segment that follow
+// the document with the after _id. The zero after opens the cursor from the segment start.
+type openAfterFunc func(ctx context.Context, after segmentKey) (segmentCursor, error)
+
+// resumableCursor iterates a segment sorted by _id. If the server kills the cursor
+// (e.g. by the idle cursor timeout or maxTimeMS), it reopens the cursor after the last read
+// _id instead of failing the clone.
+type resumableCursor struct {
+	open openAfterFunc
+	cur  segmentCursor
+
+	lastID  segmentKey
+	resumes int // consecutive resumes without progress
+	err     error
+}
+
+// newResumableCursor opens the cursor from the segment start.
+func newResumableCursor(ctx context.Context, open openAfterFunc) (*resumableCursor, error) {
+	cur, err := open(ctx, segmentKey{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resumableCursor{open: open, cur: cur}, nil
+}
+
+func (c *resumableCursor) Next(ctx context.Context) bool {
+	for {
+		if c.err != nil {
+			return false
+		}
+
+		if c.cur.Next(ctx) {
+			c.lastID = c.cur.Document().Lookup("_id")
+			c.resumes = 0
+
+			return true
+		}
+
+		err := c.cur.Err()
+		if err == nil {
+			return false
+		}
+
+		if !topo.IsCursorTimeout(err) || c.resumes >= config.MaxCloneCursorResumes {
+			c.err = err
+
+			return false
+		}
+
+		c.resumes++
+
+		log.Ctx(ctx).Warnf("Clone cursor is killed: %v. Resume after _id %v (attempt %d)",
+			err, c.lastID, c.resumes)
+
+		err = c.cur.Close(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error(err, "Close killed cursor")
+		}
+
+		c.cur, err = c.open(ctx, c.lastID)
+		if err != nil {
+			c.err = errors.Wrap(err, "resume")
+		}
+	}
+}
+
+func (c *resumableCursor) Document() bson.Raw {
+	return c.cur.Document()
+}
+
+func (c *resumableCursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.cur.Err()
+}
+
+func (c *resumableCursor) Close(ctx context.Context) error {
+	if c.cur == nil {
+		return nil
+	}
+
+	return c.cur.Close(ctx) //nolint:wrapcheck
+}
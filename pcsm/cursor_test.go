@@ -0,0 +1,156 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// fakeCursor returns the documents and then fails with err.
+type fakeCursor struct {
+	docs []bson.Raw
+	err  error
+	curr bson.Raw
+}
+
+func (c *fakeCursor) Next(context.Context) bool {
+	if len(c.docs) == 0 {
+		return false
+	}
+
+	c.curr, c.docs = c.docs[0], c.docs[1:]
+
+	return true
+}
+
+func (c *fakeCursor) Document() bson.Raw          { return c.curr }
+func (c *fakeCursor) Close(context.Context) error { return nil }
+func (c *fakeCursor) Err() error {
+	if len(c.docs) != 0 {
+		return nil
+	}
+
+	return c.err
+}
+
+var errCursorNotFound = mongo.CommandError{Code: 43, Name: "CursorNotFound"} //nolint:gochecknoglobals
+
+func makeIDDocs(ids ...int32) []bson.Raw {
+	docs := make([]bson.Raw, len(ids))
+	for i, id := range ids {
+		docs[i], _ = bson.Marshal(bson.D{{"_id", id}})
+	}
+
+	return docs
+}
+
+func readIDs(t *testing.T, cur *resumableCursor) []int32 {
+	t.Helper()
+
+	var ids []int32
+	for cur.Next(t.Context()) {
+		ids = append(ids, cur.Document().Lookup("_id").Int32())
+	}
+
+	return ids
+}
+
+func TestResumableCursor_ResumeAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	var afterIDs []bson.RawValue
+
+	// the first cursor is killed after 3 documents. the resumed one reads the rest.
+	open := func(_ context.Context, after segmentKey) (segmentCursor, error) {
+		afterIDs = append(afterIDs, after)
+		if after.IsZero() {
+			return &fakeCursor{docs: makeIDDocs(1, 2, 3), err: errCursorNotFound}, nil
+		}
+
+		return &fakeCursor{docs: makeIDDocs(4, 5)}, nil
+	}
+
+	cur, err := newResumableCursor(t.Context(), open)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := readIDs(t, cur)
+	if err := cur.Err(); err != nil {
+		t.Fatalf("Err: got = %v, want nil", err)
+	}
+
+	want := []int32{1, 2, 3, 4, 5}
+	if len(ids) != len(want) {
+		t.Fatalf("got = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("got = %v, want %v", ids, want)
+		}
+	}
+
+	if len(afterIDs) != 2 || !afterIDs[0].IsZero() || afterIDs[1].Int32() != 3 {
+		t.Errorf("got resume points = %v, want [<start>, 3]", afterIDs)
+	}
+}
+
+func TestResumableCursor_GiveUpWithoutProgress(t *testing.T) {
+	t.Parallel()
+
+	opened := 0
+	open := func(_ context.Context, _ segmentKey) (segmentCursor, error) {
+		opened++
+
+		return &fakeCursor{err: errCursorNotFound}, nil
+	}
+
+	cur, err := newResumableCursor(t.Context(), open)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := readIDs(t, cur); len(ids) != 0 {
+		t.Errorf("got = %v, want no documents", ids)
+	}
+
+	if !topo.IsCursorTimeout(cur.Err()) {
+		t.Errorf("Err: got = %v, want %v", cur.Err(), errCursorNotFound)
+	}
+
+	if opened != 1+config.MaxCloneCursorResumes {
+		t.Errorf("opened: got = %d, want %d", opened, 1+config.MaxCloneCursorResumes)
+	}
+}
+
+func TestResumableCursor_NonTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	errOther := mongo.CommandError{Code: 2, Name: "BadValue"}
+
+	open := func(_ context.Context, after segmentKey) (segmentCursor, error) {
+		if !after.IsZero() {
+			t.Fatal("unexpected resume")
+		}
+
+		return &fakeCursor{docs: makeIDDocs(1), err: errOther}, nil
+	}
+
+	cur, err := newResumableCursor(t.Context(), open)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readIDs(t, cur)
+
+	var cmdErr mongo.CommandError
+	if !errors.As(cur.Err(), &cmdErr) || cmdErr.Name != errOther.Name {
+		t.Errorf("Err: got = %v, want %v", cur.Err(), errOther)
+	}
+}
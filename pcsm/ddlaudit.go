@@ -0,0 +1,88 @@
+package pcsm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// ParseDDLAuditNamespace parses the "db.collection" namespace of the DDL audit collection.
+// The empty string returns the zero namespace (disabled).
+func ParseDDLAuditNamespace(s string) (Namespace, error) {
+	if s == "" {
+		return Namespace{}, nil
+	}
+
+	db, coll, _ := strings.Cut(s, ".")
+	if db == "" || coll == "" {
+		return Namespace{}, errors.Errorf("invalid DDL audit namespace %q", s)
+	}
+
+	ns := Namespace{Database: db, Collection: coll}
+
+	err := ns.ValidateLength()
+	if err != nil {
+		return Namespace{}, errors.Wrap(err, "DDL audit namespace")
+	}
+
+	return ns, nil
+}
+
+// ddlAuditEntry is a DDL change replicated to the target. It is stored in the DDL audit
+// collection on the target as the schema change history of the migration.
+type ddlAuditEntry struct {
+	ID            bson.ObjectID  `bson:"_id,omitempty"`
+	Namespace     Namespace      `bson:"ns"`
+	OperationType OperationType  `bson:"operationType"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+	Details       bson.Raw       `bson:"details"`
+	AppliedAt     time.Time      `bson:"appliedAt"`
+}
+
+func newDDLAuditEntry(change *ChangeEvent, appliedAt time.Time) (*ddlAuditEntry, error) {
+	details, err := bson.Marshal(change.Event)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal event")
+	}
+
+	entry := &ddlAuditEntry{
+		Namespace:     change.Namespace,
+		OperationType: change.OperationType,
+		ClusterTime:   change.ClusterTime,
+		Details:       details,
+		AppliedAt:     appliedAt,
+	}
+
+	return entry, nil
+}
+
+// writeDDLAudit stores the applied DDL change in the audit collection.
+func writeDDLAudit(ctx context.Context, m *mongo.Client, audit Namespace, change *ChangeEvent) error {
+	entry, err := newDDLAuditEntry(change, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Database(audit.Database).Collection(audit.Collection).InsertOne(ctx, entry)
+
+	return errors.Wrap(err, "insert")
+}
+
+// auditDDL records the applied DDL change with [ReplOptions.DDLAudit]. A failed write is logged:
+// the audit does not stop the replication.
+func (r *Repl) auditDDL(ctx context.Context, change *ChangeEvent) {
+	if r.options.DDLAudit.Database == "" {
+		return
+	}
+
+	err := writeDDLAudit(ctx, r.target, r.options.DDLAudit, change)
+	if err != nil {
+		log.Ctx(ctx).Error(err, "Write DDL audit entry")
+	}
+}
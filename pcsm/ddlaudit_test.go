@@ -0,0 +1,65 @@
+package pcsm //nolint
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+func TestParseDDLAuditNamespace(t *testing.T) {
+	t.Parallel()
+
+	ns, err := ParseDDLAuditNamespace("pcsm_audit.ddl")
+	if err != nil || ns != (Namespace{"pcsm_audit", "ddl"}) {
+		t.Errorf("got = %v, %v, want pcsm_audit.ddl", ns, err)
+	}
+
+	ns, err = ParseDDLAuditNamespace("")
+	if err != nil || ns.Database != "" {
+		t.Errorf("empty: got = %v, %v, want the zero namespace", ns, err)
+	}
+
+	for _, s := range []string{"pcsm_audit", ".ddl", "pcsm_audit."} {
+		_, err = ParseDDLAuditNamespace(s)
+		if err == nil {
+			t.Errorf("%q: got = nil, want an error", s)
+		}
+	}
+}
+
+func TestNewDDLAuditEntry_CreateIndexes(t *testing.T) {
+	t.Parallel()
+
+	change := &ChangeEvent{
+		EventHeader: EventHeader{
+			OperationType: CreateIndexes,
+			Namespace:     Namespace{"db_1", "coll_1"},
+			ClusterTime:   bson.Timestamp{T: 1700000000, I: 1},
+		},
+		Event: CreateIndexesEvent{
+			OperationDescription: createIndexesOpDesc{
+				Indexes: []*topo.IndexSpecification{{Name: "i_1", KeysDocument: mustMarshal(t, bson.D{{"i", 1}})}},
+			},
+		},
+	}
+
+	appliedAt := time.Now()
+
+	entry, err := newDDLAuditEntry(change, appliedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Namespace != change.Namespace || entry.OperationType != CreateIndexes ||
+		entry.ClusterTime != change.ClusterTime || !entry.AppliedAt.Equal(appliedAt) {
+		t.Errorf("got = %+v, want createIndexes of %s at %v", entry, change.Namespace, change.ClusterTime)
+	}
+
+	name, ok := entry.Details.Lookup("operationDescription", "indexes", "0", "name").StringValueOK()
+	if !ok || name != "i_1" {
+		t.Errorf("details: got = %v, want the index i_1", entry.Details)
+	}
+}
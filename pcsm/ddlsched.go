@@ -0,0 +1,147 @@
+package pcsm
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// isConcurrentDDL reports whether the DDL change affects only its own namespace and is applied
+// by the [ddlScheduler] concurrently with the changes of the other namespaces.
+// The other DDL changes (e.g. create, drop, or rename) change the namespace mapping and
+// are applied after all scheduled DDL changes.
+func isConcurrentDDL(op OperationType) bool {
+	switch op { //nolint:exhaustive
+	case CreateIndexes, DropIndexes, Modify:
+		return true
+	}
+
+	return false
+}
+
+// ddlTask is a DDL change scheduled by the [ddlScheduler].
+type ddlTask struct {
+	ts   bson.Timestamp // cluster time of the change
+	done chan struct{}  // closed when the change is applied
+}
+
+// ddlScheduler applies the DDL changes of different namespaces concurrently. The DDL changes
+// of the same namespace are applied in order, and the CRUD changes of a namespace wait for
+// its pending DDL changes with [ddlScheduler.wait].
+type ddlScheduler struct {
+	workers chan struct{} // limits the concurrently applied DDL changes
+
+	lock    sync.Mutex
+	last    map[Namespace]*ddlTask // last scheduled DDL change of each namespace
+	pending map[*ddlTask]struct{}  // scheduled DDL changes not applied yet
+	err     error                  // first failed DDL change
+
+	wg sync.WaitGroup
+}
+
+func newDDLScheduler(workers int) *ddlScheduler {
+	return &ddlScheduler{
+		workers: make(chan struct{}, workers),
+		last:    make(map[Namespace]*ddlTask),
+		pending: make(map[*ddlTask]struct{}),
+	}
+}
+
+// schedule applies the DDL change of the namespace with apply after the previously scheduled
+// DDL changes of the namespace. It blocks while all workers are busy.
+func (s *ddlScheduler) schedule(
+	ctx context.Context,
+	ns Namespace,
+	ts bson.Timestamp,
+	apply func(context.Context) error,
+) {
+	task := &ddlTask{ts: ts, done: make(chan struct{})}
+
+	s.lock.Lock()
+	prev := s.last[ns]
+	s.last[ns] = task
+	s.pending[task] = struct{}{}
+	s.lock.Unlock()
+
+	s.workers <- struct{}{}
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer s.finish(ns, task)
+		defer func() { <-s.workers }()
+
+		if prev != nil {
+			<-prev.done
+		}
+
+		if s.failed() != nil {
+			return
+		}
+
+		err := apply(ctx)
+		if err != nil {
+			s.lock.Lock()
+			if s.err == nil {
+				s.err = err
+			}
+			s.lock.Unlock()
+		}
+	}()
+}
+
+func (s *ddlScheduler) finish(ns Namespace, task *ddlTask) {
+	close(task.done)
+
+	s.lock.Lock()
+	delete(s.pending, task)
+	if s.last[ns] == task {
+		delete(s.last, ns)
+	}
+	s.lock.Unlock()
+}
+
+// wait waits until the scheduled DDL changes of the namespace are applied.
+func (s *ddlScheduler) wait(ns Namespace) {
+	s.lock.Lock()
+	task := s.last[ns]
+	s.lock.Unlock()
+
+	if task != nil {
+		<-task.done
+	}
+}
+
+// waitAll waits until all scheduled DDL changes are applied.
+func (s *ddlScheduler) waitAll() {
+	s.wg.Wait()
+}
+
+// failed returns the error of the first failed DDL change.
+func (s *ddlScheduler) failed() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.err
+}
+
+// earliestPending returns the cluster time of the earliest DDL change not applied yet.
+// The change replication resumes from it, so the change is applied again after a restart.
+func (s *ddlScheduler) earliestPending() (bson.Timestamp, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var earliest bson.Timestamp
+
+	found := false
+
+	for task := range s.pending {
+		if !found || task.ts.Before(earliest) {
+			earliest = task.ts
+			found = true
+		}
+	}
+
+	return earliest, found
+}
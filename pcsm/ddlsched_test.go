@@ -0,0 +1,142 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestDDLScheduler(t *testing.T) {
+	t.Parallel()
+
+	nsA := Namespace{"db_1", "a"}
+	nsB := Namespace{"db_1", "b"}
+
+	s := newDDLScheduler(2)
+
+	var (
+		lock  sync.Mutex
+		order []string
+	)
+
+	applied := func(name string) {
+		lock.Lock()
+		order = append(order, name)
+		lock.Unlock()
+	}
+
+	release := make(chan struct{})
+
+	s.schedule(t.Context(), nsA, bson.Timestamp{T: 10}, func(context.Context) error {
+		<-release // a long collMod on A
+		applied("collMod A")
+
+		return nil
+	})
+	s.schedule(t.Context(), nsA, bson.Timestamp{T: 11}, func(context.Context) error {
+		applied("createIndexes A")
+
+		return nil
+	})
+
+	// the CRUD changes of B are not held by the collMod on A
+	waitC := make(chan struct{})
+	go func() {
+		s.wait(nsB)
+		close(waitC)
+	}()
+
+	select {
+	case <-waitC:
+	case <-time.After(time.Second):
+		t.Fatal("B waits for the collMod on A")
+	}
+
+	if ts, ok := s.earliestPending(); !ok || ts != (bson.Timestamp{T: 10}) {
+		t.Errorf("earliest pending: got = %v, %v, want 10", ts, ok)
+	}
+
+	// the CRUD changes of A wait for its DDL changes
+	waitC = make(chan struct{})
+	go func() {
+		s.wait(nsA)
+		close(waitC)
+	}()
+
+	select {
+	case <-waitC:
+		t.Fatal("A does not wait for its collMod")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-waitC:
+	case <-time.After(time.Second):
+		t.Fatal("A is not released after its DDL changes")
+	}
+
+	s.waitAll()
+
+	if len(order) != 2 || order[0] != "collMod A" || order[1] != "createIndexes A" {
+		t.Errorf("got = %v, want the DDL changes of A in order", order)
+	}
+
+	if _, ok := s.earliestPending(); ok {
+		t.Error("earliest pending: got a pending change after waitAll")
+	}
+
+	if err := s.failed(); err != nil {
+		t.Errorf("got = %v, want no error", err)
+	}
+}
+
+func TestDDLScheduler_Failed(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "a"}
+	s := newDDLScheduler(1)
+
+	s.schedule(t.Context(), ns, bson.Timestamp{T: 1}, func(context.Context) error {
+		return errors.New("collMod failed")
+	})
+
+	second := false
+	s.schedule(t.Context(), ns, bson.Timestamp{T: 2}, func(context.Context) error {
+		second = true
+
+		return nil
+	})
+
+	s.waitAll()
+
+	if err := s.failed(); err == nil {
+		t.Error("got = nil, want error")
+	}
+
+	if second {
+		t.Error("the change after the failed change is applied")
+	}
+}
+
+func TestIsConcurrentDDL(t *testing.T) {
+	t.Parallel()
+
+	for _, op := range []OperationType{CreateIndexes, DropIndexes, Modify} {
+		if !isConcurrentDDL(op) {
+			t.Errorf("%s: got = false, want true", op)
+		}
+	}
+
+	for _, op := range []OperationType{Create, Drop, DropDatabase, Rename, Insert} {
+		if isConcurrentDDL(op) {
+			t.Errorf("%s: got = true, want false", op)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package pcsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrNoDeadLetterNamespace indicates that the dead-letter namespace is not configured.
+var ErrNoDeadLetterNamespace = errors.New("dead-letter namespace is not set")
+
+// ParseDeadLetterNamespace parses the "db.collection" dead-letter namespace.
+// The empty string returns the zero namespace (disabled).
+func ParseDeadLetterNamespace(s string) (Namespace, error) {
+	if s == "" {
+		return Namespace{}, nil
+	}
+
+	db, coll, _ := strings.Cut(s, ".")
+	if db == "" || coll == "" {
+		return Namespace{}, errors.Errorf("invalid dead-letter namespace %q", s)
+	}
+
+	ns := Namespace{Database: db, Collection: coll}
+
+	err := ns.ValidateLength()
+	if err != nil {
+		return Namespace{}, errors.Wrap(err, "dead-letter namespace")
+	}
+
+	return ns, nil
+}
+
+// deadLetterEntry is a change event that failed to apply to the target.
+// It is stored in the dead-letter collection on the target to be replayed later.
+type deadLetterEntry struct {
+	ID            bson.ObjectID  `bson:"_id,omitempty"`
+	Namespace     Namespace      `bson:"ns"`
+	OperationType OperationType  `bson:"operationType"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+	Event         bson.Raw       `bson:"event"`
+	Error         string         `bson:"error"`
+	FailedAt      time.Time      `bson:"failedAt"`
+	Attempts      int            `bson:"attempts"`
+}
+
+func newDeadLetterEntry(ns Namespace, change *ChangeEvent, cause error, attempts int) (*deadLetterEntry, error) {
+	event, err := bson.Marshal(change.Event)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal event")
+	}
+
+	entry := &deadLetterEntry{
+		Namespace:     ns,
+		OperationType: change.OperationType,
+		ClusterTime:   change.ClusterTime,
+		Event:         event,
+		Error:         cause.Error(),
+		FailedAt:      time.Now(),
+		Attempts:      attempts,
+	}
+
+	return entry, nil
+}
+
+// decodeEvent decodes the original change event data.
+func (e *deadLetterEntry) decodeEvent() (any, error) {
+	var event any
+
+	switch e.OperationType { //nolint:exhaustive
+	case Insert:
+		event = &InsertEvent{}
+	case Update:
+		event = &UpdateEvent{}
+	case Replace:
+		event = &ReplaceEvent{}
+	case Delete:
+		event = &DeleteEvent{}
+	default:
+		return nil, errors.Errorf("unsupported operation type %q", e.OperationType)
+	}
+
+	err := bson.Unmarshal(e.Event, event)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal event")
+	}
+
+	return event, nil
+}
+
+// addToBulk adds the CRUD change event to the bulk write.
+func addToBulk(bw bulkWrite, ns Namespace, event any) error {
+	switch event := event.(type) {
+	case *InsertEvent:
+		bw.Insert(ns, event)
+	case *UpdateEvent:
+		bw.Update(ns, event)
+	case *ReplaceEvent:
+		bw.Replace(ns, event)
+	case *DeleteEvent:
+		bw.Delete(ns, event)
+	default:
+		return errors.Errorf("unsupported event %T", event)
+	}
+
+	return nil
+}
+
+// applyWithDocRetries calls apply until it succeeds or fails with an error other than a write error.
+// A write error is retried up to maxRetries times, pausing for the interval between the attempts.
+// It returns the number of attempts and the last error.
+func applyWithDocRetries(
+	ctx context.Context,
+	maxRetries int,
+	interval time.Duration,
+	apply func(context.Context) error,
+) (int, error) {
+	attempts := 1
+
+	for {
+		err := apply(ctx)
+		if err == nil || !topo.IsWriteError(err) || attempts > maxRetries {
+			return attempts, err
+		}
+
+		log.Ctx(ctx).Warnf("Apply failed: %v. Retry attempt %d of %d in %s", err, attempts, maxRetries, interval)
+
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		attempts++
+	}
+}
+
+// applyOne applies a single CRUD change event to the target.
+func applyOne(ctx context.Context, m *mongo.Client, ns Namespace, event any, bulkOptions bulkOptions) error {
+	bw := newCollectionBulkWrite(1, bulkOptions)
+
+	err := addToBulk(bw, ns, event)
+	if err != nil {
+		return err
+	}
+
+	_, err = bw.Do(ctx, m)
+
+	return err
+}
+
+// writeDeadLetter inserts the entries into the dead-letter collection.
+func writeDeadLetter(
+	ctx context.Context,
+	m *mongo.Client,
+	dl Namespace,
+	entries []*deadLetterEntry,
+) error {
+	_, err := m.Database(dl.Database).Collection(dl.Collection).InsertMany(ctx, entries)
+
+	return errors.Wrap(err, "insert")
+}
+
+// ReplayResult is the result of a dead-letter replay.
+type ReplayResult struct {
+	// Replayed is the number of the events applied and removed from the dead-letter collection.
+	Replayed int
+	// Failed is the number of the events that failed again. They remain in the collection.
+	Failed int
+}
+
+// replayDeadLetter applies the events of the dead-letter collection in the cluster time order.
+// An applied event is removed from the collection. An event that fails with a write error again
+// remains in the collection with the new error.
+func replayDeadLetter(
+	ctx context.Context,
+	m *mongo.Client,
+	dl Namespace,
+	bulkOptions bulkOptions,
+) (*ReplayResult, error) {
+	mcoll := m.Database(dl.Database).Collection(dl.Collection)
+
+	cur, err := mcoll.Find(ctx, bson.D{},
+		options.Find().SetSort(bson.D{{"clusterTime", 1}, {"_id", 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "find")
+	}
+	defer cur.Close(ctx)
+
+	lg := log.Ctx(ctx)
+	res := &ReplayResult{}
+
+	for cur.Next(ctx) {
+		var entry deadLetterEntry
+
+		err = cur.Decode(&entry)
+		if err != nil {
+			return res, errors.Wrap(err, "decode")
+		}
+
+		event, err := entry.decodeEvent()
+		if err != nil {
+			return res, errors.Wrapf(err, "entry %s", entry.ID.Hex())
+		}
+
+		applyErr := applyOne(ctx, m, entry.Namespace, event, bulkOptions)
+		if applyErr != nil {
+			if !topo.IsWriteError(applyErr) {
+				return res, errors.Wrapf(applyErr, "apply entry %s", entry.ID.Hex())
+			}
+
+			lg.Warnf("Dead-letter entry %s has failed again: %v", entry.ID.Hex(), applyErr)
+
+			_, err = mcoll.UpdateByID(ctx, entry.ID, bson.D{
+				{"$set", bson.D{{"error", applyErr.Error()}, {"failedAt", time.Now()}}},
+				{"$inc", bson.D{{"attempts", 1}}},
+			})
+			if err != nil {
+				return res, errors.Wrapf(err, "update entry %s", entry.ID.Hex())
+			}
+
+			res.Failed++
+
+			continue
+		}
+
+		_, err = mcoll.DeleteOne(ctx, bson.D{{"_id", entry.ID}})
+		if err != nil {
+			return res, errors.Wrapf(err, "delete entry %s", entry.ID.Hex())
+		}
+
+		res.Replayed++
+	}
+
+	return res, errors.Wrap(cur.Err(), "cursor")
+}
+
+// ReplayDeadLetterFile applies the dead-letter entries of the JSON Lines file (e.g. exported
+// with mongoexport from the dead-letter collection) to the target in the cluster time order.
+// An entry that fails with a write error again is counted as failed and logged.
+func ReplayDeadLetterFile(ctx context.Context, m *mongo.Client, r io.Reader) (*ReplayResult, error) {
+	return replayEntries(ctx, r, func(ctx context.Context, ns Namespace, event any) error {
+		return applyOne(ctx, m, ns, event, bulkOptions{})
+	})
+}
+
+// replayEntries reads the dead-letter entries in the Extended JSON from each line of r
+// and applies them in the cluster time order.
+func replayEntries(
+	ctx context.Context,
+	r io.Reader,
+	apply func(ctx context.Context, ns Namespace, event any) error,
+) (*ReplayResult, error) {
+	type fileEntry struct {
+		line  int
+		entry deadLetterEntry
+	}
+
+	var entries []fileEntry
+
+	br := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(err, "read line %d", line)
+		}
+
+		if data = bytes.TrimSpace(data); len(data) != 0 {
+			var entry deadLetterEntry
+
+			uerr := bson.UnmarshalExtJSON(data, false, &entry)
+			if uerr != nil {
+				return nil, errors.Wrapf(uerr, "line %d", line)
+			}
+
+			entries = append(entries, fileEntry{line, entry})
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b fileEntry) int {
+		return a.entry.ClusterTime.Compare(b.entry.ClusterTime)
+	})
+
+	lg := log.Ctx(ctx)
+	res := &ReplayResult{}
+
+	for _, e := range entries {
+		event, err := e.entry.decodeEvent()
+		if err != nil {
+			return res, errors.Wrapf(err, "line %d", e.line)
+		}
+
+		err = apply(ctx, e.entry.Namespace, event)
+		if err != nil {
+			if !topo.IsWriteError(err) {
+				return res, errors.Wrapf(err, "apply line %d", e.line)
+			}
+
+			lg.Warnf("Dead-letter entry of line %d has failed again: %v", e.line, err)
+
+			res.Failed++
+
+			continue
+		}
+
+		res.Replayed++
+	}
+
+	return res, nil
+}
@@ -0,0 +1,280 @@
+package pcsm //nolint
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseDeadLetterNamespace(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseDeadLetterNamespace("pcsm_dlq.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Namespace{Database: "pcsm_dlq", Collection: "events"}); got != want {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	got, err = ParseDeadLetterNamespace("")
+	if err != nil || got != (Namespace{}) {
+		t.Errorf("empty: got = %v, %v, want disabled", got, err)
+	}
+
+	for _, s := range []string{"pcsm_dlq", ".events", "pcsm_dlq."} {
+		_, err = ParseDeadLetterNamespace(s)
+		if err == nil {
+			t.Errorf("%q: got = nil, want error", s)
+		}
+	}
+
+	for _, s := range []string{
+		strings.Repeat("d", MaxDatabaseNameLength+1) + ".events",
+		"pcsm_dlq." + strings.Repeat("c", MaxNamespaceLength-len("pcsm_dlq.")+1),
+	} {
+		_, err = ParseDeadLetterNamespace(s)
+		if !errors.Is(err, ErrNamespaceTooLong) {
+			t.Errorf("%d bytes: got = %v, want %v", len(s), err, ErrNamespaceTooLong)
+		}
+	}
+}
+
+func TestNamespace_ValidateLength(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{
+		Database:   strings.Repeat("d", MaxDatabaseNameLength),
+		Collection: strings.Repeat("c", MaxNamespaceLength-MaxDatabaseNameLength-1),
+	}
+
+	err := ns.ValidateLength()
+	if err != nil {
+		t.Errorf("at the limit: got = %v, want nil", err)
+	}
+
+	ns.Collection += "c"
+
+	err = ns.ValidateLength()
+	if !errors.Is(err, ErrNamespaceTooLong) || !strings.Contains(err.Error(), ns.String()) {
+		t.Errorf("over the limit: got = %v, want %v with the namespace", err, ErrNamespaceTooLong)
+	}
+}
+
+func TestDeadLetterEntry_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{Database: "db1", Collection: "coll1"}
+	cause := errors.New("E11000 duplicate key error")
+
+	changes := []*ChangeEvent{
+		{
+			EventHeader: EventHeader{OperationType: Insert, ClusterTime: bson.Timestamp{T: 100, I: 1}},
+			Event: InsertEvent{
+				DocumentKey:  bson.D{{"_id", 1}},
+				FullDocument: mustMarshal(t, bson.D{{"_id", 1}, {"a", 1}}),
+			},
+		},
+		{
+			EventHeader: EventHeader{OperationType: Update, ClusterTime: bson.Timestamp{T: 100, I: 2}},
+			Event: UpdateEvent{
+				DocumentKey:       bson.D{{"_id", 1}},
+				UpdateDescription: UpdateDescription{UpdatedFields: bson.D{{"a", 2}}},
+			},
+		},
+		{
+			EventHeader: EventHeader{OperationType: Delete, ClusterTime: bson.Timestamp{T: 100, I: 3}},
+			Event:       DeleteEvent{DocumentKey: bson.D{{"_id", 1}}},
+		},
+	}
+
+	bw := newCollectionBulkWrite(len(changes), bulkOptions{})
+
+	for _, change := range changes {
+		entry, err := newDeadLetterEntry(ns, change, cause, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stored deadLetterEntry
+
+		err = bson.Unmarshal(mustMarshal(t, entry), &stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.Namespace != ns || stored.OperationType != change.OperationType ||
+			stored.ClusterTime != change.ClusterTime || stored.Error != cause.Error() || stored.Attempts != 3 {
+			t.Errorf("%s: got = %+v", change.OperationType, stored)
+		}
+
+		event, err := stored.decodeEvent()
+		if err != nil {
+			t.Fatalf("%s: %v", change.OperationType, err)
+		}
+
+		err = addToBulk(bw, stored.Namespace, event)
+		if err != nil {
+			t.Errorf("%s: %v", change.OperationType, err)
+		}
+	}
+
+	if !bw.Full() {
+		t.Errorf("got not full bulk write, want %d operations", len(changes))
+	}
+
+	_, err := (&deadLetterEntry{OperationType: Create}).decodeEvent()
+	if err == nil {
+		t.Error("create: got = nil, want error")
+	}
+}
+
+func TestApplyWithDocRetries(t *testing.T) {
+	t.Parallel()
+
+	writeErr := mongo.WriteException{
+		WriteErrors: []mongo.WriteError{{Code: 121, Message: "Document failed validation"}},
+	}
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"no retries", 0, 1, writeErr, 1, true},
+		{"resolved on retry", 3, 2, writeErr, 3, false},
+		{"dead-lettered after retries", 3, 10, writeErr, 4, true},
+		{"not a write error", 3, 10, errors.New("other"), 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			apply := func(context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+
+				return nil
+			}
+
+			attempts, err := applyWithDocRetries(t.Context(), tt.maxRetries, time.Millisecond, apply)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got err = %v, want err %v", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts || calls != tt.wantAttempts {
+				t.Errorf("got %d attempts (%d calls), want %d", attempts, calls, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestReplayEntries(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{Database: "db1", Collection: "coll1"}
+	doc := mustMarshal(t, bson.D{{"_id", 1}, {"a", 1}})
+
+	changes := []*ChangeEvent{
+		{
+			EventHeader: EventHeader{OperationType: Delete, ClusterTime: bson.Timestamp{T: 100, I: 2}},
+			Event:       DeleteEvent{DocumentKey: bson.D{{"_id", 2}}},
+		},
+		{
+			EventHeader: EventHeader{OperationType: Insert, ClusterTime: bson.Timestamp{T: 100, I: 1}},
+			Event:       InsertEvent{DocumentKey: bson.D{{"_id", 1}}, FullDocument: doc},
+		},
+	}
+
+	// the relaxed Extended JSON lines of mongoexport
+	var file strings.Builder
+
+	for _, change := range changes {
+		entry, err := newDeadLetterEntry(ns, change, errors.New("E11000 duplicate key error"), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := bson.MarshalExtJSON(entry, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file.Write(data)
+		file.WriteString("\n\n")
+	}
+
+	writeErr := mongo.WriteException{
+		WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}},
+	}
+
+	bw := newCollectionBulkWrite(len(changes), bulkOptions{})
+
+	var applied []any
+
+	res, err := replayEntries(t.Context(), strings.NewReader(file.String()),
+		func(_ context.Context, gotNS Namespace, event any) error {
+			if gotNS != ns {
+				t.Errorf("namespace: got = %v, want %v", gotNS, ns)
+			}
+
+			applied = append(applied, event)
+
+			if _, ok := event.(*DeleteEvent); ok {
+				return writeErr
+			}
+
+			return addToBulk(bw, gotNS, event)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Replayed != 1 || res.Failed != 1 {
+		t.Errorf("got = %+v, want 1 replayed and 1 failed", res)
+	}
+
+	// applied in the cluster time order
+	if len(applied) != 2 {
+		t.Fatalf("got %d applied events, want 2", len(applied))
+	}
+
+	insert, ok := applied[0].(*InsertEvent)
+	if !ok || !bytes.Equal(insert.FullDocument, doc) {
+		t.Errorf("first: got = %+v, want the insert of %v", applied[0], doc)
+	}
+
+	if bw.Empty() {
+		t.Error("got empty bulk write, want the insert")
+	}
+
+	// not a write error stops the replay
+	errApply := errors.New("connection refused")
+
+	_, err = replayEntries(t.Context(), strings.NewReader(file.String()),
+		func(context.Context, Namespace, any) error { return errApply })
+	if !errors.Is(err, errApply) {
+		t.Errorf("got = %v, want %v", err, errApply)
+	}
+
+	_, err = replayEntries(t.Context(), strings.NewReader("{not json}\n"),
+		func(context.Context, Namespace, any) error { return nil })
+	if err == nil {
+		t.Error("invalid line: got = nil, want error")
+	}
+}
@@ -0,0 +1,101 @@
+package pcsm
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// dedupKey identifies an applied CRUD change event by the namespace, the document key,
+// and the cluster time. The events of a transaction share the cluster time; for them,
+// the key also includes the resume token, so two changes of the same document in one
+// transaction are not duplicates.
+type dedupKey struct {
+	ns          Namespace
+	documentKey string
+	clusterTime bson.Timestamp
+	txnToken    string
+}
+
+// dedupWindow remembers the keys of the most recent applied CRUD change events
+// to skip the events read again after a reconnect of the change stream.
+type dedupWindow struct {
+	keys  []dedupKey // ring buffer of the keys in the apply order
+	next  int        // position of the next key in the ring buffer
+	index map[dedupKey]struct{}
+}
+
+// isDuplicate returns true if the CRUD change event is in the window of the applied events.
+// The event is added to the window otherwise. A nil window has no duplicates.
+func (w *dedupWindow) isDuplicate(change *ChangeEvent) bool {
+	if w == nil {
+		return false
+	}
+
+	key, ok := makeDedupKey(change)
+
+	return ok && w.seen(key)
+}
+
+// newDedupWindow returns a window of the size most recent events or nil for a non-positive size.
+func newDedupWindow(size int) *dedupWindow {
+	if size <= 0 {
+		return nil
+	}
+
+	return &dedupWindow{
+		keys:  make([]dedupKey, 0, size),
+		index: make(map[dedupKey]struct{}, size),
+	}
+}
+
+// seen returns true if the key is in the window. Otherwise, it adds the key and evicts
+// the oldest key of a full window.
+func (w *dedupWindow) seen(key dedupKey) bool {
+	if _, ok := w.index[key]; ok {
+		return true
+	}
+
+	if len(w.keys) < cap(w.keys) {
+		w.keys = append(w.keys, key)
+	} else {
+		delete(w.index, w.keys[w.next])
+		w.keys[w.next] = key
+		w.next = (w.next + 1) % len(w.keys)
+	}
+
+	w.index[key] = struct{}{}
+
+	return false
+}
+
+// makeDedupKey returns the key of the CRUD change event.
+// It returns false for other events and for the events without a document key.
+func makeDedupKey(change *ChangeEvent) (dedupKey, bool) {
+	var documentKey []byte
+
+	switch event := change.Event.(type) {
+	case InsertEvent:
+		documentKey, _ = bson.Marshal(event.DocumentKey)
+	case UpdateEvent:
+		documentKey, _ = bson.Marshal(event.DocumentKey)
+	case DeleteEvent:
+		documentKey, _ = bson.Marshal(event.DocumentKey)
+	case ReplaceEvent:
+		documentKey = event.DocumentKey
+	}
+
+	if len(documentKey) == 0 {
+		return dedupKey{}, false
+	}
+
+	key := dedupKey{
+		ns:          change.Namespace,
+		documentKey: string(documentKey),
+		clusterTime: change.ClusterTime,
+	}
+
+	if change.TxnNumber != nil {
+		key.txnToken = string(change.ID)
+	}
+
+	return key, true
+}
@@ -0,0 +1,126 @@
+package pcsm //nolint
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDedupWindow_ReplayedEvents(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	insert := func(id, ts uint32) *ChangeEvent {
+		return &ChangeEvent{
+			EventHeader: EventHeader{OperationType: Insert, Namespace: ns, ClusterTime: bson.Timestamp{T: ts}},
+			Event: InsertEvent{
+				DocumentKey:  bson.D{{"_id", id}},
+				FullDocument: mustMarshal(t, bson.D{{"_id", id}}),
+			},
+		}
+	}
+	update := func(id, ts uint32) *ChangeEvent {
+		return &ChangeEvent{
+			EventHeader: EventHeader{OperationType: Update, Namespace: ns, ClusterTime: bson.Timestamp{T: ts}},
+			Event:       UpdateEvent{DocumentKey: bson.D{{"_id", id}}},
+		}
+	}
+
+	// the events after the reconnect at ts 2 are read again
+	changes := []*ChangeEvent{
+		insert(1, 1), insert(2, 2), update(1, 3),
+		insert(2, 2), update(1, 3),
+		update(2, 4),
+	}
+
+	w := newDedupWindow(10)
+
+	applied := map[bson.Timestamp]int{}
+	for _, change := range changes {
+		if !w.isDuplicate(change) {
+			applied[change.ClusterTime]++
+		}
+	}
+
+	for ts, n := range applied {
+		if n != 1 {
+			t.Errorf("%v: applied %d times, want once", ts, n)
+		}
+	}
+
+	if len(applied) != 4 {
+		t.Errorf("got %d applied events, want 4", len(applied))
+	}
+
+	// the same document and cluster time in another namespace is not a duplicate
+	other := insert(1, 1)
+	other.Namespace = Namespace{"db_1", "coll_2"}
+
+	if w.isDuplicate(other) {
+		t.Error("other namespace: got duplicate")
+	}
+
+	var disabled *dedupWindow
+	if disabled.isDuplicate(insert(1, 1)) || disabled.isDuplicate(insert(1, 1)) {
+		t.Error("disabled: got duplicate")
+	}
+}
+
+func TestDedupWindow_Eviction(t *testing.T) {
+	t.Parallel()
+
+	w := newDedupWindow(2)
+
+	key := func(ts uint32) dedupKey {
+		return dedupKey{ns: Namespace{"db_1", "coll_1"}, documentKey: "1", clusterTime: bson.Timestamp{T: ts}}
+	}
+
+	for ts := range uint32(3) {
+		if w.seen(key(ts)) {
+			t.Fatalf("%d: got duplicate", ts)
+		}
+	}
+
+	// the oldest key is evicted from the full window
+	if !w.seen(key(2)) || !w.seen(key(1)) {
+		t.Error("got the recent keys evicted")
+	}
+
+	if w.seen(key(0)) {
+		t.Error("got the evicted key as duplicate")
+	}
+
+	if len(w.index) != 2 {
+		t.Errorf("got %d keys in the index, want 2", len(w.index))
+	}
+}
+
+func TestDedupWindow_Transaction(t *testing.T) {
+	t.Parallel()
+
+	txnNumber := int64(1)
+	ns := Namespace{"db_1", "coll_1"}
+	update := func(token string) *ChangeEvent {
+		return &ChangeEvent{
+			EventHeader: EventHeader{
+				OperationType: Update,
+				Namespace:     ns,
+				ClusterTime:   bson.Timestamp{T: 1, I: 1},
+				TxnNumber:     &txnNumber,
+				ID:            mustMarshal(t, bson.D{{"_data", token}}),
+			},
+			Event: UpdateEvent{DocumentKey: bson.D{{"_id", 1}}},
+		}
+	}
+
+	w := newDedupWindow(10)
+
+	// two updates of the same document in a transaction share the cluster time
+	if w.isDuplicate(update("01")) || w.isDuplicate(update("02")) {
+		t.Error("got duplicate of a different transaction change")
+	}
+
+	if !w.isDuplicate(update("02")) {
+		t.Error("got no duplicate of the change read again")
+	}
+}
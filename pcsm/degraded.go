@@ -0,0 +1,137 @@
+package pcsm
+
+import (
+	"context"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// failureGrace tracks the change replication failures within the grace period
+// (--failure-grace-period). A failure starts the period: the failures within it are retried,
+// and a failure after it fails the replication. The period ends when the replication has been
+// healthy for the period since the last failure.
+type failureGrace struct {
+	period time.Duration
+	since  time.Time // first failure of the period. Zero if healthy
+	last   time.Time // last failure of the period
+}
+
+// degrade records the failure at now and reports whether it is within the grace period.
+func (g *failureGrace) degrade(now time.Time) bool {
+	if g.period <= 0 {
+		return false
+	}
+
+	if g.since.IsZero() {
+		g.since = now
+	}
+
+	g.last = now
+
+	return now.Sub(g.since) < g.period
+}
+
+// recovered reports whether the replication has been healthy for the grace period since
+// the last failure, and ends the period then. A later failure starts a new period.
+func (g *failureGrace) recovered(last, now time.Time) bool {
+	if g.since.IsZero() || !g.last.Equal(last) || now.Sub(last) < g.period {
+		return false
+	}
+
+	g.since = time.Time{}
+	g.last = time.Time{}
+
+	return true
+}
+
+// failOrDegrade fails the replication on the change replication error, or marks it degraded
+// and retries when the error is within the failure grace period.
+func (ml *PCSM) failOrDegrade(err error) {
+	ml.lock.Lock()
+	if !ml.failureGrace.degrade(time.Now()) {
+		ml.lock.Unlock()
+		ml.setFailed(err)
+
+		return
+	}
+
+	ml.state = StateDegraded
+	ml.err = err
+	ml.errorCount++
+	deadline := ml.failureGrace.since.Add(ml.failureGrace.period)
+	ml.lock.Unlock()
+
+	log.New("pcsm").Warnf("Cluster Replication degraded: %v. Retrying until %s",
+		err, deadline.Format(time.RFC3339))
+
+	go ml.onStateChanged(StateDegraded)
+
+	time.AfterFunc(config.DegradedRetryInterval, ml.retryDegraded)
+}
+
+// retryDegraded restarts the degraded replication. The state stays degraded until
+// the replication is healthy for the grace period.
+func (ml *PCSM) retryDegraded() {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state != StateDegraded {
+		return
+	}
+
+	replStatus := ml.repl.Status()
+	if !replStatus.IsPaused() {
+		// the failed change replication is still stopping
+		time.AfterFunc(config.DegradedRetryInterval, ml.retryDegraded)
+
+		return
+	}
+
+	log.New("pcsm").Info("Retrying degraded Cluster Replication")
+
+	// the error is reported by the status until the replication recovers
+	ml.clone.resetError()
+	ml.repl.resetError()
+
+	go ml.run()
+}
+
+// watchDegraded returns the degraded replication to running after it has been healthy
+// for the grace period since the last failure. It stops when ctx is done.
+func (ml *PCSM) watchDegraded(ctx context.Context) {
+	ml.lock.Lock()
+	last := ml.failureGrace.last
+	period := ml.failureGrace.period
+	ml.lock.Unlock()
+
+	if last.IsZero() {
+		return
+	}
+
+	t := time.NewTimer(time.Until(last.Add(period)))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-t.C:
+	}
+
+	ml.lock.Lock()
+	if ml.state != StateDegraded || ml.repl.Status().Err != nil ||
+		!ml.failureGrace.recovered(last, time.Now()) {
+		ml.lock.Unlock()
+
+		return
+	}
+
+	ml.state = StateRunning
+	ml.err = nil
+	ml.lock.Unlock()
+
+	log.New("pcsm").Info("Cluster Replication recovered from the degraded state")
+
+	go ml.onStateChanged(StateRunning)
+}
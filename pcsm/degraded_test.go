@@ -0,0 +1,93 @@
+package pcsm //nolint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestFailureGrace(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	g := failureGrace{period: time.Minute}
+
+	// the transient failures within the period are retried
+	for _, d := range []time.Duration{0, 10 * time.Second, 50 * time.Second} {
+		if !g.degrade(start.Add(d)) {
+			t.Errorf("%s: got = failed, want degraded", d)
+		}
+	}
+
+	// healthy for less than the period since the last failure
+	last := start.Add(50 * time.Second)
+	if g.recovered(last, last.Add(30*time.Second)) {
+		t.Error("got = recovered, want degraded")
+	}
+
+	// the failure persists beyond the period
+	if g.degrade(start.Add(70 * time.Second)) {
+		t.Error("persistent: got = degraded, want failed")
+	}
+}
+
+func TestFailureGrace_Recovered(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	g := failureGrace{period: time.Minute}
+
+	g.degrade(start)
+
+	if !g.recovered(start, start.Add(time.Minute)) {
+		t.Fatal("got = degraded, want recovered")
+	}
+
+	// a later failure starts a new period
+	if !g.degrade(start.Add(5 * time.Minute)) {
+		t.Error("got = failed, want degraded")
+	}
+}
+
+func TestFailureGrace_Disabled(t *testing.T) {
+	t.Parallel()
+
+	var g failureGrace
+	if g.degrade(time.Now()) {
+		t.Error("got = degraded, want failed")
+	}
+}
+
+func TestPCSM_FailOrDegrade(t *testing.T) {
+	t.Parallel()
+
+	client := unreachableClient(t)
+	ml := New(client, client, Options{})
+	ml.catalog = NewCatalog(client, CatalogOptions{})
+	ml.clone = NewClone(client, client, ml.catalog, makeNSFilter(nil, nil), CloneOptions{})
+	ml.repl = NewRepl(client, client, ml.catalog, makeNSFilter(nil, nil), ReplOptions{})
+	ml.state = StateRunning
+	ml.failureGrace = failureGrace{period: time.Minute}
+
+	transient := errors.New("transient")
+	ml.failOrDegrade(transient)
+
+	status := ml.Status(t.Context())
+	if status.State != StateDegraded || !errors.Is(status.Error, transient) {
+		t.Fatalf("transient: got = %s (%v), want %s", status.State, status.Error, StateDegraded)
+	}
+
+	// the failures continue after the grace period
+	ml.lock.Lock()
+	ml.failureGrace.since = time.Now().Add(-2 * time.Minute)
+	ml.lock.Unlock()
+
+	persistent := errors.New("persistent")
+	ml.failOrDegrade(persistent)
+
+	status = ml.Status(t.Context())
+	if status.State != StateFailed || !errors.Is(status.Error, persistent) {
+		t.Errorf("persistent: got = %s (%v), want %s", status.State, status.Error, StateFailed)
+	}
+}
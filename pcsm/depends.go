@@ -0,0 +1,224 @@
+package pcsm
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// CloneDependency declares that a namespace is cloned after the namespace it depends on
+// (e.g. the reference data of a $lookup view).
+type CloneDependency struct {
+	Namespace Namespace `bson:"ns"`
+	DependsOn Namespace `bson:"dependsOn"`
+}
+
+// ParseCloneDependencies parses the "<db.coll>:<db.coll>" dependency declarations.
+// The namespace before the colon is cloned after the namespace after it.
+// It returns an error if the dependencies have a cycle.
+func ParseCloneDependencies(specs []string) ([]CloneDependency, error) {
+	deps := make([]CloneDependency, 0, len(specs))
+
+	for _, spec := range specs {
+		ns, dependsOn, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, errors.Errorf("invalid dependency %q: expected <ns>:<dependsOnNs>", spec)
+		}
+
+		dep := CloneDependency{}
+
+		var err error
+
+		dep.Namespace, err = parseDependencyNamespace(ns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid dependency %q", spec)
+		}
+
+		dep.DependsOn, err = parseDependencyNamespace(dependsOn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid dependency %q", spec)
+		}
+
+		deps = append(deps, dep)
+	}
+
+	cycle := dependencyCycle(dependencyMap(deps))
+	if cycle != nil {
+		names := make([]string, len(cycle))
+		for i, ns := range cycle {
+			names[i] = ns.String()
+		}
+
+		return nil, errors.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+
+	return deps, nil
+}
+
+func parseDependencyNamespace(s string) (Namespace, error) {
+	db, coll, _ := strings.Cut(s, ".")
+	if db == "" || coll == "" {
+		return Namespace{}, errors.Errorf("namespace %q: expected db.collection", s)
+	}
+
+	ns := Namespace{Database: db, Collection: coll}
+
+	return ns, ns.ValidateLength() //nolint:wrapcheck
+}
+
+// dependencyMap returns the namespaces that each namespace depends on in the declaration order.
+func dependencyMap(deps []CloneDependency) map[Namespace][]Namespace {
+	m := make(map[Namespace][]Namespace, len(deps))
+	for _, dep := range deps {
+		m[dep.Namespace] = append(m[dep.Namespace], dep.DependsOn)
+	}
+
+	return m
+}
+
+// dependencyCycle returns the namespaces of a dependency cycle, starting and ending with
+// the same namespace, or nil if there is no cycle.
+func dependencyCycle(deps map[Namespace][]Namespace) []Namespace {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[Namespace]int, len(deps))
+	var path []Namespace
+
+	var visit func(ns Namespace) []Namespace
+	visit = func(ns Namespace) []Namespace {
+		switch state[ns] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == ns {
+					return append(append([]Namespace{}, path[i:]...), ns)
+				}
+			}
+		}
+
+		state[ns] = visiting
+		path = append(path, ns)
+
+		for _, dep := range deps[ns] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[ns] = visited
+
+		return nil
+	}
+
+	// visit in the name order for a stable error message
+	keys := make([]Namespace, 0, len(deps))
+	for ns := range deps {
+		keys = append(keys, ns)
+	}
+
+	slices.SortFunc(keys, func(a, b Namespace) int { return strings.Compare(a.String(), b.String()) })
+
+	for _, ns := range keys {
+		if cycle := visit(ns); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+// orderByDependencies returns the namespaces with each namespace after the namespaces
+// it depends on. Otherwise, the order is kept.
+func orderByDependencies(namespaces []namespaceInfo, deps map[Namespace][]Namespace) []namespaceInfo {
+	if len(deps) == 0 {
+		return namespaces
+	}
+
+	index := make(map[Namespace]int, len(namespaces))
+	for i, ns := range namespaces {
+		index[ns.Namespace] = i
+	}
+
+	ordered := make([]namespaceInfo, 0, len(namespaces))
+	added := make(map[Namespace]bool, len(namespaces))
+
+	var add func(ns namespaceInfo)
+	add = func(ns namespaceInfo) {
+		if added[ns.Namespace] {
+			return
+		}
+
+		added[ns.Namespace] = true // the dependencies have no cycle
+
+		for _, dep := range deps[ns.Namespace] {
+			if i, ok := index[dep]; ok {
+				add(namespaces[i])
+			}
+		}
+
+		ordered = append(ordered, ns)
+	}
+
+	for _, ns := range namespaces {
+		add(ns)
+	}
+
+	return ordered
+}
+
+// dependencyWaiter waits for the clone of the namespaces that a namespace depends on.
+type dependencyWaiter struct {
+	deps map[Namespace][]Namespace
+	done map[Namespace]chan struct{} // closed when the namespace clone returns
+}
+
+// newDependencyWaiter returns the waiter of the dependencies of the cloned namespaces.
+// A dependency that is not cloned (e.g. filtered out or copied before) is not waited for.
+func newDependencyWaiter(namespaces []namespaceInfo, deps map[Namespace][]Namespace) *dependencyWaiter {
+	w := &dependencyWaiter{deps: deps, done: make(map[Namespace]chan struct{})}
+
+	for _, ns := range namespaces {
+		w.done[ns.Namespace] = make(chan struct{})
+	}
+
+	return w
+}
+
+// wait waits until the clone of the dependencies of the namespace returns or the context is done.
+func (w *dependencyWaiter) wait(ctx context.Context, ns Namespace) error {
+	for _, dep := range w.deps[ns] {
+		doneC, ok := w.done[dep]
+		if !ok {
+			continue
+		}
+
+		select {
+		case <-doneC:
+		default:
+			log.Ctx(ctx).Infof("Waiting for the clone of %s before %s", dep, ns)
+
+			select {
+			case <-doneC:
+			case <-ctx.Done():
+				return ctx.Err() //nolint:wrapcheck
+			}
+		}
+	}
+
+	return nil
+}
+
+// finish marks the clone of the namespace returned.
+func (w *dependencyWaiter) finish(ns Namespace) {
+	if doneC, ok := w.done[ns]; ok {
+		close(doneC)
+	}
+}
@@ -0,0 +1,130 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCloneDependencies(t *testing.T) {
+	t.Parallel()
+
+	deps, err := ParseCloneDependencies([]string{"db_1.orders:db_1.customers", "db_1.orders:db_2.items"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CloneDependency{
+		{Namespace{"db_1", "orders"}, Namespace{"db_1", "customers"}},
+		{Namespace{"db_1", "orders"}, Namespace{"db_2", "items"}},
+	}
+	if len(deps) != len(want) || deps[0] != want[0] || deps[1] != want[1] {
+		t.Errorf("got = %v, want %v", deps, want)
+	}
+
+	for _, specs := range [][]string{
+		{"db_1.orders"},
+		{"db_1:db_1.customers"},
+		{"db_1.orders:"},
+	} {
+		if _, err := ParseCloneDependencies(specs); err == nil {
+			t.Errorf("%v: got = nil, want error", specs)
+		}
+	}
+
+	tests := []struct {
+		specs []string
+		cycle string
+	}{
+		{[]string{"db_1.a:db_1.a"}, "db_1.a -> db_1.a"},
+		{[]string{"db_1.a:db_1.b", "db_1.b:db_1.c", "db_1.c:db_1.a"}, "db_1.a -> db_1.b -> db_1.c -> db_1.a"},
+		{[]string{"db_1.x:db_1.a", "db_1.a:db_1.b", "db_1.b:db_1.a"}, "db_1.a -> db_1.b -> db_1.a"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseCloneDependencies(test.specs)
+		if err == nil || !strings.Contains(err.Error(), test.cycle) {
+			t.Errorf("%v: got = %v, want the cycle %s", test.specs, err, test.cycle)
+		}
+	}
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	t.Parallel()
+
+	// prioritized by size: the dependents are larger than their dependencies
+	namespaces := []namespaceInfo{
+		{Namespace: Namespace{"db_1", "orders"}},
+		{Namespace: Namespace{"db_1", "logs"}},
+		{Namespace: Namespace{"db_1", "customers"}},
+		{Namespace: Namespace{"db_1", "countries"}},
+	}
+
+	deps := dependencyMap([]CloneDependency{
+		{Namespace{"db_1", "orders"}, Namespace{"db_1", "customers"}},
+		{Namespace{"db_1", "customers"}, Namespace{"db_1", "countries"}},
+		{Namespace{"db_1", "orders"}, Namespace{"db_2", "filtered_out"}},
+	})
+
+	got := orderByDependencies(namespaces, deps)
+	want := []string{"db_1.countries", "db_1.customers", "db_1.orders", "db_1.logs"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("got = %v, want %v", got, want)
+
+			break
+		}
+	}
+}
+
+func TestDependencyWaiter(t *testing.T) {
+	t.Parallel()
+
+	orders := Namespace{"db_1", "orders"}
+	customers := Namespace{"db_1", "customers"}
+	namespaces := []namespaceInfo{{Namespace: customers}, {Namespace: orders}}
+	deps := dependencyMap([]CloneDependency{{orders, customers}, {orders, Namespace{"db_2", "gone"}}})
+
+	w := newDependencyWaiter(namespaces, deps)
+
+	err := w.wait(t.Context(), customers)
+	if err != nil {
+		t.Fatalf("customers: got = %v, want no wait", err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- w.wait(t.Context(), orders) }()
+
+	select {
+	case err := <-waited:
+		t.Fatalf("orders started before customers: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	w.finish(customers)
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("orders is not started after customers")
+	}
+
+	// a failed clone cancels the waiting dependents
+	w = newDependencyWaiter(namespaces, deps)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if err := w.wait(ctx, orders); err == nil {
+		t.Error("canceled: got = nil, want error")
+	}
+}
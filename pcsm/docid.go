@@ -0,0 +1,69 @@
+package pcsm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// deterministicDocumentID returns the _id of the inserted document without an _id. It is derived
+// from the change event, so that a re-apply of the event writes the same document instead of
+// a new one with the _id generated by the target. The first 4 bytes are the seconds of
+// the cluster time, as the timestamp of an ObjectID.
+func deterministicDocumentID(change *ChangeEvent, fullDocument bson.Raw) bson.ObjectID {
+	var buf [16]byte
+
+	h := sha256.New()
+	h.Write([]byte(change.Namespace.String()))
+	binary.BigEndian.PutUint32(buf[0:4], change.ClusterTime.T)
+	binary.BigEndian.PutUint32(buf[4:8], change.ClusterTime.I)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(change.opIndex)) //nolint:gosec
+	h.Write(buf[:])
+	h.Write(change.ID)
+	h.Write(fullDocument)
+
+	var id bson.ObjectID
+
+	binary.BigEndian.PutUint32(id[0:4], change.ClusterTime.T)
+	copy(id[4:], h.Sum(nil))
+
+	return id
+}
+
+// ensureDocumentID sets the deterministic _id on the inserted document without an _id
+// (e.g. written by a driver that does not generate it). The document key of the sharded
+// collections keeps the shard key fields.
+func ensureDocumentID(change *ChangeEvent, event *InsertEvent) error {
+	_, err := event.FullDocument.LookupErr("_id")
+	if err == nil {
+		return nil
+	}
+
+	var doc bson.D
+
+	err = bson.Unmarshal(event.FullDocument, &doc)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal document")
+	}
+
+	id := deterministicDocumentID(change, event.FullDocument)
+
+	event.FullDocument, err = bson.Marshal(append(bson.D{{"_id", id}}, doc...))
+	if err != nil {
+		return errors.Wrap(err, "marshal document")
+	}
+
+	key := bson.D{{"_id", id}}
+	for _, e := range event.DocumentKey {
+		if e.Key != "_id" {
+			key = append(key, e)
+		}
+	}
+
+	event.DocumentKey = key
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package pcsm //nolint
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestEnsureDocumentID_Retry(t *testing.T) {
+	t.Parallel()
+
+	change := &ChangeEvent{
+		EventHeader: EventHeader{
+			OperationType: Insert,
+			Namespace:     Namespace{"db_1", "coll_1"},
+			ID:            mustMarshal(t, bson.D{{"_data", "8266C4A1B2000000012B"}}),
+			ClusterTime:   bson.Timestamp{T: 1724000000, I: 1},
+		},
+	}
+	doc := mustMarshal(t, bson.D{{"a", 1}})
+
+	// the same event applied on each retry
+	apply := func(change *ChangeEvent) *mongo.ReplaceOneModel {
+		t.Helper()
+
+		event := InsertEvent{FullDocument: doc}
+
+		err := ensureDocumentID(change, &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bw := newCollectionBulkWrite(1, bulkOptions{})
+		bw.Insert(change.Namespace, &event)
+
+		return bw.writes[change.Namespace][0].(*mongo.ReplaceOneModel) //nolint:forcetypeassert
+	}
+
+	first := apply(change)
+	retry := apply(change)
+
+	if !reflect.DeepEqual(first.Filter, retry.Filter) ||
+		!bytes.Equal(first.Replacement.(bson.Raw), retry.Replacement.(bson.Raw)) { //nolint:forcetypeassert
+		t.Errorf("retry: got = %v %v, want %v %v", retry.Filter, retry.Replacement, first.Filter, first.Replacement)
+	}
+
+	filter := first.Filter.(bson.D) //nolint:forcetypeassert
+
+	id, ok := filter[0].Value.(bson.ObjectID)
+	if !ok || filter[0].Key != "_id" {
+		t.Fatalf("filter: got = %v, want _id", filter)
+	}
+
+	if got := first.Replacement.(bson.Raw).Lookup("_id").ObjectID(); got != id { //nolint:forcetypeassert
+		t.Errorf("document _id: got = %v, want %v", got, id)
+	}
+
+	if got := id.Timestamp().Unix(); got != int64(change.ClusterTime.T) {
+		t.Errorf("timestamp: got = %d, want %d", got, change.ClusterTime.T)
+	}
+
+	// another event of the same document
+	other := *change
+	other.ClusterTime.I = 2
+
+	if got := apply(&other).Filter; reflect.DeepEqual(got, first.Filter) {
+		t.Errorf("other event: got = %v, want another _id", got)
+	}
+}
+
+func TestEnsureDocumentID(t *testing.T) {
+	t.Parallel()
+
+	change := &ChangeEvent{
+		EventHeader: EventHeader{ClusterTime: bson.Timestamp{T: 100, I: 1}},
+	}
+
+	// the document with _id is not changed
+	event := InsertEvent{
+		DocumentKey:  bson.D{{"_id", 1}},
+		FullDocument: mustMarshal(t, bson.D{{"_id", 1}, {"a", 1}}),
+	}
+	want := event
+
+	err := ensureDocumentID(change, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(event, want) {
+		t.Errorf("got = %v, want %v", event, want)
+	}
+
+	// the shard key fields are kept
+	event = InsertEvent{
+		DocumentKey:  bson.D{{"region", "eu"}},
+		FullDocument: mustMarshal(t, bson.D{{"region", "eu"}}),
+	}
+
+	err = ensureDocumentID(change, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(event.DocumentKey) != 2 || event.DocumentKey[0].Key != "_id" || event.DocumentKey[1].Key != "region" {
+		t.Errorf("document key: got = %v, want _id and region", event.DocumentKey)
+	}
+}
+
+func TestOplogParser_InsertWithoutID(t *testing.T) {
+	t.Parallel()
+
+	p := newOplogParser()
+	ts := bson.Timestamp{T: 100, I: 1}
+	insert := bson.D{{"op", "i"}, {"ns", "db1.coll1"}, {"o", bson.D{{"a", 1}}}}
+
+	changes := parseOplogEntry(t, p, bson.D{
+		{"ts", ts},
+		{"op", "c"},
+		{"ns", "admin.$cmd"},
+		{"o", bson.D{{"applyOps", bson.A{insert, insert}}}},
+	})
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+
+	var ids []any
+
+	for _, change := range changes {
+		event := change.Event.(InsertEvent) //nolint:forcetypeassert
+		if len(event.DocumentKey) != 0 {
+			t.Errorf("document key: got = %v, want none", event.DocumentKey)
+		}
+
+		err := ensureDocumentID(change, &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, event.DocumentKey[0].Value)
+	}
+
+	// the identical documents of a transaction are different documents
+	if ids[0] == ids[1] {
+		t.Errorf("got the same _id %v, want different", ids[0])
+	}
+}
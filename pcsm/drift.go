@@ -0,0 +1,345 @@
+package pcsm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrSchemaDrift indicates that the existing target collection has the options
+// that differ from the source collection.
+var ErrSchemaDrift = errors.New("target collection options differ from the source")
+
+// SchemaDriftAction is the handling of the source collection that already exists on the target.
+type SchemaDriftAction string
+
+const (
+	// SchemaDriftAdopt keeps the existing target collection as is. The documents are
+	// copied into it.
+	SchemaDriftAdopt SchemaDriftAction = "adopt"
+	// SchemaDriftError fails the start (or the clone of a collection created later) if
+	// the options of the existing target collection differ from the source.
+	SchemaDriftError SchemaDriftAction = "error"
+	// SchemaDriftCollMod modifies the options of the existing target collection to the source
+	// options with collMod. The options that collMod cannot change (capped, collation,
+	// clusteredIndex) fail as [SchemaDriftError].
+	SchemaDriftCollMod SchemaDriftAction = "collmod"
+)
+
+// ParseSchemaDriftAction parses the schema drift action. The empty string drops and recreates
+// the existing target collection.
+func ParseSchemaDriftAction(s string) (SchemaDriftAction, error) {
+	switch a := SchemaDriftAction(s); a {
+	case "", SchemaDriftAdopt, SchemaDriftError, SchemaDriftCollMod:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid schema drift action %q", s)
+}
+
+// the collection options changed by collMod.
+const (
+	driftValidator        = "validator"
+	driftValidationLevel  = "validationLevel"
+	driftValidationAction = "validationAction"
+	driftCappedSize       = "size"
+	driftCappedMax        = "max"
+	driftPrePostImages    = "changeStreamPreAndPostImages"
+)
+
+// schemaDrift returns the names of the options of the target collection that differ from
+// the source. The expireAfterSeconds of a clustered collection is not compared: it is not
+// applied on the target.
+func schemaDrift(source, target *CreateCollectionOptions) []string {
+	var drift []string
+
+	if valueOr(source.Capped, false) != valueOr(target.Capped, false) {
+		drift = append(drift, "capped")
+	} else if valueOr(source.Capped, false) {
+		if valueOr(source.Size, 0) != valueOr(target.Size, 0) {
+			drift = append(drift, driftCappedSize)
+		}
+
+		if valueOr(source.Max, 0) != valueOr(target.Max, 0) {
+			drift = append(drift, driftCappedMax)
+		}
+	}
+
+	if !bytes.Equal(source.Collation, target.Collation) {
+		drift = append(drift, "collation")
+	}
+
+	if (len(source.ClusteredIndex) == 0) != (len(target.ClusteredIndex) == 0) {
+		drift = append(drift, "clusteredIndex")
+	}
+
+	if !bytes.Equal(validatorOf(source), validatorOf(target)) {
+		drift = append(drift, driftValidator)
+	}
+
+	if valueOr(source.ValidationLevel, "strict") != valueOr(target.ValidationLevel, "strict") {
+		drift = append(drift, driftValidationLevel)
+	}
+
+	if valueOr(source.ValidationAction, "error") != valueOr(target.ValidationAction, "error") {
+		drift = append(drift, driftValidationAction)
+	}
+
+	if prePostImagesEnabled(source) != prePostImagesEnabled(target) {
+		drift = append(drift, driftPrePostImages)
+	}
+
+	return drift
+}
+
+// unreconcilableDrift returns the drifted options that collMod cannot change.
+func unreconcilableDrift(drift []string) []string {
+	var names []string
+
+	for _, name := range drift {
+		switch name {
+		case driftValidator, driftValidationLevel, driftValidationAction,
+			driftCappedSize, driftCappedMax, driftPrePostImages:
+		default:
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// checkSchemaDrift returns [ErrSchemaDrift] for each source collection that exists on the target
+// with the drifted options. With [SchemaDriftCollMod], only the options that collMod cannot change
+// are reported.
+func (ml *PCSM) checkSchemaDrift(
+	ctx context.Context,
+	namespaces []Namespace,
+	action SchemaDriftAction,
+	prefix string,
+) error {
+	if action != SchemaDriftError && action != SchemaDriftCollMod {
+		return nil
+	}
+
+	var errs []error
+
+	for _, ns := range namespaces {
+		drift, err := collectionDrift(ctx, ml.source, ml.target, ns, Namespace{prefix + ns.Database, ns.Collection})
+		if err != nil {
+			return err
+		}
+
+		if action == SchemaDriftCollMod {
+			drift = unreconcilableDrift(drift)
+		}
+
+		if len(drift) != 0 {
+			errs = append(errs, errors.Wrapf(ErrSchemaDrift, "%q: %s", ns, strings.Join(drift, ", ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// collectionDrift returns the drifted options of the existing target collection. It returns nil
+// if the target has no collection of the namespace or the source namespace is a view.
+func collectionDrift(ctx context.Context, source, target *mongo.Client, ns, targetNS Namespace) ([]string, error) {
+	targetSpec, err := topo.GetCollectionSpec(ctx, target, targetNS.Database, targetNS.Collection)
+	if err != nil {
+		if errors.Is(err, topo.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "get target collection spec %q", targetNS)
+	}
+
+	sourceSpec, err := topo.GetCollectionSpec(ctx, source, ns.Database, ns.Collection)
+	if err != nil {
+		if errors.Is(err, topo.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "get source collection spec %q", ns)
+	}
+
+	return specDrift(sourceSpec, targetSpec)
+}
+
+// specDrift returns the drifted options of the target collection spec. A target view or
+// timeseries collection of the source collection drifts by type.
+func specDrift(source, target *topo.CollectionSpecification) ([]string, error) {
+	if source.Type != topo.TypeCollection {
+		return nil, nil
+	}
+
+	if target.Type != topo.TypeCollection {
+		return []string{"type"}, nil
+	}
+
+	var sourceOptions, targetOptions CreateCollectionOptions
+
+	err := bson.Unmarshal(source.Options, &sourceOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal source options")
+	}
+
+	err = bson.Unmarshal(target.Options, &targetOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal target options")
+	}
+
+	return schemaDrift(&sourceOptions, &targetOptions), nil
+}
+
+// keepExistingCollection reports whether the existing target collection is kept for the copy
+// with [CloneOptions.OnSchemaDrift]. The drifted options are handled by the action.
+// The kept collection is added to the catalog.
+func (c *Clone) keepExistingCollection(
+	ctx context.Context,
+	ns Namespace,
+	spec *topo.CollectionSpecification,
+) (bool, error) {
+	if c.options.OnSchemaDrift == "" || spec.Type != topo.TypeCollection {
+		return false, nil
+	}
+
+	targetNS := c.catalog.TargetNamespace(ns)
+
+	targetSpec, err := topo.GetCollectionSpec(ctx, c.target, targetNS.Database, targetNS.Collection)
+	if err != nil {
+		if errors.Is(err, topo.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "get target collection spec")
+	}
+
+	drift, err := specDrift(spec, targetSpec)
+	if err != nil {
+		return false, err
+	}
+
+	lg := log.Ctx(ctx)
+
+	if len(drift) != 0 {
+		switch c.options.OnSchemaDrift {
+		case SchemaDriftError:
+			return false, errors.Wrapf(ErrSchemaDrift, "%q: %s", ns, strings.Join(drift, ", "))
+
+		case SchemaDriftCollMod:
+			err = c.reconcileSchema(ctx, ns, spec, drift)
+			if err != nil {
+				return false, errors.Wrap(err, "reconcile options")
+			}
+
+			lg.Infof("Collection %q exists on the target. Modified options: %s",
+				ns.String(), strings.Join(drift, ", "))
+
+		case SchemaDriftAdopt:
+			lg.Warnf("Collection %q exists on the target with different options: %s. "+
+				"The target options are kept", ns.String(), strings.Join(drift, ", "))
+		}
+	} else {
+		lg.Infof("Collection %q exists on the target. It is kept", ns.String())
+	}
+
+	c.catalog.adoptCollection(ctx, ns.Database, ns.Collection, spec.UUID, nil)
+
+	return true, nil
+}
+
+// reconcileSchema modifies the drifted options of the target collection to the source options.
+func (c *Clone) reconcileSchema(
+	ctx context.Context,
+	ns Namespace,
+	spec *topo.CollectionSpecification,
+	drift []string,
+) error {
+	if names := unreconcilableDrift(drift); len(names) != 0 {
+		return errors.Wrapf(ErrSchemaDrift, "%q: cannot modify %s", ns, strings.Join(names, ", "))
+	}
+
+	var options CreateCollectionOptions
+
+	err := bson.Unmarshal(spec.Options, &options)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal options")
+	}
+
+	modValidation := false
+	modCapped := false
+	modPrePostImages := false
+
+	for _, name := range drift {
+		switch name {
+		case driftValidator, driftValidationLevel, driftValidationAction:
+			modValidation = true
+		case driftCappedSize, driftCappedMax:
+			modCapped = true
+		case driftPrePostImages:
+			modPrePostImages = true
+		}
+	}
+
+	if modValidation {
+		validator := bson.Raw(validatorOf(&options))
+		level := valueOr(options.ValidationLevel, "strict")
+		action := valueOr(options.ValidationAction, "error")
+
+		err = c.catalog.ModifyValidation(ctx, ns.Database, ns.Collection, &validator, &level, &action)
+		if err != nil {
+			return err
+		}
+	}
+
+	if modCapped {
+		size := valueOr(options.Size, 0)
+		maxDocs := int64(valueOr(options.Max, 0))
+
+		err = c.catalog.ModifyCappedCollection(ctx, ns.Database, ns.Collection, &size, &maxDocs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if modPrePostImages {
+		err = c.catalog.ModifyChangeStreamPreAndPostImages(ctx, ns.Database, ns.Collection,
+			prePostImagesEnabled(&options))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatorOf returns the validator of the options. No validator is the empty document.
+func validatorOf(options *CreateCollectionOptions) []byte {
+	if options.Validator == nil || len(*options.Validator) == 0 {
+		empty, _ := bson.Marshal(bson.D{})
+
+		return empty
+	}
+
+	return *options.Validator
+}
+
+func prePostImagesEnabled(options *CreateCollectionOptions) bool {
+	return options.ChangeStreamPreAndPostImages != nil && options.ChangeStreamPreAndPostImages.Enabled
+}
+
+// valueOr returns the value of p or def if p is nil.
+func valueOr[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+
+	return *p
+}
@@ -0,0 +1,85 @@
+package pcsm //nolint
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	t.Parallel()
+
+	validator := bson.Raw(mustMarshal(t, bson.D{{"i", bson.D{{"$type", "int"}}}}))
+	other := bson.Raw(mustMarshal(t, bson.D{{"i", bson.D{{"$type", "string"}}}}))
+	capped := true
+	size := int64(4096)
+	otherSize := int64(8192)
+	moderate := "moderate"
+	strict := "strict"
+	ttl := int64(60)
+
+	for _, tc := range []struct {
+		name           string
+		source, target CreateCollectionOptions
+		want           []string
+	}{
+		{"same", CreateCollectionOptions{Validator: &validator}, CreateCollectionOptions{Validator: &validator}, nil},
+		{
+			"validator",
+			CreateCollectionOptions{Validator: &validator, ValidationLevel: &moderate},
+			CreateCollectionOptions{Validator: &other},
+			[]string{"validator", "validationLevel"},
+		},
+		{"default validation level", CreateCollectionOptions{ValidationLevel: &strict}, CreateCollectionOptions{}, nil},
+		{
+			"capped size",
+			CreateCollectionOptions{Capped: &capped, Size: &size},
+			CreateCollectionOptions{Capped: &capped, Size: &otherSize},
+			[]string{"size"},
+		},
+		{"capped", CreateCollectionOptions{Capped: &capped, Size: &size}, CreateCollectionOptions{}, []string{"capped"}},
+		{
+			"collation",
+			CreateCollectionOptions{Collation: mustMarshal(t, bson.D{{"locale", "fr"}})},
+			CreateCollectionOptions{},
+			[]string{"collation"},
+		},
+		{
+			"clustered ttl",
+			CreateCollectionOptions{ClusteredIndex: bson.D{{"key", bson.D{{"_id", 1}}}}, ExpireAfterSeconds: &ttl},
+			CreateCollectionOptions{ClusteredIndex: bson.D{{"key", bson.D{{"_id", 1}}}}},
+			nil,
+		},
+	} {
+		got := schemaDrift(&tc.source, &tc.target)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestUnreconcilableDrift(t *testing.T) {
+	t.Parallel()
+
+	got := unreconcilableDrift([]string{"validator", "capped", "size", "collation", "changeStreamPreAndPostImages"})
+	if want := []string{"capped", "collation"}; !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func TestParseSchemaDriftAction(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "adopt", "error", "collmod"} {
+		got, err := ParseSchemaDriftAction(s)
+		if err != nil || string(got) != s {
+			t.Errorf("%q: got = %q, %v, want %q", s, got, err, s)
+		}
+	}
+
+	_, err := ParseSchemaDriftAction("drop")
+	if err == nil {
+		t.Error("drop: got = nil, want an error")
+	}
+}
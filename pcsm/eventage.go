@@ -0,0 +1,88 @@
+package pcsm
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// ErrStaleEvents indicates that the change events received after a reconnect are older
+// than [ReplOptions.MaxEventAge].
+var ErrStaleEvents = errors.New("change events are older than the max event age")
+
+// StaleEventsAction is the handling of the change events older than [ReplOptions.MaxEventAge]
+// received after a reconnect.
+type StaleEventsAction string
+
+const (
+	// StaleEventsWarn logs a warning and catches up.
+	StaleEventsWarn StaleEventsAction = "warn"
+	// StaleEventsPause pauses the replication. The changes are applied on resume.
+	StaleEventsPause StaleEventsAction = "pause"
+	// StaleEventsRefuse fails the replication with [ErrStaleEvents].
+	StaleEventsRefuse StaleEventsAction = "refuse"
+)
+
+// ParseStaleEventsAction parses the stale events action. The empty string is [StaleEventsWarn].
+func ParseStaleEventsAction(s string) (StaleEventsAction, error) {
+	switch a := StaleEventsAction(s); a {
+	case "":
+		return StaleEventsWarn, nil
+	case StaleEventsWarn, StaleEventsPause, StaleEventsRefuse:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid stale events action %q", s)
+}
+
+// eventAge returns the age of the change event by its cluster time.
+func eventAge(ts bson.Timestamp, now time.Time) time.Duration {
+	return now.Sub(time.Unix(int64(ts.T), 0))
+}
+
+// checkEventAge handles the first change event received after a reconnect if it is older
+// than [ReplOptions.MaxEventAge]. It returns false if the replication must stop.
+func (r *Repl) checkEventAge(change *ChangeEvent, startAt bson.Timestamp) bool {
+	age := eventAge(change.ClusterTime, time.Now())
+	if age <= r.options.MaxEventAge {
+		return true
+	}
+
+	age = age.Round(time.Second)
+	lg := loggerForEvent(change)
+
+	switch r.options.OnStaleEvents {
+	case StaleEventsPause:
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		// the check is skipped once on resume, so the operator resume catches up
+		r.staleEventsHeld = true
+
+		if r.lastReplicatedOpTime.IsZero() {
+			r.lastReplicatedOpTime = startAt
+		}
+
+		lg.Warnf("Change events are %s old after the reconnect (max event age %s). "+
+			"Pausing Change Replication", age, r.options.MaxEventAge)
+
+		if !r.pausing {
+			r.doPause()
+		}
+
+		return false
+
+	case StaleEventsRefuse:
+		r.setFailed(errors.Wrapf(ErrStaleEvents, "%s old (max event age %s)", age, r.options.MaxEventAge),
+			"Stale change events")
+
+		return false
+	}
+
+	lg.Warnf("Change events are %s old after the reconnect (max event age %s). Catching up",
+		age, r.options.MaxEventAge)
+
+	return true
+}
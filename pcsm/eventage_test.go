@@ -0,0 +1,92 @@
+package pcsm //nolint
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseStaleEventsAction(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]StaleEventsAction{
+		"":       StaleEventsWarn,
+		"warn":   StaleEventsWarn,
+		"pause":  StaleEventsPause,
+		"refuse": StaleEventsRefuse,
+	} {
+		got, err := ParseStaleEventsAction(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q, %v, want %q", s, got, err, want)
+		}
+	}
+
+	_, err := ParseStaleEventsAction("skip")
+	if err == nil {
+		t.Error("skip: got = nil, want error")
+	}
+}
+
+func TestRepl_CheckEventAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	startAt := bson.Timestamp{T: uint32(now.Add(-3 * time.Hour).Unix())}
+	stale := &ChangeEvent{EventHeader: EventHeader{
+		OperationType: Insert,
+		ClusterTime:   bson.Timestamp{T: uint32(now.Add(-2 * time.Hour).Unix()), I: 1},
+	}}
+	fresh := &ChangeEvent{EventHeader: EventHeader{
+		OperationType: Insert,
+		ClusterTime:   bson.Timestamp{T: uint32(now.Unix()), I: 1},
+	}}
+
+	newRepl := func(action StaleEventsAction) *Repl {
+		doneSig := make(chan struct{})
+		close(doneSig)
+
+		return &Repl{
+			options: ReplOptions{MaxEventAge: time.Hour, OnStaleEvents: action},
+			pauseC:  make(chan struct{}, 1),
+			doneSig: doneSig,
+		}
+	}
+
+	for _, action := range []StaleEventsAction{StaleEventsWarn, StaleEventsPause, StaleEventsRefuse} {
+		r := newRepl(action)
+		if !r.checkEventAge(fresh, startAt) || r.pausing || r.err != nil {
+			t.Errorf("%s: fresh event stops the replication", action)
+		}
+	}
+
+	r := newRepl(StaleEventsWarn)
+	if !r.checkEventAge(stale, startAt) || r.pausing || r.err != nil {
+		t.Error("warn: stale event stops the replication")
+	}
+
+	r = newRepl(StaleEventsPause)
+	if r.checkEventAge(stale, startAt) {
+		t.Fatal("pause: stale event is applied")
+	}
+
+	r.lock.Lock()
+	if r.err != nil || !r.staleEventsHeld || r.lastReplicatedOpTime != startAt {
+		t.Errorf("pause: got err = %v, held = %v, optime = %v, want the held resume from %v",
+			r.err, r.staleEventsHeld, r.lastReplicatedOpTime, startAt)
+	}
+	r.lock.Unlock()
+
+	r = newRepl(StaleEventsRefuse)
+	if r.checkEventAge(stale, startAt) {
+		t.Fatal("refuse: stale event is applied")
+	}
+
+	r.lock.Lock()
+	if !errors.Is(r.err, ErrStaleEvents) {
+		t.Errorf("refuse: got err = %v, want %v", r.err, ErrStaleEvents)
+	}
+	r.lock.Unlock()
+}
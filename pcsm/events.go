@@ -109,6 +109,36 @@ func (ns Namespace) String() string {
 	return rv
 }
 
+// Namespace length limits of MongoDB 4.4+ in bytes.
+const (
+	MaxNamespaceLength    = 255
+	MaxDatabaseNameLength = 63
+)
+
+// InvalidDatabaseNameChars are the characters not allowed in a database name.
+const InvalidDatabaseNameChars = "/\\. \"$*<>:|?\x00"
+
+// InvalidCollectionNameChars are the characters not allowed in a collection name.
+const InvalidCollectionNameChars = "$\x00"
+
+// ErrNamespaceTooLong indicates a namespace over the MongoDB namespace length limit.
+var ErrNamespaceTooLong = errors.New("namespace is too long")
+
+// ValidateLength returns [ErrNamespaceTooLong] if the database name or the namespace
+// exceeds the MongoDB length limit.
+func (ns Namespace) ValidateLength() error {
+	if len(ns.Database) > MaxDatabaseNameLength {
+		return errors.Wrapf(ErrNamespaceTooLong, "%q: database name is %d bytes, the limit is %d",
+			ns, len(ns.Database), MaxDatabaseNameLength)
+	}
+
+	if n := len(ns.String()); n > MaxNamespaceLength {
+		return errors.Wrapf(ErrNamespaceTooLong, "%q: %d bytes, the limit is %d", ns, n, MaxNamespaceLength)
+	}
+
+	return nil
+}
+
 // InvalidateEvent occurs when an operation renders the change stream invalid. For example, a change
 // stream opened on a collection that was later dropped or renamed would cause an invalidate event.
 type InvalidateEvent struct {
@@ -540,6 +570,9 @@ type ChangeEvent struct {
 	EventHeader
 
 	Event any
+
+	size    int // bytes of the raw event held in the memory budget while queued
+	opIndex int // index of the operation in the applyOps oplog entry of the oplog replication
 }
 
 func parseChangeEvent(data bson.Raw, change *ChangeEvent) error {
@@ -548,6 +581,8 @@ func parseChangeEvent(data bson.Raw, change *ChangeEvent) error {
 		return ParsingError{cause: err}
 	}
 
+	change.size = len(data)
+
 	switch change.OperationType {
 	case Insert:
 		var e InsertEvent
@@ -0,0 +1,271 @@
+package pcsm
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrInvalidNamespacePattern indicates an include or exclude pattern that matches no namespace
+// as intended (e.g. a database name without a collection).
+var ErrInvalidNamespacePattern = errors.New("invalid namespace pattern")
+
+// ValidateNamespacePatterns returns an error for each invalid include or exclude pattern.
+// A pattern is "db.coll" or "db.*". An included internal database is reported with
+// [ErrInternalNamespace].
+func ValidateNamespacePatterns(include, exclude []string) []error {
+	var errs []error
+
+	validate := func(kind string, patterns []string) {
+		for _, pattern := range patterns {
+			db, coll, found := strings.Cut(pattern, ".")
+
+			switch {
+			case db == "":
+				errs = append(errs, errors.Wrapf(ErrInvalidNamespacePattern,
+					"%s %q: missing database name", kind, pattern))
+			case !found || coll == "":
+				errs = append(errs, errors.Wrapf(ErrInvalidNamespacePattern,
+					"%s %q: missing collection name (use %q for all collections)", kind, pattern, db+".*"))
+			case coll != "*" && strings.Contains(coll, "*"):
+				errs = append(errs, errors.Wrapf(ErrInvalidNamespacePattern,
+					"%s %q: only the entire collection name can be a wildcard", kind, pattern))
+			}
+		}
+	}
+
+	validate("include", include)
+	validate("exclude", exclude)
+
+	err := validateIncludeNamespaces(include)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "include"))
+	}
+
+	return errs
+}
+
+// FilterNamespaces returns the namespaces allowed by the include and exclude patterns
+// as the replication filters them, sorted by name.
+func FilterNamespaces(namespaces []Namespace, include, exclude []string) []Namespace {
+	filter := makeNSFilter(include, exclude)
+
+	allowed := []Namespace{}
+	for _, ns := range namespaces {
+		if filter(ns.Database, ns.Collection) {
+			allowed = append(allowed, ns)
+		}
+	}
+
+	slices.SortFunc(allowed, func(a, b Namespace) int {
+		return cmp.Or(cmp.Compare(a.Database, b.Database), cmp.Compare(a.Collection, b.Collection))
+	})
+
+	return allowed
+}
+
+// replicateFilter narrows the namespace filter of the change replication with the replicate
+// include and exclude patterns. The namespaces of the filter not matched by them are cloned
+// but their changes are not replicated. Without the patterns, the filter is returned as is.
+func replicateFilter(filter sel.NSFilter, include, exclude []string) sel.NSFilter {
+	if len(include) == 0 && len(exclude) == 0 {
+		return filter
+	}
+
+	replicate := sel.MakeFilter(include, exclude)
+
+	return func(db, coll string) bool {
+		return filter(db, coll) && replicate(db, coll)
+	}
+}
+
+// UnmatchedIncludePatterns returns the include patterns that match none of the namespaces
+// (e.g. a typo in the database or collection name), in the order of the patterns.
+func UnmatchedIncludePatterns(namespaces []Namespace, include []string) []string {
+	var unmatched []string
+
+	for _, pattern := range include {
+		db, coll, _ := strings.Cut(pattern, ".")
+
+		matched := slices.ContainsFunc(namespaces, func(ns Namespace) bool {
+			return ns.Database == db && (coll == "*" || ns.Collection == coll)
+		})
+		if !matched {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+
+	return unmatched
+}
+
+// ListSourceNamespaces returns the collections and views of the source. The timeseries
+// collections are not replicated and not listed. The listing is read-only.
+func ListSourceNamespaces(ctx context.Context, source *mongo.Client) ([]Namespace, error) {
+	databases, err := topo.ListDatabaseNames(ctx, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "list database names")
+	}
+
+	var namespaces []Namespace
+
+	for _, db := range databases {
+		specs, err := topo.ListCollectionSpecs(ctx, source, db)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listCollections for %q", db)
+		}
+
+		for _, spec := range specs {
+			if spec.Type == topo.TypeTimeseries {
+				continue
+			}
+
+			namespaces = append(namespaces, Namespace{db, spec.Name})
+		}
+	}
+
+	return namespaces, nil
+}
+
+// NamespacePlan is the clone plan of a source namespace allowed by the filters.
+type NamespacePlan struct {
+	Namespace Namespace
+	// Type is the collection type ("collection" or "view").
+	Type string
+	// Options are the collection options reported by listCollections.
+	Options bson.Raw
+	// Indexes are the index specs of the collection. Views have no indexes.
+	Indexes []*topo.IndexSpecification
+	// Size is the estimated data size of the collection in bytes (storageStats.size,
+	// or the average size of the sampled documents times the count with [EstimateSample]).
+	Size int64
+	// Count is the estimated number of documents of the collection.
+	Count int64
+}
+
+// EstimateMode is the method of the size estimate of the plan.
+type EstimateMode string
+
+const (
+	// EstimateCollStats estimates the size by the collStats storage stats: fast but approximate
+	// (e.g. after many updates or an unclean shutdown).
+	EstimateCollStats EstimateMode = "collstats"
+	// EstimateSample estimates the size by the average BSON size of a random document sample
+	// times the collStats document count.
+	EstimateSample EstimateMode = "sample"
+)
+
+// ParseEstimateMode parses the estimate mode. The empty string is [EstimateCollStats].
+func ParseEstimateMode(s string) (EstimateMode, error) {
+	switch m := EstimateMode(s); m {
+	case "":
+		return EstimateCollStats, nil
+	case EstimateCollStats, EstimateSample:
+		return m, nil
+	}
+
+	return "", errors.Errorf("invalid estimate mode %q", s)
+}
+
+// PlanOptions are the options of the [PlanNamespaces].
+type PlanOptions struct {
+	// Estimate is the method of the size estimate. Empty is [EstimateCollStats].
+	Estimate EstimateMode
+	// SampleSize is the number of the sampled documents per collection with [EstimateSample].
+	// Zero is [config.DefaultEstimateSampleSize].
+	SampleSize int
+}
+
+// sampledSize returns the estimated size of count documents of the average size.
+func sampledSize(count int64, avgSize float64) int64 {
+	return int64(math.Round(float64(count) * avgSize))
+}
+
+// PlanNamespaces returns the plan of the source namespaces allowed by the include and exclude
+// patterns, sorted by name. The timeseries collections are not replicated and not listed.
+// The listing is read-only.
+func PlanNamespaces(
+	ctx context.Context,
+	source *mongo.Client,
+	include, exclude []string,
+	options PlanOptions,
+) ([]NamespacePlan, error) {
+	sampleSize := options.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = config.DefaultEstimateSampleSize
+	}
+
+	filter := makeNSFilter(include, exclude)
+
+	databases, err := topo.ListDatabaseNames(ctx, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "list database names")
+	}
+
+	plan := []NamespacePlan{}
+
+	for _, db := range databases {
+		specs, err := topo.ListCollectionSpecs(ctx, source, db)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listCollections for %q", db)
+		}
+
+		for _, spec := range specs {
+			if spec.Type == topo.TypeTimeseries || !filter(db, spec.Name) {
+				continue
+			}
+
+			nsPlan := NamespacePlan{
+				Namespace: Namespace{db, spec.Name},
+				Type:      spec.Type,
+				Options:   spec.Options,
+			}
+
+			if spec.Type == topo.TypeCollection {
+				nsPlan.Indexes, err = topo.ListIndexes(ctx, source, db, spec.Name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "list indexes for %q", nsPlan.Namespace)
+				}
+
+				stats, err := topo.GetCollStats(ctx, source, db, spec.Name)
+				if err != nil && !errors.Is(err, topo.ErrNotFound) {
+					return nil, errors.Wrapf(err, "collStats for %q", nsPlan.Namespace)
+				}
+
+				if stats != nil {
+					nsPlan.Size = stats.Size
+					nsPlan.Count = stats.Count
+				}
+
+				if options.Estimate == EstimateSample && nsPlan.Count != 0 {
+					sample, err := topo.SampleDocSize(ctx, source, db, spec.Name, sampleSize)
+					if err != nil && !errors.Is(err, topo.ErrNotFound) {
+						return nil, errors.Wrapf(err, "sample %q", nsPlan.Namespace)
+					}
+
+					if sample != nil && sample.Count != 0 {
+						nsPlan.Size = sampledSize(nsPlan.Count, sample.AvgSize)
+					}
+				}
+			}
+
+			plan = append(plan, nsPlan)
+		}
+	}
+
+	slices.SortFunc(plan, func(a, b NamespacePlan) int {
+		return cmp.Or(cmp.Compare(a.Namespace.Database, b.Namespace.Database),
+			cmp.Compare(a.Namespace.Collection, b.Namespace.Collection))
+	})
+
+	return plan, nil
+}
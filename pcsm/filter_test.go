@@ -0,0 +1,154 @@
+package pcsm //nolint
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestValidateNamespacePatterns(t *testing.T) {
+	t.Parallel()
+
+	errs := ValidateNamespacePatterns([]string{"db1.*", "db2.coll1"}, []string{"db1.coll2", "db3.*"})
+	if len(errs) != 0 {
+		t.Errorf("got = %v, want none", errs)
+	}
+
+	for _, tc := range []struct {
+		include, exclude []string
+		want             error
+	}{
+		{[]string{"db1"}, nil, ErrInvalidNamespacePattern},
+		{[]string{"db1."}, nil, ErrInvalidNamespacePattern},
+		{nil, []string{".coll1"}, ErrInvalidNamespacePattern},
+		{nil, []string{"db1.coll*"}, ErrInvalidNamespacePattern},
+		{[]string{"admin.*"}, nil, ErrInternalNamespace},
+	} {
+		errs := ValidateNamespacePatterns(tc.include, tc.exclude)
+		if len(errs) != 1 || !errors.Is(errs[0], tc.want) {
+			t.Errorf("%v %v: got = %v, want %v", tc.include, tc.exclude, errs, tc.want)
+		}
+	}
+
+	errs = ValidateNamespacePatterns([]string{"db1", "local.oplog.rs"}, []string{"db2"})
+	if len(errs) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(errs), errs)
+	}
+}
+
+func TestFilterNamespaces(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []Namespace{
+		{"db2", "coll1"},
+		{"db1", "coll2"},
+		{"db1", "coll1"},
+		{"db3", "coll1"},
+		{"admin", "coll1"},
+		{"percona_clustersync_mongodb", "checkpoints"},
+	}
+
+	// the include patterns restrict only the databases they name
+	for _, tc := range []struct {
+		include, exclude []string
+		want             []Namespace
+	}{
+		{nil, nil, []Namespace{{"db1", "coll1"}, {"db1", "coll2"}, {"db2", "coll1"}, {"db3", "coll1"}}},
+		{[]string{"db1.coll2"}, nil, []Namespace{{"db1", "coll2"}, {"db2", "coll1"}, {"db3", "coll1"}}},
+		{[]string{"db1.*"}, []string{"db1.coll2"}, []Namespace{{"db1", "coll1"}, {"db2", "coll1"}, {"db3", "coll1"}}},
+		{nil, []string{"db1.*", "db3.coll1"}, []Namespace{{"db2", "coll1"}}},
+		{[]string{"admin.*"}, []string{"db1.*", "db2.*", "db3.*"}, []Namespace{}},
+	} {
+		got := FilterNamespaces(namespaces, tc.include, tc.exclude)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%v %v: got = %v, want %v", tc.include, tc.exclude, got, tc.want)
+		}
+	}
+}
+
+func TestReplicateFilter(t *testing.T) {
+	t.Parallel()
+
+	filter := makeNSFilter(nil, []string{"db2.*"})
+
+	for _, tc := range []struct {
+		include, exclude []string
+		want             []Namespace
+	}{
+		{nil, nil, []Namespace{{"db1", "hot"}, {"db1", "cold"}, {"db3", "coll1"}}},
+		{[]string{"db1.hot"}, nil, []Namespace{{"db1", "hot"}, {"db3", "coll1"}}},
+		{nil, []string{"db1.cold", "db3.*"}, []Namespace{{"db1", "hot"}}},
+		// the namespace excluded from the clone is not replicated
+		{[]string{"db2.*"}, nil, []Namespace{{"db1", "hot"}, {"db1", "cold"}, {"db3", "coll1"}}},
+	} {
+		replicate := replicateFilter(filter, tc.include, tc.exclude)
+
+		var got []Namespace
+
+		for _, ns := range []Namespace{{"db1", "hot"}, {"db1", "cold"}, {"db2", "coll1"}, {"db3", "coll1"}} {
+			if replicate(ns.Database, ns.Collection) {
+				got = append(got, ns)
+			}
+		}
+
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%v %v: got = %v, want %v", tc.include, tc.exclude, got, tc.want)
+		}
+	}
+}
+
+func TestParseEstimateMode(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]EstimateMode{
+		"":          EstimateCollStats,
+		"collstats": EstimateCollStats,
+		"sample":    EstimateSample,
+	} {
+		got, err := ParseEstimateMode(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q (%v), want %q", s, got, err, want)
+		}
+	}
+
+	if _, err := ParseEstimateMode("scan"); err == nil {
+		t.Error("scan: got = nil, want error")
+	}
+}
+
+func TestSampledSize(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		count   int64
+		avgSize float64
+		want    int64
+	}{
+		{0, 100, 0},
+		{1000, 1024, 1024000},
+		{3, 33.4, 100},
+	} {
+		if got := sampledSize(tc.count, tc.avgSize); got != tc.want {
+			t.Errorf("%d x %v: got = %d, want %d", tc.count, tc.avgSize, got, tc.want)
+		}
+	}
+}
+
+func TestUnmatchedIncludePatterns(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []Namespace{{"db_1", "coll_1"}, {"db_1", "coll_2"}, {"db_2", "coll_1"}}
+
+	got := UnmatchedIncludePatterns(namespaces,
+		[]string{"db_1.coll_1", "db_1.colll_2", "db_2.*", "db_3.*", "db_2.coll_2"})
+
+	want := []string{"db_1.colll_2", "db_3.*", "db_2.coll_2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	if got := UnmatchedIncludePatterns(namespaces, []string{"db_1.*", "db_2.coll_1"}); len(got) != 0 {
+		t.Errorf("got = %v, want none", got)
+	}
+}
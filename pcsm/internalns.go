@@ -0,0 +1,110 @@
+package pcsm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrInvalidInternalNamespace indicates an internal namespace that cannot be replicated
+// on demand (e.g. a wildcard or a collection of the local database).
+var ErrInvalidInternalNamespace = errors.New("invalid internal namespace")
+
+// ParseInternalNamespaces parses the internal collections replicated on demand
+// (e.g. "config.system.sessions"). A namespace is the exact name of a collection
+// of the admin or config database. Wildcards are not allowed, so the internal collections
+// are never replicated by a broad pattern.
+func ParseInternalNamespaces(names []string) ([]Namespace, error) {
+	namespaces := make([]Namespace, 0, len(names))
+
+	for _, name := range names {
+		db, coll, _ := strings.Cut(name, ".")
+
+		switch {
+		case db == "" || coll == "":
+			return nil, errors.Wrapf(ErrInvalidInternalNamespace, "%q: missing collection name", name)
+		case strings.Contains(name, "*"):
+			return nil, errors.Wrapf(ErrInvalidInternalNamespace, "%q: wildcards are not allowed", name)
+		case db != "admin" && db != "config":
+			// local is never written, and the PCSM database holds the recovery data
+			return nil, errors.Wrapf(ErrInvalidInternalNamespace,
+				"%q: only the admin and config collections", name)
+		}
+
+		namespaces = append(namespaces, Namespace{Database: db, Collection: coll})
+	}
+
+	return namespaces, nil
+}
+
+// allowInternalNamespaces returns the filter that also allows the internal namespaces.
+// The other namespaces are allowed by the filter.
+func allowInternalNamespaces(filter sel.NSFilter, namespaces []Namespace) sel.NSFilter {
+	if len(namespaces) == 0 {
+		return filter
+	}
+
+	allowed := make(map[Namespace]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		allowed[ns] = struct{}{}
+	}
+
+	return func(db, coll string) bool {
+		if _, ok := allowed[Namespace{db, coll}]; ok {
+			return true
+		}
+
+		return filter(db, coll)
+	}
+}
+
+// collectInternalNamespaces adds the internal namespaces of the clone to the size map and
+// returns their size. The database listings do not report them, so they are looked up by name.
+// A namespace missing on the source is skipped.
+func (c *Clone) collectInternalNamespaces(ctx context.Context, sm sizeMap) (uint64, error) {
+	lg := log.Ctx(ctx)
+
+	var total uint64
+
+	for _, ns := range c.options.InternalNamespaces {
+		if !c.nsFilter(ns.Database, ns.Collection) {
+			continue // not cloned again (e.g. a reclone of the lost oplog gap)
+		}
+
+		spec, err := topo.GetCollectionSpec(ctx, c.source, ns.Database, ns.Collection)
+		if err != nil {
+			if errors.Is(err, topo.ErrNotFound) {
+				lg.With(log.NS(ns.Database, ns.Collection)).
+					Warnf("Internal namespace %q not found on the source. skipping", ns.String())
+
+				continue
+			}
+
+			return 0, errors.Wrapf(err, "get collection spec for %q", ns.String())
+		}
+
+		if spec.Type != topo.TypeCollection {
+			continue
+		}
+
+		stats, err := topo.GetCollStats(ctx, c.source, ns.Database, ns.Collection)
+		if err != nil {
+			return 0, errors.Wrapf(err, "get collection stats for %q", ns.String())
+		}
+
+		sm[ns] = sizeMapElem{
+			UUID:  spec.UUID,
+			Size:  uint64(stats.Size), //nolint:gosec
+			Count: stats.Count,
+		}
+		total += uint64(stats.Size) //nolint:gosec
+
+		lg.With(log.NS(ns.Database, ns.Collection)).Infof("Internal namespace %q included", ns.String())
+	}
+
+	return total, nil
+}
@@ -0,0 +1,102 @@
+package pcsm //nolint
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseInternalNamespaces(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseInternalNamespaces([]string{"config.system.sessions", "admin.system.version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Namespace{{"config", "system.sessions"}, {"admin", "system.version"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	for _, name := range []string{
+		"config",
+		"config.*",
+		"config.system.*",
+		"local.oplog.rs",
+		"percona_clustersync_mongodb.checkpoints",
+		"db1.coll1",
+	} {
+		_, err := ParseInternalNamespaces([]string{name})
+		if !errors.Is(err, ErrInvalidInternalNamespace) {
+			t.Errorf("%s: got = %v, want %v", name, err, ErrInvalidInternalNamespace)
+		}
+	}
+}
+
+func TestAllowInternalNamespaces(t *testing.T) {
+	t.Parallel()
+
+	// excluded by default, even if a broad include matches them
+	for _, include := range [][]string{nil, {"config.*"}, {"config.system.sessions"}} {
+		filter := allowInternalNamespaces(makeNSFilter(include, nil), nil)
+		if filter("config", "system.sessions") {
+			t.Errorf("%v: got = allowed, want excluded", include)
+		}
+	}
+
+	filter := allowInternalNamespaces(makeNSFilter([]string{"db1.coll1"}, []string{"db2.*"}),
+		[]Namespace{{"config", "system.sessions"}})
+
+	for _, tc := range []struct {
+		db, coll string
+		want     bool
+	}{
+		{"config", "system.sessions", true},
+		{"config", "transactions", false},
+		{"admin", "system.users", false},
+		{"db1", "coll1", true},
+		{"db1", "coll2", false},
+		{"db2", "coll1", false},
+		{"db3", "coll1", true},
+	} {
+		if got := filter(tc.db, tc.coll); got != tc.want {
+			t.Errorf("%s.%s: got = %v, want %v", tc.db, tc.coll, got, tc.want)
+		}
+	}
+}
+
+func TestOplogParser_InternalNamespaces(t *testing.T) {
+	t.Parallel()
+
+	entry := func(ns string) bson.D {
+		return bson.D{
+			{"ts", bson.Timestamp{T: 100, I: 1}},
+			{"op", "i"},
+			{"ns", ns},
+			{"o", bson.D{{"_id", 1}}},
+		}
+	}
+
+	changes := parseOplogEntry(t, newOplogParser(), entry("config.system.sessions"))
+	if len(changes) != 0 {
+		t.Errorf("default: got = %v, want skipped", changes)
+	}
+
+	p := newOplogParser(Namespace{"config", "system.sessions"})
+
+	changes = parseOplogEntry(t, p, entry("config.system.sessions"))
+	if len(changes) != 1 || changes[0].Namespace != (Namespace{"config", "system.sessions"}) {
+		t.Errorf("included: got = %v, want the insert", changes)
+	}
+
+	for _, ns := range []string{"config.transactions", "admin.system.users", "local.coll1"} {
+		changes = parseOplogEntry(t, p, entry(ns))
+		if len(changes) != 0 {
+			t.Errorf("%s: got = %v, want skipped", ns, changes)
+		}
+	}
+}
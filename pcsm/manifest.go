@@ -0,0 +1,355 @@
+package pcsm
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// CloneManifest is the clone progress of each namespace written to the manifest file
+// (--clone-manifest) during the clone. [PCSM.Resume] with the manifest continues the clone
+// from the remaining namespaces when the recovery data is lost.
+type CloneManifest struct {
+	// StartTS is the source cluster time of the clone start. The change replication starts at it.
+	StartTS bson.Timestamp `json:"startTS"`
+	// UpdatedAt is the time of the manifest update.
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Finished indicates that the clone has completed.
+	Finished bool `json:"finished"`
+
+	// IncludeNamespaces are the included namespaces of the clone.
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	// ExcludeNamespaces are the excluded namespaces of the clone.
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// InternalNamespaces are the internal collections replicated on demand.
+	InternalNamespaces []string `json:"internalNamespaces,omitempty"`
+
+	// Namespaces is the progress of each namespace sorted by name.
+	Namespaces []ManifestNamespace `json:"namespaces"`
+}
+
+// ManifestNamespace is the clone progress of a namespace.
+type ManifestNamespace struct {
+	Namespace string `json:"namespace"`
+
+	TotalCount  int64  `json:"totalCount"`  // estimated documents to be copied
+	TotalSize   uint64 `json:"totalSize"`   // estimated bytes to be copied
+	CopiedCount int64  `json:"copiedCount"` // documents copied so far
+	CopiedSize  uint64 `json:"copiedSize"`  // bytes copied so far
+
+	Completed bool `json:"completed"` // copied entirely. A resume skips it
+}
+
+// nsProgress is the copy progress of a namespace listed by the clone.
+type nsProgress struct {
+	TotalCount  int64
+	TotalSize   uint64
+	CopiedCount int64
+	CopiedSize  uint64
+}
+
+// ReadCloneManifest reads the clone manifest file.
+func ReadCloneManifest(path string) (*CloneManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+
+	var m CloneManifest
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %q", path)
+	}
+
+	return &m, nil
+}
+
+// writeCloneManifest replaces the clone manifest file atomically: a crash during a write keeps
+// the previous manifest.
+func writeCloneManifest(path string, m *CloneManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600) //nolint:mnd
+	if err != nil {
+		return errors.Wrap(err, "write")
+	}
+
+	return errors.Wrap(os.Rename(tmp, path), "rename")
+}
+
+// parseManifestNamespace parses the "db.collection" namespace of the manifest.
+func parseManifestNamespace(s string) (Namespace, error) {
+	db, coll, _ := strings.Cut(s, ".")
+	if db == "" || coll == "" {
+		return Namespace{}, errors.Errorf("invalid namespace %q", s)
+	}
+
+	return Namespace{db, coll}, nil
+}
+
+// trackProgress adds the listed namespaces to the progress of the manifest.
+// The caller must hold the lock.
+func (c *Clone) trackProgress(sm sizeMap) {
+	if c.progress == nil {
+		c.progress = make(map[Namespace]*nsProgress, len(sm))
+	}
+
+	for ns, elem := range sm {
+		if _, ok := c.progress[ns]; !ok {
+			c.progress[ns] = &nsProgress{TotalCount: elem.Count, TotalSize: elem.Size}
+		}
+	}
+}
+
+// Manifest returns the clone progress of each namespace.
+func (c *Clone) Manifest() *CloneManifest {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	m := &CloneManifest{
+		StartTS:    c.startTS,
+		Finished:   !c.finishTime.IsZero() && c.err == nil,
+		Namespaces: make([]ManifestNamespace, 0, len(c.progress)),
+	}
+
+	for ns, p := range c.progress {
+		_, completed := c.completed[ns]
+		m.Namespaces = append(m.Namespaces, ManifestNamespace{
+			Namespace:   ns.String(),
+			TotalCount:  p.TotalCount,
+			TotalSize:   p.TotalSize,
+			CopiedCount: p.CopiedCount,
+			CopiedSize:  p.CopiedSize,
+			Completed:   completed,
+		})
+	}
+
+	for ns := range c.completed {
+		if _, ok := c.progress[ns]; !ok { // completed before a restart
+			m.Namespaces = append(m.Namespaces, ManifestNamespace{Namespace: ns.String(), Completed: true})
+		}
+	}
+
+	slices.SortFunc(m.Namespaces, func(a, b ManifestNamespace) int {
+		return cmp.Compare(a.Namespace, b.Namespace)
+	})
+
+	return m
+}
+
+// recoverManifest restores the clone start time and the completed namespaces of the manifest.
+// The started clone copies the remaining namespaces.
+func (c *Clone) recoverManifest(m *CloneManifest) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.startTS.IsZero() {
+		return errors.New("cannot restore: already used")
+	}
+
+	completed := make(map[Namespace]struct{})
+	progress := make(map[Namespace]*nsProgress)
+
+	for _, entry := range m.Namespaces {
+		if !entry.Completed {
+			continue
+		}
+
+		ns, err := parseManifestNamespace(entry.Namespace)
+		if err != nil {
+			return err
+		}
+
+		completed[ns] = struct{}{}
+		progress[ns] = &nsProgress{
+			TotalCount:  entry.TotalCount,
+			TotalSize:   entry.TotalSize,
+			CopiedCount: entry.CopiedCount,
+			CopiedSize:  entry.CopiedSize,
+		}
+	}
+
+	c.startTS = m.StartTS
+	c.completed = completed
+	c.progress = progress
+
+	return nil
+}
+
+// adoptCompleted adds the completed namespaces to the catalog with the indexes of the source.
+// The collections were copied to the target before the recovery data was lost.
+func (c *Clone) adoptCompleted(ctx context.Context) error {
+	c.lock.Lock()
+	namespaces := make([]Namespace, 0, len(c.completed))
+	for ns := range c.completed {
+		namespaces = append(namespaces, ns)
+	}
+	c.lock.Unlock()
+
+	for _, ns := range namespaces {
+		spec, err := topo.GetCollectionSpec(ctx, c.source, ns.Database, ns.Collection)
+		if err != nil {
+			if errors.Is(err, topo.ErrNotFound) {
+				continue // dropped: the change replication drops it on the target
+			}
+
+			return errors.Wrapf(err, "get collection spec: %s", ns)
+		}
+
+		var indexes []*topo.IndexSpecification
+
+		// the indexes of an internal collection are kept by the target
+		if spec.Type == topo.TypeCollection && !slices.Contains(c.options.InternalNamespaces, ns) {
+			indexes, err = topo.ListIndexes(ctx, c.source, ns.Database, ns.Collection)
+			if err != nil {
+				return errors.Wrapf(err, "list indexes: %s", ns)
+			}
+		}
+
+		c.catalog.adoptCollection(ctx, ns.Database, ns.Collection, spec.UUID, indexes)
+	}
+
+	return nil
+}
+
+// startCloneManifest updates the clone manifest file every [config.CloneManifestInterval].
+// The returned function stops the updates and writes the final manifest.
+func (ml *PCSM) startCloneManifest(path string) func() {
+	if path == "" {
+		return func() {}
+	}
+
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+
+	go func() {
+		defer close(doneC)
+
+		t := time.NewTicker(config.CloneManifestInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-t.C:
+				ml.saveCloneManifest(path)
+			}
+		}
+	}()
+
+	return func() {
+		close(stopC)
+		<-doneC
+		ml.saveCloneManifest(path)
+	}
+}
+
+// saveCloneManifest writes the clone manifest file. A failed write is logged: the clone continues.
+func (ml *PCSM) saveCloneManifest(path string) {
+	ml.lock.Lock()
+	clone := ml.clone
+	include, exclude := ml.nsInclude, ml.nsExclude
+	internal := ml.internalNamespaces
+	ml.lock.Unlock()
+
+	m := clone.Manifest()
+	m.UpdatedAt = time.Now()
+	m.IncludeNamespaces = include
+	m.ExcludeNamespaces = exclude
+
+	for _, ns := range internal {
+		m.InternalNamespaces = append(m.InternalNamespaces, ns.String())
+	}
+
+	err := writeCloneManifest(path, m)
+	if err != nil {
+		log.New("pcsm").Error(err, "Write clone manifest")
+	}
+}
+
+// resumeFromManifest starts the replication of the idle PCSM from the clone manifest file.
+// The clone skips the completed namespaces of the manifest, and the change replication starts
+// at the clone start time of the manifest. The caller must hold the lock.
+func (ml *PCSM) resumeFromManifest(ctx context.Context, path string) error {
+	if ml.state != StateIdle {
+		return errors.New("cannot resume from manifest: not idle")
+	}
+
+	m, err := ReadCloneManifest(path)
+	if err != nil {
+		return errors.Wrap(err, "read clone manifest")
+	}
+
+	if m.StartTS.IsZero() {
+		return errors.New("cannot resume from manifest: the clone is not started")
+	}
+
+	replMethod, err := ml.selectReplicationMethod(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "replication method")
+	}
+
+	internal, err := ParseInternalNamespaces(m.InternalNamespaces)
+	if err != nil {
+		return errors.Wrap(err, "internal namespaces")
+	}
+
+	nsFilter := allowInternalNamespaces(makeNSFilter(m.IncludeNamespaces, m.ExcludeNamespaces), internal)
+	catalog := NewCatalog(ml.target, ml.catalogOptions())
+	clone := NewClone(ml.source, ml.target, catalog, nsFilter, CloneOptions{
+		Memory:             ml.options.Memory,
+		InternalNamespaces: internal,
+	})
+
+	err = clone.recoverManifest(m)
+	if err != nil {
+		return errors.Wrap(err, "recover clone")
+	}
+
+	err = clone.adoptCompleted(ctx)
+	if err != nil {
+		return errors.Wrap(err, "adopt completed collections")
+	}
+
+	ml.nsInclude = m.IncludeNamespaces
+	ml.nsExclude = m.ExcludeNamespaces
+	ml.nsFilter = nsFilter
+	ml.replInclude = nil
+	ml.replExclude = nil
+	ml.internalNamespaces = internal
+	ml.replMethod = replMethod
+	ml.cloneManifest = path
+	ml.catalog = catalog
+	ml.clone = clone
+	ml.repl = NewRepl(ml.source, ml.target, catalog, excludeSkipped(nsFilter, clone), ml.replOptions())
+	ml.state = StateRunning
+	ml.errorCount = 0
+	ml.throughput = throughputMeter{}
+
+	log.New("pcsm").With(log.OpTime(m.StartTS.T, m.StartTS.I)).
+		Infof("Cluster Replication resumed from clone manifest %q: %d collections are completed",
+			path, len(clone.completed))
+
+	go ml.run()
+	go ml.onStateChanged(StateRunning)
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package pcsm //nolint
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+func TestClone_Manifest(t *testing.T) {
+	t.Parallel()
+
+	c := &Clone{startTS: bson.Timestamp{T: 100}, startTime: time.Now()}
+	c.trackProgress(sizeMap{
+		Namespace{"db_0", "coll_0"}: {Size: 100, Count: 10},
+		Namespace{"db_0", "coll_1"}: {Size: 200, Count: 20},
+		Namespace{"db_1", "coll_0"}: {Size: 300, Count: 30},
+	})
+
+	// coll_1 is copied entirely, and db_1.coll_0 is being copied
+	*c.progress[Namespace{"db_0", "coll_1"}] = nsProgress{
+		TotalCount: 20, TotalSize: 200, CopiedCount: 20, CopiedSize: 200,
+	}
+	c.markCompleted(Namespace{"db_0", "coll_1"})
+	c.progress[Namespace{"db_1", "coll_0"}].CopiedCount = 12
+	c.progress[Namespace{"db_1", "coll_0"}].CopiedSize = 120
+
+	m := c.Manifest()
+
+	want := []ManifestNamespace{
+		{Namespace: "db_0.coll_0", TotalCount: 10, TotalSize: 100},
+		{
+			Namespace: "db_0.coll_1", TotalCount: 20, TotalSize: 200,
+			CopiedCount: 20, CopiedSize: 200, Completed: true,
+		},
+		{Namespace: "db_1.coll_0", TotalCount: 30, TotalSize: 300, CopiedCount: 12, CopiedSize: 120},
+	}
+
+	if !slices.Equal(m.Namespaces, want) {
+		t.Errorf("got = %v, want %v", m.Namespaces, want)
+	}
+
+	if m.StartTS != (bson.Timestamp{T: 100}) || m.Finished {
+		t.Errorf("got start %v, finished %v, want 100 and not finished", m.StartTS, m.Finished)
+	}
+
+	c.finishTime = time.Now()
+
+	if !c.Manifest().Finished {
+		t.Error("got not finished, want finished")
+	}
+}
+
+func TestClone_RecoverManifest(t *testing.T) {
+	t.Parallel()
+
+	c := &Clone{startTS: bson.Timestamp{T: 100}, startTime: time.Now()}
+	c.trackProgress(sizeMap{
+		Namespace{"db_0", "coll_0"}: {Size: 100, Count: 10},
+		Namespace{"db_0", "coll_1"}: {Size: 200, Count: 20},
+		Namespace{"db_0", "coll_2"}: {Size: 300, Count: 30},
+	})
+	c.markCompleted(Namespace{"db_0", "coll_2"})
+
+	m := c.Manifest()
+	m.IncludeNamespaces = []string{"db_0.*"}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+
+	err := writeCloneManifest(path, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the recovery data is lost: the clone is resumed from the manifest
+	read, err := ReadCloneManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(read.IncludeNamespaces, m.IncludeNamespaces) {
+		t.Errorf("include: got = %v, want %v", read.IncludeNamespaces, m.IncludeNamespaces)
+	}
+
+	restored := &Clone{}
+
+	err = restored.recoverManifest(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.startTS != (bson.Timestamp{T: 100}) {
+		t.Errorf("start: got = %v, want 100", restored.startTS)
+	}
+
+	// the size map listed by the resumed clone
+	restored.sizeMap = sizeMap{
+		Namespace{"db_0", "coll_0"}: {Size: 100},
+		Namespace{"db_0", "coll_1"}: {Size: 200},
+		Namespace{"db_0", "coll_2"}: {Size: 300},
+	}
+
+	var got []Namespace
+	for _, ns := range restored.listPrioritizedNamespaces() {
+		got = append(got, ns.Namespace)
+	}
+
+	want := []Namespace{{"db_0", "coll_1"}, {"db_0", "coll_0"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	// the resumed clone reports the completed collection of the manifest
+	entries := restored.Manifest().Namespaces
+	if len(entries) != 1 || entries[0].Namespace != "db_0.coll_2" || !entries[0].Completed {
+		t.Errorf("got = %v, want the completed db_0.coll_2", entries)
+	}
+
+	if err := restored.recoverManifest(read); err == nil {
+		t.Error("recover again: got = nil, want error")
+	}
+}
+
+func TestCatalog_AdoptCollection(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalog(nil, CatalogOptions{})
+	uuid := &bson.Binary{Subtype: bson.TypeBinaryUUID, Data: []byte("0123456789abcdef")}
+	unique := true
+
+	c.adoptCollection(t.Context(), "db_0", "coll_0", uuid, []*topo.IndexSpecification{
+		{Name: IDIndex},
+		{Name: "a_1", Unique: &unique},
+	})
+
+	coll := c.Databases["db_0"].Collections["coll_0"]
+	if coll.UUID != uuid {
+		t.Errorf("uuid: got = %v, want %v", coll.UUID, uuid)
+	}
+
+	if len(coll.Indexes) != 2 || coll.Indexes[1].Name != "a_1" || coll.Indexes[1].Unsuccessful() {
+		t.Errorf("got = %v, want the indexes of the source", coll.Indexes)
+	}
+}
+
+func TestPCSM_ResumeFromManifest(t *testing.T) {
+	t.Parallel()
+
+	ml := New(nil, nil, Options{})
+	ml.state = StateRunning
+
+	err := ml.Resume(t.Context(), ResumeOptions{Manifest: "manifest.json"})
+	if err == nil {
+		t.Error("running: got = nil, want error")
+	}
+
+	ml.state = StateIdle
+
+	err = ml.Resume(t.Context(), ResumeOptions{Manifest: filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil {
+		t.Error("missing manifest: got = nil, want error")
+	}
+}
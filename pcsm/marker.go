@@ -0,0 +1,161 @@
+package pcsm
+
+import (
+	"context"
+	"encoding/binary"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// ValidateMarkerField validates the name of the copy marker field. It is a top-level field
+// other than _id.
+func ValidateMarkerField(field string) error {
+	switch {
+	case field == "":
+		return errors.New("empty field name")
+	case field == "_id":
+		return errors.New("_id cannot be the marker field")
+	case strings.HasPrefix(field, "$"):
+		return errors.New("field name starts with $")
+	case strings.ContainsAny(field, ".\x00"):
+		return errors.New("field name contains a dot or a null character")
+	}
+
+	return nil
+}
+
+// stampMarker returns the document with the marker field set to the value. The existing
+// field of the document is replaced.
+func stampMarker(doc bson.Raw, field string, value bson.Timestamp) (bson.Raw, error) {
+	_, err := doc.LookupErr(field)
+	if err == nil {
+		var d bson.D
+
+		err = bson.Unmarshal(doc, &d)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal document")
+		}
+
+		for i := range d {
+			if d[i].Key == field {
+				d[i].Value = value
+			}
+		}
+
+		raw, err := bson.Marshal(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal document")
+		}
+
+		return raw, nil
+	}
+
+	stamped := make([]byte, 0, len(doc)+len(field)+10) //nolint:mnd
+	stamped = append(stamped, doc[:len(doc)-1]...)
+	stamped = bsoncore.AppendTimestampElement(stamped, field, value.T, value.I)
+	stamped = append(stamped, 0)
+	binary.LittleEndian.PutUint32(stamped, uint32(len(stamped))) //nolint:gosec
+
+	return stamped, nil
+}
+
+// markerIDKey returns the map key of the _id value.
+func markerIDKey(id bson.RawValue) string {
+	return string(byte(id.Type)) + string(id.Value)
+}
+
+// filterMarked returns the documents whose _id is not in the marked set and their size.
+func filterMarked(documents []any, marked map[string]struct{}) ([]any, int) {
+	unmarked := make([]any, 0, len(documents))
+	sizeBytes := 0
+
+	for _, doc := range documents {
+		raw := doc.(bson.Raw) //nolint:forcetypeassert
+		if _, ok := marked[markerIDKey(raw.Lookup("_id"))]; ok {
+			continue
+		}
+
+		unmarked = append(unmarked, doc)
+		sizeBytes += len(raw)
+	}
+
+	return unmarked, sizeBytes
+}
+
+// findMarked returns the _id of the documents of the batch that are stamped with
+// the marker on the target, i.e. copied by the previous run of the clone.
+func (cm *CopyManager) findMarked(
+	ctx context.Context,
+	collection *mongo.Collection,
+	documents []any,
+) (map[string]struct{}, error) {
+	ids := make(bson.A, len(documents))
+	for i, doc := range documents {
+		ids[i] = doc.(bson.Raw).Lookup("_id") //nolint:forcetypeassert
+	}
+
+	cur, err := collection.Find(ctx,
+		bson.D{{"_id", bson.D{{"$in", ids}}}, {cm.options.MarkerField, cm.options.MarkerValue}},
+		options.Find().SetProjection(bson.D{{"_id", 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "find")
+	}
+
+	defer cur.Close(context.Background())
+
+	marked := make(map[string]struct{})
+	for cur.Next(ctx) {
+		marked[markerIDKey(cur.Current.Lookup("_id"))] = struct{}{}
+	}
+
+	return marked, errors.Wrap(cur.Err(), "cursor")
+}
+
+// hasMarkedDocuments reports whether the target collection has a document stamped with
+// the marker of the clone.
+func (c *Clone) hasMarkedDocuments(ctx context.Context, ns Namespace, marker bson.Timestamp) (bool, error) {
+	ns = c.catalog.TargetNamespace(ns)
+
+	err := c.target.Database(ns.Database).Collection(ns.Collection).
+		FindOne(ctx, bson.D{{c.options.MarkerField, marker}},
+			options.FindOne().SetProjection(bson.D{{"_id", 1}})).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "find marked document")
+	}
+
+	return true, nil
+}
+
+// resumeMarkedCollection reports whether the copy of the collection is resumed into the target
+// collection created by the previous run of the clone. The collection is kept if it is in
+// the recovered catalog and has the documents stamped with the marker of the clone.
+func (c *Clone) resumeMarkedCollection(ctx context.Context, ns Namespace) (bool, error) {
+	if c.options.MarkerField == "" || !slices.Contains(c.catalog.collectionNames(ns.Database), ns.Collection) {
+		return false, nil
+	}
+
+	c.lock.Lock()
+	marker := c.startTS
+	c.lock.Unlock()
+
+	ok, err := c.hasMarkedDocuments(ctx, ns, marker)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	log.Ctx(ctx).Infof("Collection %q copy is resumed: the marked documents are skipped", ns.String())
+
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package pcsm //nolint
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestValidateMarkerField(t *testing.T) {
+	t.Parallel()
+
+	for field, valid := range map[string]bool{
+		"_pcsm":  true,
+		"copied": true,
+		"":       false,
+		"_id":    false,
+		"$copy":  false,
+		"a.b":    false,
+	} {
+		err := ValidateMarkerField(field)
+		if (err == nil) != valid {
+			t.Errorf("%q: got = %v, want valid %v", field, err, valid)
+		}
+	}
+}
+
+func TestStampMarker(t *testing.T) {
+	t.Parallel()
+
+	ts := bson.Timestamp{T: 1700000000, I: 2}
+
+	stamped, err := stampMarker(mustMarshal(t, bson.D{{"_id", 1}, {"a", "x"}}), "_m", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got bson.D
+
+	err = bson.Unmarshal(stamped, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := bson.D{{"_id", int32(1)}, {"a", "x"}, {"_m", ts}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	// the field of the document is replaced
+	stamped, err = stampMarker(mustMarshal(t, bson.D{{"_id", 1}, {"_m", "old"}, {"a", "x"}}), "_m", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got = nil
+
+	err = bson.Unmarshal(stamped, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = bson.D{{"_id", int32(1)}, {"_m", ts}, {"a", "x"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("replaced: got = %v, want %v", got, want)
+	}
+}
+
+func TestCopyManager_MarkedDocumentsSkipped(t *testing.T) {
+	t.Parallel()
+
+	var docs []bson.Raw
+	for i := range 5 {
+		docs = append(docs, mustMarshal(t, bson.D{{"_id", i}, {"a", i}}))
+	}
+
+	cm := &CopyManager{options: CopyManagerOptions{
+		MarkerField: "_m",
+		MarkerValue: bson.Timestamp{T: 1700000000, I: 1},
+	}}
+
+	read := func() []any {
+		t.Helper()
+
+		resultC := make(chan readBatchResult, 1)
+
+		err := cm.readSegment(t.Context(), resultC, &fakeCursor{docs: docs}, func() uint32 { return 1 })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return (<-resultC).Documents
+	}
+
+	// the first pass is interrupted after 3 documents are copied
+	marked := map[string]struct{}{}
+
+	for _, doc := range read()[:3] {
+		raw := doc.(bson.Raw) //nolint:forcetypeassert
+		if ts, i, ok := raw.Lookup("_m").TimestampOK(); !ok || ts != 1700000000 || i != 1 {
+			t.Errorf("marker: got = %v, want 1700000000.1", raw.Lookup("_m"))
+		}
+
+		marked[markerIDKey(raw.Lookup("_id"))] = struct{}{}
+	}
+
+	// the second pass inserts only the documents not marked on the target
+	documents := read()
+
+	unmarked, sizeBytes := filterMarked(documents, marked)
+
+	var ids []int32
+	for _, doc := range unmarked {
+		ids = append(ids, doc.(bson.Raw).Lookup("_id").Int32()) //nolint:forcetypeassert
+	}
+
+	if want := []int32{3, 4}; !slices.Equal(ids, want) {
+		t.Errorf("got = %v, want %v", ids, want)
+	}
+
+	if want := len(documents[3].(bson.Raw)) + len(documents[4].(bson.Raw)); sizeBytes != want { //nolint:forcetypeassert
+		t.Errorf("size: got = %d, want %d", sizeBytes, want)
+	}
+}
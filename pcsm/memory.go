@@ -0,0 +1,117 @@
+package pcsm
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBudget bounds the combined size of the documents held by the clone read batches and
+// the queued change events (--max-memory). A read waits while its batch would exceed the limit,
+// so the reads are throttled until the inserted batches and the applied events release memory.
+// The methods of a nil budget do not limit the memory.
+type MemoryBudget struct {
+	limit int64
+
+	lock     sync.Mutex
+	used     int64
+	releaseC chan struct{} // closed and replaced on each release
+}
+
+// NewMemoryBudget returns the budget limited to the bytes. Zero or less returns nil (unlimited).
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &MemoryBudget{limit: limit, releaseC: make(chan struct{})}
+}
+
+// Limit returns the limit in bytes.
+func (b *MemoryBudget) Limit() int64 {
+	if b == nil {
+		return 0
+	}
+
+	return b.limit
+}
+
+// Used returns the bytes held by the buffers and the queues.
+func (b *MemoryBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.used
+}
+
+// acquire waits until the n bytes fit in the limit and holds them. A batch larger than the limit
+// is acquired when nothing else is held, so it does not wait forever.
+func (b *MemoryBudget) acquire(ctx context.Context, n int) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+
+	for {
+		b.lock.Lock()
+		if b.used == 0 || b.used+int64(n) <= b.limit {
+			b.used += int64(n)
+			b.lock.Unlock()
+
+			return nil
+		}
+
+		releaseC := b.releaseC
+		b.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-releaseC:
+		}
+	}
+}
+
+// release returns the n acquired bytes and wakes the waiting reads.
+func (b *MemoryBudget) release(n int) {
+	if b == nil || n <= 0 {
+		return
+	}
+
+	b.lock.Lock()
+	b.used -= int64(n)
+	close(b.releaseC)
+	b.releaseC = make(chan struct{})
+	b.lock.Unlock()
+}
+
+// throttleChanges forwards the change events read from the source to the apply queue. It waits
+// while the queued events exceed the budget: the reads are blocked until the events are taken
+// from the queue. It closes out when in is closed.
+func throttleChanges(
+	ctx context.Context,
+	memory *MemoryBudget,
+	in <-chan *ChangeEvent,
+	out chan<- *ChangeEvent,
+) {
+	defer close(out)
+
+	for change := range in {
+		err := memory.acquire(ctx, change.size)
+		if err != nil {
+			return
+		}
+
+		out <- change
+	}
+}
+
+// releaseQueued releases the memory of the change events left in the apply queue when
+// the replication stops.
+func releaseQueued(memory *MemoryBudget, changeC <-chan *ChangeEvent) {
+	for change := range changeC {
+		memory.release(change.size)
+	}
+}
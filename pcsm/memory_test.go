@@ -0,0 +1,185 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	b := NewMemoryBudget(100)
+
+	for _, n := range []int{60, 30} {
+		if err := b.acquire(t.Context(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the next batch does not fit: the read waits until the inserted batch is released
+	acquired := make(chan struct{})
+	go func() {
+		_ = b.acquire(context.Background(), 20)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if got := b.Used(); got != 90 {
+		t.Errorf("used: got = %d, want 90", got)
+	}
+
+	b.release(60)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("not acquired after the release")
+	}
+
+	if got := b.Used(); got != 50 {
+		t.Errorf("used: got = %d, want 50", got)
+	}
+}
+
+func TestMemoryBudget_Oversize(t *testing.T) {
+	t.Parallel()
+
+	b := NewMemoryBudget(100)
+
+	// a batch larger than the limit is acquired alone, so it does not wait forever
+	if err := b.acquire(t.Context(), 150); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	err := b.acquire(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestMemoryBudget_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	b := NewMemoryBudget(0)
+	if b != nil {
+		t.Fatal("got a budget, want nil (unlimited)")
+	}
+
+	if err := b.acquire(t.Context(), 1<<40); err != nil {
+		t.Fatal(err)
+	}
+
+	b.release(1 << 40)
+
+	if b.Used() != 0 || b.Limit() != 0 {
+		t.Errorf("got used %d, limit %d, want 0", b.Used(), b.Limit())
+	}
+}
+
+func TestThrottleChanges(t *testing.T) {
+	t.Parallel()
+
+	b := NewMemoryBudget(100)
+	in := make(chan *ChangeEvent)
+	out := make(chan *ChangeEvent, 10)
+
+	go throttleChanges(t.Context(), b, in, out)
+
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+
+		for range 4 {
+			in <- &ChangeEvent{size: 40}
+		}
+
+		close(in)
+	}()
+
+	// the third event would exceed the limit: the reads wait for the apply
+	deadline := time.Now().Add(time.Second)
+	for len(out) != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if len(out) != 2 || b.Used() != 80 {
+		t.Fatalf("queued: got = %d (%d bytes), want 2 (80 bytes)", len(out), b.Used())
+	}
+
+	select {
+	case <-read:
+		t.Fatal("the reads are not throttled")
+	default:
+	}
+
+	// the applied events release the memory
+	for range 4 {
+		change := <-out
+		b.release(change.size)
+	}
+
+	<-read
+
+	if _, ok := <-out; ok {
+		t.Error("the queue is not closed")
+	}
+
+	if got := b.Used(); got != 0 {
+		t.Errorf("used: got = %d, want 0", got)
+	}
+}
+
+func TestCopyManager_ReadSegmentMemory(t *testing.T) {
+	t.Parallel()
+
+	const batchSize = 4096
+
+	b := NewMemoryBudget(batchSize)
+
+	docs := makeIDDocs(1, 2, 3)
+	cm := &CopyManager{options: CopyManagerOptions{BatchSizeBytes: batchSize, Memory: b}}
+	resultC := make(chan readBatchResult, 1)
+
+	// the inserted batch holds the memory: the next batch is not read until it is released
+	if err := b.acquire(t.Context(), batchSize); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := &fakeCursor{docs: docs}
+	done := make(chan error)
+
+	go func() {
+		done <- cm.readSegment(context.Background(), resultC, cur, func() uint32 { return 1 })
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if cur.curr != nil {
+		t.Fatal("read before the memory is acquired")
+	}
+
+	b.release(batchSize)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// the batch holds its size, the rest of the acquired memory is released
+	batch := <-resultC
+	if got := b.Used(); got != int64(batch.SizeBytes) {
+		t.Errorf("used: got = %d, want %d", got, batch.SizeBytes)
+	}
+}
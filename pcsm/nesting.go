@@ -0,0 +1,82 @@
+package pcsm
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// ErrNestingExceeded indicates that a document nests deeper than [config.MaxNestingDepth]
+// and cannot be written to the target.
+var ErrNestingExceeded = errors.New("document nesting depth exceeds the target limit")
+
+// NestingExceededAction is the handling of the documents nested deeper than
+// [config.MaxNestingDepth].
+type NestingExceededAction string
+
+const (
+	// NestingExceededSkip skips the document and logs its _id.
+	NestingExceededSkip NestingExceededAction = "skip"
+	// NestingExceededFail fails the replication with [ErrNestingExceeded].
+	NestingExceededFail NestingExceededAction = "fail"
+)
+
+// ParseNestingExceededAction parses the nesting exceeded action. The empty string disables
+// the check and the target rejects the write of an over-nested document.
+func ParseNestingExceededAction(s string) (NestingExceededAction, error) {
+	switch a := NestingExceededAction(s); a {
+	case "", NestingExceededSkip, NestingExceededFail:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid nesting exceeded action %q", s)
+}
+
+// exceedsNesting reports whether the document nests more than maxDepth levels of embedded
+// documents and arrays. The top-level document is the first level.
+func exceedsNesting(doc bson.Raw, maxDepth int) bool {
+	if maxDepth < 1 {
+		return true
+	}
+
+	elems, err := doc.Elements()
+	if err != nil {
+		return false // invalid documents are rejected by the target
+	}
+
+	for _, elem := range elems {
+		val := elem.Value()
+		if val.Type != bson.TypeEmbeddedDocument && val.Type != bson.TypeArray {
+			continue
+		}
+
+		if exceedsNesting(val.Value, maxDepth-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkNesting returns [ErrNestingExceeded] with the _id of the document if the document
+// nests deeper than [config.MaxNestingDepth].
+func checkNesting(doc bson.Raw) error {
+	if !exceedsNesting(doc, config.MaxNestingDepth) {
+		return nil
+	}
+
+	return errors.Wrapf(ErrNestingExceeded, "_id %s", doc.Lookup("_id"))
+}
+
+// changeFullDocument returns the full document written by an insert or a replace change event.
+func changeFullDocument(change *ChangeEvent) bson.Raw {
+	switch event := change.Event.(type) {
+	case InsertEvent:
+		return event.FullDocument
+	case ReplaceEvent:
+		return event.FullDocument
+	}
+
+	return nil
+}
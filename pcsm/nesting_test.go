@@ -0,0 +1,127 @@
+package pcsm //nolint
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// nestedDoc returns a document with the _id and the depth levels of nesting including
+// the top-level document. The odd levels are embedded documents and the even are arrays.
+func nestedDoc(t *testing.T, id int32, depth int) bson.Raw {
+	t.Helper()
+
+	var val any = int32(1)
+	for level := depth; level > 1; level-- {
+		if level%2 == 0 {
+			val = bson.A{val}
+		} else {
+			val = bson.D{{"a", val}}
+		}
+	}
+
+	return mustMarshal(t, bson.D{{"_id", id}, {"a", val}})
+}
+
+func TestParseNestingExceededAction(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "skip", "fail"} {
+		got, err := ParseNestingExceededAction(s)
+		if err != nil || got != NestingExceededAction(s) {
+			t.Errorf("%q: got = %q, %v, want %q", s, got, err, s)
+		}
+	}
+
+	_, err := ParseNestingExceededAction("truncate")
+	if err == nil {
+		t.Error("truncate: got = nil, want error")
+	}
+}
+
+func TestCheckNesting(t *testing.T) {
+	t.Parallel()
+
+	for _, depth := range []int{1, 2, config.MaxNestingDepth} {
+		err := checkNesting(nestedDoc(t, 1, depth))
+		if err != nil {
+			t.Errorf("depth %d: got = %v, want nil", depth, err)
+		}
+	}
+
+	err := checkNesting(nestedDoc(t, 7, config.MaxNestingDepth+1))
+	if !errors.Is(err, ErrNestingExceeded) {
+		t.Fatalf("over-nested: got = %v, want %v", err, ErrNestingExceeded)
+	}
+
+	if !strings.Contains(err.Error(), `_id {"$numberInt":"7"}`) {
+		t.Errorf("over-nested: got = %v, want the _id reported", err)
+	}
+}
+
+func TestChangeFullDocument(t *testing.T) {
+	t.Parallel()
+
+	doc := nestedDoc(t, 1, config.MaxNestingDepth+1)
+
+	for _, change := range []*ChangeEvent{
+		{EventHeader: EventHeader{OperationType: Insert}, Event: InsertEvent{FullDocument: doc}},
+		{EventHeader: EventHeader{OperationType: Replace}, Event: ReplaceEvent{FullDocument: doc}},
+	} {
+		if !errors.Is(checkNesting(changeFullDocument(change)), ErrNestingExceeded) {
+			t.Errorf("%s: the over-nested document is not detected", change.OperationType)
+		}
+	}
+
+	change := &ChangeEvent{EventHeader: EventHeader{OperationType: Delete}, Event: DeleteEvent{}}
+	if err := checkNesting(changeFullDocument(change)); err != nil {
+		t.Errorf("delete: got = %v, want nil", err)
+	}
+}
+
+func TestCopyManager_ReadSegmentNesting(t *testing.T) {
+	t.Parallel()
+
+	read := func(action NestingExceededAction) ([]int32, error) {
+		cm := &CopyManager{options: CopyManagerOptions{OnNestingExceeded: action}}
+		cur := &fakeCursor{docs: []bson.Raw{
+			nestedDoc(t, 1, 3),
+			nestedDoc(t, 2, config.MaxNestingDepth+1),
+			nestedDoc(t, 3, 3),
+		}}
+
+		resultC := make(chan readBatchResult, 1)
+		var batchID uint32
+
+		err := cm.readSegment(t.Context(), resultC, cur, func() uint32 { batchID++; return batchID })
+		close(resultC)
+
+		var ids []int32
+		for batch := range resultC {
+			for _, doc := range batch.Documents {
+				ids = append(ids, doc.(bson.Raw).Lookup("_id").Int32()) //nolint:forcetypeassert
+			}
+		}
+
+		return ids, err
+	}
+
+	ids, err := read(NestingExceededSkip)
+	if err != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("skip: got = %v, %v, want [1 3]", ids, err)
+	}
+
+	_, err = read(NestingExceededFail)
+	if !errors.Is(err, ErrNestingExceeded) {
+		t.Errorf("fail: got = %v, want %v", err, ErrNestingExceeded)
+	}
+
+	ids, err = read("")
+	if err != nil || len(ids) != 3 {
+		t.Errorf("no check: got = %v, %v, want all documents", ids, err)
+	}
+}
@@ -0,0 +1,192 @@
+package pcsm
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// ValidateNamespacePattern returns an error if the pattern is not "db.collection" or "db.*".
+func ValidateNamespacePattern(pattern string) error {
+	db, coll, _ := strings.Cut(pattern, ".")
+	if db == "" || coll == "" || strings.Contains(db, "*") ||
+		(strings.Contains(coll, "*") && coll != "*") {
+		return errors.Errorf("invalid namespace pattern %q", pattern)
+	}
+
+	return nil
+}
+
+// matchNamespacePattern reports whether the namespace matches the "db.collection"
+// or "db.*" pattern.
+func matchNamespacePattern(pattern string, ns Namespace) bool {
+	db, coll, _ := strings.Cut(pattern, ".")
+
+	return ns.Database == db && (coll == "*" || ns.Collection == coll)
+}
+
+// heldChange is a CRUD change of a paused namespace held until the namespace is resumed.
+type heldChange struct {
+	ns     Namespace // namespace of the collection UUID when the change is received
+	change *ChangeEvent
+}
+
+// namespacePauses tracks the paused namespace patterns and holds the CRUD changes
+// of the paused namespaces in the order they are received.
+type namespacePauses struct {
+	lock sync.Mutex
+
+	patterns []string
+	held     []heldChange
+	resumed  bool // a pattern with the held changes has been resumed
+}
+
+// pause adds the pattern to the paused patterns.
+func (p *namespacePauses) pause(pattern string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if slices.Contains(p.patterns, pattern) {
+		return errors.Errorf("%q is already paused", pattern)
+	}
+
+	p.patterns = append(p.patterns, pattern)
+
+	return nil
+}
+
+// resume removes the pattern from the paused patterns. The held changes of the namespaces
+// not paused by other patterns are returned by [namespacePauses.release].
+func (p *namespacePauses) resume(pattern string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := slices.Index(p.patterns, pattern)
+	if i == -1 {
+		return errors.Errorf("%q is not paused", pattern)
+	}
+
+	p.patterns = slices.Delete(p.patterns, i, i+1)
+	p.resumed = len(p.held) != 0
+
+	return nil
+}
+
+// resumeAll removes all paused patterns.
+func (p *namespacePauses) resumeAll() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.patterns = nil
+	p.resumed = len(p.held) != 0
+}
+
+// list returns the paused patterns.
+func (p *namespacePauses) list() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return slices.Clone(p.patterns)
+}
+
+// restore sets the paused patterns of the checkpoint.
+func (p *namespacePauses) restore(patterns []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.patterns = slices.Clone(patterns)
+}
+
+// isPaused reports whether the namespace matches a paused pattern.
+func (p *namespacePauses) isPaused(ns Namespace) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.isPausedLocked(ns)
+}
+
+func (p *namespacePauses) isPausedLocked(ns Namespace) bool {
+	return slices.ContainsFunc(p.patterns, func(pattern string) bool {
+		return matchNamespacePattern(pattern, ns)
+	})
+}
+
+// hold holds the change of the paused namespace and returns the number of the held changes.
+func (p *namespacePauses) hold(ns Namespace, change *ChangeEvent) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.held = append(p.held, heldChange{ns: ns, change: change})
+
+	return len(p.held)
+}
+
+// count returns the number of the held changes.
+func (p *namespacePauses) count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.held)
+}
+
+// hasResumed reports whether a pattern with the held changes has been resumed since
+// the last [namespacePauses.release].
+func (p *namespacePauses) hasResumed() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.resumed
+}
+
+// release returns the held changes of the namespaces that are not paused, and of the paused
+// namespaces for which force returns true (nil forces none). The changes stay held until
+// [namespacePauses.remove], so the applied optime does not pass them before they are written.
+func (p *namespacePauses) release(force func(Namespace) bool) []heldChange {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.resumed = false
+
+	var released []heldChange
+
+	for _, h := range p.held {
+		if !p.isPausedLocked(h.ns) || (force != nil && force(h.ns)) {
+			released = append(released, h)
+		}
+	}
+
+	return released
+}
+
+// remove removes the written changes returned by [namespacePauses.release].
+func (p *namespacePauses) remove(released []heldChange) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	written := make(map[*ChangeEvent]struct{}, len(released))
+	for _, h := range released {
+		written[h.change] = struct{}{}
+	}
+
+	p.held = slices.DeleteFunc(p.held, func(h heldChange) bool {
+		_, ok := written[h.change]
+
+		return ok
+	})
+}
+
+// earliest returns the cluster time of the earliest held change.
+func (p *namespacePauses) earliest() (bson.Timestamp, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.held) == 0 {
+		return bson.Timestamp{}, false
+	}
+
+	return p.held[0].change.ClusterTime, true
+}
@@ -0,0 +1,127 @@
+package pcsm //nolint
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestValidateNamespacePattern(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"db1.coll1", "db1.*", "db1.coll.with.dots"} {
+		err := ValidateNamespacePattern(pattern)
+		if err != nil {
+			t.Errorf("%q: got = %v, want nil", pattern, err)
+		}
+	}
+
+	for _, pattern := range []string{"", "db1", "db1.", ".coll1", "*.coll1", "db1.coll*"} {
+		err := ValidateNamespacePattern(pattern)
+		if err == nil {
+			t.Errorf("%q: got = nil, want error", pattern)
+		}
+	}
+}
+
+func TestNamespacePauses(t *testing.T) {
+	t.Parallel()
+
+	p := &namespacePauses{}
+
+	err := p.pause("db1.coll1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.pause("db2.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.pause("db1.coll1"); err == nil {
+		t.Errorf("pause again: got = nil, want error")
+	}
+
+	for _, test := range []struct {
+		ns   Namespace
+		want bool
+	}{
+		{Namespace{"db1", "coll1"}, true},
+		{Namespace{"db1", "coll2"}, false},
+		{Namespace{"db2", "coll1"}, true},
+		{Namespace{"db3", "coll1"}, false},
+	} {
+		if got := p.isPaused(test.ns); got != test.want {
+			t.Errorf("%s: got = %v, want %v", test.ns, got, test.want)
+		}
+	}
+
+	change := func(ts uint32) *ChangeEvent {
+		return &ChangeEvent{EventHeader: EventHeader{ClusterTime: bson.Timestamp{T: ts}}}
+	}
+
+	p.hold(Namespace{"db1", "coll1"}, change(1))
+	p.hold(Namespace{"db2", "coll1"}, change(2))
+	p.hold(Namespace{"db1", "coll1"}, change(3))
+
+	if ts, ok := p.earliest(); !ok || ts.T != 1 {
+		t.Errorf("earliest: got = %v %v, want 1", ts, ok)
+	}
+
+	if got := p.release(nil); len(got) != 0 {
+		t.Errorf("paused: got = %d released, want 0", len(got))
+	}
+
+	err = p.resume("db1.coll1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.hasResumed() {
+		t.Errorf("got = not resumed, want resumed")
+	}
+
+	// the changes of the resumed namespace are released in order
+	released := p.release(nil)
+	if len(released) != 2 || released[0].change.ClusterTime.T != 1 || released[1].change.ClusterTime.T != 3 {
+		t.Fatalf("resumed: got = %v, want the changes at 1 and 3", released)
+	}
+
+	// the released changes are held until written
+	if ts, _ := p.earliest(); ts.T != 1 {
+		t.Errorf("earliest before remove: got = %v, want 1", ts)
+	}
+
+	p.remove(released)
+
+	if ts, _ := p.earliest(); ts.T != 2 || p.count() != 1 {
+		t.Errorf("earliest after remove: got = %v of %d, want 2 of 1", ts, p.count())
+	}
+
+	// a DDL change of the database forces the held changes of the paused namespace
+	forced := p.release(func(ns Namespace) bool { return ns.Database == "db2" })
+	if len(forced) != 1 || !p.isPaused(Namespace{"db2", "coll1"}) {
+		t.Errorf("forced: got = %d released, want 1 and db2 paused", len(forced))
+	}
+
+	if err := p.resume("db1.coll1"); err == nil {
+		t.Errorf("resume again: got = nil, want error")
+	}
+}
+
+func TestRepl_AppliedOpTimeHeldChanges(t *testing.T) {
+	t.Parallel()
+
+	r := NewRepl(nil, nil, NewCatalog(nil, CatalogOptions{}), nil, ReplOptions{})
+	r.lastReplicatedOpTime = bson.Timestamp{T: 100, I: 1}
+
+	r.nsPauses.hold(Namespace{"db1", "coll1"}, &ChangeEvent{
+		EventHeader: EventHeader{ClusterTime: bson.Timestamp{T: 90, I: 2}},
+	})
+
+	// the held change is read again after a restart
+	if got := r.appliedOpTime(); got != (bson.Timestamp{T: 90, I: 2}) {
+		t.Errorf("got = %v, want 90.2", got)
+	}
+}
@@ -0,0 +1,592 @@
+package pcsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// ErrUnsupportedOplogEntry indicates an oplog entry that cannot be replicated by oplog tailing.
+var ErrUnsupportedOplogEntry = errors.New("unsupported oplog entry")
+
+// oplogEntry represents an entry of the local.oplog.rs collection
+// (or an operation of an applyOps entry).
+type oplogEntry struct {
+	TS          bson.Timestamp `bson:"ts"`
+	Op          string         `bson:"op"`
+	NS          string         `bson:"ns"`
+	UI          *bson.Binary   `bson:"ui,omitempty"`
+	O           bson.Raw       `bson:"o"`
+	O2          bson.Raw       `bson:"o2,omitempty"`
+	FromMigrate bool           `bson:"fromMigrate,omitempty"`
+
+	LSID      bson.Raw `bson:"lsid,omitempty"`
+	TxnNumber *int64   `bson:"txnNumber,omitempty"`
+}
+
+// oplogParser converts oplog entries into change events.
+// It buffers the operations of unprepared transactions that span multiple applyOps entries
+// until the transaction is committed.
+type oplogParser struct {
+	txnOps   map[string][]oplogEntry // partial transaction operations by lsid and txnNumber
+	internal map[string]struct{}     // internal namespaces replicated on demand
+}
+
+// newOplogParser returns the parser. The entries of the internal namespaces are converted
+// into change events. The other internal entries are skipped.
+func newOplogParser(internal ...Namespace) *oplogParser {
+	p := &oplogParser{
+		txnOps:   make(map[string][]oplogEntry),
+		internal: make(map[string]struct{}, len(internal)),
+	}
+
+	for _, ns := range internal {
+		p.internal[ns.String()] = struct{}{}
+	}
+
+	return p
+}
+
+// Parse converts the raw oplog entry into change events in order. It returns no events
+// for entries that are not replicated (e.g. internal namespaces or chunk migrations).
+func (p *oplogParser) Parse(raw bson.Raw) ([]*ChangeEvent, error) {
+	var entry oplogEntry
+
+	err := bson.Unmarshal(raw, &entry)
+	if err != nil {
+		return nil, ParsingError{cause: err}
+	}
+
+	if entry.Op == "n" {
+		tick := &ChangeEvent{
+			EventHeader: EventHeader{
+				OperationType: advanceTimePseudoEvent,
+				ClusterTime:   entry.TS,
+			},
+		}
+
+		return []*ChangeEvent{tick}, nil
+	}
+
+	if entry.Op == "c" {
+		if _, ok := entry.O.Lookup("applyOps").ArrayOK(); ok {
+			return p.parseApplyOps(&entry)
+		}
+	}
+
+	change, err := convertOplogEntry(&entry, entry.TS, p.internal)
+	if err != nil || change == nil {
+		return nil, err
+	}
+
+	return []*ChangeEvent{change}, nil
+}
+
+func (p *oplogParser) parseApplyOps(entry *oplogEntry) ([]*ChangeEvent, error) {
+	if prepare, _ := entry.O.Lookup("prepare").BooleanOK(); prepare {
+		return nil, errors.Wrap(ErrUnsupportedOplogEntry, "prepared transaction")
+	}
+
+	var applyOps struct {
+		Ops        []oplogEntry `bson:"applyOps"`
+		PartialTxn bool         `bson:"partialTxn,omitempty"`
+	}
+
+	err := bson.Unmarshal(entry.O, &applyOps)
+	if err != nil {
+		return nil, ParsingError{cause: err}
+	}
+
+	ops := applyOps.Ops
+
+	if entry.TxnNumber != nil {
+		key := string(entry.LSID) + ":" + strconv.FormatInt(*entry.TxnNumber, 10)
+
+		if applyOps.PartialTxn {
+			p.txnOps[key] = append(p.txnOps[key], ops...)
+
+			return nil, nil
+		}
+
+		ops = append(p.txnOps[key], ops...)
+		delete(p.txnOps, key)
+	}
+
+	changes := make([]*ChangeEvent, 0, len(ops))
+
+	for i := range ops {
+		ops[i].LSID = entry.LSID
+		ops[i].TxnNumber = entry.TxnNumber
+
+		change, err := convertOplogEntry(&ops[i], entry.TS, p.internal)
+		if err != nil {
+			return nil, err
+		}
+
+		if change != nil {
+			change.opIndex = i
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// convertOplogEntry converts a single CRUD or DDL oplog entry into a change event
+// at the cluster time ts. Returns nil if the entry is not replicated. The entries of
+// the internal namespaces are replicated only if listed in internal.
+func convertOplogEntry(
+	entry *oplogEntry,
+	ts bson.Timestamp,
+	internal map[string]struct{},
+) (*ChangeEvent, error) {
+	if entry.FromMigrate {
+		return nil, nil
+	}
+
+	db, coll, _ := strings.Cut(entry.NS, ".")
+	if isInternalDatabase(db) || strings.HasPrefix(coll, "system.") {
+		if _, ok := internal[entry.NS]; !ok {
+			return nil, nil
+		}
+	}
+
+	change := &ChangeEvent{
+		EventHeader: EventHeader{
+			Namespace:      Namespace{Database: db, Collection: coll},
+			CollectionUUID: entry.UI,
+			TxnNumber:      entry.TxnNumber,
+			LSID:           entry.LSID,
+			ClusterTime:    ts,
+		},
+	}
+
+	var err error
+
+	switch entry.Op {
+	case "i":
+		documentKey := entry.O2
+		if id, idErr := entry.O.LookupErr("_id"); documentKey == nil && idErr == nil {
+			documentKey, err = bson.Marshal(bson.D{{"_id", id}})
+			if err != nil {
+				return nil, ParsingError{cause: err}
+			}
+		}
+
+		var key bson.D
+
+		// the document without _id has no document key: [ensureDocumentID] sets it
+		if documentKey != nil {
+			err = bson.Unmarshal(documentKey, &key)
+			if err != nil {
+				return nil, ParsingError{cause: err}
+			}
+		}
+
+		change.OperationType = Insert
+		change.Event = InsertEvent{DocumentKey: key, FullDocument: entry.O}
+
+	case "d":
+		var key bson.D
+
+		err = bson.Unmarshal(entry.O, &key)
+		if err != nil {
+			return nil, ParsingError{cause: err}
+		}
+
+		change.OperationType = Delete
+		change.Event = DeleteEvent{DocumentKey: key}
+
+	case "u":
+		err = convertOplogUpdate(entry, change)
+
+	case "c":
+		err = convertOplogCommand(entry, change)
+
+	default:
+		err = errors.Wrapf(ErrUnsupportedOplogEntry, "op %q", entry.Op)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if change.OperationType == "" {
+		return nil, nil // skipped
+	}
+
+	return change, nil
+}
+
+// isInternalDatabase returns true for the databases that are not replicated.
+func isInternalDatabase(db string) bool {
+	return db == "admin" || db == "config" || db == "local"
+}
+
+// makeNSFilter returns the filter of the replicated namespaces. The internal databases
+// (admin, config, and local) and the PCSM database are never allowed, even if included.
+// The oplog method reads local.oplog.rs on the source, but nothing is written to local.
+func makeNSFilter(include, exclude []string) sel.NSFilter {
+	filter := sel.MakeFilter(include, exclude)
+
+	return func(db, coll string) bool {
+		if isInternalDatabase(db) || db == config.PCSMDatabase {
+			return false
+		}
+
+		return filter(db, coll)
+	}
+}
+
+func convertOplogUpdate(entry *oplogEntry, change *ChangeEvent) error {
+	var key bson.D
+
+	err := bson.Unmarshal(entry.O2, &key)
+	if err != nil {
+		return ParsingError{cause: err}
+	}
+
+	if diff, ok := entry.O.Lookup("diff").DocumentOK(); ok {
+		var desc UpdateDescription
+
+		err = convertUpdateDiff(diff, "", &desc)
+		if err != nil {
+			return errors.Wrap(err, "update diff")
+		}
+
+		change.OperationType = Update
+		change.Event = UpdateEvent{DocumentKey: key, UpdateDescription: desc}
+
+		return nil
+	}
+
+	setDoc, hasSet := entry.O.Lookup("$set").DocumentOK()
+	unsetDoc, hasUnset := entry.O.Lookup("$unset").DocumentOK()
+
+	if hasSet || hasUnset { // the legacy update format ($v: 1)
+		var desc UpdateDescription
+
+		if hasSet {
+			err = bson.Unmarshal(setDoc, &desc.UpdatedFields)
+			if err != nil {
+				return ParsingError{cause: err}
+			}
+		}
+
+		if hasUnset {
+			elems, _ := unsetDoc.Elements()
+			for _, elem := range elems {
+				desc.RemovedFields = append(desc.RemovedFields, elem.Key())
+			}
+		}
+
+		change.OperationType = Update
+		change.Event = UpdateEvent{DocumentKey: key, UpdateDescription: desc}
+
+		return nil
+	}
+
+	change.OperationType = Replace
+	change.Event = ReplaceEvent{DocumentKey: entry.O2, FullDocument: entry.O}
+
+	return nil
+}
+
+// convertUpdateDiff converts the $v: 2 update diff into the [UpdateDescription].
+//
+// The diff document contains "u" (updated fields), "i" (inserted fields), "d" (deleted fields),
+// and "s<field>" (a sub-diff of the field). An array sub-diff is marked with "a": true and
+// contains "u<index>" (updated elements), "s<index>" (element sub-diffs), and "l" (new length).
+func convertUpdateDiff(diff bson.Raw, prefix string, desc *UpdateDescription) error {
+	elems, err := diff.Elements()
+	if err != nil {
+		return ParsingError{cause: err}
+	}
+
+	isArray, _ := diff.Lookup("a").BooleanOK()
+
+	for _, elem := range elems {
+		key := elem.Key()
+
+		switch {
+		case isArray && key == "a":
+			continue
+
+		case isArray && key == "l":
+			newSize, ok := elem.Value().AsInt64OK()
+			if !ok {
+				return errors.Errorf("invalid array length for %q", prefix)
+			}
+
+			desc.TruncatedArrays = append(desc.TruncatedArrays, struct {
+				Field   string `bson:"field"`
+				NewSize int32  `bson:"newSize"`
+			}{
+				Field:   strings.TrimSuffix(prefix, "."),
+				NewSize: int32(newSize), //nolint:gosec
+			})
+
+		case isArray && strings.HasPrefix(key, "u"):
+			desc.UpdatedFields = append(desc.UpdatedFields,
+				bson.E{prefix + key[1:], elem.Value()})
+
+		case key == "u" || key == "i":
+			fields, ok := elem.Value().DocumentOK()
+			if !ok {
+				return errors.Errorf("invalid %q section for %q", key, prefix)
+			}
+
+			fieldElems, _ := fields.Elements()
+			for _, f := range fieldElems {
+				desc.UpdatedFields = append(desc.UpdatedFields, bson.E{prefix + f.Key(), f.Value()})
+			}
+
+		case key == "d":
+			fields, ok := elem.Value().DocumentOK()
+			if !ok {
+				return errors.Errorf("invalid %q section for %q", key, prefix)
+			}
+
+			fieldElems, _ := fields.Elements()
+			for _, f := range fieldElems {
+				desc.RemovedFields = append(desc.RemovedFields, prefix+f.Key())
+			}
+
+		case strings.HasPrefix(key, "s"):
+			subDiff, ok := elem.Value().DocumentOK()
+			if !ok {
+				return errors.Errorf("invalid sub-diff %q for %q", key, prefix)
+			}
+
+			err = convertUpdateDiff(subDiff, prefix+key[1:]+".", desc)
+			if err != nil {
+				return err
+			}
+
+		default:
+			return errors.Errorf("unknown diff section %q for %q", key, prefix)
+		}
+	}
+
+	return nil
+}
+
+func convertOplogCommand(entry *oplogEntry, change *ChangeEvent) error {
+	elems, err := entry.O.Elements()
+	if err != nil || len(elems) == 0 {
+		return ParsingError{cause: errors.New("empty command")}
+	}
+
+	cmd := elems[0].Key()
+	coll, _ := elems[0].Value().StringValueOK()
+
+	switch cmd {
+	case "create":
+		var opts CreateCollectionOptions
+
+		err = bson.Unmarshal(entry.O, &opts)
+		if err != nil {
+			return ParsingError{cause: err}
+		}
+
+		change.Namespace.Collection = coll
+		change.OperationType = Create
+		change.Event = CreateEvent{OperationDescription: opts}
+
+	case "drop":
+		change.Namespace.Collection = coll
+		change.OperationType = Drop
+		change.Event = DropEvent{}
+
+	case "dropDatabase":
+		change.Namespace.Collection = ""
+		change.OperationType = DropDatabase
+		change.Event = DropDatabaseEvent{}
+
+	case "renameCollection":
+		// the source and the target are full namespaces: a rename can change the database
+		fromDB, fromColl, _ := strings.Cut(coll, ".")
+		to, _ := entry.O.Lookup("to").StringValueOK()
+		toDB, toColl, _ := strings.Cut(to, ".")
+
+		if fromColl == "" || toColl == "" {
+			return ParsingError{cause: errors.Errorf("invalid rename of %q to %q", coll, to)}
+		}
+
+		change.Namespace = Namespace{Database: fromDB, Collection: fromColl}
+		change.OperationType = Rename
+		change.Event = RenameEvent{
+			OperationDescription: renameOpDesc{To: Namespace{Database: toDB, Collection: toColl}},
+		}
+
+	case "createIndexes":
+		var index topo.IndexSpecification
+
+		err = bson.Unmarshal(entry.O, &index)
+		if err != nil {
+			return ParsingError{cause: err}
+		}
+
+		change.Namespace.Collection = coll
+		change.OperationType = CreateIndexes
+		change.Event = CreateIndexesEvent{
+			OperationDescription: createIndexesOpDesc{
+				Indexes: []*topo.IndexSpecification{&index},
+			},
+		}
+
+	case "commitIndexBuild":
+		var build struct {
+			Indexes []*topo.IndexSpecification `bson:"indexes"`
+		}
+
+		err = bson.Unmarshal(entry.O, &build)
+		if err != nil {
+			return ParsingError{cause: err}
+		}
+
+		change.Namespace.Collection = coll
+		change.OperationType = CreateIndexes
+		change.Event = CreateIndexesEvent{
+			OperationDescription: createIndexesOpDesc{Indexes: build.Indexes},
+		}
+
+	case "dropIndexes":
+		name, _ := entry.O.Lookup("index").StringValueOK()
+
+		var desc dropIndexesOpDesc
+		desc.Indexes = append(desc.Indexes, struct {
+			Name string `bson:"name"`
+		}{Name: name})
+
+		change.Namespace.Collection = coll
+		change.OperationType = DropIndexes
+		change.Event = DropIndexesEvent{OperationDescription: desc}
+
+	case "collMod":
+		var desc modifyOpDesc
+
+		err = bson.Unmarshal(entry.O, &desc)
+		if err != nil {
+			return ParsingError{cause: err}
+		}
+
+		delete(desc.Unknown, "collMod")
+
+		change.Namespace.Collection = coll
+		change.OperationType = Modify
+		change.Event = ModifyEvent{OperationDescription: desc}
+
+	case "startIndexBuild", "abortIndexBuild":
+		// the index is created on commitIndexBuild
+
+	default:
+		return errors.Wrapf(ErrUnsupportedOplogEntry,
+			"command %q: use the changestream replication method", cmd)
+	}
+
+	return nil
+}
+
+// tailOplog reads changes from the source local.oplog.rs collection starting at startAt
+// and sends them to changeC. It is used when the source does not support change streams.
+func (r *Repl) tailOplog(
+	ctx context.Context,
+	startAt bson.Timestamp,
+	changeC chan<- *ChangeEvent,
+) error {
+	coll := r.source.Database("local").Collection("oplog.rs")
+
+	var oldest struct {
+		TS bson.Timestamp `bson:"ts"`
+	}
+
+	err := coll.FindOne(ctx, bson.D{},
+		options.FindOne().
+			SetSort(bson.D{{"$natural", 1}}).
+			SetProjection(bson.D{{"ts", 1}})).
+		Decode(&oldest)
+	if err != nil {
+		return errors.Wrap(err, "find oldest entry")
+	}
+
+	if oldest.TS.After(startAt) {
+		return ErrOplogHistoryLost
+	}
+
+	cur, err := coll.Find(ctx, bson.D{{"ts", bson.D{{"$gte", startAt}}}},
+		options.Find().
+			SetCursorType(options.TailableAwait).
+			SetBatchSize(config.ChangeStreamBatchSize).
+			SetMaxAwaitTime(config.ChangeStreamAwaitTime))
+	if err != nil {
+		return errors.Wrap(err, "open")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(context.Background(), config.CloseCursorTimeout, cur.Close)
+		if err != nil {
+			log.New("repl:oplog").Error(err, "Close oplog cursor")
+		}
+	}()
+
+	parser := newOplogParser(r.options.InternalNamespaces...)
+
+	// a secondary cannot append the oplog note. the noop entries of the primary progress pcsm time
+	appendNote := !r.isSourceSecondary(ctx)
+
+	for {
+		for cur.TryNext(ctx) {
+			changes, err := parser.Parse(cur.Current)
+			if err != nil {
+				t, i, _ := cur.Current.Lookup("ts").TimestampOK()
+
+				return errors.Wrapf(err, "oplog entry at %d.%d", t, i)
+			}
+
+			if len(changes) != 0 {
+				changes[0].size = len(cur.Current) // the entry is held by its first change
+			}
+
+			for _, change := range changes {
+				changeC <- change
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := cur.Err(); err != nil {
+			return errors.Wrap(err, "cursor")
+		}
+
+		if cur.ID() == 0 {
+			return errors.New("oplog cursor is closed")
+		}
+
+		if !appendNote {
+			continue
+		}
+
+		// no entry available yet. the noop entry progresses pcsm time on the next read
+		_, err = topo.AdvanceClusterTime(ctx, r.source)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			log.New("repl:oplog").Error(err, "Unable to advance the source cluster time")
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package pcsm //nolint
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestResolveReplicationMethod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		method  ReplicationMethod
+		caps    sourceCapabilities
+		want    ReplicationMethod
+		wantErr bool
+	}{
+		{
+			name:   "auto with change streams",
+			method: ReplicationAuto,
+			caps:   sourceCapabilities{ChangeStream: true, Oplog: true},
+			want:   ReplicationChangeStream,
+		},
+		{
+			name:   "auto without change streams",
+			method: ReplicationAuto,
+			caps:   sourceCapabilities{Oplog: true},
+			want:   ReplicationOplog,
+		},
+		{
+			name:   "explicit oplog",
+			method: ReplicationOplog,
+			caps:   sourceCapabilities{ChangeStream: true, Oplog: true},
+			want:   ReplicationOplog,
+		},
+		{
+			name:    "changestream is unavailable",
+			method:  ReplicationChangeStream,
+			caps:    sourceCapabilities{Oplog: true},
+			wantErr: true,
+		},
+		{
+			name:    "oplog is unavailable",
+			method:  ReplicationOplog,
+			caps:    sourceCapabilities{ChangeStream: true},
+			wantErr: true,
+		},
+		{
+			name:    "standalone",
+			method:  ReplicationAuto,
+			caps:    sourceCapabilities{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := resolveReplicationMethod(tt.method, tt.caps)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReplicationUnavailable) {
+					t.Errorf("got = %v, want %v", err, ErrReplicationUnavailable)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplicationMethod(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseReplicationMethod("")
+	if err != nil || got != ReplicationAuto {
+		t.Errorf("got = %q, %v, want %q", got, err, ReplicationAuto)
+	}
+
+	_, err = ParseReplicationMethod("binlog")
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
+
+func mustMarshal(t *testing.T, v any) bson.Raw {
+	t.Helper()
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func parseOplogEntry(t *testing.T, p *oplogParser, entry bson.D) []*ChangeEvent {
+	t.Helper()
+
+	changes, err := p.Parse(mustMarshal(t, entry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return changes
+}
+
+func TestOplogParser_CRUD(t *testing.T) {
+	t.Parallel()
+
+	p := newOplogParser()
+	ts := bson.Timestamp{T: 100, I: 1}
+
+	changes := parseOplogEntry(t, p, bson.D{
+		{"ts", ts},
+		{"op", "i"},
+		{"ns", "db1.coll1"},
+		{"o", bson.D{{"_id", 1}, {"a", "x"}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != Insert {
+		t.Fatalf("insert: got = %v", changes)
+	}
+
+	if changes[0].Namespace != (Namespace{Database: "db1", Collection: "coll1"}) {
+		t.Errorf("insert: got namespace = %v", changes[0].Namespace)
+	}
+
+	if changes[0].ClusterTime != ts {
+		t.Errorf("insert: got cluster time = %v, want %v", changes[0].ClusterTime, ts)
+	}
+
+	insert := changes[0].Event.(InsertEvent) //nolint:forcetypeassert
+	if len(insert.DocumentKey) != 1 || insert.DocumentKey[0].Key != "_id" {
+		t.Errorf("insert: got document key = %v", insert.DocumentKey)
+	}
+
+	changes = parseOplogEntry(t, p, bson.D{
+		{"ts", ts},
+		{"op", "u"},
+		{"ns", "db1.coll1"},
+		{"o", bson.D{{"_id", 1}, {"b", "y"}}},
+		{"o2", bson.D{{"_id", 1}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != Replace {
+		t.Fatalf("replace: got = %v", changes)
+	}
+
+	changes = parseOplogEntry(t, p, bson.D{
+		{"ts", ts},
+		{"op", "d"},
+		{"ns", "db1.coll1"},
+		{"o", bson.D{{"_id", 1}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != Delete {
+		t.Fatalf("delete: got = %v", changes)
+	}
+
+	changes = parseOplogEntry(t, p, bson.D{
+		{"ts", ts},
+		{"op", "n"},
+		{"ns", ""},
+		{"o", bson.D{{"msg", "periodic noop"}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != advanceTimePseudoEvent {
+		t.Fatalf("noop: got = %v", changes)
+	}
+
+	for _, ns := range []string{"admin.coll1", "config.system.sessions", "local.coll1", "db1.system.views"} {
+		changes = parseOplogEntry(t, p, bson.D{
+			{"ts", ts},
+			{"op", "i"},
+			{"ns", ns},
+			{"o", bson.D{{"_id", 1}}},
+		})
+		if len(changes) != 0 {
+			t.Errorf("%s: got = %v, want skipped", ns, changes)
+		}
+	}
+}
+
+func TestOplogParser_UpdateDiff(t *testing.T) {
+	t.Parallel()
+
+	changes := parseOplogEntry(t, newOplogParser(), bson.D{
+		{"ts", bson.Timestamp{T: 100, I: 1}},
+		{"op", "u"},
+		{"ns", "db1.coll1"},
+		{"o", bson.D{
+			{"$v", 2},
+			{"diff", bson.D{
+				{"u", bson.D{{"a", 1}}},
+				{"i", bson.D{{"b", 2}}},
+				{"d", bson.D{{"c", false}}},
+				{"sd", bson.D{{"u", bson.D{{"e", 3}}}}},
+				{"sarr", bson.D{
+					{"a", true},
+					{"l", 2},
+					{"u1", "v"},
+					{"s0", bson.D{{"d", bson.D{{"f", false}}}}},
+				}},
+			}},
+		}},
+		{"o2", bson.D{{"_id", 1}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != Update {
+		t.Fatalf("got = %v", changes)
+	}
+
+	desc := changes[0].Event.(UpdateEvent).UpdateDescription //nolint:forcetypeassert
+
+	updated := make([]string, 0, len(desc.UpdatedFields))
+	for _, e := range desc.UpdatedFields {
+		updated = append(updated, e.Key)
+	}
+
+	if want := []string{"a", "b", "d.e", "arr.1"}; !slices.Equal(updated, want) {
+		t.Errorf("updated fields: got = %v, want %v", updated, want)
+	}
+
+	if want := []string{"c", "arr.0.f"}; !slices.Equal(desc.RemovedFields, want) {
+		t.Errorf("removed fields: got = %v, want %v", desc.RemovedFields, want)
+	}
+
+	if len(desc.TruncatedArrays) != 1 ||
+		desc.TruncatedArrays[0].Field != "arr" || desc.TruncatedArrays[0].NewSize != 2 {
+		t.Errorf("truncated arrays: got = %v, want [{arr 2}]", desc.TruncatedArrays)
+	}
+}
+
+func TestOplogParser_Transaction(t *testing.T) {
+	t.Parallel()
+
+	p := newOplogParser()
+	lsid := bson.D{{"id", bson.Binary{Subtype: 4, Data: make([]byte, 16)}}}
+	commitTS := bson.Timestamp{T: 100, I: 2}
+
+	insert := func(id int) bson.D {
+		return bson.D{{"op", "i"}, {"ns", "db1.coll1"}, {"o", bson.D{{"_id", id}}}}
+	}
+
+	changes := parseOplogEntry(t, p, bson.D{
+		{"ts", bson.Timestamp{T: 100, I: 1}},
+		{"op", "c"},
+		{"ns", "admin.$cmd"},
+		{"o", bson.D{{"applyOps", bson.A{insert(1)}}, {"partialTxn", true}}},
+		{"lsid", lsid},
+		{"txnNumber", int64(1)},
+	})
+	if len(changes) != 0 {
+		t.Fatalf("partial: got = %v, want none", changes)
+	}
+
+	changes = parseOplogEntry(t, p, bson.D{
+		{"ts", commitTS},
+		{"op", "c"},
+		{"ns", "admin.$cmd"},
+		{"o", bson.D{{"applyOps", bson.A{insert(2), insert(3)}}}},
+		{"lsid", lsid},
+		{"txnNumber", int64(1)},
+	})
+	if len(changes) != 3 {
+		t.Fatalf("commit: got %d changes, want 3", len(changes))
+	}
+
+	for i, change := range changes {
+		if change.OperationType != Insert || change.ClusterTime != commitTS {
+			t.Errorf("%d: got = %s at %v, want insert at %v",
+				i, change.OperationType, change.ClusterTime, commitTS)
+		}
+
+		if !change.IsTransaction() {
+			t.Errorf("%d: got not a transaction operation", i)
+		}
+	}
+
+	_, err := p.Parse(mustMarshal(t, bson.D{
+		{"ts", commitTS},
+		{"op", "c"},
+		{"ns", "admin.$cmd"},
+		{"o", bson.D{{"applyOps", bson.A{insert(4)}}, {"prepare", true}}},
+		{"lsid", lsid},
+		{"txnNumber", int64(2)},
+	}))
+	if !errors.Is(err, ErrUnsupportedOplogEntry) {
+		t.Errorf("prepare: got = %v, want %v", err, ErrUnsupportedOplogEntry)
+	}
+}
+
+func TestOplogParser_DDL(t *testing.T) {
+	t.Parallel()
+
+	p := newOplogParser()
+	ts := bson.Timestamp{T: 100, I: 1}
+
+	command := func(o bson.D) []*ChangeEvent {
+		return parseOplogEntry(t, p, bson.D{{"ts", ts}, {"op", "c"}, {"ns", "db1.$cmd"}, {"o", o}})
+	}
+
+	changes := command(bson.D{{"create", "coll1"}, {"capped", true}, {"size", int64(4096)}})
+	if len(changes) != 1 || changes[0].OperationType != Create {
+		t.Fatalf("create: got = %v", changes)
+	}
+
+	create := changes[0].Event.(CreateEvent) //nolint:forcetypeassert
+	capped := create.OperationDescription.Capped
+	if changes[0].Namespace.Collection != "coll1" || capped == nil || !*capped {
+		t.Errorf("create: got = %v %+v", changes[0].Namespace, create.OperationDescription)
+	}
+
+	changes = command(bson.D{{"drop", "coll1"}})
+	if len(changes) != 1 || changes[0].OperationType != Drop ||
+		changes[0].Namespace.Collection != "coll1" {
+		t.Fatalf("drop: got = %v", changes)
+	}
+
+	changes = command(bson.D{{"dropDatabase", 1}})
+	if len(changes) != 1 || changes[0].OperationType != DropDatabase {
+		t.Fatalf("dropDatabase: got = %v", changes)
+	}
+
+	changes = command(bson.D{
+		{"commitIndexBuild", "coll1"},
+		{"indexes", bson.A{bson.D{{"v", 2}, {"key", bson.D{{"a", 1}}}, {"name", "a_1"}}}},
+	})
+	if len(changes) != 1 || changes[0].OperationType != CreateIndexes {
+		t.Fatalf("commitIndexBuild: got = %v", changes)
+	}
+
+	changes = command(bson.D{{"startIndexBuild", "coll1"}})
+	if len(changes) != 0 {
+		t.Errorf("startIndexBuild: got = %v, want skipped", changes)
+	}
+
+	changes = command(bson.D{{"renameCollection", "db1.coll1"}, {"to", "db2.coll2"}, {"dropTarget", true}})
+	if len(changes) != 1 || changes[0].OperationType != Rename ||
+		changes[0].Namespace != (Namespace{"db1", "coll1"}) {
+		t.Fatalf("renameCollection: got = %v", changes)
+	}
+
+	rename := changes[0].Event.(RenameEvent) //nolint:forcetypeassert
+	if rename.OperationDescription.To != (Namespace{"db2", "coll2"}) {
+		t.Errorf("renameCollection: got to %v, want db2.coll2", rename.OperationDescription.To)
+	}
+
+	_, err := p.Parse(mustMarshal(t, bson.D{
+		{"ts", ts},
+		{"op", "c"},
+		{"ns", "db1.$cmd"},
+		{"o", bson.D{{"importCollection", "coll1"}}},
+	}))
+	if !errors.Is(err, ErrUnsupportedOplogEntry) {
+		t.Errorf("importCollection: got = %v, want %v", err, ErrUnsupportedOplogEntry)
+	}
+}
+
+func TestOplogParser_CollMod(t *testing.T) {
+	t.Parallel()
+
+	p := newOplogParser()
+
+	command := func(o bson.D) ModifyEvent {
+		t.Helper()
+
+		changes := parseOplogEntry(t, p, bson.D{
+			{"ts", bson.Timestamp{T: 100, I: 1}},
+			{"op", "c"},
+			{"ns", "db1.$cmd"},
+			{"o", o},
+		})
+		if len(changes) != 1 || changes[0].OperationType != Modify ||
+			changes[0].Namespace != (Namespace{"db1", "coll1"}) {
+			t.Fatalf("got = %v, want the modify of db1.coll1", changes)
+		}
+
+		return changes[0].Event.(ModifyEvent) //nolint:forcetypeassert
+	}
+
+	// the TTL index change
+	event := command(bson.D{
+		{"collMod", "coll1"},
+		{"index", bson.D{{"name", "at_1"}, {"expireAfterSeconds", int64(3600)}}},
+	})
+
+	index := event.OperationDescription.Index
+	if index == nil || index.Name != "at_1" || index.ExpireAfterSeconds == nil || *index.ExpireAfterSeconds != 3600 {
+		t.Errorf("index: got = %+v, want at_1 expiring after 3600s", index)
+	}
+
+	if len(event.OperationDescription.Unknown) != 0 {
+		t.Errorf("unknown: got = %v, want none", event.OperationDescription.Unknown)
+	}
+
+	// the TTL of a clustered collection is reported to be skipped on apply
+	event = command(bson.D{{"collMod", "coll1"}, {"expireAfterSeconds", int64(60)}})
+	if got := event.OperationDescription.ExpireAfterSeconds; got == nil || *got != 60 {
+		t.Errorf("collection TTL: got = %v, want 60", got)
+	}
+}
+
+func TestMakeNSFilter_InternalDatabases(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+	}{
+		{"all", nil, nil},
+		{"include", []string{"local.*", "admin.*", "config.*", config.PCSMDatabase + ".*", "db1.*"}, nil},
+		{"exclude", nil, []string{"db2.*"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			filter := makeNSFilter(test.include, test.exclude)
+
+			for _, ns := range []Namespace{
+				{"local", "oplog.rs"},
+				{"local", "coll1"},
+				{"admin", "system.users"},
+				{"config", "transactions"},
+				{config.PCSMDatabase, "checkpoints"},
+			} {
+				if filter(ns.Database, ns.Collection) {
+					t.Errorf("%s is allowed", ns)
+				}
+			}
+
+			if !filter("db1", "coll1") {
+				t.Error("db1.coll1 is not allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package pcsm
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// OplogLostAction is the handling of the change replication that falls off the source oplog.
+type OplogLostAction string
+
+const (
+	// OplogLostFail fails the replication with [ErrOplogHistoryLost].
+	OplogLostFail OplogLostAction = "fail"
+	// OplogLostReclone clones again the namespaces written since the last replicated change
+	// and resumes the replication.
+	OplogLostReclone OplogLostAction = "reclone"
+)
+
+// ParseOplogLostAction parses the oplog lost action. The empty string is [OplogLostFail].
+func ParseOplogLostAction(s string) (OplogLostAction, error) {
+	switch a := OplogLostAction(s); a {
+	case "":
+		return OplogLostFail, nil
+	case OplogLostFail, OplogLostReclone:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid oplog lost action %q", s)
+}
+
+// gapRetryInterval is the delay before the gap tracker reopens its change stream after an error.
+const gapRetryInterval = time.Second
+
+// gapEvent is the projection of a change event read by the gap tracker.
+type gapEvent struct {
+	OperationType OperationType  `bson:"operationType"`
+	Namespace     Namespace      `bson:"ns"`
+	To            *Namespace     `bson:"to,omitempty"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+}
+
+// gapTracker records the last write time of each source namespace. It reads the namespaces of
+// the change events ahead of the change replication, which is slowed down by the apply.
+// When the change replication falls off the oplog, the namespaces written since its
+// last replicated change are the namespaces to reclone.
+type gapTracker struct {
+	lock    sync.Mutex
+	startTS bson.Timestamp               // tracked from
+	lastTS  bson.Timestamp               // tracked up to
+	writes  map[Namespace]bson.Timestamp // last write time. A database-level write has no collection
+	err     error                        // the tracking has a gap
+}
+
+func newGapTracker(startAt bson.Timestamp) *gapTracker {
+	return &gapTracker{
+		startTS: startAt,
+		lastTS:  startAt,
+		writes:  make(map[Namespace]bson.Timestamp),
+	}
+}
+
+// record records the write of the change event.
+func (t *gapTracker) record(ev *gapEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if ev.Namespace.Database != "" {
+		t.writes[ev.Namespace] = ev.ClusterTime
+	}
+
+	if ev.To != nil {
+		t.writes[*ev.To] = ev.ClusterTime
+	}
+
+	t.advance(ev.ClusterTime)
+}
+
+func (t *gapTracker) advance(ts bson.Timestamp) {
+	if ts.After(t.lastTS) {
+		t.lastTS = ts
+	}
+}
+
+// affected returns the namespaces written at or after the timestamp and the timestamp
+// the writes are tracked up to. A written database has no collection in the result.
+// It returns an error if the writes are not tracked since the timestamp.
+func (t *gapTracker) affected(since bson.Timestamp) ([]Namespace, bson.Timestamp, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.err != nil {
+		return nil, bson.Timestamp{}, errors.Wrap(t.err, "untracked writes")
+	}
+
+	if since.Before(t.startTS) {
+		return nil, bson.Timestamp{}, errors.Errorf("untracked writes before %d.%d", t.startTS.T, t.startTS.I)
+	}
+
+	namespaces := []Namespace{}
+	for ns, ts := range t.writes {
+		if !ts.Before(since) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	slices.SortFunc(namespaces, func(a, b Namespace) int { return strings.Compare(a.String(), b.String()) })
+
+	return namespaces, t.lastTS, nil
+}
+
+// setFailed stops the tracking. The writes after the error are not known.
+func (t *gapTracker) setFailed(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.err = err
+}
+
+// run tracks the writes of the source until the context is done. A transient error reopens
+// the change stream from the last tracked time. The lost history stops the tracking.
+func (t *gapTracker) run(ctx context.Context, source *mongo.Client) {
+	lg := log.New("repl:gap")
+
+	for {
+		t.lock.Lock()
+		startAt := t.lastTS
+		t.lock.Unlock()
+
+		err := t.watch(ctx, source, startAt)
+		if err == nil || errors.Is(err, context.Canceled) {
+			return
+		}
+
+		if topo.IsChangeStreamHistoryLost(err) || topo.IsCappedPositionLost(err) {
+			lg.Warnf("Stopped tracking the written namespaces: %v", err)
+			t.setFailed(err)
+
+			return
+		}
+
+		lg.Warnf("Track the written namespaces: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(gapRetryInterval):
+		}
+	}
+}
+
+func (t *gapTracker) watch(ctx context.Context, source *mongo.Client, startAt bson.Timestamp) error {
+	pipeline := mongo.Pipeline{{{"$project", bson.D{
+		{"operationType", 1},
+		{"ns", 1},
+		{"to", 1},
+		{"clusterTime", 1},
+	}}}}
+
+	cur, err := source.Watch(ctx, pipeline, options.ChangeStream().
+		SetStartAtOperationTime(&startAt).
+		SetShowExpandedEvents(true).
+		SetBatchSize(config.ChangeStreamBatchSize).
+		SetMaxAwaitTime(config.ChangeStreamAwaitTime))
+	if err != nil {
+		return errors.Wrap(err, "open")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(context.Background(), config.CloseCursorTimeout, cur.Close)
+		if err != nil {
+			log.New("repl:gap").Error(err, "Close change stream cursor")
+		}
+	}()
+
+	for {
+		// the writes before the cluster time are read by the next batch
+		sourceTS, err := topo.AdvanceClusterTime(ctx, source)
+		if err != nil {
+			return errors.Wrap(err, "advance cluster time")
+		}
+
+		for cur.TryNext(ctx) {
+			var ev gapEvent
+
+			err := cur.Decode(&ev)
+			if err != nil {
+				return errors.Wrap(err, "decode")
+			}
+
+			t.record(&ev)
+		}
+
+		if err := cur.Err(); err != nil || cur.ID() == 0 {
+			return errors.Wrap(err, "cursor")
+		}
+
+		t.lock.Lock()
+		t.advance(sourceTS)
+		t.lock.Unlock()
+	}
+}
+
+// recloneFilter returns the filter of the namespaces to reclone. A namespace without
+// a collection matches all collections of the database.
+func recloneFilter(filter sel.NSFilter, namespaces []Namespace) sel.NSFilter {
+	colls := make(map[Namespace]struct{}, len(namespaces))
+	dbs := make(map[string]struct{})
+
+	for _, ns := range namespaces {
+		if ns.Collection == "" {
+			dbs[ns.Database] = struct{}{}
+		} else {
+			colls[ns] = struct{}{}
+		}
+	}
+
+	return func(db, coll string) bool {
+		if !filter(db, coll) {
+			return false
+		}
+
+		if _, ok := dbs[db]; ok {
+			return true
+		}
+
+		_, ok := colls[Namespace{db, coll}]
+
+		return ok
+	}
+}
+
+// prepareReclone drops the target namespaces written since the last replicated change
+// of the failed change replication and replaces the clone and the change replication
+// to reclone them and to resume from the tracked time. It returns an error if the written
+// namespaces are not known.
+func (ml *PCSM) prepareReclone(ctx context.Context, tracker *gapTracker, since bson.Timestamp) error {
+	affected, startAt, err := tracker.affected(since)
+	if err != nil {
+		return err
+	}
+
+	lg := log.New("pcsm").With(log.OpTime(startAt.T, startAt.I))
+	ctx = lg.WithContext(ctx)
+
+	filter := recloneFilter(ml.nsFilter, affected)
+	dropped := 0
+
+	for _, ns := range affected {
+		colls := []string{ns.Collection}
+		if ns.Collection == "" {
+			colls = ml.catalog.collectionNames(ns.Database)
+		}
+
+		for _, coll := range colls {
+			if !filter(ns.Database, coll) {
+				continue
+			}
+
+			err = ml.catalog.DropCollection(ctx, ns.Database, coll)
+			if err != nil {
+				return errors.Wrapf(err, "drop %s.%s", ns.Database, coll)
+			}
+
+			dropped++
+		}
+	}
+
+	lg.Warnf("Oplog history is lost after %d.%d: recloning %d written namespaces (%d dropped on the target)",
+		since.T, since.I, len(affected), dropped)
+
+	clone := NewClone(ml.source, ml.target, ml.catalog, filter, ml.clone.options)
+	clone.startTS = startAt // the change replication resumes from the tracked time
+	clone.skipped = ml.clone.skippedCollections()
+
+	ml.lock.Lock()
+	ml.reclone = affected
+	ml.clone = clone
+	replFilter := replicateFilter(ml.nsFilter, ml.replInclude, ml.replExclude)
+	ml.repl = NewRepl(ml.source, ml.target, ml.catalog, excludeSkipped(replFilter, clone), ml.replOptions())
+	ml.lock.Unlock()
+
+	return nil
+}
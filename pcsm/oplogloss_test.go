@@ -0,0 +1,149 @@
+package pcsm //nolint
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseOplogLostAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want OplogLostAction
+	}{
+		{"", OplogLostFail},
+		{"fail", OplogLostFail},
+		{"reclone", OplogLostReclone},
+	}
+
+	for _, test := range tests {
+		got, err := ParseOplogLostAction(test.s)
+		if err != nil || got != test.want {
+			t.Errorf("%q: got = %q, %v, want %q", test.s, got, err, test.want)
+		}
+	}
+
+	if _, err := ParseOplogLostAction("resync"); err == nil {
+		t.Error("resync: got = nil, want error")
+	}
+}
+
+func TestGapTracker_Affected(t *testing.T) {
+	t.Parallel()
+
+	tracker := newGapTracker(bson.Timestamp{T: 100})
+
+	for _, ev := range []gapEvent{
+		{OperationType: Insert, Namespace: Namespace{"db_1", "coll_1"}, ClusterTime: bson.Timestamp{T: 101}},
+		{OperationType: Update, Namespace: Namespace{"db_1", "coll_2"}, ClusterTime: bson.Timestamp{T: 105}},
+		{
+			OperationType: Rename,
+			Namespace:     Namespace{"db_2", "coll_1"},
+			To:            &Namespace{"db_2", "coll_2"},
+			ClusterTime:   bson.Timestamp{T: 106},
+		},
+		{OperationType: DropDatabase, Namespace: Namespace{Database: "db_3"}, ClusterTime: bson.Timestamp{T: 110}},
+		{OperationType: Insert, Namespace: Namespace{"db_1", "coll_2"}, ClusterTime: bson.Timestamp{T: 111}},
+	} {
+		tracker.record(&ev)
+	}
+
+	namespaces, through, err := tracker.affected(bson.Timestamp{T: 105})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Namespace{{"db_1", "coll_2"}, {"db_2", "coll_1"}, {"db_2", "coll_2"}, {Database: "db_3"}}
+	if len(namespaces) != len(want) {
+		t.Fatalf("got = %v, want %v", namespaces, want)
+	}
+
+	for i := range want {
+		if namespaces[i] != want[i] {
+			t.Errorf("got = %v, want %v", namespaces, want)
+
+			break
+		}
+	}
+
+	if through != (bson.Timestamp{T: 111}) {
+		t.Errorf("through: got = %v, want 111", through)
+	}
+
+	if _, _, err := tracker.affected(bson.Timestamp{T: 99}); err == nil {
+		t.Error("before the tracking: got = nil, want error")
+	}
+
+	tracker.setFailed(errors.New("history lost"))
+
+	if _, _, err := tracker.affected(bson.Timestamp{T: 105}); err == nil {
+		t.Error("broken tracking: got = nil, want error")
+	}
+}
+
+func TestRecloneFilter(t *testing.T) {
+	t.Parallel()
+
+	filter := recloneFilter(makeNSFilter(nil, []string{"db_3.excluded"}), []Namespace{
+		{"db_1", "coll_1"},
+		{Database: "db_3"},
+	})
+
+	tests := []struct {
+		db, coll string
+		want     bool
+	}{
+		{"db_1", "coll_1", true},
+		{"db_1", "coll_2", false},
+		{"db_2", "coll_1", false},
+		{"db_3", "coll_1", true},
+		{"db_3", "excluded", false},
+	}
+
+	for _, test := range tests {
+		if got := filter(test.db, test.coll); got != test.want {
+			t.Errorf("%s.%s: got = %v, want %v", test.db, test.coll, got, test.want)
+		}
+	}
+}
+
+func TestGapTracker_OplogLost(t *testing.T) {
+	t.Parallel()
+
+	// the change replication applied up to 200 and fell off the oplog while
+	// the tracker read ahead to 300
+	tracker := newGapTracker(bson.Timestamp{T: 100})
+
+	tracker.record(&gapEvent{
+		OperationType: Insert,
+		Namespace:     Namespace{"db_1", "cold"},
+		ClusterTime:   bson.Timestamp{T: 150},
+	})
+	tracker.record(&gapEvent{
+		OperationType: Insert,
+		Namespace:     Namespace{"db_1", "hot"},
+		ClusterTime:   bson.Timestamp{T: 250},
+	})
+
+	tracker.lock.Lock()
+	tracker.advance(bson.Timestamp{T: 300})
+	tracker.lock.Unlock()
+
+	namespaces, through, err := tracker.affected(bson.Timestamp{T: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if through != (bson.Timestamp{T: 300}) {
+		t.Errorf("through: got = %v, want 300", through)
+	}
+
+	filter := recloneFilter(makeNSFilter(nil, nil), namespaces)
+	if filter("db_1", "cold") || !filter("db_1", "hot") {
+		t.Errorf("got = %v, want only db_1.hot recloned", namespaces)
+	}
+}
@@ -16,6 +16,8 @@ package pcsm
 import (
 	"context"
 	"math"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +42,9 @@ const (
 	StateIdle = "idle"
 	// StateRunning indicates that the pcsm is running.
 	StateRunning = "running"
+	// StateDegraded indicates that the change replication has failed within the failure
+	// grace period and is retried.
+	StateDegraded = "degraded"
 	// StatePaused indicates that the pcsm is paused.
 	StatePaused = "paused"
 	// StateFinalizing indicates that the pcsm is finalizing.
@@ -68,6 +73,25 @@ type Status struct {
 	Repl ReplStatus
 	// Clone is the status of the cloning process.
 	Clone CloneStatus
+
+	// IndexBuilds is the progress of the in-progress index builds on the target.
+	IndexBuilds []topo.IndexBuildProgress
+
+	// ClusterParameters is the result of the cluster parameters copy. Nil if not copied.
+	ClusterParameters *ClusterParametersReport
+
+	// Budget is the status of the max total duration. Nil if not set.
+	Budget *BudgetStatus
+}
+
+// Options represents the options of the PCSM that are set on the server start.
+type Options struct {
+	// UseCollectionBulkWrite forces the collection-level bulk write for the change replication.
+	// Required if the target client uses automatic encryption.
+	UseCollectionBulkWrite bool
+	// Memory bounds the clone read batches and the queued change events (--max-memory).
+	// The budget may be shared by the PCSM instances of the process. Nil does not limit the memory.
+	Memory *MemoryBudget
 }
 
 // PCSM manages the replication process.
@@ -75,10 +99,70 @@ type PCSM struct {
 	source *mongo.Client // Source MongoDB client
 	target *mongo.Client // Target MongoDB client
 
+	options Options
+
 	nsInclude []string
 	nsExclude []string
 	nsFilter  sel.NSFilter // Namespace filter
 
+	replInclude []string // replicate include patterns narrowing the replicated namespaces
+	replExclude []string // replicate exclude patterns narrowing the replicated namespaces
+
+	internalNamespaces []Namespace // internal collections replicated on demand
+
+	transforms []string       // transform specs
+	transform  TransformChain // transform applied to change events
+
+	replMethod    ReplicationMethod // resolved method of reading changes from the source
+	deadLetter    Namespace         // target namespace of the change events failed to apply
+	maxDocRetries int               // retries of a change failed to apply before the dead-letter
+	ddlAudit      Namespace         // target namespace of the applied DDL changes history
+	pauseOnDDL    bool              // hold DDL changes for the operator approval
+
+	skipDropDatabase bool // do not apply the dropDatabase changes on the target
+
+	applyOpTimeout time.Duration // deadline of a single apply write
+
+	failureGrace failureGrace // change replication failures retried before the replication fails
+
+	maxEventAge   time.Duration     // max age of the first change event after a reconnect
+	onStaleEvents StaleEventsAction // handling of the change events older than maxEventAge
+
+	onNestingExceeded NestingExceededAction // handling of the documents nested too deep for the target
+
+	onUnknownEvent UnknownEventAction // handling of the change events of an unknown operation type
+
+	maxTotalDuration time.Duration // max time since the start to reach a terminal state
+	budgetDeadline   time.Time     // time the max total duration is exceeded at
+	onBudgetExceeded BudgetAction  // handling of the replication exceeded the max total duration
+	budgetExceeded   bool          // the budget action has been taken
+	budgetDraining   bool          // the data clone is draining for the budget action
+
+	onOplogLost OplogLostAction // handling of the change replication fallen off the source oplog
+	reclone     []Namespace     // namespaces written in the lost oplog gap. Cloned again
+
+	changeStreamBatchSize    int32         // batch size of the change stream
+	changeStreamMaxAwaitTime time.Duration // max await time of the change stream
+
+	bypassDocumentValidation bool // skip the target document validation on apply
+	dedupWindow              int  // number of the recent applied changes to skip when read again
+	preserveTxnOrder         bool // apply a transaction in one bulk write
+	updateAsUpsert           bool // apply the updates as the upserts of the full-document image
+	ddlWorkers               int  // number of the DDL changes applied concurrently
+
+	copyClusterParameters bool                     // copy the cluster parameters before the clone
+	clusterParameters     *ClusterParametersReport // result of the cluster parameters copy
+
+	onKeyTooLong  KeyTooLongAction // handling of the index builds failed with too long keys
+	keepTargetTTL bool             // keep the target TTL indexes active during the replication
+
+	replicateHiddenIndexes bool // apply the hidden indexes on the target during the replication
+
+	renameCollisionSuffix string // suffix of the collections that replace the existing target collections
+	targetDatabasePrefix  string // prefix of the target database names
+
+	cloneManifest string // file of the clone progress of each namespace
+
 	onStateChanged OnStateChangedFunc // onStateChanged is invoked on each state change
 
 	pauseOnInitialSync bool
@@ -91,14 +175,20 @@ type PCSM struct {
 
 	err error
 
+	errorCount int64           // number of failures of the cluster replication
+	throughput throughputMeter // peak throughput of the processed documents and events
+
+	tail tailHub // subscribers of the change events read from the source
+
 	lock sync.Mutex
 }
 
 // New creates a new PCSM.
-func New(source, target *mongo.Client) *PCSM {
+func New(source, target *mongo.Client, options Options) *PCSM {
 	return &PCSM{
 		source:         source,
 		target:         target,
+		options:        options,
 		state:          StateIdle,
 		onStateChanged: func(State) {},
 	}
@@ -108,9 +198,65 @@ type checkpoint struct {
 	NSInclude []string `bson:"nsInclude,omitempty"`
 	NSExclude []string `bson:"nsExclude,omitempty"`
 
+	ReplInclude []string `bson:"replInclude,omitempty"`
+	ReplExclude []string `bson:"replExclude,omitempty"`
+
+	Transforms    []string          `bson:"transforms,omitempty"`
+	ReplMethod    ReplicationMethod `bson:"replMethod,omitempty"`
+	DeadLetter    string            `bson:"deadLetter,omitempty"`
+	MaxDocRetries int               `bson:"maxDocRetries,omitempty"`
+	DDLAudit      string            `bson:"ddlAudit,omitempty"`
+	PauseOnDDL    bool              `bson:"pauseOnDDL,omitempty"`
+
+	SkipDropDatabase bool `bson:"skipDropDatabase,omitempty"`
+
+	ApplyOpTimeout time.Duration `bson:"applyOpTimeout,omitempty"`
+
+	FailureGracePeriod time.Duration `bson:"failureGracePeriod,omitempty"`
+
+	MaxEventAge   time.Duration     `bson:"maxEventAge,omitempty"`
+	OnStaleEvents StaleEventsAction `bson:"onStaleEvents,omitempty"`
+
+	OnNestingExceeded NestingExceededAction `bson:"onNestingExceeded,omitempty"`
+
+	OnUnknownEvent UnknownEventAction `bson:"onUnknownEvent,omitempty"`
+
+	MaxTotalDuration time.Duration `bson:"maxTotalDuration,omitempty"`
+	BudgetDeadline   time.Time     `bson:"budgetDeadline,omitempty"`
+	OnBudgetExceeded BudgetAction  `bson:"onBudgetExceeded,omitempty"`
+	BudgetExceeded   bool          `bson:"budgetExceeded,omitempty"`
+
+	OnOplogLost OplogLostAction `bson:"onOplogLost,omitempty"`
+	Reclone     []Namespace     `bson:"reclone,omitempty"`
+
+	ChangeStreamBatchSize    int32         `bson:"changeStreamBatchSize,omitempty"`
+	ChangeStreamMaxAwaitTime time.Duration `bson:"changeStreamMaxAwaitTime,omitempty"`
+
+	BypassDocumentValidation bool `bson:"bypassDocumentValidation,omitempty"`
+	DedupWindow              int  `bson:"dedupWindow,omitempty"`
+	PreserveTxnOrder         bool `bson:"preserveTxnOrder,omitempty"`
+	UpdateAsUpsert           bool `bson:"updateAsUpsert,omitempty"`
+	DDLWorkers               int  `bson:"ddlWorkers,omitempty"`
+
+	CopyClusterParameters bool                     `bson:"copyClusterParameters,omitempty"`
+	ClusterParameters     *ClusterParametersReport `bson:"clusterParameters,omitempty"`
+
+	OnKeyTooLong  KeyTooLongAction `bson:"onKeyTooLong,omitempty"`
+	KeepTargetTTL bool             `bson:"keepTargetTTL,omitempty"`
+
+	ReplicateHiddenIndexes bool `bson:"replicateHiddenIndexes,omitempty"`
+
+	RenameCollisionSuffix string `bson:"renameCollisionSuffix,omitempty"`
+	TargetDatabasePrefix  string `bson:"targetDatabasePrefix,omitempty"`
+
+	CloneManifest string `bson:"cloneManifest,omitempty"`
+
+	InternalNamespaces []Namespace `bson:"internalNamespaces,omitempty"`
+
 	Catalog *catalogCheckpoint `bson:"catalog,omitempty"`
 	Clone   *cloneCheckpoint   `bson:"clone,omitempty"`
 	Repl    *replCheckpoint    `bson:"repl,omitempty"`
+	Stats   *statsCheckpoint   `bson:"stats,omitempty"`
 
 	State State  `bson:"state"`
 	Error string `bson:"error,omitempty"`
@@ -132,9 +278,68 @@ func (ml *PCSM) Checkpoint(context.Context) ([]byte, error) {
 		NSInclude: ml.nsInclude,
 		NSExclude: ml.nsExclude,
 
+		ReplInclude: ml.replInclude,
+		ReplExclude: ml.replExclude,
+
+		Transforms:    ml.transforms,
+		ReplMethod:    ml.replMethod,
+		DeadLetter:    ml.deadLetter.String(),
+		MaxDocRetries: ml.maxDocRetries,
+		DDLAudit:      ml.ddlAudit.String(),
+		PauseOnDDL:    ml.pauseOnDDL,
+
+		SkipDropDatabase: ml.skipDropDatabase,
+
+		ApplyOpTimeout: ml.applyOpTimeout,
+
+		FailureGracePeriod: ml.failureGrace.period,
+
+		MaxEventAge:   ml.maxEventAge,
+		OnStaleEvents: ml.onStaleEvents,
+
+		OnNestingExceeded: ml.onNestingExceeded,
+
+		OnUnknownEvent: ml.onUnknownEvent,
+
+		MaxTotalDuration: ml.maxTotalDuration,
+		BudgetDeadline:   ml.budgetDeadline,
+		OnBudgetExceeded: ml.onBudgetExceeded,
+		BudgetExceeded:   ml.budgetExceeded,
+
+		OnOplogLost: ml.onOplogLost,
+		Reclone:     ml.reclone,
+
+		ChangeStreamBatchSize:    ml.changeStreamBatchSize,
+		ChangeStreamMaxAwaitTime: ml.changeStreamMaxAwaitTime,
+
+		BypassDocumentValidation: ml.bypassDocumentValidation,
+		DedupWindow:              ml.dedupWindow,
+		PreserveTxnOrder:         ml.preserveTxnOrder,
+		UpdateAsUpsert:           ml.updateAsUpsert,
+		DDLWorkers:               ml.ddlWorkers,
+
+		CopyClusterParameters: ml.copyClusterParameters,
+		ClusterParameters:     ml.clusterParameters,
+
+		OnKeyTooLong:  ml.onKeyTooLong,
+		KeepTargetTTL: ml.keepTargetTTL,
+
+		ReplicateHiddenIndexes: ml.replicateHiddenIndexes,
+
+		RenameCollisionSuffix: ml.renameCollisionSuffix,
+		TargetDatabasePrefix:  ml.targetDatabasePrefix,
+
+		CloneManifest: ml.cloneManifest,
+
+		InternalNamespaces: ml.internalNamespaces,
+
 		Catalog: ml.catalog.Checkpoint(),
 		Clone:   ml.clone.Checkpoint(),
 		Repl:    ml.repl.Checkpoint(),
+		Stats: &statsCheckpoint{
+			Errors:         ml.errorCount,
+			PeakThroughput: ml.throughput.Peak(),
+		},
 
 		State: ml.state,
 	}
@@ -165,10 +370,70 @@ func (ml *PCSM) Recover(ctx context.Context, data []byte) error {
 		return nil
 	}
 
-	nsFilter := sel.MakeFilter(cp.NSInclude, cp.NSExclude)
-	catalog := NewCatalog(ml.target)
-	clone := NewClone(ml.source, ml.target, catalog, nsFilter)
-	repl := NewRepl(ml.source, ml.target, catalog, nsFilter)
+	nsFilter := allowInternalNamespaces(makeNSFilter(cp.NSInclude, cp.NSExclude), cp.InternalNamespaces)
+
+	transform, err := ParseTransforms(cp.Transforms)
+	if err != nil {
+		return errors.Wrap(err, "recover transform")
+	}
+
+	ml.transforms = cp.Transforms
+	ml.transform = transform
+	ml.replMethod = cp.ReplMethod
+	ml.maxDocRetries = cp.MaxDocRetries
+	ml.pauseOnDDL = cp.PauseOnDDL
+	ml.skipDropDatabase = cp.SkipDropDatabase
+	ml.applyOpTimeout = cp.ApplyOpTimeout
+	ml.failureGrace = failureGrace{period: cp.FailureGracePeriod}
+	ml.maxEventAge = cp.MaxEventAge
+	ml.onStaleEvents = cp.OnStaleEvents
+	ml.onNestingExceeded = cp.OnNestingExceeded
+	ml.onUnknownEvent = cp.OnUnknownEvent
+	ml.maxTotalDuration = cp.MaxTotalDuration
+	ml.budgetDeadline = cp.BudgetDeadline
+	ml.onBudgetExceeded = cp.OnBudgetExceeded
+	ml.budgetExceeded = cp.BudgetExceeded
+	ml.onOplogLost = cp.OnOplogLost
+	ml.reclone = cp.Reclone
+	ml.changeStreamBatchSize = cp.ChangeStreamBatchSize
+	ml.changeStreamMaxAwaitTime = cp.ChangeStreamMaxAwaitTime
+	ml.bypassDocumentValidation = cp.BypassDocumentValidation
+	ml.dedupWindow = cp.DedupWindow
+	ml.preserveTxnOrder = cp.PreserveTxnOrder
+	ml.updateAsUpsert = cp.UpdateAsUpsert
+	ml.ddlWorkers = cp.DDLWorkers
+	ml.copyClusterParameters = cp.CopyClusterParameters
+	ml.clusterParameters = cp.ClusterParameters
+	ml.onKeyTooLong = cp.OnKeyTooLong
+	ml.keepTargetTTL = cp.KeepTargetTTL
+	ml.replicateHiddenIndexes = cp.ReplicateHiddenIndexes
+	ml.renameCollisionSuffix = cp.RenameCollisionSuffix
+	ml.targetDatabasePrefix = cp.TargetDatabasePrefix
+	ml.cloneManifest = cp.CloneManifest
+	ml.internalNamespaces = cp.InternalNamespaces
+
+	ml.deadLetter, err = ParseDeadLetterNamespace(cp.DeadLetter)
+	if err != nil {
+		return errors.Wrap(err, "recover dead-letter namespace")
+	}
+
+	ml.ddlAudit, err = ParseDDLAuditNamespace(cp.DDLAudit)
+	if err != nil {
+		return errors.Wrap(err, "recover DDL audit namespace")
+	}
+
+	catalog := NewCatalog(ml.target, ml.catalogOptions())
+	cloneFilter := nsFilter
+	if len(ml.reclone) != 0 {
+		cloneFilter = recloneFilter(nsFilter, ml.reclone)
+	}
+
+	clone := NewClone(ml.source, ml.target, catalog, cloneFilter, CloneOptions{
+		Memory:             ml.options.Memory,
+		InternalNamespaces: ml.internalNamespaces,
+	})
+	replFilter := replicateFilter(nsFilter, cp.ReplInclude, cp.ReplExclude)
+	repl := NewRepl(ml.source, ml.target, catalog, excludeSkipped(replFilter, clone), ml.replOptions())
 
 	if cp.Catalog != nil {
 		err = catalog.Recover(cp.Catalog)
@@ -194,16 +459,27 @@ func (ml *PCSM) Recover(ctx context.Context, data []byte) error {
 	ml.nsInclude = cp.NSInclude
 	ml.nsExclude = cp.NSExclude
 	ml.nsFilter = nsFilter
+	ml.replInclude = cp.ReplInclude
+	ml.replExclude = cp.ReplExclude
 	ml.catalog = catalog
 	ml.clone = clone
 	ml.repl = repl
 	ml.state = cp.State
 
+	if cp.Stats != nil {
+		ml.errorCount = cp.Stats.Errors
+		ml.throughput.Recover(cp.Stats.PeakThroughput)
+	}
+
 	if cp.Error != "" {
 		ml.err = errors.New(cp.Error)
 	}
 
-	if cp.State == StateRunning {
+	if cp.State != StateFinalized && cp.State != StateFailed {
+		ml.watchBudget()
+	}
+
+	if cp.State == StateRunning || cp.State == StateDegraded {
 		return ml.doResume(ctx, false)
 	}
 
@@ -234,6 +510,10 @@ func (ml *PCSM) Status(ctx context.Context) *Status {
 		State: ml.state,
 		Clone: ml.clone.Status(),
 		Repl:  ml.repl.Status(),
+
+		ClusterParameters: ml.clusterParameters,
+
+		Budget: ml.budgetStatus(),
 	}
 
 	switch {
@@ -272,6 +552,12 @@ func (ml *PCSM) Status(ctx context.Context) *Status {
 		s.InitialSyncLagTime = s.TotalLagTime
 	}
 
+	s.IndexBuilds, err = topo.ListIndexBuildProgress(ctx, ml.target)
+	if err != nil {
+		// the target may not report the progress (e.g. missing inprog privilege)
+		log.New("pcsm").Error(err, "Status: get target index build progress")
+	}
+
 	return s
 }
 
@@ -289,15 +575,210 @@ type StartOptions struct {
 	IncludeNamespaces []string
 	// ExcludeNamespaces are the namespaces to exclude.
 	ExcludeNamespaces []string
+	// ReplicateInclude are the patterns of the included namespaces whose changes are replicated.
+	// The other included namespaces are cloned only. Empty replicates all included namespaces.
+	ReplicateInclude []string
+	// ReplicateExclude are the patterns of the included namespaces whose changes are not replicated.
+	ReplicateExclude []string
+
+	// MinOplogWindow is the minimum source oplog window required to start. Zero disables the check.
+	MinOplogWindow time.Duration
+	// IgnoreOplogWindow reports an insufficient oplog window without failing the start.
+	IgnoreOplogWindow bool
+	// RequireMatchingFCV fails the start if the target featureCompatibilityVersion is lower
+	// than the source. Otherwise, it is reported as a warning.
+	RequireMatchingFCV bool
+
+	// StartAt starts the change replication at the source timestamp without the clone
+	// (e.g. the restore point of a Percona Backup for MongoDB backup restored on the target).
+	// The timestamp must be in the source oplog. Zero clones the data.
+	StartAt bson.Timestamp
+
+	// CloneNoCursorTimeout disables the server idle timeout for the clone read cursors.
+	CloneNoCursorTimeout bool
+	// CloneBatchBytes is the maximum size in bytes of a clone copy batch, so that the collections
+	// with highly variable document sizes have a consistent memory use.
+	// Zero is [config.MaxWriteBatchSizeBytes].
+	CloneBatchBytes int
+	// CloneMaxInflightBatches is the maximum number of the clone insert batches of a collection
+	// not inserted yet, so that the memory of a collection copy is bounded. Zero does not limit them.
+	CloneMaxInflightBatches int
+	// CopyMarkerField is the field set on each cloned target document to the clone start
+	// timestamp. A restarted clone keeps the target collection and skips the stamped documents.
+	// The empty value does not stamp the documents.
+	CopyMarkerField string
+	// CappedTail is the number of the most recent documents to clone per capped collection
+	// namespace (e.g. "db.log": 1000).
+	CappedTail map[string]int64
+	// CloneSnapshot is the read consistency mode of the collection copy.
+	CloneSnapshot CloneSnapshotMode
+	// CloneOrder is the order in which the collections are cloned.
+	CloneOrder CloneOrder
+	// CloneReadConcern is the read concern level of the collection copy reads.
+	// The empty value is the read concern of the source connection ([CloneReadConcernMajority]).
+	CloneReadConcern CloneReadConcern
+	// DisableBalancerDuringClone stops the balancer of the sharded target during the clone
+	// and starts it after the clone.
+	DisableBalancerDuringClone bool
+	// SkipEmptyCollections skips the clone of the collections without documents.
+	// By default, empty collections are created on the target with their options and indexes.
+	SkipEmptyCollections bool
+	// DiscoverNewCollections copies the source collections created during the clone.
+	// By default, such collections are created by the change replication.
+	DiscoverNewCollections bool
+	// CloneDependencies are the namespaces cloned before the namespaces that depend on them
+	// (e.g. the reference data of a $lookup view).
+	CloneDependencies []CloneDependency
+	// OnCollectionError is the handling of a collection that fails to clone.
+	// The empty value is [CollectionErrorFail].
+	OnCollectionError CollectionErrorAction
+	// OnSchemaDrift keeps the source collections that exist on the target and handles their
+	// options that differ from the source. The empty value drops and recreates them.
+	OnSchemaDrift SchemaDriftAction
+
+	// Transforms are the specs of the transforms applied to change events (e.g. "noop").
+	Transforms []string
+	// MaxClockSkew reports the date and timestamp values of the replicated documents later than
+	// the cluster time of the change by more than the duration. Zero disables the check.
+	MaxClockSkew time.Duration
+
+	// ReplicationMethod is the method of reading changes from the source.
+	// The empty value is [ReplicationAuto].
+	ReplicationMethod ReplicationMethod
+
+	// DeadLetterNamespace is the target namespace ("db.collection") to store the change events
+	// that fail to apply. Empty disables the dead-letter collection.
+	DeadLetterNamespace string
+	// MaxDocRetries is the number of retries of a change event that fails to apply with
+	// a write error before it is stored in the dead-letter collection. Requires DeadLetterNamespace.
+	MaxDocRetries int
+	// DDLAuditCollection is the target namespace ("db.collection") to record each applied DDL
+	// change with its cluster time and details. Empty disables the audit.
+	DDLAuditCollection string
+
+	// PauseOnDDL pauses the replication on each create, drop, rename, or collMod change
+	// until the change is approved with [PCSM.ApproveDDL].
+	PauseOnDDL bool
+
+	// SkipDropDatabase does not apply the dropDatabase changes on the target. The drops of
+	// the collections of the database are applied.
+	SkipDropDatabase bool
+
+	// ApplyOpTimeout is the deadline of a single apply write to the target. A write that does
+	// not complete in time is aborted and retried. Zero disables the deadline.
+	ApplyOpTimeout time.Duration
+
+	// FailureGracePeriod is the period of the change replication failures retried before
+	// the replication fails. A failure within it marks the replication degraded. Zero fails
+	// on the first failure.
+	FailureGracePeriod time.Duration
+
+	// MaxEventAge is the maximum age of the first change event received after a reconnect.
+	// An older event indicates a long outage and is handled by OnStaleEvents instead of
+	// catching up silently. Zero disables the check.
+	MaxEventAge time.Duration
+	// OnStaleEvents is the handling of the change events older than MaxEventAge.
+	// The empty value is [StaleEventsWarn].
+	OnStaleEvents StaleEventsAction
+
+	// OnNestingExceeded is the handling of the documents nested deeper than the target limit
+	// of 100 levels. The offending _id is reported. The empty value disables the check.
+	OnNestingExceeded NestingExceededAction
+
+	// OnUnknownEvent is the handling of the change events of an operation type unknown to
+	// the change replication, e.g. introduced by a newer MongoDB version.
+	// The empty value is [UnknownEventLog].
+	OnUnknownEvent UnknownEventAction
+
+	// MaxTotalDuration is the max time since the start for the replication to be finalized.
+	// The replication still running then is handled by OnBudgetExceeded. Zero does not limit.
+	MaxTotalDuration time.Duration
+	// OnBudgetExceeded is the handling of the replication that has exceeded MaxTotalDuration.
+	// The empty value is [BudgetAbort].
+	OnBudgetExceeded BudgetAction
+
+	// OnOplogLost is the handling of the change replication that falls off the source oplog.
+	// [OplogLostReclone] clones again the namespaces written since the last replicated change
+	// and resumes the replication. The empty value is [OplogLostFail].
+	OnOplogLost OplogLostAction
+
+	// ChangeStreamBatchSize is the batch size of the source change stream.
+	// Zero uses the default.
+	ChangeStreamBatchSize int32
+	// ChangeStreamMaxAwaitTime is the maximum time the source change stream waits for new changes
+	// before returning an empty batch. Zero uses the default.
+	ChangeStreamMaxAwaitTime time.Duration
+
+	// BypassDocumentValidation skips the document validation of the target collections
+	// when the changes are applied (e.g. the validators are stricter than the source data).
+	// The clone always bypasses the document validation.
+	BypassDocumentValidation bool
+
+	// DedupWindow is the number of the most recent applied CRUD changes remembered to skip
+	// the changes read again after a reconnect of the change stream. Zero disables it.
+	DedupWindow int
+
+	// PreserveTxnOrder applies the operations of a transaction in one bulk write
+	// in the order of applyOps.
+	PreserveTxnOrder bool
+
+	// UpdateAsUpsert applies the updates as the upserts of the full document looked up by
+	// the change stream (updateLookup), so an update of a document missing on the target creates it.
+	// Requires the change stream method.
+	UpdateAsUpsert bool
+
+	// DDLWorkers is the number of the index builds, index drops, and collMod changes applied
+	// concurrently with the changes of the other namespaces. The changes of the same namespace
+	// are applied after them. Zero applies the DDL changes one by one in order.
+	DDLWorkers int
+
+	// CopyClusterParameters copies the supported cluster parameters of the source
+	// (e.g. defaultMaxTimeMS) to the target before the clone.
+	CopyClusterParameters bool
+
+	// OnKeyTooLong is the handling of an index build that fails because of the keys longer than
+	// the index key limit of the target (MongoDB 4.0 and earlier). The empty value is [KeyTooLongSkip].
+	OnKeyTooLong KeyTooLongAction
+
+	// KeepTargetTTL keeps the TTL indexes of the target active during the replication.
+	// By default, the target TTL indexes do not expire documents until finalization and
+	// the TTL deletes of the source are replicated as delete changes.
+	KeepTargetTTL bool
+
+	// ReplicateHiddenIndexes creates the hidden indexes as hidden on the target and applies
+	// the hidden toggles of collMod during the replication, so the target query plans match
+	// the source. By default, the indexes are visible on the target until finalization.
+	ReplicateHiddenIndexes bool
+
+	// RenameCollisionSuffix is the suffix of the collections that the existing target collections
+	// are cloned into. The suffixed collections replace the existing collections on finalization,
+	// so the existing collections stay readable during the clone and the replication.
+	// By default, the existing target collections are dropped before the clone.
+	RenameCollisionSuffix string
+
+	// TargetDatabasePrefix is prepended to the name of each target database, so that
+	// the source database "app" is cloned and replicated into the "<prefix>app" database.
+	TargetDatabasePrefix string
+
+	// CloneManifest is the file of the clone progress of each namespace updated during the clone.
+	// [PCSM.Resume] with the manifest continues the clone when the recovery data is lost.
+	CloneManifest string
+
+	// InternalNamespaces are the internal collections replicated on demand by the exact name
+	// (e.g. "config.system.sessions"). By default, the admin and config collections are never
+	// replicated, even if an include pattern matches them ([ParseInternalNamespaces]).
+	// The change streams do not report their changes: they are only copied by the clone
+	// unless the replication method is [ReplicationOplog].
+	InternalNamespaces []string
 }
 
 // Start starts the replication process with the given options.
-func (ml *PCSM) Start(_ context.Context, options *StartOptions) error {
+func (ml *PCSM) Start(ctx context.Context, options *StartOptions) error {
 	ml.lock.Lock()
 	defer ml.lock.Unlock()
 
 	switch ml.state {
-	case StateRunning, StateFinalizing, StateFailed:
+	case StateRunning, StateDegraded, StateFinalizing, StateFailed:
 		err := errors.New("already running")
 		log.New("pcsm:start").Error(err, "")
 
@@ -314,24 +795,311 @@ func (ml *PCSM) Start(_ context.Context, options *StartOptions) error {
 		options = &StartOptions{}
 	}
 
+	transforms := options.Transforms
+	if options.MaxClockSkew > 0 {
+		transforms = append(slices.Clone(transforms), "max-clock-skew:"+options.MaxClockSkew.String())
+	}
+
+	transform, err := ParseTransforms(transforms)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Invalid transform")
+
+		return errors.Wrap(err, "transform")
+	}
+
+	deadLetter, err := ParseDeadLetterNamespace(options.DeadLetterNamespace)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Invalid dead-letter namespace")
+
+		return err
+	}
+
+	ddlAudit, err := ParseDDLAuditNamespace(options.DDLAuditCollection)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Invalid DDL audit namespace")
+
+		return err
+	}
+
+	if errs := ValidateNamespacePatterns(options.ReplicateInclude, options.ReplicateExclude); len(errs) != 0 {
+		err = errors.Wrap(errors.Join(errs...), "replicate namespaces")
+		log.New("pcsm:start").Error(err, "Invalid replicate namespaces")
+
+		return err
+	}
+
+	if options.CloneSnapshot == CloneSnapshotSession &&
+		options.CloneReadConcern != "" && options.CloneReadConcern != CloneReadConcernSnapshot {
+		err = errors.Errorf("clone read concern %q: the snapshot session reads with %q",
+			options.CloneReadConcern, CloneReadConcernSnapshot)
+		log.New("pcsm:start").Error(err, "Invalid clone read concern")
+
+		return err
+	}
+
+	if strings.ContainsAny(options.RenameCollisionSuffix, InvalidCollectionNameChars) {
+		err = errors.Errorf("rename collision suffix %q: invalid in a collection name",
+			options.RenameCollisionSuffix)
+		log.New("pcsm:start").Error(err, "Invalid rename collision suffix")
+
+		return err
+	}
+
+	if options.OnSchemaDrift != "" && options.RenameCollisionSuffix != "" {
+		err = errors.Errorf("schema drift action %q: the existing collections are replaced "+
+			"with the rename collision suffix", options.OnSchemaDrift)
+		log.New("pcsm:start").Error(err, "Invalid schema drift action")
+
+		return err
+	}
+
+	internalNamespaces, err := ParseInternalNamespaces(options.InternalNamespaces)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Invalid internal namespace")
+
+		return err
+	}
+
+	if options.MaxDocRetries != 0 && deadLetter.Database == "" {
+		err = errors.Wrap(ErrNoDeadLetterNamespace, "max doc retries")
+		log.New("pcsm:start").Error(err, "Invalid max doc retries")
+
+		return err
+	}
+
+	err = ml.preflight(ctx, options)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Preflight check failed")
+
+		return errors.Wrap(err, "preflight")
+	}
+
+	replMethod, err := ml.selectReplicationMethod(ctx, options.ReplicationMethod)
+	if err != nil {
+		log.New("pcsm:start").Error(err, "Select replication method")
+
+		return errors.Wrap(err, "replication method")
+	}
+
+	if options.OnOplogLost == OplogLostReclone && replMethod == ReplicationOplog {
+		// the written namespaces are tracked with a change stream
+		err = errors.Errorf("oplog lost action %q: requires change streams", OplogLostReclone)
+		log.New("pcsm:start").Error(err, "Invalid oplog lost action")
+
+		return err
+	}
+
+	if options.UpdateAsUpsert && replMethod == ReplicationOplog {
+		// the oplog entries of the updates have no full document
+		err = errors.New("update as upsert: requires change streams")
+		log.New("pcsm:start").Error(err, "Invalid update as upsert")
+
+		return err
+	}
+
+	if len(internalNamespaces) != 0 && replMethod != ReplicationOplog {
+		log.New("pcsm:start").Warnf("The changes of the internal namespaces are not replicated "+
+			"with %q: they are only copied by the clone", replMethod)
+	}
+
 	ml.nsInclude = options.IncludeNamespaces
 	ml.nsExclude = options.ExcludeNamespaces
-	ml.nsFilter = sel.MakeFilter(ml.nsInclude, ml.nsExclude)
+	ml.nsFilter = allowInternalNamespaces(makeNSFilter(ml.nsInclude, ml.nsExclude), internalNamespaces)
+	ml.replInclude = options.ReplicateInclude
+	ml.replExclude = options.ReplicateExclude
+	ml.internalNamespaces = internalNamespaces
 	ml.pauseOnInitialSync = options.PauseOnInitialSync
-	ml.catalog = NewCatalog(ml.target)
-	ml.clone = NewClone(ml.source, ml.target, ml.catalog, ml.nsFilter)
-	ml.repl = NewRepl(ml.source, ml.target, ml.catalog, ml.nsFilter)
+	ml.transforms = transforms
+	ml.transform = transform
+	ml.replMethod = replMethod
+	ml.deadLetter = deadLetter
+	ml.maxDocRetries = options.MaxDocRetries
+	ml.ddlAudit = ddlAudit
+	ml.pauseOnDDL = options.PauseOnDDL
+	ml.skipDropDatabase = options.SkipDropDatabase
+	ml.applyOpTimeout = options.ApplyOpTimeout
+	ml.failureGrace = failureGrace{period: options.FailureGracePeriod}
+	ml.maxEventAge = options.MaxEventAge
+	ml.onStaleEvents = options.OnStaleEvents
+	ml.onNestingExceeded = options.OnNestingExceeded
+	ml.onUnknownEvent = options.OnUnknownEvent
+	ml.maxTotalDuration = options.MaxTotalDuration
+	ml.onBudgetExceeded = options.OnBudgetExceeded
+	ml.budgetDeadline = time.Time{}
+	ml.budgetExceeded = false
+	ml.budgetDraining = false
+	ml.onOplogLost = options.OnOplogLost
+	ml.reclone = nil
+	ml.changeStreamBatchSize = options.ChangeStreamBatchSize
+	ml.changeStreamMaxAwaitTime = options.ChangeStreamMaxAwaitTime
+	ml.bypassDocumentValidation = options.BypassDocumentValidation
+	ml.dedupWindow = options.DedupWindow
+	ml.preserveTxnOrder = options.PreserveTxnOrder
+	ml.updateAsUpsert = options.UpdateAsUpsert
+	ml.ddlWorkers = options.DDLWorkers
+	ml.copyClusterParameters = options.CopyClusterParameters
+	ml.clusterParameters = nil
+	ml.onKeyTooLong = options.OnKeyTooLong
+	ml.keepTargetTTL = options.KeepTargetTTL
+	ml.replicateHiddenIndexes = options.ReplicateHiddenIndexes
+	ml.renameCollisionSuffix = options.RenameCollisionSuffix
+	ml.targetDatabasePrefix = options.TargetDatabasePrefix
+	ml.cloneManifest = options.CloneManifest
+	ml.catalog = NewCatalog(ml.target, ml.catalogOptions())
+	ml.clone = NewClone(ml.source, ml.target, ml.catalog, ml.nsFilter, CloneOptions{
+		NoCursorTimeout: options.CloneNoCursorTimeout,
+		BatchSizeBytes:  options.CloneBatchBytes,
+		MarkerField:     options.CopyMarkerField,
+		CappedTail:      options.CappedTail,
+		Snapshot:        options.CloneSnapshot,
+		Order:           options.CloneOrder,
+		ReadConcern:     options.CloneReadConcern,
+		DisableBalancer: options.DisableBalancerDuringClone,
+
+		SkipEmptyCollections:   options.SkipEmptyCollections,
+		DiscoverNewCollections: options.DiscoverNewCollections,
+
+		OnNestingExceeded: options.OnNestingExceeded,
+		Dependencies:      options.CloneDependencies,
+		OnCollectionError: options.OnCollectionError,
+		OnSchemaDrift:     options.OnSchemaDrift,
+
+		MaxInflightBatches: options.CloneMaxInflightBatches,
+		Memory:             ml.options.Memory,
+		InternalNamespaces: internalNamespaces,
+	})
+	replFilter := replicateFilter(ml.nsFilter, ml.replInclude, ml.replExclude)
+	ml.repl = NewRepl(ml.source, ml.target, ml.catalog, excludeSkipped(replFilter, ml.clone), ml.replOptions())
 	ml.state = StateRunning
 
+	if !options.StartAt.IsZero() {
+		ml.clone.Skip(options.StartAt)
+	}
+	ml.errorCount = 0
+	ml.throughput = throughputMeter{}
+
+	if ml.maxTotalDuration > 0 {
+		ml.budgetDeadline = time.Now().Add(ml.maxTotalDuration)
+		ml.watchBudget()
+	}
+
 	go ml.run()
 
 	return nil
 }
 
+func (ml *PCSM) catalogOptions() CatalogOptions {
+	return CatalogOptions{
+		OnKeyTooLong:  ml.onKeyTooLong,
+		KeepTargetTTL: ml.keepTargetTTL,
+
+		ReplicateHiddenIndexes: ml.replicateHiddenIndexes,
+
+		RenameCollisionSuffix: ml.renameCollisionSuffix,
+		TargetDatabasePrefix:  ml.targetDatabasePrefix,
+	}
+}
+
+func (ml *PCSM) replOptions() ReplOptions {
+	return ReplOptions{
+		UseCollectionBulkWrite: ml.options.UseCollectionBulkWrite,
+		Transform:              ml.transform,
+		Method:                 ml.replMethod,
+		DeadLetter:             ml.deadLetter,
+		MaxDocRetries:          ml.maxDocRetries,
+		DDLAudit:               ml.ddlAudit,
+		PauseOnDDL:             ml.pauseOnDDL,
+		SkipDropDatabase:       ml.skipDropDatabase,
+		ApplyOpTimeout:         ml.applyOpTimeout,
+		MaxEventAge:            ml.maxEventAge,
+		OnStaleEvents:          ml.onStaleEvents,
+		OnNestingExceeded:      ml.onNestingExceeded,
+		OnUnknownEvent:         ml.onUnknownEvent,
+
+		ChangeStreamBatchSize:    ml.changeStreamBatchSize,
+		ChangeStreamMaxAwaitTime: ml.changeStreamMaxAwaitTime,
+		BypassDocumentValidation: ml.bypassDocumentValidation,
+		DedupWindow:              ml.dedupWindow,
+		PreserveTxnOrder:         ml.preserveTxnOrder,
+		UpdateAsUpsert:           ml.updateAsUpsert,
+		DDLWorkers:               ml.ddlWorkers,
+
+		Tap:    ml.tail.publish,
+		Memory: ml.options.Memory,
+
+		InternalNamespaces: ml.internalNamespaces,
+	}
+}
+
+// Tail returns the change events read from the source before they are applied, as relaxed
+// extended JSON documents, until the context is done. The channel is closed then.
+// The events are dropped if the receiver does not keep up.
+func (ml *PCSM) Tail(ctx context.Context, options TailOptions) <-chan []byte {
+	return ml.tail.subscribe(ctx, options)
+}
+
+// ReplayDeadLetter applies the change events stored in the dead-letter collection to the target.
+// The namespace overrides the dead-letter namespace set on start.
+func (ml *PCSM) ReplayDeadLetter(ctx context.Context, namespace string) (*ReplayResult, error) {
+	dl, err := ParseDeadLetterNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ml.lock.Lock()
+	if dl.Database == "" {
+		dl = ml.deadLetter
+	}
+
+	bulkOptions := ml.replOptions().bulkOptions()
+	ml.lock.Unlock()
+
+	if dl.Database == "" {
+		return nil, ErrNoDeadLetterNamespace
+	}
+
+	lg := log.New("pcsm:replay").With(log.NS(dl.Database, dl.Collection))
+
+	res, err := replayDeadLetter(lg.WithContext(ctx), ml.target, dl, bulkOptions)
+	if err != nil {
+		return res, errors.Wrap(err, "replay")
+	}
+
+	lg.Infof("Dead-letter replay: %d replayed, %d failed", res.Replayed, res.Failed)
+
+	return res, nil
+}
+
+// selectReplicationMethod resolves the replication method supported by the source.
+func (ml *PCSM) selectReplicationMethod(
+	ctx context.Context,
+	method ReplicationMethod,
+) (ReplicationMethod, error) {
+	caps, err := detectSourceCapabilities(ctx, ml.source)
+	if err != nil {
+		return "", errors.Wrap(err, "detect source capabilities")
+	}
+
+	resolved, err := resolveReplicationMethod(method, caps)
+	if err != nil {
+		return "", err
+	}
+
+	lg := log.New("pcsm:start")
+	if resolved == ReplicationOplog && method != ReplicationOplog {
+		lg.Warn("Change streams are unavailable on the source. Fall back to oplog tailing")
+	}
+
+	lg.Infof("Replication method: %s", resolved)
+
+	return resolved, nil
+}
+
 func (ml *PCSM) setFailed(err error) {
 	ml.lock.Lock()
 	ml.state = StateFailed
 	ml.err = err
+	ml.errorCount++
 	ml.lock.Unlock()
 
 	log.New("pcsm").Error(err, "Cluster Replication has failed")
@@ -348,6 +1116,25 @@ func (ml *PCSM) run() {
 
 	lg.Info("Starting Cluster Replication")
 
+	go ml.monitorThroughput(ctx)
+
+	ml.lock.Lock()
+	copyParams := ml.copyClusterParameters && ml.clusterParameters == nil
+	ml.lock.Unlock()
+
+	if copyParams {
+		report := copyClusterParameters(lg.WithContext(ctx),
+			mongoClusterParameters{ml.source}, mongoClusterParameters{ml.target}, clusterParameterNames)
+
+		ml.lock.Lock()
+		ml.clusterParameters = &report
+		ml.lock.Unlock()
+	}
+
+	ml.lock.Lock()
+	manifest := ml.cloneManifest
+	ml.lock.Unlock()
+
 	cloneStatus := ml.clone.Status()
 	if !cloneStatus.IsFinished() {
 		err := ml.clone.Start(ctx)
@@ -357,17 +1144,64 @@ func (ml *PCSM) run() {
 			return
 		}
 
+		stopManifest := ml.startCloneManifest(manifest)
+
 		<-ml.clone.Done()
 
+		stopManifest()
+
 		cloneStatus = ml.clone.Status()
+		if cloneStatus.Interrupted {
+			ml.pauseOnAuthFailed(errors.Wrap(cloneStatus.Err, "clone"))
+
+			return
+		}
+
 		if cloneStatus.Err != nil {
 			ml.setFailed(errors.Wrap(cloneStatus.Err, "clone"))
 
 			return
 		}
+
+		if cloneStatus.Drained {
+			ml.lock.Lock()
+			if ml.budgetDraining {
+				ml.applyBudgetAction()
+				ml.lock.Unlock()
+
+				lg.Warn("Cluster Replication stopped: Data Clone drained for the max total duration")
+
+				return
+			}
+
+			ml.state = StatePaused
+			ml.lock.Unlock()
+
+			lg.Info("Cluster Replication paused: Data Clone drained")
+
+			go ml.onStateChanged(StatePaused)
+
+			return
+		}
 	}
 
 	replStatus := ml.repl.Status()
+
+	ml.lock.Lock()
+	trackGap := ml.onOplogLost == OplogLostReclone
+	ml.lock.Unlock()
+
+	var tracker *gapTracker
+	if trackGap {
+		startAt := cloneStatus.StartTS
+		if replStatus.IsStarted() {
+			startAt = replStatus.LastReplicatedOpTime
+		}
+
+		tracker = newGapTracker(startAt)
+		go tracker.run(ctx, ml.source)
+	}
+
 	if !replStatus.IsStarted() {
 		err := ml.repl.Start(ctx, cloneStatus.StartTS)
 		if err != nil {
@@ -378,7 +1212,7 @@ func (ml *PCSM) run() {
 	} else {
 		err := ml.repl.Resume(ctx)
 		if err != nil {
-			ml.setFailed(errors.Wrap(err, "resume change replication"))
+			ml.failOrDegrade(errors.Wrap(err, "resume change replication"))
 
 			return
 		}
@@ -388,12 +1222,48 @@ func (ml *PCSM) run() {
 		go ml.monitorInitialSync(ctx)
 	}
 	go ml.monitorLagTime(ctx)
+	go ml.watchDegraded(ctx)
 
 	<-ml.repl.Done()
 
 	replStatus = ml.repl.Status()
 	if replStatus.Err != nil {
-		ml.setFailed(errors.Wrap(replStatus.Err, "change replication"))
+		if topo.IsAuthenticationFailed(replStatus.Err) {
+			ml.pauseOnAuthFailed(errors.Wrap(replStatus.Err, "change replication"))
+
+			return
+		}
+
+		if tracker != nil && errors.Is(replStatus.Err, ErrOplogHistoryLost) {
+			since := replStatus.LastReplicatedOpTime
+			if since.IsZero() {
+				since = cloneStatus.StartTS
+			}
+
+			err := ml.prepareReclone(ctx, tracker, since)
+			if err == nil {
+				go ml.run()
+
+				return
+			}
+
+			lg.Error(err, "Reclone the namespaces written in the lost oplog")
+		}
+
+		ml.failOrDegrade(errors.Wrap(replStatus.Err, "change replication"))
+
+		return
+	}
+
+	if ddl := replStatus.PendingDDL; ddl != nil {
+		ml.lock.Lock()
+		ml.state = StatePaused
+		ml.lock.Unlock()
+
+		lg.Warnf("Cluster Replication paused: %s on %q is pending approval",
+			ddl.OperationType, ddl.Namespace.String())
+
+		go ml.onStateChanged(StatePaused)
 	}
 }
 
@@ -507,6 +1377,27 @@ func (ml *PCSM) monitorLagTime(ctx context.Context) {
 	}
 }
 
+// Drain stops the cluster replication after the collections being copied by the clone are
+// completed. The clone does not start new collections. The cluster replication is paused then
+// and continues the clone from the remaining collections on resume.
+func (ml *PCSM) Drain(context.Context) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state != StateRunning {
+		return errors.New("cannot drain: not running")
+	}
+
+	err := ml.clone.Drain()
+	if err != nil {
+		return errors.Wrap(err, "cannot drain: Data Clone")
+	}
+
+	log.New("pcsm").Info("Draining Data Clone")
+
+	return nil
+}
+
 // Pause pauses the replication process.
 func (ml *PCSM) Pause(ctx context.Context) error {
 	ml.lock.Lock()
@@ -546,8 +1437,50 @@ func (ml *PCSM) doPause(ctx context.Context) error {
 	return nil
 }
 
+// PauseNamespace pauses the change replication of the namespaces matching the pattern
+// ("db.collection" or "db.*"). The other namespaces are replicated.
+func (ml *PCSM) PauseNamespace(_ context.Context, pattern string) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state != StateRunning && ml.state != StatePaused {
+		return errors.New("cannot pause namespace: not running")
+	}
+
+	err := ml.repl.PauseNamespace(pattern)
+	if err != nil {
+		log.New("pcsm").Errorf(err, "Pause namespace %q", pattern)
+
+		return errors.Wrap(err, "cannot pause namespace")
+	}
+
+	return nil
+}
+
+// ResumeNamespace resumes the change replication of the namespaces paused with the pattern.
+func (ml *PCSM) ResumeNamespace(_ context.Context, pattern string) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.repl == nil {
+		return errors.New("cannot resume namespace: not started")
+	}
+
+	err := ml.repl.ResumeNamespace(pattern)
+	if err != nil {
+		log.New("pcsm").Errorf(err, "Resume namespace %q", pattern)
+
+		return errors.Wrap(err, "cannot resume namespace")
+	}
+
+	return nil
+}
+
 type ResumeOptions struct {
 	ResumeFromFailure bool
+	// Manifest is the clone manifest file ([StartOptions.CloneManifest]) to resume the replication
+	// of the idle PCSM from when the recovery data is lost.
+	Manifest string
 }
 
 // Resume resumes the replication process.
@@ -555,10 +1488,23 @@ func (ml *PCSM) Resume(ctx context.Context, options ResumeOptions) error {
 	ml.lock.Lock()
 	defer ml.lock.Unlock()
 
+	if options.Manifest != "" {
+		err := ml.resumeFromManifest(ctx, options.Manifest)
+		if err != nil {
+			log.New("pcsm").Error(err, "Resume Cluster Replication")
+		}
+
+		return err
+	}
+
 	if ml.state != StatePaused && !(ml.state == StateFailed && options.ResumeFromFailure) {
 		return errors.New("cannot resume: not paused or not resuming from failure")
 	}
 
+	if ml.repl.Status().PendingDDL != nil {
+		return errors.New("cannot resume: DDL change is pending approval")
+	}
+
 	err := ml.doResume(ctx, options.ResumeFromFailure)
 	if err != nil {
 		log.New("pcsm").Error(err, "Resume Cluster Replication")
@@ -574,7 +1520,8 @@ func (ml *PCSM) Resume(ctx context.Context, options ResumeOptions) error {
 func (ml *PCSM) doResume(_ context.Context, fromFailure bool) error {
 	replStatus := ml.repl.Status()
 
-	if !replStatus.IsStarted() && !fromFailure {
+	cloneStatus := ml.clone.Status()
+	if !replStatus.IsStarted() && !fromFailure && !cloneStatus.Drained && !cloneStatus.Interrupted {
 		return errors.New("cannot resume: replication is not started or not resuming from failure")
 	}
 
@@ -591,8 +1538,46 @@ func (ml *PCSM) doResume(_ context.Context, fromFailure bool) error {
 	return nil
 }
 
+// ApproveDDL approves the pending DDL change and resumes the replication.
+func (ml *PCSM) ApproveDDL(ctx context.Context) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state != StatePaused {
+		return errors.New("cannot approve DDL: not paused")
+	}
+
+	ddl, err := ml.repl.ApproveDDL()
+	if err != nil {
+		return errors.Wrap(err, "cannot approve DDL")
+	}
+
+	log.New("pcsm").Infof("Approved %s on %q", ddl.OperationType, ddl.Namespace.String())
+
+	err = ml.doResume(ctx, false)
+	if err != nil {
+		log.New("pcsm").Error(err, "Resume Cluster Replication")
+
+		return err
+	}
+
+	log.New("pcsm").Info("Cluster Replication resumed")
+
+	return nil
+}
+
 type FinalizeOptions struct {
 	IgnoreHistoryLost bool
+	// LockSource locks the source writes with fsyncLock and waits for the change replication
+	// to apply the last source write before it is paused. The source is unlocked if
+	// the finalization fails.
+	LockSource bool
+	// SourceLocked waits for the change replication to apply the last write of the source locked
+	// by the finalization of another target before it is paused.
+	SourceLocked bool
+	// SourceURI is the connection string of the source. On a sharded source, the last write is
+	// read from the oplog of each shard connected with it.
+	SourceURI string
 }
 
 // Finalize finalizes the replication process.
@@ -620,25 +1605,42 @@ func (ml *PCSM) Finalize(ctx context.Context, options FinalizeOptions) error {
 		return errors.New("initial sync is not completed")
 	}
 
+	if status.Repl.PendingDDL != nil {
+		return errors.New("DDL change is pending approval")
+	}
+
+	if len(status.Repl.PausedNamespaces) != 0 || status.Repl.HeldChanges != 0 {
+		return errors.Errorf("namespaces are paused: %q (%d held changes)",
+			status.Repl.PausedNamespaces, status.Repl.HeldChanges)
+	}
+
 	lg := log.New("finalize")
 	lg.Info("Starting Finalization")
 
-	if status.Repl.IsRunning() {
-		lg.Info("Pausing Change Replication")
+	var srcLock *sourceLock
+
+	locker := fsyncLocker{client: ml.source, uri: options.SourceURI}
+
+	if options.LockSource {
+		var err error
 
-		err := ml.repl.Pause(ctx)
+		srcLock, err = lockSource(ctx, locker)
 		if err != nil {
-			return errors.Wrap(err, "pause change replication")
+			return err
 		}
+	}
 
-		<-ml.repl.Done()
-		lg.Info("Change Replication is paused")
+	if options.LockSource || options.SourceLocked {
+		err := ml.catchUpLockedSource(ctx, locker)
+		if err != nil {
+			return srcLock.unlockOnError(err)
+		}
+	}
 
-		err = ml.repl.Status().Err
+	if status.Repl.IsRunning() {
+		err := ml.pauseForFinalize(ctx)
 		if err != nil {
-			// no need to set the PCSM failed status here.
-			// [PCSM.setFailed] is called in [PCSM.run].
-			return errors.Wrap(err, "post-pause change replication")
+			return srcLock.unlockOnError(err)
 		}
 	}
 
@@ -648,7 +1650,7 @@ func (ml *PCSM) Finalize(ctx context.Context, options FinalizeOptions) error {
 	go func() {
 		err := ml.catalog.Finalize(context.Background())
 		if err != nil {
-			ml.setFailed(errors.Wrap(err, "finalization"))
+			ml.setFailed(srcLock.unlockOnError(errors.Wrap(err, "finalization")))
 
 			return
 		}
@@ -660,6 +1662,10 @@ func (ml *PCSM) Finalize(ctx context.Context, options FinalizeOptions) error {
 		lg.With(log.Elapsed(time.Since(startedTime))).
 			Info("Finalization is completed")
 
+		if srcLock != nil {
+			lg.Warn("Source is locked. Unlock it with db.fsyncUnlock() after the cutover")
+		}
+
 		go ml.onStateChanged(StateFinalized)
 	}()
 
@@ -669,3 +1675,52 @@ func (ml *PCSM) Finalize(ctx context.Context, options FinalizeOptions) error {
 
 	return nil
 }
+
+// catchUpLockedSource waits for the change replication to apply the writes made on the source
+// before it was locked. Otherwise, the writes not yet applied are lost on pause.
+func (ml *PCSM) catchUpLockedSource(ctx context.Context, locker sourceLocker) error {
+	optime, err := locker.LastOpTime(ctx)
+	if err != nil {
+		return errors.Wrap(err, "locked source optime")
+	}
+
+	lg := log.New("finalize")
+	lg.With(log.OpTime(optime.T, optime.I)).
+		Info("Waiting for Change Replication to apply the last write of the locked source")
+
+	// the catch-up may outlast the request
+	err = waitCaughtUp(context.WithoutCancel(ctx), ml.repl, optime)
+	if err != nil {
+		return errors.Wrap(err, "catch up with the locked source")
+	}
+
+	return nil
+}
+
+// pauseForFinalize pauses the change replication and waits for it to stop.
+func (ml *PCSM) pauseForFinalize(ctx context.Context) error {
+	lg := log.New("finalize")
+	lg.Info("Pausing Change Replication")
+
+	err := ml.repl.Pause(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pause change replication")
+	}
+
+	<-ml.repl.Done()
+	lg.Info("Change Replication is paused")
+
+	err = ml.repl.Status().Err
+	if err != nil {
+		// no need to set the PCSM failed status here.
+		// [PCSM.setFailed] is called in [PCSM.run].
+		return errors.Wrap(err, "post-pause change replication")
+	}
+
+	if ml.repl.Status().PendingDDL != nil {
+		// the paused state is set in [PCSM.run].
+		return errors.New("DDL change is pending approval")
+	}
+
+	return nil
+}
@@ -0,0 +1,401 @@
+package pcsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrInsufficientOplogWindow indicates that the source oplog window is below the required minimum.
+var ErrInsufficientOplogWindow = errors.New("insufficient oplog window")
+
+// ErrStartAtOutsideOplogWindow indicates that the start timestamp of the replication is not
+// in the source oplog.
+var ErrStartAtOutsideOplogWindow = errors.New("start timestamp is outside of the oplog window")
+
+// ErrInternalNamespace indicates that an internal database is included in the replication.
+var ErrInternalNamespace = errors.New("internal database cannot be replicated")
+
+// ErrNamespaceCaseCollision indicates that the replicated namespaces have the names
+// that differ only by case and collide on a case-insensitive target.
+var ErrNamespaceCaseCollision = errors.New("namespace names differ only by case")
+
+// ErrTargetNotWritable indicates that the target is a secondary or a read-only server.
+var ErrTargetNotWritable = errors.New("target is not writable")
+
+// ErrFCVMismatch indicates that the target featureCompatibilityVersion is lower than the source.
+var ErrFCVMismatch = errors.New("target featureCompatibilityVersion is lower than the source")
+
+// preflight runs the checks required before the replication can be started.
+func (ml *PCSM) preflight(ctx context.Context, options *StartOptions) error {
+	err := validateIncludeNamespaces(options.IncludeNamespaces)
+	if err != nil {
+		return err
+	}
+
+	err = ml.checkSourceNamespaces(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	hello, err := topo.SayHello(ctx, ml.target)
+	if err != nil {
+		return errors.Wrap(err, "target hello")
+	}
+
+	err = validateTargetWritable(hello)
+	if err != nil {
+		return err
+	}
+
+	hello, err = topo.SayHello(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "source hello")
+	}
+
+	ml.checkSourceDelay(ctx, hello)
+
+	err = ml.checkFCV(ctx, options.RequireMatchingFCV)
+	if err != nil {
+		return errors.Wrap(err, "featureCompatibilityVersion")
+	}
+
+	if !options.StartAt.IsZero() {
+		err = ml.checkStartAt(ctx, options.StartAt)
+		if err != nil {
+			return errors.Wrap(err, "start timestamp")
+		}
+	}
+
+	if options.MinOplogWindow > 0 {
+		err = ml.checkOplogWindow(ctx, options.MinOplogWindow, options.IgnoreOplogWindow)
+		if err != nil {
+			return errors.Wrap(err, "oplog window")
+		}
+	}
+
+	return nil
+}
+
+// validateIncludeNamespaces returns [ErrInternalNamespace] if a namespace of the admin, config,
+// local, or PCSM database is included.
+func validateIncludeNamespaces(include []string) error {
+	for _, ns := range include {
+		db, _, _ := strings.Cut(ns, ".")
+		if isInternalDatabase(db) || db == config.PCSMDatabase {
+			return errors.Wrapf(ErrInternalNamespace, "%q", ns)
+		}
+	}
+
+	return nil
+}
+
+// checkSourceNamespaces verifies that the source namespaces allowed by the filters
+// do not collide when the case is ignored, fit the length limits on the target, and
+// have no drifted target collection with [SchemaDriftError] or [SchemaDriftCollMod].
+func (ml *PCSM) checkSourceNamespaces(ctx context.Context, options *StartOptions) error {
+	namespaces, err := ListSourceNamespaces(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "list source namespaces")
+	}
+
+	namespaces = FilterNamespaces(namespaces, options.IncludeNamespaces, options.ExcludeNamespaces)
+
+	err = validateNamespaceCase(namespaces)
+	if err != nil {
+		return err
+	}
+
+	err = validateTargetNamespaces(namespaces, options.TargetDatabasePrefix,
+		options.RenameCollisionSuffix)
+	if err != nil {
+		return err
+	}
+
+	return ml.checkSchemaDrift(ctx, namespaces, options.OnSchemaDrift, options.TargetDatabasePrefix)
+}
+
+// validateTargetNamespaces returns [ErrNamespaceTooLong] for each namespace that exceeds
+// the length limits with the target database prefix or the rename collision suffix
+// of the collection that replaces an existing one, and [ErrInternalNamespace] if
+// a prefixed database name is internal.
+func validateTargetNamespaces(namespaces []Namespace, prefix, suffix string) error {
+	if prefix == "" && suffix == "" {
+		return nil
+	}
+
+	var errs []error
+
+	for _, ns := range namespaces {
+		target := Namespace{prefix + ns.Database, ns.Collection + suffix}
+		if prefix != "" &&
+			(isInternalDatabase(target.Database) || target.Database == config.PCSMDatabase) {
+			errs = append(errs, errors.Wrapf(ErrInternalNamespace, "target of %q: %q", ns, target))
+
+			continue
+		}
+
+		err := target.ValidateLength()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "target of %q", ns))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateNamespaceCase returns [ErrNamespaceCaseCollision] for each pair of the database names
+// or the namespaces that differ only by case (e.g. "db1.Coll" and "db1.coll").
+func validateNamespaceCase(namespaces []Namespace) error {
+	var errs []error
+
+	databases := make(map[string]string)
+	collections := make(map[Namespace]Namespace)
+
+	for _, ns := range namespaces {
+		db := strings.ToLower(ns.Database)
+		if other, ok := databases[db]; !ok {
+			databases[db] = ns.Database
+		} else if other != ns.Database {
+			errs = append(errs, errors.Wrapf(ErrNamespaceCaseCollision, "databases %q and %q", other, ns.Database))
+			databases[db] = ns.Database // report each pair once
+		}
+
+		key := Namespace{ns.Database, strings.ToLower(ns.Collection)}
+		if other, ok := collections[key]; ok {
+			errs = append(errs, errors.Wrapf(ErrNamespaceCaseCollision, "%q and %q", other, ns))
+
+			continue
+		}
+
+		collections[key] = ns
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateTargetWritable returns [ErrTargetNotWritable] if the target is in the read-only mode
+// or is not a writable primary (e.g. a direct connection to a secondary).
+func validateTargetWritable(hello *topo.Hello) error {
+	if hello.ReadOnly {
+		return errors.Wrap(ErrTargetNotWritable, "the server is in the read-only mode")
+	}
+
+	if !hello.IsWritablePrimary {
+		return errors.Wrap(ErrTargetNotWritable, "the server is not a writable primary")
+	}
+
+	return nil
+}
+
+// checkSourceDelay warns if the source connection reads from a delayed secondary.
+// The replication lags behind the primary by the delay.
+func (ml *PCSM) checkSourceDelay(ctx context.Context, hello *topo.Hello) {
+	if !hello.Secondary {
+		return
+	}
+
+	lg := log.Ctx(ctx)
+
+	members, err := topo.GetReplSetMembers(ctx, ml.source)
+	if err != nil {
+		lg.Warnf("The source is the secondary %s. Cannot check its replication delay: %v", hello.Me, err)
+
+		return
+	}
+
+	delay := sourceReadDelay(hello, members)
+	if delay == 0 {
+		lg.Infof("The source is the secondary %s", hello.Me)
+
+		return
+	}
+
+	lg.Warnf("The source is the delayed secondary %s (delay %s). The replication lags behind "+
+		"the primary by the delay. The last replicated optime is advanced by the change events only, "+
+		"so the replication resumes from it on any member", hello.Me, delay)
+}
+
+// sourceReadDelay returns the replication delay of the source member of the hello.
+// It is zero for a primary or a member without a delay.
+func sourceReadDelay(hello *topo.Hello, members []topo.ReplSetMember) time.Duration {
+	if !hello.Secondary {
+		return 0
+	}
+
+	for _, m := range members {
+		if m.Host == hello.Me {
+			return m.Delay()
+		}
+	}
+
+	return 0
+}
+
+// checkFCV reports the source and target featureCompatibilityVersion. The target with a lower
+// FCV can reject the newer features of the source changes (e.g. an index type or an update
+// operator) during the apply. It fails the check only if require is true.
+func (ml *PCSM) checkFCV(ctx context.Context, require bool) error {
+	lg := log.New("preflight")
+
+	source, err := topo.FeatureCompatibilityVersion(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "source")
+	}
+
+	target, err := topo.FeatureCompatibilityVersion(ctx, ml.target)
+	if err != nil {
+		return errors.Wrap(err, "target")
+	}
+
+	lg.Infof("Source featureCompatibilityVersion: %s. Target featureCompatibilityVersion: %s",
+		source, target)
+
+	err = validateFCV(source, target)
+	if err != nil && !require {
+		lg.Warn(err.Error() + ". The newer features of the source can be rejected by the target")
+
+		return nil
+	}
+
+	return err
+}
+
+// validateFCV returns [ErrFCVMismatch] if the target FCV is lower than the source FCV.
+func validateFCV(source, target string) error {
+	sourceVersion, err := parseFCV(source)
+	if err != nil {
+		return errors.Wrap(err, "source")
+	}
+
+	targetVersion, err := parseFCV(target)
+	if err != nil {
+		return errors.Wrap(err, "target")
+	}
+
+	if targetVersion[0] > sourceVersion[0] ||
+		targetVersion[0] == sourceVersion[0] && targetVersion[1] >= sourceVersion[1] {
+		return nil
+	}
+
+	return errors.Wrapf(ErrFCVMismatch, "%s is lower than %s", target, source)
+}
+
+// parseFCV parses the MAJOR.MINOR featureCompatibilityVersion.
+func parseFCV(fcv string) ([2]int, error) {
+	var version [2]int
+
+	major, minor, ok := strings.Cut(fcv, ".")
+	if !ok {
+		return version, errors.Errorf("invalid version %q", fcv)
+	}
+
+	for i, s := range []string{major, minor} {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return version, errors.Errorf("invalid version %q", fcv)
+		}
+
+		version[i] = n
+	}
+
+	return version, nil
+}
+
+// checkOplogWindow measures the source oplog window and verifies it is not below minWindow.
+// If ignore is true, an insufficient window is reported but does not fail the check.
+// The oplog of a sharded source is not readable through mongos, so it is not checked.
+func (ml *PCSM) checkOplogWindow(ctx context.Context, minWindow time.Duration, ignore bool) error {
+	lg := log.New("preflight")
+
+	hello, err := topo.SayHello(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "source hello")
+	}
+
+	if hello.Msg == "isdbgrid" {
+		lg.Warn("The source is a sharded cluster. The oplog window is not checked")
+
+		return nil
+	}
+
+	window, err := topo.OplogWindow(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "measure source oplog window")
+	}
+
+	lg.Infof("Source oplog window: %s", window.Round(time.Second))
+
+	err = validateOplogWindow(window, minWindow)
+	if err != nil && ignore {
+		lg.Warn(err.Error() + " [ignored]")
+
+		return nil
+	}
+
+	return err
+}
+
+// validateOplogWindow returns [ErrInsufficientOplogWindow] if window is less than minWindow.
+func validateOplogWindow(window, minWindow time.Duration) error {
+	if window >= minWindow {
+		return nil
+	}
+
+	return errors.Wrapf(ErrInsufficientOplogWindow, "%s is less than the required %s",
+		window.Round(time.Second), minWindow)
+}
+
+// checkStartAt verifies that the changes since the start timestamp are in the source oplog.
+// The oldest oplog entry of a sharded source is not checked: an expired timestamp fails
+// the change stream.
+func (ml *PCSM) checkStartAt(ctx context.Context, startAt bson.Timestamp) error {
+	lg := log.New("preflight")
+
+	clusterTime, err := topo.ClusterTime(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "source cluster time")
+	}
+
+	hello, err := topo.SayHello(ctx, ml.source)
+	if err != nil {
+		return errors.Wrap(err, "source hello")
+	}
+
+	var oldest bson.Timestamp
+
+	if hello.Msg == "isdbgrid" {
+		lg.Warn("The source is a sharded cluster. The oldest oplog entry is not checked")
+	} else {
+		oldest, err = topo.OldestOplogTimestamp(ctx, ml.source)
+		if err != nil {
+			return errors.Wrap(err, "oldest source oplog entry")
+		}
+	}
+
+	return validateStartAt(startAt, oldest, clusterTime)
+}
+
+// validateStartAt returns [ErrStartAtOutsideOplogWindow] if startAt is earlier than the oldest
+// oplog entry or later than the cluster time. The zero oldest timestamp is not checked.
+func validateStartAt(startAt, oldest, clusterTime bson.Timestamp) error {
+	if startAt.After(clusterTime) {
+		return errors.Wrapf(ErrStartAtOutsideOplogWindow, "%d.%d is later than the cluster time %d.%d",
+			startAt.T, startAt.I, clusterTime.T, clusterTime.I)
+	}
+
+	if !oldest.IsZero() && startAt.Before(oldest) {
+		return errors.Wrapf(ErrStartAtOutsideOplogWindow, "%d.%d is earlier than the oldest oplog entry %d.%d",
+			startAt.T, startAt.I, oldest.T, oldest.I)
+	}
+
+	return nil
+}
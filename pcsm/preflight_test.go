@@ -0,0 +1,222 @@
+package pcsm //nolint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+func TestValidateOplogWindow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("undersized", func(t *testing.T) {
+		t.Parallel()
+
+		err := validateOplogWindow(2*time.Hour, 24*time.Hour)
+		if !errors.Is(err, ErrInsufficientOplogWindow) {
+			t.Errorf("got = %v, want %v", err, ErrInsufficientOplogWindow)
+		}
+	})
+
+	t.Run("sufficient", func(t *testing.T) {
+		t.Parallel()
+
+		for _, window := range []time.Duration{24 * time.Hour, 48 * time.Hour} {
+			err := validateOplogWindow(window, 24*time.Hour)
+			if err != nil {
+				t.Errorf("%s: got = %v, want nil", window, err)
+			}
+		}
+	})
+}
+
+func TestValidateIncludeNamespaces(t *testing.T) {
+	t.Parallel()
+
+	for _, ns := range []string{"local.*", "local.oplog.rs", "admin.*", "config.coll1"} {
+		err := validateIncludeNamespaces([]string{"db1.*", ns})
+		if !errors.Is(err, ErrInternalNamespace) {
+			t.Errorf("%s: got = %v, want %v", ns, err, ErrInternalNamespace)
+		}
+	}
+
+	err := validateIncludeNamespaces([]string{"db1.*", "localdb.coll1"})
+	if err != nil {
+		t.Errorf("got = %v, want nil", err)
+	}
+}
+
+func TestValidateTargetNamespaces(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []Namespace{{"db1", "coll1"}, {"min", "coll1"}, {strings.Repeat("d", 58), "coll1"}}
+
+	err := validateTargetNamespaces(namespaces, "", "")
+	if err != nil {
+		t.Errorf("no prefix: got = %v, want nil", err)
+	}
+
+	err = validateTargetNamespaces(namespaces[:1], "test_", "")
+	if err != nil {
+		t.Errorf("prefixed: got = %v, want nil", err)
+	}
+
+	// the 63-byte limit of the database name is exceeded with the prefix
+	err = validateTargetNamespaces(namespaces[2:], "test_1", "")
+	if !errors.Is(err, ErrNamespaceTooLong) {
+		t.Errorf("too long: got = %v, want %v", err, ErrNamespaceTooLong)
+	}
+
+	err = validateTargetNamespaces(namespaces[1:2], "ad", "")
+	if !errors.Is(err, ErrInternalNamespace) {
+		t.Errorf("internal: got = %v, want %v", err, ErrInternalNamespace)
+	}
+
+	// the 255-byte limit of the namespace is exceeded with the suffix, without the prefix
+	long := []Namespace{{"db1", strings.Repeat("c", 248)}}
+
+	err = validateTargetNamespaces(long, "", "")
+	if err != nil {
+		t.Errorf("no suffix: got = %v, want nil", err)
+	}
+
+	err = validateTargetNamespaces(long, "", "_pcsm")
+	if !errors.Is(err, ErrNamespaceTooLong) {
+		t.Errorf("suffixed: got = %v, want %v", err, ErrNamespaceTooLong)
+	}
+}
+
+func TestValidateStartAt(t *testing.T) {
+	t.Parallel()
+
+	oldest := bson.Timestamp{T: 100, I: 1}
+	clusterTime := bson.Timestamp{T: 200, I: 3}
+
+	for _, startAt := range []bson.Timestamp{{T: 99, I: 9}, {T: 100}, {T: 200, I: 4}, {T: 300}} {
+		err := validateStartAt(startAt, oldest, clusterTime)
+		if !errors.Is(err, ErrStartAtOutsideOplogWindow) {
+			t.Errorf("%v: got = %v, want %v", startAt, err, ErrStartAtOutsideOplogWindow)
+		}
+	}
+
+	for _, startAt := range []bson.Timestamp{oldest, {T: 150}, clusterTime} {
+		err := validateStartAt(startAt, oldest, clusterTime)
+		if err != nil {
+			t.Errorf("%v: got = %v, want nil", startAt, err)
+		}
+	}
+
+	// the oldest oplog entry is unknown for a sharded source
+	err := validateStartAt(bson.Timestamp{T: 1}, bson.Timestamp{}, clusterTime)
+	if err != nil {
+		t.Errorf("got = %v, want nil", err)
+	}
+}
+
+func TestValidateNamespaceCase(t *testing.T) {
+	t.Parallel()
+
+	err := validateNamespaceCase(FilterNamespaces(
+		[]Namespace{{"db1", "coll1"}, {"db1", "Coll1"}, {"db2", "coll1"}, {"db1", "coll2"}}, nil, nil))
+	if !errors.Is(err, ErrNamespaceCaseCollision) {
+		t.Fatalf("collections: got = %v, want %v", err, ErrNamespaceCaseCollision)
+	}
+
+	if want := `"db1.Coll1" and "db1.coll1": ` + ErrNamespaceCaseCollision.Error(); err.Error() != want {
+		t.Errorf("collections: got = %q, want %q", err.Error(), want)
+	}
+
+	err = validateNamespaceCase(FilterNamespaces(
+		[]Namespace{{"DB1", "coll1"}, {"db1", "coll1"}, {"db1", "coll2"}}, nil, nil))
+	if want := `databases "DB1" and "db1": ` + ErrNamespaceCaseCollision.Error(); err == nil || err.Error() != want {
+		t.Errorf("databases: got = %v, want %q", err, want)
+	}
+
+	// the excluded namespace does not collide
+	err = validateNamespaceCase(FilterNamespaces(
+		[]Namespace{{"db1", "coll1"}, {"db1", "Coll1"}}, nil, []string{"db1.Coll1"}))
+	if err != nil {
+		t.Errorf("excluded: got = %v, want nil", err)
+	}
+}
+
+func TestValidateTargetWritable(t *testing.T) {
+	t.Parallel()
+
+	for _, hello := range []topo.Hello{{}, {IsWritablePrimary: true, ReadOnly: true}} {
+		err := validateTargetWritable(&hello)
+		if !errors.Is(err, ErrTargetNotWritable) {
+			t.Errorf("%+v: got = %v, want %v", hello, err, ErrTargetNotWritable)
+		}
+	}
+
+	err := validateTargetWritable(&topo.Hello{IsWritablePrimary: true})
+	if err != nil {
+		t.Errorf("writable primary: got = %v, want nil", err)
+	}
+}
+
+func TestSourceReadDelay(t *testing.T) {
+	t.Parallel()
+
+	members := []topo.ReplSetMember{
+		{Host: "rs0:27017"},
+		{Host: "rs1:27017"},
+		{Host: "rs2:27017", Hidden: true, SecondaryDelaySecs: 3600},
+	}
+
+	tests := []struct {
+		name  string
+		hello topo.Hello
+		want  time.Duration
+	}{
+		{"primary", topo.Hello{IsWritablePrimary: true, Me: "rs0:27017"}, 0},
+		{"secondary", topo.Hello{Secondary: true, Me: "rs1:27017"}, 0},
+		{"delayed secondary", topo.Hello{Secondary: true, Hidden: true, Me: "rs2:27017"}, time.Hour},
+		{"unknown member", topo.Hello{Secondary: true, Me: "rs3:27017"}, 0},
+	}
+
+	for _, test := range tests {
+		if got := sourceReadDelay(&test.hello, members); got != test.want {
+			t.Errorf("%s: got = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateFCV(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct{ source, target string }{
+		{"7.0", "7.0"},
+		{"6.0", "7.0"},
+		{"7.0", "8.0"},
+		{"8.0", "8.2"},
+	} {
+		err := validateFCV(tc.source, tc.target)
+		if err != nil {
+			t.Errorf("%s to %s: got = %v, want nil", tc.source, tc.target, err)
+		}
+	}
+
+	for _, tc := range []struct{ source, target string }{
+		{"8.0", "7.0"},
+		{"7.3", "7.0"},
+	} {
+		err := validateFCV(tc.source, tc.target)
+		if !errors.Is(err, ErrFCVMismatch) {
+			t.Errorf("%s to %s: got = %v, want %v", tc.source, tc.target, err, ErrFCVMismatch)
+		}
+	}
+
+	for _, fcv := range []string{"", "7", "7.x", "-1.0"} {
+		err := validateFCV(fcv, "7.0")
+		if err == nil || errors.Is(err, ErrFCVMismatch) {
+			t.Errorf("%q: got = %v, want invalid version", fcv, err)
+		}
+	}
+}
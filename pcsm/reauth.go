@@ -0,0 +1,54 @@
+package pcsm
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+)
+
+// ErrSourceAuthFailed indicates that the source rejected the credentials during the replication
+// (e.g. a rotated password). The replication is paused until [PCSM.Reauth].
+var ErrSourceAuthFailed = errors.New("source authentication failed: credentials need refresh")
+
+// pauseOnAuthFailed pauses the replication on the source authentication failure.
+func (ml *PCSM) pauseOnAuthFailed(err error) {
+	ml.lock.Lock()
+	ml.state = StatePaused
+	ml.err = errors.Errorf("%w: %w", ErrSourceAuthFailed, err)
+	ml.lock.Unlock()
+
+	log.New("pcsm").Warnf("Cluster Replication paused: %v. Supply new source credentials with reauth", err)
+
+	go ml.onStateChanged(StatePaused)
+}
+
+// Reauth replaces the source client of the replication paused on the source authentication
+// failure and resumes the replication. The client must connect to the same source with
+// the new credentials.
+func (ml *PCSM) Reauth(ctx context.Context, source *mongo.Client) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state != StatePaused || !errors.Is(ml.err, ErrSourceAuthFailed) {
+		return errors.New("cannot reauth: not paused on the source authentication failure")
+	}
+
+	// the clone and the change replication are stopped
+	ml.source = source
+	ml.clone.source = source
+	ml.repl.source = source
+
+	err := ml.doResume(ctx, false)
+	if err != nil {
+		log.New("pcsm").Error(err, "Resume Cluster Replication")
+
+		return err
+	}
+
+	log.New("pcsm").Info("Cluster Replication resumed with new source credentials")
+
+	return nil
+}
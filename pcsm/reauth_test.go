@@ -0,0 +1,86 @@
+package pcsm //nolint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// unreachableClient returns a client of a source that is never reached by the test.
+func unreachableClient(t *testing.T) *mongo.Client {
+	t.Helper()
+
+	client, err := mongo.Connect(options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = client.Disconnect(t.Context()) })
+
+	return client
+}
+
+func TestPCSM_Reauth(t *testing.T) {
+	t.Parallel()
+
+	old := unreachableClient(t)
+	ml := New(old, old, Options{})
+	ml.catalog = NewCatalog(old, CatalogOptions{})
+	ml.clone = NewClone(old, old, ml.catalog, makeNSFilter(nil, nil), CloneOptions{})
+	ml.repl = NewRepl(old, old, ml.catalog, makeNSFilter(nil, nil), ReplOptions{})
+	ml.state = StateRunning
+
+	if err := ml.Reauth(t.Context(), old); err == nil {
+		t.Fatal("running: got = nil, want error")
+	}
+
+	// the change replication stopped on the rotated source password: the clone is finished
+	ml.clone.Skip(bson.Timestamp{T: 100})
+
+	err := ml.repl.Recover(&replCheckpoint{
+		StartTime:            time.Now(),
+		PauseTime:            time.Now(),
+		LastReplicatedOpTime: bson.Timestamp{T: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authErr := mongo.CommandError{Code: 18, Name: "AuthenticationFailed", Message: "Authentication failed."}
+	ml.pauseOnAuthFailed(errors.Wrap(authErr, "change replication"))
+
+	if ml.state != StatePaused || !errors.Is(ml.err, ErrSourceAuthFailed) {
+		t.Fatalf("got state %q, error %v, want paused on the source authentication failure", ml.state, ml.err)
+	}
+
+	if !strings.Contains(ml.err.Error(), "credentials need refresh") {
+		t.Errorf("got error %q, want the credentials refresh message", ml.err)
+	}
+
+	source := unreachableClient(t)
+
+	err = ml.Reauth(t.Context(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ml.lock.Lock()
+	state, mlErr, mlSource := ml.state, ml.err, ml.source
+	ml.lock.Unlock()
+
+	if state != StateRunning || mlErr != nil {
+		t.Errorf("got state %q, error %v, want running", state, mlErr)
+	}
+
+	if mlSource != source || ml.clone.source != source || ml.repl.source != source {
+		t.Error("the source client is not replaced")
+	}
+}
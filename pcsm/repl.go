@@ -34,13 +34,16 @@ type Repl struct {
 
 	nsFilter sel.NSFilter // Namespace filter
 	catalog  *Catalog     // Catalog for managing collections and indexes
+	options  ReplOptions
 
 	lastReplicatedOpTime bson.Timestamp
+	lastReplicatedToken  bson.Raw // resume token of the last applied change event
 
 	lock sync.Mutex
 	err  error
 
 	eventsProcessed int64
+	deadLettered    int64
 
 	startTime time.Time
 	pauseTime time.Time
@@ -52,7 +55,56 @@ type Repl struct {
 	bulkWrite      bulkWrite
 	bulkToken      bson.Raw
 	bulkTS         bson.Timestamp
+	bulkTxn        *EventHeader // last buffered change if it is a part of a transaction
 	lastBulkDoneAt time.Time
+	bulkMemory     int // memory of the changes read since the bulk write was empty
+
+	// bulkChanges are the buffered changes of the bulk write (only with the dead-letter collection)
+	bulkChanges []pendingChange
+
+	pendingDDL  *DDLChange // DDL change waiting for the operator approval
+	approvedDDL *DDLChange // approved DDL change to apply on resume
+
+	dedup *dedupWindow // recent applied CRUD changes (nil if disabled)
+
+	ddl *ddlScheduler // concurrently applied DDL changes of the run (nil if disabled)
+
+	nsPauses namespacePauses // paused namespaces and their held changes
+
+	staleEventsHeld bool // paused on the stale change events. the check is skipped on resume
+}
+
+// DDLChange identifies a DDL change event held for the operator approval.
+type DDLChange struct {
+	OperationType OperationType  `bson:"operationType"`
+	Namespace     Namespace      `bson:"ns"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+}
+
+func newDDLChange(change *ChangeEvent) *DDLChange {
+	return &DDLChange{
+		OperationType: change.OperationType,
+		Namespace:     change.Namespace,
+		ClusterTime:   change.ClusterTime,
+	}
+}
+
+// requiresDDLApproval reports whether the operation type is a DDL change
+// that is held for the operator approval with [ReplOptions.PauseOnDDL].
+func requiresDDLApproval(op OperationType) bool {
+	switch op { //nolint:exhaustive
+	case Create, Drop, DropDatabase, Rename, Modify:
+		return true
+	}
+
+	return false
+}
+
+// pendingChange is a CRUD change buffered in the bulk write.
+type pendingChange struct {
+	ns     Namespace
+	change *ChangeEvent
+	event  any // pointer to the change event data
 }
 
 // ReplStatus represents the status of change replication.
@@ -61,7 +113,14 @@ type ReplStatus struct {
 	PauseTime time.Time
 
 	LastReplicatedOpTime bson.Timestamp // Last applied operation time
+	LastReplicatedToken  bson.Raw       // Resume token of the last applied change event
 	EventsProcessed      int64          // Number of events processed
+	DeadLettered         int64          // Number of events stored in the dead-letter collection
+
+	PendingDDL *DDLChange // DDL change waiting for the operator approval
+
+	PausedNamespaces []string // paused namespace patterns
+	HeldChanges      int      // number of the held changes of the paused namespaces
 
 	Err error
 }
@@ -81,14 +140,104 @@ func (rs *ReplStatus) IsPaused() bool {
 	return !rs.PauseTime.IsZero()
 }
 
-func NewRepl(source, target *mongo.Client, catalog *Catalog, nsFilter sel.NSFilter) *Repl {
+// ReplOptions configures the change replication.
+type ReplOptions struct {
+	// UseCollectionBulkWrite forces the collection-level bulk write.
+	UseCollectionBulkWrite bool
+	// Transform is applied to each change event of the replicated namespaces before apply.
+	Transform TransformChain
+	// Method is the method of reading changes from the source.
+	// The empty value is [ReplicationChangeStream].
+	Method ReplicationMethod
+	// DeadLetter is the target namespace to store the change events that fail to apply
+	// with a write error. The zero value disables the dead-letter collection.
+	DeadLetter Namespace
+	// MaxDocRetries is the number of retries of a change event that fails to apply with
+	// a write error before it is stored in the dead-letter collection.
+	// Zero stores the change event on the first failure.
+	MaxDocRetries int
+	// DDLAudit is the target namespace to record the applied DDL changes.
+	// The zero value disables the audit.
+	DDLAudit Namespace
+	// PauseOnDDL pauses the replication on a create, drop, rename, or collMod change
+	// until the change is approved with [Repl.ApproveDDL].
+	PauseOnDDL bool
+	// ApplyOpTimeout is the deadline of a single apply write. Zero disables the deadline.
+	ApplyOpTimeout time.Duration
+	// SkipDropDatabase does not apply the dropDatabase changes on the target. The source reports
+	// the drop of each collection of the database before, so only the target collections
+	// not replicated from the source are kept.
+	SkipDropDatabase bool
+	// ChangeStreamBatchSize is the batch size of the change stream.
+	// Zero is [config.ChangeStreamBatchSize].
+	ChangeStreamBatchSize int32
+	// ChangeStreamMaxAwaitTime is the maximum time the change stream waits for new changes.
+	// Zero is [config.ChangeStreamAwaitTime].
+	ChangeStreamMaxAwaitTime time.Duration
+	// BypassDocumentValidation skips the document validation of the target collections
+	// on apply.
+	BypassDocumentValidation bool
+	// DedupWindow is the number of the most recent applied CRUD changes remembered to skip
+	// the changes read again after a reconnect. Zero disables the deduplication.
+	DedupWindow int
+	// PreserveTxnOrder applies the operations of a transaction in one bulk write in the order
+	// of applyOps. The bulk write is not flushed in the middle of a transaction.
+	PreserveTxnOrder bool
+	// MaxEventAge is the maximum age of the first change event received after a reconnect
+	// (a resume). An older event indicates a long outage and is handled by [ReplOptions.OnStaleEvents].
+	// Zero disables the check.
+	MaxEventAge time.Duration
+	// OnStaleEvents is the handling of the stale change events. The empty value is [StaleEventsWarn].
+	OnStaleEvents StaleEventsAction
+	// OnNestingExceeded is the handling of the inserted or replaced documents nested deeper than
+	// [config.MaxNestingDepth]. The empty value disables the check.
+	OnNestingExceeded NestingExceededAction
+	// OnUnknownEvent is the handling of the change events of an unknown operation type.
+	// The empty value is [UnknownEventLog].
+	OnUnknownEvent UnknownEventAction
+	// DDLWorkers is the number of the index builds, index drops, and collMod changes applied
+	// concurrently. The changes of other namespaces are applied while they run; the changes
+	// of the same namespace wait for them. Zero applies the DDL changes one by one in order.
+	DDLWorkers int
+	// Tap is called with each change event read from the source before it is applied.
+	// It must not block or modify the event. Nil disables it.
+	Tap func(*ChangeEvent)
+	// Memory bounds the queued change events. The reads wait while the queue exceeds it.
+	// Nil does not limit the memory.
+	Memory *MemoryBudget
+	// UpdateAsUpsert applies the updates as the upserts of the full-document image looked up by
+	// the change stream (updateLookup), so an update of a document missing on the target
+	// (e.g. not cloned because of a race) creates it. Requires the change stream method.
+	UpdateAsUpsert bool
+	// InternalNamespaces are the internal collections replicated on demand
+	// (e.g. config.system.sessions). The oplog method reads their changes;
+	// the change streams do not report the changes of the internal databases.
+	InternalNamespaces []Namespace
+}
+
+func (o ReplOptions) bulkOptions() bulkOptions {
+	return bulkOptions{
+		opTimeout:                o.ApplyOpTimeout,
+		bypassDocumentValidation: o.BypassDocumentValidation,
+	}
+}
+
+func NewRepl(
+	source *mongo.Client,
+	target *mongo.Client,
+	catalog *Catalog,
+	nsFilter sel.NSFilter,
+	options ReplOptions,
+) *Repl {
 	return &Repl{
 		source:   source,
 		target:   target,
 		nsFilter: nsFilter,
 		catalog:  catalog,
+		options:  options,
 		pauseC:   make(chan struct{}),
 		doneSig:  make(chan struct{}),
+		dedup:    newDedupWindow(options.DedupWindow),
 	}
 }
 
@@ -97,8 +246,14 @@ type replCheckpoint struct {
 	PauseTime            time.Time      `bson:"pauseTime,omitempty"`
 	EventsProcessed      int64          `bson:"events,omitempty"`
 	LastReplicatedOpTime bson.Timestamp `bson:"lastOpTS,omitempty"`
+	LastReplicatedToken  bson.Raw       `bson:"lastToken,omitempty"`
 	Error                string         `bson:"error,omitempty"`
 	UseClientBulkWrite   bool           `bson:"clientBulk,omitempty"`
+	DeadLettered         int64          `bson:"deadLettered,omitempty"`
+	PendingDDL           *DDLChange     `bson:"pendingDDL,omitempty"`
+	ApprovedDDL          *DDLChange     `bson:"approvedDDL,omitempty"`
+	StaleEventsHeld      bool           `bson:"staleEventsHeld,omitempty"`
+	PausedNamespaces     []string       `bson:"pausedNamespaces,omitempty"`
 }
 
 func (r *Repl) Checkpoint() *replCheckpoint { //nolint:revive
@@ -113,7 +268,13 @@ func (r *Repl) Checkpoint() *replCheckpoint { //nolint:revive
 		StartTime:            r.startTime,
 		PauseTime:            r.pauseTime,
 		EventsProcessed:      r.eventsProcessed,
-		LastReplicatedOpTime: r.lastReplicatedOpTime,
+		LastReplicatedOpTime: r.appliedOpTime(),
+		LastReplicatedToken:  r.lastReplicatedToken,
+		DeadLettered:         r.deadLettered,
+		PendingDDL:           r.pendingDDL,
+		ApprovedDDL:          r.approvedDDL,
+		StaleEventsHeld:      r.staleEventsHeld,
+		PausedNamespaces:     r.nsPauses.list(),
 	}
 
 	_, ok := r.bulkWrite.(*clientBulkWrite)
@@ -147,11 +308,17 @@ func (r *Repl) Recover(cp *replCheckpoint) error {
 	r.pauseTime = pauseTime
 	r.eventsProcessed = cp.EventsProcessed
 	r.lastReplicatedOpTime = cp.LastReplicatedOpTime
-
-	if cp.UseClientBulkWrite {
-		r.bulkWrite = newClientBulkWrite(config.BulkOpsSize)
+	r.lastReplicatedToken = cp.LastReplicatedToken
+	r.deadLettered = cp.DeadLettered
+	r.pendingDDL = cp.PendingDDL
+	r.approvedDDL = cp.ApprovedDDL
+	r.staleEventsHeld = cp.StaleEventsHeld
+	r.nsPauses.restore(cp.PausedNamespaces)
+
+	if cp.UseClientBulkWrite && !r.options.UseCollectionBulkWrite {
+		r.bulkWrite = newClientBulkWrite(config.BulkOpsSize, r.options.bulkOptions())
 	} else {
-		r.bulkWrite = newCollectionBulkWrite(config.BulkOpsSize)
+		r.bulkWrite = newCollectionBulkWrite(config.BulkOpsSize, r.options.bulkOptions())
 	}
 
 	if cp.Error != "" {
@@ -167,8 +334,15 @@ func (r *Repl) Status() ReplStatus {
 	defer r.lock.Unlock()
 
 	return ReplStatus{
-		LastReplicatedOpTime: r.lastReplicatedOpTime,
+		LastReplicatedOpTime: r.appliedOpTime(),
+		LastReplicatedToken:  r.lastReplicatedToken,
 		EventsProcessed:      r.eventsProcessed,
+		DeadLettered:         r.deadLettered,
+
+		PendingDDL: r.pendingDDL,
+
+		PausedNamespaces: r.nsPauses.list(),
+		HeldChanges:      r.nsPauses.count(),
 
 		StartTime: r.startTime,
 		PauseTime: r.pauseTime,
@@ -177,6 +351,26 @@ func (r *Repl) Status() ReplStatus {
 	}
 }
 
+// appliedOpTime returns the last replicated optime before the scheduled DDL changes
+// and the held changes of the paused namespaces that are not applied yet.
+func (r *Repl) appliedOpTime() bson.Timestamp {
+	optime := r.lastReplicatedOpTime
+
+	if r.ddl != nil {
+		ts, ok := r.ddl.earliestPending()
+		if ok && ts.Before(optime) {
+			optime = ts
+		}
+	}
+
+	ts, ok := r.nsPauses.earliest()
+	if ok && ts.Before(optime) {
+		optime = ts
+	}
+
+	return optime
+}
+
 func (r *Repl) resetError() {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -209,15 +403,16 @@ func (r *Repl) Start(ctx context.Context, startAt bson.Timestamp) error {
 		return errors.Wrap(err, "major version")
 	}
 
-	if topo.Support(serverVersion).ClientBulkWrite() && !config.UseCollectionBulkWrite() {
-		r.bulkWrite = newClientBulkWrite(config.BulkOpsSize)
+	useCollectionBulkWrite := r.options.UseCollectionBulkWrite || config.UseCollectionBulkWrite()
+	if topo.Support(serverVersion).ClientBulkWrite() && !useCollectionBulkWrite {
+		r.bulkWrite = newClientBulkWrite(config.BulkOpsSize, r.options.bulkOptions())
 	} else {
-		r.bulkWrite = newCollectionBulkWrite(config.BulkOpsSize)
+		r.bulkWrite = newCollectionBulkWrite(config.BulkOpsSize, r.options.bulkOptions())
 
 		log.New("repl").Debug("Use collection-level bulk write")
 	}
 
-	go r.run(options.ChangeStream().SetStartAtOperationTime(&startAt))
+	go r.run(startAt, false)
 
 	r.startTime = time.Now()
 
@@ -249,6 +444,48 @@ func (r *Repl) Pause(context.Context) error {
 	return nil
 }
 
+// PauseNamespace pauses the apply of the CRUD changes of the namespaces matching the pattern
+// ("db.collection" or "db.*"). The changes of other namespaces are applied. The changes of
+// the paused namespaces are held in memory and applied in order on [Repl.ResumeNamespace].
+func (r *Repl) PauseNamespace(pattern string) error {
+	err := ValidateNamespacePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.startTime.IsZero() {
+		return errors.New("not running")
+	}
+
+	err = r.nsPauses.pause(pattern)
+	if err != nil {
+		return err
+	}
+
+	log.New("repl").Infof("Change Replication of %q paused", pattern)
+
+	return nil
+}
+
+// ResumeNamespace resumes the namespaces paused with the pattern. Their held changes are
+// applied before the next change event.
+func (r *Repl) ResumeNamespace(pattern string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	err := r.nsPauses.resume(pattern)
+	if err != nil {
+		return err
+	}
+
+	log.New("repl").Infof("Change Replication of %q resumed", pattern)
+
+	return nil
+}
+
 func (r *Repl) doPause() {
 	r.pausing = true
 	doneSig := r.doneSig
@@ -282,6 +519,58 @@ func (r *Repl) setFailed(err error, msg string) {
 	r.doPause()
 }
 
+// ApproveDDL approves the pending DDL change. The change is applied when the replication resumes.
+func (r *Repl) ApproveDDL() (*DDLChange, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pausing {
+		return nil, errors.New("pausing")
+	}
+
+	if r.pendingDDL == nil {
+		return nil, errors.New("no pending DDL change")
+	}
+
+	r.approvedDDL = r.pendingDDL
+	r.pendingDDL = nil
+
+	return r.approvedDDL, nil
+}
+
+// pauseOnDDL holds the DDL change for the operator approval and pauses the replication.
+// The change is received again on resume as it is after the last replicated optime.
+func (r *Repl) pauseOnDDL(change *ChangeEvent, startAt bson.Timestamp) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.pendingDDL = newDDLChange(change)
+
+	if r.lastReplicatedOpTime.IsZero() {
+		r.lastReplicatedOpTime = startAt
+	}
+
+	loggerForEvent(change).Warn("DDL change requires approval. Pausing Change Replication")
+
+	if !r.pausing {
+		r.doPause()
+	}
+}
+
+// takeDDLApproval reports whether the DDL change is approved. The approval is used once.
+func (r *Repl) takeDDLApproval(change *ChangeEvent) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.approvedDDL == nil || *r.approvedDDL != *newDDLChange(change) {
+		return false
+	}
+
+	r.approvedDDL = nil
+
+	return true
+}
+
 func (r *Repl) Resume(context.Context) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -307,7 +596,10 @@ func (r *Repl) Resume(context.Context) error {
 	r.pauseTime = time.Time{}
 	r.doneSig = make(chan struct{})
 
-	go r.run(options.ChangeStream().SetStartAtOperationTime(&r.lastReplicatedOpTime))
+	checkEventAge := r.options.MaxEventAge > 0 && !r.staleEventsHeld
+	r.staleEventsHeld = false
+
+	go r.run(r.lastReplicatedOpTime, checkEventAge)
 
 	log.New("repl").With(log.OpTime(r.lastReplicatedOpTime.T, r.lastReplicatedOpTime.I)).
 		Info("Change Replication resumed")
@@ -315,15 +607,68 @@ func (r *Repl) Resume(context.Context) error {
 	return nil
 }
 
+// isSourceSecondary returns true if the source connection reads from a secondary
+// (a direct connection to the member).
+func (r *Repl) isSourceSecondary(ctx context.Context) bool {
+	hello, err := topo.SayHello(ctx, r.source)
+	if err != nil {
+		log.New("repl").Warnf("Source hello: %v", err)
+
+		return false
+	}
+
+	return hello.Secondary
+}
+
+// changeStreamOptions returns the options of the change stream started at startAt.
+func (r *Repl) changeStreamOptions(startAt bson.Timestamp) *options.ChangeStreamOptionsBuilder {
+	batchSize := r.options.ChangeStreamBatchSize
+	if batchSize <= 0 {
+		batchSize = config.ChangeStreamBatchSize
+	}
+
+	maxAwaitTime := r.options.ChangeStreamMaxAwaitTime
+	if maxAwaitTime <= 0 {
+		maxAwaitTime = config.ChangeStreamAwaitTime
+	}
+
+	opts := options.ChangeStream().
+		SetStartAtOperationTime(&startAt).
+		SetShowExpandedEvents(true).
+		SetBatchSize(batchSize).
+		SetMaxAwaitTime(maxAwaitTime)
+
+	if r.options.UpdateAsUpsert {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+
+	return opts
+}
+
+// updateUpsert returns the upsert of the full-document image of the update with
+// [ReplOptions.UpdateAsUpsert], so an update of a document missing on the target creates it.
+// It returns nil to apply the update as is: the option is disabled, or the change stream
+// reported no full document (e.g. the document was deleted before the lookup).
+func (r *Repl) updateUpsert(event *UpdateEvent) (*InsertEvent, error) {
+	if !r.options.UpdateAsUpsert || event.FullDocument == nil {
+		return nil, nil
+	}
+
+	doc, err := bson.Marshal(event.FullDocument)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal full document")
+	}
+
+	// an insert is a replace of the document key with upsert
+	return &InsertEvent{DocumentKey: event.DocumentKey, FullDocument: doc}, nil
+}
+
 func (r *Repl) watchChangeEvents(
 	ctx context.Context,
 	streamOptions *options.ChangeStreamOptionsBuilder,
 	changeC chan<- *ChangeEvent,
 ) error {
-	cur, err := r.source.Watch(ctx, mongo.Pipeline{},
-		streamOptions.SetShowExpandedEvents(true).
-			SetBatchSize(config.ChangeStreamBatchSize).
-			SetMaxAwaitTime(config.ChangeStreamAwaitTime))
+	cur, err := r.source.Watch(ctx, mongo.Pipeline{}, streamOptions)
 	if err != nil {
 		return errors.Wrap(err, "open")
 	}
@@ -339,15 +684,23 @@ func (r *Repl) watchChangeEvents(
 	// This buffer is reused to minimize memory allocations.
 	var txnOps []*ChangeEvent
 
+	// the cluster time is ahead of the change stream of a secondary (e.g. a delayed secondary).
+	// the optime is advanced by the change events only, so it is valid to resume from.
+	advanceTime := !r.isSourceSecondary(ctx)
+
 	for {
 		lastEventTS := bson.Timestamp{}
-		sourceTS, err := topo.AdvanceClusterTime(ctx, r.source)
-		if err != nil {
-			if errors.Is(err, context.Canceled) {
-				return nil
-			}
 
-			log.New("watch").Error(err, "Unable to advance the source cluster time")
+		var sourceTS bson.Timestamp
+		if advanceTime {
+			sourceTS, err = topo.AdvanceClusterTime(ctx, r.source)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+
+				log.New("watch").Error(err, "Unable to advance the source cluster time")
+			}
 		}
 
 		for cur.TryNext(ctx) {
@@ -432,14 +785,52 @@ func (r *Repl) watchChangeEvents(
 	}
 }
 
-func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
+// run replicates the changes from startAt. With checkEventAge, the age of the first change event
+// is checked against [ReplOptions.MaxEventAge].
+func (r *Repl) run(startAt bson.Timestamp, checkEventAge bool) {
 	defer close(r.doneSig)
 
+	if r.options.DDLWorkers > 0 {
+		ddl := newDDLScheduler(r.options.DDLWorkers)
+
+		r.lock.Lock()
+		r.ddl = ddl
+		r.lock.Unlock()
+
+		defer func() {
+			ddl.waitAll()
+
+			err := ddl.failed()
+			if err == nil {
+				return
+			}
+
+			r.lock.Lock()
+			if r.err == nil {
+				r.err = err
+				log.New("repl").Error(err, "Apply change")
+			}
+			r.lock.Unlock()
+		}()
+	}
+
 	ctx := context.Background()
 	changeC := make(chan *ChangeEvent, config.ReplQueueSize)
 
+	readC := changeC
+	if r.options.Memory != nil {
+		readC = make(chan *ChangeEvent)
+		go throttleChanges(ctx, r.options.Memory, readC, changeC)
+
+		defer func() { go releaseQueued(r.options.Memory, changeC) }()
+		defer func() {
+			r.options.Memory.release(r.bulkMemory)
+			r.bulkMemory = 0
+		}()
+	}
+
 	go func() {
-		defer close(changeC)
+		defer close(readC)
 
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
@@ -449,7 +840,13 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 			cancel()
 		}()
 
-		err := r.watchChangeEvents(ctx, opts, changeC)
+		var err error
+		if r.options.Method == ReplicationOplog {
+			err = r.tailOplog(ctx, startAt, readC)
+		} else {
+			err = r.watchChangeEvents(ctx, r.changeStreamOptions(startAt), readC)
+		}
+
 		if err != nil && !errors.Is(err, context.Canceled) {
 			if topo.IsChangeStreamHistoryLost(err) || topo.IsCappedPositionLost(err) {
 				err = ErrOplogHistoryLost
@@ -465,7 +862,15 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 	lg := log.New("repl")
 
 	for change := range changeC {
-		if time.Since(r.lastBulkDoneAt) >= config.BulkOpsInterval && !r.bulkWrite.Empty() {
+		r.releaseApplied()
+		r.bulkMemory += change.size
+
+		if r.nsPauses.hasResumed() && !r.applyHeldChanges(ctx, nil) {
+			return
+		}
+
+		if (time.Since(r.lastBulkDoneAt) >= config.BulkOpsInterval || r.bulkWrite.Full()) &&
+			!r.bulkWrite.Empty() && r.canFlushBefore(change) {
 			if !r.doBulkOps(ctx) {
 				return
 			}
@@ -474,67 +879,111 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 		if change.OperationType == advanceTimePseudoEvent {
 			lg.With(log.OpTime(change.ClusterTime.T, change.ClusterTime.I)).Trace("tick")
 
-			r.lock.Lock()
-			r.lastReplicatedOpTime = change.ClusterTime
-			r.lock.Unlock()
+			r.advanceTime(change.ClusterTime)
 
 			continue
 		}
 
-		if change.Namespace.Database == config.PCSMDatabase {
-			if r.bulkWrite.Empty() {
-				r.lock.Lock()
-				r.lastReplicatedOpTime = change.ClusterTime
-				r.eventsProcessed++
-				r.lock.Unlock()
+		if r.options.Tap != nil {
+			r.options.Tap(change)
+		}
+
+		if checkEventAge {
+			checkEventAge = false
 
-				metrics.AddEventsProcessed(1)
+			if !r.checkEventAge(change, startAt) {
+				return
+			}
+		}
+
+		if !isKnownOperationType(change.OperationType) {
+			if !r.handleUnknownEvent(change) {
+				return
 			}
 
+			r.skipChange(change)
+
 			continue
 		}
 
 		if !r.nsFilter(change.Namespace.Database, change.Namespace.Collection) {
-			if r.bulkWrite.Empty() {
-				r.lock.Lock()
-				r.lastReplicatedOpTime = change.ClusterTime
-				r.eventsProcessed++
-				r.lock.Unlock()
+			r.skipChange(change)
+
+			continue
+		}
+
+		if len(r.options.Transform) != 0 {
+			err := r.options.Transform.Apply(ctx, change)
+			if err != nil {
+				r.setFailed(errors.Wrap(err, "transform"), "Transform change")
+
+				return
+			}
+		}
+
+		if r.options.OnNestingExceeded != "" {
+			err := checkNesting(changeFullDocument(change))
+			if err != nil {
+				if r.options.OnNestingExceeded == NestingExceededFail {
+					r.setFailed(errors.Wrapf(err, "%s %s", change.OperationType, change.Namespace),
+						"Nesting exceeded")
+
+					return
+				}
+
+				lg.With(log.OpTime(change.ClusterTime.T, change.ClusterTime.I)).
+					Warnf("Skip %s change of %s: %v", change.OperationType, change.Namespace, err)
+
+				r.skipChange(change)
 
-				metrics.AddEventsProcessed(1)
+				continue
 			}
+		}
+
+		if r.dedup.isDuplicate(change) {
+			lg.With(log.OpTime(change.ClusterTime.T, change.ClusterTime.I)).
+				Debugf("Skip duplicate %s change of %s", change.OperationType, change.Namespace)
+
+			r.skipChange(change)
 
 			continue
 		}
 
+		if r.ddl != nil && !r.waitDDL(change) {
+			return
+		}
+
 		switch change.OperationType { //nolint:exhaustive
-		case Insert:
-			event := change.Event.(InsertEvent) //nolint:forcetypeassert
+		case Insert, Update, Delete, Replace:
 			ns := findNamespaceByUUID(uuidMap, change)
-			r.bulkWrite.Insert(ns, &event)
-			r.bulkToken = change.ID
-			r.bulkTS = change.ClusterTime
 
-		case Update:
-			event := change.Event.(UpdateEvent) //nolint:forcetypeassert
-			ns := findNamespaceByUUID(uuidMap, change)
-			r.bulkWrite.Update(ns, &event)
-			r.bulkToken = change.ID
-			r.bulkTS = change.ClusterTime
+			if r.nsPauses.isPaused(ns) {
+				if r.nsPauses.hold(ns, change) <= config.MaxHeldChanges {
+					continue
+				}
 
-		case Delete:
-			event := change.Event.(DeleteEvent) //nolint:forcetypeassert
-			ns := findNamespaceByUUID(uuidMap, change)
-			r.bulkWrite.Delete(ns, &event)
-			r.bulkToken = change.ID
-			r.bulkTS = change.ClusterTime
+				lg.Warnf("Held changes of the paused namespaces exceed %d. Resuming %q",
+					config.MaxHeldChanges, r.nsPauses.list())
+
+				r.nsPauses.resumeAll()
+
+				if !r.applyHeldChanges(ctx, nil) {
+					return
+				}
+
+				continue
+			}
+
+			err := r.bufferChange(ns, change)
+			if err != nil {
+				r.setFailed(err, "Apply change")
+
+				return
+			}
 
-		case Replace:
-			event := change.Event.(ReplaceEvent) //nolint:forcetypeassert
-			ns := findNamespaceByUUID(uuidMap, change)
-			r.bulkWrite.Replace(ns, &event)
 			r.bulkToken = change.ID
 			r.bulkTS = change.ClusterTime
+			r.trackTxn(change)
 
 		default:
 			if !r.bulkWrite.Empty() {
@@ -543,19 +992,31 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 				}
 			}
 
-			err := r.applyDDLChange(ctx, change)
-			if err != nil {
-				r.setFailed(err, "Apply change")
+			if !r.applyHeldChanges(ctx, func(ns Namespace) bool { return affectedByDDL(change, ns) }) {
+				return
+			}
+
+			if r.options.PauseOnDDL && requiresDDLApproval(change.OperationType) &&
+				!r.takeDDLApproval(change) {
+				r.pauseOnDDL(change, startAt)
 
 				return
 			}
 
-			r.lock.Lock()
-			r.lastReplicatedOpTime = change.ClusterTime
-			r.eventsProcessed++
-			r.lock.Unlock()
+			if r.ddl != nil && isConcurrentDDL(change.OperationType) {
+				r.ddl.schedule(ctx, change.Namespace, change.ClusterTime, func(ctx context.Context) error {
+					return r.applyDDLChange(ctx, change)
+				})
+			} else {
+				err := r.applyDDLChange(ctx, change)
+				if err != nil {
+					r.setFailed(err, "Apply change")
 
-			metrics.AddEventsProcessed(1)
+					return
+				}
+			}
+
+			r.markReplicated(change)
 
 			switch change.OperationType { //nolint:exhaustive
 			case Create, Rename, Drop, DropDatabase:
@@ -563,7 +1024,7 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 			}
 		}
 
-		if r.bulkWrite.Full() {
+		if r.bulkWrite.Full() && r.canFlushBefore(nil) {
 			if !r.doBulkOps(ctx) {
 				return
 			}
@@ -575,6 +1036,136 @@ func (r *Repl) run(opts *options.ChangeStreamOptionsBuilder) {
 	}
 }
 
+// bufferChange adds the CRUD change of the namespace to the bulk write.
+func (r *Repl) bufferChange(ns Namespace, change *ChangeEvent) error {
+	target := r.catalog.TargetNamespace(ns)
+
+	switch change.OperationType { //nolint:exhaustive
+	case Insert:
+		event := change.Event.(InsertEvent) //nolint:forcetypeassert
+
+		err := ensureDocumentID(change, &event)
+		if err != nil {
+			return errors.Wrap(err, "document _id")
+		}
+
+		r.bulkWrite.Insert(target, &event)
+		r.trackChange(ns, change, &event)
+
+	case Update:
+		event := change.Event.(UpdateEvent) //nolint:forcetypeassert
+
+		upsert, err := r.updateUpsert(&event)
+		if err != nil {
+			return err
+		}
+
+		if upsert != nil {
+			r.bulkWrite.Insert(target, upsert)
+		} else {
+			r.bulkWrite.Update(target, &event)
+		}
+
+		r.trackChange(ns, change, &event)
+
+	case Delete:
+		event := change.Event.(DeleteEvent) //nolint:forcetypeassert
+		r.bulkWrite.Delete(target, &event)
+		r.trackChange(ns, change, &event)
+
+	case Replace:
+		event := change.Event.(ReplaceEvent) //nolint:forcetypeassert
+		r.bulkWrite.Replace(target, &event)
+		r.trackChange(ns, change, &event)
+	}
+
+	return nil
+}
+
+// applyHeldChanges writes the held changes of the resumed namespaces, and of the paused
+// namespaces for which force returns true, in the order they are received. The buffered
+// changes are written before. The last replicated optime is not changed: the held changes
+// are before it. It returns false if the write has failed.
+func (r *Repl) applyHeldChanges(ctx context.Context, force func(Namespace) bool) bool {
+	held := r.nsPauses.release(force)
+	if len(held) == 0 {
+		return true
+	}
+
+	if !r.bulkWrite.Empty() && !r.doBulkOps(ctx) {
+		return false
+	}
+
+	for i, h := range held {
+		err := r.bufferChange(h.ns, h.change)
+		if err != nil {
+			r.setFailed(err, "Apply held change")
+
+			return false
+		}
+
+		if !r.bulkWrite.Full() && i != len(held)-1 {
+			continue
+		}
+
+		size, err := r.writeBulk(ctx)
+		if err != nil {
+			r.setFailed(err, "Flush held changes")
+
+			return false
+		}
+
+		r.lock.Lock()
+		r.eventsProcessed += int64(size)
+		r.lock.Unlock()
+
+		metrics.AddEventsProcessed(size)
+	}
+
+	r.nsPauses.remove(held)
+
+	log.New("repl").Infof("Applied %d held changes of the paused namespaces", len(held))
+
+	return true
+}
+
+// affectedByDDL reports whether the held changes of the namespace must be applied before
+// the DDL change: the DDL change is of the same database or renames into it.
+func affectedByDDL(change *ChangeEvent, ns Namespace) bool {
+	if ns.Database == change.Namespace.Database {
+		return true
+	}
+
+	if event, ok := change.Event.(RenameEvent); ok {
+		return ns.Database == event.OperationDescription.To.Database
+	}
+
+	return false
+}
+
+// waitDDL waits for the scheduled DDL changes that the change is applied after: the DDL changes
+// of the namespace for a CRUD change, and all of them for a DDL change not applied concurrently.
+// It returns false if a scheduled DDL change has failed.
+func (r *Repl) waitDDL(change *ChangeEvent) bool {
+	switch change.OperationType { //nolint:exhaustive
+	case Insert, Update, Delete, Replace:
+		r.ddl.wait(change.Namespace)
+	default:
+		if !isConcurrentDDL(change.OperationType) {
+			r.ddl.waitAll()
+		}
+	}
+
+	err := r.ddl.failed()
+	if err != nil {
+		r.setFailed(err, "Apply change")
+
+		return false
+	}
+
+	return true
+}
+
 //go:inline
 func findNamespaceByUUID(uuidMap UUIDMap, change *ChangeEvent) Namespace {
 	if change.CollectionUUID == nil {
@@ -589,20 +1180,58 @@ func findNamespaceByUUID(uuidMap UUIDMap, change *ChangeEvent) Namespace {
 	return ns
 }
 
+// skipChange advances the last replicated optime to the change that is not applied.
+// The optime is not advanced while the bulk write buffers the earlier changes.
+func (r *Repl) skipChange(change *ChangeEvent) {
+	if r.bulkWrite.Empty() {
+		r.markReplicated(change)
+	}
+}
+
+// markReplicated advances the last replicated optime and the resume token to the change.
+func (r *Repl) markReplicated(change *ChangeEvent) {
+	r.lock.Lock()
+	r.lastReplicatedOpTime = change.ClusterTime
+	r.lastReplicatedToken = change.ID
+	r.eventsProcessed++
+	r.lock.Unlock()
+
+	metrics.AddEventsProcessed(1)
+}
+
+// advanceTime moves the last replicated optime to the source cluster time without changes.
+// The optime is not moved past the buffered changes that are not written to the target yet.
+func (r *Repl) advanceTime(ts bson.Timestamp) {
+	if !r.bulkWrite.Empty() {
+		return
+	}
+
+	r.lock.Lock()
+	r.lastReplicatedOpTime = ts
+	r.lock.Unlock()
+}
+
+// doBulkOps writes the buffered changes to the target. The last replicated optime (the resume
+// position of the checkpoint) is advanced to the last buffered change only after the write is
+// acknowledged with the write concern of the target client (majority). A failed write,
+// including a write concern error, fails the replication without advancing the optime.
 func (r *Repl) doBulkOps(ctx context.Context) bool {
-	size, err := r.bulkWrite.Do(ctx, r.target)
+	size, err := r.writeBulk(ctx)
 	if err != nil {
 		r.setFailed(err, "Flush bulk ops")
 
 		return false
 	}
 
+	r.releaseApplied()
+
 	if size == 0 {
 		return true
 	}
 
 	r.lock.Lock()
 	r.lastReplicatedOpTime = r.bulkTS
+	r.lastReplicatedToken = r.bulkToken
 	r.eventsProcessed += int64(size)
 	r.lock.Unlock()
 
@@ -617,6 +1246,113 @@ func (r *Repl) doBulkOps(ctx context.Context) bool {
 	return true
 }
 
+// releaseApplied releases the memory of the changes read so far once none of them is
+// buffered in the bulk write: they are applied or skipped.
+func (r *Repl) releaseApplied() {
+	if r.bulkWrite.Empty() {
+		r.options.Memory.release(r.bulkMemory)
+		r.bulkMemory = 0
+	}
+}
+
+// writeBulk writes the buffered changes to the target. With the dead-letter collection,
+// the changes of a bulk write failed with a write error are applied one by one.
+func (r *Repl) writeBulk(ctx context.Context) (int, error) {
+	size, err := r.bulkWrite.Do(ctx, r.target)
+	if err != nil && r.options.DeadLetter.Database != "" && topo.IsWriteError(err) {
+		log.New("bulk:write").Warnf("Bulk write has failed: %v. Apply one by one", err)
+
+		size, err = r.applyWithDeadLetter(ctx)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	clear(r.bulkChanges)
+	r.bulkChanges = r.bulkChanges[:0]
+	r.bulkTxn = nil
+
+	return size, nil
+}
+
+// canFlushBefore reports whether the bulk write can be flushed before the next change.
+// With PreserveTxnOrder, a transaction is not split across bulk writes: the bulk write
+// of a transaction is flushed only before a change of another transaction or a change
+// outside of a transaction. A nil next change is not known yet.
+func (r *Repl) canFlushBefore(next *ChangeEvent) bool {
+	if r.bulkTxn == nil {
+		return true
+	}
+
+	return next != nil && !r.bulkTxn.IsSameTransaction(&next.EventHeader)
+}
+
+// trackTxn remembers the buffered change of a transaction if PreserveTxnOrder is set.
+func (r *Repl) trackTxn(change *ChangeEvent) {
+	r.bulkTxn = nil
+	if r.options.PreserveTxnOrder && change.IsTransaction() {
+		r.bulkTxn = &change.EventHeader
+	}
+}
+
+// trackChange buffers the change for the one-by-one apply if the dead-letter collection is set.
+func (r *Repl) trackChange(ns Namespace, change *ChangeEvent, event any) {
+	if r.options.DeadLetter.Database != "" {
+		r.bulkChanges = append(r.bulkChanges, pendingChange{ns: ns, change: change, event: event})
+	}
+}
+
+// applyWithDeadLetter applies the buffered changes one by one. The changes that fail
+// with a write error are stored in the dead-letter collection with the target namespace.
+// Other errors fail the apply.
+func (r *Repl) applyWithDeadLetter(ctx context.Context) (int, error) {
+	var entries []*deadLetterEntry
+
+	for _, p := range r.bulkChanges {
+		lg := loggerForEvent(p.change)
+		target := r.catalog.TargetNamespace(p.ns)
+
+		attempts, applyErr := applyWithDocRetries(lg.WithContext(ctx), r.options.MaxDocRetries,
+			config.DocRetryInterval, func(ctx context.Context) error {
+				return applyOne(ctx, r.target, target, p.event, r.options.bulkOptions())
+			})
+		if applyErr == nil {
+			continue
+		}
+
+		if !topo.IsWriteError(applyErr) {
+			return 0, applyErr
+		}
+
+		lg.Warnf("Store failed change in the dead-letter collection after %d attempts: %v",
+			attempts, applyErr)
+
+		entry, err := newDeadLetterEntry(target, p.change, applyErr, attempts)
+		if err != nil {
+			return 0, errors.Wrap(err, "dead-letter entry")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 0 {
+		err := writeDeadLetter(ctx, r.target, r.options.DeadLetter, entries)
+		if err != nil {
+			return 0, errors.Wrap(err, "write dead-letter")
+		}
+	}
+
+	size := len(r.bulkChanges)
+	r.bulkWrite.Reset()
+
+	r.lock.Lock()
+	r.deadLettered += int64(len(entries))
+	r.lock.Unlock()
+
+	return size, nil
+}
+
 // applyDDLChange applies a schema change to the target MongoDB.
 func (r *Repl) applyDDLChange(ctx context.Context, change *ChangeEvent) error {
 	lg := loggerForEvent(change)
@@ -670,7 +1406,13 @@ func (r *Repl) applyDDLChange(ctx context.Context, change *ChangeEvent) error {
 		lg.Infof("Collection %q has been dropped", change.Namespace)
 
 	case DropDatabase:
-		err = r.catalog.DropDatabase(ctx, change.Namespace.Database)
+		if r.options.SkipDropDatabase {
+			lg.Warnf("Database %q has been dropped on the source. Skipping on the target", change.Namespace)
+
+			break
+		}
+
+		err = r.catalog.DropDatabase(ctx, change.Namespace.Database, r.nsFilter)
 		if err != nil {
 			break
 		}
@@ -742,6 +1484,8 @@ func (r *Repl) applyDDLChange(ctx context.Context, change *ChangeEvent) error {
 		return errors.Wrap(err, string(change.OperationType))
 	}
 
+	r.auditDDL(ctx, change)
+
 	return nil
 }
 
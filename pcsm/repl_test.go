@@ -0,0 +1,373 @@
+package pcsm //nolint
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+)
+
+func TestRequiresDDLApproval(t *testing.T) {
+	t.Parallel()
+
+	for _, op := range []OperationType{Create, Drop, DropDatabase, Rename, Modify} {
+		if !requiresDDLApproval(op) {
+			t.Errorf("%s: got = false, want true", op)
+		}
+	}
+
+	for _, op := range []OperationType{Insert, Update, CreateIndexes, DropIndexes} {
+		if requiresDDLApproval(op) {
+			t.Errorf("%s: got = true, want false", op)
+		}
+	}
+}
+
+func TestRepl_ApproveDDL(t *testing.T) {
+	t.Parallel()
+
+	drop := &ChangeEvent{
+		EventHeader: EventHeader{
+			OperationType: Drop,
+			Namespace:     Namespace{Database: "db1", Collection: "coll1"},
+			ClusterTime:   bson.Timestamp{T: 100, I: 1},
+		},
+	}
+
+	r := &Repl{
+		pauseC:  make(chan struct{}),
+		doneSig: make(chan struct{}),
+	}
+
+	_, err := r.ApproveDDL()
+	if err == nil {
+		t.Fatal("no pending: got = nil, want error")
+	}
+
+	if r.takeDDLApproval(drop) {
+		t.Fatal("not approved: got = true, want false")
+	}
+
+	r.pendingDDL = newDDLChange(drop)
+
+	ddl, err := r.ApproveDDL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *ddl != *newDDLChange(drop) || r.Status().PendingDDL != nil {
+		t.Errorf("got = %+v, pending %+v", ddl, r.Status().PendingDDL)
+	}
+
+	other := *drop
+	other.ClusterTime = bson.Timestamp{T: 100, I: 2}
+
+	if r.takeDDLApproval(&other) {
+		t.Error("other change: got = true, want false")
+	}
+
+	if !r.takeDDLApproval(drop) {
+		t.Error("approved: got = false, want true")
+	}
+
+	if r.takeDDLApproval(drop) {
+		t.Error("used approval: got = true, want false")
+	}
+}
+
+func TestRepl_ChangeStreamOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		options       ReplOptions
+		wantBatchSize int32
+		wantMaxAwait  time.Duration
+	}{
+		{
+			"default",
+			ReplOptions{},
+			config.ChangeStreamBatchSize,
+			config.ChangeStreamAwaitTime,
+		},
+		{
+			"configured",
+			ReplOptions{ChangeStreamBatchSize: 100, ChangeStreamMaxAwaitTime: 250 * time.Millisecond},
+			100,
+			250 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &Repl{options: test.options}
+			startAt := bson.Timestamp{T: 100, I: 1}
+
+			var opts options.ChangeStreamOptions
+
+			for _, set := range r.changeStreamOptions(startAt).List() {
+				err := set(&opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if opts.BatchSize == nil || *opts.BatchSize != test.wantBatchSize {
+				t.Errorf("batch size: got = %v, want %d", opts.BatchSize, test.wantBatchSize)
+			}
+
+			if opts.MaxAwaitTime == nil || *opts.MaxAwaitTime != test.wantMaxAwait {
+				t.Errorf("max await time: got = %v, want %s", opts.MaxAwaitTime, test.wantMaxAwait)
+			}
+
+			if opts.StartAtOperationTime == nil || *opts.StartAtOperationTime != startAt {
+				t.Errorf("start at: got = %v, want %v", opts.StartAtOperationTime, startAt)
+			}
+		})
+	}
+}
+
+func TestRepl_PreserveTxnOrder(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	txnNumber := int64(1)
+	txn := EventHeader{
+		OperationType: Insert,
+		Namespace:     ns,
+		TxnNumber:     &txnNumber,
+		LSID:          mustMarshal(t, bson.D{{"id", "session_1"}}),
+	}
+	insert := func(header EventHeader, id int) *ChangeEvent {
+		header.OperationType = Insert
+
+		return &ChangeEvent{EventHeader: header, Event: InsertEvent{
+			DocumentKey:  bson.D{{"_id", id}},
+			FullDocument: mustMarshal(t, bson.D{{"_id", id}}),
+		}}
+	}
+	update := func(header EventHeader, id int) *ChangeEvent {
+		header.OperationType = Update
+
+		return &ChangeEvent{EventHeader: header, Event: UpdateEvent{
+			DocumentKey:       bson.D{{"_id", id}},
+			UpdateDescription: UpdateDescription{UpdatedFields: bson.D{{"n", 2}}},
+		}}
+	}
+
+	// the transaction inserts a document and then updates it
+	changes := []*ChangeEvent{
+		insert(txn, 1), update(txn, 1), insert(txn, 2),
+		insert(EventHeader{Namespace: ns}, 3),
+	}
+
+	// replay the changes with the flush points of the run loop and
+	// return the operation types of each flushed bulk write in order
+	replay := func(preserveTxnOrder bool) [][]string {
+		bw := newCollectionBulkWrite(2, bulkOptions{})
+		r := &Repl{options: ReplOptions{PreserveTxnOrder: preserveTxnOrder}, bulkWrite: bw}
+
+		var bulks [][]string
+
+		flush := func() {
+			var ops []string
+			for _, model := range bw.writes[ns] {
+				switch model.(type) {
+				case *mongo.ReplaceOneModel: // an insert is an upsert
+					ops = append(ops, "insert")
+				case *mongo.UpdateOneModel:
+					ops = append(ops, "update")
+				}
+			}
+
+			bulks = append(bulks, ops)
+			bw.Reset()
+			r.bulkTxn = nil
+		}
+
+		for _, change := range changes {
+			if bw.Full() && r.canFlushBefore(change) {
+				flush()
+			}
+
+			switch event := change.Event.(type) {
+			case InsertEvent:
+				bw.Insert(ns, &event)
+			case UpdateEvent:
+				bw.Update(ns, &event)
+			}
+
+			r.trackTxn(change)
+
+			if bw.Full() && r.canFlushBefore(nil) {
+				flush()
+			}
+		}
+
+		if !bw.Empty() {
+			flush()
+		}
+
+		return bulks
+	}
+
+	got := replay(true)
+	want := [][]string{{"insert", "update", "insert"}, {"insert"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("preserve txn order: got = %v, want %v", got, want)
+	}
+
+	got = replay(false)
+	want = [][]string{{"insert", "update"}, {"insert", "insert"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("default: got = %v, want %v", got, want)
+	}
+}
+
+// fakeBulkWrite is a bulk write of the buffered number of changes that fails with the error.
+type fakeBulkWrite struct {
+	count int
+	err   error
+}
+
+func (o *fakeBulkWrite) Full() bool  { return false }
+func (o *fakeBulkWrite) Empty() bool { return o.count == 0 }
+func (o *fakeBulkWrite) Reset()      { o.count = 0 }
+
+func (o *fakeBulkWrite) Do(context.Context, *mongo.Client) (int, error) {
+	if o.err != nil {
+		return 0, o.err
+	}
+
+	n := o.count
+	o.count = 0
+
+	return n, nil
+}
+
+func (o *fakeBulkWrite) Insert(Namespace, *InsertEvent)   { o.count++ }
+func (o *fakeBulkWrite) Update(Namespace, *UpdateEvent)   { o.count++ }
+func (o *fakeBulkWrite) Replace(Namespace, *ReplaceEvent) { o.count++ }
+func (o *fakeBulkWrite) Delete(Namespace, *DeleteEvent)   { o.count++ }
+
+func TestRepl_OpTimeAdvancesAfterDurableWrite(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	bw := &fakeBulkWrite{}
+	r := &Repl{
+		bulkWrite: bw,
+		pauseC:    make(chan struct{}, 1),
+		doneSig:   make(chan struct{}),
+	}
+	close(r.doneSig)
+
+	r.lastReplicatedOpTime = bson.Timestamp{T: 100}
+
+	bw.Insert(ns, &InsertEvent{})
+	r.bulkTS = bson.Timestamp{T: 101}
+
+	// the source time does not advance past the buffered change
+	r.advanceTime(bson.Timestamp{T: 102})
+	if got := r.lastReplicatedOpTime; got != (bson.Timestamp{T: 100}) {
+		t.Fatalf("tick with a buffered change: got = %v, want {100 0}", got)
+	}
+
+	// the write concern is not satisfied
+	bw.err = mongo.BulkWriteException{
+		WriteConcernError: &mongo.WriteConcernError{Code: 64, Name: "WriteConcernTimeout"},
+	}
+
+	if r.doBulkOps(t.Context()) {
+		t.Fatal("write concern error: got = true, want false")
+	}
+
+	if got := r.lastReplicatedOpTime; got != (bson.Timestamp{T: 100}) {
+		t.Errorf("write concern error: got = %v, want {100 0}", got)
+	}
+
+	if r.err == nil {
+		t.Error("write concern error: the replication is not failed")
+	}
+
+	bw.err = nil
+
+	if !r.doBulkOps(t.Context()) {
+		t.Fatal("acknowledged: got = false, want true")
+	}
+
+	if got := r.lastReplicatedOpTime; got != (bson.Timestamp{T: 101}) {
+		t.Errorf("acknowledged: got = %v, want {101 0}", got)
+	}
+
+	r.advanceTime(bson.Timestamp{T: 102})
+	if got := r.lastReplicatedOpTime; got != (bson.Timestamp{T: 102}) {
+		t.Errorf("tick: got = %v, want {102 0}", got)
+	}
+}
+
+func TestRepl_UpdateAsUpsert(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	event := UpdateEvent{
+		DocumentKey:       bson.D{{"_id", 1}},
+		FullDocument:      bson.D{{"_id", 1}, {"a", 1}, {"n", 2}},
+		UpdateDescription: UpdateDescription{UpdatedFields: bson.D{{"n", 2}}},
+	}
+
+	r := &Repl{options: ReplOptions{UpdateAsUpsert: true}}
+
+	// the updated document is missing on the target: the full document is upserted
+	upsert, err := r.updateUpsert(&event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bw := newCollectionBulkWrite(10, bulkOptions{})
+	bw.Insert(ns, upsert)
+
+	model, ok := bw.writes[ns][0].(*mongo.ReplaceOneModel)
+	if !ok || model.Upsert == nil || !*model.Upsert {
+		t.Fatalf("got = %#v, want a replace with upsert", bw.writes[ns][0])
+	}
+
+	want := mustMarshal(t, event.FullDocument)
+	if got, _ := model.Replacement.(bson.Raw); !bytes.Equal(got, want) {
+		t.Errorf("replacement: got = %v, want %v", model.Replacement, want)
+	}
+
+	// deleted before the lookup: the update is applied as is
+	upsert, err = r.updateUpsert(&UpdateEvent{DocumentKey: event.DocumentKey})
+	if err != nil || upsert != nil {
+		t.Errorf("no full document: got = %v, %v, want nil", upsert, err)
+	}
+
+	upsert, err = (&Repl{}).updateUpsert(&event)
+	if err != nil || upsert != nil {
+		t.Errorf("disabled: got = %v, %v, want nil", upsert, err)
+	}
+
+	// the change stream looks up the full document of the updates
+	var opts options.ChangeStreamOptions
+
+	for _, set := range r.changeStreamOptions(bson.Timestamp{T: 100}).List() {
+		err := set(&opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if opts.FullDocument == nil || *opts.FullDocument != options.UpdateLookup {
+		t.Errorf("full document: got = %v, want %q", opts.FullDocument, options.UpdateLookup)
+	}
+}
@@ -0,0 +1,123 @@
+package pcsm
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// ErrReplicationUnavailable indicates that the source supports none of the replication methods.
+var ErrReplicationUnavailable = errors.New("replication is unavailable")
+
+// ReplicationMethod is the method of reading changes from the source.
+type ReplicationMethod string
+
+const (
+	// ReplicationAuto selects change streams if supported by the source. Otherwise, oplog tailing.
+	ReplicationAuto ReplicationMethod = "auto"
+	// ReplicationChangeStream reads changes with a cluster-wide change stream.
+	ReplicationChangeStream ReplicationMethod = "changestream"
+	// ReplicationOplog reads changes by tailing the local.oplog.rs collection.
+	ReplicationOplog ReplicationMethod = "oplog"
+)
+
+// ParseReplicationMethod parses the replication method. The empty string is [ReplicationAuto].
+func ParseReplicationMethod(s string) (ReplicationMethod, error) {
+	switch m := ReplicationMethod(s); m {
+	case "":
+		return ReplicationAuto, nil
+	case ReplicationAuto, ReplicationChangeStream, ReplicationOplog:
+		return m, nil
+	}
+
+	return "", errors.Errorf("invalid replication method %q", s)
+}
+
+// sourceCapabilities describes the replication methods supported by the source.
+type sourceCapabilities struct {
+	ChangeStream bool // a cluster-wide change stream can be opened
+	Oplog        bool // the source is a replica set member with the local.oplog.rs collection
+}
+
+// detectSourceCapabilities checks which replication methods the source supports.
+func detectSourceCapabilities(ctx context.Context, m *mongo.Client) (sourceCapabilities, error) {
+	var caps sourceCapabilities
+
+	hello, err := topo.SayHello(ctx, m)
+	if err != nil {
+		return caps, errors.Wrap(err, "hello")
+	}
+
+	isMongos := hello.Msg == "isdbgrid"
+
+	if isMongos || hello.SetName != "" {
+		cur, err := m.Watch(ctx, mongo.Pipeline{})
+		if err != nil {
+			log.Ctx(ctx).Debugf("Change stream is unavailable: %v", err)
+		} else {
+			caps.ChangeStream = true
+
+			err = cur.Close(ctx)
+			if err != nil {
+				log.Ctx(ctx).Error(err, "Close change stream cursor")
+			}
+		}
+	}
+
+	if !isMongos && hello.SetName != "" {
+		names, err := m.Database("local").ListCollectionNames(ctx, bson.D{{"name", "oplog.rs"}})
+		if err != nil {
+			log.Ctx(ctx).Debugf("Oplog is unavailable: %v", err)
+		} else {
+			caps.Oplog = len(names) != 0
+		}
+	}
+
+	return caps, nil
+}
+
+// resolveReplicationMethod selects the replication method supported by the source.
+func resolveReplicationMethod(
+	method ReplicationMethod,
+	caps sourceCapabilities,
+) (ReplicationMethod, error) {
+	switch method {
+	case ReplicationChangeStream:
+		if !caps.ChangeStream {
+			return "", errors.Wrap(ErrReplicationUnavailable,
+				"the source does not support change streams (requires a replica set or sharded cluster "+
+					"and the changeStream privilege). Use --replication-method=oplog or auto")
+		}
+
+		return ReplicationChangeStream, nil
+
+	case ReplicationOplog:
+		if !caps.Oplog {
+			return "", errors.Wrap(ErrReplicationUnavailable,
+				"the source oplog is unavailable (requires a replica set member and read access "+
+					"to local.oplog.rs). Use --replication-method=changestream or auto")
+		}
+
+		return ReplicationOplog, nil
+
+	case ReplicationAuto, "":
+		if caps.ChangeStream {
+			return ReplicationChangeStream, nil
+		}
+
+		if caps.Oplog {
+			return ReplicationOplog, nil
+		}
+
+		return "", errors.Wrap(ErrReplicationUnavailable,
+			"the source supports neither change streams nor oplog tailing. "+
+				"Convert a standalone source into a single-node replica set")
+	}
+
+	return "", errors.Errorf("invalid replication method %q", method)
+}
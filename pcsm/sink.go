@@ -0,0 +1,100 @@
+package pcsm
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// EventSink receives the change events of the source in the order of the change stream
+// instead of a MongoDB target.
+type EventSink interface {
+	Write(change *ChangeEvent) error
+}
+
+// JSONLSink serializes the change events to the writer as JSON Lines: one relaxed extended
+// JSON document per event. The event data (e.g. fullDocument) is in the "event" field.
+type JSONLSink struct {
+	w io.Writer
+}
+
+// NewJSONLSink returns a sink that writes the change events to w.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w}
+}
+
+type sinkEvent struct {
+	EventHeader `bson:",inline"`
+
+	Event any `bson:"event,omitempty"`
+}
+
+// Write writes the change event as a single line.
+func (s *JSONLSink) Write(change *ChangeEvent) error {
+	line, err := bson.MarshalExtJSON(sinkEvent{change.EventHeader, change.Event}, false, false)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	_, err = s.w.Write(append(line, '\n'))
+
+	return errors.Wrap(err, "write")
+}
+
+// WatchToSink writes the change events of the source to the sink from the current cluster time
+// until the context is canceled. The changes of the internal databases are skipped.
+func WatchToSink(ctx context.Context, source *mongo.Client, sink EventSink) error {
+	startAt, err := topo.ClusterTime(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "get source cluster time")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := NewRepl(source, nil, nil, nil, ReplOptions{})
+
+	changeC := make(chan *ChangeEvent, config.ReplQueueSize)
+	errC := make(chan error, 1)
+
+	go func() {
+		defer close(changeC)
+
+		errC <- r.watchChangeEvents(ctx, r.changeStreamOptions(startAt), changeC)
+	}()
+
+	log.Ctx(ctx).With(log.OpTime(startAt.T, startAt.I)).Info("Writing change events to the sink")
+
+	for change := range changeC {
+		if change.OperationType == advanceTimePseudoEvent ||
+			change.Namespace.Database == config.PCSMDatabase ||
+			isInternalDatabase(change.Namespace.Database) {
+			continue
+		}
+
+		err := sink.Write(change)
+		if err != nil {
+			cancel()
+
+			for range changeC { //nolint:revive
+				// unblock the change stream until it is closed
+			}
+
+			return errors.Wrap(err, "sink")
+		}
+	}
+
+	err = <-errC
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return errors.Wrap(err, "watch")
+}
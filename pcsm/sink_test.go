@@ -0,0 +1,104 @@
+package pcsm //nolint
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestJSONLSink(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	token := func(s string) bson.Raw { return mustMarshal(t, bson.D{{"_data", s}}) }
+	changes := []*ChangeEvent{
+		{
+			EventHeader: EventHeader{OperationType: Insert, Namespace: ns, ID: token("01"),
+				ClusterTime: bson.Timestamp{T: 1, I: 1}},
+			Event: InsertEvent{
+				DocumentKey:  bson.D{{"_id", 1}},
+				FullDocument: mustMarshal(t, bson.D{{"_id", 1}, {"i", "a"}}),
+			},
+		},
+		{
+			EventHeader: EventHeader{OperationType: Delete, Namespace: ns, ID: token("02"),
+				ClusterTime: bson.Timestamp{T: 1, I: 2}},
+			Event: DeleteEvent{DocumentKey: bson.D{{"_id", 1}}},
+		},
+		{
+			EventHeader: EventHeader{OperationType: Drop, Namespace: ns, ID: token("03"),
+				ClusterTime: bson.Timestamp{T: 2, I: 1}},
+			Event: DropEvent{},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := NewJSONLSink(f)
+	for _, change := range changes {
+		err := sink.Write(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type sinkLine struct {
+		OperationType OperationType  `bson:"operationType"`
+		Namespace     Namespace      `bson:"ns"`
+		ClusterTime   bson.Timestamp `bson:"clusterTime"`
+		Event         struct {
+			FullDocument struct {
+				I string `bson:"i"`
+			} `bson:"fullDocument"`
+		} `bson:"event"`
+	}
+
+	var lines []sinkLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var line sinkLine
+
+		err := bson.UnmarshalExtJSON(scanner.Bytes(), false, &line)
+		if err != nil {
+			t.Fatalf("line %d: %v: %s", len(lines), err, scanner.Text())
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) != len(changes) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(changes))
+	}
+
+	for i, change := range changes {
+		got := lines[i]
+		if got.OperationType != change.OperationType ||
+			got.ClusterTime != change.ClusterTime ||
+			got.Namespace != change.Namespace {
+			t.Errorf("line %d: got %s %v %s, want %s %v %s", i,
+				got.OperationType, got.ClusterTime, got.Namespace,
+				change.OperationType, change.ClusterTime, change.Namespace)
+		}
+	}
+
+	if got := lines[0].Event.FullDocument.I; got != "a" {
+		t.Errorf("got fullDocument.i %q, want %q", got, "a")
+	}
+}
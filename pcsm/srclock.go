@@ -0,0 +1,155 @@
+package pcsm
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// sourceLocker blocks and unblocks the writes on the source.
+type sourceLocker interface {
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// LastOpTime returns the optime of the newest oplog entry of the source. On a sharded
+	// cluster, it is the newest of the shards.
+	LastOpTime(ctx context.Context) (bson.Timestamp, error)
+}
+
+// fsyncLocker locks the source with fsyncLock. On a sharded cluster, mongos locks all shards.
+type fsyncLocker struct {
+	client *mongo.Client
+	uri    string // connection string of the source for the shards oplog
+}
+
+func (l fsyncLocker) Lock(ctx context.Context) error {
+	hello, err := topo.SayHello(ctx, l.client)
+	if err != nil {
+		return errors.Wrap(err, "hello")
+	}
+
+	if hello.Msg == "isdbgrid" {
+		version, err := topo.Version(ctx, l.client)
+		if err != nil {
+			return errors.Wrap(err, "version")
+		}
+
+		if !topo.Support(version).FsyncLockThroughMongos() {
+			return errors.Errorf("fsyncLock through mongos requires 6.0.11+, 7.0.2+, or 7.1+ (%s)",
+				version)
+		}
+	}
+
+	err = l.client.Database("admin").RunCommand(ctx, bson.D{{"fsync", 1}, {"lock", true}}).Err()
+
+	return errors.Wrap(err, "fsyncLock")
+}
+
+func (l fsyncLocker) Unlock(ctx context.Context) error {
+	err := l.client.Database("admin").RunCommand(ctx, bson.D{{"fsyncUnlock", 1}}).Err()
+
+	return errors.Wrap(err, "fsyncUnlock")
+}
+
+func (l fsyncLocker) LastOpTime(ctx context.Context) (bson.Timestamp, error) {
+	hello, err := topo.SayHello(ctx, l.client)
+	if err != nil {
+		return bson.Timestamp{}, errors.Wrap(err, "hello")
+	}
+
+	if hello.Msg != "isdbgrid" {
+		ts, err := topo.NewestOplogTimestamp(ctx, l.client)
+
+		return ts, errors.Wrap(err, "newest oplog entry")
+	}
+
+	shards, err := topo.ShardNewestOplogTimestamps(ctx, l.client, l.uri)
+	if err != nil {
+		return bson.Timestamp{}, errors.Wrap(err, "newest shard oplog entry")
+	}
+
+	var last bson.Timestamp
+	for _, ts := range shards {
+		if ts.After(last) {
+			last = ts
+		}
+	}
+
+	return last, nil
+}
+
+// sourceLock is the source lock held by the finalization. The nil sourceLock does nothing.
+type sourceLock struct {
+	locker sourceLocker
+}
+
+// lockSource locks the source writes, so that no write is made after the cutover point.
+func lockSource(ctx context.Context, locker sourceLocker) (*sourceLock, error) {
+	log.New("finalize").Warn("Locking the source writes (fsyncLock). The source stays read-only " +
+		"after the finalization until it is unlocked with db.fsyncUnlock()")
+
+	err := locker.Lock(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lock source")
+	}
+
+	return &sourceLock{locker: locker}, nil
+}
+
+// unlockOnError unlocks the source if the finalization failed with err. It returns err.
+func (l *sourceLock) unlockOnError(err error) error {
+	if l == nil || err == nil {
+		return err
+	}
+
+	lg := log.New("finalize")
+
+	//nolint:contextcheck
+	unlockErr := l.locker.Unlock(context.Background())
+	if unlockErr != nil {
+		lg.Error(unlockErr, "Unlock the source after the failed finalization. "+
+			"Unlock it with db.fsyncUnlock()")
+
+		return err
+	}
+
+	lg.Info("Source is unlocked after the failed finalization")
+
+	return err
+}
+
+// waitCaughtUp waits for the change replication to apply the source changes up to the optime.
+// It fails if the change replication stops or does not catch up within
+// [config.FinalizeCatchUpTimeout].
+func waitCaughtUp(ctx context.Context, repl *Repl, optime bson.Timestamp) error {
+	ctx, cancel := context.WithTimeout(ctx, config.FinalizeCatchUpTimeout)
+	defer cancel()
+
+	t := time.NewTicker(config.FinalizeCatchUpInterval)
+	defer t.Stop()
+
+	for {
+		status := repl.Status()
+		if !status.LastReplicatedOpTime.Before(optime) {
+			return nil
+		}
+
+		if status.Err != nil || !status.IsRunning() {
+			return errors.Errorf("change replication stopped at %d.%d before %d.%d",
+				status.LastReplicatedOpTime.T, status.LastReplicatedOpTime.I, optime.T, optime.I)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "change replication at %d.%d: wait for %d.%d",
+				status.LastReplicatedOpTime.T, status.LastReplicatedOpTime.I, optime.T, optime.I)
+		case <-t.C:
+		}
+	}
+}
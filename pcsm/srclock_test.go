@@ -0,0 +1,120 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+type fakeLocker struct {
+	lockErr error
+	locks   int
+	unlocks int
+}
+
+func (l *fakeLocker) Lock(context.Context) error {
+	l.locks++
+
+	return l.lockErr
+}
+
+func (l *fakeLocker) Unlock(context.Context) error {
+	l.unlocks++
+
+	return nil
+}
+
+func (l *fakeLocker) LastOpTime(context.Context) (bson.Timestamp, error) {
+	return bson.Timestamp{}, nil
+}
+
+func TestSourceLock(t *testing.T) {
+	t.Parallel()
+
+	errFinalize := errors.New("finalize failed")
+
+	// the source is unlocked on the failed finalization
+	locker := &fakeLocker{}
+
+	l, err := lockSource(t.Context(), locker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if locker.locks != 1 {
+		t.Errorf("locks: got = %d, want 1", locker.locks)
+	}
+
+	if got := l.unlockOnError(errFinalize); !errors.Is(got, errFinalize) {
+		t.Errorf("got = %v, want %v", got, errFinalize)
+	}
+
+	if locker.unlocks != 1 {
+		t.Errorf("unlocks: got = %d, want 1", locker.unlocks)
+	}
+
+	// the source stays locked after the successful finalization
+	locker = &fakeLocker{}
+
+	l, err = lockSource(t.Context(), locker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := l.unlockOnError(nil); got != nil || locker.unlocks != 0 {
+		t.Errorf("got = %v with %d unlocks, want nil with 0", got, locker.unlocks)
+	}
+
+	// the failed lock is not unlocked
+	errLock := errors.New("lock failed")
+	locker = &fakeLocker{lockErr: errLock}
+
+	l, err = lockSource(t.Context(), locker)
+	if !errors.Is(err, errLock) || l != nil {
+		t.Errorf("got = %v, want %v", err, errLock)
+	}
+
+	l.unlockOnError(errFinalize) //nolint:errcheck
+
+	if locker.locks != 1 || locker.unlocks != 0 {
+		t.Errorf("got = %d locks and %d unlocks, want 1 and 0", locker.locks, locker.unlocks)
+	}
+}
+
+func TestWaitCaughtUp(t *testing.T) {
+	t.Parallel()
+
+	optime := bson.Timestamp{T: 100, I: 2}
+
+	// the write made before the lock is applied
+	r := &Repl{startTime: time.Now(), lastReplicatedOpTime: bson.Timestamp{T: 100, I: 1}}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		r.lock.Lock()
+		r.lastReplicatedOpTime = optime
+		r.lock.Unlock()
+	}()
+
+	err := waitCaughtUp(t.Context(), r, optime)
+	if err != nil {
+		t.Errorf("got = %v, want nil", err)
+	}
+
+	// the paused change replication does not apply the write
+	r = &Repl{
+		startTime:            time.Now(),
+		pauseTime:            time.Now(),
+		lastReplicatedOpTime: bson.Timestamp{T: 100, I: 1},
+	}
+
+	err = waitCaughtUp(t.Context(), r, optime)
+	if err == nil {
+		t.Error("paused: got = nil, want error")
+	}
+}
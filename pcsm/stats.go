@@ -0,0 +1,145 @@
+package pcsm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// Stats represents the lifetime counters of the cluster replication run.
+type Stats struct {
+	// StartTime is the time when the cluster replication started.
+	StartTime time.Time
+	// Elapsed is the time elapsed since the cluster replication started.
+	Elapsed time.Duration
+
+	// ClonedDocuments is the total number of cloned documents.
+	ClonedDocuments int64
+	// ClonedSize is the total size of the cloned documents in bytes.
+	ClonedSize uint64
+	// EventsApplied is the total number of applied change events.
+	EventsApplied int64
+
+	// Retries is the total number of retries of transient errors since the process start.
+	Retries int64
+	// Errors is the total number of failures of the cluster replication.
+	Errors int64
+
+	// AvgThroughput is the average number of documents and events processed per second.
+	AvgThroughput float64
+	// PeakThroughput is the peak number of documents and events processed per second.
+	PeakThroughput float64
+}
+
+type statsCheckpoint struct {
+	Errors         int64   `bson:"errors,omitempty"`
+	PeakThroughput float64 `bson:"peakThroughput,omitempty"`
+}
+
+// Stats returns the lifetime counters of the cluster replication.
+func (ml *PCSM) Stats(context.Context) *Stats {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	if ml.state == StateIdle {
+		return &Stats{Retries: topo.RetryCount()}
+	}
+
+	stats := makeStats(ml.clone.Status(), ml.repl.Status(), time.Now())
+	stats.Retries = topo.RetryCount()
+	stats.Errors = ml.errorCount
+	stats.PeakThroughput = max(ml.throughput.Peak(), stats.AvgThroughput)
+
+	return stats
+}
+
+// makeStats aggregates the clone and the change replication counters at the now time.
+func makeStats(clone CloneStatus, repl ReplStatus, now time.Time) *Stats {
+	stats := &Stats{
+		StartTime:       clone.StartTime,
+		ClonedDocuments: clone.CopiedCount,
+		ClonedSize:      clone.CopiedSize,
+		EventsApplied:   repl.EventsProcessed,
+	}
+
+	if !stats.StartTime.IsZero() {
+		stats.Elapsed = now.Sub(stats.StartTime)
+	}
+
+	if secs := stats.Elapsed.Seconds(); secs > 0 {
+		stats.AvgThroughput = float64(stats.ClonedDocuments+stats.EventsApplied) / secs
+	}
+
+	return stats
+}
+
+// monitorThroughput samples the number of the processed documents and events every second
+// to track the peak throughput.
+func (ml *PCSM) monitorThroughput(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	ml.throughput.Reset()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-t.C:
+			total := ml.clone.Status().CopiedCount + ml.repl.Status().EventsProcessed
+			ml.throughput.Observe(total, now)
+		}
+	}
+}
+
+// throughputMeter tracks the peak rate of a monotonically increasing counter.
+type throughputMeter struct {
+	lock sync.Mutex
+
+	lastTotal int64
+	lastAt    time.Time
+	peak      float64
+}
+
+// Observe records the counter total at the time and updates the peak rate.
+func (m *throughputMeter) Observe(total int64, at time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.lastAt.IsZero() && at.After(m.lastAt) {
+		rate := float64(total-m.lastTotal) / at.Sub(m.lastAt).Seconds()
+		m.peak = max(m.peak, rate)
+	}
+
+	m.lastTotal = total
+	m.lastAt = at
+}
+
+// Reset discards the last observation. The next observation starts a new interval.
+// It must be called when the counter was not sampled for a while (e.g. on resume).
+func (m *throughputMeter) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lastTotal = 0
+	m.lastAt = time.Time{}
+}
+
+// Peak returns the peak observed rate per second.
+func (m *throughputMeter) Peak() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.peak
+}
+
+// Recover restores the peak rate.
+func (m *throughputMeter) Recover(peak float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.peak = peak
+}
@@ -0,0 +1,82 @@
+package pcsm //nolint
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeStats(t *testing.T) {
+	t.Parallel()
+
+	startTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	clone := CloneStatus{
+		CopiedCount: 1500,
+		CopiedSize:  3 << 20,
+		StartTime:   startTime,
+		FinishTime:  startTime.Add(5 * time.Second),
+	}
+	repl := ReplStatus{
+		StartTime:       startTime.Add(5 * time.Second),
+		EventsProcessed: 500,
+	}
+
+	stats := makeStats(clone, repl, startTime.Add(10*time.Second))
+
+	if stats.ClonedDocuments != 1500 {
+		t.Errorf("ClonedDocuments: got = %d, want %d", stats.ClonedDocuments, 1500)
+	}
+	if stats.ClonedSize != 3<<20 {
+		t.Errorf("ClonedSize: got = %d, want %d", stats.ClonedSize, 3<<20)
+	}
+	if stats.EventsApplied != 500 {
+		t.Errorf("EventsApplied: got = %d, want %d", stats.EventsApplied, 500)
+	}
+	if stats.Elapsed != 10*time.Second {
+		t.Errorf("Elapsed: got = %s, want %s", stats.Elapsed, 10*time.Second)
+	}
+	if stats.AvgThroughput != 200 {
+		t.Errorf("AvgThroughput: got = %v, want %v", stats.AvgThroughput, 200)
+	}
+}
+
+func TestMakeStats_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	stats := makeStats(CloneStatus{}, ReplStatus{}, time.Now())
+	if stats.Elapsed != 0 || stats.AvgThroughput != 0 {
+		t.Errorf("got = %+v, want zero elapsed and throughput", stats)
+	}
+}
+
+func TestThroughputMeter(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var m throughputMeter
+
+	// simulated activity: 100/s, 400/s, 50/s
+	for _, total := range []int64{0, 100, 500, 550} {
+		m.Observe(total, at)
+		at = at.Add(time.Second)
+	}
+
+	if got := m.Peak(); got != 400 {
+		t.Errorf("Peak: got = %v, want %v", got, 400)
+	}
+
+	// the gap between the observations around the reset must not be measured
+	m.Reset()
+	m.Observe(10_000, at.Add(time.Hour))
+
+	if got := m.Peak(); got != 400 {
+		t.Errorf("Peak after reset: got = %v, want %v", got, 400)
+	}
+
+	m.Observe(11_000, at.Add(time.Hour+time.Second))
+
+	if got := m.Peak(); got != 1000 {
+		t.Errorf("Peak after burst: got = %v, want %v", got, 1000)
+	}
+}
@@ -0,0 +1,152 @@
+package pcsm
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// PrepareSwap checks whether the target already has the collection of the namespace.
+// With [CatalogOptions.RenameCollisionSuffix], the existing collection is kept,
+// and the namespace is cloned and replicated into the suffixed collection that replaces it
+// on finalization. It returns true if the namespace is swapped.
+func (c *Catalog) PrepareSwap(ctx context.Context, db, coll string) (bool, error) {
+	if c.options.RenameCollisionSuffix == "" {
+		return false, nil
+	}
+
+	spec, err := topo.GetCollectionSpec(ctx, c.target, c.targetDatabase(db), coll)
+	if err != nil {
+		if errors.Is(err, topo.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "get target collection spec")
+	}
+
+	if spec.Type != topo.TypeCollection {
+		return false, nil
+	}
+
+	c.swapLock.Lock()
+	if c.swaps == nil {
+		c.swaps = make(map[Namespace]struct{})
+	}
+	c.swaps[Namespace{db, coll}] = struct{}{}
+	c.swapLock.Unlock()
+
+	log.Ctx(ctx).Infof("Collection %s.%s exists on the target. It is cloned into %s.%s "+
+		"and replaced on finalization", c.targetDatabase(db), coll, c.targetDatabase(db),
+		coll+c.options.RenameCollisionSuffix)
+
+	return true, nil
+}
+
+// TargetNamespace returns the target namespace of the source namespace.
+func (c *Catalog) TargetNamespace(ns Namespace) Namespace {
+	return Namespace{c.targetDatabase(ns.Database), c.targetCollection(ns.Database, ns.Collection)}
+}
+
+// targetDatabase returns the name of the target database of the source database.
+// It has the [CatalogOptions.TargetDatabasePrefix].
+func (c *Catalog) targetDatabase(db string) string {
+	return c.options.TargetDatabasePrefix + db
+}
+
+// targetCollection returns the name of the target collection of the source collection.
+// It is the suffixed name for a swapped namespace.
+func (c *Catalog) targetCollection(db, coll string) string {
+	c.swapLock.Lock()
+	defer c.swapLock.Unlock()
+
+	if _, ok := c.swaps[Namespace{db, coll}]; ok {
+		return coll + c.options.RenameCollisionSuffix
+	}
+
+	return coll
+}
+
+// forgetDatabaseSwaps removes the swapped namespaces of the database.
+func (c *Catalog) forgetDatabaseSwaps(db string) {
+	c.swapLock.Lock()
+	defer c.swapLock.Unlock()
+
+	for ns := range c.swaps {
+		if ns.Database == db {
+			delete(c.swaps, ns)
+		}
+	}
+}
+
+// swappedNamespaces returns the swapped namespaces sorted by name.
+func (c *Catalog) swappedNamespaces() []Namespace {
+	c.swapLock.Lock()
+	defer c.swapLock.Unlock()
+
+	namespaces := make([]Namespace, 0, len(c.swaps))
+	for ns := range c.swaps {
+		namespaces = append(namespaces, ns)
+	}
+
+	slices.SortFunc(namespaces, func(a, b Namespace) int { return strings.Compare(a.String(), b.String()) })
+
+	return namespaces
+}
+
+// swapRenameCmd returns the command that replaces the collection by its suffixed collection.
+func swapRenameCmd(ns Namespace, suffix string) bson.D {
+	return bson.D{
+		{"renameCollection", ns.Database + "." + ns.Collection + suffix},
+		{"to", ns.String()},
+		{"dropTarget", true},
+	}
+}
+
+// finalizeSwaps replaces the existing target collections by their suffixed collections.
+// The rename with dropTarget is atomic, so the readers of the target collection see either
+// the old or the new documents. If the suffixed collection does not exist, the namespace has been
+// dropped on the source, and the old collection is dropped.
+func (c *Catalog) finalizeSwaps(ctx context.Context) error {
+	lg := log.Ctx(ctx)
+	suffix := c.options.RenameCollisionSuffix
+
+	for _, ns := range c.swappedNamespaces() {
+		target := Namespace{c.targetDatabase(ns.Database), ns.Collection}
+
+		err := runWithRetry(ctx, func(ctx context.Context) error {
+			err := c.target.Database("admin").RunCommand(ctx, swapRenameCmd(target, suffix)).Err()
+
+			return errors.Wrapf(err, "swap collection %s", ns)
+		})
+		if err != nil && !topo.IsNamespaceNotFound(err) {
+			return err //nolint:wrapcheck
+		}
+
+		if err != nil {
+			err = runWithRetry(ctx, func(ctx context.Context) error {
+				err := c.target.Database(target.Database).Collection(target.Collection).Drop(ctx)
+
+				return errors.Wrapf(err, "drop swapped collection %s", ns)
+			})
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			lg.Infof("Dropped collection %s: it does not exist on the source", ns)
+		} else {
+			lg.Infof("Swapped collection %s", ns)
+		}
+
+		c.swapLock.Lock()
+		delete(c.swaps, ns)
+		c.swapLock.Unlock()
+	}
+
+	return nil
+}
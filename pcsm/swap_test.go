@@ -0,0 +1,110 @@
+package pcsm //nolint
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCatalog_TargetNamespace(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalog(nil, CatalogOptions{RenameCollisionSuffix: "__plm_new"})
+	c.Databases["db_1"] = databaseCatalog{Collections: map[string]collectionCatalog{"coll_1": {}}}
+	c.swaps = map[Namespace]struct{}{
+		{"db_1", "coll_1"}: {},
+		{"db_2", "coll_1"}: {},
+	}
+
+	tests := []struct {
+		ns   Namespace
+		want Namespace
+	}{
+		{Namespace{"db_1", "coll_1"}, Namespace{"db_1", "coll_1__plm_new"}},
+		{Namespace{"db_1", "coll_2"}, Namespace{"db_1", "coll_2"}},
+		{Namespace{"db_2", "coll_1"}, Namespace{"db_2", "coll_1__plm_new"}},
+	}
+
+	for _, test := range tests {
+		if got := c.TargetNamespace(test.ns); got != test.want {
+			t.Errorf("%s: got = %s, want %s", test.ns, got, test.want)
+		}
+	}
+
+	// the swaps survive the recovery
+	data, err := bson.Marshal(c.Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cp catalogCheckpoint
+
+	err = bson.Unmarshal(data, &cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := NewCatalog(nil, CatalogOptions{RenameCollisionSuffix: "__plm_new"})
+
+	err = recovered.Recover(&cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		if got := recovered.TargetNamespace(test.ns); got != test.want {
+			t.Errorf("recovered %s: got = %s, want %s", test.ns, got, test.want)
+		}
+	}
+
+	// a dropped database is not swapped on finalization
+	recovered.forgetDatabaseSwaps("db_2")
+
+	got := recovered.swappedNamespaces()
+	if len(got) != 1 || got[0] != (Namespace{"db_1", "coll_1"}) {
+		t.Errorf("swapped after drop database: got = %v, want [db_1.coll_1]", got)
+	}
+}
+
+func TestCatalog_TargetDatabasePrefix(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalog(nil, CatalogOptions{TargetDatabasePrefix: "test_", RenameCollisionSuffix: "__plm_new"})
+	c.swaps = map[Namespace]struct{}{{"db_1", "coll_1"}: {}}
+
+	tests := []struct {
+		ns   Namespace
+		want Namespace
+	}{
+		{Namespace{"db_1", "coll_1"}, Namespace{"test_db_1", "coll_1__plm_new"}},
+		{Namespace{"db_1", "coll_2"}, Namespace{"test_db_1", "coll_2"}},
+		{Namespace{"db_2", "coll_1"}, Namespace{"test_db_2", "coll_1"}},
+	}
+
+	for _, test := range tests {
+		if got := c.TargetNamespace(test.ns); got != test.want {
+			t.Errorf("%s: got = %s, want %s", test.ns, got, test.want)
+		}
+	}
+}
+
+func TestSwapRenameCmd(t *testing.T) {
+	t.Parallel()
+
+	got := swapRenameCmd(Namespace{"db_1", "coll_1"}, "__plm_new")
+	want := bson.D{
+		{"renameCollection", "db_1.coll_1__plm_new"},
+		{"to", "db_1.coll_1"},
+		{"dropTarget", true},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package pcsm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/sel"
+)
+
+// tailBufferSize is the number of the change events buffered per tail subscriber.
+// The events are dropped for a subscriber that does not keep up.
+const tailBufferSize = 1000
+
+// TailOptions are the options of [PCSM.Tail].
+type TailOptions struct {
+	// Namespaces are the namespace patterns of the reported change events (e.g. "db.*").
+	// Empty reports all namespaces.
+	Namespaces []string
+	// FullDocument reports the full document of the insert, replace, and update change events.
+	FullDocument bool
+}
+
+// tailEvent is a change event in the layout of [JSONLSink]. The change events of
+// the oplog replication have no resume token (_id).
+type tailEvent struct {
+	ID            bson.Raw       `bson:"_id,omitempty"`
+	OperationType OperationType  `bson:"operationType"`
+	Namespace     Namespace      `bson:"ns"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+	TxnNumber     *int64         `bson:"txnNumber,omitempty"`
+
+	Event any `bson:"event,omitempty"`
+}
+
+// newTailEvent returns the tail event of the change event.
+// The full document is omitted unless requested.
+func newTailEvent(change *ChangeEvent, fullDocument bool) tailEvent {
+	event := change.Event
+
+	if !fullDocument {
+		switch e := event.(type) {
+		case InsertEvent:
+			event = bson.D{{"documentKey", e.DocumentKey}}
+		case UpdateEvent:
+			event = bson.D{{"documentKey", e.DocumentKey}, {"updateDescription", e.UpdateDescription}}
+		case ReplaceEvent:
+			event = bson.D{{"documentKey", e.DocumentKey}}
+		}
+	}
+
+	return tailEvent{
+		ID:            change.ID,
+		OperationType: change.OperationType,
+		Namespace:     change.Namespace,
+		ClusterTime:   change.ClusterTime,
+		TxnNumber:     change.TxnNumber,
+		Event:         event,
+	}
+}
+
+// tailSubscriber receives the change events of the matching namespaces
+// as relaxed extended JSON documents.
+type tailSubscriber struct {
+	filter       sel.NSFilter
+	fullDocument bool
+	eventC       chan []byte
+	dropped      int
+}
+
+// tailHub broadcasts the change events read from the source to the tail subscribers.
+// The broadcast does not block the replication.
+type tailHub struct {
+	lock        sync.Mutex
+	subscribers map[*tailSubscriber]struct{}
+	count       atomic.Int32
+}
+
+// subscribe adds a subscriber until the context is done. The returned channel is closed
+// when the subscriber is removed.
+func (h *tailHub) subscribe(ctx context.Context, options TailOptions) <-chan []byte {
+	sub := &tailSubscriber{
+		filter:       makeNSFilter(options.Namespaces, nil),
+		fullDocument: options.FullDocument,
+		eventC:       make(chan []byte, tailBufferSize),
+	}
+
+	h.lock.Lock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[*tailSubscriber]struct{})
+	}
+	h.subscribers[sub] = struct{}{}
+	h.count.Add(1)
+	h.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		h.lock.Lock()
+		delete(h.subscribers, sub)
+		h.count.Add(-1)
+		close(sub.eventC)
+		h.lock.Unlock()
+
+		if sub.dropped != 0 {
+			log.New("tail").Warnf("Dropped %d change events of a slow tail subscriber", sub.dropped)
+		}
+	}()
+
+	return sub.eventC
+}
+
+// publish sends the change event to the subscribers of its namespace.
+func (h *tailHub) publish(change *ChangeEvent) {
+	if h.count.Load() == 0 {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var encoded [2][]byte // without and with the full document
+
+	for sub := range h.subscribers {
+		if !sub.filter(change.Namespace.Database, change.Namespace.Collection) {
+			continue
+		}
+
+		i := 0
+		if sub.fullDocument {
+			i = 1
+		}
+
+		if encoded[i] == nil {
+			data, err := bson.MarshalExtJSON(newTailEvent(change, sub.fullDocument), false, false)
+			if err != nil {
+				log.New("tail").Error(err, "Encode change event")
+
+				return
+			}
+
+			encoded[i] = data
+		}
+
+		select {
+		case sub.eventC <- encoded[i]:
+		default:
+			sub.dropped++
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestTailHub(t *testing.T) {
+	t.Parallel()
+
+	var hub tailHub
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	db1 := hub.subscribe(ctx, TailOptions{Namespaces: []string{"db_1.*"}})
+	all := hub.subscribe(ctx, TailOptions{FullDocument: true})
+
+	insert := func(db string, id int32) *ChangeEvent {
+		return &ChangeEvent{
+			EventHeader: EventHeader{
+				OperationType: Insert,
+				Namespace:     Namespace{db, "coll_1"},
+				ClusterTime:   bson.Timestamp{T: 1, I: uint32(id)}, //nolint:gosec
+			},
+			Event: InsertEvent{
+				DocumentKey:  bson.D{{"_id", id}},
+				FullDocument: mustMarshal(t, bson.D{{"_id", id}, {"secret", "x"}}),
+			},
+		}
+	}
+
+	hub.publish(insert("db_1", 1))
+	hub.publish(insert("db_2", 2))
+	hub.publish(&ChangeEvent{EventHeader: EventHeader{
+		OperationType: Insert,
+		Namespace:     Namespace{"admin", "system.users"},
+	}})
+
+	data := <-db1
+	if !strings.Contains(string(data), `"ns":{"db":"db_1","coll":"coll_1"}`) {
+		t.Errorf("db_1: got = %s, want the db_1.coll_1 insert", data)
+	}
+
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("db_1: got = %s, want no full document", data)
+	}
+
+	for _, db := range []string{"db_1", "db_2"} {
+		data := <-all
+		if !strings.Contains(string(data), `"db":"`+db+`"`) || !strings.Contains(string(data), "secret") {
+			t.Errorf("all: got = %s, want the %s insert with the full document", data, db)
+		}
+	}
+
+	// a subscriber that does not read does not block the publisher
+	for i := range tailBufferSize + 10 {
+		hub.publish(insert("db_1", int32(i))) //nolint:gosec
+	}
+
+	cancel()
+
+	n := 0
+	for range db1 {
+		n++
+	}
+
+	if n != tailBufferSize {
+		t.Errorf("got %d buffered events, want %d", n, tailBufferSize)
+	}
+
+	for range all { //nolint:revive
+	}
+
+	if got := hub.count.Load(); got != 0 {
+		t.Errorf("got %d subscribers after cancel, want 0", got)
+	}
+}
@@ -0,0 +1,275 @@
+package pcsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// Transform processes a change event before it is applied to the target.
+// Apply may modify the change in place.
+type Transform interface {
+	// Name returns the transform spec (e.g. "noop", "delay:1s").
+	Name() string
+	// Apply processes the change event.
+	Apply(ctx context.Context, change *ChangeEvent) error
+}
+
+// TransformChain applies transforms in order.
+type TransformChain []Transform
+
+// Apply applies each transform of the chain to the change event in order.
+// It stops on the first error.
+func (c TransformChain) Apply(ctx context.Context, change *ChangeEvent) error {
+	for _, t := range c {
+		err := t.Apply(ctx, change)
+		if err != nil {
+			return errors.Wrap(err, t.Name())
+		}
+	}
+
+	return nil
+}
+
+// ParseTransforms builds a [TransformChain] from the transform specs.
+//
+// Supported specs:
+//   - "noop": passes the change event unchanged.
+//   - "delay:<duration>": delays each change event by the duration (e.g. "delay:50ms").
+//   - "max-clock-skew:<duration>": reports the date and timestamp values of the documents
+//     that are later than the cluster time of the change by more than the duration.
+//   - "clamp-clock-skew:<duration>": same as "max-clock-skew", but also rewrites such values
+//     to the cluster time of the change.
+func ParseTransforms(specs []string) (TransformChain, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	chain := make(TransformChain, 0, len(specs))
+
+	for _, spec := range specs {
+		t, err := parseTransform(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "transform %q", spec)
+		}
+
+		chain = append(chain, t)
+	}
+
+	return chain, nil
+}
+
+func parseTransform(spec string) (Transform, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+
+	switch name {
+	case "noop":
+		if hasArg {
+			return nil, errors.New("unexpected argument")
+		}
+
+		return noopTransform{}, nil
+
+	case "delay":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse duration")
+		}
+
+		if d <= 0 {
+			return nil, errors.New("duration must be positive")
+		}
+
+		return delayTransform{d: d}, nil
+
+	case "max-clock-skew", "clamp-clock-skew":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse duration")
+		}
+
+		if d < 0 {
+			return nil, errors.New("duration must not be negative")
+		}
+
+		return clockSkewTransform{max: d, clamp: name == "clamp-clock-skew"}, nil
+	}
+
+	return nil, errors.New("unknown transform")
+}
+
+// noopTransform is the identity transform.
+type noopTransform struct{}
+
+func (noopTransform) Name() string {
+	return "noop"
+}
+
+func (noopTransform) Apply(context.Context, *ChangeEvent) error {
+	return nil
+}
+
+// delayTransform slows down the apply to simulate a slow target (chaos testing).
+type delayTransform struct {
+	d time.Duration
+}
+
+func (t delayTransform) Name() string {
+	return "delay:" + t.d.String()
+}
+
+func (t delayTransform) Apply(ctx context.Context, _ *ChangeEvent) error {
+	timer := time.NewTimer(t.d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
+
+// clockSkewTransform checks the date and timestamp values of the inserted, replaced, and updated
+// documents against the cluster time of the change. The values later than the cluster time
+// by more than the max skew are reported (e.g. set by an application with a clock ahead of
+// the source). With clamp, the values are rewritten to the cluster time.
+type clockSkewTransform struct {
+	max   time.Duration
+	clamp bool
+}
+
+func (t clockSkewTransform) Name() string {
+	if t.clamp {
+		return "clamp-clock-skew:" + t.max.String()
+	}
+
+	return "max-clock-skew:" + t.max.String()
+}
+
+func (t clockSkewTransform) Apply(_ context.Context, change *ChangeEvent) error {
+	check := &futureDateCheck{
+		limit: time.Unix(int64(change.ClusterTime.T), 0).Add(t.max),
+		now:   change.ClusterTime,
+		clamp: t.clamp,
+	}
+
+	switch event := change.Event.(type) {
+	case InsertEvent:
+		doc, err := check.document(event.FullDocument)
+		if err != nil {
+			return err
+		}
+
+		event.FullDocument = doc
+		change.Event = event
+
+	case ReplaceEvent:
+		doc, err := check.document(event.FullDocument)
+		if err != nil {
+			return err
+		}
+
+		event.FullDocument = doc
+		change.Event = event
+
+	case UpdateEvent:
+		check.visit("", event.UpdateDescription.UpdatedFields)
+
+	default:
+		return nil
+	}
+
+	if len(check.paths) != 0 {
+		loggerForEvent(change).Warnf("Future-dated values beyond the clock skew of %s: %s",
+			t.max, strings.Join(check.paths, ", "))
+	}
+
+	return nil
+}
+
+// futureDateCheck finds the date and timestamp values later than the limit.
+type futureDateCheck struct {
+	limit time.Time      // latest allowed time
+	now   bson.Timestamp // cluster time of the change
+	clamp bool           // rewrite the future-dated values to the cluster time
+
+	paths []string // paths of the found future-dated values
+}
+
+// document checks the raw document. The document is re-encoded only if a value is clamped.
+func (c *futureDateCheck) document(raw bson.Raw) (bson.Raw, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+
+	var doc bson.D
+
+	err := bson.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal document")
+	}
+
+	c.visit("", doc)
+
+	if !c.clamp || len(c.paths) == 0 {
+		return raw, nil
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal document")
+	}
+
+	return data, nil
+}
+
+// visit checks the value at the path and returns the value to keep.
+// Embedded documents and arrays are modified in place.
+func (c *futureDateCheck) visit(path string, v any) any {
+	switch v := v.(type) {
+	case bson.DateTime:
+		if v.Time().After(c.limit) {
+			c.paths = append(c.paths, path)
+
+			if c.clamp {
+				return bson.NewDateTimeFromTime(time.Unix(int64(c.now.T), 0))
+			}
+		}
+
+	case bson.Timestamp:
+		if int64(v.T) > c.limit.Unix() {
+			c.paths = append(c.paths, path)
+
+			if c.clamp {
+				return c.now
+			}
+		}
+
+	case bson.D:
+		for i := range v {
+			v[i].Value = c.visit(joinPath(path, v[i].Key), v[i].Value)
+		}
+
+	case bson.A:
+		for i := range v {
+			v[i] = c.visit(joinPath(path, strconv.Itoa(i)), v[i])
+		}
+	}
+
+	return v
+}
+
+//go:inline
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,208 @@
+package pcsm //nolint
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// recordTransform records the names of the applied transforms.
+type recordTransform struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (t recordTransform) Name() string {
+	return t.name
+}
+
+func (t recordTransform) Apply(context.Context, *ChangeEvent) error {
+	*t.calls = append(*t.calls, t.name)
+
+	return t.err
+}
+
+func TestParseTransforms(t *testing.T) {
+	t.Parallel()
+
+	chain, err := ParseTransforms([]string{"noop", "delay:20ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chain) != 2 {
+		t.Fatalf("got %d transforms, want 2", len(chain))
+	}
+
+	if chain[0].Name() != "noop" || chain[1].Name() != "delay:20ms" {
+		t.Errorf("got = [%s %s], want [noop delay:20ms]", chain[0].Name(), chain[1].Name())
+	}
+
+	for _, spec := range []string{"unknown", "noop:1", "delay", "delay:abc", "delay:-1s"} {
+		_, err := ParseTransforms([]string{spec})
+		if err == nil {
+			t.Errorf("%q: got = nil, want error", spec)
+		}
+	}
+}
+
+func TestTransformChain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	noop, _ := parseTransform("noop")
+	delay, _ := parseTransform("delay:20ms")
+
+	chain := TransformChain{
+		recordTransform{name: "first", calls: &calls},
+		noop,
+		delay,
+		recordTransform{name: "last", calls: &calls},
+	}
+
+	change := &ChangeEvent{EventHeader: EventHeader{OperationType: Insert}}
+
+	startedAt := time.Now()
+
+	err := chain.Apply(t.Context(), change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(startedAt); elapsed < 20*time.Millisecond {
+		t.Errorf("elapsed: got = %s, want at least 20ms", elapsed)
+	}
+
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "last" {
+		t.Errorf("got = %v, want [first last]", calls)
+	}
+
+	if change.OperationType != Insert {
+		t.Errorf("noop modified the change: %v", change.OperationType)
+	}
+}
+
+func TestTransformChain_StopOnError(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	errFailed := errors.New("failed")
+
+	chain := TransformChain{
+		recordTransform{name: "first", calls: &calls, err: errFailed},
+		recordTransform{name: "second", calls: &calls},
+	}
+
+	err := chain.Apply(t.Context(), &ChangeEvent{})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("got = %v, want %v", err, errFailed)
+	}
+
+	if len(calls) != 1 {
+		t.Errorf("got = %v, want [first]", calls)
+	}
+}
+
+func TestDelayTransform_Canceled(t *testing.T) {
+	t.Parallel()
+
+	delay, _ := parseTransform("delay:1h")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err := delay.Apply(ctx, &ChangeEvent{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClockSkewTransform(t *testing.T) {
+	t.Parallel()
+
+	clusterTime := bson.Timestamp{T: 1_700_000_000, I: 1}
+	now := time.Unix(int64(clusterTime.T), 0)
+
+	newInsert := func() *ChangeEvent {
+		return &ChangeEvent{
+			EventHeader: EventHeader{OperationType: Insert, ClusterTime: clusterTime},
+			Event: InsertEvent{
+				DocumentKey: bson.D{{"_id", 1}},
+				FullDocument: mustMarshal(t, bson.D{
+					{"_id", 1},
+					{"createdAt", bson.NewDateTimeFromTime(now.Add(time.Second))},
+					{"meta", bson.D{
+						{"updatedAt", bson.NewDateTimeFromTime(now.Add(time.Hour))},
+						{"ts", bson.Timestamp{T: clusterTime.T + 3600}},
+					}},
+					{"history", bson.A{bson.NewDateTimeFromTime(now.Add(-time.Hour))}},
+				}),
+			},
+		}
+	}
+
+	check := &futureDateCheck{limit: now.Add(5 * time.Second), now: clusterTime}
+
+	var doc bson.D
+
+	err := bson.Unmarshal(newInsert().Event.(InsertEvent).FullDocument, &doc) //nolint:forcetypeassert
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check.visit("", doc)
+
+	if want := []string{"meta.updatedAt", "meta.ts"}; !slices.Equal(check.paths, want) {
+		t.Errorf("got = %v, want %v", check.paths, want)
+	}
+
+	change := newInsert()
+	original := change.Event.(InsertEvent).FullDocument //nolint:forcetypeassert
+
+	report, _ := parseTransform("max-clock-skew:5s")
+
+	err = report.Apply(t.Context(), change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(change.Event.(InsertEvent).FullDocument, original) { //nolint:forcetypeassert
+		t.Error("max-clock-skew modified the document")
+	}
+
+	clamp, _ := parseTransform("clamp-clock-skew:5s")
+
+	err = clamp.Apply(t.Context(), change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clamped := change.Event.(InsertEvent).FullDocument //nolint:forcetypeassert
+	if got := clamped.Lookup("meta", "updatedAt").Time(); !got.Equal(now) {
+		t.Errorf("meta.updatedAt: got = %v, want %v", got, now)
+	}
+
+	if got, _ := clamped.Lookup("meta", "ts").Timestamp(); got != clusterTime.T {
+		t.Errorf("meta.ts: got = %d, want %d", got, clusterTime.T)
+	}
+
+	if got := clamped.Lookup("createdAt").Time(); !got.Equal(now.Add(time.Second)) {
+		t.Errorf("createdAt within the skew: got = %v, want unchanged", got)
+	}
+
+	for _, spec := range []string{"max-clock-skew", "max-clock-skew:-1s", "clamp-clock-skew:x"} {
+		_, err := parseTransform(spec)
+		if err == nil {
+			t.Errorf("%q: got = nil, want error", spec)
+		}
+	}
+}
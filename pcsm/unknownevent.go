@@ -0,0 +1,70 @@
+package pcsm
+
+import (
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// ErrUnknownEvent indicates a change event of an operation type that the change replication
+// does not recognize (e.g. introduced by a newer MongoDB version).
+var ErrUnknownEvent = errors.New("unknown change event type")
+
+// UnknownEventAction is the handling of the change events of an unknown operation type.
+type UnknownEventAction string
+
+const (
+	// UnknownEventLog logs the event with a warning and skips it.
+	UnknownEventLog UnknownEventAction = "log"
+	// UnknownEventSkip skips the event. It is logged at the debug level only.
+	UnknownEventSkip UnknownEventAction = "skip"
+	// UnknownEventFail fails the replication with [ErrUnknownEvent].
+	UnknownEventFail UnknownEventAction = "fail"
+)
+
+// ParseUnknownEventAction parses the unknown event action. The empty string is [UnknownEventLog].
+func ParseUnknownEventAction(s string) (UnknownEventAction, error) {
+	switch a := UnknownEventAction(s); a {
+	case "":
+		return UnknownEventLog, nil
+	case UnknownEventLog, UnknownEventSkip, UnknownEventFail:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid unknown event action %q", s)
+}
+
+// isKnownOperationType reports whether the change replication recognizes the operation type.
+func isKnownOperationType(t OperationType) bool {
+	switch t {
+	case Insert, Update, Replace, Delete,
+		Create, Drop, DropDatabase, Rename, Modify,
+		CreateIndexes, DropIndexes,
+		ShardCollection, ReshardCollection, RefineCollectionShardKey,
+		Invalidate, advanceTimePseudoEvent:
+		return true
+	}
+
+	return false
+}
+
+// handleUnknownEvent handles the change event of an unknown operation type by
+// [ReplOptions.OnUnknownEvent]. It returns false if the replication must stop.
+func (r *Repl) handleUnknownEvent(change *ChangeEvent) bool {
+	lg := loggerForEvent(change)
+
+	switch r.options.OnUnknownEvent {
+	case UnknownEventFail:
+		r.setFailed(errors.Wrapf(ErrUnknownEvent, "%q of %s", change.OperationType, change.Namespace),
+			"Unknown change event")
+
+		return false
+
+	case UnknownEventSkip:
+		lg.Debugf("Skip unknown %q change event", change.OperationType)
+
+		return true
+	}
+
+	lg.Warnf("Skip unknown %q change event of %s", change.OperationType, change.Namespace)
+
+	return true
+}
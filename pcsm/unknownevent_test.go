@@ -0,0 +1,84 @@
+package pcsm //nolint
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+func TestParseUnknownEventAction(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]UnknownEventAction{
+		"":     UnknownEventLog,
+		"log":  UnknownEventLog,
+		"skip": UnknownEventSkip,
+		"fail": UnknownEventFail,
+	} {
+		got, err := ParseUnknownEventAction(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %v %v, want %v", s, got, err, want)
+		}
+	}
+
+	if _, err := ParseUnknownEventAction("ignore"); err == nil {
+		t.Error("ignore: got = nil, want error")
+	}
+}
+
+func TestRepl_HandleUnknownEvent(t *testing.T) {
+	t.Parallel()
+
+	data := mustMarshal(t, bson.D{
+		{"_id", bson.D{{"_data", "8266C4A1B2000000012B"}}},
+		{"operationType", "futureEvent"},
+		{"clusterTime", bson.Timestamp{T: 1724000000, I: 1}},
+		{"ns", bson.D{{"db", "db_1"}, {"coll", "coll_1"}}},
+	})
+
+	var change ChangeEvent
+
+	err := parseChangeEvent(data, &change)
+	if err != nil {
+		t.Fatalf("parse: got = %v, want nil", err)
+	}
+
+	if isKnownOperationType(change.OperationType) {
+		t.Fatalf("got known %q, want unknown", change.OperationType)
+	}
+
+	for _, typ := range []OperationType{Insert, Rename, RefineCollectionShardKey, advanceTimePseudoEvent} {
+		if !isKnownOperationType(typ) {
+			t.Errorf("got unknown %q, want known", typ)
+		}
+	}
+
+	newRepl := func(action UnknownEventAction) *Repl {
+		doneSig := make(chan struct{})
+		close(doneSig)
+
+		return &Repl{
+			options: ReplOptions{OnUnknownEvent: action},
+			pauseC:  make(chan struct{}, 1),
+			doneSig: doneSig,
+		}
+	}
+
+	for _, action := range []UnknownEventAction{"", UnknownEventLog, UnknownEventSkip} {
+		r := newRepl(action)
+		if !r.handleUnknownEvent(&change) || r.pausing || r.err != nil {
+			t.Errorf("%q: unknown event stops the replication", action)
+		}
+	}
+
+	r := newRepl(UnknownEventFail)
+	if r.handleUnknownEvent(&change) {
+		t.Fatal("fail: unknown event is skipped")
+	}
+
+	if !errors.Is(r.err, ErrUnknownEvent) {
+		t.Errorf("fail: got = %v, want %v", r.err, ErrUnknownEvent)
+	}
+}
@@ -0,0 +1,438 @@
+package pcsm
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"hash"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// VerifyOptions are the options of the verification of the target documents.
+type VerifyOptions struct {
+	// BatchSize is the number of documents of a verified range.
+	// Zero is [config.VerifyBatchSize].
+	BatchSize int
+	// RateLimit is the maximum number of documents per second read from the source
+	// and from the target. Zero is unlimited.
+	RateLimit int
+	// Checksum is the algorithm of the range checksums of the source and the target.
+	// The empty value is [ChecksumXXHash].
+	Checksum ChecksumAlgorithm
+}
+
+// ChecksumAlgorithm is the hashing algorithm of the verified ranges. The faster algorithms
+// trade the collision resistance for the speed.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumXXHash is the 64-bit xxHash. The fastest, not collision resistant.
+	ChecksumXXHash ChecksumAlgorithm = "xxhash"
+	// ChecksumMD5 is the MD5 hash.
+	ChecksumMD5 ChecksumAlgorithm = "md5"
+	// ChecksumSHA256 is the SHA-256 hash. The slowest, collision resistant.
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+)
+
+// ParseChecksumAlgorithm parses the checksum algorithm. The empty string is [ChecksumXXHash].
+func ParseChecksumAlgorithm(s string) (ChecksumAlgorithm, error) {
+	switch a := ChecksumAlgorithm(s); a {
+	case "":
+		return ChecksumXXHash, nil
+	case ChecksumXXHash, ChecksumMD5, ChecksumSHA256:
+		return a, nil
+	}
+
+	return "", errors.Errorf("invalid checksum algorithm %q", s)
+}
+
+// newHash returns the hash of the algorithm.
+func (a ChecksumAlgorithm) newHash() hash.Hash {
+	switch a {
+	case ChecksumMD5:
+		return md5.New() //nolint:gosec
+	case ChecksumSHA256:
+		return sha256.New()
+	default:
+		return xxhash.New()
+	}
+}
+
+// VerifyMismatch is a range of documents that differs between the source and the target.
+// The bounds are the {_id: value} documents.
+type VerifyMismatch struct {
+	// After is the _id before the range. It is empty for the range from the first document.
+	After bson.Raw `bson:"after,omitempty"`
+	// Last is the last source _id of the range. It is empty for the target documents
+	// after the last source document.
+	Last bson.Raw `bson:"last,omitempty"`
+}
+
+// NamespaceVerification is the progress of the verification of a namespace.
+type NamespaceVerification struct {
+	// LastKey is the {_id: value} of the last verified source document.
+	LastKey bson.Raw `bson:"lastKey,omitempty"`
+	// Documents is the number of the verified source documents.
+	Documents int64 `bson:"documents"`
+	// Mismatches are the ranges that differ.
+	Mismatches []VerifyMismatch `bson:"mismatches,omitempty"`
+	// Done indicates that all documents of the namespace are verified.
+	Done bool `bson:"done,omitempty"`
+}
+
+// rangeDigest is the checksum of a range of documents in the _id order.
+type rangeDigest struct {
+	Count   int
+	Hash    [sha256.Size]byte // fits the checksum of each algorithm
+	LastKey bson.Raw          // {_id: value} of the last document
+}
+
+// rangeReader reads the documents of a namespace in the _id order.
+type rangeReader interface {
+	// ReadRange returns the digest of up to limit documents of the namespace after the {_id: value}
+	// key. An empty key starts from the first document.
+	ReadRange(ctx context.Context, ns Namespace, after bson.Raw, limit int) (rangeDigest, error)
+}
+
+// mongoRangeReader reads the ranges with the _id index bounds, so the _id values of all types
+// are read in the index order.
+type mongoRangeReader struct {
+	m        *mongo.Client
+	checksum ChecksumAlgorithm
+}
+
+func (r mongoRangeReader) ReadRange(
+	ctx context.Context,
+	ns Namespace,
+	after bson.Raw,
+	limit int,
+) (rangeDigest, error) {
+	opts := options.Find().
+		SetSort(bson.D{{"_id", 1}}).
+		SetHint(bson.D{{"_id", 1}}).
+		SetLimit(int64(limit) + 1) // the min bound is inclusive
+
+	if len(after) != 0 {
+		opts.SetMin(after)
+	}
+
+	cur, err := r.m.Database(ns.Database).Collection(ns.Collection).Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return rangeDigest{}, errors.Wrap(err, "find")
+	}
+
+	var docs []bson.Raw
+
+	for cur.Next(ctx) {
+		doc := slices.Clone(cur.Current)
+		if len(docs) == 0 && len(after) != 0 && sameKey(doc, after) {
+			continue
+		}
+
+		if len(docs) == limit {
+			break
+		}
+
+		docs = append(docs, doc)
+	}
+
+	err = cur.Err()
+	cur.Close(ctx) //nolint:errcheck
+
+	if err != nil {
+		return rangeDigest{}, errors.Wrap(err, "cursor")
+	}
+
+	return digestDocuments(docs, r.checksum), nil
+}
+
+// sameKey returns true if the document has the _id of the {_id: value} key.
+func sameKey(doc, key bson.Raw) bool {
+	a, b := doc.Lookup("_id"), key.Lookup("_id")
+
+	return a.Type == b.Type && bytes.Equal(a.Value, b.Value)
+}
+
+// digestDocuments returns the checksum of the documents. The field order is a part of the checksum.
+func digestDocuments(docs []bson.Raw, checksum ChecksumAlgorithm) rangeDigest {
+	h := checksum.newHash()
+	for _, doc := range docs {
+		h.Write(doc)
+	}
+
+	d := rangeDigest{Count: len(docs)}
+	copy(d.Hash[:], h.Sum(nil))
+
+	if len(docs) != 0 {
+		d.LastKey = idKey(docs[len(docs)-1])
+	}
+
+	return d
+}
+
+// idKey returns the {_id: value} key of the document.
+func idKey(doc bson.Raw) bson.Raw {
+	key, _ := bson.Marshal(bson.D{{"_id", doc.Lookup("_id")}})
+
+	return key
+}
+
+// rateLimiter delays the reads to keep the average rate under the limit.
+type rateLimiter struct {
+	rate  int
+	start time.Time
+	count int64
+}
+
+// wait adds n documents and sleeps until the average rate is under the limit.
+// A zero rate is unlimited.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	if l.start.IsZero() {
+		l.start = time.Now()
+	}
+
+	l.count += int64(n)
+
+	delay := throttleDelay(l.count, l.rate, time.Since(l.start))
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-t.C:
+		return nil
+	}
+}
+
+// throttleDelay returns the time to wait after count documents in the elapsed time
+// to keep the average rate of documents per second.
+func throttleDelay(count int64, rate int, elapsed time.Duration) time.Duration {
+	return time.Duration(count*int64(time.Second)/int64(rate)) - elapsed
+}
+
+// Verifier compares the documents of the source and the target namespaces range by range.
+// The progress is checkpointed, so a stopped verification resumes from the last verified range.
+type Verifier struct {
+	source  rangeReader
+	target  rangeReader
+	options VerifyOptions
+
+	lock       sync.Mutex
+	namespaces map[string]*NamespaceVerification
+}
+
+type verifierCheckpoint struct {
+	Namespaces map[string]*NamespaceVerification `bson:"namespaces"`
+}
+
+// NewVerifier creates a verifier of the target documents. The source and the target ranges
+// are checksummed with the same algorithm.
+func NewVerifier(source, target *mongo.Client, options VerifyOptions) *Verifier {
+	return newVerifier(
+		mongoRangeReader{source, options.Checksum},
+		mongoRangeReader{target, options.Checksum},
+		options)
+}
+
+func newVerifier(source, target rangeReader, options VerifyOptions) *Verifier {
+	if options.BatchSize <= 0 {
+		options.BatchSize = config.VerifyBatchSize
+	}
+
+	return &Verifier{
+		source:     source,
+		target:     target,
+		options:    options,
+		namespaces: make(map[string]*NamespaceVerification),
+	}
+}
+
+// Checkpoint returns the verified ranges of the namespaces.
+func (v *Verifier) Checkpoint(context.Context) ([]byte, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if len(v.namespaces) == 0 {
+		return nil, nil
+	}
+
+	return bson.Marshal(verifierCheckpoint{Namespaces: v.namespaces}) //nolint:wrapcheck
+}
+
+// Recover restores the verified ranges of the namespaces.
+func (v *Verifier) Recover(_ context.Context, data []byte) error {
+	var cp verifierCheckpoint
+
+	err := bson.Unmarshal(data, &cp)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal")
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.namespaces = cp.Namespaces
+	if v.namespaces == nil {
+		v.namespaces = make(map[string]*NamespaceVerification)
+	}
+
+	return nil
+}
+
+// Results returns a copy of the progress of the namespaces.
+func (v *Verifier) Results() map[string]NamespaceVerification {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	res := make(map[string]NamespaceVerification, len(v.namespaces))
+	for ns, nv := range v.namespaces {
+		res[ns] = *nv
+	}
+
+	return res
+}
+
+// ListVerifyNamespaces returns the source collections allowed by the filters, sorted by name.
+func ListVerifyNamespaces(ctx context.Context, source *mongo.Client, include, exclude []string) ([]Namespace, error) {
+	plan, err := PlanNamespaces(ctx, source, include, exclude, PlanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []Namespace
+
+	for _, p := range plan {
+		if p.Type == topo.TypeCollection {
+			namespaces = append(namespaces, p.Namespace)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// Verify verifies the namespaces. The done namespaces of the recovered checkpoint are skipped,
+// and the others continue after the last verified range. The checkpoint function is called
+// after each range.
+func (v *Verifier) Verify(
+	ctx context.Context,
+	namespaces []Namespace,
+	checkpoint func(context.Context) error,
+) error {
+	limiter := &rateLimiter{rate: v.options.RateLimit}
+
+	for _, ns := range namespaces {
+		v.lock.Lock()
+		nv := v.namespaces[ns.String()]
+		if nv == nil {
+			nv = &NamespaceVerification{}
+			v.namespaces[ns.String()] = nv
+		}
+		done, after := nv.Done, nv.LastKey
+		v.lock.Unlock()
+
+		if done {
+			continue
+		}
+
+		lg := log.New("verify").With(log.NS(ns.Database, ns.Collection))
+		if len(after) != 0 {
+			lg.Infof("Resume after %s", after)
+		}
+
+		for !done {
+			mismatch, last, count, err := v.verifyRange(ctx, ns, after, limiter)
+			if err != nil {
+				return errors.Wrap(err, ns.String())
+			}
+
+			done = count == 0
+
+			v.lock.Lock()
+			nv.Documents += int64(count)
+			nv.Done = done
+			if !done {
+				nv.LastKey = last
+			}
+			if mismatch != nil {
+				nv.Mismatches = append(nv.Mismatches, *mismatch)
+			}
+			documents := nv.Documents
+			v.lock.Unlock()
+
+			if mismatch != nil {
+				lg.Warnf("Mismatched range after %s to %s", mismatch.After, mismatch.Last)
+			}
+
+			err = checkpoint(ctx)
+			if err != nil {
+				return errors.Wrap(err, "checkpoint")
+			}
+
+			after = last
+
+			if done {
+				lg.Infof("Verified %d documents", documents)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyRange compares the next range of the source documents after the key with the target.
+// A zero count is the end of the source namespace.
+func (v *Verifier) verifyRange(
+	ctx context.Context,
+	ns Namespace,
+	after bson.Raw,
+	limiter *rateLimiter,
+) (*VerifyMismatch, bson.Raw, int, error) {
+	src, err := v.source.ReadRange(ctx, ns, after, v.options.BatchSize)
+	if err != nil {
+		return nil, nil, 0, errors.Wrap(err, "source")
+	}
+
+	limit := src.Count
+	if limit == 0 {
+		limit = 1 // any target document after the last source document is a mismatch
+	}
+
+	tgt, err := v.target.ReadRange(ctx, ns, after, limit)
+	if err != nil {
+		return nil, nil, 0, errors.Wrap(err, "target")
+	}
+
+	err = limiter.wait(ctx, max(src.Count, tgt.Count))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var mismatch *VerifyMismatch
+	if src.Count != tgt.Count || src.Hash != tgt.Hash {
+		mismatch = &VerifyMismatch{After: after, Last: src.LastKey}
+	}
+
+	return mismatch, src.LastKey, src.Count, nil
+}
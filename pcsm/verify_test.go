@@ -0,0 +1,283 @@
+package pcsm //nolint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// fakeRangeReader reads the documents of each namespace sorted by _id.
+type fakeRangeReader struct {
+	docs     map[Namespace][]bson.Raw
+	checksum ChecksumAlgorithm
+	after    []bson.Raw // the after keys of the reads
+}
+
+func (r *fakeRangeReader) ReadRange(_ context.Context, ns Namespace, after bson.Raw, limit int) (rangeDigest, error) {
+	r.after = append(r.after, after)
+
+	docs := r.docs[ns]
+	if len(after) != 0 {
+		for i, doc := range docs {
+			if sameKey(doc, after) {
+				docs = docs[i+1:]
+
+				break
+			}
+		}
+	}
+
+	return digestDocuments(docs[:min(limit, len(docs))], r.checksum), nil
+}
+
+func verifyDocs(t *testing.T, n int, modify func(id int, doc bson.D) bson.D) []bson.Raw {
+	t.Helper()
+
+	var docs []bson.Raw
+	for id := range n {
+		doc := bson.D{{"_id", id}, {"n", id}}
+		if modify != nil {
+			doc = modify(id, doc)
+		}
+
+		docs = append(docs, mustMarshal(t, doc))
+	}
+
+	return docs
+}
+
+func keyOf(t *testing.T, id int) bson.Raw {
+	t.Helper()
+
+	return mustMarshal(t, bson.D{{"_id", id}})
+}
+
+func TestVerifier_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	ns1, ns2 := Namespace{"db_1", "coll_1"}, Namespace{"db_1", "coll_2"}
+	source := &fakeRangeReader{docs: map[Namespace][]bson.Raw{
+		ns1: verifyDocs(t, 10, nil),
+		ns2: verifyDocs(t, 5, nil),
+	}}
+	target := &fakeRangeReader{docs: map[Namespace][]bson.Raw{
+		ns1: verifyDocs(t, 10, func(id int, doc bson.D) bson.D {
+			if id == 6 {
+				return bson.D{{"_id", id}, {"n", -1}}
+			}
+
+			return doc
+		}),
+		ns2: verifyDocs(t, 7, nil), // the target has extra documents
+	}}
+
+	v := newVerifier(source, target, VerifyOptions{BatchSize: 4})
+
+	err := v.Verify(t.Context(), []Namespace{ns1, ns2}, func(context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := v.Results()
+
+	got := res[ns1.String()]
+	if !got.Done || got.Documents != 10 || len(got.Mismatches) != 1 {
+		t.Fatalf("%s: got = %+v", ns1, got)
+	}
+
+	if m := got.Mismatches[0]; !sameKey(m.After, keyOf(t, 3)) || !sameKey(m.Last, keyOf(t, 7)) {
+		t.Errorf("%s: mismatch got = %s..%s, want after 3 to 7", ns1, m.After, m.Last)
+	}
+
+	got = res[ns2.String()]
+	if !got.Done || got.Documents != 5 || len(got.Mismatches) != 1 {
+		t.Fatalf("%s: got = %+v", ns2, got)
+	}
+
+	if m := got.Mismatches[0]; !sameKey(m.After, keyOf(t, 4)) || len(m.Last) != 0 {
+		t.Errorf("%s: mismatch got = %s..%s, want after 4 to the end", ns2, m.After, m.Last)
+	}
+}
+
+func TestVerifier_ResumeFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	ns1, ns2 := Namespace{"db_1", "coll_1"}, Namespace{"db_1", "coll_2"}
+	docs := map[Namespace][]bson.Raw{
+		ns1: verifyDocs(t, 6, nil),
+		ns2: verifyDocs(t, 10, nil),
+	}
+	namespaces := []Namespace{ns1, ns2}
+
+	errStop := errors.New("stop")
+
+	// stop after the first range of the second namespace
+	var data []byte
+
+	v := newVerifier(&fakeRangeReader{docs: docs}, &fakeRangeReader{docs: docs}, VerifyOptions{BatchSize: 4})
+	ranges := 0
+
+	err := v.Verify(t.Context(), namespaces, func(ctx context.Context) error {
+		var err error
+
+		data, err = v.Checkpoint(ctx)
+		if err != nil {
+			return err
+		}
+
+		ranges++
+		if ranges == 4 {
+			return errStop
+		}
+
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("got = %v, want %v", err, errStop)
+	}
+
+	source := &fakeRangeReader{docs: docs}
+	v = newVerifier(source, &fakeRangeReader{docs: docs}, VerifyOptions{BatchSize: 4})
+
+	err = v.Recover(t.Context(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = v.Verify(t.Context(), namespaces, func(context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the done namespace is skipped and the second one continues after the verified range
+	if len(source.after) == 0 || !sameKey(source.after[0], keyOf(t, 3)) {
+		t.Fatalf("resumed reads after = %v, want the first after 3", source.after)
+	}
+
+	res := v.Results()
+	for _, ns := range namespaces {
+		got := res[ns.String()]
+		if !got.Done || got.Documents != int64(len(docs[ns])) || len(got.Mismatches) != 0 {
+			t.Errorf("%s: got = %+v", ns, got)
+		}
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		count   int64
+		rate    int
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{100, 100, 0, time.Second},
+		{100, 100, 400 * time.Millisecond, 600 * time.Millisecond},
+		{100, 100, 2 * time.Second, -time.Second},
+		{50, 1000, 0, 50 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		got := throttleDelay(test.count, test.rate, test.elapsed)
+		if got != test.want {
+			t.Errorf("throttleDelay(%d, %d, %s): got = %s, want %s",
+				test.count, test.rate, test.elapsed, got, test.want)
+		}
+	}
+}
+
+func TestDigestDocuments_Checksum(t *testing.T) {
+	t.Parallel()
+
+	docs := verifyDocs(t, 10, nil)
+	modified := verifyDocs(t, 10, func(id int, doc bson.D) bson.D {
+		if id == 6 {
+			return bson.D{{"_id", id}, {"n", -1}}
+		}
+
+		return doc
+	})
+	reordered := verifyDocs(t, 10, func(id int, _ bson.D) bson.D {
+		return bson.D{{"n", id}, {"_id", id}}
+	})
+
+	for _, alg := range []ChecksumAlgorithm{ChecksumXXHash, ChecksumMD5, ChecksumSHA256} {
+		want := digestDocuments(docs, alg)
+
+		if got := digestDocuments(verifyDocs(t, 10, nil), alg); got.Hash != want.Hash {
+			t.Errorf("%s: same documents: got = %x, want %x", alg, got.Hash, want.Hash)
+		}
+
+		if got := digestDocuments(modified, alg); got.Hash == want.Hash {
+			t.Errorf("%s: modified document: got the same checksum", alg)
+		}
+
+		if got := digestDocuments(reordered, alg); got.Hash == want.Hash {
+			t.Errorf("%s: reordered fields: got the same checksum", alg)
+		}
+	}
+
+	// each algorithm is a different checksum
+	if digestDocuments(docs, ChecksumXXHash).Hash == digestDocuments(docs, ChecksumSHA256).Hash {
+		t.Error("xxhash and sha256: got the same checksum")
+	}
+}
+
+func TestVerifier_ChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	ns := Namespace{"db_1", "coll_1"}
+	modified := verifyDocs(t, 8, func(id int, doc bson.D) bson.D {
+		if id == 2 {
+			return bson.D{{"_id", id}, {"n", -1}}
+		}
+
+		return doc
+	})
+
+	for _, alg := range []ChecksumAlgorithm{ChecksumXXHash, ChecksumMD5, ChecksumSHA256} {
+		source := &fakeRangeReader{docs: map[Namespace][]bson.Raw{ns: verifyDocs(t, 8, nil)}, checksum: alg}
+		target := &fakeRangeReader{docs: map[Namespace][]bson.Raw{ns: modified}, checksum: alg}
+
+		v := newVerifier(source, target, VerifyOptions{BatchSize: 4, Checksum: alg})
+
+		err := v.Verify(t.Context(), []Namespace{ns}, func(context.Context) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := v.Results()[ns.String()]
+		if !got.Done || got.Documents != 8 || len(got.Mismatches) != 1 {
+			t.Fatalf("%s: got = %+v, want 1 mismatch", alg, got)
+		}
+
+		if m := got.Mismatches[0]; len(m.After) != 0 || !sameKey(m.Last, keyOf(t, 3)) {
+			t.Errorf("%s: mismatch got = %s..%s, want the first range", alg, m.After, m.Last)
+		}
+	}
+}
+
+func TestParseChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]ChecksumAlgorithm{
+		"":       ChecksumXXHash,
+		"xxhash": ChecksumXXHash,
+		"md5":    ChecksumMD5,
+		"sha256": ChecksumSHA256,
+	} {
+		got, err := ParseChecksumAlgorithm(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q (%v), want %q", s, got, err, want)
+		}
+	}
+
+	if _, err := ParseChecksumAlgorithm("crc32"); err == nil {
+		t.Error("crc32: got = nil, want error")
+	}
+}
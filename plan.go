@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/dustin/go-humanize"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+// planRequest represents the request body for the /plan/detailed endpoint.
+type planRequest struct {
+	// IncludeNamespaces are the namespaces to include in the replication.
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	// ExcludeNamespaces are the namespaces to exclude from the replication.
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// EstimateMode is the method of the size estimate (collstats or sample).
+	EstimateMode string `json:"estimateMode,omitempty"`
+	// SampleSize is the number of the sampled documents per collection of the sample estimate.
+	SampleSize int `json:"sampleSize,omitempty"`
+}
+
+// planResponse represents the response body for the /plan/detailed endpoint.
+type planResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+
+	// Namespaces are the source namespaces allowed by the filters, sorted by name.
+	Namespaces []namespacePlan `json:"namespaces,omitempty"`
+}
+
+// namespacePlan is the clone plan of a namespace in the /plan/detailed response.
+type namespacePlan struct {
+	// Namespace is the "db.collection" name.
+	Namespace string `json:"namespace"`
+	// Type is the collection type (collection or view).
+	Type string `json:"type"`
+	// Options are the collection options as relaxed Extended JSON.
+	Options json.RawMessage `json:"options,omitempty"`
+	// Indexes are the index specs as relaxed Extended JSON.
+	Indexes []json.RawMessage `json:"indexes,omitempty"`
+	// Size is the estimated data size in bytes.
+	Size int64 `json:"size"`
+	// Count is the estimated number of documents.
+	Count int64 `json:"count"`
+}
+
+// newNamespacePlan converts the plan of the namespace to the response form.
+func newNamespacePlan(p pcsm.NamespacePlan) (namespacePlan, error) {
+	res := namespacePlan{
+		Namespace: p.Namespace.String(),
+		Type:      p.Type,
+		Size:      p.Size,
+		Count:     p.Count,
+	}
+
+	if len(p.Options) != 0 {
+		data, err := bson.MarshalExtJSON(p.Options, false, false)
+		if err != nil {
+			return res, errors.Wrap(err, "options")
+		}
+
+		res.Options = data
+	}
+
+	for _, index := range p.Indexes {
+		data, err := bson.MarshalExtJSON(index, false, false)
+		if err != nil {
+			return res, errors.Wrapf(err, "index %q", index.Name)
+		}
+
+		res.Indexes = append(res.Indexes, data)
+	}
+
+	return res, nil
+}
+
+// handlePlanDetailed handles the /plan/detailed endpoint.
+func (s *server) handlePlanDetailed(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodPost {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	var params planRequest
+
+	if r.ContentLength != 0 {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError)
+
+			return
+		}
+
+		err = json.Unmarshal(data, &params)
+		if err != nil {
+			http.Error(w,
+				http.StatusText(http.StatusBadRequest),
+				http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	errs := pcsm.ValidateNamespacePatterns(params.IncludeNamespaces, params.ExcludeNamespaces)
+	if len(errs) != 0 {
+		writeResponse(w, planResponse{Err: errors.Join(errs...).Error()})
+
+		return
+	}
+
+	estimateMode, err := pcsm.ParseEstimateMode(params.EstimateMode)
+	if err != nil {
+		writeResponse(w, planResponse{Err: err.Error()})
+
+		return
+	}
+
+	if params.SampleSize < 0 {
+		writeResponse(w, planResponse{Err: "sample size must not be negative"})
+
+		return
+	}
+
+	plan, err := pcsm.PlanNamespaces(ctx, s.source(), params.IncludeNamespaces, params.ExcludeNamespaces,
+		pcsm.PlanOptions{Estimate: estimateMode, SampleSize: params.SampleSize})
+	if err != nil {
+		writeResponse(w, planResponse{Err: err.Error()})
+
+		return
+	}
+
+	resp := planResponse{Ok: true, Namespaces: make([]namespacePlan, 0, len(plan))}
+
+	for _, p := range plan {
+		nsPlan, err := newNamespacePlan(p)
+		if err != nil {
+			writeResponse(w, planResponse{Err: errors.Wrap(err, p.Namespace.String()).Error()})
+
+			return
+		}
+
+		resp.Namespaces = append(resp.Namespaces, nsPlan)
+	}
+
+	writeResponse(w, resp)
+}
+
+// PlanDetailed sends a request to get the detailed plan of the namespaces allowed by the filters.
+// The output is either "json" or a human-readable "text".
+func (c PCSMClient) PlanDetailed(ctx context.Context, req planRequest, output string) error {
+	if output == "json" {
+		return doClientRequest[planResponse](ctx, c, http.MethodPost, "plan/detailed", req)
+	}
+
+	resp, err := fetchClientResponse[planResponse](ctx, c, http.MethodPost, "plan/detailed", req)
+	if err != nil {
+		return err
+	}
+
+	if resp.Err != "" {
+		return errors.New(resp.Err)
+	}
+
+	writeDetailedPlan(os.Stdout, resp.Namespaces)
+
+	return nil
+}
+
+// writeDetailedPlan prints the namespaces with their size, options, and indexes.
+func writeDetailedPlan(w io.Writer, namespaces []namespacePlan) {
+	var totalSize int64
+
+	for _, ns := range namespaces {
+		if ns.Type == topo.TypeView {
+			fmt.Fprintf(w, "%s (view)\n", ns.Namespace)
+		} else {
+			fmt.Fprintf(w, "%s (%s, %d documents, %s)\n", ns.Namespace, ns.Type,
+				ns.Count, humanize.Bytes(uint64(max(ns.Size, 0)))) //nolint:gosec
+		}
+
+		if len(ns.Options) != 0 && string(ns.Options) != "{}" {
+			fmt.Fprintf(w, "  options: %s\n", ns.Options)
+		}
+
+		for _, index := range ns.Indexes {
+			fmt.Fprintf(w, "  index: %s\n", index)
+		}
+
+		totalSize += ns.Size
+	}
+
+	fmt.Fprintf(w, "%d namespaces, %s\n", len(namespaces), humanize.Bytes(uint64(max(totalSize, 0)))) //nolint:gosec
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+func TestNewNamespacePlan(t *testing.T) {
+	t.Parallel()
+
+	options, err := bson.Marshal(bson.D{{"capped", true}, {"size", int64(4096)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idKey, _ := bson.Marshal(bson.D{{"_id", 1}})
+	aKey, _ := bson.Marshal(bson.D{{"a", 1}, {"b", -1}})
+	unique := true
+
+	plan := pcsm.NamespacePlan{
+		Namespace: pcsm.Namespace{Database: "db_1", Collection: "coll_1"},
+		Type:      topo.TypeCollection,
+		Options:   options,
+		Indexes: []*topo.IndexSpecification{
+			{Name: "_id_", KeysDocument: idKey, Version: 2},
+			{Name: "a_1_b_-1", KeysDocument: aKey, Version: 2, Unique: &unique},
+		},
+		Size:  2048,
+		Count: 10,
+	}
+
+	got, err := newNamespacePlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Namespace != "db_1.coll_1" || got.Type != "collection" || got.Size != 2048 || got.Count != 10 {
+		t.Errorf("got = %+v", got)
+	}
+
+	var gotOptions map[string]any
+
+	err = json.Unmarshal(got.Options, &gotOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotOptions["capped"] != true || gotOptions["size"] != float64(4096) {
+		t.Errorf("options: got %s", got.Options)
+	}
+
+	if len(got.Indexes) != 2 {
+		t.Fatalf("got %d indexes, want 2", len(got.Indexes))
+	}
+
+	var gotIndex struct {
+		Name   string         `json:"name"`
+		Key    map[string]int `json:"key"`
+		Unique bool           `json:"unique"`
+	}
+
+	err = json.Unmarshal(got.Indexes[1], &gotIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotIndex.Name != "a_1_b_-1" || !gotIndex.Unique || gotIndex.Key["a"] != 1 || gotIndex.Key["b"] != -1 {
+		t.Errorf("index: got %s", got.Indexes[1])
+	}
+}
+
+func TestWriteDetailedPlan(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []namespacePlan{
+		{
+			Namespace: "db_1.coll_1",
+			Type:      "collection",
+			Options:   json.RawMessage(`{"capped":true,"size":4096}`),
+			Indexes: []json.RawMessage{
+				json.RawMessage(`{"v":2,"key":{"_id":1},"name":"_id_"}`),
+				json.RawMessage(`{"v":2,"key":{"a":1},"name":"a_1","unique":true}`),
+			},
+			Size:  2048,
+			Count: 10,
+		},
+		{
+			Namespace: "db_1.view_1",
+			Type:      "view",
+			Options:   json.RawMessage(`{"viewOn":"coll_1","pipeline":[]}`),
+		},
+	}
+
+	var buf bytes.Buffer
+
+	writeDetailedPlan(&buf, namespaces)
+
+	want := strings.Join([]string{
+		"db_1.coll_1 (collection, 10 documents, 2.0 kB)",
+		`  options: {"capped":true,"size":4096}`,
+		`  index: {"v":2,"key":{"_id":1},"name":"_id_"}`,
+		`  index: {"v":2,"key":{"a":1},"name":"a_1","unique":true}`,
+		"db_1.view_1 (view)",
+		`  options: {"viewOn":"coll_1","pipeline":[]}`,
+		"2 namespaces, 2.0 kB",
+		"",
+	}, "\n")
+
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
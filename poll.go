@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+// errWaitFailed indicates that the replication has failed before the waited state.
+var errWaitFailed = errors.New("replication failed")
+
+// waitStates are the states that the wait command waits for.
+var waitStates = []pcsm.State{ //nolint:gochecknoglobals
+	pcsm.StateIdle,
+	pcsm.StateRunning,
+	pcsm.StateDegraded,
+	pcsm.StatePaused,
+	pcsm.StateFinalizing,
+	pcsm.StateFinalized,
+	pcsm.StateFailed,
+}
+
+// validatePollInterval returns an error if the interval is less than [config.MinPollInterval].
+func validatePollInterval(interval time.Duration) error {
+	if interval < config.MinPollInterval {
+		return errors.Errorf("invalid interval %s: the minimum is %s", interval, config.MinPollInterval)
+	}
+
+	return nil
+}
+
+// poll calls f until it returns true or an error, or the context is done.
+// The next call is made the interval after the previous call returns, so a slow response
+// does not make the calls back-to-back.
+func poll(ctx context.Context, interval time.Duration, f func(context.Context) (bool, error)) error {
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-t.C:
+		}
+
+		done, err := f(ctx)
+		if err != nil || done {
+			return err
+		}
+
+		t.Reset(interval)
+	}
+}
+
+// WatchStatus prints the status of the cluster replication every interval until the context is done.
+func (c PCSMClient) WatchStatus(ctx context.Context, interval time.Duration) error {
+	err := poll(ctx, interval, func(ctx context.Context) (bool, error) {
+		return false, c.Status(ctx)
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+// Wait waits until the cluster replication is in the state and prints the status.
+// It returns [errWaitFailed] if the replication fails before.
+func (c PCSMClient) Wait(ctx context.Context, state pcsm.State, interval time.Duration) error {
+	var status *statusResponse
+
+	err := poll(ctx, interval, func(ctx context.Context) (bool, error) {
+		var err error
+
+		status, err = fetchClientResponse[statusResponse](ctx, c, http.MethodGet, "status", nil)
+		if err != nil {
+			return false, err
+		}
+
+		log.Ctx(ctx).Debugf("State: %s", status.State)
+
+		if status.State == state {
+			return true, nil
+		}
+
+		if status.State == pcsm.StateFailed {
+			return false, errors.Wrap(errWaitFailed, status.Err)
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "wait for %s", state)
+	}
+
+	return printJSON(status)
+}
+
+// parseWaitState parses the state of the wait command.
+func parseWaitState(s string) (pcsm.State, error) {
+	state := pcsm.State(s)
+	if !slices.Contains(waitStates, state) {
+		return "", errors.Errorf("invalid state %q", s)
+	}
+
+	return state, nil
+}
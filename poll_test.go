@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestValidatePollInterval(t *testing.T) {
+	t.Parallel()
+
+	for _, interval := range []time.Duration{config.MinPollInterval, config.DefaultPollInterval} {
+		if err := validatePollInterval(interval); err != nil {
+			t.Errorf("%s: got = %v, want nil", interval, err)
+		}
+	}
+
+	for _, interval := range []time.Duration{0, 100 * time.Millisecond, -time.Second} {
+		if err := validatePollInterval(interval); err == nil {
+			t.Errorf("%s: got = nil, want error", interval)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	const interval = 50 * time.Millisecond
+
+	// a slow call does not shorten the interval before the next call
+	var calls []time.Time
+
+	err := poll(t.Context(), interval, func(context.Context) (bool, error) {
+		calls = append(calls, time.Now())
+		time.Sleep(interval / 2)
+
+		return len(calls) == 3, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want 3", len(calls))
+	}
+
+	for i := 1; i < len(calls); i++ {
+		if gap := calls[i].Sub(calls[i-1]); gap < interval+interval/2 {
+			t.Errorf("call %d: got gap = %s, want at least %s", i, gap, interval+interval/2)
+		}
+	}
+
+	errStop := errors.New("stop")
+
+	err = poll(t.Context(), interval, func(context.Context) (bool, error) { return false, errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("got = %v, want %v", err, errStop)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 2*interval)
+	defer cancel()
+
+	err = poll(ctx, interval, func(context.Context) (bool, error) { return false, nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPCSMClient_Wait(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(states ...pcsm.State) (PCSMClient, *atomic.Int32) {
+		var requests atomic.Int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			n := int(requests.Add(1))
+			state := states[min(n, len(states))-1]
+
+			json.NewEncoder(w).Encode(statusResponse{Ok: true, State: state, Err: "boom"}) //nolint:errcheck
+		}))
+		t.Cleanup(srv.Close)
+
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		port, err := strconv.Atoi(u.Port())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return NewClient(port, ""), &requests
+	}
+
+	client, requests := newServer(pcsm.StateRunning, pcsm.StateFinalizing, pcsm.StateFinalized)
+
+	err := client.Wait(t.Context(), pcsm.StateFinalized, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d status requests, want 3", got)
+	}
+
+	client, _ = newServer(pcsm.StateRunning, pcsm.StateFailed)
+
+	err = client.Wait(t.Context(), pcsm.StateFinalized, 10*time.Millisecond)
+	if !errors.Is(err, errWaitFailed) {
+		t.Errorf("failed: got = %v, want %v", err, errWaitFailed)
+	}
+}
+
+func TestParseWaitState(t *testing.T) {
+	t.Parallel()
+
+	state, err := parseWaitState("finalized")
+	if err != nil || state != pcsm.StateFinalized {
+		t.Errorf("got = %q, %v, want %q", state, err, pcsm.StateFinalized)
+	}
+
+	_, err = parseWaitState("done")
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
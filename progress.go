@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+// progressSnapshot is a document of the progress collection.
+type progressSnapshot struct {
+	TS    time.Time  `bson:"ts"`
+	State pcsm.State `bson:"state"`
+	Error string     `bson:"error,omitempty"`
+
+	LagTimeSeconds       int64 `bson:"lagTimeSeconds"`
+	InitialSyncCompleted bool  `bson:"initialSyncCompleted"`
+
+	EventsProcessed      int64          `bson:"eventsProcessed"`
+	LastReplicatedOpTime bson.Timestamp `bson:"lastReplicatedOpTime"`
+
+	EstimatedCloneSizeBytes uint64 `bson:"estimatedCloneSizeBytes"`
+	ClonedSizeBytes         uint64 `bson:"clonedSizeBytes"`
+	ClonedDocuments         int64  `bson:"clonedDocuments"`
+}
+
+func newProgressSnapshot(ts time.Time, status *pcsm.Status) *progressSnapshot {
+	snap := &progressSnapshot{
+		TS:    ts,
+		State: status.State,
+
+		LagTimeSeconds:       status.TotalLagTime,
+		InitialSyncCompleted: status.InitialSyncCompleted,
+
+		EventsProcessed:      status.Repl.EventsProcessed,
+		LastReplicatedOpTime: status.Repl.LastReplicatedOpTime,
+
+		EstimatedCloneSizeBytes: status.Clone.EstimatedTotalSize,
+		ClonedSizeBytes:         status.Clone.CopiedSize,
+		ClonedDocuments:         status.Clone.CopiedCount,
+	}
+
+	if status.Error != nil {
+		snap.Error = status.Error.Error()
+	}
+
+	return snap
+}
+
+// progressRecorder takes a progress snapshot at each interval and writes the snapshots
+// in batches of batchSize. The pending snapshots are written when the recorder stops.
+type progressRecorder struct {
+	status    func(context.Context) *pcsm.Status
+	write     func(context.Context, []any) error
+	interval  time.Duration
+	batchSize int
+}
+
+func (p *progressRecorder) run(ctx context.Context) {
+	lg := log.New("progress")
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	batch := make([]any, 0, p.batchSize)
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+
+		err := p.write(ctx, batch)
+		if err != nil {
+			lg.Error(err, "Failed to write progress snapshots")
+		} else {
+			lg.Tracef("%d progress snapshots written", len(batch))
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.DisconnectTimeout)
+			flush(flushCtx)
+			cancel()
+
+			return
+
+		case ts := <-t.C:
+			status := p.status(ctx)
+			if status.State == pcsm.StateIdle {
+				continue
+			}
+
+			batch = append(batch, newProgressSnapshot(ts, status))
+			if len(batch) >= p.batchSize {
+				flush(ctx)
+			}
+		}
+	}
+}
+
+// RunProgress periodically writes the progress snapshots of the PCSM to the collection
+// of the PCSM database on the target. The snapshots expire after [config.ProgressRetention].
+func RunProgress(
+	ctx context.Context,
+	m *mongo.Client,
+	collName string,
+	interval time.Duration,
+	pcs *pcsm.PCSM,
+) error {
+	coll := m.Database(config.PCSMDatabase).Collection(collName)
+
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"ts", 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(config.ProgressRetention.Seconds())),
+	})
+	if err != nil {
+		return errors.Wrap(err, "create ttl index")
+	}
+
+	rec := &progressRecorder{
+		status: pcs.Status,
+		write: func(ctx context.Context, docs []any) error {
+			_, err := coll.InsertMany(ctx, docs)
+
+			return errors.Wrap(err, "insert")
+		},
+		interval:  interval,
+		batchSize: config.ProgressBatchSize,
+	}
+
+	go rec.run(ctx)
+
+	return nil
+}
+
+// progressReporter logs a one-line progress summary at each interval (--report-interval):
+// the phase, the clone percent, the lag, and the documents and events processed per second
+// since the previous report.
+type progressReporter struct {
+	status   func(context.Context) *pcsm.Status
+	report   func(string)
+	interval time.Duration
+}
+
+func (p *progressReporter) run(ctx context.Context) {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	var lastOps int64
+	var lastAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-t.C:
+			status := p.status(ctx)
+			if status.State == pcsm.StateIdle {
+				continue
+			}
+
+			ops := status.Clone.CopiedCount + status.Repl.EventsProcessed
+
+			var opsPerSec float64
+			if !lastAt.IsZero() {
+				opsPerSec = float64(ops-lastOps) / now.Sub(lastAt).Seconds()
+			}
+
+			lastOps, lastAt = ops, now
+
+			p.report(formatProgressReport(status, opsPerSec))
+		}
+	}
+}
+
+// formatProgressReport returns the progress summary line of the status.
+func formatProgressReport(status *pcsm.Status, opsPerSec float64) string {
+	phase := statusInfo(status)
+	if phase == "" {
+		phase = string(status.State)
+	}
+
+	var percent float64
+
+	switch {
+	case status.Clone.IsFinished():
+		percent = 100 //nolint:mnd
+	case status.Clone.EstimatedTotalSize != 0:
+		percent = min(100, float64(status.Clone.CopiedSize)/float64(status.Clone.EstimatedTotalSize)*100) //nolint:mnd
+	}
+
+	return fmt.Sprintf("Progress: %s, %.1f%% cloned, lag %ds, %.1f ops/s",
+		phase, percent, status.TotalLagTime, opsPerSec)
+}
+
+// RunProgressReport periodically logs the progress summary of the PCSM.
+func RunProgressReport(ctx context.Context, interval time.Duration, pcs *pcsm.PCSM) {
+	lg := log.New("progress")
+
+	rep := &progressReporter{
+		status:   pcs.Status,
+		report:   func(line string) { lg.Info(line) },
+		interval: interval,
+	}
+
+	go rep.run(ctx)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestProgressRecorder(t *testing.T) {
+	t.Parallel()
+
+	const interval = 20 * time.Millisecond
+
+	var mu sync.Mutex
+	var batches [][]*progressSnapshot
+
+	batchC := make(chan struct{}, 10)
+	var state pcsm.State = pcsm.StateIdle
+
+	rec := &progressRecorder{
+		status: func(context.Context) *pcsm.Status {
+			mu.Lock()
+			defer mu.Unlock()
+
+			s := &pcsm.Status{State: state}
+			state = pcsm.StateRunning // idle only on the first snapshot
+
+			return s
+		},
+		write: func(_ context.Context, docs []any) error {
+			batch := make([]*progressSnapshot, len(docs))
+			for i, doc := range docs {
+				batch[i] = doc.(*progressSnapshot) //nolint:forcetypeassert
+			}
+
+			mu.Lock()
+			batches = append(batches, batch)
+			mu.Unlock()
+
+			batchC <- struct{}{}
+
+			return nil
+		},
+		interval:  interval,
+		batchSize: 3,
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		rec.run(ctx)
+		close(done)
+	}()
+
+	for range 2 {
+		select {
+		case <-batchC:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a batch")
+		}
+	}
+
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var snapshots []*progressSnapshot
+
+	for i, batch := range batches {
+		if i < 2 && len(batch) != 3 {
+			t.Errorf("batch %d: got %d snapshots, want 3", i, len(batch))
+		}
+
+		snapshots = append(snapshots, batch...)
+	}
+
+	for i, snap := range snapshots {
+		if snap.State != pcsm.StateRunning {
+			t.Errorf("snapshot %d: got state %q, want %q", i, snap.State, pcsm.StateRunning)
+		}
+
+		if i != 0 {
+			if d := snap.TS.Sub(snapshots[i-1].TS); d < interval/2 {
+				t.Errorf("snapshot %d: taken %s after the previous, want about %s", i, d, interval)
+			}
+		}
+	}
+}
+
+func TestProgressReporter(t *testing.T) {
+	t.Parallel()
+
+	const interval = 20 * time.Millisecond
+
+	type line struct {
+		at   time.Time
+		text string
+	}
+
+	lineC := make(chan line, 10)
+
+	var mu sync.Mutex
+	var calls int64
+
+	rep := &progressReporter{
+		status: func(context.Context) *pcsm.Status {
+			mu.Lock()
+			defer mu.Unlock()
+
+			calls++
+
+			return &pcsm.Status{
+				State:        pcsm.StateRunning,
+				TotalLagTime: 7,
+				Clone: pcsm.CloneStatus{
+					StartTime:          time.Now(),
+					EstimatedTotalSize: 200,
+					CopiedSize:         100,
+					CopiedCount:        calls * 10,
+				},
+			}
+		},
+		report:   func(s string) { lineC <- line{time.Now(), s} },
+		interval: interval,
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	go rep.run(ctx)
+
+	var lines []line
+
+	for range 3 {
+		select {
+		case l := <-lineC:
+			lines = append(lines, l)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a progress line")
+		}
+	}
+
+	for i, l := range lines {
+		want := "Progress: Initial Sync: Cloning Data, 50.0% cloned, lag 7s, "
+		if !strings.HasPrefix(l.text, want) || !strings.HasSuffix(l.text, " ops/s") {
+			t.Errorf("line %d: got = %q, want %q...", i, l.text, want)
+		}
+
+		if i != 0 {
+			if d := l.at.Sub(lines[i-1].at); d < interval/2 {
+				t.Errorf("line %d: logged %s after the previous, want about %s", i, d, interval)
+			}
+		}
+	}
+
+	if strings.HasSuffix(lines[1].text, " 0.0 ops/s") {
+		t.Errorf("got = %q, want the processed documents per second", lines[1].text)
+	}
+}
+
+func TestFormatProgressReport(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		status *pcsm.Status
+		want   string
+	}{
+		{
+			&pcsm.Status{State: pcsm.StateRunning, Clone: pcsm.CloneStatus{StartTime: time.Now()}},
+			"Progress: Initial Sync: Cloning Data, 0.0% cloned, lag 0s, 12.5 ops/s",
+		},
+		{
+			&pcsm.Status{
+				State:                pcsm.StateRunning,
+				TotalLagTime:         3,
+				InitialSyncCompleted: true,
+				Clone:                pcsm.CloneStatus{FinishTime: time.Now()},
+			},
+			"Progress: Replicating Changes, 100.0% cloned, lag 3s, 12.5 ops/s",
+		},
+		{
+			&pcsm.Status{State: pcsm.StatePaused, Clone: pcsm.CloneStatus{EstimatedTotalSize: 4, CopiedSize: 1}},
+			"Progress: paused, 25.0% cloned, lag 0s, 12.5 ops/s",
+		},
+	} {
+		if got := formatProgressReport(tc.status, 12.5); got != tc.want {
+			t.Errorf("got = %q, want %q", got, tc.want)
+		}
+	}
+}
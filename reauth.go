@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// reauthRequest represents the request body for the /reauth endpoint.
+type reauthRequest struct {
+	// Source is the connection string of the source with the new credentials.
+	Source string `json:"source"`
+}
+
+// reauthResponse represents the response body for the /reauth endpoint.
+type reauthResponse struct {
+	// Ok indicates if the operation was successful.
+	Ok bool `json:"ok"`
+	// Err is the error message if the operation failed.
+	Err string `json:"error,omitempty"`
+}
+
+// source returns the MongoDB client for the source cluster.
+func (s *server) source() *mongo.Client {
+	s.sourceLock.Lock()
+	defer s.sourceLock.Unlock()
+
+	return s.sourceCluster
+}
+
+// currentSourceURI returns the connection string of the source with the current credentials.
+func (s *server) currentSourceURI() string {
+	s.sourceLock.Lock()
+	defer s.sourceLock.Unlock()
+
+	if s.reauthSourceURI != "" {
+		return s.reauthSourceURI
+	}
+
+	return s.sourceURI
+}
+
+// handleReauth handles the /reauth endpoint. It connects to the source with the new credentials
+// and resumes the replication paused on the source authentication failure.
+func (s *server) handleReauth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ServerResponseTimeout)
+	defer cancel()
+
+	if r.Method != http.MethodPost {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if r.ContentLength > MaxRequestSize {
+		http.Error(w,
+			http.StatusText(http.StatusRequestEntityTooLarge),
+			http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError)
+
+		return
+	}
+
+	var params reauthRequest
+
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		http.Error(w,
+			http.StatusText(http.StatusBadRequest),
+			http.StatusBadRequest)
+
+		return
+	}
+
+	err = checkSameCluster(s.sourceURI, params.Source)
+	if err != nil {
+		writeResponse(w, reauthResponse{Err: err.Error()})
+
+		return
+	}
+
+	source, err := topo.Connect(ctx, params.Source)
+	if err != nil {
+		writeResponse(w, reauthResponse{Err: errors.Wrap(err, "connect to source cluster").Error()})
+
+		return
+	}
+
+	resumed := 0
+	prevInUse := false
+
+	err = s.fanOut(func(p *pcsm.PCSM) error {
+		err := p.Reauth(ctx, source)
+		if err == nil {
+			resumed++
+		} else if p.Status(ctx).State == pcsm.StateRunning {
+			prevInUse = true
+		}
+
+		return err
+	})
+
+	switch {
+	case resumed == 0:
+		// the previous client is still in use
+		err1 := util.CtxWithTimeout(ctx, config.DisconnectTimeout, source.Disconnect)
+		if err1 != nil {
+			log.New("http:reauth").Warn("Disconnect Source Cluster: " + err1.Error())
+		}
+
+	case resumed == len(s.targets)+1:
+		s.sourceLock.Lock()
+		prev := s.sourceCluster
+		s.sourceCluster = source
+		s.reauthSourceURI = params.Source
+		s.sourceLock.Unlock()
+
+		err1 := util.CtxWithTimeout(ctx, config.DisconnectTimeout, prev.Disconnect)
+		if err1 != nil {
+			log.New("http:reauth").Warn("Disconnect Source Cluster: " + err1.Error())
+		}
+
+	default:
+		s.sourceLock.Lock()
+		prev := s.sourceCluster
+		s.sourceCluster = source
+		s.reauthSourceURI = params.Source
+		s.sourceLock.Unlock()
+
+		if prevInUse {
+			// a running target is still replicating with the previous client
+			break
+		}
+
+		// the targets not resumed are resumed by another reauth with a new client
+		err1 := util.CtxWithTimeout(ctx, config.DisconnectTimeout, prev.Disconnect)
+		if err1 != nil {
+			log.New("http:reauth").Warn("Disconnect Source Cluster: " + err1.Error())
+		}
+	}
+
+	if err != nil {
+		writeResponse(w, reauthResponse{Err: err.Error()})
+
+		return
+	}
+
+	writeResponse(w, reauthResponse{Ok: true})
+}
+
+// checkSameCluster returns an error if the connection string is invalid or does not have
+// the hosts of the original connection string.
+func checkSameCluster(origURI, uri string) error {
+	if uri == "" {
+		return errors.New("missing source connection string")
+	}
+
+	cs, err := connstring.Parse(uri)
+	if err != nil {
+		return errors.Wrap(err, "invalid source connection string")
+	}
+
+	orig, err := connstring.Parse(origURI)
+	if err != nil {
+		return errors.Wrap(err, "invalid original source connection string")
+	}
+
+	hosts := slices.Sorted(slices.Values(cs.Hosts))
+	origHosts := slices.Sorted(slices.Values(orig.Hosts))
+
+	if !slices.Equal(hosts, origHosts) {
+		return errors.Errorf("source hosts %v: expected the hosts of the source %v", cs.Hosts, orig.Hosts)
+	}
+
+	return nil
+}
+
+// Reauth sends the new source credentials and resumes the cluster replication.
+func (c PCSMClient) Reauth(ctx context.Context, req reauthRequest) error {
+	return doClientRequest[reauthResponse](ctx, c, http.MethodPost, "reauth", req)
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -28,30 +32,191 @@ type checkpoint struct {
 	Data bson.Raw  `bson:"data"`
 }
 
-func Restore(ctx context.Context, m *mongo.Client, rec Recoverable) error {
-	lg := log.New("recovery")
+// Backends of the recovery data (--state-backend).
+const (
+	// stateBackendMongoDB stores the recovery data in the PCSM database of the target.
+	stateBackendMongoDB = "mongodb"
+	// stateBackendFile stores the recovery data in a local file.
+	stateBackendFile = "file"
+)
 
-	lg.Infof("Checking Recovery Data for %q", recoveryID)
+// StateStore persists the recovery data (the checkpoint with the resume position) of PCSM.
+type StateStore interface {
+	// Load returns the saved recovery data. It returns [errNoRecoveryData] if none is saved.
+	Load(ctx context.Context) (bson.Raw, error)
+	// Save replaces the saved recovery data.
+	Save(ctx context.Context, data bson.Raw) error
+	// Delete deletes the saved recovery data. It is no-op if none is saved.
+	Delete(ctx context.Context) error
+}
+
+// mongoStateStore stores the recovery data in the PCSM database of the target.
+type mongoStateStore struct {
+	m *mongo.Client
+}
 
+func (s mongoStateStore) collection() *mongo.Collection {
+	return s.m.Database(config.PCSMDatabase).Collection(config.RecoveryCollection)
+}
+
+func (s mongoStateStore) Load(ctx context.Context) (bson.Raw, error) {
 	var cp checkpoint
 
-	err := m.Database(config.PCSMDatabase).
-		Collection(config.RecoveryCollection).
-		FindOne(ctx, bson.D{{"_id", recoveryID}}).
-		Decode(&cp)
+	err := s.collection().FindOne(ctx, bson.D{{"_id", recoveryID}}).Decode(&cp)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errNoRecoveryData
+		}
+
+		return nil, errors.Wrap(err, "find")
+	}
+
+	return cp.Data, nil
+}
+
+func (s mongoStateStore) Save(ctx context.Context, data bson.Raw) error {
+	_, err := s.collection().ReplaceOne(ctx,
+		bson.D{{"_id", recoveryID}},
+		checkpoint{
+			ID:   recoveryID,
+			TS:   time.Now(),
+			Data: data,
+		},
+		options.Replace().SetUpsert(true))
+
+	return errors.Wrap(err, "save")
+}
+
+func (s mongoStateStore) Delete(ctx context.Context) error {
+	_, err := s.collection().DeleteOne(ctx, bson.D{{"_id", recoveryID}})
+
+	return err //nolint:wrapcheck
+}
+
+// fileStateStore stores the recovery data in a local file as a BSON document. The file is
+// replaced atomically: a crash during a save keeps the previous checkpoint.
+type fileStateStore struct {
+	path string
+	lock sync.Mutex // serializes the saves of the checkpointing and the state changes
+}
+
+func newFileStateStore(path string) *fileStateStore {
+	return &fileStateStore{path: path}
+}
+
+func (s *fileStateStore) Load(context.Context) (bson.Raw, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errNoRecoveryData
+		}
+
+		return nil, errors.Wrap(err, "read")
+	}
+
+	var cp checkpoint
+
+	err = bson.Unmarshal(data, &cp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %q", s.path)
+	}
+
+	return cp.Data, nil
+}
+
+// Save writes the recovery data into a temporary file in the directory of the state file and
+// renames it to the state file after it is synced to disk.
+func (s *fileStateStore) Save(_ context.Context, data bson.Raw) error {
+	doc, err := bson.Marshal(checkpoint{
+		ID:   recoveryID,
+		TS:   time.Now(),
+		Data: data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dir := filepath.Dir(s.path)
+
+	f, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "create")
+	}
+
+	_, err = f.Write(doc)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+
+		return errors.Wrap(err, "write")
+	}
+
+	err = os.Rename(f.Name(), s.path)
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+
+		return errors.Wrap(err, "rename")
+	}
+
+	return errors.Wrap(syncDir(dir), "sync directory")
+}
+
+// syncDir syncs the directory, so that a rename in it is durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	err = dir.Sync()
+	closeErr := dir.Close()
+
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return closeErr //nolint:wrapcheck
+}
+
+func (s *fileStateStore) Delete(context.Context) error {
+	err := os.Remove(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+func Restore(ctx context.Context, store StateStore, rec Recoverable) error {
+	lg := log.New("recovery")
+
+	lg.Infof("Checking Recovery Data for %q", recoveryID)
+
+	data, err := store.Load(ctx)
+	if err != nil {
+		if errors.Is(err, errNoRecoveryData) {
 			lg.Info("Recovery Data not found")
 
 			return nil
 		}
 
-		return errors.Wrap(err, "find")
+		return errors.Wrap(err, "load")
 	}
 
 	lg.Info("Found Recovery Data. Recovering...")
 
-	err = rec.Recover(ctx, cp.Data)
+	err = rec.Recover(ctx, data)
 	if err != nil {
 		return errors.Wrap(err, "recover")
 	}
@@ -61,11 +226,11 @@ func Restore(ctx context.Context, m *mongo.Client, rec Recoverable) error {
 	return nil
 }
 
-func RunCheckpointing(ctx context.Context, m *mongo.Client, rec Recoverable) {
+func RunCheckpointing(ctx context.Context, store StateStore, rec Recoverable) {
 	lg := log.New("checkpointing")
 
 	for {
-		err := DoCheckpoint(ctx, m, rec)
+		err := DoCheckpoint(ctx, store, rec)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				return
@@ -81,7 +246,7 @@ func RunCheckpointing(ctx context.Context, m *mongo.Client, rec Recoverable) {
 	}
 }
 
-func DoCheckpoint(ctx context.Context, m *mongo.Client, rec Recoverable) error {
+func DoCheckpoint(ctx context.Context, store StateStore, rec Recoverable) error {
 	data, err := rec.Checkpoint(ctx)
 	if err != nil {
 		return errors.Wrap(err, "checkpoint")
@@ -90,27 +255,9 @@ func DoCheckpoint(ctx context.Context, m *mongo.Client, rec Recoverable) error {
 		return errNoRecoveryData
 	}
 
-	_, err = m.Database(config.PCSMDatabase).
-		Collection(config.RecoveryCollection).
-		ReplaceOne(ctx,
-			bson.D{{"_id", recoveryID}},
-			checkpoint{
-				ID:   recoveryID,
-				TS:   time.Now(),
-				Data: data,
-			},
-			options.Replace().SetUpsert(true))
-	if err != nil {
-		return errors.Wrap(err, "save")
-	}
-
-	return nil
+	return store.Save(ctx, data)
 }
 
-func DeleteRecoveryData(ctx context.Context, m *mongo.Client) error {
-	_, err := m.Database(config.PCSMDatabase).
-		Collection(config.RecoveryCollection).
-		DeleteOne(ctx, bson.D{{"_id", recoveryID}})
-
-	return err //nolint:wrapcheck
+func DeleteRecoveryData(ctx context.Context, store StateStore) error {
+	return store.Delete(ctx)
 }
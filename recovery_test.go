@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+)
+
+type testRecoverable struct {
+	data bson.Raw
+}
+
+func (r *testRecoverable) Checkpoint(context.Context) ([]byte, error) {
+	return r.data, nil
+}
+
+func (r *testRecoverable) Recover(_ context.Context, data []byte) error {
+	r.data = data
+
+	return nil
+}
+
+func testStateStore(t *testing.T, store StateStore) {
+	t.Helper()
+
+	ctx := t.Context()
+
+	err := store.Delete(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Load(ctx)
+	if !errors.Is(err, errNoRecoveryData) {
+		t.Fatalf("load: got = %v, want %v", err, errNoRecoveryData)
+	}
+
+	for _, v := range []string{"first", "second"} {
+		data, err := bson.Marshal(bson.D{{"state", "running"}, {"token", v}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = DoCheckpoint(ctx, store, &testRecoverable{data: data})
+		if err != nil {
+			t.Fatalf("checkpoint %q: %v", v, err)
+		}
+
+		rec := &testRecoverable{}
+
+		err = Restore(ctx, store, rec)
+		if err != nil {
+			t.Fatalf("restore %q: %v", v, err)
+		}
+
+		if !bytes.Equal(rec.data, data) {
+			t.Errorf("restore %q: got %s, want %s", v, rec.data, bson.Raw(data))
+		}
+	}
+
+	err = DeleteRecoveryData(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Load(ctx)
+	if !errors.Is(err, errNoRecoveryData) {
+		t.Errorf("load after delete: got = %v, want %v", err, errNoRecoveryData)
+	}
+}
+
+func TestFileStateStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pcsm.state")
+
+	testStateStore(t, newFileStateStore(path))
+
+	err := DoCheckpoint(t.Context(), newFileStateStore(path), &testRecoverable{})
+	if !errors.Is(err, errNoRecoveryData) {
+		t.Errorf("empty checkpoint: got = %v, want %v", err, errNoRecoveryData)
+	}
+
+	if tmp, _ := filepath.Glob(path + ".*.tmp"); len(tmp) != 0 {
+		t.Errorf("temporary files are left: %v", tmp)
+	}
+}
+
+func TestFileStateStore_ConcurrentSave(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pcsm.state")
+	store := newFileStateStore(path)
+
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			data, _ := bson.Marshal(bson.D{{"i", i}, {"pad", strings.Repeat("x", 64<<10)}})
+
+			err := store.Save(t.Context(), data)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the state file is one of the complete saves
+	data, err := store.Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := data.Lookup("i").AsInt64OK(); !ok || len(data.Lookup("pad").StringValue()) != 64<<10 {
+		t.Errorf("got = %v, want a complete save", data.Lookup("i"))
+	}
+
+	if tmp, _ := filepath.Glob(path + ".*.tmp"); len(tmp) != 0 {
+		t.Errorf("temporary files are left: %v", tmp)
+	}
+}
+
+func TestMongoStateStore(t *testing.T) {
+	t.Parallel()
+
+	uri := os.Getenv("PCSM_TARGET_URI")
+	if uri == "" {
+		t.Skip("PCSM_TARGET_URI is empty")
+	}
+
+	target, err := topo.Connect(t.Context(), uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Disconnect(context.Background()) //nolint:errcheck
+
+	testStateStore(t, mongoStateStore{target})
+}
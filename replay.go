@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// errReplayFailed indicates that the replay command has dead-letter entries that failed again.
+var errReplayFailed = errors.New("dead-letter entries failed to apply")
+
+// runReplayFile applies the dead-letter entries of the file to the target and prints the result.
+func runReplayFile(ctx context.Context, w io.Writer, targetURI, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrap(err, "open file")
+	}
+	defer f.Close()
+
+	target, err := topo.Connect(ctx, targetURI)
+	if err != nil {
+		return errors.Wrap(err, "connect to target")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, target.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect target: " + err.Error())
+		}
+	}()
+
+	res, err := pcsm.ReplayDeadLetterFile(ctx, target, f)
+	if res != nil {
+		fmt.Fprintf(w, "%d replayed, %d failed\n", res.Replayed, res.Failed)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "replay")
+	}
+
+	if res.Failed != 0 {
+		return errors.Wrapf(errReplayFailed, "%d entries", res.Failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// startRequestSchemaData is the JSON schema of the /start request body.
+//
+//go:embed start-request.schema.json
+var startRequestSchemaData []byte
+
+//nolint:gochecknoglobals
+var startRequestSchema = mustParseSchema(startRequestSchemaData)
+
+// jsonSchema is the subset of the JSON schema used to validate the API requests.
+type jsonSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// additionalProperties is either a boolean or a schema of the additional properties.
+type additionalProperties struct {
+	Allowed bool
+	Schema  *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, &a.Allowed)
+	if err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+
+	return json.Unmarshal(data, &a.Schema) //nolint:wrapcheck
+}
+
+func mustParseSchema(data []byte) *jsonSchema {
+	var s jsonSchema
+
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		panic(errors.Wrap(err, "parse schema"))
+	}
+
+	s.compile()
+
+	return &s
+}
+
+func (s *jsonSchema) compile() {
+	if s.Pattern != "" {
+		s.pattern = regexp.MustCompile(s.Pattern)
+	}
+
+	for _, p := range s.Properties {
+		p.compile()
+	}
+
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		s.AdditionalProperties.Schema.compile()
+	}
+
+	if s.Items != nil {
+		s.Items.compile()
+	}
+}
+
+// schemaError is a validation error of a request field.
+type schemaError struct {
+	// Field is the path of the invalid field (e.g. "cappedTail.db1.log").
+	Field string `json:"field"`
+	// Message describes why the field is invalid.
+	Message string `json:"message"`
+}
+
+func (e schemaError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+
+	return e.Field + ": " + e.Message
+}
+
+// validateRequest validates the JSON data against the schema.
+func validateRequest(s *jsonSchema, data []byte) []schemaError {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+
+	err := dec.Decode(&v)
+	if err != nil {
+		return []schemaError{{Message: "invalid JSON: " + err.Error()}}
+	}
+
+	return s.validate("", v, nil)
+}
+
+func (s *jsonSchema) validate(path string, v any, errs []schemaError) []schemaError {
+	if got := jsonType(v); s.Type != "" && got != s.Type && (s.Type != "number" || got != "integer") {
+		return append(errs, schemaError{path, fmt.Sprintf("expected %s, got %s", s.Type, got)})
+	}
+
+	if len(s.Enum) != 0 && !slices.Contains(s.Enum, v) {
+		return append(errs, schemaError{path, fmt.Sprintf("must be one of %s", formatEnum(s.Enum))})
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			field := joinField(path, k)
+
+			if p, ok := s.Properties[k]; ok {
+				errs = p.validate(field, v[k], errs)
+
+				continue
+			}
+
+			switch add := s.AdditionalProperties; {
+			case add == nil:
+			case !add.Allowed:
+				errs = append(errs, schemaError{field, "unknown field"})
+			case add.Schema != nil:
+				errs = add.Schema.validate(field, v[k], errs)
+			}
+		}
+
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+
+	case json.Number:
+		n, _ := v.Float64()
+
+		if s.Minimum != nil && n < *s.Minimum {
+			errs = append(errs, schemaError{path, "must be greater than or equal to " + formatNumber(*s.Minimum)})
+		}
+
+		if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+			errs = append(errs, schemaError{path, "must be greater than " + formatNumber(*s.ExclusiveMinimum)})
+		}
+
+		if s.Maximum != nil && n > *s.Maximum {
+			errs = append(errs, schemaError{path, "must be less than or equal to " + formatNumber(*s.Maximum)})
+		}
+
+	case string:
+		if s.MinLength != nil && utf8.RuneCountInString(v) < *s.MinLength {
+			errs = append(errs, schemaError{path, fmt.Sprintf("length must be at least %d", *s.MinLength)})
+		} else if s.pattern != nil && !s.pattern.MatchString(v) {
+			errs = append(errs, schemaError{path, fmt.Sprintf("%q does not match %q", v, s.Pattern)})
+		}
+	}
+
+	return errs
+}
+
+// jsonType returns the JSON schema type of the decoded value.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return "number"
+		}
+
+		return "integer"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// formatNumber formats the schema limit without the exponent (e.g. 47999488).
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func formatEnum(values []any) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = fmt.Sprintf("%q", v)
+	}
+
+	return strings.Join(s, ", ")
+}
+
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
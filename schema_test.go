@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStartRequestSchema_Fields(t *testing.T) {
+	t.Parallel()
+
+	fields := make(map[string]bool)
+
+	typ := reflect.TypeFor[startRequest]()
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+
+		if _, ok := startRequestSchema.Properties[name]; !ok {
+			t.Errorf("%q is missing in the schema", name)
+		}
+	}
+
+	for name := range startRequestSchema.Properties {
+		if !fields[name] {
+			t.Errorf("%q is not a startRequest field", name)
+		}
+	}
+}
+
+func TestValidateRequest_Valid(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(startRequest{
+		IncludeNamespaces:   []string{"db1.*"},
+		MinOplogHours:       1.5,
+		CappedTail:          map[string]int64{"db1.log": 100},
+		CloneSnapshot:       "session",
+		Transforms:          []string{"noop"},
+		MaxClockSkew:        "1m30s",
+		ReplicationMethod:   "auto",
+		DeadLetterNamespace: "pcsm_dlq.events",
+		PauseOnDDL:          true,
+		ApplyOpTimeout:      "30s",
+
+		ChangeStreamMaxAwaitTime: "500ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := validateRequest(startRequestSchema, data); len(errs) != 0 {
+		t.Errorf("got = %v, want no errors", errs)
+	}
+}
+
+func TestHandleStart_SchemaValidation(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer((&server{}).Handler())
+	defer srv.Close()
+
+	body := `{
+		"pauseOnInitialSync": "yes",
+		"includeNamespaces": ["db1.*", 2],
+		"minOplogHours": -1,
+		"cappedTail": {"db1.log": "10", "db1.events": 0},
+		"replicationMethod": "binlog",
+		"maxClockSkew": "5 seconds",
+		"changeStreamBatchSize": 0,
+		"cloneBatchBytes": 48000000,
+		"copyMarkerField": "",
+		"unknownOption": true
+	}`
+
+	res, err := http.Post(srv.URL+"/start", "application/json", bytes.NewBufferString(body)) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var resp startResponse
+
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Ok || !strings.HasPrefix(resp.Err, "invalid request: ") {
+		t.Errorf("got = %+v, want invalid request", resp)
+	}
+
+	want := map[string]string{
+		"cappedTail.db1.events": "must be greater than 0",
+		"cappedTail.db1.log":    "expected integer, got string",
+		"changeStreamBatchSize": "must be greater than 0",
+		"cloneBatchBytes":       "must be less than or equal to 47999488",
+		"copyMarkerField":       "length must be at least 1",
+		"includeNamespaces[1]":  "expected string, got integer",
+		"maxClockSkew":          `"5 seconds" does not match`,
+		"minOplogHours":         "must be greater than or equal to 0",
+		"pauseOnInitialSync":    "expected boolean, got string",
+		"replicationMethod":     `must be one of "", "auto", "changestream", "oplog"`,
+		"unknownOption":         "unknown field",
+	}
+
+	if len(resp.ValidationErrors) != len(want) {
+		t.Errorf("got %d errors, want %d: %v", len(resp.ValidationErrors), len(want), resp.ValidationErrors)
+	}
+
+	for _, e := range resp.ValidationErrors {
+		if msg, ok := want[e.Field]; !ok || !strings.HasPrefix(e.Message, msg) {
+			t.Errorf("%s: got = %q, want %q", e.Field, e.Message, msg)
+		}
+	}
+}
+
+func TestHandleStartSchema(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer((&server{}).Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/schema/start") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var schema map[string]any
+
+	err = json.NewDecoder(res.Body).Decode(&schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema["type"] != "object" {
+		t.Errorf("got type = %v, want object", schema["type"])
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// Target types (--target-type).
+const (
+	// targetTypeMongoDB replicates to the MongoDB cluster of --target.
+	targetTypeMongoDB = "mongodb"
+	// targetTypeFile writes the change events to the file of --target as JSON Lines.
+	targetTypeFile = "file"
+	// targetTypeStdout writes the change events to the standard output as JSON Lines.
+	targetTypeStdout = "stdout"
+)
+
+// runSink writes the change events of the source to the file or stdout sink until interrupted.
+// There is no clone, HTTP server, or checkpoint: the sink is for debugging the replication
+// without a target cluster.
+func runSink(ctx context.Context, sourceURI, targetType, path string) error {
+	lg := log.New("sink")
+
+	var w io.Writer = os.Stdout
+
+	if targetType == targetTypeFile {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:mnd,gosec
+		if err != nil {
+			return errors.Wrap(err, "open file")
+		}
+
+		defer func() {
+			err := f.Close()
+			if err != nil {
+				lg.Error(err, "Close file")
+			}
+		}()
+
+		w = f
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, os.Kill)
+	defer stop()
+
+	source, err := topo.Connect(ctx, sourceURI)
+	if err != nil {
+		return errors.Wrap(err, "connect to source cluster")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(context.Background(), config.DisconnectTimeout, source.Disconnect)
+		if err != nil {
+			lg.Warn("Disconnect Source Cluster: " + err.Error())
+		}
+	}()
+
+	err = pcsm.WatchToSink(ctx, source, pcsm.NewJSONLSink(w))
+	if err != nil {
+		return errors.Wrap(err, "sink")
+	}
+
+	lg.Info("Stopped")
+
+	return nil
+}
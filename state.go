@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// stateExportVersion is the version of the state export format.
+const stateExportVersion = 1
+
+// redactedValue replaces the secrets in the state export.
+const redactedValue = "<redacted>"
+
+var (
+	// errStateExists indicates that the state store already has the recovery data to import over.
+	errStateExists = errors.New("recovery data already exists")
+	// errStateExportVersion indicates an unsupported version of the state export.
+	errStateExportVersion = errors.New("unsupported state export version")
+)
+
+// secretKeyPattern matches the field names of the secret values.
+//
+//nolint:gochecknoglobals
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|credential)`)
+
+// connectionStringPattern matches the MongoDB connection strings in a text (e.g. an error message).
+var connectionStringPattern = regexp.MustCompile(`mongodb(\+srv)?://[^\s"']+`) //nolint:gochecknoglobals
+
+// stateExport is the file form of the recovery data moved between the hosts.
+type stateExport struct {
+	Version    int       `bson:"version"`
+	ExportedAt time.Time `bson:"exportedAt"`
+	Data       bson.Raw  `bson:"data"`
+}
+
+// withStateStore calls f with the file state store of the state file or with the state store
+// in the PCSM database of the target.
+func withStateStore(ctx context.Context, targetURI, stateFile string, f func(StateStore) error) error {
+	if stateFile != "" {
+		return f(newFileStateStore(stateFile))
+	}
+
+	target, err := topo.Connect(ctx, targetURI)
+	if err != nil {
+		return errors.Wrap(err, "connect")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, target.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect: " + err.Error())
+		}
+	}()
+
+	return f(mongoStateStore{target})
+}
+
+// exportState writes the recovery data of the store as canonical Extended JSON.
+// The secrets are redacted.
+func exportState(ctx context.Context, store StateStore, w io.Writer) error {
+	data, err := store.Load(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load")
+	}
+
+	data, err = redactState(data)
+	if err != nil {
+		return errors.Wrap(err, "redact")
+	}
+
+	out, err := bson.MarshalExtJSONIndent(stateExport{
+		Version:    stateExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Data:       data,
+	}, true, false, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+
+	_, err = w.Write(append(out, '\n'))
+
+	return errors.Wrap(err, "write")
+}
+
+// importState saves the recovery data of the export to the store. The existing recovery data
+// is replaced only with force.
+func importState(ctx context.Context, store StateStore, r io.Reader, force bool) error {
+	in, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "read")
+	}
+
+	var export stateExport
+
+	err = bson.UnmarshalExtJSON(in, true, &export)
+	if err != nil {
+		return errors.Wrap(err, "decode")
+	}
+
+	if export.Version != stateExportVersion {
+		return errors.Wrapf(errStateExportVersion, "%d", export.Version)
+	}
+
+	if len(export.Data) == 0 {
+		return errNoRecoveryData
+	}
+
+	if !force {
+		_, err = store.Load(ctx)
+		if err == nil {
+			return errStateExists
+		}
+
+		if !errors.Is(err, errNoRecoveryData) {
+			return errors.Wrap(err, "load")
+		}
+	}
+
+	return errors.Wrap(store.Save(ctx, export.Data), "save")
+}
+
+// redactState returns a copy of the recovery data with the secret fields and the passwords
+// of the connection strings replaced.
+func redactState(data bson.Raw) (bson.Raw, error) {
+	var doc bson.D
+
+	err := bson.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+
+	res, err := bson.Marshal(redactDocument(doc))
+
+	return res, errors.Wrap(err, "marshal")
+}
+
+func redactDocument(doc bson.D) bson.D {
+	for i, elem := range doc {
+		if s, ok := elem.Value.(string); ok && secretKeyPattern.MatchString(elem.Key) && s != "" {
+			doc[i].Value = redactedValue
+		} else {
+			doc[i].Value = redactValue(elem.Value)
+		}
+	}
+
+	return doc
+}
+
+func redactValue(val any) any {
+	switch v := val.(type) {
+	case bson.D:
+		return redactDocument(v)
+	case bson.A:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+
+		return v
+	case string:
+		return connectionStringPattern.ReplaceAllStringFunc(v, redactConnectionString)
+	}
+
+	return val
+}
+
+// redactConnectionString replaces the password of the MongoDB connection string.
+func redactConnectionString(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return redactedValue
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+// tailContentType is the content type of the /tail stream: one JSON document per line.
+const tailContentType = "application/x-ndjson"
+
+// handleTail handles the /tail endpoint. It streams the change events read from the source
+// until the client disconnects.
+func (s *server) handleTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w,
+			http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	options, err := parseTailQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// subscribe before the response headers, so the events after them are streamed
+	eventC := s.pcsm.Tail(r.Context(), options)
+
+	w.Header().Set("Content-Type", tailContentType)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for data := range eventC {
+		_, err = w.Write(append(data, '\n'))
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// parseTailQuery parses the "namespace" (repeated) and "fullDocument" query parameters
+// of the /tail endpoint.
+func parseTailQuery(query url.Values) (pcsm.TailOptions, error) {
+	options := pcsm.TailOptions{Namespaces: query["namespace"]}
+
+	for _, ns := range options.Namespaces {
+		if !strings.Contains(ns, ".") {
+			return pcsm.TailOptions{}, errors.Errorf("invalid namespace %q: expected db.collection or db.*", ns)
+		}
+	}
+
+	if v := query.Get("fullDocument"); v != "" {
+		var err error
+
+		options.FullDocument, err = strconv.ParseBool(v)
+		if err != nil {
+			return pcsm.TailOptions{}, errors.Errorf("invalid fullDocument %q", v)
+		}
+	}
+
+	return options, nil
+}
+
+// Tail prints the change events read from the source as JSON Lines until the context is done.
+func (c PCSMClient) Tail(ctx context.Context, namespaces []string, fullDocument bool) error {
+	query := url.Values{"namespace": namespaces}
+	if fullDocument {
+		query.Set("fullDocument", "true")
+	}
+
+	path := "tail"
+	if q := query.Encode(); q != "" {
+		path += "?" + q
+	}
+
+	res, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+
+		return errors.Errorf("tail: %s", strings.TrimSpace(string(msg)))
+	}
+
+	_, err = io.Copy(os.Stdout, res.Body)
+	if err != nil && ctx.Err() == nil {
+		return errors.Wrap(err, "read change events")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestParseTailQuery(t *testing.T) {
+	t.Parallel()
+
+	options, err := parseTailQuery(url.Values{
+		"namespace":    {"db_1.coll_1", "db_2.*"},
+		"fullDocument": {"true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(options.Namespaces) != 2 || !options.FullDocument {
+		t.Errorf("got = %+v, want 2 namespaces with the full document", options)
+	}
+
+	for _, query := range []url.Values{
+		{"namespace": {"db_1"}},
+		{"fullDocument": {"yes please"}},
+	} {
+		if _, err := parseTailQuery(query); err == nil {
+			t.Errorf("%v: got = nil, want error", query)
+		}
+	}
+}
+
+func TestHandleTail(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer((&server{pcsm: pcsm.New(nil, nil, pcsm.Options{})}).Handler())
+	t.Cleanup(srv.Close)
+
+	res, err := http.Post(srv.URL+"/tail", "", nil) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got status %d, want %d", res.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	res, err = http.Get(srv.URL + "/tail?namespace=db_1") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid namespace: got status %d, want %d", res.StatusCode, http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tail?namespace=db_1.*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the headers are sent before the first change event
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != tailContentType {
+		t.Errorf("got status %d, content type %q, want %d, %q",
+			res.StatusCode, res.Header.Get("Content-Type"), http.StatusOK, tailContentType)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// replicationTarget is an additional target of the fan-out replication (--target-uri).
+// Each target has its own PCSM: the clone, the change replication, and the checkpoint
+// are independent of the other targets, so a failed or lagging target does not stall
+// the others.
+type replicationTarget struct {
+	// name is the hosts of the target connection string.
+	name string
+	// cluster is the MongoDB client for the target cluster.
+	cluster *mongo.Client
+	// pcsm is the PCSM instance replicating to the target.
+	pcsm *pcsm.PCSM
+	// stopHeartbeat stops the heartbeat on the target.
+	stopHeartbeat StopHeartbeat
+}
+
+// connectReplicationTarget connects to the additional target and recovers its PCSM.
+func connectReplicationTarget(
+	ctx context.Context,
+	source *mongo.Client,
+	uri string,
+	memory *pcsm.MemoryBudget,
+	options serverOptions,
+) (*replicationTarget, error) {
+	cs, _ := connstring.Parse(uri)
+	name := strings.Join(cs.Hosts, ",")
+
+	target, err := topo.ConnectWithOptions(ctx, uri, &topo.ConnectOptions{
+		Compressors: config.UseTargetClientCompressors(),
+		Encryption:  options.targetEncryption,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "connect")
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		err1 := util.CtxWithTimeout(ctx, config.DisconnectTimeout, target.Disconnect)
+		if err1 != nil {
+			log.Ctx(ctx).Warn("Disconnect Target Cluster: " + err1.Error())
+		}
+	}()
+
+	targetVersion, err := topo.Version(ctx, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "version")
+	}
+
+	log.Ctx(ctx).Infof("Connected to additional target cluster [%s]: %s://%s",
+		targetVersion.FullString(), cs.Scheme, name)
+
+	stopHeartbeat, err := RunHeartbeat(ctx, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "heartbeat")
+	}
+
+	pcs := pcsm.New(source, target, pcsm.Options{
+		UseCollectionBulkWrite: options.targetEncryption != nil,
+		Memory:                 memory,
+	})
+
+	err = Restore(ctx, mongoStateStore{target}, pcs)
+	if err != nil {
+		_ = stopHeartbeat(ctx)
+
+		return nil, errors.Wrap(err, "recover PCSM")
+	}
+
+	go RunCheckpointing(ctx, mongoStateStore{target}, pcs)
+
+	t := &replicationTarget{
+		name:          name,
+		cluster:       target,
+		pcsm:          pcs,
+		stopHeartbeat: stopHeartbeat,
+	}
+
+	return t, nil
+}
+
+// close stops the heartbeat and disconnects the target.
+func (t *replicationTarget) close(ctx context.Context) error {
+	err0 := t.stopHeartbeat(ctx)
+	err1 := t.cluster.Disconnect(ctx)
+
+	return errors.Join(err0, err1)
+}
+
+// fanOut calls f with the PCSM of the target and of each additional target.
+// A failed call does not prevent the calls for the other targets. The errors of
+// the additional targets are prefixed with the target name.
+func (s *server) fanOut(f func(*pcsm.PCSM) error) error {
+	errs := []error{f(s.pcsm)}
+
+	for _, t := range s.targets {
+		err := f(t.pcsm)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "target "+t.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fanOutOrUndo calls f with the PCSM of the target and of each additional target in order,
+// and stops at the first failed call. undo is called then with the PCSM of each target
+// f succeeded for, so the targets are not left partially started. The errors of
+// the additional targets are prefixed with the target name.
+func (s *server) fanOutOrUndo(f, undo func(*pcsm.PCSM) error) error {
+	err := f(s.pcsm)
+	if err != nil {
+		return err
+	}
+
+	done := []*pcsm.PCSM{s.pcsm}
+
+	for _, t := range s.targets {
+		err := f(t.pcsm)
+		if err == nil {
+			done = append(done, t.pcsm)
+
+			continue
+		}
+
+		errs := []error{errors.Wrap(err, "target "+t.name)}
+		for _, p := range done {
+			errs = append(errs, undo(p))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// stopStarted stops the replication just started on a target after the start has failed
+// on another target: the change replication is paused, or the clone is drained.
+func stopStarted(ctx context.Context, p *pcsm.PCSM) error {
+	err := p.Pause(ctx)
+	if err == nil {
+		return nil
+	}
+
+	return errors.Wrap(p.Drain(ctx), "stop started target")
+}
+
+// onTargetStateChanged checkpoints the PCSM on each state change. With --pause-on-target-failure,
+// a failure of the replication to one target pauses the replication to the other targets.
+func (s *server) onTargetStateChanged(
+	ctx context.Context,
+	state StateStore,
+	pcs *pcsm.PCSM,
+) pcsm.OnStateChangedFunc {
+	return func(newState pcsm.State) {
+		err := DoCheckpoint(ctx, state, pcs)
+		if err != nil {
+			log.New("http:checkpointing").Error(err, "checkpoint")
+		} else {
+			log.New("http:checkpointing").Debugf("Checkpoint saved on %q", newState)
+		}
+
+		if newState != pcsm.StateFailed || !s.pauseOnTargetFailure {
+			return
+		}
+
+		err = s.fanOut(func(other *pcsm.PCSM) error {
+			if other == pcs || other.Status(ctx).State != pcsm.StateRunning {
+				return nil
+			}
+
+			return other.Pause(ctx)
+		})
+		if err != nil {
+			log.New("targets").Error(err, "Pause on target failure")
+		}
+	}
+}
+
+// statusTargetResponse represents the status of an additional target in the /status response.
+type statusTargetResponse struct {
+	// Target is the hosts of the target connection string.
+	Target string `json:"target"`
+
+	// Ok indicates if the replication to the target has no error.
+	Ok bool `json:"ok"`
+	// Err is the error message of the replication to the target.
+	Err string `json:"error,omitempty"`
+	// State is the current state of the replication to the target.
+	State pcsm.State `json:"state"`
+
+	// LagTime is the current lag time of the target in logical seconds.
+	LagTime int64 `json:"lagTime"`
+	// EventsProcessed is the number of events applied to the target.
+	EventsProcessed int64 `json:"eventsProcessed"`
+	// LastReplicatedOpTime is the last operation time applied to the target.
+	LastReplicatedOpTime string `json:"lastReplicatedOpTime,omitempty"`
+	// InitialSyncCompleted indicates if the initial sync of the target is completed.
+	InitialSyncCompleted bool `json:"initialSyncCompleted"`
+}
+
+func newStatusTargetResponse(name string, status *pcsm.Status) statusTargetResponse {
+	res := statusTargetResponse{
+		Target: name,
+		Ok:     status.Error == nil,
+		State:  status.State,
+
+		LagTime:              status.TotalLagTime,
+		EventsProcessed:      status.Repl.EventsProcessed,
+		InitialSyncCompleted: status.InitialSyncCompleted,
+	}
+
+	if status.Error != nil {
+		res.Err = status.Error.Error()
+	}
+
+	if optime := status.Repl.LastReplicatedOpTime; !optime.IsZero() {
+		res.LastReplicatedOpTime = fmt.Sprintf("%d.%d", optime.T, optime.I)
+	}
+
+	return res
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestServer_FanOut(t *testing.T) {
+	t.Parallel()
+
+	s := &server{
+		pcsm: pcsm.New(nil, nil, pcsm.Options{}),
+		targets: []*replicationTarget{
+			{name: "target-1:27017", pcsm: pcsm.New(nil, nil, pcsm.Options{})},
+			{name: "target-2:27017", pcsm: pcsm.New(nil, nil, pcsm.Options{})},
+		},
+	}
+
+	failed := s.targets[0].pcsm
+	called := make(map[*pcsm.PCSM]bool)
+
+	err := s.fanOut(func(p *pcsm.PCSM) error {
+		called[p] = true
+
+		if p == failed {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	})
+
+	// the failure on one target does not stop the other targets
+	for _, p := range []*pcsm.PCSM{s.pcsm, s.targets[0].pcsm, s.targets[1].pcsm} {
+		if !called[p] {
+			t.Errorf("not called for %p", p)
+		}
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "target target-1:27017: connection refused") {
+		t.Errorf("got error %v, want the target-1 error", err)
+	}
+
+	if strings.Contains(err.Error(), "target-2") {
+		t.Errorf("got error %v, want no target-2 error", err)
+	}
+}
+
+func TestServer_FanOutOrUndo(t *testing.T) {
+	t.Parallel()
+
+	s := &server{
+		pcsm: pcsm.New(nil, nil, pcsm.Options{}),
+		targets: []*replicationTarget{
+			{name: "target-1:27017", pcsm: pcsm.New(nil, nil, pcsm.Options{})},
+			{name: "target-2:27017", pcsm: pcsm.New(nil, nil, pcsm.Options{})},
+		},
+	}
+
+	failed := s.targets[0].pcsm
+	called := make(map[*pcsm.PCSM]bool)
+	undone := make(map[*pcsm.PCSM]bool)
+
+	err := s.fanOutOrUndo(func(p *pcsm.PCSM) error {
+		called[p] = true
+
+		if p == failed {
+			return errors.New("preflight failed")
+		}
+
+		return nil
+	}, func(p *pcsm.PCSM) error {
+		undone[p] = true
+
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "target target-1:27017: preflight failed") {
+		t.Errorf("got error %v, want the target-1 error", err)
+	}
+
+	// the target started before the failure is stopped, the next targets are not started
+	if !undone[s.pcsm] || len(undone) != 1 {
+		t.Errorf("undone: got = %v, want the first target only", undone)
+	}
+
+	if called[s.targets[1].pcsm] {
+		t.Error("started after the failure")
+	}
+}
+
+func TestNewStatusTargetResponse(t *testing.T) {
+	t.Parallel()
+
+	status := &pcsm.Status{
+		State:                pcsm.StateFailed,
+		Error:                errors.New("change replication: write error"),
+		TotalLagTime:         12,
+		InitialSyncCompleted: true,
+	}
+	status.Repl.EventsProcessed = 100
+	status.Repl.LastReplicatedOpTime = bson.Timestamp{T: 1700000000, I: 3}
+
+	got := newStatusTargetResponse("target-1:27017", status)
+	want := statusTargetResponse{
+		Target: "target-1:27017",
+		Ok:     false,
+		Err:    "change replication: write error",
+		State:  pcsm.StateFailed,
+
+		LagTime:              12,
+		EventsProcessed:      100,
+		LastReplicatedOpTime: "1700000000.3",
+		InitialSyncCompleted: true,
+	}
+
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -52,13 +52,108 @@ class PCSM:
 
         return payload
 
-    def start(self, include_namespaces=None, exclude_namespaces=None, pause_on_initial_sync=False):
+    def start(
+        self,
+        include_namespaces=None,
+        exclude_namespaces=None,
+        replicate_include=None,
+        replicate_exclude=None,
+        strict_namespaces=False,
+        pause_on_initial_sync=False,
+        capped_tail=None,
+        clone_batch_bytes=None,
+        clone_max_inflight_batches=None,
+        clone_snapshot=None,
+        copy_marker_field=None,
+        dead_letter_namespace=None,
+        ddl_audit_collection=None,
+        max_doc_retries=None,
+        pause_on_ddl=False,
+        disable_balancer_during_clone=False,
+        include_empty_collections=None,
+        manage_ttl_during_replication=None,
+        replicate_hidden_indexes=False,
+        replicate_dropdatabase=None,
+        discover_new_collections=False,
+        start_from_backup_timestamp=None,
+        clone_read_concern=None,
+        clone_order=None,
+        preserve_order_within_transaction=False,
+        rename_collision_suffix=None,
+        target_namespace_prefix=None,
+        on_schema_drift=None,
+        on_unknown_event=None,
+        max_total_duration=None,
+        on_budget_exceeded=None,
+        update_as_upsert=False,
+        require_matching_fcv=False,
+    ):
         """Start the PCSM service with the given parameters."""
         options = {"pauseOnInitialSync": pause_on_initial_sync}
         if include_namespaces:
             options["includeNamespaces"] = include_namespaces
         if exclude_namespaces:
             options["excludeNamespaces"] = exclude_namespaces
+        if replicate_include:
+            options["replicateInclude"] = replicate_include
+        if replicate_exclude:
+            options["replicateExclude"] = replicate_exclude
+        if strict_namespaces:
+            options["strictNamespaces"] = strict_namespaces
+        if capped_tail:
+            options["cappedTail"] = capped_tail
+        if clone_batch_bytes:
+            options["cloneBatchBytes"] = clone_batch_bytes
+        if clone_max_inflight_batches:
+            options["cloneMaxInflightBatches"] = clone_max_inflight_batches
+        if copy_marker_field:
+            options["copyMarkerField"] = copy_marker_field
+        if dead_letter_namespace:
+            options["deadLetterNamespace"] = dead_letter_namespace
+        if ddl_audit_collection:
+            options["ddlAuditCollection"] = ddl_audit_collection
+        if max_doc_retries:
+            options["maxDocRetries"] = max_doc_retries
+        if pause_on_ddl:
+            options["pauseOnDDL"] = pause_on_ddl
+        if disable_balancer_during_clone:
+            options["disableBalancerDuringClone"] = disable_balancer_during_clone
+        if include_empty_collections is not None:
+            options["includeEmptyCollections"] = include_empty_collections
+        if manage_ttl_during_replication is not None:
+            options["manageTTLDuringReplication"] = manage_ttl_during_replication
+        if replicate_hidden_indexes:
+            options["replicateHiddenIndexes"] = replicate_hidden_indexes
+        if replicate_dropdatabase is not None:
+            options["replicateDropDatabase"] = replicate_dropdatabase
+        if discover_new_collections:
+            options["discoverNewCollections"] = discover_new_collections
+        if start_from_backup_timestamp:
+            options["startFromBackupTimestamp"] = start_from_backup_timestamp
+        if clone_read_concern:
+            options["cloneReadConcern"] = clone_read_concern
+        if clone_order:
+            options["cloneOrder"] = clone_order
+        if clone_snapshot:
+            options["cloneSnapshot"] = clone_snapshot
+        if preserve_order_within_transaction:
+            options["preserveOrderWithinTransaction"] = preserve_order_within_transaction
+        if rename_collision_suffix:
+            options["renameCollisionSuffix"] = rename_collision_suffix
+        if target_namespace_prefix:
+            options["targetNamespacePrefix"] = target_namespace_prefix
+        if on_schema_drift:
+            options["onSchemaDrift"] = on_schema_drift
+        if on_unknown_event:
+            options["onUnknownEvent"] = on_unknown_event
+        if max_total_duration:
+            options["maxTotalDuration"] = max_total_duration
+        if on_budget_exceeded:
+            options["onBudgetExceeded"] = on_budget_exceeded
+        if update_as_upsert:
+            options["updateAsUpsert"] = update_as_upsert
+        if require_matching_fcv:
+            options["requireMatchingFcv"] = require_matching_fcv
 
         res = requests.post(f"{self.uri}/start", json=options, timeout=DFL_REQ_TIMEOUT)
         res.raise_for_status()
@@ -69,9 +164,70 @@ class PCSM:
 
         return payload
 
-    def pause(self):
-        """Pause the PCSM service."""
-        res = requests.post(f"{self.uri}/pause", timeout=DFL_REQ_TIMEOUT)
+    def pause(self, namespace=None):
+        """Pause the PCSM service or the namespaces matching the pattern."""
+        body = {"namespace": namespace} if namespace else None
+        res = requests.post(f"{self.uri}/pause", json=body, timeout=DFL_REQ_TIMEOUT)
+        res.raise_for_status()
+
+        payload = res.json()
+        if not payload["ok"]:
+            raise PCSMServerError(payload["error"])
+
+        return payload
+
+    def drain(self):
+        """Stop the data clone of the PCSM service after the collections in progress."""
+        res = requests.post(f"{self.uri}/drain", timeout=DFL_REQ_TIMEOUT)
+        res.raise_for_status()
+
+        payload = res.json()
+        if not payload["ok"]:
+            raise PCSMServerError(payload["error"])
+
+        return payload
+
+    def resume(self, namespace=None):
+        """Resume the PCSM service or the namespaces paused with the pattern."""
+        body = {"namespace": namespace} if namespace else None
+        res = requests.post(f"{self.uri}/resume", json=body, timeout=DFL_REQ_TIMEOUT)
+        res.raise_for_status()
+
+        payload = res.json()
+        if not payload["ok"]:
+            raise PCSMServerError(payload["error"])
+
+        return payload
+
+    def approve_ddl(self):
+        """Approve the pending DDL change of the PCSM service."""
+        res = requests.post(f"{self.uri}/approve-ddl", timeout=DFL_REQ_TIMEOUT)
+        res.raise_for_status()
+
+        payload = res.json()
+        if not payload["ok"]:
+            raise PCSMServerError(payload["error"])
+
+        return payload
+
+    def plan_detailed(
+        self,
+        include_namespaces=None,
+        exclude_namespaces=None,
+        estimate_mode=None,
+        sample_size=None,
+    ):
+        """Get the source namespaces of the filters with their options, indexes, and size."""
+        options = {}
+        if include_namespaces:
+            options["includeNamespaces"] = include_namespaces
+        if exclude_namespaces:
+            options["excludeNamespaces"] = exclude_namespaces
+        if estimate_mode:
+            options["estimateMode"] = estimate_mode
+        if sample_size:
+            options["sampleSize"] = sample_size
+        res = requests.post(f"{self.uri}/plan/detailed", json=options, timeout=DFL_REQ_TIMEOUT)
         res.raise_for_status()
 
         payload = res.json()
@@ -80,9 +236,10 @@ class PCSM:
 
         return payload
 
-    def resume(self):
-        """Resume the PCSM service."""
-        res = requests.post(f"{self.uri}/resume", timeout=DFL_REQ_TIMEOUT)
+    def replay_dead_letter(self, namespace=None):
+        """Replay the change events stored in the dead-letter collection."""
+        options = {"namespace": namespace} if namespace else {}
+        res = requests.post(f"{self.uri}/replay-dead-letter", json=options, timeout=DFL_REQ_TIMEOUT)
         res.raise_for_status()
 
         payload = res.json()
@@ -91,9 +248,23 @@ class PCSM:
 
         return payload
 
-    def finalize(self):
+    def tail(self, namespaces=None, full_document=False):
+        """Stream the change events read from the source. Returns the streaming response."""
+        params = {"namespace": namespaces or []}
+        if full_document:
+            params["fullDocument"] = "true"
+        res = requests.get(f"{self.uri}/tail", params=params, stream=True, timeout=DFL_REQ_TIMEOUT)
+        res.raise_for_status()
+
+        return res
+
+    def finalize(self, lock_source=False):
         """Finalize the PCSM service."""
-        res = requests.post(f"{self.uri}/finalize", timeout=DFL_REQ_TIMEOUT)
+        options = {}
+        if lock_source:
+            options["lockSource"] = True
+
+        res = requests.post(f"{self.uri}/finalize", json=options, timeout=DFL_REQ_TIMEOUT)
         res.raise_for_status()
 
         payload = res.json()
@@ -3,9 +3,10 @@ import random
 from datetime import datetime
 
 import time
+import bson
 import pytest
 import testing
-from pcsm import PCSM, Runner
+from pcsm import PCSM, PCSMServerError, Runner
 from pymongo import MongoClient
 from testing import Testing
 from bson.decimal128 import Decimal128
@@ -94,6 +95,150 @@ def test_create_capped(t: Testing, phase: Runner.Phase):
     t.compare_all()
 
 
+def test_clone_capped_tail(t: Testing):
+    t.source["db_1"].create_collection("coll_1", capped=True, size=1_000_000)
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(100))
+    t.source["db_1"]["coll_2"].insert_many({"i": i} for i in range(100))
+
+    options = {"capped_tail": {"db_1.coll_1": 10}}
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, options):
+        pass
+
+    docs = t.target["db_1"]["coll_1"].find({}, {"_id": 0}).sort("$natural", 1)
+    assert [d["i"] for d in docs] == list(range(90, 100))
+    assert t.target["db_1"]["coll_2"].count_documents({}) == 100
+
+
+@pytest.mark.parametrize("include", [True, None])
+def test_clone_empty_collection(t: Testing, include):
+    t.source["db_1"].create_collection("coll_1", collation={"locale": "en_US"})
+    t.source["db_1"]["coll_1"].create_index({"i": 1}, name="i_1")
+
+    options = {"include_empty_collections": include}
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, options):
+        pass
+
+    assert "coll_1" in t.target["db_1"].list_collection_names()
+    assert "i_1" in t.target["db_1"]["coll_1"].index_information()
+    t.compare_all()
+
+
+def test_clone_empty_collection_skipped(t: Testing):
+    t.source["db_1"].create_collection("coll_1")
+    t.source["db_1"]["coll_2"].insert_one({"i": 1})
+
+    options = {"include_empty_collections": False}
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, options):
+        pass
+
+    assert "coll_1" not in t.target["db_1"].list_collection_names()
+    assert t.target["db_1"]["coll_2"].count_documents({}) == 1
+
+
+def test_clone_drain(t: Testing):
+    for i in range(20):
+        t.source["db_1"][f"coll_{i}"].insert_many({"i": j, "s": "x" * 1000} for j in range(10_000))
+
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, {}, wait_timeout=60) as r:
+        r.start()
+        t.pcsm.drain()
+        r.wait_for_state(PCSM.State.PAUSED)
+
+        status = t.pcsm.status()
+        assert status["info"] == "Paused: Data Clone drained", status
+        assert not status["initialSync"]["cloneCompleted"], status
+
+        # the collections in progress are completed. the others are not started
+        names = t.target["db_1"].list_collection_names()
+        assert 0 < len(names) < 20, names
+        for name in names:
+            assert t.target["db_1"][name].count_documents({}) == 10_000, name
+
+        t.source["db_1"]["coll_0"].insert_one({"i": -1})
+
+        t.pcsm.resume()
+        r.wait_for_clone_completed()
+
+    t.compare_all()
+
+
+def test_clone_discover_new_collection(t: Testing):
+    for i in range(20):
+        t.source["db_1"][f"coll_{i}"].insert_many({"i": j, "s": "x" * 1000} for j in range(10_000))
+
+    options = {"discover_new_collections": True}
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, options, wait_timeout=60) as r:
+        r.start()
+        t.source["db_1"]["coll_new"].insert_many({"i": j} for j in range(100))
+
+        # the change replication starts after the clone: the clone has copied the collection
+        for _ in range(60):
+            if t.target["db_1"]["coll_new"].count_documents({}) == 100:
+                break
+            time.sleep(0.5)
+        assert t.target["db_1"]["coll_new"].count_documents({}) == 100
+        assert not t.pcsm.status()["initialSync"]["cloneCompleted"]
+
+        r.wait_for_clone_completed()
+
+    t.compare_all()
+
+
+@pytest.mark.parametrize("read_concern", ["local", "available", "majority", "snapshot"])
+def test_clone_read_concern(t: Testing, read_concern):
+    t.source["db_1"]["coll_1"].insert_many([{"i": i} for i in range(100)])
+    t.source["db_1"]["coll_2"].create_index("i")
+    t.source["db_1"]["coll_2"].insert_many([{"i": i} for i in range(100)])
+
+    options = {"clone_read_concern": read_concern}
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, options):
+        pass
+
+    t.compare_all()
+
+
+def test_plan_detailed(t: Testing):
+    t.source["db_1"].create_collection("coll_1", capped=True, size=1024 * 1024)
+    t.source["db_1"]["coll_1"].create_index({"a": 1, "b": -1}, unique=True, name="a_1_b_-1")
+    t.source["db_1"]["coll_1"].insert_many([{"a": i, "b": i} for i in range(10)])
+    t.source["db_1"]["coll_2"].insert_one({})
+    t.source["db_2"]["coll_1"].insert_one({})
+
+    res = t.pcsm.plan_detailed(include_namespaces=["db_1.coll_1"], exclude_namespaces=["db_2.*"])
+    assert [ns["namespace"] for ns in res["namespaces"]] == ["db_1.coll_1"]
+
+    plan = res["namespaces"][0]
+    assert plan["type"] == "collection"
+    assert plan["options"]["capped"] is True
+    assert plan["options"]["size"] == 1024 * 1024
+    assert plan["count"] == 10
+    assert plan["size"] > 0
+
+    indexes = {index["name"]: index for index in plan["indexes"]}
+    assert set(indexes) == {"_id_", "a_1_b_-1"}
+    assert indexes["a_1_b_-1"]["key"] == {"a": 1, "b": -1}
+    assert indexes["a_1_b_-1"]["unique"] is True
+
+
+def test_plan_estimate_mode(t: Testing):
+    docs = [{"_id": i, "s": "x" * 1000} for i in range(100)]
+    t.source["db_1"]["coll_1"].insert_many(docs)
+    doc_size = len(bson.encode(docs[0]))
+
+    collstats = t.pcsm.plan_detailed(include_namespaces=["db_1.coll_1"], estimate_mode="collstats")
+    sample = t.pcsm.plan_detailed(
+        include_namespaces=["db_1.coll_1"], estimate_mode="sample", sample_size=10
+    )
+
+    collstats_plan = collstats["namespaces"][0]
+    sample_plan = sample["namespaces"][0]
+    assert collstats_plan["count"] == sample_plan["count"] == 100
+
+    # the documents have the same size: the sample average is exact
+    assert sample_plan["size"] == 100 * doc_size
+    assert abs(collstats_plan["size"] - sample_plan["size"]) <= sample_plan["size"] // 10
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_create_view(t: Testing, phase: Runner.Phase):
     t.source["db_1"]["coll_1"].insert_many([{"i": i} for i in range(-3, 3)])
@@ -209,6 +354,28 @@ def test_drop_capped_collection(t: Testing, phase: Runner.Phase):
     assert "coll_1" not in t.target["db_1"].list_collection_names()
 
 
+def test_pause_on_ddl_drop(t: Testing):
+    ensure_collection(t.source, "db_1", "coll_1")
+
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, {"pause_on_ddl": True}) as r:
+        t.source["db_1"]["coll_1"].insert_one({"i": 1})
+        t.source["db_1"].drop_collection("coll_1")
+        r.wait_for_state(PCSM.State.PAUSED)
+
+        status = t.pcsm.status()
+        assert status["pendingDDL"]["operationType"] == "drop", status
+        assert status["pendingDDL"]["namespace"] == "db_1.coll_1", status
+        # the changes before the drop are applied. the drop is held
+        assert t.target["db_1"]["coll_1"].count_documents({}) == 1
+
+        t.pcsm.approve_ddl()
+        r.wait_for_current_optime()
+
+        assert "pendingDDL" not in t.pcsm.status()
+
+    assert "coll_1" not in t.target["db_1"].list_collection_names()
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_drop_view(t: Testing, phase: Runner.Phase):
     ensure_collection(t.source, "db_1", "coll_1")
@@ -264,6 +431,33 @@ def test_drop_database(t: Testing, phase: Runner.Phase):
         assert t.target["db_1"].list_collection_names() == ["system.views"]
 
 
+@pytest.mark.parametrize("replicate", [True, False])
+def test_drop_database_target_only(t: Testing, replicate: bool):
+    t.source["db_1"]["coll_1"].insert_one({"i": 1})
+    t.target["db_1"]["coll_2"].insert_one({"i": 2})  # not replicated from the source
+
+    options = {"replicate_dropdatabase": replicate}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        t.source.drop_database("db_1")
+        t.source.drop_database("db_1")  # no-op on the missing database
+        r.wait_for_current_optime()
+
+    want = [] if replicate else ["coll_2"]
+    assert t.target["db_1"].list_collection_names() == want
+
+
+def test_drop_database_excluded_kept(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"i": 1})
+    t.target["db_1"]["coll_2"].insert_one({"i": 2})
+
+    options = {"exclude_namespaces": ["db_1.coll_2"]}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        t.source.drop_database("db_1")
+        r.wait_for_current_optime()
+
+    assert t.target["db_1"].list_collection_names() == ["coll_2"]
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_modify_clustered_ttl_ignored(t: Testing, phase: Runner.Phase):
     t.source["db_1"].create_collection(
@@ -662,3 +856,205 @@ def test_clone_with_nan_id_document_multi_id_types(t: Testing):
     sourceDocCount = t.source["db_1"]["coll_1"].count_documents({})
     targetDocCount = t.target["db_1"]["coll_1"].count_documents({})
     assert sourceDocCount == targetDocCount
+
+
+def test_rename_collision_suffix(t: Testing):
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(10))
+    t.source["db_1"]["coll_2"].insert_one({"i": 0})
+    t.target["db_1"]["coll_1"].insert_many({"old": i} for i in range(3))
+    t.target["db_1"]["coll_2"].insert_one({"old": 0})
+
+    options = {"rename_collision_suffix": "__plm_new"}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        t.source["db_1"]["coll_1"].insert_one({"i": 10})
+        t.source["db_1"].drop_collection("coll_2")
+        r.wait_for_current_optime()
+
+        # the existing target collections are untouched until finalization
+        assert t.target["db_1"]["coll_1"].count_documents({"old": {"$exists": True}}) == 3
+        assert t.target["db_1"]["coll_1__plm_new"].count_documents({}) == 11
+        assert t.target["db_1"]["coll_2"].count_documents({}) == 1
+
+    assert "coll_1__plm_new" not in t.target["db_1"].list_collection_names()
+    assert "coll_2" not in t.target["db_1"].list_collection_names()
+    t.compare_all()
+
+
+def test_target_namespace_prefix(t: Testing):
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(10))
+    t.source["db_1"]["coll_1"].create_index({"i": 1})
+    t.source["db_2"]["coll_1"].insert_one({"i": 0})
+
+    options = {"target_namespace_prefix": "test_"}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        t.source["db_1"]["coll_1"].insert_one({"i": 10})
+        t.source["db_2"]["coll_2"].insert_one({"i": 0})
+        t.source["db_1"]["coll_1"].rename("coll_3")
+        r.wait_for_current_optime()
+
+    target_dbs = set(testing.list_databases(t.target))
+    assert target_dbs == {"test_db_1", "test_db_2"}, target_dbs
+    assert set(t.target["test_db_1"].list_collection_names()) == {"coll_3"}
+    assert set(t.target["test_db_2"].list_collection_names()) == {"coll_1", "coll_2"}
+    assert t.target["test_db_1"]["coll_3"].count_documents({}) == 11
+    assert "i_1" in t.target["test_db_1"]["coll_3"].index_information()
+
+
+def test_pause_namespace(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"i": 0})
+    t.source["db_1"]["coll_2"].insert_one({"i": 0})
+
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY) as r:
+        t.pcsm.pause(namespace="db_1.coll_1")
+        assert t.pcsm.status()["pausedNamespaces"] == ["db_1.coll_1"]
+
+        t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(1, 10))
+        t.source["db_1"]["coll_1"].update_one({"i": 0}, {"$set": {"paused": True}})
+        t.source["db_1"]["coll_2"].insert_many({"i": i} for i in range(1, 10))
+
+        # the other namespace proceeds while the paused namespace stops applying
+        for _ in range(60):
+            if t.target["db_1"]["coll_2"].count_documents({}) == 10:
+                break
+            time.sleep(0.5)
+
+        assert t.target["db_1"]["coll_2"].count_documents({}) == 10
+        assert t.target["db_1"]["coll_1"].count_documents({}) == 1
+        assert not t.target["db_1"]["coll_1"].find_one({"paused": True})
+        assert t.pcsm.status()["heldChanges"] == 10
+
+        t.pcsm.resume(namespace="db_1.coll_1")
+        r.wait_for_current_optime()
+
+        status = t.pcsm.status()
+        assert "pausedNamespaces" not in status, status
+        assert "heldChanges" not in status, status
+
+    t.compare_all()
+
+
+def test_finalize_resume_point(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"i": 0})
+
+    r = Runner(t.source, t.pcsm, Runner.Phase.APPLY, {})
+    r.start()
+    t.source["db_1"]["coll_1"].insert_one({"i": 1})
+    r.wait_for_current_optime()
+    r.wait_for_initial_sync()
+
+    res = t.pcsm.finalize()
+    r.wait_for_state(PCSM.State.FINALIZED)
+
+    # the cutover point is the last applied change
+    assert res["resumeToken"]["_data"], res
+    assert res["clusterTime"] == t.pcsm.status()["lastReplicatedOpTime"], res
+
+    t.source["db_1"]["coll_1"].insert_one({"i": 2})
+    with t.source.watch(resume_after=res["resumeToken"]) as stream:
+        for change in stream:
+            if change["ns"] == {"db": "db_1", "coll": "coll_1"}:
+                assert change["fullDocument"]["i"] == 2, change
+                break
+
+
+def test_clone_batch_bytes(t: Testing):
+    # the documents of highly variable sizes, some larger than the batch limit
+    t.source["db_1"]["coll_1"].insert_many(
+        {"i": i, "s": "x" * (2_000_000 if i % 10 == 0 else 100)} for i in range(100)
+    )
+
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, {"clone_batch_bytes": 1_000_000}):
+        pass
+
+    assert t.target["db_1"]["coll_1"].count_documents({}) == 100
+    t.compare_all()
+
+
+def test_copy_marker_field(t: Testing):
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(100))
+
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, {"copy_marker_field": "_copied"}):
+        pass
+
+    # every cloned document is stamped with the same clone marker
+    markers = t.target["db_1"]["coll_1"].distinct("_copied")
+    assert len(markers) == 1, markers
+    assert isinstance(markers[0], bson.Timestamp), markers
+
+    source = list(t.source["db_1"]["coll_1"].find(sort=[("_id", 1)]))
+    target = list(t.target["db_1"]["coll_1"].find({}, {"_copied": 0}, sort=[("_id", 1)]))
+    assert source == target
+
+
+def _create_drifted_collection(t: Testing):
+    t.source["db_1"].create_collection("coll_1", validator={"i": {"$type": "int"}})
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(10))
+
+    # the existing target collection has another validator and a document
+    t.target["db_1"].create_collection("coll_1", validator={"i": {"$type": "string"}})
+    t.target["db_1"]["coll_1"].insert_one({"_id": "existing", "i": "x"})
+
+
+def test_schema_drift_adopt(t: Testing):
+    _create_drifted_collection(t)
+
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, {"on_schema_drift": "adopt"}):
+        pass
+
+    # the target collection is kept with its options and documents
+    options = t.target["db_1"]["coll_1"].options()
+    assert options["validator"] == {"i": {"$type": "string"}}, options
+    assert t.target["db_1"]["coll_1"].count_documents({}) == 11
+    assert t.target["db_1"]["coll_1"].find_one({"_id": "existing"})
+
+
+def test_schema_drift_error(t: Testing):
+    _create_drifted_collection(t)
+
+    with pytest.raises(PCSMServerError, match="db_1.coll_1.*validator"):
+        t.pcsm.start(on_schema_drift="error")
+
+    assert t.target["db_1"]["coll_1"].count_documents({}) == 1
+
+
+def test_schema_drift_collmod(t: Testing):
+    _create_drifted_collection(t)
+
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, {"on_schema_drift": "collmod"}) as r:
+        t.source["db_1"]["coll_1"].insert_one({"i": 10})
+        r.wait_for_current_optime()
+
+    # the target options are modified to the source options
+    options = t.target["db_1"]["coll_1"].options()
+    assert options["validator"] == {"i": {"$type": "int"}}, options
+    assert t.target["db_1"]["coll_1"].count_documents({}) == 12
+
+
+def test_schema_drift_collmod_unreconcilable(t: Testing):
+    t.source["db_1"].create_collection("coll_1", collation={"locale": "fr"})
+    t.source["db_1"]["coll_1"].insert_one({"i": 0})
+    t.target["db_1"].create_collection("coll_1")
+
+    # collMod cannot change the collation
+    with pytest.raises(PCSMServerError, match="db_1.coll_1.*collation"):
+        t.pcsm.start(on_schema_drift="collmod")
+
+
+def test_clone_order_interleave(t: Testing):
+    for i, count in enumerate([1000, 10, 500, 1]):
+        t.source["db_1"][f"coll_{i}"].insert_many({"i": j, "s": "x" * 100} for j in range(count))
+
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, {"clone_order": "interleave"}):
+        pass
+
+    t.compare_all()
+
+
+def test_clone_max_inflight_batches(t: Testing):
+    t.source["db_1"]["coll_1"].insert_many({"i": i, "s": "x" * 1000} for i in range(5000))
+
+    options = {"clone_max_inflight_batches": 1, "clone_batch_bytes": 100_000}
+    with Runner(t.source, t.pcsm, Runner.Phase.CLONE, options):
+        pass
+
+    t.compare_all()
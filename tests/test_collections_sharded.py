@@ -68,3 +68,18 @@ def test_create_collection_with_collation_with_shard_key_index_prefix(
         )
 
     t.compare_all_sharded()
+
+
+def test_disable_balancer_during_clone(t: Testing):
+    t.source["db_1"].create_collection("coll_1")
+    t.source.admin.command("shardCollection", "db_1.coll_1", key={"_id": "hashed"})
+    t.source["db_1"]["coll_1"].insert_many({"i": i} for i in range(1000))
+
+    assert t.target.admin.command("balancerStatus")["mode"] == "full"
+
+    options = {"disable_balancer_during_clone": True}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options):
+        # the balancer is started again after the clone
+        assert t.target.admin.command("balancerStatus")["mode"] == "full"
+
+    t.compare_all_sharded()
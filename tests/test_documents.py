@@ -1,9 +1,11 @@
 # pylint: disable=missing-docstring,redefined-outer-name
+import json
 from datetime import datetime
 
 import pymongo
 import pytest
-from pcsm import Runner
+import testing
+from pcsm import PCSM, Runner
 from testing import Testing
 
 
@@ -513,3 +515,154 @@ def test_id_type(t: Testing, phase: Runner.Phase):
 @pytest.mark.timeout(180)
 def test_compare_all(t: Testing):
     t.compare_all()
+
+
+def test_tail(t: Testing):
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY) as r:
+        res = t.pcsm.tail(namespaces=["db_1.*"], full_document=True)
+        try:
+            t.source["db_2"]["coll_1"].insert_one({"_id": 1})
+            t.source["db_1"]["coll_1"].insert_one({"_id": 2, "a": 2})
+            r.wait_for_current_optime()
+
+            event = json.loads(next(res.iter_lines()))
+        finally:
+            res.close()
+
+    assert event["operationType"] == "insert"
+    assert event["ns"] == {"db": "db_1", "coll": "coll_1"}
+    assert event["event"]["fullDocument"] == {"_id": 2, "a": 2}
+
+
+def test_dead_letter_replay(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"_id": 1, "a": 1})
+
+    options = {"dead_letter_namespace": "pcsm_dlq.events"}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        # the target-only unique index fails the apply of the next insert with a duplicate key
+        t.target["db_1"]["coll_1"].create_index("a", unique=True)
+        t.source["db_1"]["coll_1"].insert_one({"_id": 2, "a": 1})
+        t.source["db_1"]["coll_1"].insert_one({"_id": 3, "a": 3})
+        r.wait_for_current_optime()
+
+        entries = list(t.target["pcsm_dlq"]["events"].find())
+        assert len(entries) == 1, entries
+        assert entries[0]["operationType"] == "insert"
+        assert entries[0]["ns"] == {"db": "db_1", "coll": "coll_1"}
+        assert entries[0]["event"]["fullDocument"] == {"_id": 2, "a": 1}
+        assert t.pcsm.status()["deadLettered"] == 1
+
+        t.target["db_1"]["coll_1"].drop_index("a_1")
+        res = t.pcsm.replay_dead_letter()
+        assert res["replayed"] == 1 and res["failed"] == 0, res
+
+    assert t.target["pcsm_dlq"]["events"].count_documents({}) == 0
+    testing.compare_namespace(t.source, t.target, "db_1", "coll_1")
+
+
+def test_dead_letter_max_doc_retries(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"_id": 1, "a": 1})
+
+    options = {"dead_letter_namespace": "pcsm_dlq.events", "max_doc_retries": 2}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        # the poison insert fails on each attempt with a duplicate key of the target-only index
+        t.target["db_1"]["coll_1"].create_index("a", unique=True)
+        t.source["db_1"]["coll_1"].insert_one({"_id": 2, "a": 1})
+        t.source["db_1"]["coll_1"].insert_one({"_id": 3, "a": 3})
+        r.wait_for_current_optime()
+
+        entries = list(t.target["pcsm_dlq"]["events"].find())
+        assert len(entries) == 1, entries
+        assert entries[0]["event"]["fullDocument"] == {"_id": 2, "a": 1}
+        assert entries[0]["attempts"] == 3
+        assert t.target["db_1"]["coll_1"].find_one({"_id": 3}) == {"_id": 3, "a": 3}
+        assert t.pcsm.status()["state"] == PCSM.State.RUNNING
+
+
+def test_start_from_backup_timestamp(t: Testing):
+    # the target holds the "restored backup" of the source at backup_ts
+    docs = [{"_id": i, "i": i} for i in range(10)]
+    t.source["db_1"]["coll_1"].insert_many(docs)
+    t.target["db_1"]["coll_1"].insert_many(docs)
+    backup_ts = t.source.server_info()["$clusterTime"]["clusterTime"]
+
+    t.source["db_1"]["coll_1"].insert_one({"_id": 10, "i": 10})
+    t.source["db_1"]["coll_1"].update_one({"_id": 1}, {"$set": {"i": -1}})
+    t.source["db_1"]["coll_1"].delete_one({"_id": 2})
+
+    options = {"start_from_backup_timestamp": f"{backup_ts.time}.{backup_ts.inc}"}
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, options) as r:
+        r.start()
+
+        status = t.pcsm.status()
+        assert status["initialSync"]["cloneCompleted"], status
+
+        t.source["db_1"]["coll_1"].insert_one({"_id": 11, "i": 11})
+
+    t.compare_all()
+
+
+def test_update_as_upsert(t: Testing):
+    # the target misses a document of the source (e.g. not cloned because of a race)
+    docs = [{"_id": i, "i": i} for i in range(5)]
+    t.source["db_1"]["coll_1"].insert_many(docs)
+    t.target["db_1"]["coll_1"].insert_many(docs[1:])
+    backup_ts = t.source.server_info()["$clusterTime"]["clusterTime"]
+
+    options = {
+        "start_from_backup_timestamp": f"{backup_ts.time}.{backup_ts.inc}",
+        "update_as_upsert": True,
+    }
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, options) as r:
+        r.start()
+
+        t.source["db_1"]["coll_1"].update_one({"_id": 0}, {"$set": {"i": 100}})
+        t.source["db_1"]["coll_1"].update_one({"_id": 1}, {"$set": {"i": 101}})
+
+    assert t.target["db_1"]["coll_1"].find_one({"_id": 0}) == {"_id": 0, "i": 100}
+    t.compare_all()
+
+
+@pytest.mark.parametrize("clone_snapshot", ["none", "session"])
+def test_update_during_clone(t: Testing, clone_snapshot):
+    coll = t.source["db_1"]["coll_1"]
+    coll.insert_many([{"_id": i, "i": 0, "s": "x" * 1024} for i in range(2000)])
+
+    # the small batches slow down the clone, so the updates are made while it copies the documents
+    options = {
+        "clone_snapshot": clone_snapshot,
+        "clone_batch_bytes": 16 * 1024,
+        "clone_max_inflight_batches": 1,
+    }
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, options) as r:
+        r.start()
+
+        for i in range(1, 4):
+            coll.update_many({}, {"$set": {"i": i}})
+        coll.update_one({"_id": 1999}, {"$set": {"s": "final"}})
+
+        r.wait_for_clone_completed()
+
+    # the replicated final value overwrites the copied intermediate value
+    assert t.target["db_1"]["coll_1"].count_documents({"i": {"$ne": 3}}) == 0
+    assert t.target["db_1"]["coll_1"].find_one({"_id": 1999}) == {"_id": 1999, "i": 3, "s": "final"}
+    t.compare_all()
+
+
+def test_max_total_duration_pause(t: Testing):
+    t.source["db_1"]["coll_1"].insert_many([{"_id": i} for i in range(10)])
+
+    options = {"max_total_duration": "3s", "on_budget_exceeded": "pause"}
+    with Runner(t.source, t.pcsm, Runner.Phase.MANUAL, options) as r:
+        r.start()
+        r.wait_for_clone_completed()
+        r.wait_for_state(PCSM.State.PAUSED)
+
+        status = t.pcsm.status()
+        assert status["budget"]["exceeded"], status
+        assert status["budget"]["action"] == "pause", status
+
+        # the paused replication is resumed without the budget
+        t.source["db_1"]["coll_1"].insert_one({"_id": 10})
+
+    t.compare_all()
@@ -1,5 +1,6 @@
 # pylint: disable=missing-docstring,redefined-outer-name
 import threading
+import time
 from datetime import datetime
 
 import pymongo
@@ -174,6 +175,34 @@ def test_create_ttl(t: Testing, phase: Runner.Phase):
     t.compare_all()
 
 
+@pytest.mark.parametrize("manage_ttl", [True, False])
+def test_replicate_ttl_delete(t: Testing, manage_ttl):
+    index_name = t.source["db_1"]["coll_1"].create_index({"at": 1}, expireAfterSeconds=0)
+
+    t.source.admin.command("setParameter", 1, ttlMonitorSleepSecs=1)
+    try:
+        options = {"manage_ttl_during_replication": manage_ttl}
+        with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+            indexes = t.target["db_1"]["coll_1"].index_information()
+            want = 2**31 - 1 if manage_ttl else 0
+            assert indexes[index_name]["expireAfterSeconds"] == want
+
+            t.source["db_1"]["coll_1"].insert_many({"i": i, "at": datetime(2000, 1, 1)} for i in range(10))
+
+            for _ in range(20):  # the source TTL monitor deletes the expired documents
+                if t.source["db_1"]["coll_1"].count_documents({}) == 0:
+                    break
+                time.sleep(0.5)
+            assert t.source["db_1"]["coll_1"].count_documents({}) == 0
+
+            r.wait_for_current_optime()
+            assert t.target["db_1"]["coll_1"].count_documents({}) == 0
+    finally:
+        t.source.admin.command("setParameter", 1, ttlMonitorSleepSecs=60)
+
+    t.compare_all()
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_drop_cloned(t: Testing, phase: Runner.Phase):
     t.source["db_1"]["coll_1"].create_index([("i", 1)])
@@ -252,6 +281,45 @@ def test_modify_unhide(t: Testing, phase: Runner.Phase):
     t.compare_all()
 
 
+@pytest.mark.parametrize("hidden", [True, False])
+def test_replicate_hidden_toggle(t: Testing, hidden):
+    index_name = t.source["db_1"]["coll_1"].create_index({"i": 1}, hidden=not hidden)
+
+    target = t.target["db_1"]["coll_1"]
+
+    options = {"replicate_hidden_indexes": True}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        assert target.index_information()[index_name].get("hidden", False) != hidden
+
+        t.source["db_1"].command(
+            {"collMod": "coll_1", "index": {"name": index_name, "hidden": hidden}}
+        )
+        r.wait_for_current_optime()
+
+        # the toggle is applied before the finalization
+        assert target.index_information()[index_name].get("hidden", False) == hidden
+
+    t.compare_all()
+
+
+def test_replicate_hidden_missing_index(t: Testing):
+    index_name = t.source["db_1"]["coll_1"].create_index({"i": 1})
+
+    options = {"replicate_hidden_indexes": True}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        t.target["db_1"]["coll_1"].drop_index(index_name)
+
+        t.source["db_1"].command(
+            {"collMod": "coll_1", "index": {"name": index_name, "hidden": True}}
+        )
+        t.source["db_1"]["coll_1"].insert_one({"i": 1})
+        r.wait_for_current_optime()
+
+        assert index_name not in t.target["db_1"]["coll_1"].index_information()
+
+    assert t.target["db_1"]["coll_1"].find_one({"i": 1}, {"_id": 0}) == {"i": 1}
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_modify_ttl(t: Testing, phase: Runner.Phase):
     index_name = t.source["db_1"]["coll_1"].create_index({"i": 1}, expireAfterSeconds=123)
@@ -276,6 +344,23 @@ def test_modify_ttl(t: Testing, phase: Runner.Phase):
     t.compare_all()
 
 
+def test_modify_ttl_replayed(t: Testing):
+    index_name = t.source["db_1"]["coll_1"].create_index({"i": 1}, expireAfterSeconds=123)
+
+    options = {"manage_ttl_during_replication": False}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options) as r:
+        for _ in range(2):  # the same change applied again is a no-op
+            t.source["db_1"].command(
+                {"collMod": "coll_1", "index": {"name": index_name, "expireAfterSeconds": 432}}
+            )
+
+        r.wait_for_current_optime()
+        indexes = t.target["db_1"]["coll_1"].index_information()
+        assert indexes[index_name]["expireAfterSeconds"] == 432
+
+    t.compare_all()
+
+
 @pytest.mark.parametrize("phase", [Runner.Phase.APPLY, Runner.Phase.CLONE])
 def test_modify_unique(t: Testing, phase: Runner.Phase):
     index_name = t.source["db_1"]["coll_1"].create_index({"i": 1})
@@ -555,3 +640,21 @@ def test_pcsm_118_ignore_incomplete_index(t: Testing, index_status: str):
     runner.finalize()
 
     t.compare_all()
+
+
+def test_ddl_audit_create_index(t: Testing):
+    t.source["db_1"]["coll_1"].insert_one({"i": 0})
+
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, {"ddl_audit_collection": "pcsm_audit.ddl"}) as r:
+        t.source["db_1"]["coll_1"].create_index({"i": 1}, name="i_1")
+        r.wait_for_current_optime()
+
+    entries = list(t.target["pcsm_audit"]["ddl"].find({"operationType": "createIndexes"}))
+    assert len(entries) == 1, entries
+
+    entry = entries[0]
+    assert entry["ns"] == {"db": "db_1", "coll": "coll_1"}, entry
+    assert entry["clusterTime"], entry
+    assert entry["appliedAt"], entry
+    assert [i["name"] for i in entry["details"]["operationDescription"]["indexes"]] == ["i_1"], entry
+    assert "i_1" in t.target["db_1"]["coll_1"].index_information()
@@ -1,7 +1,7 @@
 # pylint: disable=missing-docstring,redefined-outer-name
 import pytest
 import testing
-from pcsm import Runner
+from pcsm import PCSM, PCSMServerError, Runner
 from pymongo import MongoClient
 
 
@@ -120,3 +120,65 @@ def test_create_collection(t: testing.Testing, phase: Runner.Phase):
 
     assert expected == set(testing.list_all_namespaces(t.target))
     check_if_target_is_subset(t.source, t.target)
+
+
+def test_unmatched_include_lenient(t: testing.Testing):
+    t.source["db_1"]["coll_1"].insert_one({})
+
+    include_ns = ["db_1.coll_1", "db_1.colll_1"]
+    runner = Runner(t.source, t.pcsm, Runner.Phase.MANUAL, {"include_namespaces": include_ns})
+    runner.finalize(fast=True)
+
+    res = t.pcsm.start(include_namespaces=include_ns)
+    assert res["warnings"] == ['include pattern "db_1.colll_1" matches no source namespace']
+
+    runner.finalize()
+
+    assert set(testing.list_all_namespaces(t.target)) == {"db_1.coll_1"}
+
+
+def test_unmatched_include_strict(t: testing.Testing):
+    t.source["db_1"]["coll_1"].insert_one({})
+
+    runner = Runner(t.source, t.pcsm, Runner.Phase.MANUAL, {})
+    runner.finalize(fast=True)
+    state = t.pcsm.status()["state"]
+
+    with pytest.raises(PCSMServerError, match="db_2"):
+        t.pcsm.start(include_namespaces=["db_1.coll_1", "db_2.*"], strict_namespaces=True)
+
+    # not started
+    assert t.pcsm.status()["state"] == state
+    assert state != PCSM.State.RUNNING
+
+    res = t.pcsm.start(include_namespaces=["db_1.coll_1", "db_1.*"], strict_namespaces=True)
+    assert "warnings" not in res
+
+    runner.finalize()
+
+    assert set(testing.list_all_namespaces(t.target)) == {"db_1.coll_1"}
+
+
+@pytest.mark.parametrize("option", ["replicate_include", "replicate_exclude"])
+def test_replicate_narrower_than_clone(t: testing.Testing, option):
+    for coll in ("hot", "cold"):
+        t.source["db_1"][coll].insert_one({"_id": 1, "v": 1})
+
+    patterns = ["db_1.hot"] if option == "replicate_include" else ["db_1.cold"]
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, {option: patterns}):
+        for coll in ("hot", "cold"):
+            t.source["db_1"][coll].update_one({"_id": 1}, {"$set": {"v": 2}})
+            t.source["db_1"][coll].insert_one({"_id": 2, "v": 2})
+
+    # both collections are cloned, the changes of the hot one only are replicated
+    assert set(testing.list_all_namespaces(t.target)) == {"db_1.hot", "db_1.cold"}
+    testing.compare_namespace(t.source, t.target, "db_1", "hot")
+    assert list(t.target["db_1"]["cold"].find()) == [{"_id": 1, "v": 1}]
+
+
+def test_replicate_invalid_pattern(t: testing.Testing):
+    runner = Runner(t.source, t.pcsm, Runner.Phase.MANUAL, {})
+    runner.finalize(fast=True)
+
+    with pytest.raises(PCSMServerError, match="replicate namespaces"):
+        t.pcsm.start(replicate_include=["db_1"])
@@ -24,6 +24,29 @@ def test_simple(t: Testing):
     t.compare_all()
 
 
+def test_preserve_order_within_transaction(t: Testing):
+    t.source["db_1"].create_collection("coll_1")
+    t.source["db_2"].create_collection("coll_2")
+
+    options = {"preserve_order_within_transaction": True}
+    with Runner(t.source, t.pcsm, Runner.Phase.APPLY, options):
+        # the transaction has more operations than a bulk write and depends on their order
+        with t.source.start_session() as sess:
+            sess.start_transaction()
+            for i in range(1500):
+                t.source["db_1"]["coll_1"].insert_one({"_id": i, "n": 0}, session=sess)
+                t.source["db_1"]["coll_1"].update_one({"_id": i}, {"$inc": {"n": 1}}, session=sess)
+                t.source["db_2"]["coll_2"].insert_one({"_id": i}, session=sess)
+            t.source["db_1"]["coll_1"].delete_one({"_id": 0}, session=sess)
+            sess.commit_transaction()
+
+        t.source["db_1"]["coll_1"].update_many({}, {"$inc": {"n": 1}})
+
+    assert t.source["db_1"]["coll_1"].count_documents({"n": 2}) == 1499
+
+    t.compare_all()
+
+
 def test_simple_aborted(t: Testing):
     t.source["db_1"].create_collection("coll_1")
     t.source["db_2"].create_collection("coll_2")
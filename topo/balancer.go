@@ -0,0 +1,39 @@
+package topo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// BalancerEnabled reports whether the balancer of the sharded cluster is enabled.
+// The client must be connected to mongos.
+func BalancerEnabled(ctx context.Context, m *mongo.Client) (bool, error) {
+	var res struct {
+		Mode string `bson:"mode"`
+	}
+
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"balancerStatus", 1}}).Decode(&res)
+	if err != nil {
+		return false, errors.Wrap(err, "balancerStatus")
+	}
+
+	return res.Mode != "off", nil
+}
+
+// StopBalancer disables the balancer and waits for the in-progress balancing round to complete.
+func StopBalancer(ctx context.Context, m *mongo.Client) error {
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"balancerStop", 1}}).Err()
+
+	return errors.Wrap(err, "balancerStop")
+}
+
+// StartBalancer enables the balancer.
+func StartBalancer(ctx context.Context, m *mongo.Client) error {
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"balancerStart", 1}}).Err()
+
+	return errors.Wrap(err, "balancerStart")
+}
@@ -0,0 +1,51 @@
+package topo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// GetClusterParameter returns the value of the cluster parameter (MongoDB 6.0+)
+// without the _id and clusterParameterTime fields.
+func GetClusterParameter(ctx context.Context, m *mongo.Client, name string) (bson.D, error) {
+	var res struct {
+		ClusterParameters []bson.D `bson:"clusterParameters"`
+	}
+
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"getClusterParameter", name}}).Decode(&res)
+	if err != nil {
+		return nil, errors.Wrap(err, "getClusterParameter")
+	}
+
+	if len(res.ClusterParameters) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return clusterParameterValue(res.ClusterParameters[0]), nil
+}
+
+// SetClusterParameter sets the value of the cluster parameter (MongoDB 6.0+).
+func SetClusterParameter(ctx context.Context, m *mongo.Client, name string, value bson.D) error {
+	err := m.Database("admin").RunCommand(ctx,
+		bson.D{{"setClusterParameter", bson.D{{name, value}}}}).Err()
+
+	return errors.Wrap(err, "setClusterParameter")
+}
+
+// clusterParameterValue returns the fields of the getClusterParameter document
+// that can be passed to setClusterParameter.
+func clusterParameterValue(doc bson.D) bson.D {
+	value := make(bson.D, 0, len(doc))
+
+	for _, e := range doc {
+		if e.Key != "_id" && e.Key != "clusterParameterTime" {
+			value = append(value, e)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,23 @@
+package topo //nolint:testpackage
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestClusterParameterValue(t *testing.T) {
+	t.Parallel()
+
+	doc := bson.D{
+		{"_id", "defaultMaxTimeMS"},
+		{"clusterParameterTime", bson.Timestamp{T: 1700000000, I: 1}},
+		{"readOperations", int64(5000)},
+	}
+
+	got := clusterParameterValue(doc)
+	if want := (bson.D{{"readOperations", int64(5000)}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
@@ -21,6 +21,8 @@ import (
 
 type ConnectOptions struct {
 	Compressors []string
+	// Encryption enables the automatic client-side field level encryption.
+	Encryption *EncryptionOptions
 }
 
 // Connect establishes a connection to a MongoDB instance using the provided URI.
@@ -64,6 +66,10 @@ func ConnectWithOptions(
 		opts.SetCompressors(connOpts.Compressors)
 	}
 
+	if connOpts != nil && connOpts.Encryption != nil {
+		opts.SetAutoEncryptionOptions(connOpts.Encryption.autoEncryptionOptions())
+	}
+
 	if config.MongoLogEnabled {
 		opts = opts.SetLoggerOptions(options.Logger().
 			SetSink(log.MongoLogger(ctx)).
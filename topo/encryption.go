@@ -0,0 +1,155 @@
+package topo
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+)
+
+// EncryptionOptions configures the client-side field level encryption (CSFLE)
+// of a MongoDB client.
+//
+// NOTE: Automatic encryption requires the binary to be built with the `cse` build tag
+// and libmongocrypt installed. The mongocryptd process or the crypt_shared library
+// must be available at runtime.
+type EncryptionOptions struct {
+	// KeyVaultNamespace is the namespace of the key vault collection (e.g. "encryption.__keyVault").
+	KeyVaultNamespace string
+	// KMSProviders is the configuration of the KMS providers (e.g. {"local": {"key": "<base64>"}}).
+	KMSProviders map[string]map[string]any
+	// SchemaMap maps a namespace to its JSON schema with the encrypt specifications.
+	SchemaMap map[string]bson.Raw
+}
+
+// ParseEncryptionOptions builds [EncryptionOptions] from the JSON schema map, the key vault
+// namespace, and the JSON KMS providers configuration. The schema and the KMS providers can be
+// either inline JSON documents or paths to JSON files.
+func ParseEncryptionOptions(schema, keyVaultNS, kmsProviders string) (*EncryptionOptions, error) {
+	db, coll, _ := strings.Cut(keyVaultNS, ".")
+	if db == "" || coll == "" {
+		return nil, errors.Errorf("invalid key vault namespace %q", keyVaultNS)
+	}
+
+	schemaData, err := readInlineOrFile(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "schema map")
+	}
+
+	var schemaMap map[string]bson.Raw
+
+	err = bson.UnmarshalExtJSON(schemaData, false, &schemaMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse schema map")
+	}
+
+	if len(schemaMap) == 0 {
+		return nil, errors.New("empty schema map")
+	}
+
+	for ns := range schemaMap {
+		db, coll, _ := strings.Cut(ns, ".")
+		if db == "" || coll == "" {
+			return nil, errors.Errorf("schema map: invalid namespace %q", ns)
+		}
+	}
+
+	kmsData, err := readInlineOrFile(kmsProviders)
+	if err != nil {
+		return nil, errors.Wrap(err, "kms providers")
+	}
+
+	var kms map[string]map[string]any
+
+	err = json.Unmarshal(kmsData, &kms)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse kms providers")
+	}
+
+	if len(kms) == 0 {
+		return nil, errors.New("empty kms providers")
+	}
+
+	opts := &EncryptionOptions{
+		KeyVaultNamespace: keyVaultNS,
+		KMSProviders:      kms,
+		SchemaMap:         schemaMap,
+	}
+
+	return opts, nil
+}
+
+// EncryptedFields returns the sorted list of field paths designated for encryption
+// by the schema of the namespace.
+func (o *EncryptionOptions) EncryptedFields(ns string) []string {
+	schema, ok := o.SchemaMap[ns]
+	if !ok {
+		return nil
+	}
+
+	var fields []string
+
+	collectEncryptedFields(schema, "", &fields)
+	slices.Sort(fields)
+
+	return fields
+}
+
+func collectEncryptedFields(schema bson.Raw, prefix string, fields *[]string) {
+	props, ok := schema.Lookup("properties").DocumentOK()
+	if !ok {
+		return
+	}
+
+	elems, _ := props.Elements()
+	for _, elem := range elems {
+		prop, ok := elem.Value().DocumentOK()
+		if !ok {
+			continue
+		}
+
+		path := prefix + elem.Key()
+		if _, ok := prop.Lookup("encrypt").DocumentOK(); ok {
+			*fields = append(*fields, path)
+
+			continue
+		}
+
+		collectEncryptedFields(prop, path+".", fields)
+	}
+}
+
+func (o *EncryptionOptions) autoEncryptionOptions() *options.AutoEncryptionOptions {
+	schemaMap := make(map[string]any, len(o.SchemaMap))
+	for ns, schema := range o.SchemaMap {
+		schemaMap[ns] = schema
+	}
+
+	return options.AutoEncryption().
+		SetKeyVaultNamespace(o.KeyVaultNamespace).
+		SetKmsProviders(o.KMSProviders).
+		SetSchemaMap(schemaMap)
+}
+
+func readInlineOrFile(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty value")
+	}
+
+	if strings.HasPrefix(s, "{") {
+		return []byte(s), nil
+	}
+
+	data, err := os.ReadFile(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,102 @@
+//go:build cse
+
+package topo //nolint:testpackage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TestAutoEncryption_Write writes through the target client with the automatic encryption
+// and verifies that the designated field is stored encrypted. It requires the binary built
+// with the `cse` tag, libmongocrypt, and mongocryptd or the crypt_shared library.
+func TestAutoEncryption_Write(t *testing.T) {
+	t.Parallel()
+
+	uri := os.Getenv("PCSM_TARGET_URI")
+	if uri == "" {
+		t.Skip("PCSM_TARGET_URI is empty")
+	}
+
+	const (
+		db         = "pcsm_test_cse"
+		coll       = "users"
+		keyVaultNS = db + ".__keyVault"
+	)
+
+	ctx := t.Context()
+
+	plain, err := Connect(ctx, uri)
+	require.NoError(t, err)
+
+	defer plain.Disconnect(context.Background()) //nolint:errcheck
+
+	require.NoError(t, plain.Database(db).Drop(ctx))
+
+	defer plain.Database(db).Drop(context.Background()) //nolint:errcheck
+
+	localKey := make([]byte, 96)
+	_, err = rand.Read(localKey)
+	require.NoError(t, err)
+
+	kmsProviders := fmt.Sprintf(`{"local": {"key": %q}}`, base64.StdEncoding.EncodeToString(localKey))
+
+	ce, err := mongo.NewClientEncryption(plain, options.ClientEncryption().
+		SetKeyVaultNamespace(keyVaultNS).
+		SetKmsProviders(map[string]map[string]any{"local": {"key": localKey}}))
+	require.NoError(t, err)
+
+	defer ce.Close(context.Background()) //nolint:errcheck
+
+	keyID, err := ce.CreateDataKey(ctx, "local")
+	require.NoError(t, err)
+
+	schema := fmt.Sprintf(`{%q: {
+		"bsonType": "object",
+		"encryptMetadata": {"keyId": [{"$binary": {"base64": %q, "subType": "04"}}]},
+		"properties": {
+			"ssn": {
+				"encrypt": {
+					"bsonType": "string",
+					"algorithm": "AEAD_AES_256_CBC_HMAC_SHA_512-Deterministic"
+				}
+			}
+		}
+	}}`, db+"."+coll, base64.StdEncoding.EncodeToString(keyID.Data))
+
+	encOpts, err := ParseEncryptionOptions(schema, keyVaultNS, kmsProviders)
+	require.NoError(t, err)
+
+	encrypted, err := ConnectWithOptions(ctx, uri, &ConnectOptions{Encryption: encOpts})
+	require.NoError(t, err)
+
+	defer encrypted.Disconnect(context.Background()) //nolint:errcheck
+
+	// the replication applies the changes with the collection-level bulk write
+	_, err = encrypted.Database(db).Collection(coll).BulkWrite(ctx, []mongo.WriteModel{
+		mongo.NewInsertOneModel().SetDocument(bson.D{{"_id", 1}, {"name", "a"}, {"ssn", "123-45-6789"}}),
+	})
+	require.NoError(t, err)
+
+	raw, err := plain.Database(db).Collection(coll).FindOne(ctx, bson.D{{"_id", 1}}).Raw()
+	require.NoError(t, err)
+
+	subtype, _, ok := raw.Lookup("ssn").BinaryOK()
+	require.True(t, ok, "ssn is stored unencrypted: %v", raw.Lookup("ssn"))
+	assert.Equal(t, byte(6), subtype) // encrypted value
+	assert.Equal(t, "a", raw.Lookup("name").StringValue())
+
+	raw, err = encrypted.Database(db).Collection(coll).FindOne(ctx, bson.D{{"_id", 1}}).Raw()
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", raw.Lookup("ssn").StringValue())
+}
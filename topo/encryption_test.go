@@ -0,0 +1,75 @@
+package topo //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemaMap = `{
+	"db1.users": {
+		"bsonType": "object",
+		"properties": {
+			"name": {"bsonType": "string"},
+			"ssn": {
+				"encrypt": {
+					"bsonType": "string",
+					"algorithm": "AEAD_AES_256_CBC_HMAC_SHA_512-Deterministic"
+				}
+			},
+			"contact": {
+				"bsonType": "object",
+				"properties": {
+					"email": {"bsonType": "string"},
+					"phone": {
+						"encrypt": {
+							"bsonType": "string",
+							"algorithm": "AEAD_AES_256_CBC_HMAC_SHA_512-Random"
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+const testKMSProviders = `{"local": {"key": "c2VjcmV0"}}`
+
+func TestParseEncryptionOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, err := ParseEncryptionOptions(testSchemaMap, "encryption.__keyVault", testKMSProviders)
+	require.NoError(t, err)
+
+	assert.Equal(t, "encryption.__keyVault", opts.KeyVaultNamespace)
+	assert.Contains(t, opts.KMSProviders, "local")
+	assert.Equal(t, []string{"contact.phone", "ssn"}, opts.EncryptedFields("db1.users"))
+	assert.Empty(t, opts.EncryptedFields("db1.orders"))
+}
+
+func TestParseEncryptionOptions_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		schema     string
+		keyVaultNS string
+		kms        string
+	}{
+		{"invalid key vault namespace", testSchemaMap, "keyVault", testKMSProviders},
+		{"invalid schema namespace", `{"users": {}}`, "encryption.__keyVault", testKMSProviders},
+		{"empty schema map", `{}`, "encryption.__keyVault", testKMSProviders},
+		{"empty kms providers", testSchemaMap, "encryption.__keyVault", `{}`},
+		{"missing schema file", "/nonexistent/schema.json", "encryption.__keyVault", testKMSProviders},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseEncryptionOptions(tt.schema, tt.keyVaultNS, tt.kms)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -2,9 +2,14 @@ package topo
 
 import (
 	"context"
+	"io"
 	"strings"
+	"syscall"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
 
 	"github.com/percona/percona-clustersync-mongodb/errors"
 )
@@ -19,6 +24,33 @@ func IsIndexOptionsConflict(err error) bool {
 	return isMongoCommandError(err, "IndexOptionsConflict")
 }
 
+// IsKeyTooLong checks if an error is caused by an index key longer than the index key limit
+// of the server (MongoDB 4.0 and earlier).
+func IsKeyTooLong(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Name == "KeyTooLong" || cmdErr.Code == 17280 //nolint:mnd
+	}
+
+	return false
+}
+
+// IsInvalidStorageEngineOptions checks if an error is caused by the storage engine options
+// of a collection or index that the server does not support (e.g. an unknown storage engine
+// or an invalid WiredTiger configString).
+func IsInvalidStorageEngineOptions(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || (cmdErr.Name != "InvalidOptions" && cmdErr.Name != "BadValue") {
+		return false
+	}
+
+	msg := strings.ToLower(cmdErr.Message)
+
+	return strings.Contains(msg, "storage engine") ||
+		strings.Contains(msg, "storageengine") ||
+		strings.Contains(msg, "configstring")
+}
+
 func IsNamespaceNotFound(err error) bool {
 	return isMongoCommandError(err, "NamespaceNotFound")
 }
@@ -56,6 +88,114 @@ func IsCappedPositionLost(err error) bool {
 	return isMongoCommandError(err, "CappedPositionLost")
 }
 
+// authenticationFailedCode is the code of the AuthenticationFailed server error.
+const authenticationFailedCode = 18
+
+// IsAuthenticationFailed checks if the error is caused by the credentials rejected by the server
+// (e.g. a rotated password). The credentials are checked on each new connection, so the error
+// may occur at any time after the connect.
+func IsAuthenticationFailed(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == authenticationFailedCode || cmdErr.Name == "AuthenticationFailed"
+	}
+
+	// the error of a connection handshake is not converted to a command error
+	var drvErr driver.Error
+	if errors.As(err, &drvErr) {
+		return drvErr.Code == authenticationFailedCode || drvErr.Name == "AuthenticationFailed"
+	}
+
+	return false
+}
+
+// IsConnectionExhausted checks if the error is caused by the server rejecting new connections
+// at its connection limit (maxIncomingConnections), or by a client connection pool with no
+// connection available. The server closes a rejected connection during the handshake.
+func IsConnectionExhausted(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var wqErr topology.WaitQueueTimeoutError
+	if errors.As(err, &wqErr) {
+		return !errors.Is(wqErr.Wrapped, context.Canceled)
+	}
+
+	var connErr topology.ConnectionError
+	if errors.As(err, &connErr) && strings.Contains(connErr.Error(), "connection handshake") &&
+		(errors.Is(connErr.Wrapped, io.EOF) || errors.Is(connErr.Wrapped, syscall.ECONNRESET)) {
+		return true
+	}
+
+	return strings.Contains(err.Error(), "too many open connections")
+}
+
+// IsCursorTimeout checks if the error is caused by the server killing a cursor
+// (e.g. the idle cursor timeout or maxTimeMS).
+func IsCursorTimeout(err error) bool {
+	return isMongoCommandError(err, "CursorNotFound") ||
+		isMongoCommandError(err, "CursorKilled") ||
+		isMongoCommandError(err, "MaxTimeMSExpired")
+}
+
+// IsWriteError checks if the error is caused by a document-level write error
+// (e.g. a duplicate key or a document validation failure) rather than a server or network issue.
+func IsWriteError(err error) bool {
+	if IsTransient(err) {
+		return false
+	}
+
+	var bwEx mongo.BulkWriteException
+	if errors.As(err, &bwEx) {
+		return len(bwEx.WriteErrors) != 0 && bwEx.WriteConcernError == nil
+	}
+
+	var cbwEx mongo.ClientBulkWriteException
+	if errors.As(err, &cbwEx) {
+		return len(cbwEx.WriteErrors) != 0 && len(cbwEx.WriteConcernErrors) == 0
+	}
+
+	var cbwExPtr *mongo.ClientBulkWriteException
+	if errors.As(err, &cbwExPtr) && cbwExPtr != nil {
+		return len(cbwExPtr.WriteErrors) != 0 && len(cbwExPtr.WriteConcernErrors) == 0
+	}
+
+	var wEx mongo.WriteException
+	if errors.As(err, &wEx) {
+		return len(wEx.WriteErrors) != 0 && wEx.WriteConcernError == nil
+	}
+
+	return false
+}
+
+// UniqueIndexViolations returns the _id values of the conflicting documents reported by a failed
+// conversion of an index to unique (CannotConvertIndexToUnique). Each group contains the documents
+// with the same index key. It returns false for other errors.
+func UniqueIndexViolations(err error) ([][]any, bool) {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Name != "CannotConvertIndexToUnique" {
+		return nil, false
+	}
+
+	var res struct {
+		Violations []struct {
+			IDs []any `bson:"ids"`
+		} `bson:"violations"`
+	}
+
+	if len(cmdErr.Raw) != 0 {
+		_ = bson.Unmarshal(cmdErr.Raw, &res)
+	}
+
+	groups := make([][]any, len(res.Violations))
+	for i, v := range res.Violations {
+		groups[i] = v.IDs
+	}
+
+	return groups, true
+}
+
 // isMongoCommandError checks if an error is a MongoDB error with the specified name.
 func isMongoCommandError(err error, name string) bool {
 	var cmdErr mongo.CommandError
@@ -66,44 +206,102 @@ func isMongoCommandError(err error, name string) bool {
 	return false
 }
 
+// transientErrorCodes are the codes of the errors that are resolved by a retry. The replica set
+// state change codes are returned while the target elects a new primary: the driver selects
+// the new primary for the next attempt.
+var transientErrorCodes = map[int]struct{}{ //nolint:gochecknoglobals
+	11602: {}, // InterruptedDueToReplStateChange
+	91:    {}, // ShutdownInProgress
+	189:   {}, // PrimarySteppedDown
+	10107: {}, // NotWritablePrimary
+	13435: {}, // NotPrimaryNoSecondaryOk
+}
+
+func isTransientCode(code int) bool {
+	_, ok := transientErrorCodes[code]
+
+	return ok
+}
+
 // IsTransient checks if the error is a transient error that can be retried.
-// It checks for specific MongoDB error codes that indicate transient issues.
+// It checks for specific MongoDB error codes that indicate transient issues,
+// including the write and write concern errors of the bulk writes.
 func IsTransient(err error) bool {
-	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
-		return true
+	// the rejected credentials are not resolved by a retry
+	if IsAuthenticationFailed(err) {
+		return false
 	}
 
-	le, ok := err.(mongo.LabeledError) //nolint:errorlint
-	if ok && le.HasErrorLabel("RetryableWriteError") {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
 		return true
 	}
 
-	transientErrorCodes := map[int]struct{}{
-		11602: {}, // InterruptedDueToReplStateChange
-		91:    {}, // ShutdownInProgress
-		189:   {}, // PrimarySteppedDown
-		10107: {}, // NotWritablePrimary
-		13435: {}, // NotPrimaryNoSecondaryOk
+	var le mongo.LabeledError
+	if errors.As(err, &le) && le.HasErrorLabel("RetryableWriteError") {
+		return true
 	}
 
 	var wEx mongo.WriteException
 	if errors.As(err, &wEx) {
 		for _, we := range wEx.WriteErrors {
-			if _, ok := transientErrorCodes[we.Code]; ok {
+			if isTransientCode(we.Code) {
 				return true
 			}
 		}
 
-		if wEx.WriteConcernError != nil {
-			if _, ok := transientErrorCodes[wEx.WriteConcernError.Code]; ok {
+		if wEx.WriteConcernError != nil && isTransientCode(wEx.WriteConcernError.Code) {
+			return true
+		}
+	}
+
+	var bwEx mongo.BulkWriteException
+	if errors.As(err, &bwEx) {
+		for _, we := range bwEx.WriteErrors {
+			if isTransientCode(we.Code) {
 				return true
 			}
 		}
+
+		if bwEx.WriteConcernError != nil && isTransientCode(bwEx.WriteConcernError.Code) {
+			return true
+		}
+	}
+
+	var cbwEx *mongo.ClientBulkWriteException
+	if !errors.As(err, &cbwEx) {
+		var cbwExVal mongo.ClientBulkWriteException
+		if errors.As(err, &cbwExVal) {
+			cbwEx = &cbwExVal
+		}
+	}
+
+	if cbwEx != nil && isTransientClientBulkWriteException(cbwEx) {
+		return true
 	}
 
 	var cmdErr mongo.CommandError
 	if errors.As(err, &cmdErr) {
-		if _, ok := transientErrorCodes[int(cmdErr.Code)]; ok {
+		if isTransientCode(int(cmdErr.Code)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTransientClientBulkWriteException(ex *mongo.ClientBulkWriteException) bool {
+	if ex.WriteError != nil && isTransientCode(ex.WriteError.Code) {
+		return true
+	}
+
+	for _, wce := range ex.WriteConcernErrors {
+		if isTransientCode(wce.Code) {
+			return true
+		}
+	}
+
+	for _, we := range ex.WriteErrors {
+		if isTransientCode(we.Code) {
 			return true
 		}
 	}
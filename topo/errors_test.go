@@ -0,0 +1,344 @@
+package topo //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
+)
+
+func TestIsWriteError(t *testing.T) {
+	t.Parallel()
+
+	duplicateKey := mongo.WriteError{Code: 11000, Message: "E11000 duplicate key error"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "bulk write error",
+			err: mongo.BulkWriteException{
+				WriteErrors: []mongo.BulkWriteError{{WriteError: duplicateKey}},
+			},
+			want: true,
+		},
+		{
+			name: "bulk write concern error",
+			err: mongo.BulkWriteException{
+				WriteErrors:       []mongo.BulkWriteError{{WriteError: duplicateKey}},
+				WriteConcernError: &mongo.WriteConcernError{Code: 64},
+			},
+			want: false,
+		},
+		{
+			name: "client bulk write error",
+			err: &mongo.ClientBulkWriteException{
+				WriteErrors: map[int]mongo.WriteError{0: duplicateKey},
+			},
+			want: true,
+		},
+		{
+			name: "write error",
+			err:  mongo.WriteException{WriteErrors: []mongo.WriteError{duplicateKey}},
+			want: true,
+		},
+		{
+			name: "transient write error",
+			err: mongo.WriteException{
+				WriteErrors: []mongo.WriteError{{Code: 91}}, // ShutdownInProgress
+			},
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"), //nolint:err113
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsWriteError(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueIndexViolations(t *testing.T) {
+	t.Parallel()
+
+	raw, err := bson.Marshal(bson.D{
+		{"ok", 0},
+		{"errmsg", "Cannot convert the index to unique. Please resolve conflicting documents"},
+		{"code", 359},
+		{"codeName", "CannotConvertIndexToUnique"},
+		{"violations", bson.A{
+			bson.D{{"ids", bson.A{int32(1), int32(2)}}},
+			bson.D{{"ids", bson.A{"a", "b", "c"}}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmdErr := mongo.CommandError{Code: 359, Name: "CannotConvertIndexToUnique", Raw: raw}
+
+	got, ok := UniqueIndexViolations(fmt.Errorf("convert to unique: %w", cmdErr))
+	if !ok {
+		t.Fatal("got = false, want true")
+	}
+
+	want := [][]any{{int32(1), int32(2)}, {"a", "b", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	_, ok = UniqueIndexViolations(mongo.CommandError{Name: "IndexNotFound"})
+	if ok {
+		t.Error("IndexNotFound: got = true, want false")
+	}
+}
+
+func TestIsKeyTooLong(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "code name",
+			err:  mongo.CommandError{Code: 17280, Name: "KeyTooLong"},
+			want: true,
+		},
+		{
+			name: "code without name",
+			err:  fmt.Errorf("create index: %w", mongo.CommandError{Code: 17280}),
+			want: true,
+		},
+		{
+			name: "other error",
+			err:  mongo.CommandError{Code: 85, Name: "IndexOptionsConflict"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsKeyTooLong(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAuthenticationFailed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "command error",
+			err:  mongo.CommandError{Code: 18, Name: "AuthenticationFailed"},
+			want: true,
+		},
+		{
+			name: "connection handshake error",
+			err: fmt.Errorf("connection() error occurred during connection handshake: %w",
+				driver.Error{Code: 18, Name: "AuthenticationFailed", Labels: []string{"NetworkError"}}),
+			want: true,
+		},
+		{
+			name: "other error",
+			err:  mongo.CommandError{Code: 13, Name: "Unauthorized"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsAuthenticationFailed(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+
+			if tt.want && IsTransient(tt.err) {
+				t.Errorf("got transient: %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestIsConnectionExhausted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "pool wait queue timeout",
+			err:  fmt.Errorf("bulk write: %w", topology.WaitQueueTimeoutError{Wrapped: context.DeadlineExceeded}),
+			want: true,
+		},
+		{
+			name: "canceled pool wait",
+			err:  topology.WaitQueueTimeoutError{Wrapped: context.Canceled},
+			want: false,
+		},
+		{
+			name: "server connection limit",
+			err:  errors.New("connection refused because too many open connections: 819"),
+			want: true,
+		},
+		{
+			name: "closed connection",
+			err:  topology.ConnectionError{ConnectionID: "1", Wrapped: io.EOF},
+			want: false,
+		},
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsConnectionExhausted(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsInvalidStorageEngineOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "unknown storage engine",
+			err:  mongo.CommandError{Code: 72, Name: "InvalidOptions", Message: "unknown storage engine: inMemory"},
+			want: true,
+		},
+		{
+			name: "invalid configString",
+			err: fmt.Errorf("create collection: %w", mongo.CommandError{
+				Code: 2, Name: "BadValue", Message: "Invalid option in configString: unknown configuration key",
+			}),
+			want: true,
+		},
+		{
+			name: "other invalid option",
+			err:  mongo.CommandError{Code: 72, Name: "InvalidOptions", Message: "invalid option: max"},
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  mongo.CommandError{Code: 48, Name: "NamespaceExists", Message: "storage engine"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsInvalidStorageEngineOptions(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	codes := map[string]int{
+		"PrimarySteppedDown":              189,
+		"NotWritablePrimary":              10107,
+		"InterruptedDueToReplStateChange": 11602,
+	}
+
+	for name, code := range codes {
+		tests := map[string]error{
+			"command error": mongo.CommandError{Code: int32(code), Name: name}, //nolint:gosec
+			"write error": mongo.WriteException{
+				WriteErrors: []mongo.WriteError{{Code: code, Message: name}},
+			},
+			"write concern error": mongo.WriteException{
+				WriteConcernError: &mongo.WriteConcernError{Code: code, Name: name},
+			},
+			"bulk write error": mongo.BulkWriteException{
+				WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: code, Message: name}}},
+			},
+			"client bulk write top level error": fmt.Errorf("bulk write: %w", &mongo.ClientBulkWriteException{
+				WriteError: &mongo.WriteError{Code: code, Message: name},
+			}),
+			"client bulk write concern error": fmt.Errorf("bulk write: %w", mongo.ClientBulkWriteException{
+				WriteConcernErrors: []mongo.WriteConcernError{{Code: code, Name: name}},
+			}),
+			"client bulk write error": &mongo.ClientBulkWriteException{
+				WriteErrors: map[int]mongo.WriteError{0: {Code: code, Message: name}},
+			},
+		}
+
+		for kind, err := range tests {
+			t.Run(name+"/"+kind, func(t *testing.T) {
+				t.Parallel()
+
+				if !IsTransient(err) {
+					t.Errorf("got not transient: %v", err)
+				}
+
+				if IsWriteError(err) {
+					t.Errorf("got write error: %v", err)
+				}
+			})
+		}
+	}
+
+	nonTransient := map[string]error{
+		"duplicate key": &mongo.ClientBulkWriteException{
+			WriteErrors: map[int]mongo.WriteError{0: {Code: 11000, Message: "duplicate key"}},
+		},
+		"command error": mongo.CommandError{Code: 26, Name: "NamespaceNotFound"},
+		"other error":   errors.New("other"), //nolint:err113
+	}
+
+	for name, err := range nonTransient {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if IsTransient(err) {
+				t.Errorf("got transient: %v", err)
+			}
+		})
+	}
+}
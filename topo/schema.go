@@ -262,3 +262,98 @@ func GetCollectionShardingInfo(
 
 	return info, nil
 }
+
+// IndexBuildProgress is the progress of an in-progress index build reported by $currentOp.
+type IndexBuildProgress struct {
+	// Namespace is the namespace of the collection.
+	Namespace string
+	// Index is the name of the index.
+	Index string
+	// Msg is the build phase message (e.g. "Index Build: scanning collection").
+	Msg string
+	// Done is the number of processed items of the current phase.
+	Done int64
+	// Total is the total number of items of the current phase. Zero if not reported.
+	Total int64
+}
+
+// Percent returns the progress of the current phase in percents. Zero if not reported.
+func (p IndexBuildProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+
+	return float64(p.Done) / float64(p.Total) * 100 //nolint:mnd
+}
+
+// ListIndexBuildProgress returns the progress of all in-progress index builds.
+func ListIndexBuildProgress(ctx context.Context, m *mongo.Client) ([]IndexBuildProgress, error) {
+	opts := options.Database().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Local())
+	cur, err := m.Database("admin", opts).Aggregate(ctx, mongo.Pipeline{
+		{{"$currentOp", bson.D{{"allUsers", true}}}},
+		{{"$match", bson.D{
+			{"op", "command"},
+			{"command.createIndexes", bson.D{{"$exists", true}}},
+		}}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "$currentOp")
+	}
+
+	var ops []bson.Raw
+
+	err = cur.All(ctx, &ops)
+	if err != nil {
+		return nil, errors.Wrap(err, "cursor: all")
+	}
+
+	return parseIndexBuildProgress(ops)
+}
+
+// parseIndexBuildProgress converts the $currentOp createIndexes operations
+// into the progress of each index.
+func parseIndexBuildProgress(ops []bson.Raw) ([]IndexBuildProgress, error) {
+	var res []IndexBuildProgress
+
+	for _, raw := range ops {
+		var op struct {
+			NS      string `bson:"ns"`
+			Msg     string `bson:"msg"`
+			Command struct {
+				DB         string `bson:"$db"`
+				Collection string `bson:"createIndexes"`
+				Indexes    []struct {
+					Name string `bson:"name"`
+				} `bson:"indexes"`
+			} `bson:"command"`
+			Progress struct {
+				Done  int64 `bson:"done"`
+				Total int64 `bson:"total"`
+			} `bson:"progress"`
+		}
+
+		err := bson.Unmarshal(raw, &op)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode")
+		}
+
+		ns := op.NS
+		if ns == "" {
+			ns = op.Command.DB + "." + op.Command.Collection
+		}
+
+		for _, index := range op.Command.Indexes {
+			res = append(res, IndexBuildProgress{
+				Namespace: ns,
+				Index:     index.Name,
+				Msg:       op.Msg,
+				Done:      op.Progress.Done,
+				Total:     op.Progress.Total,
+			})
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package topo //nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseIndexBuildProgress(t *testing.T) {
+	t.Parallel()
+
+	// a trimmed $currentOp response of a two-index build and a build without progress yet
+	ops := []bson.D{
+		{
+			{"type", "op"},
+			{"op", "command"},
+			{"ns", "db1.coll1"},
+			{"command", bson.D{
+				{"createIndexes", "coll1"},
+				{"indexes", bson.A{
+					bson.D{{"v", 2}, {"key", bson.D{{"a", 1}}}, {"name", "a_1"}},
+					bson.D{{"v", 2}, {"key", bson.D{{"b", 1}}}, {"name", "b_1"}},
+				}},
+				{"$db", "db1"},
+			}},
+			{"msg", "Index Build: scanning collection Index Build: scanning collection: 250/1000 25%"},
+			{"progress", bson.D{{"done", int64(250)}, {"total", int64(1000)}}},
+		},
+		{
+			{"type", "op"},
+			{"op", "command"},
+			{"command", bson.D{
+				{"createIndexes", "coll2"},
+				{"indexes", bson.A{bson.D{{"key", bson.D{{"c", 1}}}, {"name", "c_1"}}}},
+				{"$db", "db2"},
+			}},
+		},
+	}
+
+	raws := make([]bson.Raw, len(ops))
+	for i, op := range ops {
+		raw, err := bson.Marshal(op)
+		require.NoError(t, err)
+
+		raws[i] = raw
+	}
+
+	progress, err := parseIndexBuildProgress(raws)
+	require.NoError(t, err)
+	require.Len(t, progress, 3)
+
+	assert.Equal(t, "db1.coll1", progress[0].Namespace)
+	assert.Equal(t, "a_1", progress[0].Index)
+	assert.Equal(t, "b_1", progress[1].Index)
+	assert.InDelta(t, 25.0, progress[0].Percent(), 0.001)
+	assert.InDelta(t, 25.0, progress[1].Percent(), 0.001)
+
+	assert.Equal(t, "db2.coll2", progress[2].Namespace)
+	assert.Equal(t, "c_1", progress[2].Index)
+	assert.Zero(t, progress[2].Percent())
+}
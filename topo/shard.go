@@ -0,0 +1,136 @@
+package topo
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// Shard is a shard of a sharded cluster. Returned by [ListShards].
+type Shard struct {
+	// ID is the name of the shard.
+	ID string `bson:"_id"`
+	// Host is the replica set and the hosts of the shard (e.g. "rs1/host1:27017,host2:27017").
+	Host string `bson:"host"`
+}
+
+// ListShards returns the shards of the sharded cluster. m must be connected to mongos.
+func ListShards(ctx context.Context, m *mongo.Client) ([]Shard, error) {
+	var res struct {
+		Shards []Shard `bson:"shards"`
+	}
+
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"listShards", 1}}).Decode(&res)
+	if err != nil {
+		return nil, errors.Wrap(err, "listShards")
+	}
+
+	return res.Shards, nil
+}
+
+// ShardNewestOplogTimestamps returns the timestamp of the newest oplog entry of each shard by
+// the shard name. The shards are connected with the credentials and the options of the mongos
+// connection string uri. The shards must have the user of the connection string.
+func ShardNewestOplogTimestamps(
+	ctx context.Context,
+	m *mongo.Client,
+	uri string,
+) (map[string]bson.Timestamp, error) {
+	shards, err := ListShards(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[string]bson.Timestamp, len(shards))
+
+	for _, shard := range shards {
+		shardURI, err := ShardURI(uri, shard.Host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "shard %q", shard.ID)
+		}
+
+		ts, err := shardNewestOplogTimestamp(ctx, shardURI)
+		if err != nil {
+			return nil, errors.Wrapf(err, "shard %q", shard.ID)
+		}
+
+		rv[shard.ID] = ts
+	}
+
+	return rv, nil
+}
+
+func shardNewestOplogTimestamp(ctx context.Context, uri string) (bson.Timestamp, error) {
+	m, err := Connect(ctx, uri)
+	if err != nil {
+		return bson.Timestamp{}, errors.Wrap(err, "connect")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, m.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect shard: " + err.Error())
+		}
+	}()
+
+	ts, err := NewestOplogTimestamp(ctx, m)
+
+	return ts, errors.Wrap(err, "newest oplog entry")
+}
+
+// ShardURI returns the connection string of the shard with the host of listShards
+// ("rs1/host1:27017,host2:27017"). It has the credentials, the database, and the options of
+// the mongos connection string uri.
+func ShardURI(uri, shardHost string) (string, error) {
+	rs, hosts, ok := strings.Cut(shardHost, "/")
+	if !ok || rs == "" || hosts == "" {
+		return "", errors.Errorf("invalid shard host %q", shardHost)
+	}
+
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return "", errors.New("invalid MongoDB URI")
+	}
+
+	authority, suffix := rest, ""
+	if i := strings.IndexAny(rest, "/?"); i != -1 {
+		authority, suffix = rest[:i], rest[i:]
+	}
+
+	userinfo := ""
+	if i := strings.LastIndex(authority, "@"); i != -1 {
+		userinfo = authority[:i+1]
+	}
+
+	path, query, _ := strings.Cut(suffix, "?")
+	params := []string{"replicaSet=" + url.QueryEscape(rs)}
+	hasTLS := false
+
+	for _, p := range strings.FieldsFunc(query, func(r rune) bool { return r == '&' || r == ';' }) {
+		key, _, _ := strings.Cut(p, "=")
+
+		switch strings.ToLower(key) {
+		case "replicaset", "directconnection", "srvservicename", "srvmaxhosts", "loadbalanced":
+			continue
+		case "tls", "ssl":
+			hasTLS = true
+		}
+
+		params = append(params, p)
+	}
+
+	if scheme == "mongodb+srv" && !hasTLS {
+		params = append(params, "tls=true") // the default of the SRV connection strings
+	}
+
+	return "mongodb://" + userinfo + hosts + "/" + strings.TrimPrefix(path, "/") +
+		"?" + strings.Join(params, "&"), nil
+}
@@ -2,10 +2,16 @@ package topo
 
 import (
 	"context"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
 
 	"github.com/percona/percona-clustersync-mongodb/config"
 	"github.com/percona/percona-clustersync-mongodb/errors"
@@ -20,9 +26,96 @@ const (
 	DefaultMaxRetries = 3
 )
 
+// retryCount is the number of retries of transient errors since the process start.
+var retryCount atomic.Int64 //nolint:gochecknoglobals
+
+// RetryCount returns the number of retries of transient errors since the process start.
+func RetryCount() int64 {
+	return retryCount.Load()
+}
+
+// RetryJitter is the strategy of randomizing the retry backoff delay. The jitter spreads
+// the retries of many concurrent operations failed at the same time (e.g. on a primary stepdown).
+type RetryJitter string
+
+const (
+	// RetryJitterNone uses the exact exponential backoff delay.
+	RetryJitterNone RetryJitter = "none"
+	// RetryJitterFull uses a random delay between zero and the backoff delay.
+	RetryJitterFull RetryJitter = "full"
+	// RetryJitterEqual uses a half of the backoff delay plus a random delay up to the other half.
+	RetryJitterEqual RetryJitter = "equal"
+)
+
+// ParseRetryJitter parses the retry jitter strategy. The empty string is [RetryJitterNone].
+func ParseRetryJitter(s string) (RetryJitter, error) {
+	switch j := RetryJitter(s); j {
+	case "":
+		return RetryJitterNone, nil
+	case RetryJitterNone, RetryJitterFull, RetryJitterEqual:
+		return j, nil
+	}
+
+	return "", errors.Errorf("invalid retry jitter %q", s)
+}
+
+// retryJitter is the jitter strategy of [RunWithRetry].
+var retryJitter atomic.Value //nolint:gochecknoglobals
+
+// SetRetryJitter sets the jitter strategy of the retry backoff for the process.
+func SetRetryJitter(j RetryJitter) {
+	retryJitter.Store(j)
+}
+
+func currentRetryJitter() RetryJitter {
+	j, _ := retryJitter.Load().(RetryJitter)
+
+	return j
+}
+
+// retryDelay returns the delay before the next retry for the backoff interval.
+func retryDelay(interval time.Duration, jitter RetryJitter) time.Duration {
+	switch jitter {
+	case RetryJitterFull:
+		return time.Duration(rand.Int64N(int64(interval) + 1)) //nolint:gosec
+	case RetryJitterEqual:
+		half := interval / 2 //nolint:mnd
+
+		return half + time.Duration(rand.Int64N(int64(interval-half)+1)) //nolint:gosec
+	case RetryJitterNone, "":
+	}
+
+	return interval
+}
+
 // errMissingClusterTime is returned when the cluster time is missing.
 var errMissingClusterTime = errors.New("missig clusterTime")
 
+// ParseTimestamp parses an oplog timestamp in the "<seconds>.<increment>" form of the status
+// (e.g. "1700000000.3"), the "<seconds>,<increment>" form of the Percona Backup for MongoDB
+// restore points, or as "<seconds>" with the zero increment.
+func ParseTimestamp(s string) (bson.Timestamp, error) {
+	secs, inc, found := strings.Cut(s, ".")
+	if !found {
+		secs, inc, found = strings.Cut(s, ",")
+	}
+
+	t, err := strconv.ParseUint(secs, 10, 32)
+	if err != nil || t == 0 {
+		return bson.Timestamp{}, errors.Errorf("invalid timestamp %q", s)
+	}
+
+	var i uint64
+	if found {
+		i, err = strconv.ParseUint(inc, 10, 32)
+		if err != nil {
+			return bson.Timestamp{}, errors.Errorf("invalid timestamp %q: increment", s)
+		}
+	}
+
+	return bson.Timestamp{T: uint32(t), I: uint32(i)}, nil
+}
+
 // ClusterTime retrieves the cluster time from the MongoDB client.
 func ClusterTime(ctx context.Context, m *mongo.Client) (bson.Timestamp, error) {
 	raw, err := m.Database("admin").RunCommand(ctx, bson.D{{"ping", 1}}).Raw()
@@ -56,6 +149,51 @@ func AdvanceClusterTime(ctx context.Context, m *mongo.Client) (bson.Timestamp, e
 	return bson.Timestamp{T: t, I: i}, nil
 }
 
+// OplogWindow returns the time span between the oldest and the newest entries in the oplog.
+func OplogWindow(ctx context.Context, m *mongo.Client) (time.Duration, error) {
+	first, err := oplogEntryTimestamp(ctx, m, 1)
+	if err != nil {
+		return 0, errors.Wrap(err, "first oplog entry")
+	}
+
+	last, err := oplogEntryTimestamp(ctx, m, -1)
+	if err != nil {
+		return 0, errors.Wrap(err, "last oplog entry")
+	}
+
+	return time.Duration(int64(last.T)-int64(first.T)) * time.Second, nil
+}
+
+// OldestOplogTimestamp returns the timestamp of the oldest entry in the oplog.
+func OldestOplogTimestamp(ctx context.Context, m *mongo.Client) (bson.Timestamp, error) {
+	return oplogEntryTimestamp(ctx, m, 1)
+}
+
+// NewestOplogTimestamp returns the timestamp of the newest entry in the oplog.
+func NewestOplogTimestamp(ctx context.Context, m *mongo.Client) (bson.Timestamp, error) {
+	return oplogEntryTimestamp(ctx, m, -1)
+}
+
+// oplogEntryTimestamp returns the timestamp of the first oplog entry in the natural order.
+// The order is 1 for the oldest entry and -1 for the newest entry.
+func oplogEntryTimestamp(ctx context.Context, m *mongo.Client, order int) (bson.Timestamp, error) {
+	opts := options.Database().SetReadConcern(readconcern.Local())
+	coll := m.Database("local", opts).Collection("oplog.rs")
+
+	raw, err := coll.FindOne(ctx, bson.D{},
+		options.FindOne().SetSort(bson.D{{"$natural", order}}).SetProjection(bson.D{{"ts", 1}})).Raw()
+	if err != nil {
+		return bson.Timestamp{}, err //nolint:wrapcheck
+	}
+
+	t, i, ok := raw.Lookup("ts").TimestampOK()
+	if !ok {
+		return bson.Timestamp{}, errors.New("missing ts")
+	}
+
+	return bson.Timestamp{T: t, I: i}, nil
+}
+
 // Hello represents the result of the db.hello() command. Returns by [SayHello].
 type Hello struct {
 	// IsWritablePrimary indicates if the node is writable primary.
@@ -98,6 +236,25 @@ type Hello struct {
 	Tags bson.M `bson:"tags"`
 	// Me is the address of the node.
 	Me string `bson:"me"`
+	// Msg is "isdbgrid" if the server is a mongos.
+	Msg string `bson:"msg"`
+}
+
+// ReplSetMember is a member of the replica set configuration.
+type ReplSetMember struct {
+	// Host is the address of the member.
+	Host string `bson:"host"`
+	// Hidden indicates if the member is hidden.
+	Hidden bool `bson:"hidden"`
+	// SecondaryDelaySecs is the replication delay of the member (MongoDB 5.0 and later).
+	SecondaryDelaySecs int64 `bson:"secondaryDelaySecs"`
+	// SlaveDelay is the replication delay of the member (before MongoDB 5.0).
+	SlaveDelay int64 `bson:"slaveDelay"`
+}
+
+// Delay returns the configured replication delay of the member.
+func (m ReplSetMember) Delay() time.Duration {
+	return time.Duration(max(m.SecondaryDelaySecs, m.SlaveDelay)) * time.Second
 }
 
 // DBStats represents the result of the [GetDBStats].
@@ -134,6 +291,58 @@ type CollStats struct {
 	AvgObjSize int64 `bson:"avgObjSize"`
 }
 
+// DocSample represents the result of the [SampleDocSize].
+type DocSample struct {
+	// Count is the number of the sampled documents.
+	Count int64 `bson:"count"`
+	// AvgSize is the average BSON size of the sampled documents.
+	AvgSize float64 `bson:"avgSize"`
+}
+
+// SampleDocSize returns the average BSON size of a random sample of up to size documents
+// of the collection. The count of the sample is zero for an empty collection.
+func SampleDocSize(ctx context.Context, m *mongo.Client, db, coll string, size int) (*DocSample, error) {
+	cur, err := m.Database(db).Collection(coll).Aggregate(ctx, mongo.Pipeline{
+		{{"$sample", bson.D{{"size", size}}}},
+		{{"$group", bson.D{
+			{"_id", nil},
+			{"count", bson.D{{"$sum", 1}}},
+			{"avgSize", bson.D{{"$avg", bson.D{{"$bsonSize", "$$ROOT"}}}}},
+		}}},
+	})
+	if err != nil {
+		if IsNamespaceNotFound(err) {
+			err = ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "$sample")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(context.Background(), config.CloseCursorTimeout, cur.Close)
+		if err != nil {
+			log.Ctx(ctx).Errorf(err, "$sample: %s: close cursor", db)
+		}
+	}()
+
+	sample := &DocSample{}
+	if !cur.Next(ctx) {
+		err = cur.Err()
+		if err != nil {
+			return nil, errors.Wrap(err, "$sample: cursor")
+		}
+
+		return sample, nil
+	}
+
+	err = cur.Decode(sample)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode")
+	}
+
+	return sample, nil
+}
+
 // SayHello runs the db.hello() command and returns the [Hello].
 func SayHello(ctx context.Context, m *mongo.Client) (*Hello, error) {
 	var result *Hello
@@ -143,6 +352,19 @@ func SayHello(ctx context.Context, m *mongo.Client) (*Hello, error) {
 	return result, err //nolint:wrapcheck
 }
 
+// GetReplSetMembers runs the replSetGetConfig command and returns the members of the replica set.
+func GetReplSetMembers(ctx context.Context, m *mongo.Client) ([]ReplSetMember, error) {
+	var result struct {
+		Config struct {
+			Members []ReplSetMember `bson:"members"`
+		} `bson:"config"`
+	}
+
+	err := m.Database("admin").RunCommand(ctx, bson.D{{"replSetGetConfig", 1}}).Decode(&result)
+
+	return result.Config.Members, err //nolint:wrapcheck
+}
+
 // GetDBStats runs the dbStats command.
 func GetDBStats(ctx context.Context, m *mongo.Client, dbName string) (*DBStats, error) {
 	var result *DBStats
@@ -198,6 +420,7 @@ func GetCollStats(ctx context.Context, m *mongo.Client, db, coll string) (*CollS
 // RunWithRetry executes the provided function with retry logic for transient errors.
 // It retries the function up to maxRetries times,
 // with an exponential backoff starting from retryInterval.
+// The delay is randomized according to the jitter set by [SetRetryJitter].
 func RunWithRetry(
 	ctx context.Context,
 	fn func(context.Context) error,
@@ -211,6 +434,7 @@ func RunWithRetry(
 	var err error
 
 	currentInterval := retryInterval
+	jitter := currentRetryJitter()
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		err = fn(ctx)
@@ -222,10 +446,14 @@ func RunWithRetry(
 			return err //nolint:wrapcheck
 		}
 
+		delay := retryDelay(currentInterval, jitter)
+
 		log.Ctx(ctx).Warnf("Transient write error: %v, retry attempt %d retrying in %s",
-			err, attempt, currentInterval)
+			err, attempt, delay)
+
+		retryCount.Add(1)
 
-		time.Sleep(currentInterval)
+		time.Sleep(delay)
 		currentInterval *= 2
 	}
 
@@ -3,9 +3,11 @@ package topo //nolint:testpackage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
@@ -94,3 +96,155 @@ func TestRunWithRetry_SuccessOnRetry(t *testing.T) {
 		t.Errorf("expected fn to be called 2 times, got %d", calls)
 	}
 }
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	const interval = 100 * time.Millisecond
+
+	if got := retryDelay(interval, RetryJitterNone); got != interval {
+		t.Errorf("none: got = %s, want %s", got, interval)
+	}
+
+	tests := []struct {
+		jitter RetryJitter
+		min    time.Duration
+	}{
+		{RetryJitterFull, 0},
+		{RetryJitterEqual, interval / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.jitter), func(t *testing.T) {
+			t.Parallel()
+
+			seen := make(map[time.Duration]bool)
+
+			for range 1000 {
+				got := retryDelay(interval, tt.jitter)
+				if got < tt.min || got > interval {
+					t.Fatalf("got = %s, want between %s and %s", got, tt.min, interval)
+				}
+
+				seen[got] = true
+			}
+
+			if len(seen) < 2 {
+				t.Errorf("got the same delay on each call: %v", seen)
+			}
+		})
+	}
+}
+
+func TestParseRetryJitter(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]RetryJitter{
+		"":      RetryJitterNone,
+		"none":  RetryJitterNone,
+		"full":  RetryJitterFull,
+		"equal": RetryJitterEqual,
+	} {
+		got, err := ParseRetryJitter(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %q, %v, want %q", s, got, err, want)
+		}
+	}
+
+	_, err := ParseRetryJitter("decorrelated")
+	if err == nil {
+		t.Error("got = nil, want error")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]bson.Timestamp{
+		"1700000000.5": {T: 1700000000, I: 5},
+		"1700000000,5": {T: 1700000000, I: 5},
+		"1700000000":   {T: 1700000000},
+	} {
+		got, err := ParseTimestamp(s)
+		if err != nil || got != want {
+			t.Errorf("%q: got = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "0", "abc", "1700000000.", "1700000000.x", "99999999999.1"} {
+		_, err := ParseTimestamp(s)
+		if err == nil {
+			t.Errorf("%q: got = nil, want error", s)
+		}
+	}
+}
+
+func TestRunWithRetry_SuccessAfterFailover(t *testing.T) {
+	t.Parallel()
+
+	codes := map[string]int{
+		"PrimarySteppedDown":              189,
+		"NotWritablePrimary":              10107,
+		"InterruptedDueToReplStateChange": 11602,
+	}
+
+	for name, code := range codes {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+
+			// the old primary rejects the writes until the new primary is elected
+			fn := func(_ context.Context) error {
+				calls++
+				if calls < 3 {
+					return fmt.Errorf("bulk write: %w", &mongo.ClientBulkWriteException{
+						WriteError: &mongo.WriteError{Code: code, Message: name},
+					})
+				}
+
+				return nil
+			}
+
+			err := RunWithRetry(t.Context(), fn, 1*time.Millisecond, 5)
+			if err != nil {
+				t.Errorf("expected nil error, got %v", err)
+			}
+
+			if calls != 3 {
+				t.Errorf("expected fn to be called 3 times, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestReplSetMemberDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		member bson.D
+		want   time.Duration
+	}{
+		{bson.D{{"host", "rs0:27017"}}, 0},
+		{bson.D{{"host", "rs1:27017"}, {"secondaryDelaySecs", int64(600)}}, 10 * time.Minute},
+		{bson.D{{"host", "rs2:27017"}, {"slaveDelay", 60}}, time.Minute},
+	}
+
+	for _, test := range tests {
+		data, err := bson.Marshal(test.member)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var m ReplSetMember
+
+		err = bson.Unmarshal(data, &m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := m.Delay(); got != test.want {
+			t.Errorf("%s: got = %s, want %s", m.Host, got, test.want)
+		}
+	}
+}
@@ -110,3 +110,48 @@ type Support ServerVersion
 func (s Support) ClientBulkWrite() bool {
 	return ServerVersion(s).Major() >= 8 //nolint:mnd
 }
+
+// FsyncLockThroughMongos reports whether mongos locks all shards with fsyncLock
+// (7.1+, 7.0.2+ and 6.0.11+).
+func (s Support) FsyncLockThroughMongos() bool {
+	v := ServerVersion(s)
+
+	switch {
+	case v.Major() != 6 && v.Major() != 7: //nolint:mnd
+		return v.Major() > 7 //nolint:mnd
+	case v.Major() == 7 && v.Minor() == 0: //nolint:mnd
+		return v.Patch() >= 2 //nolint:mnd
+	case v.Major() == 6 && v.Minor() == 0: //nolint:mnd
+		return v.Patch() >= 11 //nolint:mnd
+	}
+
+	return v.Major() == 7 //nolint:mnd
+}
+
+// FeatureCompatibilityVersion returns the featureCompatibilityVersion of the cluster (e.g. "7.0").
+// On mongos, it is read from the admin.system.version collection of the config servers.
+func FeatureCompatibilityVersion(ctx context.Context, m *mongo.Client) (string, error) {
+	var res struct {
+		FCV struct {
+			Version string `bson:"version"`
+		} `bson:"featureCompatibilityVersion"`
+	}
+
+	err := m.Database("admin").RunCommand(ctx,
+		bson.D{{"getParameter", 1}, {"featureCompatibilityVersion", 1}}).Decode(&res)
+	if err == nil {
+		return res.FCV.Version, nil
+	}
+
+	var doc struct {
+		Version string `bson:"version"`
+	}
+
+	findErr := m.Database("admin").Collection("system.version").
+		FindOne(ctx, bson.D{{"_id", "featureCompatibilityVersion"}}).Decode(&doc)
+	if findErr != nil {
+		return "", errors.Wrap(errors.Join(err, findErr), "featureCompatibilityVersion")
+	}
+
+	return doc.Version, nil
+}
@@ -115,3 +115,20 @@ func assertAs(t *testing.T, expected any) func(any, error) {
 		require.ErrorAs(t, err, expected)
 	}
 }
+
+func TestSupportFsyncLockThroughMongos(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]bool{
+		"6.0.10": false,
+		"6.0.11": true,
+		"7.0.1":  false,
+		"7.0.2":  true,
+		"7.1.0":  true,
+		"8.0.4":  true,
+	} {
+		version, err := parseServerVersion(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, Support(version).FsyncLockThroughMongos(), s)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/percona/percona-clustersync-mongodb/config"
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/log"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+	"github.com/percona/percona-clustersync-mongodb/topo"
+	"github.com/percona/percona-clustersync-mongodb/util"
+)
+
+// errVerifyMismatch indicates that the verify command has found documents that differ.
+var errVerifyMismatch = errors.New("target documents differ from the source")
+
+// verifyOptions are the options of the verify command.
+type verifyOptions struct {
+	sourceURI string
+	targetURI string
+
+	include []string
+	exclude []string
+
+	// checkpointFile is the file of the verified ranges. An empty value disables the resume.
+	checkpointFile string
+
+	pcsm.VerifyOptions
+}
+
+// runVerify verifies the target documents and prints the report. With the checkpoint file,
+// the verification resumes after the ranges verified by the previous run.
+func runVerify(ctx context.Context, w io.Writer, opts verifyOptions) error {
+	source, err := topo.Connect(ctx, opts.sourceURI)
+	if err != nil {
+		return errors.Wrap(err, "connect to source")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, source.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect source: " + err.Error())
+		}
+	}()
+
+	target, err := topo.Connect(ctx, opts.targetURI)
+	if err != nil {
+		return errors.Wrap(err, "connect to target")
+	}
+
+	defer func() {
+		err := util.CtxWithTimeout(ctx, config.DisconnectTimeout, target.Disconnect)
+		if err != nil {
+			log.Ctx(ctx).Warn("Disconnect target: " + err.Error())
+		}
+	}()
+
+	namespaces, err := pcsm.ListVerifyNamespaces(ctx, source, opts.include, opts.exclude)
+	if err != nil {
+		return errors.Wrap(err, "list source namespaces")
+	}
+
+	v := pcsm.NewVerifier(source, target, opts.VerifyOptions)
+	checkpoint := func(context.Context) error { return nil }
+
+	if opts.checkpointFile != "" {
+		store := newFileStateStore(opts.checkpointFile)
+
+		err = Restore(ctx, store, v)
+		if err != nil {
+			return errors.Wrap(err, "restore")
+		}
+
+		checkpoint = func(ctx context.Context) error { return DoCheckpoint(ctx, store, v) }
+	}
+
+	startedAt := time.Now()
+
+	err = v.Verify(ctx, namespaces, checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "verify")
+	}
+
+	log.Ctx(ctx).Infof("Verification completed in %s", time.Since(startedAt).Round(time.Second))
+
+	return writeVerifyReport(w, namespaces, v.Results())
+}
+
+// writeVerifyReport prints the verified namespaces and their mismatched ranges.
+// It returns [errVerifyMismatch] if there is a mismatch.
+func writeVerifyReport(w io.Writer, namespaces []pcsm.Namespace, results map[string]pcsm.NamespaceVerification) error {
+	var mismatches int
+
+	for _, ns := range namespaces {
+		res := results[ns.String()]
+		fmt.Fprintf(w, "%s: %d documents, %d mismatched ranges\n", ns, res.Documents, len(res.Mismatches))
+
+		for _, m := range res.Mismatches {
+			after, last := "start", "end"
+			if len(m.After) != 0 {
+				after = m.After.String()
+			}
+			if len(m.Last) != 0 {
+				last = m.Last.String()
+			}
+
+			fmt.Fprintf(w, "  mismatch: after %s to %s\n", after, last)
+		}
+
+		mismatches += len(res.Mismatches)
+	}
+
+	fmt.Fprintf(w, "%d namespaces\n", len(namespaces))
+
+	if mismatches != 0 {
+		return errors.Wrapf(errVerifyMismatch, "%d mismatched ranges", mismatches)
+	}
+
+	return nil
+}
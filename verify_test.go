@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/percona/percona-clustersync-mongodb/errors"
+	"github.com/percona/percona-clustersync-mongodb/pcsm"
+)
+
+func TestWriteVerifyReport(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []pcsm.Namespace{{"db1", "coll1"}, {"db1", "coll2"}}
+
+	var buf bytes.Buffer
+
+	err := writeVerifyReport(&buf, namespaces, map[string]pcsm.NamespaceVerification{
+		"db1.coll1": {Documents: 10, Done: true},
+		"db1.coll2": {Documents: 5, Done: true},
+	})
+	if err != nil {
+		t.Errorf("got = %v, want nil", err)
+	}
+
+	want := "db1.coll1: 10 documents, 0 mismatched ranges\ndb1.coll2: 5 documents, 0 mismatched ranges\n2 namespaces\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+
+	buf.Reset()
+
+	after, err := bson.Marshal(bson.D{{"_id", 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeVerifyReport(&buf, namespaces[:1], map[string]pcsm.NamespaceVerification{
+		"db1.coll1": {Documents: 10, Done: true, Mismatches: []pcsm.VerifyMismatch{{After: after}}},
+	})
+	if !errors.Is(err, errVerifyMismatch) {
+		t.Errorf("got = %v, want %v", err, errVerifyMismatch)
+	}
+
+	want = "db1.coll1: 10 documents, 1 mismatched ranges\n  mismatch: after {\"_id\": {\"$numberInt\":\"3\"}} to end\n" +
+		"1 namespaces\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+}